@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/pkg/ringbuffer"
+)
+
+// runClip extracts a clip from an existing buffer directory without running
+// the agent
+func runClip(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("clip", flag.ExitOnError)
+	dir := fs.String("buffer", "", "Channel buffer directory (holds index.json)")
+	start := fs.String("start", "", "Clip start: RFC 3339 time or Unix milliseconds")
+	end := fs.String("end", "", "Clip end: RFC 3339 time or Unix milliseconds")
+	last := fs.Duration("last", 0, "Clip the last N of the buffer instead of -start/-end, e.g. 30s")
+	output := fs.String("o", "", "Output file (default: <buffer>/clips/<name>.mp4)")
+	info := fs.Bool("info", false, "Print the time range held in the buffer and exit")
+	rate := fs.Float64("rate", 0, "Slow-motion playback rate, e.g. 0.5")
+	interpolate := fs.Bool("interpolate", false, "Interpolate frames for smoother slow motion (with -rate)")
+	mute := fs.Bool("mute", false, "Drop the clip's audio")
+	audioTrack := fs.String("audio", "", "Use this audio track instead of the one muxed with video")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: capture clip -buffer DIR (-start T -end T | -last D) [-rate R [-interpolate]] [-o out.mp4]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *dir == "" {
+		fs.Usage()
+		return 2
+	}
+
+	ff, err := ffmpeg.New()
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+	buf, err := ringbuffer.Open(*dir, ff)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+	status := buf.GetStatus()
+	oldest, newest := time.UnixMilli(status.OldestTime), time.UnixMilli(status.NewestTime)
+	if seg, ok := buf.GetSegment(status.LastSeq); ok {
+		newest = seg.StartTime.Add(seg.Duration)
+	}
+
+	if *info {
+		fmt.Fprintf(out, "channel:  %s\nsegments: %d (seq %d-%d)\nfrom:     %s\nto:       %s\n",
+			status.ChannelID, status.SegmentCount, status.FirstSeq, status.LastSeq,
+			oldest.Format(time.RFC3339), newest.Format(time.RFC3339))
+		return 0
+	}
+
+	var from, to time.Time
+	switch {
+	case *last > 0:
+		from, to = newest.Add(-*last), newest
+	case *start != "" && *end != "":
+		if from, err = parseClipTime(*start); err == nil {
+			to, err = parseClipTime(*end)
+		}
+		if err != nil {
+			fmt.Fprintln(out, "Error:", err)
+			return 2
+		}
+	default:
+		fs.Usage()
+		return 2
+	}
+	if !to.After(from) {
+		fmt.Fprintln(out, "Error: clip end must be after start")
+		return 2
+	}
+
+	name := fmt.Sprintf("clip-%s", from.UTC().Format("20060102-150405"))
+	opts := ringbuffer.ClipOptions{Rate: *rate, Interpolate: *interpolate, Mute: *mute}
+	if *audioTrack != "" {
+		opts.Audio = []ringbuffer.ClipAudioTrack{{Track: *audioTrack}}
+	}
+	result, err := buf.GenerateClip(context.Background(), from.UnixMilli(), to.UnixMilli(), name, opts)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	path := result.FilePath
+	if *output != "" {
+		if err := os.Rename(path, *output); err != nil {
+			fmt.Fprintln(out, "Error:", err)
+			return 1
+		}
+		path = *output
+	}
+	fmt.Fprintf(out, "%s (%.1fs, %d segments, %d bytes)\n", path, result.Duration, result.SegmentCount, result.FileSizeBytes)
+	return 0
+}
+
+// parseClipTime parses an RFC 3339 time or Unix milliseconds
+func parseClipTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use RFC 3339 or Unix milliseconds)", s)
+	}
+	return t, nil
+}
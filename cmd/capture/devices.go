@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/pkg/decklink"
+	"github.com/video-system/go-video-capture/pkg/ndi"
+	"github.com/video-system/go-video-capture/pkg/screen"
+)
+
+// runDevices lists capture devices, DeckLink cards, screens and windows,
+// and NDI sources
+func runDevices(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("devices", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	ndiTimeout := fs.Duration("ndi-timeout", 3*time.Second, "How long to search for NDI sources")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ff, err := ffmpeg.New()
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+	devices, err := ff.InputDevices(ctx)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	if decklink.IsAvailable() {
+		names, err := decklink.Devices()
+		if err != nil {
+			fmt.Fprintln(out, "Warning: DeckLink discovery failed:", err)
+		}
+		for _, name := range names {
+			d := ffmpeg.InputDevice{Format: "decklink", Kind: "video", ID: name, Name: name}
+			if !slices.Contains(devices, d) { // Also listed by FFmpeg builds with decklink
+				devices = append(devices, d)
+			}
+		}
+	}
+
+	if screen.IsAvailable() {
+		displays, windows, err := screen.Content()
+		if err != nil {
+			fmt.Fprintln(out, "Warning: screen discovery failed:", err)
+		}
+		for i, d := range displays {
+			name := d.Name
+			if name == "" {
+				name = fmt.Sprintf("Display %d", d.ID)
+			}
+			name += fmt.Sprintf(" (%dx%d)", d.PixelWidth, d.PixelHeight)
+			devices = append(devices, ffmpeg.InputDevice{Format: "screen", Kind: "display", ID: strconv.Itoa(i), Name: name})
+		}
+		for _, w := range windows {
+			devices = append(devices, ffmpeg.InputDevice{Format: "screen", Kind: "window", ID: w.Title, Name: w.App})
+		}
+	}
+
+	if ndi.IsAvailable() {
+		ndiCtx, ndiCancel := context.WithTimeout(ctx, *ndiTimeout)
+		sources, err := ndi.DiscoverSources(ndiCtx, nil)
+		ndiCancel()
+		if err != nil {
+			fmt.Fprintln(out, "Warning: NDI discovery failed:", err)
+		}
+		for _, s := range sources {
+			devices = append(devices, ffmpeg.InputDevice{Format: "ndi", Kind: "video", ID: s.Name, Name: s.Name})
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if devices == nil {
+			devices = []ffmpeg.InputDevice{}
+		}
+		enc.Encode(devices)
+		return 0
+	}
+
+	if len(devices) == 0 {
+		fmt.Fprintln(out, "No devices found")
+		return 0
+	}
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FORMAT\tKIND\tDEVICE\tNAME")
+	for _, d := range devices {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Format, d.Kind, d.ID, d.Name)
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/diskio"
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/pkg/capture"
+	"github.com/video-system/go-video-capture/pkg/ndi"
+)
+
+// doctorBenchmarkSize is the test file written by the disk speed check
+const doctorBenchmarkSize = 64 << 20
+
+// maxClockSkew is the largest difference from the platform clock that passes
+const maxClockSkew = 2 * time.Second
+
+// checkResult is one line of the doctor report
+type checkResult struct {
+	name   string
+	status string // PASS, WARN, FAIL, SKIP
+	detail string
+}
+
+// doctor collects check results
+type doctor struct {
+	cfg     *capture.Config
+	results []checkResult
+}
+
+func (d *doctor) add(name, status, format string, args ...interface{}) {
+	d.results = append(d.results, checkResult{name, status, fmt.Sprintf(format, args...)})
+}
+
+// runDoctor checks the host can run capture and prints a pass/fail report.
+// It returns the process exit code: 1 if any check failed.
+func runDoctor(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to config file")
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	d := &doctor{}
+	cfg, err := capture.LoadConfig(*configPath)
+	if err != nil {
+		d.add("config", "FAIL", "%v", err)
+	} else {
+		d.add("config", "PASS", "%s", *configPath)
+		d.cfg = cfg
+	}
+
+	d.checkFFmpeg(ctx)
+	d.checkNDI()
+	d.checkDisk()
+	d.checkPort()
+	d.checkClock(ctx)
+	d.checkPlatform(ctx)
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	failed := false
+	for _, r := range d.results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.status, r.name, r.detail)
+		failed = failed || r.status == "FAIL"
+	}
+	tw.Flush()
+
+	if failed {
+		fmt.Fprintln(out, "\nSome checks failed")
+		return 1
+	}
+	fmt.Fprintln(out, "\nAll checks passed")
+	return 0
+}
+
+// channels returns the configured channels, including single-channel mode
+func (d *doctor) channels() []capture.ChannelConfig {
+	if d.cfg == nil {
+		return nil
+	}
+	if len(d.cfg.Channels) > 0 {
+		return d.cfg.Channels
+	}
+	return []capture.ChannelConfig{{
+		ID:     d.cfg.Session.ChannelID,
+		Input:  d.cfg.Input,
+		Encode: d.cfg.Encode,
+	}}
+}
+
+// checkFFmpeg checks FFmpeg is installed and has the configured encoders
+func (d *doctor) checkFFmpeg(ctx context.Context) {
+	ff, err := ffmpeg.New()
+	if err != nil {
+		d.add("ffmpeg", "FAIL", "%v", err)
+		return
+	}
+	v, err := ff.Version(ctx)
+	if err != nil {
+		d.add("ffmpeg", "FAIL", "run ffmpeg: %v", err)
+		return
+	}
+	d.add("ffmpeg", "PASS", "%s", v)
+
+	codecs, hardware := ff.SupportedCodecs(ctx)
+	hw := "none"
+	if len(hardware) > 0 {
+		hw = strings.Join(hardware, ", ")
+	}
+	d.add("encoders", "PASS", "codecs: %s; hardware: %s", strings.Join(codecs, ", "), hw)
+
+	for _, ch := range d.channels() {
+		if ch.Input.Type == "ndi" {
+			continue // Native NDI capture uses its own encoder
+		}
+		name, err := ffmpeg.EncoderName(ch.Encode.Type, ch.Encode.Codec)
+		if err != nil {
+			d.add("encoder "+ch.ID, "FAIL", "%v", err)
+			continue
+		}
+		if !ff.HasEncoder(ctx, name) {
+			d.add("encoder "+ch.ID, "FAIL", "%s not available in this FFmpeg build", name)
+			continue
+		}
+		if ch.Encode.Type == "vaapi" || ch.Encode.Type == "qsv" {
+			if err := ffmpeg.CheckRenderDevice(ch.Encode.Device); err != nil {
+				d.add("encoder "+ch.ID, "FAIL", "%s: %v", name, err)
+				continue
+			}
+			d.add("encoder "+ch.ID, "PASS", "%s on %s", name, ffmpeg.RenderDevice(ch.Encode.Device))
+			continue
+		}
+		d.add("encoder "+ch.ID, "PASS", "%s", name)
+	}
+}
+
+// checkNDI checks the NDI runtime, failing only if a channel needs it
+func (d *doctor) checkNDI() {
+	needed := slices.ContainsFunc(d.channels(), func(ch capture.ChannelConfig) bool {
+		return ch.Input.Type == "ndi"
+	})
+	switch {
+	case ndi.IsAvailable():
+		d.add("ndi", "PASS", "%s", ndi.Version())
+	case needed:
+		d.add("ndi", "FAIL", "NDI runtime not available but an ndi input is configured")
+	default:
+		d.add("ndi", "SKIP", "NDI runtime not available (no ndi inputs configured)")
+	}
+}
+
+// checkDisk benchmarks the buffer volume and checks free space
+func (d *doctor) checkDisk() {
+	path := "."
+	if d.cfg != nil && d.cfg.Buffer.Path != "" {
+		path = d.cfg.Buffer.Path
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		d.add("disk", "FAIL", "create %s: %v", path, err)
+		return
+	}
+
+	sample, err := diskio.Benchmark(path, doctorBenchmarkSize)
+	if err != nil {
+		d.add("disk speed", "FAIL", "%v", err)
+	} else {
+		mbps := sample.Throughput / 1e6
+		// Every channel writes at its bitrate; require comfortable headroom
+		var requiredKbps int
+		for _, ch := range d.channels() {
+			requiredKbps += ch.Encode.Bitrate
+		}
+		required := float64(requiredKbps) / 8 / 1000 * 4
+		status := "PASS"
+		if mbps < required {
+			status = "FAIL"
+		}
+		d.add("disk speed", status, "%s: %.0f MB/s write+fsync, need %.1f MB/s", path, mbps, required)
+	}
+
+	free, err := diskio.Free(path)
+	switch {
+	case err != nil:
+		d.add("disk space", "SKIP", "%v", err)
+	case free < 10e9:
+		d.add("disk space", "WARN", "%s: %.1f GB free", path, float64(free)/1e9)
+	default:
+		d.add("disk space", "PASS", "%s: %.1f GB free", path, float64(free)/1e9)
+	}
+}
+
+// checkPort checks the API port is free
+func (d *doctor) checkPort() {
+	host, port := "", 8080
+	if d.cfg != nil {
+		host, port = d.cfg.API.Host, d.cfg.API.Port
+	}
+	addr := net.JoinHostPort(host, fmt.Sprint(port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		d.add("api port", "FAIL", "%s: %v", addr, err)
+		return
+	}
+	ln.Close()
+	d.add("api port", "PASS", "%s available", addr)
+}
+
+// checkClock compares the local clock with the platform's, falling back to
+// the system's NTP status
+func (d *doctor) checkClock(ctx context.Context) {
+	if d.cfg != nil && d.cfg.Platform.Enabled && d.cfg.Platform.URL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, d.cfg.Platform.URL, nil)
+		if err == nil {
+			start := time.Now()
+			resp, err := http.DefaultClient.Do(req)
+			if err == nil {
+				resp.Body.Close()
+				if remote, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+					// Date has one-second resolution; compare against the request midpoint
+					local := start.Add(time.Since(start) / 2)
+					skew := local.Sub(remote).Round(time.Millisecond)
+					status := "PASS"
+					if skew > maxClockSkew || skew < -maxClockSkew {
+						status = "FAIL"
+					}
+					d.add("clock", status, "%s offset from platform", skew)
+					return
+				}
+			}
+		}
+	}
+
+	out, err := exec.CommandContext(ctx, "timedatectl", "show", "-p", "NTPSynchronized", "--value").Output()
+	if err != nil {
+		d.add("clock", "SKIP", "no platform or timedatectl to compare against")
+		return
+	}
+	if strings.TrimSpace(string(out)) == "yes" {
+		d.add("clock", "PASS", "NTP synchronized")
+	} else {
+		d.add("clock", "WARN", "NTP not synchronized")
+	}
+}
+
+// checkPlatform checks the platform is reachable when enabled
+func (d *doctor) checkPlatform(ctx context.Context) {
+	if d.cfg == nil || !d.cfg.Platform.Enabled || d.cfg.Platform.URL == "" {
+		d.add("platform", "SKIP", "platform integration disabled")
+		return
+	}
+	client, err := d.cfg.Platform.NewClient()
+	if err != nil {
+		d.add("platform", "FAIL", "%v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := client.CheckHealth(ctx); err != nil {
+		d.add("platform", "FAIL", "%s: %v", d.cfg.Platform.URL, err)
+		return
+	}
+	d.add("platform", "PASS", "%s reachable", d.cfg.Platform.URL)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/capture"
+)
+
+func TestDoctorChannels(t *testing.T) {
+	single := &capture.Config{}
+	single.Session.ChannelID = "main"
+	single.Input.Type = "ndi"
+
+	multi := &capture.Config{Channels: []capture.ChannelConfig{{ID: "cam1"}, {ID: "cam2"}}}
+
+	tests := []struct {
+		name    string
+		cfg     *capture.Config
+		wantIDs []string
+	}{
+		{"no config", nil, nil},
+		{"single channel", single, []string{"main"}},
+		{"multi channel", multi, []string{"cam1", "cam2"}},
+	}
+	for _, tt := range tests {
+		d := &doctor{cfg: tt.cfg}
+		got := d.channels()
+		if len(got) != len(tt.wantIDs) {
+			t.Errorf("%s: %d channels, want %d", tt.name, len(got), len(tt.wantIDs))
+			continue
+		}
+		for i, ch := range got {
+			if ch.ID != tt.wantIDs[i] {
+				t.Errorf("%s: channel %d = %q, want %q", tt.name, i, ch.ID, tt.wantIDs[i])
+			}
+		}
+	}
+	if got := (&doctor{cfg: single}).channels(); got[0].Input.Type != "ndi" {
+		t.Errorf("single channel input = %q, want ndi", got[0].Input.Type)
+	}
+}
+
+func TestDoctorCheckPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	ln, err = net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(busy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	tests := []struct {
+		name string
+		port int
+		want string
+	}{
+		{"port in use", busy, "FAIL"},
+		{"any free port", 0, "PASS"},
+	}
+	for _, tt := range tests {
+		cfg := &capture.Config{}
+		cfg.API.Host, cfg.API.Port = "127.0.0.1", tt.port
+		d := &doctor{cfg: cfg}
+		d.checkPort()
+		if got := d.results[0].status; got != tt.want {
+			t.Errorf("%s: status = %s, want %s (%s)", tt.name, got, tt.want, d.results[0].detail)
+		}
+	}
+}
+
+func TestDoctorCheckClock(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   string
+	}{
+		{"in sync", 0, "PASS"},
+		{"platform ahead", time.Minute, "FAIL"},
+		{"platform behind", -time.Minute, "FAIL"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Date", time.Now().Add(tt.offset).UTC().Format(http.TimeFormat))
+		}))
+		cfg := &capture.Config{}
+		cfg.Platform.Enabled, cfg.Platform.URL = true, srv.URL
+		d := &doctor{cfg: cfg}
+		d.checkClock(context.Background())
+		srv.Close()
+		if got := d.results[0].status; got != tt.want {
+			t.Errorf("%s: status = %s, want %s (%s)", tt.name, got, tt.want, d.results[0].detail)
+		}
+	}
+}
+
+func TestDoctorCheckPlatformDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *capture.Config
+	}{
+		{"no config", nil},
+		{"disabled", &capture.Config{}},
+	}
+	for _, tt := range tests {
+		d := &doctor{cfg: tt.cfg}
+		d.checkPlatform(context.Background())
+		if got := d.results[0].status; got != "SKIP" {
+			t.Errorf("%s: status = %s, want SKIP", tt.name, got)
+		}
+	}
+}
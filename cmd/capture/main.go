@@ -4,200 +4,119 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"github.com/video-system/go-video-capture/pkg/agent"
 	"github.com/video-system/go-video-capture/pkg/capture"
-	"github.com/video-system/go-video-capture/pkg/ndi"
-	"github.com/video-system/go-video-capture/pkg/platform"
 )
 
 const version = "1.0.0"
 
+var logger = logging.For("main")
+
+// subcommands are field tools that run instead of the agent
+var subcommands = map[string]func(args []string, out io.Writer) int{
+	"doctor":  runDoctor,
+	"devices": runDevices,
+	"probe":   runProbe,
+	"clip":    runClip,
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:], os.Stdout))
+		}
+	}
+
 	configPath := flag.String("config", "config.yaml", "Path to config file")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: capture [-config file]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       capture doctor|devices|probe|clip [flags]")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	// Load configuration
 	cfg, err := capture.LoadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
+	}
+	if err := logging.Setup(logging.Config{
+		Level:   cfg.Log.Level,
+		Format:  cfg.Log.Format,
+		Modules: cfg.Log.Modules,
+		File: logging.FileConfig{
+			Path:        cfg.Log.File.Path,
+			MaxSizeMB:   cfg.Log.File.MaxSizeMB,
+			RotateEvery: cfg.Log.File.RotateEvery,
+			MaxAge:      cfg.Log.File.MaxAge,
+			MaxBackups:  cfg.Log.File.MaxBackups,
+			Compress:    cfg.Log.File.Compress,
+		},
+	}); err != nil {
+		fatal("Invalid log config", err)
 	}
 
-	// Create channel manager
-	manager, err := capture.NewManager(cfg)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Headers:     cfg.Tracing.Headers,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		Version:     version,
+	})
 	if err != nil {
-		log.Fatalf("Failed to create manager: %v", err)
+		fatal("Failed to set up tracing", err)
 	}
 
 	// Setup graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	go func() {
-		<-sigChan
-		log.Println("Shutdown signal received...")
-		cancel()
-	}()
-
-	// Initialize platform client for agent registration
-	var platformClient *platform.Client
-	var agentID string
-	if cfg.Platform.Enabled && cfg.Platform.URL != "" {
-		platformClient = platform.New(platform.Config{
-			URL:    cfg.Platform.URL,
-			APIKey: cfg.Platform.APIKey,
-		})
-
-		// Register agent with platform
-		agentID, err = registerAgent(ctx, platformClient, cfg)
-		if err != nil {
-			log.Printf("Warning: Failed to register with platform: %v", err)
-		} else {
-			log.Printf("Registered with platform as agent: %s", agentID)
-			// Start heartbeat loop
-			go runHeartbeat(ctx, platformClient, agentID, cfg, manager)
-		}
-	}
-
-	// Start all channels
-	if err := manager.Start(ctx); err != nil {
-		log.Fatalf("Failed to start channels: %v", err)
+	a, err := agent.New(cfg, agent.WithVersion(version))
+	if err != nil {
+		fatal("Failed to create agent", err)
 	}
 
-	// Create and start API server
-	apiServer := api.NewServer(api.ServerConfig{
-		Host:    cfg.API.Host,
-		Port:    cfg.API.Port,
-		Manager: manager,
-	})
-
+	// SIGHUP re-reads the config and applies channel changes
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	go func() {
-		if err := apiServer.Start(); err != nil {
-			log.Printf("API server error: %v", err)
+		for range hup {
+			if _, err := a.Manager().ReloadConfig(); err != nil {
+				logger.Warn("Config reload failed", "error", err)
+			}
 		}
 	}()
 
-	// Wait for shutdown
-	manager.Wait()
-
-	// Cleanup
-	manager.Stop()
-	apiServer.Stop()
-
-	log.Println("Capture stopped")
-}
-
-// registerAgent registers this capture agent with the video platform
-func registerAgent(ctx context.Context, client *platform.Client, cfg *capture.Config) (string, error) {
-	hostname, _ := os.Hostname()
-
-	// Generate agent ID if not specified
-	agentID := cfg.Platform.AgentID
-	if agentID == "" {
-		agentID = fmt.Sprintf("agent-%s", hostname)
-	}
-
-	// Generate agent name if not specified
-	agentName := cfg.Platform.AgentName
-	if agentName == "" {
-		agentName = fmt.Sprintf("Capture Agent (%s)", hostname)
-	}
-
-	// Determine API URL for this agent
-	agentURL := fmt.Sprintf("http://%s:%d", hostname, cfg.API.Port)
-	if cfg.API.Host != "" && cfg.API.Host != "0.0.0.0" {
-		agentURL = fmt.Sprintf("http://%s:%d", cfg.API.Host, cfg.API.Port)
-	}
-
-	// Check NDI support dynamically
-	ndiSupported := ndi.CheckSupport(ctx)
-
-	// Build capabilities based on config and system detection
-	capabilities := platform.AgentCapabilities{
-		CanCaptureSRT:   true, // Supported via FFmpeg
-		CanCaptureRTSP:  true,
-		CanCaptureRTMP:  true,
-		CanCaptureNDI:   ndiSupported,
-		CanCaptureUSB:   true,
-		SupportedCodecs: []string{"h264", "hevc"},
-		MaxResolution:   "3840x2160",
-		MaxBitrate:      50000,
-	}
-
-	req := platform.RegisterAgentRequest{
-		ID:           agentID,
-		Name:         agentName,
-		URL:          agentURL,
-		ChannelID:    cfg.Session.ChannelID,
-		Capabilities: capabilities,
-		Version:      version,
-		Hostname:     hostname,
-	}
-
-	agent, err := client.RegisterAgent(ctx, req)
-	if err != nil {
-		return "", err
+	runErr := a.Run(ctx)
+	if runErr != nil {
+		logger.Error("Agent stopped with error", "error", runErr)
 	}
 
-	return agent.ID, nil
-}
-
-// runHeartbeat runs a periodic heartbeat to keep the platform updated
-func runHeartbeat(ctx context.Context, client *platform.Client, agentID string, cfg *capture.Config, manager *capture.Manager) {
-	interval := 10 * time.Second
-	if cfg.Platform.HeartbeatSecs > 0 {
-		interval = time.Duration(cfg.Platform.HeartbeatSecs) * time.Second
+	// Flush spans from clips uploaded during shutdown
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Warn("Failed to flush traces", "error", err)
 	}
+	flushCancel()
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			// Send final offline heartbeat
-			offlineCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			_, _ = client.Heartbeat(offlineCtx, agentID, platform.AgentHeartbeatRequest{
-				Status:    platform.AgentStatusOffline,
-				ChannelID: cfg.Session.ChannelID,
-			})
-			cancel()
-			return
-		case <-ticker.C:
-			// Determine current status based on manager state
-			status := platform.AgentStatusOnline
-			var errorMsg string
-
-			// Check if any channels are recording
-			if manager.IsRecording() {
-				status = platform.AgentStatusRecording
-			}
-
-			// Check for errors
-			if err := manager.GetError(); err != nil {
-				status = platform.AgentStatusError
-				errorMsg = err.Error()
-			}
-
-			req := platform.AgentHeartbeatRequest{
-				Status:       status,
-				SessionID:    cfg.Session.SessionID,
-				ChannelID:    cfg.Session.ChannelID,
-				ErrorMessage: errorMsg,
-			}
-
-			_, err := client.Heartbeat(ctx, agentID, req)
-			if err != nil {
-				log.Printf("Heartbeat failed: %v", err)
-			}
-		}
+	logger.Info("Capture stopped")
+	logging.Close()
+	if runErr != nil {
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+// runProbe prints stream information for a file or URL
+func runProbe(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the full ffprobe result as JSON")
+	timeout := fs.Duration("timeout", 30*time.Second, "Give up after this long (for network sources)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: capture probe [-json] <file|url>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	ff, err := ffmpeg.New()
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+	result, err := ff.Probe(ctx, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		enc.Encode(result)
+		return 0
+	}
+
+	f := result.Format
+	fmt.Fprintf(out, "%s\n  format: %s", f.Filename, f.FormatName)
+	if f.Duration != "" {
+		fmt.Fprintf(out, ", duration: %ss", f.Duration)
+	}
+	if f.BitRate != "" {
+		fmt.Fprintf(out, ", bitrate: %s b/s", f.BitRate)
+	}
+	fmt.Fprintln(out)
+	for _, s := range result.Streams {
+		switch s.CodecType {
+		case "video":
+			fmt.Fprintf(out, "  #%d video: %s %dx%d %s, %s fps\n", s.Index, s.CodecName, s.Width, s.Height, s.PixFmt, s.AvgFrameRate)
+		case "audio":
+			fmt.Fprintf(out, "  #%d audio: %s %s Hz, %d channels\n", s.Index, s.CodecName, s.SampleRate, s.Channels)
+		default:
+			fmt.Fprintf(out, "  #%d %s: %s\n", s.Index, s.CodecType, s.CodecName)
+		}
+	}
+	return 0
+}
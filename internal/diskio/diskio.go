@@ -0,0 +1,106 @@
+// Package diskio measures storage write performance.
+package diskio
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sample is the result of one write benchmark
+type Sample struct {
+	Latency    time.Duration // Time to write and fsync the test file
+	Throughput float64       // Bytes per second
+}
+
+// Benchmark writes size bytes to a temporary file in dir, syncs it to the
+// device and removes it
+func Benchmark(dir string, size int) (Sample, error) {
+	f, err := os.CreateTemp(dir, ".diskio-*")
+	if err != nil {
+		return Sample{}, fmt.Errorf("create test file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// Random-ish data so compressing filesystems can't shortcut the write
+	buf := make([]byte, 1<<20)
+	for i := range buf {
+		buf[i] = byte(i*31 + i>>8)
+	}
+
+	start := time.Now()
+	for written := 0; written < size; written += len(buf) {
+		n := min(len(buf), size-written)
+		if _, err := f.Write(buf[:n]); err != nil {
+			return Sample{}, fmt.Errorf("write test file: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return Sample{}, fmt.Errorf("sync test file: %w", err)
+	}
+	elapsed := time.Since(start)
+
+	return Sample{
+		Latency:    elapsed,
+		Throughput: float64(size) / elapsed.Seconds(),
+	}, nil
+}
+
+// Summary describes the values in a Window
+type Summary struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P95   float64 `json:"p95"`
+	Max   float64 `json:"max"`
+}
+
+// Window keeps the most recent n values of a measurement
+type Window struct {
+	mu     sync.Mutex
+	values []float64
+	next   int
+}
+
+// NewWindow creates a window holding n values
+func NewWindow(n int) *Window {
+	return &Window{values: make([]float64, 0, n)}
+}
+
+// Add records a value, replacing the oldest once the window is full
+func (w *Window) Add(v float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.values) < cap(w.values) {
+		w.values = append(w.values, v)
+		return
+	}
+	w.values[w.next] = v
+	w.next = (w.next + 1) % len(w.values)
+}
+
+// Summary returns the mean, 95th percentile and max of the window
+func (w *Window) Summary() Summary {
+	w.mu.Lock()
+	sorted := append([]float64(nil), w.values...)
+	w.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return Summary{}
+	}
+	sort.Float64s(sorted)
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	p95 := sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+	return Summary{
+		Count: len(sorted),
+		Mean:  sum / float64(len(sorted)),
+		P95:   p95,
+		Max:   sorted[len(sorted)-1],
+	}
+}
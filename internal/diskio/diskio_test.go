@@ -0,0 +1,52 @@
+package diskio
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestBenchmark(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Benchmark(dir, 3<<20+512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Latency <= 0 || s.Throughput <= 0 {
+		t.Errorf("unexpected sample %+v", s)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("test file not removed: %v", entries)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	w := NewWindow(20)
+	for i := 1; i <= 25; i++ {
+		w.Add(float64(i))
+	}
+
+	// Holds 6..25
+	got := w.Summary()
+	want := Summary{Count: 20, Mean: 15.5, P95: 24, Max: 25}
+	if got != want {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+	if (NewWindow(5).Summary() != Summary{}) {
+		t.Error("empty window should have zero summary")
+	}
+}
+
+func TestFree(t *testing.T) {
+	free, err := Free(t.TempDir())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free == 0 {
+		t.Error("expected free space")
+	}
+}
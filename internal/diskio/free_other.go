@@ -0,0 +1,10 @@
+//go:build !unix
+
+package diskio
+
+import "errors"
+
+// Free is not supported on this platform
+func Free(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
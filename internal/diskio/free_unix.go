@@ -0,0 +1,18 @@
+//go:build unix
+
+package diskio
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Free returns the bytes available to unprivileged users on the volume
+// holding path
+func Free(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
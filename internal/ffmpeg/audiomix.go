@@ -0,0 +1,76 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ClipAudioInput is one audio source for RemixClipAudio
+type ClipAudioInput struct {
+	Path   string  // Audio file (empty = the clip's own audio)
+	GainDB float64 // Level adjustment in dB (0 = unchanged)
+}
+
+// RemixClipAudio replaces a clip's audio: muted, swapped for another track,
+// or a mix of several tracks at their own levels. Video is stream copied.
+func (f *FFmpeg) RemixClipAudio(ctx context.Context, inputPath, outputPath string, tracks []ClipAudioInput, mute bool) error {
+	args, err := remixAudioArgs(inputPath, outputPath, tracks, mute)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg audio remix: %w\noutput: %s", err, output)
+	}
+	return nil
+}
+
+// remixAudioArgs builds the FFmpeg arguments for RemixClipAudio
+func remixAudioArgs(inputPath, outputPath string, tracks []ClipAudioInput, mute bool) ([]string, error) {
+	args := []string{"-y", "-i", inputPath}
+	if mute {
+		return append(args, "-map", "0:v", "-c", "copy", "-an", "-movflags", "+faststart", outputPath), nil
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("no audio tracks to mix")
+	}
+
+	// Label each track's audio stream; external tracks are extra inputs
+	labels := make([]string, len(tracks))
+	inputs := 1
+	for i, t := range tracks {
+		if t.Path == "" {
+			labels[i] = "0:a:0"
+			continue
+		}
+		args = append(args, "-i", t.Path)
+		labels[i] = fmt.Sprintf("%d:a:0", inputs)
+		inputs++
+	}
+
+	// A single track at its own level needs no re-encode
+	if len(tracks) == 1 && tracks[0].GainDB == 0 {
+		return append(args,
+			"-map", "0:v", "-map", labels[0],
+			"-c", "copy", "-shortest",
+			"-movflags", "+faststart", outputPath), nil
+	}
+
+	var graph strings.Builder
+	for i, t := range tracks {
+		fmt.Fprintf(&graph, "[%s]volume=%gdB[a%d];", labels[i], t.GainDB, i)
+	}
+	for i := range tracks {
+		fmt.Fprintf(&graph, "[a%d]", i)
+	}
+	fmt.Fprintf(&graph, "amix=inputs=%d:duration=first:normalize=0[aout]", len(tracks))
+
+	return append(args,
+		"-filter_complex", graph.String(),
+		"-map", "0:v", "-map", "[aout]",
+		"-c:v", "copy", "-c:a", "aac",
+		"-shortest",
+		"-movflags", "+faststart", outputPath), nil
+}
@@ -0,0 +1,34 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemixAudioArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tracks []ClipAudioInput
+		mute   bool
+		want   string
+	}{
+		{"mute", nil, true, "-y -i in.mp4 -map 0:v -c copy -an -movflags +faststart out.mp4"},
+		{"select", []ClipAudioInput{{Path: "natural.mp4"}}, false,
+			"-y -i in.mp4 -i natural.mp4 -map 0:v -map 1:a:0 -c copy -shortest -movflags +faststart out.mp4"},
+		{"mix", []ClipAudioInput{{GainDB: -12}, {Path: "comm.mp4"}}, false,
+			"-y -i in.mp4 -i comm.mp4 -filter_complex [0:a:0]volume=-12dB[a0];[1:a:0]volume=0dB[a1];[a0][a1]amix=inputs=2:duration=first:normalize=0[aout] " +
+				"-map 0:v -map [aout] -c:v copy -c:a aac -shortest -movflags +faststart out.mp4"},
+	} {
+		args, err := remixAudioArgs("in.mp4", "out.mp4", tc.tracks, tc.mute)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := strings.Join(args, " "); got != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := remixAudioArgs("in.mp4", "out.mp4", nil, false); err == nil {
+		t.Error("no tracks should fail")
+	}
+}
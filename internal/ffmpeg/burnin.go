@@ -0,0 +1,123 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BurnIn configures text, a clock and a logo burned into the live encode
+type BurnIn struct {
+	Text        string       // drawtext text; escape literal parts with OverlayText (empty = none)
+	Clock       string       // wallclock (HH:MM:SS) or timecode (HH:MM:SS:FF from the time of day at start); empty = none
+	Rate        int          // Frame rate for timecode (default: 30)
+	Style       OverlayStyle // Text placement and font
+	Logo        string       // Watermark image, e.g. a PNG with alpha (empty = none)
+	LogoAt      string       // top-left, top, top-right (default), bottom-left, bottom, bottom-right
+	LogoOpacity float64      // 0-1 (0 = opaque)
+}
+
+// logoPositions maps a position to overlay x and y expressions, with the
+// same margin as text overlays
+var logoPositions = map[string][2]string{
+	"top-left":     {"H/40", "H/40"},
+	"top":          {"(W-w)/2", "H/40"},
+	"top-right":    {"W-w-H/40", "H/40"},
+	"bottom-left":  {"H/40", "H-h-H/40"},
+	"bottom":       {"(W-w)/2", "H-h-H/40"},
+	"bottom-right": {"W-w-H/40", "H-h-H/40"},
+}
+
+// Validate checks the clock, text style and logo placement
+func (b BurnIn) Validate() error {
+	switch b.Clock {
+	case "", "wallclock", "timecode":
+	default:
+		return fmt.Errorf("invalid overlay clock %q (want wallclock or timecode)", b.Clock)
+	}
+	if _, err := OverlayFilter(b.Style, "overlay.txt"); err != nil {
+		return err
+	}
+	if _, ok := logoPositions[b.logoAt()]; !ok {
+		return fmt.Errorf("invalid overlay logo position: %s", b.LogoAt)
+	}
+	if b.LogoOpacity < 0 || b.LogoOpacity > 1 {
+		return fmt.Errorf("invalid overlay logo opacity: %g", b.LogoOpacity)
+	}
+	return nil
+}
+
+// Enabled reports whether anything is burned in
+func (b BurnIn) Enabled() bool {
+	return b.Text != "" || b.Clock != "" || b.Logo != ""
+}
+
+func (b BurnIn) logoAt() string {
+	if b.LogoAt == "" {
+		return "top-right"
+	}
+	return b.LogoAt
+}
+
+// writeText writes the drawtext text to dir and returns the file, or ""
+// when only a logo is burned in
+func (b BurnIn) writeText(dir string) (string, error) {
+	if b.Text == "" && b.Clock == "" {
+		return "", nil
+	}
+	text := b.Text
+	switch {
+	case b.Clock == "wallclock":
+		text = strings.TrimSpace(text + `  %{localtime:%H\:%M\:%S}`)
+	case b.Clock == "timecode" && text != "":
+		text += "  " // drawtext appends the timecode to the text
+	}
+	path := filepath.Join(dir, "overlay.txt")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return "", fmt.Errorf("write overlay text: %w", err)
+	}
+	return path, nil
+}
+
+// burnInGraph builds a -vf filtergraph that runs chain, then draws the logo
+// and the text in textFile (from writeText). A timecode counts from start.
+func burnInGraph(chain []string, b BurnIn, textFile string, start time.Time) (string, error) {
+	var post []string
+	if textFile != "" {
+		text, err := OverlayFilter(b.Style, textFile)
+		if err != nil {
+			return "", err
+		}
+		if b.Clock == "timecode" {
+			rate := b.Rate
+			if rate <= 0 {
+				rate = 30
+			}
+			tc := fmt.Sprintf("%s:%02d", start.Format("15:04:05"), start.Nanosecond()*rate/int(time.Second))
+			text += fmt.Sprintf(":timecode='%s':timecode_rate=%d", escapeFilterValue(tc), rate)
+		}
+		post = append(post, text)
+	}
+
+	if b.Logo == "" {
+		return strings.Join(append(chain, post...), ","), nil
+	}
+
+	// The logo is a second source, so the chain needs labels
+	if len(chain) == 0 {
+		chain = []string{"null"}
+	}
+	logo := fmt.Sprintf("movie='%s'", escapeFilterValue(b.Logo))
+	if b.LogoOpacity > 0 && b.LogoOpacity < 1 {
+		logo += fmt.Sprintf(",format=rgba,colorchannelmixer=aa=%g", b.LogoOpacity)
+	}
+	xy := logoPositions[b.logoAt()]
+	graph := fmt.Sprintf("%s[logo];[in]%s[base];[base][logo]overlay=%s:%s",
+		logo, strings.Join(chain, ","), xy[0], xy[1])
+	for _, f := range post {
+		graph += "," + f
+	}
+	return graph + "[out]", nil
+}
@@ -0,0 +1,47 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBurnInGraph(t *testing.T) {
+	dir := t.TempDir()
+	b := BurnIn{Text: "CAM 1", Clock: "timecode", Rate: 25, Logo: "/assets/logo.png", LogoOpacity: 0.5}
+	textFile, err := b.writeText(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(textFile); string(data) != "CAM 1  " {
+		t.Errorf("text = %q", data)
+	}
+
+	start := time.Date(2026, 1, 2, 10, 15, 30, 400*int(time.Millisecond), time.UTC)
+	graph, err := burnInGraph([]string{"bwdif=mode=send_frame:parity=auto"}, b, textFile, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`movie='/assets/logo.png',format=rgba,colorchannelmixer=aa=0.5[logo]`,
+		`[in]bwdif=mode=send_frame:parity=auto[base]`,
+		`[base][logo]overlay=W-w-H/40:H/40,drawtext=textfile='` + escapeFilterValue(filepath.Join(dir, "overlay.txt")),
+		`timecode='10\:15\:30\:10':timecode_rate=25[out]`,
+	} {
+		if !strings.Contains(graph, want) {
+			t.Errorf("graph missing %s:\n%s", want, graph)
+		}
+	}
+
+	// Without a logo the filters stay a plain chain
+	graph, err = burnInGraph([]string{"scale=1280:720"}, BurnIn{Clock: "wallclock"}, textFile, start)
+	if err != nil || !strings.HasPrefix(graph, "scale=1280:720,drawtext=") || strings.Contains(graph, "[") {
+		t.Errorf("graph = %s, %v", graph, err)
+	}
+
+	if err := (BurnIn{Clock: "sundial"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown clock")
+	}
+}
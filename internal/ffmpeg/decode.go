@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// DecoderConfig configures decoding fragmented MP4 to raw frames
+type DecoderConfig struct {
+	Width     int // Output frame size; the picture is scaled to fit and letterboxed
+	Height    int
+	Framerate int  // Output frame rate
+	Audio     bool // Also decode the first audio track to 48 kHz stereo
+}
+
+// DecoderSampleRate and DecoderChannels are the format of decoded audio
+const (
+	DecoderSampleRate = 48000
+	DecoderChannels   = 2
+)
+
+// Decoder decodes an init segment and media segments written to it into
+// raw UYVY video frames and interleaved float32 audio
+type Decoder struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+
+	Video io.Reader // Frames of FrameSize bytes
+	Audio io.Reader // Interleaved little-endian float32 samples, nil without audio
+}
+
+// StartDecoder starts an FFmpeg process that decodes the fragmented MP4
+// stream written to the decoder
+func (f *FFmpeg) StartDecoder(ctx context.Context, cfg DecoderConfig) (*Decoder, error) {
+	cmd := exec.CommandContext(ctx, f.binaryPath, decodeArgs(cfg)...)
+	d := &Decoder{cmd: cmd}
+	cmd.Stderr = &d.stderr
+
+	var err error
+	if d.stdin, err = cmd.StdinPipe(); err != nil {
+		return nil, fmt.Errorf("ffmpeg stdin: %w", err)
+	}
+	if d.Video, err = cmd.StdoutPipe(); err != nil {
+		return nil, fmt.Errorf("ffmpeg stdout: %w", err)
+	}
+
+	// Audio on fd 3
+	var audioOut *os.File
+	if cfg.Audio {
+		var audioIn *os.File
+		if audioIn, audioOut, err = os.Pipe(); err != nil {
+			return nil, fmt.Errorf("create audio pipe: %w", err)
+		}
+		cmd.ExtraFiles = []*os.File{audioOut}
+		d.Audio = audioIn
+	}
+
+	err = cmd.Start()
+	if audioOut != nil {
+		audioOut.Close() // FFmpeg has its own copy
+	}
+	if err != nil {
+		if r, ok := d.Audio.(*os.File); ok {
+			r.Close()
+		}
+		return nil, fmt.Errorf("start ffmpeg: %w", err)
+	}
+	return d, nil
+}
+
+// decodeArgs builds the FFmpeg arguments for a decoder
+func decodeArgs(cfg DecoderConfig) []string {
+	args := []string{
+		"-loglevel", "error",
+		"-f", "mp4", "-i", "pipe:0",
+		"-map", "0:v:0",
+		"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,fps=%d",
+			cfg.Width, cfg.Height, cfg.Width, cfg.Height, cfg.Framerate),
+		"-pix_fmt", "uyvy422",
+		"-f", "rawvideo", "pipe:1",
+	}
+	if cfg.Audio {
+		args = append(args,
+			"-map", "0:a:0",
+			"-ac", fmt.Sprint(DecoderChannels),
+			"-ar", fmt.Sprint(DecoderSampleRate),
+			"-f", "f32le", "pipe:3",
+		)
+	}
+	return args
+}
+
+// FrameSize returns the size in bytes of a decoded UYVY frame
+func (cfg DecoderConfig) FrameSize() int {
+	return cfg.Width * cfg.Height * 2
+}
+
+// Write feeds the init segment or a media segment to the decoder
+func (d *Decoder) Write(data []byte) (int, error) {
+	return d.stdin.Write(data)
+}
+
+// Close ends the input and waits for FFmpeg to flush and exit
+func (d *Decoder) Close() error {
+	d.stdin.Close()
+	err := d.cmd.Wait()
+	if r, ok := d.Audio.(*os.File); ok {
+		r.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("ffmpeg decode: %w\noutput: %s", err, d.stderr.String())
+	}
+	return nil
+}
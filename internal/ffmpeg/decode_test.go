@@ -0,0 +1,26 @@
+package ffmpeg
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecodeArgs(t *testing.T) {
+	cfg := DecoderConfig{Width: 1280, Height: 720, Framerate: 30}
+	args := decodeArgs(cfg)
+	if !slices.Contains(args, "pipe:1") || slices.Contains(args, "pipe:3") {
+		t.Errorf("video-only args = %v", args)
+	}
+	if vf := args[slices.Index(args, "-vf")+1]; !strings.HasPrefix(vf, "scale=1280:720:") || !strings.HasSuffix(vf, ",fps=30") {
+		t.Errorf("filter = %s", vf)
+	}
+	if cfg.FrameSize() != 1280*720*2 {
+		t.Errorf("frame size = %d", cfg.FrameSize())
+	}
+
+	cfg.Audio = true
+	if args := strings.Join(decodeArgs(cfg), " "); !strings.HasSuffix(args, "-map 0:a:0 -ac 2 -ar 48000 -f f32le pipe:3") {
+		t.Errorf("audio args = %s", args)
+	}
+}
@@ -0,0 +1,105 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// InputDevice is a capture device FFmpeg can open
+type InputDevice struct {
+	Format string `json:"format"` // FFmpeg input format: avfoundation, dshow, v4l2
+	Kind   string `json:"kind"`   // video or audio
+	ID     string `json:"id"`     // Value for input.device
+	Name   string `json:"name"`
+}
+
+var (
+	// [AVFoundation indev @ 0x7f8] [0] FaceTime HD Camera
+	avfDeviceRegex = regexp.MustCompile(`\] \[(\d+)\] (.+)$`)
+	// [dshow @ 0000020c] "Integrated Camera" (video)
+	dshowDeviceRegex = regexp.MustCompile(`\] "(.+)" \((video|audio|none)\)`)
+)
+
+// InputDevices lists the capture devices on this machine
+func (f *FFmpeg) InputDevices(ctx context.Context) ([]InputDevice, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, _ := exec.CommandContext(ctx, f.binaryPath, "-hide_banner", "-f", "avfoundation", "-list_devices", "true", "-i", "").CombinedOutput()
+		return parseAVFoundationDevices(string(out)), nil
+	case "windows":
+		out, _ := exec.CommandContext(ctx, f.binaryPath, "-hide_banner", "-f", "dshow", "-list_devices", "true", "-i", "dummy").CombinedOutput()
+		return parseDShowDevices(string(out)), nil
+	case "linux":
+		return listV4L2Devices("/sys/class/video4linux")
+	}
+	return nil, fmt.Errorf("device listing not supported on %s", runtime.GOOS)
+}
+
+// parseAVFoundationDevices parses `-f avfoundation -list_devices true` output.
+// Devices are listed by index under video and audio headings.
+func parseAVFoundationDevices(output string) []InputDevice {
+	var devices []InputDevice
+	kind := ""
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.Contains(line, "AVFoundation video devices"):
+			kind = "video"
+		case strings.Contains(line, "AVFoundation audio devices"):
+			kind = "audio"
+		case kind != "":
+			if m := avfDeviceRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				devices = append(devices, InputDevice{Format: "avfoundation", Kind: kind, ID: m[1], Name: m[2]})
+			}
+		}
+	}
+	return devices
+}
+
+// parseDShowDevices parses `-f dshow -list_devices true` output. Devices are
+// opened by name, e.g. video=Integrated Camera.
+func parseDShowDevices(output string) []InputDevice {
+	var devices []InputDevice
+	for _, line := range strings.Split(output, "\n") {
+		m := dshowDeviceRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil || m[2] == "none" {
+			continue
+		}
+		devices = append(devices, InputDevice{Format: "dshow", Kind: m[2], ID: m[2] + "=" + m[1], Name: m[1]})
+	}
+	return devices
+}
+
+// listV4L2Devices lists video devices from sysfs (root is normally
+// /sys/class/video4linux)
+func listV4L2Devices(root string) ([]InputDevice, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", root, err)
+	}
+
+	var devices []InputDevice
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "video") {
+			continue
+		}
+		name, _ := os.ReadFile(filepath.Join(root, e.Name(), "name"))
+		devices = append(devices, InputDevice{
+			Format: "v4l2",
+			Kind:   "video",
+			ID:     "/dev/" + e.Name(),
+			Name:   strings.TrimSpace(string(name)),
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices, nil
+}
@@ -0,0 +1,60 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAVFoundationDevices(t *testing.T) {
+	output := `[AVFoundation indev @ 0x7f9c5b704380] AVFoundation video devices:
+[AVFoundation indev @ 0x7f9c5b704380] [0] FaceTime HD Camera
+[AVFoundation indev @ 0x7f9c5b704380] [1] Capture screen 0
+[AVFoundation indev @ 0x7f9c5b704380] AVFoundation audio devices:
+[AVFoundation indev @ 0x7f9c5b704380] [0] MacBook Pro Microphone
+: Input/output error`
+
+	got := parseAVFoundationDevices(output)
+	want := []InputDevice{
+		{Format: "avfoundation", Kind: "video", ID: "0", Name: "FaceTime HD Camera"},
+		{Format: "avfoundation", Kind: "video", ID: "1", Name: "Capture screen 0"},
+		{Format: "avfoundation", Kind: "audio", ID: "0", Name: "MacBook Pro Microphone"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d devices, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("device %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseDShowDevices(t *testing.T) {
+	output := `[dshow @ 0000020c4f7b8a40] "Integrated Camera" (video)
+[dshow @ 0000020c4f7b8a40]   Alternative name "@device_pnp_\\?\usb#vid_04f2"
+[dshow @ 0000020c4f7b8a40] "Microphone Array (Realtek Audio)" (audio)
+dummy: Immediate exit requested`
+
+	got := parseDShowDevices(output)
+	if len(got) != 2 {
+		t.Fatalf("got %+v", got)
+	}
+	if got[0].ID != "video=Integrated Camera" || got[1].Kind != "audio" {
+		t.Errorf("unexpected devices %+v", got)
+	}
+}
+
+func TestListV4L2Devices(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "video0"), 0755)
+	os.WriteFile(filepath.Join(root, "video0", "name"), []byte("Cam Link 4K\n"), 0644)
+
+	got, err := listV4L2Devices(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "/dev/video0" || got[0].Name != "Cam Link 4K" {
+		t.Errorf("unexpected devices %+v", got)
+	}
+}
@@ -0,0 +1,279 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultRenderDevice is the DRM render node VA-API and QSV use unless one
+// is configured or VAAPI_DEVICE is set
+const defaultRenderDevice = "/dev/dri/renderD128"
+
+// encoderNames maps encoder type and codec to the FFmpeg encoder name
+var encoderNames = map[string]map[string]string{
+	"software": {
+		"h264": "libx264",
+		"hevc": "libx265",
+		"av1":  "libsvtav1",
+	},
+	"nvenc": {
+		"h264": "h264_nvenc",
+		"hevc": "hevc_nvenc",
+		"av1":  "av1_nvenc",
+	},
+	"qsv": {
+		"h264": "h264_qsv",
+		"hevc": "hevc_qsv",
+		"av1":  "av1_qsv",
+	},
+	"vaapi": {
+		"h264": "h264_vaapi",
+		"hevc": "hevc_vaapi",
+		"av1":  "av1_vaapi",
+	},
+	"videotoolbox": {
+		"h264": "h264_videotoolbox",
+		"hevc": "hevc_videotoolbox",
+	},
+}
+
+// EncoderName resolves an encoder type (software, nvenc, qsv, vaapi,
+// videotoolbox) and codec (h264, hevc, av1) to an FFmpeg encoder name.
+// Codecs that are already FFmpeg encoder names are passed through.
+func EncoderName(encType, codec string) (string, error) {
+	if encType == "" {
+		encType = "software"
+	}
+	switch codec {
+	case "":
+		codec = "h264"
+	case "h265":
+		codec = "hevc"
+	}
+
+	byCodec, ok := encoderNames[encType]
+	if !ok {
+		return "", fmt.Errorf("unknown encoder type: %s", encType)
+	}
+	if name, ok := byCodec[codec]; ok {
+		return name, nil
+	}
+	if strings.Contains(codec, "_") || strings.HasPrefix(codec, "lib") {
+		return codec, nil
+	}
+	return "", fmt.Errorf("codec %s not supported by %s encoder", codec, encType)
+}
+
+// PresetFor translates an x264-style preset name for encoders that use a
+// different scale. SVT-AV1 takes a numeric preset (0 = slowest, 13 = fastest).
+func PresetFor(encoder, preset string) string {
+	if encoder != "libsvtav1" {
+		return preset
+	}
+	switch preset {
+	case "ultrafast", "superfast":
+		return "12"
+	case "veryfast", "faster":
+		return "10"
+	case "fast":
+		return "8"
+	case "medium":
+		return "6"
+	case "slow", "slower", "veryslow":
+		return "4"
+	default:
+		return preset
+	}
+}
+
+// RawEncodeArgs returns the arguments to encode raw frames with an encoder
+// type and codec on device (see HWEncodeArgs). hwArgs go before the first
+// input; encArgs (upload filter, encoder and preset) go after the inputs.
+func RawEncodeArgs(encType, codec, preset, device string) (hwArgs, encArgs []string, err error) {
+	encoder, err := EncoderName(encType, codec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hwArgs, upload := HWEncodeArgs(encType, device)
+	if upload != "" {
+		encArgs = []string{"-vf", upload}
+	}
+	encArgs = append(encArgs, "-c:v", encoder)
+	if p := EncoderPreset(encType, encoder, preset); p != "" {
+		encArgs = append(encArgs, "-preset", p)
+	}
+	return hwArgs, encArgs, nil
+}
+
+// HWEncodeArgs returns the global args that open the device of a VA-API or
+// QSV encoder and the filter that uploads frames to it; both are empty for
+// encoders that take frames in system memory. device is the DRM render node
+// (see RenderDevice).
+func HWEncodeArgs(encType, device string) (hwArgs []string, upload string) {
+	switch encType {
+	case "vaapi":
+		return []string{"-vaapi_device", RenderDevice(device)}, "format=nv12,hwupload"
+	case "qsv":
+		return []string{"-init_hw_device", "qsv=hw:" + RenderDevice(device), "-filter_hw_device", "hw"},
+			"format=nv12,hwupload=extra_hw_frames=64,format=qsv"
+	}
+	return nil, ""
+}
+
+// RenderDevice returns the DRM render node to encode on: device if set,
+// else VAAPI_DEVICE, else /dev/dri/renderD128
+func RenderDevice(device string) string {
+	if device != "" {
+		return device
+	}
+	if env := os.Getenv("VAAPI_DEVICE"); env != "" {
+		return env
+	}
+	return defaultRenderDevice
+}
+
+// RenderDevices lists the DRM render nodes of the host's GPUs, e.g.
+// /dev/dri/renderD128 for the first
+func RenderDevices() []string {
+	nodes, _ := filepath.Glob("/dev/dri/renderD*")
+	return nodes
+}
+
+// CheckRenderDevice checks the render node to encode on (see RenderDevice)
+// can be opened, naming the nodes present when it can't
+func CheckRenderDevice(device string) error {
+	node := RenderDevice(device)
+	f, err := os.OpenFile(node, os.O_RDWR, 0)
+	if err == nil {
+		return f.Close()
+	}
+	switch nodes := RenderDevices(); {
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("render node %s: %w (add the user to the render group)", node, err)
+	case len(nodes) > 0:
+		return fmt.Errorf("render node %s: %w (found %s)", node, err, strings.Join(nodes, ", "))
+	default:
+		return fmt.Errorf("render node %s: %w (no GPU render nodes in /dev/dri)", node, err)
+	}
+}
+
+// EncoderPreset translates an x264-style preset for an encoder type and
+// FFmpeg encoder; empty means the encoder takes no preset
+func EncoderPreset(encType, encoder, preset string) string {
+	return hwPreset(encType, PresetFor(encoder, preset))
+}
+
+// hwPreset translates an x264-style preset for a hardware encoder type;
+// empty means the encoder takes no preset
+func hwPreset(encType, preset string) string {
+	switch encType {
+	case "vaapi", "videotoolbox":
+		return ""
+	case "nvenc":
+		switch preset {
+		case "ultrafast", "superfast", "veryfast":
+			return "p1"
+		case "faster":
+			return "p2"
+		case "slower", "veryslow", "placebo":
+			return "slow"
+		}
+	case "qsv":
+		switch preset {
+		case "ultrafast", "superfast":
+			return "veryfast"
+		case "placebo":
+			return "veryslow"
+		}
+	}
+	return preset
+}
+
+// ListEncoders returns the set of video encoders compiled into FFmpeg
+func (f *FFmpeg) ListEncoders(ctx context.Context) (map[string]bool, error) {
+	f.encodersOnce.Do(func() {
+		cmd := exec.CommandContext(ctx, f.binaryPath, "-hide_banner", "-encoders")
+		output, err := cmd.Output()
+		if err != nil {
+			f.encodersErr = fmt.Errorf("list encoders: %w", err)
+			return
+		}
+		f.encoders = parseEncoders(string(output))
+	})
+	return f.encoders, f.encodersErr
+}
+
+// HasEncoder reports whether FFmpeg was built with the named encoder
+func (f *FFmpeg) HasEncoder(ctx context.Context, name string) bool {
+	encoders, err := f.ListEncoders(ctx)
+	if err != nil {
+		return false
+	}
+	return encoders[name]
+}
+
+// SupportedCodecs returns the codecs (h264, hevc, av1) that have at least one
+// encoder available, and the hardware encoder names that were found. VA-API
+// and QSV encoders only count when the default render node can be opened.
+func (f *FFmpeg) SupportedCodecs(ctx context.Context) (codecs []string, hardware []string) {
+	encoders, err := f.ListEncoders(ctx)
+	if err != nil {
+		return []string{"h264"}, nil
+	}
+	renderNode := CheckRenderDevice("") == nil
+
+	for _, codec := range []string{"h264", "hevc", "av1"} {
+		found := false
+		for _, encType := range []string{"software", "nvenc", "qsv", "vaapi", "videotoolbox"} {
+			name, ok := encoderNames[encType][codec]
+			if !ok || !encoders[name] {
+				continue
+			}
+			if (encType == "vaapi" || encType == "qsv") && !renderNode {
+				continue
+			}
+			found = true
+			if encType != "software" {
+				hardware = append(hardware, name)
+			}
+		}
+		if found {
+			codecs = append(codecs, codec)
+		}
+	}
+	return codecs, hardware
+}
+
+// parseEncoders parses `ffmpeg -encoders` output, keeping video encoders
+//
+//	V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC (codec h264)
+func parseEncoders(output string) map[string]bool {
+	encoders := make(map[string]bool)
+	inList := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "------") {
+			inList = true
+			continue
+		}
+		if !inList {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields[0]) != 6 || fields[0][0] != 'V' {
+			continue
+		}
+		encoders[fields[1]] = true
+	}
+	return encoders
+}
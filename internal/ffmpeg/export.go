@@ -0,0 +1,89 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ExportSegments streams an init segment and n media segments read with
+// segment through FFmpeg into one file, without re-encoding or a temporary
+// copy of the footage. The container follows outputPath's extension (.mp4,
+// .mov or .mkv). progress, if set, is called after each segment is written.
+func (f *FFmpeg) ExportSegments(ctx context.Context, initPath string, n int, segment func(i int) ([]byte, error), outputPath string, progress func(done int)) error {
+	if n == 0 {
+		return fmt.Errorf("no segments to export")
+	}
+	initData, err := os.ReadFile(initPath)
+	if err != nil {
+		return fmt.Errorf("read init segment: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, exportArgs(outputPath)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("ffmpeg stdin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start ffmpeg: %w", err)
+	}
+
+	writeErr := func() error {
+		if _, err := stdin.Write(initData); err != nil {
+			return fmt.Errorf("write init segment: %w", err)
+		}
+		for i := 0; i < n; i++ {
+			data, err := segment(i)
+			if err != nil {
+				return err
+			}
+			if _, err := stdin.Write(data); err != nil {
+				return fmt.Errorf("write segment: %w", err)
+			}
+			if progress != nil {
+				progress(i + 1)
+			}
+		}
+		return nil
+	}()
+	stdin.Close()
+
+	// A failed write usually means FFmpeg exited; its output says why
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg export: %w\noutput: %s", err, stderr.String())
+	}
+	return writeErr
+}
+
+// exportArgs remuxes fragmented MP4 on stdin to outputPath
+func exportArgs(outputPath string) []string {
+	return append([]string{"-y", "-loglevel", "error", "-f", "mp4", "-i", "pipe:0"}, copyArgs(outputPath)...)
+}
+
+// copyArgs writes the input's streams unchanged to outputPath. -movflags
+// is an MP4 muxer option; other muxers reject it.
+func copyArgs(outputPath string) []string {
+	args := []string{"-c", "copy"}
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".mp4", ".mov":
+		args = append(args, "-movflags", "+faststart")
+	}
+	return append(args, outputPath)
+}
+
+// Remux copies inputPath's streams into the container named by outputPath's
+// extension
+func (f *FFmpeg) Remux(ctx context.Context, inputPath, outputPath string) error {
+	args := append([]string{"-y", "-loglevel", "error", "-i", inputPath}, copyArgs(outputPath)...)
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg remux: %w\noutput: %s", err, output)
+	}
+	return nil
+}
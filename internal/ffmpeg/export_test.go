@@ -0,0 +1,16 @@
+package ffmpeg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExportArgs(t *testing.T) {
+	mp4 := exportArgs("/exports/game.mp4")
+	if !slices.Contains(mp4, "+faststart") || mp4[len(mp4)-1] != "/exports/game.mp4" {
+		t.Errorf("mp4 args = %v", mp4)
+	}
+	if mkv := exportArgs("/exports/game.mkv"); slices.Contains(mkv, "-movflags") {
+		t.Errorf("mkv args = %v, want no -movflags", mkv)
+	}
+}
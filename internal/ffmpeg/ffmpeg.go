@@ -4,18 +4,28 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+
+	"github.com/video-system/go-video-capture/internal/logging"
 )
 
+var logger = logging.For("ffmpeg")
+
 // FFmpeg wraps FFmpeg binary execution
 type FFmpeg struct {
 	binaryPath  string
 	probePath   string
+
+	// Encoder list, probed once on first use
+	encodersOnce sync.Once
+	encoders     map[string]bool
+	encodersErr  error
 }
 
 // New creates a new FFmpeg wrapper
@@ -87,10 +97,25 @@ func (f *FFmpeg) Version(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("no version output")
 }
 
+// Check verifies the ffmpeg and ffprobe binaries are still present and
+// executable
+func (f *FFmpeg) Check() error {
+	for _, path := range []string{f.binaryPath, f.probePath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Mode()&0111 == 0 {
+			return fmt.Errorf("%s is not executable", path)
+		}
+	}
+	return nil
+}
+
 // Process represents a running FFmpeg process
 type Process struct {
 	cmd    *exec.Cmd
-	stdin  *os.File
+	stdin  io.WriteCloser
 	stderr *bufio.Scanner
 	done   chan error
 	mu     sync.Mutex
@@ -120,7 +145,7 @@ func (f *FFmpeg) StartEncoder(ctx context.Context, cfg EncoderConfig) (*Process,
 
 	proc := &Process{
 		cmd:    cmd,
-		stdin:  stdin.(*os.File),
+		stdin:  stdin,
 		stderr: bufio.NewScanner(stderrPipe),
 		done:   make(chan error, 1),
 	}
@@ -161,38 +186,53 @@ func (p *Process) Done() <-chan error {
 // EncoderConfig holds configuration for the encoder
 type EncoderConfig struct {
 	// Input
-	InputFormat  string // rawvideo, pipe, etc.
-	PixelFormat  string // yuv420p, nv12, etc.
-	Width        int
-	Height       int
-	Framerate    int
+	InputFormat string // rawvideo, pipe, etc.
+	PixelFormat string // yuv420p, nv12, etc.
+	Width       int
+	Height      int
+	Framerate   int
+
+	// Hardware acceleration
+	HWArgs      []string // Global args placed before the input (e.g. -vaapi_device)
+	VideoFilter string   // Optional -vf chain (e.g. format=nv12,hwupload)
 
 	// Encoding
-	Codec        string // libx264, h264_nvenc, h264_videotoolbox
-	Preset       string // ultrafast, fast, medium
-	Bitrate      int    // kbps
-	GOP          int    // Keyframe interval in frames
+	Codec          string   // libx264, h264_nvenc, h264_videotoolbox
+	EncoderOptions []string // Placed after -c:v, e.g. -gpu 1 for NVENC
+	Preset         string   // ultrafast, fast, medium (omitted if empty)
+	Bitrate        int      // kbps
+	GOP            int      // Keyframe interval in frames
 
 	// Output
-	OutputPath   string // Directory for segments
+	OutputPath      string  // Directory for segments
 	SegmentDuration float64 // Segment duration in seconds
 }
 
 // buildEncoderArgs builds FFmpeg arguments for CMAF encoding
 func buildEncoderArgs(cfg EncoderConfig) []string {
-	args := []string{
-		"-y", // Overwrite output
+	args := []string{"-y"} // Overwrite output
+	args = append(args, cfg.HWArgs...)
 
+	args = append(args,
 		// Input
 		"-f", cfg.InputFormat,
 		"-pix_fmt", cfg.PixelFormat,
 		"-s", fmt.Sprintf("%dx%d", cfg.Width, cfg.Height),
 		"-r", fmt.Sprintf("%d", cfg.Framerate),
 		"-i", "pipe:0", // Read from stdin
+	)
+
+	if cfg.VideoFilter != "" {
+		args = append(args, "-vf", cfg.VideoFilter)
+	}
 
-		// Video encoding
-		"-c:v", cfg.Codec,
-		"-preset", cfg.Preset,
+	// Video encoding
+	args = append(args, "-c:v", cfg.Codec)
+	args = append(args, cfg.EncoderOptions...)
+	if cfg.Preset != "" {
+		args = append(args, "-preset", cfg.Preset)
+	}
+	args = append(args,
 		"-b:v", fmt.Sprintf("%dk", cfg.Bitrate),
 		"-g", fmt.Sprintf("%d", cfg.GOP),
 		"-keyint_min", fmt.Sprintf("%d", cfg.GOP),
@@ -209,7 +249,7 @@ func buildEncoderArgs(cfg EncoderConfig) []string {
 
 		// Output manifest
 		filepath.Join(cfg.OutputPath, "manifest.mpd"),
-	}
+	)
 
 	return args
 }
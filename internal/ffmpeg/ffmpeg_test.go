@@ -1,9 +1,12 @@
 package ffmpeg
 
 import (
+	"bufio"
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -93,6 +96,72 @@ func TestGenerateSegments(t *testing.T) {
 	t.Logf("Generated %d segments", len(segments))
 }
 
+func TestEncoderName(t *testing.T) {
+	tests := []struct {
+		encType, codec, want string
+	}{
+		{"", "", "libx264"},
+		{"software", "hevc", "libx265"},
+		{"nvenc", "av1", "av1_nvenc"},
+		{"qsv", "av1", "av1_qsv"},
+		{"nvenc", "h265", "hevc_nvenc"},
+		{"software", "libx264", "libx264"},
+	}
+	for _, tt := range tests {
+		got, err := EncoderName(tt.encType, tt.codec)
+		if err != nil || got != tt.want {
+			t.Errorf("EncoderName(%q, %q) = %q, %v; want %q", tt.encType, tt.codec, got, err, tt.want)
+		}
+	}
+
+	if _, err := EncoderName("videotoolbox", "av1"); err == nil {
+		t.Error("expected error for videotoolbox av1")
+	}
+}
+
+func TestRawEncodeArgs(t *testing.T) {
+	t.Setenv("VAAPI_DEVICE", "")
+	tests := []struct {
+		encType, codec, preset, device string
+		hw, enc                        string
+	}{
+		{"", "h264", "fast", "", "", "-c:v libx264 -preset fast"},
+		{"nvenc", "hevc", "ultrafast", "", "", "-c:v hevc_nvenc -preset p1"},
+		{"videotoolbox", "h264", "fast", "", "", "-c:v h264_videotoolbox"},
+		{"vaapi", "h264", "fast", "", "-vaapi_device /dev/dri/renderD128", "-vf format=nv12,hwupload -c:v h264_vaapi"},
+		{"vaapi", "hevc", "fast", "/dev/dri/renderD129", "-vaapi_device /dev/dri/renderD129", "-vf format=nv12,hwupload -c:v hevc_vaapi"},
+	}
+	for _, tt := range tests {
+		hw, enc, err := RawEncodeArgs(tt.encType, tt.codec, tt.preset, tt.device)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(hw, " "); got != tt.hw {
+			t.Errorf("%s hw args = %q, want %q", tt.encType, got, tt.hw)
+		}
+		if got := strings.Join(enc, " "); got != tt.enc {
+			t.Errorf("%s encode args = %q, want %q", tt.encType, got, tt.enc)
+		}
+	}
+}
+
+func TestParseEncoders(t *testing.T) {
+	output := `Encoders:
+ V..... = Video
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC (codec h264)
+ V....D av1_nvenc            NVIDIA NVENC av1 encoder (codec av1)
+ A....D aac                  AAC (Advanced Audio Coding)
+`
+	encoders := parseEncoders(output)
+	if !encoders["libx264"] || !encoders["av1_nvenc"] {
+		t.Errorf("missing video encoders: %v", encoders)
+	}
+	if encoders["aac"] {
+		t.Error("audio encoder should be skipped")
+	}
+}
+
 func TestProbe(t *testing.T) {
 	ff, err := New()
 	if err != nil {
@@ -112,3 +181,319 @@ func TestProbe(t *testing.T) {
 	t.Logf("Video info: %dx%d @ %.2f fps, codec=%s, duration=%.2fs",
 		info.Width, info.Height, info.Framerate, info.Codec, info.Duration)
 }
+
+func TestCropFilter(t *testing.T) {
+	filter, err := CropFilter("9:16", "left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "crop=w='trunc(min(iw,ih*9/16)/2)*2':h='trunc(min(ih,iw*16/9)/2)*2':x='(iw-ow)*0':y='(ih-oh)*0'"
+	if filter != want {
+		t.Errorf("CropFilter = %s, want %s", filter, want)
+	}
+
+	for _, tc := range []struct{ aspect, anchor string }{
+		{"916", ""},
+		{"0:1", ""},
+		{"1:1", "middle"},
+		{"1:1", "1.5"},
+	} {
+		if _, err := CropFilter(tc.aspect, tc.anchor); err == nil {
+			t.Errorf("CropFilter(%q, %q) should fail", tc.aspect, tc.anchor)
+		}
+	}
+}
+
+func TestOverlayFilter(t *testing.T) {
+	filter, err := OverlayFilter(OverlayStyle{Position: "top-right", FontSize: 32, Box: true}, "/tmp/a:b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `drawtext=textfile='/tmp/a\:b.txt':fontcolor=white:fontsize=32:x=w-text_w-h/40:y=h/40:box=1:boxcolor=black@0.5:boxborderw=8`
+	if filter != want {
+		t.Errorf("OverlayFilter = %s, want %s", filter, want)
+	}
+	if _, err := OverlayFilter(OverlayStyle{Position: "middle"}, "t.txt"); err == nil {
+		t.Error("unknown position should fail")
+	}
+
+	if got := OverlayText(`100% \o/`); got != `100\% \\o/` {
+		t.Errorf("OverlayText = %s", got)
+	}
+	clock := OverlayClock(time.UnixMilli(1700000000250))
+	if clock != `%{pts:localtime:1700000000.250:%H\:%M\:%S}` {
+		t.Errorf("OverlayClock = %s", clock)
+	}
+}
+
+func TestSlowMotionFilters(t *testing.T) {
+	for _, tc := range []struct {
+		rate, fps   float64
+		interpolate bool
+		video       string
+		audio       string
+	}{
+		{0.5, 0, false, "setpts=2*PTS", "atempo=0.5"},
+		{0.25, 0, false, "setpts=4*PTS", "atempo=0.5,atempo=0.5"},
+		{0.1, 0, false, "setpts=10*PTS", "atempo=0.5,atempo=0.5,atempo=0.5,atempo=0.8"},
+		{0.5, 59.94, true, "setpts=2*PTS,minterpolate=fps=59.94:mi_mode=mci:mc_mode=aobmc:vsbmc=1", "atempo=0.5"},
+	} {
+		video, audio, err := SlowMotionFilters(tc.rate, tc.fps, tc.interpolate)
+		if err != nil {
+			t.Fatalf("rate %g: %v", tc.rate, err)
+		}
+		if video != tc.video || audio != tc.audio {
+			t.Errorf("rate %g = %q, %q, want %q, %q", tc.rate, video, audio, tc.video, tc.audio)
+		}
+	}
+
+	for _, rate := range []float64{0, 0.05, 1, 2} {
+		if _, _, err := SlowMotionFilters(rate, 30, false); err == nil {
+			t.Errorf("rate %g should fail", rate)
+		}
+	}
+	if _, _, err := SlowMotionFilters(0.5, 0, true); err == nil {
+		t.Error("interpolation without a frame rate should fail")
+	}
+}
+
+func TestMonitorOutputStats(t *testing.T) {
+	stderr := "Input #0, lavfi\n" +
+		"frame=   30 fps= 30 q=23.0 size=N/A time=00:00:01.00 bitrate=N/A speed=1x\r" +
+		"frame=   60 fps= 30 q=23.0 size=N/A time=00:00:02.00 bitrate=N/A dup=2 drop=5 speed=1x\r" +
+		"frame=   88 fps=29.5 q=23.0 size=    1536kB time=00:00:02.93 bitrate=4294.9kbits/s dup=2 drop=7 speed=0.98x\r"
+
+	sw := &SegmentWriter{}
+	scanner := bufio.NewScanner(strings.NewReader(stderr))
+	scanner.Split(scanLinesCR)
+	sw.monitorOutput(scanner)
+
+	want := EncoderStats{Frames: 88, Duplicated: 2, Dropped: 7, FPS: 29.5, BitrateKbps: 4294.9, Speed: 0.98}
+	if got := sw.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if tail := sw.StderrTail(); len(tail) != 2 || tail[0] != "Input #0, lavfi" || !strings.HasPrefix(tail[1], "frame=   88") {
+		t.Errorf("StderrTail() = %q", tail)
+	}
+}
+
+func TestBuildArgsInputOptions(t *testing.T) {
+	sw := &SegmentWriter{cfg: SegmentConfig{
+		Input:        "rtsp://camera/stream",
+		InputOptions: []string{"-rtsp_transport", "tcp"},
+		Codec:        "libx264",
+		OutputDir:    "/buffer",
+	}}
+	args := strings.Join(sw.buildArgs(), " ")
+	if !strings.HasPrefix(args, "-y -rtsp_transport tcp -i rtsp://camera/stream ") {
+		t.Errorf("args = %s", args)
+	}
+}
+
+func TestBuildArgsAudioInput(t *testing.T) {
+	sw := &SegmentWriter{cfg: SegmentConfig{
+		Input:             "pipe:0",
+		InputFormat:       "rawvideo",
+		InputOptions:      []string{"-pixel_format", "uyvy", "-video_size", "1920x1080", "-framerate", "25"},
+		AudioInput:        "pipe:3",
+		AudioInputFormat:  "s16le",
+		AudioInputOptions: []string{"-ar", "48000", "-ac", "8"},
+		Codec:             "libx264",
+		OutputDir:         "/buffer",
+	}}
+	args := strings.Join(sw.buildArgs(), " ")
+	want := "-y -f rawvideo -pixel_format uyvy -video_size 1920x1080 -framerate 25 -i pipe:0 -f s16le -ar 48000 -ac 8 -i pipe:3 "
+	if !strings.HasPrefix(args, want) {
+		t.Errorf("args = %s", args)
+	}
+}
+
+func TestBuildArgsVAAPI(t *testing.T) {
+	hwArgs, upload := HWEncodeArgs("vaapi", "/dev/dri/renderD129")
+	sw := &SegmentWriter{cfg: SegmentConfig{
+		Input:     "rtsp://camera/stream",
+		Codec:     "h264_vaapi",
+		HWArgs:    hwArgs,
+		HWUpload:  upload,
+		Width:     1280,
+		Height:    720,
+		OutputDir: "/buffer",
+	}}
+	args := strings.Join(sw.buildArgs(), " ")
+	if !strings.HasPrefix(args, "-y -vaapi_device /dev/dri/renderD129 -i rtsp://camera/stream ") {
+		t.Errorf("args = %s", args)
+	}
+	if !strings.Contains(args, "-vf scale=1280:720,format=nv12,hwupload ") {
+		t.Errorf("args = %s", args)
+	}
+	if strings.Contains(args, "-preset") {
+		t.Errorf("preset passed to VA-API: %s", args)
+	}
+
+	// A logo overlay labels its output; the upload goes inside it
+	if got := appendFilter("movie='logo.png'[logo];[in]null[base];[base][logo]overlay=10:10[out]", upload); got != "movie='logo.png'[logo];[in]null[base];[base][logo]overlay=10:10,format=nv12,hwupload[out]" {
+		t.Errorf("appendFilter = %s", got)
+	}
+}
+
+func TestCheckRenderDevice(t *testing.T) {
+	err := CheckRenderDevice("/nonexistent/renderD200")
+	if err == nil || !strings.Contains(err.Error(), "render node /nonexistent/renderD200") {
+		t.Errorf("CheckRenderDevice = %v", err)
+	}
+	t.Setenv("VAAPI_DEVICE", "/dev/dri/renderD129")
+	if got := RenderDevice(""); got != "/dev/dri/renderD129" {
+		t.Errorf("RenderDevice with VAAPI_DEVICE = %s", got)
+	}
+	if got := RenderDevice("/dev/dri/renderD130"); got != "/dev/dri/renderD130" {
+		t.Errorf("RenderDevice with device = %s", got)
+	}
+}
+
+func TestPanFilter(t *testing.T) {
+	if got := panFilter([]int{2, 3}); got != "stereo|c0=c2|c1=c3" {
+		t.Errorf("pair = %s", got)
+	}
+	if got := panFilter([]int{4}); got != "mono|c0=c4" {
+		t.Errorf("mono = %s", got)
+	}
+	if got := audioArgs(SegmentConfig{AudioCodec: "none"}, []int{2, 3}); strings.Join(got, " ") != "-an" {
+		t.Errorf("none = %v", got)
+	}
+}
+
+func TestDeinterlaceFilter(t *testing.T) {
+	cfg := SegmentConfig{Deinterlace: "bwdif", FieldOrder: "tff", Width: 1280, Height: 720, Codec: "libx264"}
+	sw := &SegmentWriter{cfg: cfg}
+	args := sw.buildArgs()
+	i := slices.Index(args, "-vf")
+	if i < 0 || args[i+1] != "bwdif=mode=send_frame:parity=tff,scale=1280:720" {
+		t.Errorf("args = %v", args)
+	}
+	if f := deinterlaceFilter(SegmentConfig{Deinterlace: "yadif", DeinterlaceFields: true}); f != "yadif=mode=send_field:parity=auto" {
+		t.Errorf("field rate = %s", f)
+	}
+}
+
+func TestScanSegments(t *testing.T) {
+	dir := t.TempDir()
+	if existing, next := scanSegments(dir); len(existing) != 0 || next != 0 {
+		t.Errorf("empty dir: %v, %d", existing, next)
+	}
+	for _, name := range []string{"segment_00007.m4s", "segment_00012.m4s", "init.mp4"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+	}
+	existing, next := scanSegments(dir)
+	if len(existing) != 2 || !existing[filepath.Join(dir, "segment_00012.m4s")] || next != 13 {
+		t.Errorf("existing = %v, next = %d", existing, next)
+	}
+}
+
+func TestWatchSegmentsAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"segment_00000.m4s", "segment_00001.m4s"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+	}
+
+	// A restarted writer skips the previous run's segments and times its
+	// own from when it started
+	sw := (&FFmpeg{}).NewSegmentWriter(SegmentConfig{OutputDir: dir, SegmentDuration: 2})
+	sw.existing, sw.firstSeq = scanSegments(dir)
+	got := make(chan SegmentInfo, 4)
+	sw.OnSegment(func(seg SegmentInfo) { got <- seg })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started := time.Now()
+	go sw.watchSegments(ctx)
+	os.WriteFile(filepath.Join(dir, "segment_00002.m4s"), []byte("x"), 0644)
+
+	select {
+	case seg := <-got:
+		if seg.Sequence != 2 {
+			t.Fatalf("reported segment %d, want 2", seg.Sequence)
+		}
+		if d := seg.StartTime.Sub(started); d < -time.Second || d > time.Second {
+			t.Errorf("start time %v after the restart, want about 0", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("new segment not reported")
+	}
+	select {
+	case seg := <-got:
+		t.Errorf("unexpected segment %d reported", seg.Sequence)
+	case <-time.After(time.Second):
+	}
+}
+
+func TestBuildArgsThumbnailsAfterRestart(t *testing.T) {
+	// A restarted writer numbers thumbnails from its first segment, so
+	// thumb_N.jpg still matches segment_N.m4s
+	sw := &SegmentWriter{
+		cfg: SegmentConfig{
+			Input:           "rtsp://camera/stream",
+			Codec:           "libx264",
+			SegmentDuration: 2,
+			OutputDir:       "/buffer",
+			ThumbnailDir:    "/buffer/thumbs",
+		},
+		firstSeq: 42,
+	}
+	args := strings.Join(sw.buildArgs(), " ")
+	if !strings.Contains(args, "-start_number 42 -f image2 /buffer/thumbs/thumb_%05d.jpg") {
+		t.Errorf("args = %s", args)
+	}
+}
+
+func TestAudioArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      SegmentConfig
+		selected []int
+		want     string
+	}{
+		{"default", SegmentConfig{}, nil, "-c:a aac"},
+		{"aac bitrate", SegmentConfig{AudioCodec: "aac", AudioBitrate: 192}, nil, "-c:a aac -b:a 192k"},
+		{"opus 5.1", SegmentConfig{AudioCodec: "opus", AudioBitrate: 256, AudioLayout: "5.1", AudioSampleRate: 48000}, nil,
+			"-c:a libopus -b:a 256k -ac 6 -ar 48000 -strict experimental"},
+		{"layout overrides channels", SegmentConfig{AudioChannels: 6, AudioLayout: "stereo"}, nil, "-c:a aac -ac 2"},
+		{"channels", SegmentConfig{AudioChannels: 1}, nil, "-c:a aac -ac 1"},
+		{"selected channels", SegmentConfig{AudioBitrate: 128}, []int{2, 3}, "-c:a aac -af stereo|c0=c2|c1=c3 -b:a 128k"},
+		{"copy", SegmentConfig{AudioCodec: "copy", AudioBitrate: 128}, nil, "-c:a copy"},
+		{"none", SegmentConfig{AudioCodec: "none", AudioBitrate: 128}, nil, "-an"},
+	} {
+		if got := strings.Join(audioArgs(tc.cfg, tc.selected), " "); got != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "ffmpeg")
+	plain := filepath.Join(dir, "plain")
+	for path, mode := range map[string]os.FileMode{exe: 0755, plain: 0644} {
+		if err := os.WriteFile(path, nil, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		binary  string
+		probe   string
+		wantErr bool
+	}{
+		{"both executable", exe, exe, false},
+		{"missing binary", filepath.Join(dir, "missing"), exe, true},
+		{"missing probe", exe, filepath.Join(dir, "missing"), true},
+		{"directory", dir, exe, true},
+		{"not executable", exe, plain, true},
+	}
+	for _, tt := range tests {
+		f := &FFmpeg{binaryPath: tt.binary, probePath: tt.probe}
+		if err := f.Check(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Check() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
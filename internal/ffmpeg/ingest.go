@@ -0,0 +1,180 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// IngestedSegment is a CMAF segment cut from a finished recording
+type IngestedSegment struct {
+	Path     string
+	Offset   float64 // Seconds from the start of the recording
+	Duration float64 // Seconds
+	Size     int64
+}
+
+// SegmentRecording re-encodes a finished recording (MP4, MKV, ...) into
+// init.mp4 and segment_NNNNN.m4s files in cfg.OutputDir, using the same
+// encode settings as live capture. Only the first video and audio streams
+// are kept.
+func (f *FFmpeg) SegmentRecording(ctx context.Context, cfg SegmentConfig) ([]IngestedSegment, error) {
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("create output dir: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, ingestArgs(cfg)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg ingest: %w\noutput: %s", err, output)
+	}
+
+	playlist, err := os.ReadFile(filepath.Join(cfg.OutputDir, "index.m3u8"))
+	if err != nil {
+		return nil, fmt.Errorf("read ingest playlist: %w", err)
+	}
+	segments, err := parseVODPlaylist(playlist)
+	if err != nil {
+		return nil, err
+	}
+	for i := range segments {
+		segments[i].Path = filepath.Join(cfg.OutputDir, segments[i].Path)
+		if info, err := os.Stat(segments[i].Path); err == nil {
+			segments[i].Size = info.Size()
+		}
+	}
+	return segments, nil
+}
+
+// ingestArgs builds the FFmpeg arguments for SegmentRecording
+func ingestArgs(cfg SegmentConfig) []string {
+	args := []string{"-y"}
+	args = append(args, cfg.HWArgs...)
+	args = append(args, "-i", cfg.Input, "-map", "0:v:0", "-map", "0:a:0?")
+
+	args = append(args, "-c:v", cfg.Codec)
+	args = append(args, cfg.EncoderOptions...)
+	if preset := PresetFor(cfg.Codec, cfg.Preset); preset != "" {
+		args = append(args, "-preset", preset)
+	}
+	if cfg.Bitrate > 0 {
+		args = append(args, "-b:v", fmt.Sprintf("%dk", cfg.Bitrate))
+	}
+	gop := cfg.GOP
+	if gop == 0 {
+		framerate := cfg.Framerate
+		if framerate == 0 {
+			framerate = 30
+		}
+		gop = int(float64(framerate) * cfg.SegmentDuration)
+	}
+	args = append(args,
+		"-g", strconv.Itoa(gop),
+		"-keyint_min", strconv.Itoa(gop),
+		"-sc_threshold", "0",
+	)
+	if cfg.BFrames >= 0 {
+		args = append(args, "-bf", strconv.Itoa(cfg.BFrames))
+	}
+
+	var filters []string
+	if f := deinterlaceFilter(cfg); f != "" {
+		filters = append(filters, f)
+	}
+	if cfg.Width > 0 && cfg.Height > 0 {
+		filters = append(filters, fmt.Sprintf("scale=%d:%d", cfg.Width, cfg.Height))
+	}
+	if cfg.Framerate > 0 {
+		filters = append(filters, fmt.Sprintf("fps=%d", cfg.Framerate))
+	}
+	if cfg.HWUpload != "" {
+		filters = append(filters, cfg.HWUpload)
+	}
+	if len(filters) > 0 {
+		args = append(args, "-vf", strings.Join(filters, ","))
+	}
+
+	args = append(args, audioArgs(cfg, cfg.AudioSelect)...)
+
+	return append(args,
+		"-f", "hls",
+		"-hls_time", fmt.Sprintf("%g", cfg.SegmentDuration),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", "fmp4",
+		"-hls_fmp4_init_filename", "init.mp4",
+		"-hls_segment_filename", filepath.Join(cfg.OutputDir, "segment_%05d.m4s"),
+		"-start_number", "0",
+		filepath.Join(cfg.OutputDir, "index.m3u8"),
+	)
+}
+
+// parseVODPlaylist reads segment URIs and durations from a media playlist
+func parseVODPlaylist(data []byte) ([]IngestedSegment, error) {
+	var segments []IngestedSegment
+	var offset, duration float64
+	pending := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			d, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid segment duration %q", value)
+			}
+			duration, pending = d, true
+		case line == "" || strings.HasPrefix(line, "#"):
+		case pending:
+			segments = append(segments, IngestedSegment{Path: line, Offset: offset, Duration: duration})
+			offset += duration
+			pending = false
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments in ingest playlist")
+	}
+	return segments, nil
+}
+
+// JoinClips joins MP4 files into one with the concat demuxer. Inputs must
+// share codecs and resolution; nothing is re-encoded.
+func (f *FFmpeg) JoinClips(ctx context.Context, inputs []string, outputPath string) error {
+	list, err := os.CreateTemp(filepath.Dir(outputPath), "join-*.txt")
+	if err != nil {
+		return fmt.Errorf("create concat list: %w", err)
+	}
+	defer os.Remove(list.Name())
+	for _, in := range inputs {
+		abs, err := filepath.Abs(in)
+		if err != nil {
+			list.Close()
+			return err
+		}
+		fmt.Fprintf(list, "file '%s'\n", strings.ReplaceAll(abs, "'", `'\''`))
+	}
+	if err := list.Close(); err != nil {
+		return fmt.Errorf("write concat list: %w", err)
+	}
+
+	args := []string{
+		"-y",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", list.Name(),
+		"-c", "copy",
+		"-movflags", "+faststart",
+		outputPath,
+	}
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg join: %w\noutput: %s", err, output)
+	}
+	return nil
+}
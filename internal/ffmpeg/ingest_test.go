@@ -0,0 +1,62 @@
+package ffmpeg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseVODPlaylist(t *testing.T) {
+	playlist := []byte(`#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:2
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:2.002000,
+segment_00000.m4s
+#EXTINF:2.002000,
+segment_00001.m4s
+#EXTINF:0.967633,
+segment_00002.m4s
+#EXT-X-ENDLIST
+`)
+	segments, err := parseVODPlaylist(playlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 3 {
+		t.Fatalf("segments = %d, want 3", len(segments))
+	}
+	last := segments[2]
+	if last.Path != "segment_00002.m4s" || last.Offset != 4.004 || last.Duration != 0.967633 {
+		t.Errorf("last segment = %+v", last)
+	}
+
+	if _, err := parseVODPlaylist([]byte("#EXTM3U\n#EXT-X-ENDLIST\n")); err == nil {
+		t.Error("expected an error for a playlist without segments")
+	}
+}
+
+func TestIngestArgsMatchLiveEncode(t *testing.T) {
+	args := ingestArgs(SegmentConfig{
+		Input:           "/media/cam1.mkv",
+		Codec:           "libx264",
+		Preset:          "fast",
+		GOP:             60,
+		SegmentDuration: 2,
+		OutputDir:       "/buffer/imports/1",
+	})
+	for _, want := range [][]string{
+		{"-keyint_min", "60"},
+		{"-g", "60"},
+		{"-hls_playlist_type", "vod"},
+		{"-hls_segment_filename", "/buffer/imports/1/segment_%05d.m4s"},
+	} {
+		i := slices.Index(args, want[0])
+		if i < 0 || i+1 >= len(args) || args[i+1] != want[1] {
+			t.Errorf("missing %v in %v", want, args)
+		}
+	}
+	if args[len(args)-1] != "/buffer/imports/1/index.m3u8" {
+		t.Errorf("output = %s", args[len(args)-1])
+	}
+}
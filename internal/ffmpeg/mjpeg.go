@@ -0,0 +1,49 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxJPEGSize bounds a single frame so a corrupt stream can't grow the
+// buffer without limit
+const maxJPEGSize = 8 << 20
+
+var (
+	jpegSOI = []byte{0xFF, 0xD8}
+	jpegEOI = []byte{0xFF, 0xD9}
+)
+
+// ReadJPEGs splits a stream of concatenated JPEG images, as written by
+// FFmpeg's image2pipe muxer with the mjpeg encoder, and calls fn with each
+// one. It returns nil at the end of the stream.
+func ReadJPEGs(r io.Reader, fn func(jpeg []byte)) error {
+	br := bufio.NewReaderSize(r, 64<<10)
+	var frame []byte
+	for {
+		chunk, err := br.ReadSlice(jpegEOI[1])
+		frame = append(frame, chunk...)
+
+		// FFmpeg's encoder writes no embedded thumbnails and stuffs 0xFF in
+		// entropy-coded data, so FF D9 only ends an image
+		if bytes.HasSuffix(frame, jpegEOI) {
+			if start := bytes.Index(frame, jpegSOI); start >= 0 {
+				fn(bytes.Clone(frame[start:]))
+			}
+			frame = frame[:0]
+		} else if len(frame) > maxJPEGSize {
+			return fmt.Errorf("jpeg frame larger than %d bytes", maxJPEGSize)
+		}
+
+		switch {
+		case err == nil, errors.Is(err, bufio.ErrBufferFull):
+		case err == io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadJPEGs(t *testing.T) {
+	first := []byte{0xFF, 0xD8, 0x01, 0xFF, 0x00, 0xD9, 0xFF, 0xD9}
+	second := append([]byte{0xFF, 0xD8}, bytes.Repeat([]byte{0x42}, 100<<10)...)
+	second = append(second, 0xFF, 0xD9)
+	partial := []byte{0xFF, 0xD8, 0x03}
+
+	var stream []byte
+	for _, b := range [][]byte{first, second, partial} {
+		stream = append(stream, b...)
+	}
+
+	var got [][]byte
+	if err := ReadJPEGs(bytes.NewReader(stream), func(jpeg []byte) { got = append(got, jpeg) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !bytes.Equal(got[0], first) || !bytes.Equal(got[1], second) {
+		t.Fatalf("got %d frames", len(got))
+	}
+}
@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OverlayStyle places text burned into a clip
+type OverlayStyle struct {
+	Position string // top-left, top, top-right, bottom-left (default), bottom, bottom-right
+	FontSize int    // Pixels (default: 1/24 of the frame height)
+	FontFile string // TrueType font (default: fontconfig's default font)
+	Box      bool   // Draw a translucent box behind the text
+}
+
+// overlayPositions maps a position to drawtext x and y expressions, with a
+// margin of 1/40 of the frame height
+var overlayPositions = map[string][2]string{
+	"top-left":     {"h/40", "h/40"},
+	"top":          {"(w-text_w)/2", "h/40"},
+	"top-right":    {"w-text_w-h/40", "h/40"},
+	"bottom-left":  {"h/40", "h-text_h-h/40"},
+	"bottom":       {"(w-text_w)/2", "h-text_h-h/40"},
+	"bottom-right": {"w-text_w-h/40", "h-text_h-h/40"},
+}
+
+// OverlayFilter builds a drawtext filter that reads its text from textFile
+func OverlayFilter(style OverlayStyle, textFile string) (string, error) {
+	position := style.Position
+	if position == "" {
+		position = "bottom-left"
+	}
+	xy, ok := overlayPositions[position]
+	if !ok {
+		return "", fmt.Errorf("invalid overlay position: %s", position)
+	}
+	if style.FontSize < 0 {
+		return "", fmt.Errorf("invalid overlay font size: %d", style.FontSize)
+	}
+
+	size := "h/24"
+	if style.FontSize > 0 {
+		size = fmt.Sprint(style.FontSize)
+	}
+	filter := fmt.Sprintf("drawtext=textfile='%s':fontcolor=white:fontsize=%s:x=%s:y=%s", escapeFilterValue(textFile), size, xy[0], xy[1])
+	if style.FontFile != "" {
+		filter += fmt.Sprintf(":fontfile='%s'", escapeFilterValue(style.FontFile))
+	}
+	if style.Box {
+		filter += ":box=1:boxcolor=black@0.5:boxborderw=8"
+	} else {
+		filter += ":shadowcolor=black@0.8:shadowx=2:shadowy=2"
+	}
+	return filter, nil
+}
+
+// OverlayText escapes literal text for drawtext, which expands %{...}
+// sequences and backslashes
+func OverlayText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`).Replace(s)
+}
+
+// OverlayClock is a drawtext expansion showing the wall-clock time of each
+// frame, for a clip whose first frame was captured at start
+func OverlayClock(start time.Time) string {
+	return fmt.Sprintf(`%%{pts:localtime:%.3f:%%H\:%%M\:%%S}`, float64(start.UnixMilli())/1000)
+}
+
+// OverlayClip re-encodes a clip with text burned in. text is drawtext text:
+// escape literal parts with OverlayText.
+func (f *FFmpeg) OverlayClip(ctx context.Context, inputPath, outputPath, text string, style OverlayStyle, encoder string, bitrate int) error {
+	// drawtext reads the text from a file so it needs no filtergraph escaping
+	tf, err := os.CreateTemp(filepath.Dir(outputPath), "overlay-*.txt")
+	if err != nil {
+		return fmt.Errorf("write overlay text: %w", err)
+	}
+	defer os.Remove(tf.Name())
+	_, err = tf.WriteString(text)
+	if cerr := tf.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write overlay text: %w", err)
+	}
+
+	filter, err := OverlayFilter(style, tf.Name())
+	if err != nil {
+		return err
+	}
+	if encoder == "" {
+		encoder = "libx264"
+	}
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-vf", filter,
+		"-c:v", encoder,
+	}
+	if bitrate > 0 {
+		args = append(args, "-b:v", fmt.Sprintf("%dk", bitrate))
+	}
+	args = append(args,
+		"-c:a", "copy",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg overlay: %w\noutput: %s", err, output)
+	}
+	return nil
+}
@@ -72,6 +72,7 @@ type VideoInfo struct {
 	Codec      string
 	BitRate    int64
 	PixelFmt   string
+	HasAudio   bool
 }
 
 // GetVideoInfo returns simplified video information
@@ -107,6 +108,13 @@ func (f *FFmpeg) GetVideoInfo(ctx context.Context, path string) (*VideoInfo, err
 		}
 	}
 
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "audio" {
+			info.HasAudio = true
+			break
+		}
+	}
+
 	// Parse duration from format
 	if probe.Format.Duration != "" {
 		info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
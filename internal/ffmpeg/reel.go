@@ -0,0 +1,191 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ReelTransitions are the transitions RenderReel accepts. Everything but cut
+// is an xfade transition.
+var ReelTransitions = []string{
+	"cut", "fade", "dissolve", "fadeblack", "fadewhite",
+	"wipeleft", "wiperight", "slideleft", "slideright",
+	"circleopen", "circleclose",
+}
+
+// ReelClip is one input of a highlight reel
+type ReelClip struct {
+	Path     string
+	Duration float64 // Seconds
+	HasAudio bool
+}
+
+// ReelConfig configures a highlight reel render
+type ReelConfig struct {
+	Width              int
+	Height             int
+	FPS                float64
+	Transition         string  // One of ReelTransitions (default: cut)
+	TransitionDuration float64 // Seconds; ignored for cut
+	Title              string  // Title slate text (empty = no slate)
+	TitleDuration      float64 // Seconds the slate is shown
+	Encoder            string  // Default: libx264
+	Bitrate            int     // kbps (0 = encoder default)
+}
+
+// RenderReel joins clips into one video, scaling every clip to the reel
+// size and optionally opening with a title slate
+func (f *FFmpeg) RenderReel(ctx context.Context, clips []ReelClip, cfg ReelConfig, outputPath string) error {
+	var titleFile string
+	if cfg.Title != "" {
+		// drawtext reads the title from a file so it needs no escaping
+		tf, err := os.CreateTemp(filepath.Dir(outputPath), "reel-title-*.txt")
+		if err != nil {
+			return fmt.Errorf("write title: %w", err)
+		}
+		defer os.Remove(tf.Name())
+		_, err = tf.WriteString(cfg.Title)
+		if cerr := tf.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("write title: %w", err)
+		}
+		titleFile = tf.Name()
+	}
+
+	graph, err := reelFilter(clips, cfg, titleFile)
+	if err != nil {
+		return err
+	}
+	encoder := cfg.Encoder
+	if encoder == "" {
+		encoder = "libx264"
+	}
+
+	args := []string{"-y"}
+	for _, c := range clips {
+		args = append(args, "-i", c.Path)
+	}
+	args = append(args,
+		"-filter_complex", graph,
+		"-map", "[vout]",
+		"-map", "[aout]",
+		"-c:v", encoder,
+	)
+	if cfg.Bitrate > 0 {
+		args = append(args, "-b:v", fmt.Sprintf("%dk", cfg.Bitrate))
+	}
+	args = append(args,
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg reel: %w\noutput: %s", err, output)
+	}
+	return nil
+}
+
+// reelFilter builds the filter graph for RenderReel. Every part (slate and
+// clips) is normalized to the same size, frame rate and audio layout so they
+// can be concatenated or cross-faded; clips without audio get silence.
+func reelFilter(clips []ReelClip, cfg ReelConfig, titleFile string) (string, error) {
+	if len(clips) == 0 {
+		return "", fmt.Errorf("reel has no clips")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.FPS <= 0 {
+		return "", fmt.Errorf("invalid reel format %dx%d@%g", cfg.Width, cfg.Height, cfg.FPS)
+	}
+	transition := cfg.Transition
+	if transition == "" {
+		transition = "cut"
+	}
+	if !slices.Contains(ReelTransitions, transition) {
+		return "", fmt.Errorf("unknown transition %q", transition)
+	}
+
+	var (
+		parts     []string
+		durations []float64
+	)
+	size := fmt.Sprintf("%dx%d", cfg.Width, cfg.Height)
+	normalize := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%.4g,format=yuv420p,setpts=PTS-STARTPTS",
+		cfg.Width, cfg.Height, cfg.Width, cfg.Height, cfg.FPS)
+	audioFormat := "aresample=48000,aformat=sample_fmts=fltp:channel_layouts=stereo,asetpts=PTS-STARTPTS"
+	silence := func(d float64) string {
+		return fmt.Sprintf("anullsrc=r=48000:cl=stereo,atrim=duration=%.3f,%s", d, audioFormat)
+	}
+
+	if titleFile != "" {
+		d := cfg.TitleDuration
+		if d <= 0 {
+			return "", fmt.Errorf("title duration must be positive")
+		}
+		parts = append(parts,
+			fmt.Sprintf("color=c=black:s=%s:r=%.4g:d=%.3f,drawtext=textfile='%s':fontcolor=white:fontsize=h/12:x=(w-text_w)/2:y=(h-text_h)/2,%s[v0]",
+				size, cfg.FPS, d, escapeFilterValue(titleFile), normalize),
+			silence(d)+"[a0]")
+		durations = append(durations, d)
+	}
+	for i, c := range clips {
+		if c.Duration <= 0 {
+			return "", fmt.Errorf("clip %d has no duration", i)
+		}
+		n := len(durations)
+		parts = append(parts, fmt.Sprintf("[%d:v]%s[v%d]", i, normalize, n))
+		if c.HasAudio {
+			parts = append(parts, fmt.Sprintf("[%d:a]%s[a%d]", i, audioFormat, n))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s[a%d]", silence(c.Duration), n))
+		}
+		durations = append(durations, c.Duration)
+	}
+
+	if transition == "cut" || len(durations) == 1 {
+		var inputs strings.Builder
+		for i := range durations {
+			fmt.Fprintf(&inputs, "[v%d][a%d]", i, i)
+		}
+		parts = append(parts, fmt.Sprintf("%sconcat=n=%d:v=1:a=1[vout][aout]", inputs.String(), len(durations)))
+		return strings.Join(parts, ";"), nil
+	}
+
+	td := cfg.TransitionDuration
+	if td <= 0 {
+		return "", fmt.Errorf("transition duration must be positive")
+	}
+	for i, d := range durations {
+		if d <= td {
+			return "", fmt.Errorf("part %d (%.1fs) is shorter than the transition", i, d)
+		}
+	}
+
+	// Each cross-fade starts td before the end of everything joined so far
+	video, audio := "v0", "a0"
+	elapsed := durations[0]
+	for i := 1; i < len(durations); i++ {
+		vout, aout := fmt.Sprintf("xv%d", i), fmt.Sprintf("xa%d", i)
+		if i == len(durations)-1 {
+			vout, aout = "vout", "aout"
+		}
+		parts = append(parts,
+			fmt.Sprintf("[%s][v%d]xfade=transition=%s:duration=%.3f:offset=%.3f[%s]", video, i, transition, td, elapsed-td, vout),
+			fmt.Sprintf("[%s][a%d]acrossfade=d=%.3f[%s]", audio, i, td, aout))
+		video, audio = vout, aout
+		elapsed += durations[i] - td
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+// escapeFilterValue escapes a quoted filter option value
+func escapeFilterValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `'\''`, `:`, `\:`).Replace(s)
+}
@@ -0,0 +1,53 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReelFilter(t *testing.T) {
+	clips := []ReelClip{
+		{Path: "a.mp4", Duration: 10, HasAudio: true},
+		{Path: "b.mp4", Duration: 8},
+	}
+	cfg := ReelConfig{Width: 1280, Height: 720, FPS: 30}
+
+	graph, err := reelFilter(clips, cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[0:a]aresample", "anullsrc=r=48000:cl=stereo,atrim=duration=8.000", "[v0][a0][v1][a1]concat=n=2:v=1:a=1[vout][aout]"} {
+		if !strings.Contains(graph, want) {
+			t.Errorf("cut graph missing %q: %s", want, graph)
+		}
+	}
+
+	cfg.Transition = "fade"
+	cfg.TransitionDuration = 1
+	cfg.Title = "Highlights"
+	cfg.TitleDuration = 3
+	graph, err = reelFilter(clips, cfg, "/tmp/title.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"drawtext=textfile='/tmp/title.txt'",
+		"[v0][v1]xfade=transition=fade:duration=1.000:offset=2.000[xv1]",
+		"[xv1][v2]xfade=transition=fade:duration=1.000:offset=11.000[vout]",
+		"[xa1][a2]acrossfade=d=1.000[aout]",
+	} {
+		if !strings.Contains(graph, want) {
+			t.Errorf("fade graph missing %q: %s", want, graph)
+		}
+	}
+
+	cfg.Transition = "spin"
+	if _, err := reelFilter(clips, cfg, ""); err == nil {
+		t.Error("unknown transition should fail")
+	}
+	cfg.Transition = "fade"
+	cfg.TransitionDuration = 9
+	if _, err := reelFilter(clips, cfg, ""); err == nil {
+		t.Error("transition longer than a clip should fail")
+	}
+}
@@ -0,0 +1,78 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// RestreamConfig configures remuxing a fragmented MP4 stream to a live
+// destination
+type RestreamConfig struct {
+	URL        string
+	Format     string   // Output container, e.g. flv (RTMP) or mpegts (SRT)
+	Options    []string // Muxer and protocol options placed before the URL
+	AudioCodec string   // Re-encode audio, e.g. aac (empty = copy)
+	Realtime   bool     // Read input at its native rate, smoothing segment bursts
+}
+
+// Restreamer remuxes the fragmented MP4 stream written to it and pushes it
+// to a live destination. Video is always copied.
+type Restreamer struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+}
+
+// StartRestream starts an FFmpeg process pushing to cfg.URL
+func (f *FFmpeg) StartRestream(ctx context.Context, cfg RestreamConfig) (*Restreamer, error) {
+	cmd := exec.CommandContext(ctx, f.binaryPath, restreamArgs(cfg)...)
+	r := &Restreamer{cmd: cmd}
+	cmd.Stderr = &r.stderr
+
+	var err error
+	if r.stdin, err = cmd.StdinPipe(); err != nil {
+		return nil, fmt.Errorf("ffmpeg stdin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start ffmpeg: %w", err)
+	}
+	return r, nil
+}
+
+// restreamArgs builds the FFmpeg arguments for a push
+func restreamArgs(cfg RestreamConfig) []string {
+	args := []string{"-loglevel", "error"}
+	if cfg.Realtime {
+		args = append(args, "-re")
+	}
+	args = append(args,
+		"-f", "mp4", "-i", "pipe:0",
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-c:v", "copy",
+	)
+	if cfg.AudioCodec != "" {
+		args = append(args, "-c:a", cfg.AudioCodec, "-b:a", "160k", "-ar", "48000")
+	} else {
+		args = append(args, "-c:a", "copy")
+	}
+	args = append(args, "-f", cfg.Format)
+	args = append(args, cfg.Options...)
+	return append(args, cfg.URL)
+}
+
+// Write feeds the init segment or a media segment to FFmpeg
+func (r *Restreamer) Write(data []byte) (int, error) {
+	return r.stdin.Write(data)
+}
+
+// Close ends the input and waits for FFmpeg to flush and exit
+func (r *Restreamer) Close() error {
+	r.stdin.Close()
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg restream: %w\noutput: %s", err, r.stderr.String())
+	}
+	return nil
+}
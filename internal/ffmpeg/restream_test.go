@@ -0,0 +1,34 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRestreamArgs(t *testing.T) {
+	rtmp := strings.Join(restreamArgs(RestreamConfig{
+		URL:        "rtmp://a.rtmp.youtube.com/live2/key",
+		Format:     "flv",
+		Options:    []string{"-flvflags", "no_duration_filesize"},
+		AudioCodec: "aac",
+		Realtime:   true,
+	}), " ")
+	if !strings.Contains(rtmp, "-re -f mp4 -i pipe:0") || !strings.Contains(rtmp, "-c:v copy -c:a aac") {
+		t.Errorf("rtmp input args = %s", rtmp)
+	}
+	if !strings.HasSuffix(rtmp, "-f flv -flvflags no_duration_filesize rtmp://a.rtmp.youtube.com/live2/key") {
+		t.Errorf("rtmp output args = %s", rtmp)
+	}
+
+	srt := strings.Join(restreamArgs(RestreamConfig{
+		URL:     "srt://switcher:9000",
+		Format:  "mpegts",
+		Options: []string{"-mode", "caller"},
+	}), " ")
+	if strings.Contains(srt, "-re") || !strings.Contains(srt, "-c:a copy") {
+		t.Errorf("srt input args = %s", srt)
+	}
+	if !strings.HasSuffix(srt, "-f mpegts -mode caller srt://switcher:9000") {
+		t.Errorf("srt output args = %s", srt)
+	}
+}
@@ -0,0 +1,53 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// RTPConfig configures packetizing a fragmented MP4 stream as RTP for
+// WebRTC: H.264 video copied, audio encoded to Opus
+type RTPConfig struct {
+	VideoURL string // e.g. rtp://127.0.0.1:5004?pkt_size=1200
+	AudioURL string // Opus audio (empty = video only)
+}
+
+// StartRTP starts an FFmpeg process sending the stream written to it as
+// RTP. The returned Restreamer takes the init segment and media segments.
+func (f *FFmpeg) StartRTP(ctx context.Context, cfg RTPConfig) (*Restreamer, error) {
+	cmd := exec.CommandContext(ctx, f.binaryPath, rtpArgs(cfg)...)
+	r := &Restreamer{cmd: cmd}
+	cmd.Stderr = &r.stderr
+
+	var err error
+	if r.stdin, err = cmd.StdinPipe(); err != nil {
+		return nil, fmt.Errorf("ffmpeg stdin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start ffmpeg: %w", err)
+	}
+	return r, nil
+}
+
+// rtpArgs builds the FFmpeg arguments for RTP output. RTP carries one
+// stream per session, so video and audio go to separate URLs.
+func rtpArgs(cfg RTPConfig) []string {
+	args := []string{
+		"-loglevel", "error",
+		"-fflags", "nobuffer",
+		"-f", "mp4", "-i", "pipe:0",
+		// Annex B with SPS/PPS before every keyframe, so players can join
+		// at any keyframe
+		"-map", "0:v:0", "-c:v", "copy", "-bsf:v", "h264_mp4toannexb",
+		"-f", "rtp", cfg.VideoURL,
+	}
+	if cfg.AudioURL != "" {
+		args = append(args,
+			"-map", "0:a:0", "-c:a", "libopus", "-b:a", "96k", "-ar", "48000", "-ac", "2",
+			"-application", "lowdelay",
+			"-f", "rtp", cfg.AudioURL,
+		)
+	}
+	return args
+}
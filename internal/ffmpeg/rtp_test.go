@@ -0,0 +1,21 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRTPArgs(t *testing.T) {
+	video := strings.Join(rtpArgs(RTPConfig{VideoURL: "rtp://127.0.0.1:5004"}), " ")
+	if !strings.HasSuffix(video, "-c:v copy -bsf:v h264_mp4toannexb -f rtp rtp://127.0.0.1:5004") {
+		t.Errorf("video args = %s", video)
+	}
+	if strings.Contains(video, "0:a") {
+		t.Errorf("video-only args map audio: %s", video)
+	}
+
+	both := strings.Join(rtpArgs(RTPConfig{VideoURL: "rtp://127.0.0.1:5004", AudioURL: "rtp://127.0.0.1:5006"}), " ")
+	if !strings.HasSuffix(both, "-map 0:a:0 -c:a libopus -b:a 96k -ar 48000 -ac 2 -application lowdelay -f rtp rtp://127.0.0.1:5006") {
+		t.Errorf("audio args = %s", both)
+	}
+}
@@ -2,16 +2,20 @@ package ffmpeg
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
 )
 
 // SegmentWriter generates CMAF segments from a video source
@@ -21,10 +25,36 @@ type SegmentWriter struct {
 	cmd         *exec.Cmd
 	outputPath  string
 	onSegment   func(SegmentInfo)
+	onSegmentStart func(seq int, path string)
+	onError     func(error)
+	videoGraph  string // Filtergraph with burn-in, built at start
+	existing    map[string]bool // Segment files left by an earlier run
+	firstSeq    int             // Sequence of this run's first segment
 
 	cancel   context.CancelFunc
 	lastErr  error
 	errMutex sync.RWMutex
+
+	exited  chan struct{} // Closed once the FFmpeg process has exited
+	exitErr error
+
+	stats    EncoderStats
+	stderr   []string // Recent non-progress stderr lines
+	progress string   // Latest progress line
+	statsMu  sync.RWMutex
+}
+
+// stderrLines is how many stderr lines StderrTail keeps
+const stderrLines = 200
+
+// EncoderStats are running counters parsed from FFmpeg's progress output
+type EncoderStats struct {
+	Frames      int64   `json:"frames"`
+	Duplicated  int64   `json:"duplicated"`   // Frames repeated to keep the output rate
+	Dropped     int64   `json:"dropped"`      // Input frames discarded
+	FPS         float64 `json:"fps"`          // Encoding rate
+	BitrateKbps float64 `json:"bitrate_kbps"` // Output bitrate (0 when FFmpeg reports N/A)
+	Speed       float64 `json:"speed"`        // Encoding speed relative to real time; below 1 the encoder falls behind
 }
 
 // SegmentConfig holds configuration for segment generation
@@ -32,19 +62,59 @@ type SegmentConfig struct {
 	// Input source (file path, device, or URL)
 	Input       string
 	InputFormat string // Optional: force input format
+	InputOptions []string // Optional: demuxer/protocol options placed before -i
+	Stdin       io.Reader // Optional: fed to FFmpeg for a pipe:0 input, e.g. raw frames
+
+	// Optional second input carrying the audio, e.g. raw PCM from a native
+	// input on an extra pipe. Without alternate renditions FFmpeg picks the
+	// audio from whichever input has it.
+	AudioInput        string
+	AudioInputFormat  string
+	AudioInputOptions []string
+	ExtraFiles        []*os.File // Passed to FFmpeg as fd 3 onwards (pipe:3); closed once it starts
 
 	// Encoding settings
-	Codec       string  // libx264, h264_nvenc, h264_videotoolbox
-	Preset      string  // ultrafast, fast, medium
-	Bitrate     int     // kbps (0 = use source bitrate)
-	Width       int     // Output width (0 = source)
-	Height      int     // Output height (0 = source)
-	Framerate   int     // Output framerate (0 = source)
+	Codec          string   // libx264, h264_nvenc, h264_videotoolbox
+	EncoderOptions []string // Placed after -c:v, e.g. -gpu 1 for NVENC
+	Preset         string   // ultrafast, fast, medium (omitted if empty)
+	HWArgs         []string // Global args opening the encoder's device, e.g. -vaapi_device (see HWEncodeArgs)
+	HWUpload       string   // Filter uploading frames to the device, last in the video chain
+	Bitrate        int      // kbps (0 = use source bitrate)
+	Width          int      // Output width (0 = source)
+	Height         int      // Output height (0 = source)
+	Framerate      int      // Output framerate (0 = source)
+
+	// Deinterlacing for interlaced (e.g. 1080i) sources
+	Deinterlace       string // yadif, bwdif (empty = off)
+	FieldOrder        string // tff, bff (empty = detect from input)
+	DeinterlaceFields bool   // One frame per field, doubling the frame rate (e.g. 1080i50 -> 50p)
+
+	// Text, clock and logo burned into the video (and so into clips)
+	BurnIn BurnIn
+
+	// Audio settings
+	AudioCodec      string // aac, opus, copy, none (default: aac)
+	AudioBitrate    int    // kbps (default: 128)
+	AudioChannels   int    // Output channel count (0 = source)
+	AudioLayout     string // mono, stereo, 5.1, 7.1 (overrides AudioChannels)
+	AudioSampleRate int    // Hz (0 = source)
+	AudioSelect     []int  // Input channels kept from the primary track, in order (nil = all)
 
 	// Segment settings
 	SegmentDuration float64 // Seconds per segment (default: 2)
 	GOP             int     // Keyframe interval in frames (0 = auto based on segment duration)
 	BFrames         int     // Number of B-frames (-1 = default, 0 = disabled)
+	ChunkDuration   float64 // CMAF chunk (fragment) duration in seconds (0 = one fragment per segment)
+
+	// Alternate audio renditions, written as separate audio-only HLS
+	// streams under audio/{name}/. The primary track stays muxed with video.
+	PrimaryAudioTrack int              // Input audio stream index muxed with video
+	AudioRenditions   []AudioRendition // Extra audio tracks to publish
+	RenditionWindow   int              // Segments kept per rendition playlist (0 = keep all)
+
+	// Thumbnail track: one JPEG per segment duration, numbered like segments
+	ThumbnailDir   string // Output directory (empty = disabled)
+	ThumbnailWidth int    // Thumbnail width in pixels (height keeps aspect)
 
 	// Output
 	OutputDir   string // Directory for segments
@@ -71,6 +141,12 @@ func (f *FFmpeg) NewSegmentWriter(cfg SegmentConfig) *SegmentWriter {
 	if cfg.Preset == "" {
 		cfg.Preset = "fast"
 	}
+	if cfg.AudioCodec == "" {
+		cfg.AudioCodec = "aac"
+	}
+	if cfg.AudioBitrate == 0 {
+		cfg.AudioBitrate = 128
+	}
 
 	return &SegmentWriter{
 		ffmpeg:     f,
@@ -84,12 +160,57 @@ func (sw *SegmentWriter) OnSegment(fn func(SegmentInfo)) {
 	sw.onSegment = fn
 }
 
+// AudioRendition is an alternate audio track published alongside the main stream
+type AudioRendition struct {
+	Name   string // Directory and playlist name (e.g. "natural")
+	Track  int    // Input audio stream index (0:a:N)
+	Select []int  // Input channels kept from the track, in order (nil = all)
+}
+
+// AudioRenditionDir is the directory, relative to the output path, that
+// holds an audio rendition's init segment, segments and playlist
+func AudioRenditionDir(name string) string {
+	return filepath.Join("audio", name)
+}
+
+// OnSegmentStart sets a callback for when FFmpeg opens a new segment file,
+// before it is complete. Used to stream CMAF chunks as they are written.
+func (sw *SegmentWriter) OnSegmentStart(fn func(seq int, path string)) {
+	sw.onSegmentStart = fn
+}
+
+// OnError sets a callback for errors reported by FFmpeg
+func (sw *SegmentWriter) OnError(fn func(error)) {
+	sw.onError = fn
+}
+
 // Start begins generating segments
 func (sw *SegmentWriter) Start(ctx context.Context) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(sw.outputPath, 0755); err != nil {
 		return fmt.Errorf("create output dir: %w", err)
 	}
+	for _, r := range sw.cfg.AudioRenditions {
+		if err := os.MkdirAll(filepath.Join(sw.outputPath, AudioRenditionDir(r.Name)), 0755); err != nil {
+			return fmt.Errorf("create rendition dir: %w", err)
+		}
+	}
+	if sw.cfg.ThumbnailDir != "" {
+		if err := os.MkdirAll(sw.cfg.ThumbnailDir, 0755); err != nil {
+			return fmt.Errorf("create thumbnail dir: %w", err)
+		}
+	}
+	if sw.cfg.BurnIn.Enabled() {
+		textFile, err := sw.cfg.BurnIn.writeText(sw.outputPath)
+		if err != nil {
+			return err
+		}
+		if sw.videoGraph, err = burnInGraph(videoFilters(sw.cfg), sw.cfg.BurnIn, textFile, time.Now()); err != nil {
+			return fmt.Errorf("overlay: %w", err)
+		}
+	}
+
+	sw.existing, sw.firstSeq = scanSegments(sw.outputPath)
 
 	ctx, sw.cancel = context.WithCancel(ctx)
 
@@ -101,16 +222,52 @@ func (sw *SegmentWriter) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("get stderr pipe: %w", err)
 	}
+	var stdin io.WriteCloser
+	if sw.cfg.Stdin != nil {
+		if stdin, err = sw.cmd.StdinPipe(); err != nil {
+			return fmt.Errorf("get stdin pipe: %w", err)
+		}
+	}
 
-	if err := sw.cmd.Start(); err != nil {
+	sw.cmd.ExtraFiles = sw.cfg.ExtraFiles
+	err = sw.cmd.Start()
+	for _, f := range sw.cfg.ExtraFiles {
+		f.Close() // FFmpeg has its own copy
+	}
+	if err != nil {
 		return fmt.Errorf("start ffmpeg: %w", err)
 	}
+	if stdin != nil {
+		// Not waited for: a source that stops producing must not block Wait
+		go func() {
+			io.Copy(stdin, sw.cfg.Stdin)
+			stdin.Close()
+		}()
+	}
 
-	// Monitor output in background
-	go sw.monitorOutput(bufio.NewScanner(stderr))
+	// Monitor output in background. Progress lines end in \r, not \n.
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanLinesCR)
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		defer recovery.Handle("ffmpeg output monitor", sw.setError)
+		sw.monitorOutput(scanner)
+	}()
+
+	// Reap the process once its output is drained
+	sw.exited = make(chan struct{})
+	go func() {
+		<-outputDone
+		sw.exitErr = sw.cmd.Wait()
+		close(sw.exited)
+	}()
 
 	// Watch for new segments
-	go sw.watchSegments(ctx)
+	go func() {
+		defer recovery.Handle("segment watcher", sw.setError)
+		sw.watchSegments(ctx)
+	}()
 
 	return nil
 }
@@ -125,11 +282,8 @@ func (sw *SegmentWriter) Stop() error {
 		sw.cmd.Process.Signal(os.Interrupt)
 
 		// Wait with timeout
-		done := make(chan error, 1)
-		go func() { done <- sw.cmd.Wait() }()
-
 		select {
-		case <-done:
+		case <-sw.exited:
 		case <-time.After(5 * time.Second):
 			sw.cmd.Process.Kill()
 		}
@@ -137,28 +291,59 @@ func (sw *SegmentWriter) Stop() error {
 	return nil
 }
 
-// Wait waits for the segment writer to finish
+// Abort stops FFmpeg as failed with err, e.g. when its input stalled
+// without FFmpeg noticing
+func (sw *SegmentWriter) Abort(err error) {
+	sw.setError(err)
+	sw.Stop()
+}
+
+// Wait waits for the FFmpeg process to exit and returns its exit error
 func (sw *SegmentWriter) Wait() error {
-	if sw.cmd == nil {
+	if sw.exited == nil {
 		return nil
 	}
-	return sw.cmd.Wait()
+	<-sw.exited
+	return sw.exitErr
+}
+
+// Exited returns a channel that is closed when the FFmpeg process exits,
+// whether stopped or on its own
+func (sw *SegmentWriter) Exited() <-chan struct{} {
+	return sw.exited
 }
 
 // buildArgs builds FFmpeg arguments for CMAF segment generation
 func (sw *SegmentWriter) buildArgs() []string {
 	cfg := sw.cfg
 	args := []string{"-y"}
+	args = append(args, cfg.HWArgs...)
 
 	// Input
 	if cfg.InputFormat != "" {
 		args = append(args, "-f", cfg.InputFormat)
 	}
+	args = append(args, cfg.InputOptions...)
 	args = append(args, "-i", cfg.Input)
+	if cfg.AudioInput != "" {
+		if cfg.AudioInputFormat != "" {
+			args = append(args, "-f", cfg.AudioInputFormat)
+		}
+		args = append(args, cfg.AudioInputOptions...)
+		args = append(args, "-i", cfg.AudioInput)
+	}
+
+	// With alternate renditions, pin the main output to video + primary audio
+	if len(cfg.AudioRenditions) > 0 {
+		args = append(args, "-map", "0:v:0", "-map", fmt.Sprintf("0:a:%d?", cfg.PrimaryAudioTrack))
+	}
 
 	// Video encoding
 	args = append(args, "-c:v", cfg.Codec)
-	args = append(args, "-preset", cfg.Preset)
+	args = append(args, cfg.EncoderOptions...)
+	if preset := PresetFor(cfg.Codec, cfg.Preset); preset != "" {
+		args = append(args, "-preset", preset)
+	}
 
 	if cfg.Bitrate > 0 {
 		args = append(args, "-b:v", fmt.Sprintf("%dk", cfg.Bitrate))
@@ -182,13 +367,16 @@ func (sw *SegmentWriter) buildArgs() []string {
 		args = append(args, "-bf", fmt.Sprintf("%d", cfg.BFrames))
 	}
 
-	// Scaling if specified
-	if cfg.Width > 0 && cfg.Height > 0 {
-		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d", cfg.Width, cfg.Height))
+	// Deinterlace, scale if specified, burn in overlays, then upload to
+	// the encoder's device
+	if sw.videoGraph != "" {
+		args = append(args, "-vf", appendFilter(sw.videoGraph, cfg.HWUpload))
+	} else if graph := appendFilter(strings.Join(videoFilters(cfg), ","), cfg.HWUpload); graph != "" {
+		args = append(args, "-vf", graph)
 	}
 
-	// Audio (copy or aac)
-	args = append(args, "-c:a", "aac", "-b:a", "128k")
+	// Audio
+	args = append(args, audioArgs(cfg, cfg.AudioSelect)...)
 
 	// CMAF/fMP4 output via HLS muxer with fmp4 segments
 	// Creates init.mp4 + segment_NNNNN.m4s files for instant concatenation
@@ -201,44 +389,261 @@ func (sw *SegmentWriter) buildArgs() []string {
 		"-hls_segment_filename", filepath.Join(sw.outputPath, "segment_%05d.m4s"),
 		"-hls_flags", "independent_segments+program_date_time+append_list",
 		"-hls_list_size", "0",
-		filepath.Join(sw.outputPath, "playlist.m3u8"),
 	)
 
+	// Low-latency CMAF: split each segment into several moof/mdat chunks
+	if cfg.ChunkDuration > 0 {
+		args = append(args, "-hls_segment_options",
+			fmt.Sprintf("movflags=+cmaf:frag_duration=%d", int(cfg.ChunkDuration*1e6)))
+	}
+
+	args = append(args, filepath.Join(sw.outputPath, "playlist.m3u8"))
+
+	// Alternate audio renditions as additional audio-only outputs. These
+	// aren't tracked by the ring buffer, so FFmpeg trims them itself.
+	for _, r := range cfg.AudioRenditions {
+		dir := filepath.Join(sw.outputPath, AudioRenditionDir(r.Name))
+		flags := "independent_segments+program_date_time"
+		if cfg.RenditionWindow > 0 {
+			flags += "+delete_segments"
+		}
+		args = append(args, "-map", fmt.Sprintf("0:a:%d", r.Track))
+		args = append(args, audioArgs(cfg, r.Select)...)
+		args = append(args,
+			"-f", "hls",
+			"-hls_time", fmt.Sprintf("%g", cfg.SegmentDuration),
+			"-hls_segment_type", "fmp4",
+			"-hls_fmp4_init_filename", "init.mp4",
+			"-hls_segment_filename", filepath.Join(dir, "segment_%05d.m4s"),
+			"-hls_flags", flags,
+			"-hls_list_size", fmt.Sprintf("%d", cfg.RenditionWindow),
+			filepath.Join(dir, "playlist.m3u8"),
+		)
+	}
+
+	// Thumbnail track as an extra image output. Frames are sampled once per
+	// segment duration so thumb_N.jpg lines up with segment_N.m4s.
+	if cfg.ThumbnailDir != "" {
+		width := cfg.ThumbnailWidth
+		if width <= 0 {
+			width = 320
+		}
+		filter := fmt.Sprintf("fps=1/%g,scale=%d:-2", cfg.SegmentDuration, width)
+		if f := deinterlaceFilter(cfg); f != "" {
+			filter = f + "," + filter
+		}
+		args = append(args,
+			"-map", "0:v:0",
+			"-vf", filter,
+			"-q:v", "5",
+			"-start_number", strconv.Itoa(sw.firstSeq),
+			"-f", "image2",
+			filepath.Join(cfg.ThumbnailDir, "thumb_%05d.jpg"),
+		)
+	}
+
+	return args
+}
+
+// audioArgs builds the audio encoding arguments for a segment writer,
+// keeping only the selected input channels
+func audioArgs(cfg SegmentConfig, selected []int) []string {
+	switch cfg.AudioCodec {
+	case "none":
+		return []string{"-an"}
+	case "copy":
+		return []string{"-c:a", "copy"}
+	}
+
+	args := []string{"-c:a", AudioEncoderName(cfg.AudioCodec)}
+	if len(selected) > 0 {
+		args = append(args, "-af", panFilter(selected))
+	}
+	if cfg.AudioBitrate > 0 {
+		args = append(args, "-b:a", fmt.Sprintf("%dk", cfg.AudioBitrate))
+	}
+
+	channels := cfg.AudioChannels
+	if n := layoutChannels(cfg.AudioLayout); n > 0 {
+		channels = n
+	}
+	if channels > 0 {
+		args = append(args, "-ac", fmt.Sprintf("%d", channels))
+	}
+	if cfg.AudioSampleRate > 0 {
+		args = append(args, "-ar", fmt.Sprintf("%d", cfg.AudioSampleRate))
+	}
+
+	// Opus in MP4 is still flagged experimental in older FFmpeg builds
+	if cfg.AudioCodec == "opus" {
+		args = append(args, "-strict", "experimental")
+	}
 	return args
 }
 
+// AudioEncoderName maps an audio codec name to the FFmpeg encoder
+func AudioEncoderName(codec string) string {
+	switch codec {
+	case "", "aac":
+		return "aac"
+	case "opus":
+		return "libopus"
+	default:
+		return codec
+	}
+}
+
+// videoFilters returns the deinterlace and scale filters for the main output
+func videoFilters(cfg SegmentConfig) []string {
+	var filters []string
+	if f := deinterlaceFilter(cfg); f != "" {
+		filters = append(filters, f)
+	}
+	if cfg.Width > 0 && cfg.Height > 0 {
+		filters = append(filters, fmt.Sprintf("scale=%d:%d", cfg.Width, cfg.Height))
+	}
+	return filters
+}
+
+// appendFilter adds filter to the end of a -vf graph, inside its output
+// label if it has one
+func appendFilter(graph, filter string) string {
+	switch {
+	case filter == "":
+		return graph
+	case graph == "":
+		return filter
+	case strings.HasSuffix(graph, "[out]"):
+		return strings.TrimSuffix(graph, "[out]") + "," + filter + "[out]"
+	default:
+		return graph + "," + filter
+	}
+}
+
+// deinterlaceFilter returns the yadif/bwdif filter for the config, or ""
+// when deinterlacing is off
+func deinterlaceFilter(cfg SegmentConfig) string {
+	if cfg.Deinterlace == "" {
+		return ""
+	}
+	mode := "send_frame"
+	if cfg.DeinterlaceFields {
+		mode = "send_field"
+	}
+	parity := "auto"
+	if cfg.FieldOrder != "" {
+		parity = cfg.FieldOrder
+	}
+	return fmt.Sprintf("%s=mode=%s:parity=%s", cfg.Deinterlace, mode, parity)
+}
+
+// panFilter builds a pan filter that keeps the given input channels, e.g.
+// [2, 3] picks the second pair of an 8-channel SDI feed as stereo
+func panFilter(selected []int) string {
+	var layout string
+	switch len(selected) {
+	case 1:
+		layout = "mono"
+	case 2:
+		layout = "stereo"
+	default:
+		layout = fmt.Sprintf("%dc", len(selected))
+	}
+	parts := []string{layout}
+	for i, c := range selected {
+		parts = append(parts, fmt.Sprintf("c%d=c%d", i, c))
+	}
+	return strings.Join(parts, "|")
+}
+
+// layoutChannels returns the channel count for a named channel layout
+func layoutChannels(layout string) int {
+	switch layout {
+	case "mono":
+		return 1
+	case "stereo":
+		return 2
+	case "5.1":
+		return 6
+	case "7.1":
+		return 8
+	default:
+		return 0
+	}
+}
+
 // monitorOutput parses FFmpeg stderr for progress
 func (sw *SegmentWriter) monitorOutput(scanner *bufio.Scanner) {
 	frameRegex := regexp.MustCompile(`frame=\s*(\d+)`)
+	dupRegex := regexp.MustCompile(`dup=\s*(\d+)`)
+	dropRegex := regexp.MustCompile(`drop=\s*(\d+)`)
 	fpsRegex := regexp.MustCompile(`fps=\s*([\d.]+)`)
-	timeRegex := regexp.MustCompile(`time=(\d+):(\d+):([\d.]+)`)
+	bitrateRegex := regexp.MustCompile(`bitrate=\s*([\d.]+)kbits/s`)
+	speedRegex := regexp.MustCompile(`speed=\s*([\d.]+)x`)
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
 		// Log errors and track them
 		if strings.Contains(line, "Error") || strings.Contains(line, "error") {
-			log.Printf("FFmpeg: %s", line)
+			logger.Error("FFmpeg error", "output", line, "path", sw.outputPath)
 			sw.setError(fmt.Errorf("FFmpeg error: %s", line))
 		}
 
-		// Parse progress (optional - for monitoring)
-		if frameRegex.MatchString(line) {
-			// Could emit progress events here
-			_ = fpsRegex
-			_ = timeRegex
+		if !frameRegex.MatchString(line) {
+			sw.statsMu.Lock()
+			sw.stderr = append(sw.stderr, line)
+			if len(sw.stderr) > stderrLines {
+				sw.stderr = sw.stderr[len(sw.stderr)-stderrLines:]
+			}
+			sw.statsMu.Unlock()
+		}
+
+		// Parse progress
+		if m := frameRegex.FindStringSubmatch(line); m != nil {
+			var stats EncoderStats
+			stats.Frames, _ = strconv.ParseInt(m[1], 10, 64)
+			if m := dupRegex.FindStringSubmatch(line); m != nil {
+				stats.Duplicated, _ = strconv.ParseInt(m[1], 10, 64)
+			}
+			if m := dropRegex.FindStringSubmatch(line); m != nil {
+				stats.Dropped, _ = strconv.ParseInt(m[1], 10, 64)
+			}
+			if m := fpsRegex.FindStringSubmatch(line); m != nil {
+				stats.FPS, _ = strconv.ParseFloat(m[1], 64)
+			}
+			if m := bitrateRegex.FindStringSubmatch(line); m != nil {
+				stats.BitrateKbps, _ = strconv.ParseFloat(m[1], 64)
+			}
+			if m := speedRegex.FindStringSubmatch(line); m != nil {
+				stats.Speed, _ = strconv.ParseFloat(m[1], 64)
+			}
+			sw.statsMu.Lock()
+			sw.stats = stats
+			sw.progress = line
+			sw.statsMu.Unlock()
 		}
 	}
 }
 
 // watchSegments monitors for new segment files
 func (sw *SegmentWriter) watchSegments(ctx context.Context) {
-	if sw.onSegment == nil {
+	if sw.onSegment == nil && sw.onSegmentStart == nil {
 		return
 	}
 
-	seen := make(map[string]bool)
-	ticker := time.NewTicker(500 * time.Millisecond)
+	// Segments from before a restart were reported by the previous run
+	seen := make(map[string]bool, len(sw.existing))
+	started := make(map[string]bool, len(sw.existing))
+	for f := range sw.existing {
+		seen[f], started[f] = true, true
+	}
+	// Poll faster when chunks are streamed so the first chunk isn't delayed
+	interval := 500 * time.Millisecond
+	if sw.onSegmentStart != nil {
+		interval = 50 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	startTime := time.Now()
@@ -255,22 +660,32 @@ func (sw *SegmentWriter) watchSegments(ctx context.Context) {
 					continue
 				}
 
+				// Parse sequence number from filename
+				base := filepath.Base(f)
+				var seq int
+				fmt.Sscanf(base, "segment_%05d.m4s", &seq)
+
+				if !started[f] {
+					started[f] = true
+					if sw.onSegmentStart != nil {
+						sw.onSegmentStart(seq, f)
+					}
+				}
+
 				// Check if file is complete (not being written)
 				info, err := os.Stat(f)
 				if err != nil || info.Size() == 0 {
 					continue
 				}
 
-				// Parse sequence number from filename
-				base := filepath.Base(f)
-				var seq int
-				fmt.Sscanf(base, "segment_%05d.m4s", &seq)
-
 				seen[f] = true
+				if sw.onSegment == nil {
+					continue
+				}
 				sw.onSegment(SegmentInfo{
 					Sequence:  seq,
 					Path:      f,
-					StartTime: startTime.Add(time.Duration(seq) * segmentDur),
+					StartTime: startTime.Add(time.Duration(seq-sw.firstSeq) * segmentDur),
 					Duration:  segmentDur,
 					Size:      info.Size(),
 				})
@@ -279,6 +694,30 @@ func (sw *SegmentWriter) watchSegments(ctx context.Context) {
 	}
 }
 
+// scanSegments returns the segment files already in dir and the sequence
+// the next run starts at. With append_list FFmpeg resumes numbering after
+// the playlist's last segment, so sequences stay continuous across restarts.
+func scanSegments(dir string) (map[string]bool, int) {
+	files, _ := filepath.Glob(filepath.Join(dir, "segment_*.m4s"))
+	existing := make(map[string]bool, len(files))
+	next := 0
+	for _, f := range files {
+		existing[f] = true
+		var seq int
+		if _, err := fmt.Sscanf(filepath.Base(f), "segment_%05d.m4s", &seq); err == nil && seq >= next {
+			next = seq + 1
+		}
+	}
+	return existing, next
+}
+
+// NextSegment returns the sequence a writer starting in dir numbers its
+// first segment with, following on from segments an earlier run left
+func NextSegment(dir string) int {
+	_, next := scanSegments(dir)
+	return next
+}
+
 // GenerateSegmentsFromFile generates segments from a video file (useful for testing)
 func (f *FFmpeg) GenerateSegmentsFromFile(ctx context.Context, inputPath, outputDir string, segmentDur float64) error {
 	sw := f.NewSegmentWriter(SegmentConfig{
@@ -302,6 +741,28 @@ func (f *FFmpeg) ConcatSegments(ctx context.Context, initPath string, segments [
 	if len(segments) == 0 {
 		return fmt.Errorf("no segments to concatenate")
 	}
+	return f.concatSegments(ctx, initPath, len(segments), func(i int) ([]byte, error) {
+		data, err := os.ReadFile(segments[i])
+		if err != nil {
+			return nil, fmt.Errorf("read segment %s: %w", segments[i], err)
+		}
+		return data, nil
+	}, outputPath)
+}
+
+// ConcatSegmentData is ConcatSegments for media segments held in memory
+func (f *FFmpeg) ConcatSegmentData(ctx context.Context, initPath string, segments [][]byte, outputPath string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("no segments to concatenate")
+	}
+	return f.concatSegments(ctx, initPath, len(segments), func(i int) ([]byte, error) {
+		return segments[i], nil
+	}, outputPath)
+}
+
+// concatSegments writes the init segment and n media segments read with
+// segment to one fMP4 file and remuxes it to outputPath
+func (f *FFmpeg) concatSegments(ctx context.Context, initPath string, n int, segment func(i int) ([]byte, error), outputPath string) error {
 
 	// Create combined fMP4 file by concatenating init.mp4 + media segments
 	tmpCombined, err := os.CreateTemp("", "combined_*.mp4")
@@ -320,10 +781,10 @@ func (f *FFmpeg) ConcatSegments(ctx context.Context, initPath string, segments [
 	}
 
 	// Append each media segment (contains moof + mdat fragments)
-	for _, seg := range segments {
-		segData, err := os.ReadFile(seg)
+	for i := 0; i < n; i++ {
+		segData, err := segment(i)
 		if err != nil {
-			return fmt.Errorf("read segment %s: %w", seg, err)
+			return err
 		}
 		if _, err := tmpCombined.Write(segData); err != nil {
 			return fmt.Errorf("write segment: %w", err)
@@ -367,6 +828,167 @@ func (f *FFmpeg) TrimClip(ctx context.Context, inputPath, outputPath string, sta
 	return nil
 }
 
+// TranscodeClip re-encodes a clip's video with the given FFmpeg encoder,
+// copying audio. Used for clip exports in a different codec than the buffer.
+func (f *FFmpeg) TranscodeClip(ctx context.Context, inputPath, outputPath, encoder string, bitrate int) error {
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-c:v", encoder,
+	}
+	if bitrate > 0 {
+		args = append(args, "-b:v", fmt.Sprintf("%dk", bitrate))
+	}
+	args = append(args,
+		"-c:a", "copy",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg transcode: %w\noutput: %s", err, output)
+	}
+
+	return nil
+}
+
+// CropFilter builds a crop filter for an aspect ratio such as "9:16".
+// The anchor picks which part of the frame is kept: center (default),
+// left, right, top, bottom, or a 0-1 fraction along the cropped axis.
+func CropFilter(aspect, anchor string) (string, error) {
+	var w, h int
+	if n, _ := fmt.Sscanf(aspect, "%d:%d", &w, &h); n != 2 || w <= 0 || h <= 0 {
+		return "", fmt.Errorf("invalid aspect ratio: %s", aspect)
+	}
+
+	pos := "0.5"
+	switch anchor {
+	case "", "center":
+	case "left", "top":
+		pos = "0"
+	case "right", "bottom":
+		pos = "1"
+	default:
+		f, err := strconv.ParseFloat(anchor, 64)
+		if err != nil || f < 0 || f > 1 {
+			return "", fmt.Errorf("invalid crop anchor: %s", anchor)
+		}
+		pos = anchor
+	}
+
+	// Keep dimensions even for 4:2:0 encoders; only one axis is ever cropped
+	// so the anchor applies to whichever has slack
+	return fmt.Sprintf("crop=w='trunc(min(iw,ih*%d/%d)/2)*2':h='trunc(min(ih,iw*%d/%d)/2)*2':x='(iw-ow)*%s':y='(ih-oh)*%s'",
+		w, h, h, w, pos, pos), nil
+}
+
+// CropClip re-encodes a clip cropped to a different aspect ratio
+func (f *FFmpeg) CropClip(ctx context.Context, inputPath, outputPath, aspect, anchor, encoder string, bitrate int) error {
+	filter, err := CropFilter(aspect, anchor)
+	if err != nil {
+		return err
+	}
+	if encoder == "" {
+		encoder = "libx264"
+	}
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-vf", filter,
+		"-c:v", encoder,
+	}
+	if bitrate > 0 {
+		args = append(args, "-b:v", fmt.Sprintf("%dk", bitrate))
+	}
+	args = append(args,
+		"-c:a", "copy",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg crop: %w\noutput: %s", err, output)
+	}
+
+	return nil
+}
+
+// Slow-motion playback rate limits. atempo handles 0.5-2 per stage, so
+// slower rates chain several stages.
+const (
+	MinSlowMotionRate = 0.1
+	MaxSlowMotionRate = 1.0
+)
+
+// SlowMotionFilters builds the video and audio filters that play a clip at
+// rate (0.5 = half speed). With interpolate, minterpolate synthesizes
+// in-between frames so the output keeps the source frame rate instead of
+// repeating frames.
+func SlowMotionFilters(rate, fps float64, interpolate bool) (video, audio string, err error) {
+	if rate < MinSlowMotionRate || rate >= MaxSlowMotionRate {
+		return "", "", fmt.Errorf("slow-motion rate must be at least %g and below %g, got %g", MinSlowMotionRate, MaxSlowMotionRate, rate)
+	}
+
+	video = fmt.Sprintf("setpts=%.4g*PTS", 1/rate)
+	if interpolate {
+		if fps <= 0 {
+			return "", "", fmt.Errorf("frame interpolation needs the source frame rate")
+		}
+		video += fmt.Sprintf(",minterpolate=fps=%.4g:mi_mode=mci:mc_mode=aobmc:vsbmc=1", fps)
+	}
+
+	var stages []string
+	r := rate
+	for r < 0.5 {
+		stages = append(stages, "atempo=0.5")
+		r /= 0.5
+	}
+	stages = append(stages, fmt.Sprintf("atempo=%.4g", r))
+	return video, strings.Join(stages, ","), nil
+}
+
+// SlowMotionClip re-encodes a clip to play at rate, optionally interpolating
+// frames. fps is the source frame rate, needed only for interpolation. Set
+// audio false for clips without an audio track.
+func (f *FFmpeg) SlowMotionClip(ctx context.Context, inputPath, outputPath string, rate, fps float64, interpolate, audio bool, encoder string, bitrate int) error {
+	videoFilter, audioFilter, err := SlowMotionFilters(rate, fps, interpolate)
+	if err != nil {
+		return err
+	}
+	if encoder == "" {
+		encoder = "libx264"
+	}
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-vf", videoFilter,
+		"-c:v", encoder,
+	}
+	if bitrate > 0 {
+		args = append(args, "-b:v", fmt.Sprintf("%dk", bitrate))
+	}
+	if audio {
+		args = append(args, "-af", audioFilter, "-c:a", "aac")
+	} else {
+		args = append(args, "-an")
+	}
+	args = append(args,
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg slow motion: %w\noutput: %s", err, output)
+	}
+
+	return nil
+}
+
 // GenerateThumbnail generates a thumbnail from a video
 func (f *FFmpeg) GenerateThumbnail(ctx context.Context, inputPath, outputPath string, atSecond float64) error {
 	args := []string{
@@ -406,6 +1028,36 @@ func (f *FFmpeg) ListInputDevices(ctx context.Context) (string, error) {
 	return string(output), nil
 }
 
+// Stats returns the latest encoder progress counters
+func (sw *SegmentWriter) Stats() EncoderStats {
+	sw.statsMu.RLock()
+	defer sw.statsMu.RUnlock()
+	return sw.stats
+}
+
+// StderrTail returns recent FFmpeg stderr output: the last log lines and
+// the latest progress line
+func (sw *SegmentWriter) StderrTail() []string {
+	sw.statsMu.RLock()
+	defer sw.statsMu.RUnlock()
+	lines := append([]string(nil), sw.stderr...)
+	if sw.progress != "" {
+		lines = append(lines, sw.progress)
+	}
+	return lines
+}
+
+// scanLinesCR is bufio.ScanLines that also splits on a bare \r
+func scanLinesCR(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
 // GetError returns the last error encountered by the segment writer
 func (sw *SegmentWriter) GetError() error {
 	sw.errMutex.RLock()
@@ -416,6 +1068,10 @@ func (sw *SegmentWriter) GetError() error {
 // setError sets the last error
 func (sw *SegmentWriter) setError(err error) {
 	sw.errMutex.Lock()
-	defer sw.errMutex.Unlock()
 	sw.lastErr = err
+	sw.errMutex.Unlock()
+
+	if sw.onError != nil {
+		sw.onError(err)
+	}
 }
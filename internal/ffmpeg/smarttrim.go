@@ -0,0 +1,158 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// trimEpsilon is the gap (seconds) below which a cut counts as on a keyframe
+const trimEpsilon = 0.001
+
+// trimPart is one piece of a smart trim: a keyframe-aligned middle that is
+// stream copied, or a boundary that is re-encoded
+type trimPart struct {
+	start, end float64
+	copy       bool
+}
+
+// Keyframes returns the video keyframe times of a file in seconds from its
+// start, in order
+func (f *FFmpeg) Keyframes(ctx context.Context, path string) ([]float64, error) {
+	args := []string{
+		"-v", "quiet",
+		"-select_streams", "v:0",
+		"-show_entries", "packet=pts_time,flags:format=start_time",
+		"-print_format", "json",
+		path,
+	}
+	output, err := exec.CommandContext(ctx, f.probePath, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe keyframes: %w", err)
+	}
+	return parseKeyframes(output)
+}
+
+// parseKeyframes reads keyframe times from ffprobe's packet listing
+func parseKeyframes(output []byte) ([]float64, error) {
+	var probe struct {
+		Packets []struct {
+			PTSTime string `json:"pts_time"`
+			Flags   string `json:"flags"`
+		} `json:"packets"`
+		Format struct {
+			StartTime string `json:"start_time"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+	origin, _ := strconv.ParseFloat(probe.Format.StartTime, 64)
+
+	var keyframes []float64
+	for _, p := range probe.Packets {
+		if !strings.Contains(p.Flags, "K") {
+			continue
+		}
+		t, err := strconv.ParseFloat(p.PTSTime, 64)
+		if err != nil {
+			continue
+		}
+		keyframes = append(keyframes, t-origin)
+	}
+	sort.Float64s(keyframes)
+	return keyframes, nil
+}
+
+// smartTrimPlan splits [start, end) at the first and last keyframes inside
+// it. Without a whole GOP inside, the range is re-encoded in one piece.
+func smartTrimPlan(keyframes []float64, start, end float64) []trimPart {
+	first, last := -1.0, -1.0
+	for _, k := range keyframes {
+		if first < 0 && k >= start-trimEpsilon {
+			first = k
+		}
+		if k <= end+trimEpsilon {
+			last = k
+		}
+	}
+	if first < 0 || last-first < trimEpsilon {
+		return []trimPart{{start: start, end: end}}
+	}
+
+	var parts []trimPart
+	if first-start > trimEpsilon {
+		parts = append(parts, trimPart{start: start, end: first})
+	}
+	parts = append(parts, trimPart{start: first, end: last, copy: true})
+	if end-last > trimEpsilon {
+		parts = append(parts, trimPart{start: last, end: end})
+	}
+	return parts
+}
+
+// SmartTrimClip cuts a frame-accurate range from a file while re-encoding
+// only the partial GOPs at either end with encoder; whole GOPs in between
+// are stream copied. Audio is copied throughout. The encoder must produce
+// the input's codec so the pieces can be joined.
+func (f *FFmpeg) SmartTrimClip(ctx context.Context, inputPath, outputPath string, startSec, durationSec float64, encoder string, bitrate int) error {
+	keyframes, err := f.Keyframes(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	parts := smartTrimPlan(keyframes, startSec, startSec+durationSec)
+	if len(parts) == 1 {
+		return f.trimPart(ctx, inputPath, outputPath, parts[0], encoder, bitrate)
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(outputPath), "trim-*")
+	if err != nil {
+		return fmt.Errorf("create trim dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	paths := make([]string, len(parts))
+	for i, part := range parts {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("part%d.mp4", i))
+		if err := f.trimPart(ctx, inputPath, paths[i], part, encoder, bitrate); err != nil {
+			return err
+		}
+	}
+	return f.JoinClips(ctx, paths, outputPath)
+}
+
+// trimPart writes one piece of a smart trim
+func (f *FFmpeg) trimPart(ctx context.Context, inputPath, outputPath string, part trimPart, encoder string, bitrate int) error {
+	args := []string{
+		"-y",
+		"-ss", fmt.Sprintf("%.6f", part.start),
+		"-i", inputPath,
+		"-t", fmt.Sprintf("%.6f", part.end-part.start),
+	}
+	if part.copy {
+		args = append(args, "-c", "copy")
+	} else {
+		args = append(args, "-c:v", encoder)
+		if bitrate > 0 {
+			args = append(args, "-b:v", fmt.Sprintf("%dk", bitrate))
+		}
+		args = append(args, "-c:a", "copy")
+	}
+	args = append(args,
+		"-avoid_negative_ts", "make_zero",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, f.binaryPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg smart trim: %w\noutput: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package ffmpeg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseKeyframes(t *testing.T) {
+	output := []byte(`{
+    "packets": [
+        {"pts_time": "10.000000", "flags": "K__"},
+        {"pts_time": "10.033333", "flags": "___"},
+        {"pts_time": "12.000000", "flags": "K__"}
+    ],
+    "format": {"start_time": "10.000000"}
+}`)
+	keyframes, err := parseKeyframes(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(keyframes, []float64{0, 2}) {
+		t.Errorf("keyframes = %v, want [0 2]", keyframes)
+	}
+}
+
+func TestSmartTrimPlan(t *testing.T) {
+	keyframes := []float64{0, 2, 4, 6, 8}
+	tests := []struct {
+		name       string
+		start, end float64
+		want       []trimPart
+	}{
+		{"both boundaries", 1.5, 6.5, []trimPart{{1.5, 2, false}, {2, 6, true}, {6, 6.5, false}}},
+		{"on keyframes", 2, 6, []trimPart{{2, 6, true}}},
+		{"inside one GOP", 2.5, 3.5, []trimPart{{2.5, 3.5, false}}},
+		{"one keyframe inside", 1.5, 2.5, []trimPart{{1.5, 2.5, false}}},
+	}
+	for _, tt := range tests {
+		if got := smartTrimPlan(keyframes, tt.start, tt.end); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: plan = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SegmentTail reads a segment file while FFmpeg is still writing it.
+// Reads block at EOF until more data arrives, and the tail ends once the
+// following segment file exists (FFmpeg has moved on) or the context ends.
+type SegmentTail struct {
+	ctx      context.Context
+	file     *os.File
+	nextPath string
+	poll     time.Duration
+	finished bool
+}
+
+// TailSegment opens a growing segment for streaming reads
+func TailSegment(ctx context.Context, path string, seq int) (*SegmentTail, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open segment: %w", err)
+	}
+	return &SegmentTail{
+		ctx:      ctx,
+		file:     file,
+		nextPath: filepath.Join(filepath.Dir(path), fmt.Sprintf("segment_%05d.m4s", seq+1)),
+		poll:     20 * time.Millisecond,
+	}, nil
+}
+
+// Read implements io.Reader, waiting for data at the end of the file
+func (t *SegmentTail) Read(p []byte) (int, error) {
+	for {
+		n, err := t.file.Read(p)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if t.finished {
+			return 0, io.EOF
+		}
+
+		// The segment is complete once FFmpeg opens the next one;
+		// loop once more to drain anything written before that
+		if _, err := os.Stat(t.nextPath); err == nil {
+			t.finished = true
+			continue
+		}
+
+		select {
+		case <-t.ctx.Done():
+			return 0, t.ctx.Err()
+		case <-time.After(t.poll):
+		}
+	}
+}
+
+// Close closes the underlying file
+func (t *SegmentTail) Close() error {
+	return t.file.Close()
+}
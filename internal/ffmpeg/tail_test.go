@@ -0,0 +1,59 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSegmentTail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "segment_00004.m4s")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString("moof")
+
+	tail, err := TailSegment(context.Background(), path, 4)
+	if err != nil {
+		t.Fatalf("TailSegment: %v", err)
+	}
+	defer tail.Close()
+
+	// The tail follows the file until FFmpeg opens the next segment
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		f.WriteString("mdat")
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(filepath.Join(dir, "segment_00005.m4s"), nil, 0644)
+	}()
+	data, err := io.ReadAll(tail)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(data) != "moofmdat" {
+		t.Errorf("read %q, want the whole segment", data)
+	}
+
+	// A cancelled context ends a tail that never finishes
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	stuck, err := TailSegment(ctx, filepath.Join(dir, "segment_00005.m4s"), 5)
+	if err != nil {
+		t.Fatalf("TailSegment: %v", err)
+	}
+	defer stuck.Close()
+	if _, err := io.ReadAll(stuck); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("read = %v, want deadline exceeded", err)
+	}
+
+	if _, err := TailSegment(context.Background(), filepath.Join(dir, "missing.m4s"), 9); err == nil {
+		t.Error("expected an error for a missing segment")
+	}
+}
@@ -0,0 +1,27 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TestPatterns are the lavfi video sources a test source can show
+var TestPatterns = []string{"smptehdbars", "smptebars", "pal100bars", "testsrc2", "testsrc", "rgbtestsrc"}
+
+// TestSource returns a lavfi graph for -f lavfi -i: the pattern at size
+// (WxH) and rate, with a 1 kHz tone. Read it with -re, lavfi runs as fast
+// as it can otherwise.
+func TestSource(pattern, size string, rate int) (string, error) {
+	if !slices.Contains(TestPatterns, pattern) {
+		return "", fmt.Errorf("unknown test pattern %q (want %s)", pattern, strings.Join(TestPatterns, ", "))
+	}
+	var w, h int
+	if n, err := fmt.Sscanf(size, "%dx%d", &w, &h); err != nil || n != 2 || w <= 0 || h <= 0 {
+		return "", fmt.Errorf("invalid test source size %q (want WxH)", size)
+	}
+	if rate <= 0 {
+		return "", fmt.Errorf("invalid test source rate %d", rate)
+	}
+	return fmt.Sprintf("%s=size=%dx%d:rate=%d[out0];sine=frequency=1000:sample_rate=48000[out1]", pattern, w, h, rate), nil
+}
@@ -0,0 +1,27 @@
+package ffmpeg
+
+import "testing"
+
+func TestTestSource(t *testing.T) {
+	got, err := TestSource("smptehdbars", "1280x720", 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "smptehdbars=size=1280x720:rate=50[out0];sine=frequency=1000:sample_rate=48000[out1]"
+	if got != want {
+		t.Errorf("TestSource = %q, want %q", got, want)
+	}
+
+	for _, tc := range []struct {
+		pattern, size string
+		rate          int
+	}{
+		{"mandelbrot", "1280x720", 30},
+		{"testsrc2", "720p", 30},
+		{"testsrc2", "1280x720", 0},
+	} {
+		if _, err := TestSource(tc.pattern, tc.size, tc.rate); err == nil {
+			t.Errorf("TestSource(%q, %q, %d) succeeded", tc.pattern, tc.size, tc.rate)
+		}
+	}
+}
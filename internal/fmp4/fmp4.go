@@ -0,0 +1,260 @@
+package fmp4
+
+import (
+	"encoding/binary"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+)
+
+// VideoTrack describes the single H.264 or H.265 track carried in CMAF
+// segments
+type VideoTrack struct {
+	Codec     string // h264 (default) or hevc
+	Width     int
+	Height    int
+	Timescale uint32 // Ticks per second for sample durations
+	VPS       []byte // Video parameter set, hevc only (without start code or length prefix)
+	SPS       []byte // Sequence parameter set (without start code or length prefix)
+	PPS       []byte // Picture parameter set (without start code or length prefix)
+}
+
+// Sample is a single encoded access unit in AVCC (length-prefixed) form
+type Sample struct {
+	Data              []byte
+	Duration          uint32 // In track timescale ticks
+	CompositionOffset int32  // PTS - DTS in ticks (non-zero with B-frames)
+	Keyframe          bool
+}
+
+const trackID = 1
+
+// InitSegment builds the ftyp+moov init segment for the track
+func InitSegment(t VideoTrack) []byte {
+	entry := avc1(t)
+	if t.Codec == h26x.H265 {
+		entry = hvc1(t)
+	}
+	ftyp := box("ftyp",
+		[]byte("cmfc"), u32(0),
+		[]byte("iso6"), []byte("cmfc"), entry[4:8], []byte("mp41"),
+	)
+
+	mvhd := fullBox("mvhd", 0, 0,
+		u32(0), u32(0), // creation/modification time
+		u32(t.Timescale), u32(0), // timescale, duration
+		u32(0x00010000), u16(0x0100), u16(0), // rate, volume, reserved
+		u32(0), u32(0), // reserved
+		matrix(),
+		make([]byte, 24), // pre_defined
+		u32(trackID+1),   // next_track_ID
+	)
+
+	tkhd := fullBox("tkhd", 0, 0x000003, // enabled, in movie
+		u32(0), u32(0), // creation/modification time
+		u32(trackID), u32(0), // track_ID, reserved
+		u32(0),         // duration
+		u32(0), u32(0), // reserved
+		u16(0), u16(0), // layer, alternate_group
+		u16(0), u16(0), // volume, reserved
+		matrix(),
+		u32(uint32(t.Width)<<16), u32(uint32(t.Height)<<16),
+	)
+
+	mdhd := fullBox("mdhd", 0, 0,
+		u32(0), u32(0),
+		u32(t.Timescale), u32(0),
+		u16(0x55c4), u16(0), // language "und", pre_defined
+	)
+
+	hdlr := fullBox("hdlr", 0, 0,
+		u32(0), []byte("vide"),
+		u32(0), u32(0), u32(0),
+		[]byte("VideoHandler\x00"),
+	)
+
+	vmhd := fullBox("vmhd", 0, 1, u16(0), u16(0), u16(0), u16(0))
+	dinf := box("dinf", fullBox("dref", 0, 0, u32(1), fullBox("url ", 0, 1)))
+
+	stbl := box("stbl",
+		fullBox("stsd", 0, 0, u32(1), entry),
+		fullBox("stts", 0, 0, u32(0)),
+		fullBox("stsc", 0, 0, u32(0)),
+		fullBox("stsz", 0, 0, u32(0), u32(0)),
+		fullBox("stco", 0, 0, u32(0)),
+	)
+
+	trak := box("trak",
+		tkhd,
+		box("mdia", mdhd, hdlr, box("minf", vmhd, dinf, stbl)),
+	)
+
+	mvex := box("mvex", fullBox("trex", 0, 0,
+		u32(trackID), u32(1), // track_ID, default_sample_description_index
+		u32(0), u32(0), u32(0), // default duration, size, flags
+	))
+
+	return concat(ftyp, box("moov", mvhd, trak, mvex))
+}
+
+// MediaSegment builds a styp+moof+mdat fragment from samples.
+// baseDecodeTime is the decode time of the first sample in track ticks.
+func MediaSegment(sequence uint32, baseDecodeTime uint64, samples []Sample) []byte {
+	return concat(SegmentType(), Chunk(sequence, baseDecodeTime, samples))
+}
+
+// SegmentType builds the styp box that starts a media segment
+func SegmentType() []byte {
+	return box("styp", []byte("msdh"), u32(0), []byte("msdh"), []byte("msix"), []byte("cmfs"))
+}
+
+// Chunk builds a moof+mdat fragment from samples, one of the chunks a
+// low-latency segment is written in
+func Chunk(sequence uint32, baseDecodeTime uint64, samples []Sample) []byte {
+	// trun flags: data-offset, duration, size, flags, composition offset
+	const trunFlags = 0x000001 | 0x000100 | 0x000200 | 0x000400 | 0x000800
+	entries := make([][]byte, 0, len(samples)*4)
+	mdatSize := 0
+	for _, s := range samples {
+		flags := uint32(0x01010000) // depends on others, non-sync sample
+		if s.Keyframe {
+			flags = 0x02000000 // sample_depends_on = 2 (I-frame)
+		}
+		entries = append(entries,
+			u32(s.Duration), u32(uint32(len(s.Data))), u32(flags), u32(uint32(s.CompositionOffset)))
+		mdatSize += len(s.Data)
+	}
+
+	build := func(dataOffset uint32) []byte {
+		trun := fullBox("trun", 1, trunFlags,
+			append([][]byte{u32(uint32(len(samples))), u32(dataOffset)}, entries...)...)
+		traf := box("traf",
+			fullBox("tfhd", 0, 0x020000, u32(trackID)), // default-base-is-moof
+			fullBox("tfdt", 1, 0, u64(baseDecodeTime)),
+			trun,
+		)
+		return box("moof", fullBox("mfhd", 0, 0, u32(sequence)), traf)
+	}
+
+	// data_offset is relative to the start of moof and points past the mdat header
+	moof := build(0)
+	moof = build(uint32(len(moof) + 8))
+
+	mdat := make([]byte, 8, 8+mdatSize)
+	binary.BigEndian.PutUint32(mdat, uint32(8+mdatSize))
+	copy(mdat[4:], "mdat")
+	for _, s := range samples {
+		mdat = append(mdat, s.Data...)
+	}
+
+	return concat(moof, mdat)
+}
+
+// avc1 builds the visual sample entry with its avcC configuration
+func avc1(t VideoTrack) []byte {
+	var profile, compat, level byte
+	if len(t.SPS) >= 4 {
+		profile, compat, level = t.SPS[1], t.SPS[2], t.SPS[3]
+	}
+
+	avcC := box("avcC",
+		[]byte{1, profile, compat, level, 0xff, 0xe1}, // version, profile, 4-byte lengths, 1 SPS
+		u16(uint16(len(t.SPS))), t.SPS,
+		[]byte{1}, u16(uint16(len(t.PPS))), t.PPS,
+	)
+
+	return visualEntry("avc1", t, avcC)
+}
+
+// hvc1 builds the visual sample entry with its hvcC configuration. The
+// parameter sets are all in hvcC, as hvc1 (unlike hev1) requires.
+func hvc1(t VideoTrack) []byte {
+	sps, _ := h26x.ParseH265SPS(t.SPS)
+	if sps.SubLayers == 0 {
+		sps.ChromaFormat, sps.BitDepthLuma, sps.BitDepthChroma, sps.SubLayers = 1, 8, 8, 1
+	}
+	nested := byte(0)
+	if sps.TemporalNested {
+		nested = 1
+	}
+
+	arrays := [][]byte{{3}} // numOfArrays
+	for _, ps := range []struct {
+		typ  byte
+		nalu []byte
+	}{{h26x.H265NALVPS, t.VPS}, {h26x.H265NALSPS, t.SPS}, {h26x.H265NALPPS, t.PPS}} {
+		arrays = append(arrays,
+			[]byte{0x80 | ps.typ}, u16(1), // array_completeness, NAL type, one NAL unit
+			u16(uint16(len(ps.nalu))), ps.nalu)
+	}
+
+	hvcC := box("hvcC",
+		[]byte{1}, sps.ProfileTierLevel[:],
+		u16(0xf000),  // min_spatial_segmentation_idc
+		[]byte{0xfc}, // parallelismType
+		[]byte{0xfc | byte(sps.ChromaFormat), 0xf8 | byte(sps.BitDepthLuma-8), 0xf8 | byte(sps.BitDepthChroma-8)},
+		u16(0), // avgFrameRate
+		[]byte{byte(sps.SubLayers)<<3 | nested<<2 | 3}, // 4-byte lengths
+		concat(arrays...),
+	)
+
+	return visualEntry("hvc1", t, hvcC)
+}
+
+// visualEntry builds a visual sample entry around a codec configuration box
+func visualEntry(typ string, t VideoTrack, config []byte) []byte {
+	return box(typ,
+		make([]byte, 6), u16(1), // reserved, data_reference_index
+		make([]byte, 16), // pre_defined, reserved
+		u16(uint16(t.Width)), u16(uint16(t.Height)),
+		u32(0x00480000), u32(0x00480000), // 72 dpi
+		u32(0), u16(1), // reserved, frame_count
+		make([]byte, 32),         // compressorname
+		u16(0x0018), u16(0xffff), // depth, pre_defined
+		config,
+	)
+}
+
+func box(typ string, payload ...[]byte) []byte {
+	body := concat(payload...)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+	copy(b[4:], typ)
+	return append(b, body...)
+}
+
+func fullBox(typ string, version byte, flags uint32, payload ...[]byte) []byte {
+	header := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	return box(typ, append([][]byte{header}, payload...)...)
+}
+
+func matrix() []byte {
+	return concat(
+		u32(0x00010000), u32(0), u32(0),
+		u32(0), u32(0x00010000), u32(0),
+		u32(0), u32(0), u32(0x40000000),
+	)
+}
+
+func concat(parts ...[]byte) []byte {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	out := make([]byte, 0, n)
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func u16(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+func u32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func u64(v uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, v)
+}
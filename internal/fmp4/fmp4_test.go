@@ -0,0 +1,141 @@
+package fmp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// topLevelBoxes splits a buffer into its top-level box types and payloads
+func topLevelBoxes(t *testing.T, data []byte) ([]string, [][]byte) {
+	t.Helper()
+	var types []string
+	var bodies [][]byte
+	for len(data) > 0 {
+		if len(data) < 8 {
+			t.Fatalf("truncated box header: %d bytes left", len(data))
+		}
+		size := int(binary.BigEndian.Uint32(data))
+		if size < 8 || size > len(data) {
+			t.Fatalf("invalid box size %d (have %d)", size, len(data))
+		}
+		types = append(types, string(data[4:8]))
+		bodies = append(bodies, data[8:size])
+		data = data[size:]
+	}
+	return types, bodies
+}
+
+func TestInitSegment(t *testing.T) {
+	init := InitSegment(VideoTrack{
+		Width:     1920,
+		Height:    1080,
+		Timescale: 90000,
+		SPS:       []byte{0x67, 0x64, 0x00, 0x28, 0xac},
+		PPS:       []byte{0x68, 0xee, 0x3c, 0x80},
+	})
+
+	types, _ := topLevelBoxes(t, init)
+	if len(types) != 2 || types[0] != "ftyp" || types[1] != "moov" {
+		t.Fatalf("unexpected boxes: %v", types)
+	}
+	if !bytes.Contains(init, []byte("avcC")) || !bytes.Contains(init, []byte("trex")) {
+		t.Error("init segment missing avcC or trex")
+	}
+}
+
+func TestInitSegmentHEVC(t *testing.T) {
+	vps := []byte{0x40, 0x01, 0x0c}
+	pps := []byte{0x44, 0x01, 0xc1}
+	init := InitSegment(VideoTrack{Codec: "hevc", Width: 1920, Height: 1080, Timescale: 90000,
+		VPS: vps, SPS: []byte{0x42, 0x01}, PPS: pps})
+
+	types, bodies := topLevelBoxes(t, init)
+	if len(types) != 2 || !bytes.Contains(bodies[0], []byte("hvc1")) {
+		t.Fatalf("ftyp doesn't list hvc1: %q", bodies[0])
+	}
+	hvcC := bytes.Index(init, []byte("hvcC"))
+	if hvcC < 0 || !bytes.Contains(init[hvcC:], vps) || !bytes.Contains(init[hvcC:], pps) {
+		t.Fatal("hvcC missing or without parameter sets")
+	}
+	if bytes.Contains(init, []byte("avcC")) {
+		t.Error("hevc init segment has avcC")
+	}
+}
+
+func TestMediaSegmentDataOffset(t *testing.T) {
+	samples := []Sample{
+		{Data: []byte{0, 0, 0, 2, 0x65, 0x01}, Duration: 3000, Keyframe: true},
+		{Data: []byte{0, 0, 0, 2, 0x41, 0x02}, Duration: 3000},
+	}
+	seg := MediaSegment(7, 90000, samples)
+
+	types, bodies := topLevelBoxes(t, seg)
+	if len(types) != 3 || types[0] != "styp" || types[1] != "moof" || types[2] != "mdat" {
+		t.Fatalf("unexpected boxes: %v", types)
+	}
+
+	// Locate trun data_offset: it follows the fullbox header and sample_count
+	moofStart := 8 + len(bodies[0]) + 8
+	trun := bytes.Index(seg, []byte("trun"))
+	if trun < 0 {
+		t.Fatal("trun not found")
+	}
+	offset := int(binary.BigEndian.Uint32(seg[trun+12:]))
+
+	got := seg[moofStart-8+offset:]
+	if !bytes.HasPrefix(got, samples[0].Data) {
+		t.Errorf("data_offset %d does not point at first sample", offset)
+	}
+	if !bytes.Equal(bodies[2], append(samples[0].Data, samples[1].Data...)) {
+		t.Error("mdat payload mismatch")
+	}
+}
+
+func TestReadRoundTrip(t *testing.T) {
+	init := InitSegment(VideoTrack{Width: 1280, Height: 720, Timescale: 90000, SPS: []byte{0x67}, PPS: []byte{0x68}})
+	tracks, err := ReadTracks(bytes.NewReader(init))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 || tracks[0] != (TrackInfo{ID: 1, Handler: "vide", Timescale: 90000}) {
+		t.Fatalf("unexpected tracks: %+v", tracks)
+	}
+
+	seg := MediaSegment(3, 180000, []Sample{{Data: []byte{0, 0, 0, 1, 0x65}, Duration: 3000, Keyframe: true}})
+	times, err := ReadDecodeTimes(bytes.NewReader(seg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if times[1] != 180000 {
+		t.Errorf("decode time = %d, want 180000", times[1])
+	}
+}
+
+func TestFragments(t *testing.T) {
+	key := Sample{Data: []byte{0, 0, 0, 1, 0x65}, Duration: 3000, Keyframe: true}
+	delta := Sample{Data: []byte{0, 0, 0, 1, 0x41}, Duration: 3000}
+	first := MediaSegment(1, 0, []Sample{key, delta, delta})
+	second := MediaSegment(2, 9000, []Sample{delta, delta})
+	data := append(append([]byte(nil), first...), second...)
+
+	// The second fragment is still being written
+	frags := Fragments(data[:len(data)-2])
+	if len(frags) != 1 {
+		t.Fatalf("got %d fragments, want 1", len(frags))
+	}
+
+	frags = Fragments(data)
+	if len(frags) != 2 {
+		t.Fatalf("got %d fragments, want 2", len(frags))
+	}
+	if frags[0].Offset != 0 || frags[0].Size != len(first) || frags[1].Offset != len(first) || frags[1].Size != len(second) {
+		t.Errorf("unexpected ranges: %+v", frags)
+	}
+	if frags[0].Durations[1] != 9000 || frags[1].Durations[1] != 6000 {
+		t.Errorf("durations = %d, %d, want 9000, 6000", frags[0].Durations[1], frags[1].Durations[1])
+	}
+	if !frags[0].Independent[1] || frags[1].Independent[1] {
+		t.Errorf("independent = %v, %v, want true, false", frags[0].Independent[1], frags[1].Independent[1])
+	}
+}
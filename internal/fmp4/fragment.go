@@ -0,0 +1,148 @@
+package fmp4
+
+import (
+	"encoding/binary"
+)
+
+// Fragment is one moof+mdat chunk of a media segment, with any boxes
+// (e.g. styp) written before its moof
+type Fragment struct {
+	Offset int // Byte offset in the segment
+	Size   int
+
+	// Durations sums the sample durations of each track in its timescale,
+	// keyed by track ID
+	Durations map[uint32]uint64
+
+	// Independent is set for tracks whose first sample is a sync sample
+	Independent map[uint32]bool
+}
+
+// Fragments lists the complete fragments at the start of data, which may be
+// a segment still being written; a trailing partial fragment is ignored
+func Fragments(data []byte) []Fragment {
+	var frags []Fragment
+	start, pos := 0, 0
+	var moof []byte
+	for len(data)-pos >= 8 {
+		size := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		headerLen := 8
+		if size == 1 {
+			if len(data)-pos < 16 {
+				break
+			}
+			size = int(binary.BigEndian.Uint64(data[pos+8:]))
+			headerLen = 16
+		}
+		if size < headerLen || size > len(data)-pos {
+			break // Incomplete, or extends to the end of a file still being written
+		}
+		switch typ {
+		case "moof":
+			moof = data[pos+headerLen : pos+size]
+		case "mdat":
+			if moof != nil {
+				f := Fragment{Offset: start, Size: pos + size - start}
+				f.Durations, f.Independent = readMoof(moof)
+				frags = append(frags, f)
+				moof = nil
+				start = pos + size
+			}
+		}
+		pos += size
+	}
+	return frags
+}
+
+// readMoof sums each track's sample durations in a moof and checks whether
+// its first sample is a sync sample
+func readMoof(moof []byte) (map[uint32]uint64, map[uint32]bool) {
+	durations := make(map[uint32]uint64)
+	independent := make(map[uint32]bool)
+	for _, traf := range children(moof, "traf") {
+		tfhd := child(traf, "tfhd")
+		if len(tfhd) < 8 {
+			continue
+		}
+		flags := binary.BigEndian.Uint32(tfhd) & 0xffffff
+		id := binary.BigEndian.Uint32(tfhd[4:])
+
+		// Optional tfhd fields, in order
+		pos := 8
+		var defaultDuration, defaultFlags uint32
+		hasDefaultFlags := false
+		if flags&0x01 != 0 { // base-data-offset
+			pos += 8
+		}
+		if flags&0x02 != 0 { // sample-description-index
+			pos += 4
+		}
+		if flags&0x08 != 0 && len(tfhd) >= pos+4 {
+			defaultDuration = binary.BigEndian.Uint32(tfhd[pos:])
+			pos += 4
+		}
+		if flags&0x10 != 0 { // default-sample-size
+			pos += 4
+		}
+		if flags&0x20 != 0 && len(tfhd) >= pos+4 {
+			defaultFlags = binary.BigEndian.Uint32(tfhd[pos:])
+			hasDefaultFlags = true
+		}
+
+		first := true
+		for _, trun := range children(traf, "trun") {
+			if len(trun) < 8 {
+				continue
+			}
+			tflags := binary.BigEndian.Uint32(trun) & 0xffffff
+			count := int(binary.BigEndian.Uint32(trun[4:]))
+			pos := 8
+			if tflags&0x01 != 0 { // data-offset
+				pos += 4
+			}
+			var firstFlags uint32
+			hasFirstFlags := false
+			if tflags&0x04 != 0 && len(trun) >= pos+4 {
+				firstFlags = binary.BigEndian.Uint32(trun[pos:])
+				hasFirstFlags = true
+				pos += 4
+			}
+			entry := 0
+			for _, bit := range []uint32{0x100, 0x200, 0x400, 0x800} {
+				if tflags&bit != 0 {
+					entry += 4
+				}
+			}
+			for i := 0; i < count; i++ {
+				if len(trun) < pos+entry {
+					break
+				}
+				e := trun[pos:]
+				pos += entry
+				duration := defaultDuration
+				if tflags&0x100 != 0 {
+					duration = binary.BigEndian.Uint32(e)
+					e = e[4:]
+				}
+				durations[id] += uint64(duration)
+				if !first {
+					continue
+				}
+				first = false
+				if tflags&0x200 != 0 {
+					e = e[4:]
+				}
+				sampleFlags, known := defaultFlags, hasDefaultFlags
+				if hasFirstFlags {
+					sampleFlags, known = firstFlags, true
+				} else if tflags&0x400 != 0 {
+					sampleFlags, known = binary.BigEndian.Uint32(e), true
+				}
+				// sample_is_non_sync_sample
+				independent[id] = known && sampleFlags&0x00010000 == 0
+			}
+		}
+	}
+	return durations, independent
+}
@@ -0,0 +1,168 @@
+package fmp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// TrackInfo describes a track declared in an init segment
+type TrackInfo struct {
+	ID        uint32
+	Handler   string // "vide", "soun", ...
+	Timescale uint32
+}
+
+// ReadTracks lists the tracks in an init segment's moov box
+func ReadTracks(r io.ReadSeeker) ([]TrackInfo, error) {
+	moov, err := findTopLevel(r, "moov")
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []TrackInfo
+	for _, trak := range children(moov, "trak") {
+		var t TrackInfo
+		if tkhd := child(trak, "tkhd"); len(tkhd) >= 24 {
+			if tkhd[0] == 1 {
+				t.ID = binary.BigEndian.Uint32(tkhd[20:])
+			} else {
+				t.ID = binary.BigEndian.Uint32(tkhd[12:])
+			}
+		}
+		mdia := child(trak, "mdia")
+		if mdhd := child(mdia, "mdhd"); len(mdhd) >= 24 {
+			if mdhd[0] == 1 {
+				t.Timescale = binary.BigEndian.Uint32(mdhd[20:])
+			} else {
+				t.Timescale = binary.BigEndian.Uint32(mdhd[12:])
+			}
+		}
+		if hdlr := child(mdia, "hdlr"); len(hdlr) >= 12 {
+			t.Handler = string(hdlr[8:12])
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, nil
+}
+
+// ReadDecodeTimes returns the base media decode time (tfdt) of the first
+// fragment of each track in a media segment, keyed by track ID. Only moof
+// boxes are read; mdat payloads are skipped.
+func ReadDecodeTimes(r io.ReadSeeker) (map[uint32]uint64, error) {
+	times := make(map[uint32]uint64)
+	for {
+		typ, payload, err := nextBox(r, "moof")
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if typ != "moof" {
+			continue
+		}
+		for _, traf := range children(payload, "traf") {
+			tfhd := child(traf, "tfhd")
+			tfdt := child(traf, "tfdt")
+			if len(tfhd) < 8 || len(tfdt) < 8 {
+				continue
+			}
+			id := binary.BigEndian.Uint32(tfhd[4:])
+			if _, seen := times[id]; seen {
+				continue
+			}
+			if tfdt[0] == 1 && len(tfdt) >= 12 {
+				times[id] = binary.BigEndian.Uint64(tfdt[4:])
+			} else {
+				times[id] = uint64(binary.BigEndian.Uint32(tfdt[4:]))
+			}
+		}
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no tfdt found")
+	}
+	return times, nil
+}
+
+// findTopLevel returns the payload of the first top-level box of a type
+func findTopLevel(r io.ReadSeeker, want string) ([]byte, error) {
+	for {
+		typ, payload, err := nextBox(r, want)
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s box not found", want)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if typ == want {
+			return payload, nil
+		}
+	}
+}
+
+// nextBox reads the next box header, returning the payload only for boxes
+// of type load and seeking past all others
+func nextBox(r io.ReadSeeker, load string) (string, []byte, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(r, hdr[:8]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return "", nil, err
+	}
+	size := uint64(binary.BigEndian.Uint32(hdr[:4]))
+	typ := string(hdr[4:8])
+	headerLen := uint64(8)
+	switch size {
+	case 0:
+		// Box extends to the end of the file
+		if typ != load {
+			return typ, nil, io.EOF
+		}
+		payload, err := io.ReadAll(r)
+		return typ, payload, err
+	case 1:
+		if _, err := io.ReadFull(r, hdr[8:16]); err != nil {
+			return "", nil, err
+		}
+		size = binary.BigEndian.Uint64(hdr[8:16])
+		headerLen = 16
+	}
+	if size < headerLen {
+		return "", nil, fmt.Errorf("invalid %s box size %d", typ, size)
+	}
+
+	if typ != load {
+		_, err := r.Seek(int64(size-headerLen), io.SeekCurrent)
+		return typ, nil, err
+	}
+	payload := make([]byte, size-headerLen)
+	_, err := io.ReadFull(r, payload)
+	return typ, payload, err
+}
+
+// children returns the payloads of the boxes of a type directly inside buf
+func children(buf []byte, want string) [][]byte {
+	var out [][]byte
+	for len(buf) >= 8 {
+		size := int(binary.BigEndian.Uint32(buf))
+		typ := string(buf[4:8])
+		if size < 8 || size > len(buf) {
+			break
+		}
+		if typ == want {
+			out = append(out, buf[8:size])
+		}
+		buf = buf[size:]
+	}
+	return out
+}
+
+// child returns the payload of the first box of a type directly inside buf
+func child(buf []byte, want string) []byte {
+	if c := children(buf, want); len(c) > 0 {
+		return c[0]
+	}
+	return nil
+}
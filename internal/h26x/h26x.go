@@ -0,0 +1,362 @@
+// Package h26x reads the parts of H.264 and H.265 bitstreams needed to mux
+// them without decoding: NAL unit types, keyframes and the picture size
+// and profile in sequence parameter sets.
+package h26x
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Codecs
+const (
+	H264 = "h264"
+	H265 = "hevc"
+)
+
+// NAL unit types
+const (
+	H264NALIDR = 5
+	H264NALSPS = 7
+	H264NALPPS = 8
+	H264NALAUD = 9
+
+	H265NALVPS = 32
+	H265NALSPS = 33
+	H265NALPPS = 34
+	H265NALAUD = 35
+)
+
+// NALType returns the type of a NAL unit, from its header
+func NALType(codec string, nalu []byte) int {
+	if len(nalu) == 0 {
+		return -1
+	}
+	if codec == H265 {
+		return int(nalu[0]>>1) & 0x3f
+	}
+	return int(nalu[0]) & 0x1f
+}
+
+// IsKeyframe reports whether an access unit starts a GOP: an IDR picture in
+// H.264, any IRAP picture (BLA, IDR, CRA) in H.265
+func IsKeyframe(codec string, nalus [][]byte) bool {
+	for _, n := range nalus {
+		t := NALType(codec, n)
+		if codec == H265 && t >= 16 && t <= 21 || codec != H265 && t == H264NALIDR {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitAnnexB splits a byte stream into NAL units at 3- and 4-byte start
+// codes
+func SplitAnnexB(b []byte) [][]byte {
+	var nalus [][]byte
+	start := -1
+	for i := 0; i+2 < len(b); i++ {
+		if b[i] != 0 || b[i+1] != 0 || b[i+2] != 1 {
+			continue
+		}
+		if start >= 0 {
+			end := i
+			if end > start && b[end-1] == 0 {
+				end-- // 4-byte start code
+			}
+			if end > start {
+				nalus = append(nalus, b[start:end])
+			}
+		}
+		start = i + 3
+		i += 2
+	}
+	if start >= 0 && start < len(b) {
+		nalus = append(nalus, b[start:])
+	}
+	return nalus
+}
+
+// AVCC joins NAL units with 4-byte big-endian length prefixes, the form
+// MP4 samples carry them in
+func AVCC(nalus [][]byte) []byte {
+	n := 0
+	for _, nalu := range nalus {
+		n += 4 + len(nalu)
+	}
+	out := make([]byte, 0, n)
+	for _, nalu := range nalus {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(nalu)))
+		out = append(out, nalu...)
+	}
+	return out
+}
+
+// unescape removes emulation prevention bytes (00 00 03) from a NAL unit
+func unescape(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	zeros := 0
+	for _, c := range b {
+		if zeros >= 2 && c == 3 {
+			zeros = 0
+			continue
+		}
+		if c == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+var errShortSPS = errors.New("sps truncated")
+
+// bitReader reads the bit fields and Exp-Golomb codes of a parameter set
+type bitReader struct {
+	buf []byte
+	pos int // In bits
+	err error
+}
+
+func (r *bitReader) bits(n int) uint32 {
+	var v uint32
+	for range n {
+		if r.pos >= len(r.buf)*8 {
+			r.err = errShortSPS
+			return 0
+		}
+		v = v<<1 | uint32(r.buf[r.pos/8]>>(7-r.pos%8))&1
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) flag() bool {
+	return r.bits(1) == 1
+}
+
+// ue reads an unsigned Exp-Golomb code
+func (r *bitReader) ue() uint32 {
+	zeros := 0
+	for !r.flag() {
+		if r.err != nil || zeros > 31 {
+			r.err = errShortSPS
+			return 0
+		}
+		zeros++
+	}
+	return 1<<zeros - 1 + r.bits(zeros)
+}
+
+// se reads a signed Exp-Golomb code
+func (r *bitReader) se() int32 {
+	v := r.ue()
+	if v&1 == 1 {
+		return int32(v/2 + 1)
+	}
+	return -int32(v / 2)
+}
+
+// H264Size returns the picture size an H.264 sequence parameter set
+// describes, after cropping
+func H264Size(sps []byte) (width, height int, err error) {
+	if len(sps) < 4 {
+		return 0, 0, errShortSPS
+	}
+	r := &bitReader{buf: unescape(sps[1:])}
+	profile := r.bits(8)
+	r.bits(16) // constraint flags, level
+	r.ue()     // seq_parameter_set_id
+
+	chroma := uint32(1)
+	separatePlanes := false
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		if chroma = r.ue(); chroma == 3 {
+			separatePlanes = r.flag()
+		}
+		r.ue() // bit_depth_luma_minus8
+		r.ue() // bit_depth_chroma_minus8
+		r.flag()
+		if r.flag() { // seq_scaling_matrix_present_flag
+			lists := 8
+			if chroma == 3 {
+				lists = 12
+			}
+			for i := range lists {
+				if !r.flag() {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				last, next := int32(8), int32(8)
+				for range size {
+					if next != 0 {
+						next = (last + r.se() + 256) % 256
+					}
+					if next != 0 {
+						last = next
+					}
+				}
+			}
+		}
+	}
+
+	r.ue()          // log2_max_frame_num_minus4
+	switch r.ue() { // pic_order_cnt_type
+	case 0:
+		r.ue()
+	case 1:
+		r.flag()
+		r.se()
+		r.se()
+		for range r.ue() {
+			r.se()
+		}
+	}
+	r.ue()   // max_num_ref_frames
+	r.flag() // gaps_in_frame_num_value_allowed_flag
+	mbWidth := int(r.ue()) + 1
+	mapHeight := int(r.ue()) + 1
+	frameMBsOnly := r.flag()
+	if !frameMBsOnly {
+		r.flag() // mb_adaptive_frame_field_flag
+	}
+	r.flag() // direct_8x8_inference_flag
+
+	fieldFactor := 2
+	if frameMBsOnly {
+		fieldFactor = 1
+	}
+	width, height = mbWidth*16, mapHeight*16*fieldFactor
+	if r.flag() { // frame_cropping_flag
+		left, right, top, bottom := int(r.ue()), int(r.ue()), int(r.ue()), int(r.ue())
+		cropX, cropY := 1, fieldFactor
+		if chroma != 0 && !separatePlanes {
+			if chroma != 3 {
+				cropX = 2
+			}
+			if chroma == 1 {
+				cropY *= 2
+			}
+		}
+		width -= cropX * (left + right)
+		height -= cropY * (top + bottom)
+	}
+	if r.err != nil {
+		return 0, 0, r.err
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("sps: invalid size %dx%d", width, height)
+	}
+	return width, height, nil
+}
+
+// Size returns the picture size a sequence parameter set describes
+func Size(codec string, sps []byte) (width, height int, err error) {
+	if codec != H265 {
+		return H264Size(sps)
+	}
+	s, err := ParseH265SPS(sps)
+	return s.Width, s.Height, err
+}
+
+// H265SPS is what muxing needs from an H.265 sequence parameter set
+type H265SPS struct {
+	Width, Height  int
+	ChromaFormat   int
+	BitDepthLuma   int
+	BitDepthChroma int
+	SubLayers      int
+	TemporalNested bool
+
+	// General profile_tier_level: profile space, tier and profile, the
+	// compatibility and constraint flags and the level, as they appear in
+	// the SPS and in hvcC
+	ProfileTierLevel [12]byte
+}
+
+// ParseH265SPS reads an H.265 sequence parameter set
+func ParseH265SPS(sps []byte) (H265SPS, error) {
+	var s H265SPS
+	if len(sps) < 16 {
+		return s, errShortSPS
+	}
+	buf := unescape(sps[2:])
+	r := &bitReader{buf: buf}
+	r.bits(4) // sps_video_parameter_set_id
+	maxSubLayers := int(r.bits(3))
+	s.SubLayers = maxSubLayers + 1
+	s.TemporalNested = r.flag()
+
+	copy(s.ProfileTierLevel[:], buf[1:13])
+	r.bits(96)
+	profilePresent := make([]bool, maxSubLayers)
+	levelPresent := make([]bool, maxSubLayers)
+	for i := range maxSubLayers {
+		profilePresent[i] = r.flag()
+		levelPresent[i] = r.flag()
+	}
+	if maxSubLayers > 0 {
+		for range 8 - maxSubLayers {
+			r.bits(2)
+		}
+	}
+	for i := range maxSubLayers {
+		if profilePresent[i] {
+			r.bits(88)
+		}
+		if levelPresent[i] {
+			r.bits(8)
+		}
+	}
+
+	r.ue() // sps_seq_parameter_set_id
+	s.ChromaFormat = int(r.ue())
+	if s.ChromaFormat == 3 && r.flag() { // separate_colour_plane_flag
+		s.ChromaFormat = 0
+	}
+	s.Width, s.Height = int(r.ue()), int(r.ue())
+	if r.flag() { // conformance_window_flag
+		left, right, top, bottom := int(r.ue()), int(r.ue()), int(r.ue()), int(r.ue())
+		subX, subY := 1, 1
+		switch s.ChromaFormat {
+		case 1:
+			subX, subY = 2, 2
+		case 2:
+			subX = 2
+		}
+		s.Width -= subX * (left + right)
+		s.Height -= subY * (top + bottom)
+	}
+	s.BitDepthLuma = int(r.ue()) + 8
+	s.BitDepthChroma = int(r.ue()) + 8
+	if r.err != nil {
+		return s, r.err
+	}
+	if s.Width <= 0 || s.Height <= 0 {
+		return s, fmt.Errorf("sps: invalid size %dx%d", s.Width, s.Height)
+	}
+	return s, nil
+}
+
+// ParamSets returns the parameter sets among an access unit's NAL units,
+// nil for those it doesn't carry; there is no VPS in H.264
+func ParamSets(codec string, nalus [][]byte) (vps, sps, pps []byte) {
+	for _, n := range nalus {
+		switch t := NALType(codec, n); {
+		case codec == H265 && t == H265NALVPS:
+			vps = n
+		case codec == H265 && t == H265NALSPS, codec != H265 && t == H264NALSPS:
+			sps = n
+		case codec == H265 && t == H265NALPPS, codec != H265 && t == H264NALPPS:
+			pps = n
+		}
+	}
+	return vps, sps, pps
+}
@@ -0,0 +1,127 @@
+package h26x
+
+import (
+	"bytes"
+	"testing"
+)
+
+// bitWriter builds parameter sets for the tests
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *bitWriter) bits(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(v>>i&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+func (w *bitWriter) ue(v uint32) {
+	v++
+	n := 0
+	for x := v; x > 1; x >>= 1 {
+		n++
+	}
+	w.bits(0, n)
+	w.bits(v, n+1)
+}
+
+func TestH264Size(t *testing.T) {
+	w := &bitWriter{}
+	w.bits(0x67, 8) // NAL header
+	w.bits(66, 8)   // baseline
+	w.bits(0, 8)
+	w.bits(40, 8)
+	w.ue(0)      // sps id
+	w.ue(0)      // log2_max_frame_num_minus4
+	w.ue(2)      // pic_order_cnt_type
+	w.ue(1)      // max_num_ref_frames
+	w.bits(0, 1) // gaps
+	w.ue(119)    // 120 MBs wide
+	w.ue(67)     // 68 MBs high
+	w.bits(1, 1) // frame_mbs_only
+	w.bits(1, 1) // direct_8x8
+	w.bits(1, 1) // cropping
+	w.ue(0)
+	w.ue(0)
+	w.ue(0)
+	w.ue(4) // 8 lines off the bottom
+	w.bits(1, 1)
+
+	width, height, err := H264Size(w.buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 1920 || height != 1080 {
+		t.Errorf("size = %dx%d, want 1920x1080", width, height)
+	}
+	if _, _, err := H264Size(w.buf[:6]); err == nil {
+		t.Error("truncated sps parsed")
+	}
+}
+
+func TestParseH265SPS(t *testing.T) {
+	w := &bitWriter{}
+	w.bits(0x4201, 16) // NAL header, type 33
+	w.bits(0, 4)       // vps id
+	w.bits(0, 3)       // one sub-layer
+	w.bits(1, 1)       // temporal id nesting
+	w.bits(0x01, 8)    // main profile
+	w.bits(0x60000000, 32)
+	w.bits(0x9000, 16)
+	w.bits(0, 32)
+	w.bits(120, 8) // level 4
+	w.ue(0)        // sps id
+	w.ue(1)        // 4:2:0
+	w.ue(1920)
+	w.ue(1088)
+	w.bits(1, 1) // conformance window
+	w.ue(0)
+	w.ue(0)
+	w.ue(0)
+	w.ue(4)
+	w.ue(0) // 8-bit
+	w.ue(0)
+	w.bits(1, 1)
+
+	sps, err := ParseH265SPS(w.buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sps.Width != 1920 || sps.Height != 1080 || sps.ChromaFormat != 1 || sps.BitDepthLuma != 8 {
+		t.Errorf("sps = %+v", sps)
+	}
+	if sps.ProfileTierLevel[0] != 0x01 || sps.ProfileTierLevel[11] != 120 || !sps.TemporalNested {
+		t.Errorf("profile_tier_level = %x", sps.ProfileTierLevel)
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	got := unescape([]byte{1, 0, 0, 3, 1, 0, 0, 3, 0, 0, 3})
+	want := []byte{1, 0, 0, 1, 0, 0, 0, 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unescape = %v, want %v", got, want)
+	}
+}
+
+func TestSplitAnnexB(t *testing.T) {
+	stream := []byte{0, 0, 0, 1, 0x67, 1, 2, 0, 0, 1, 0x68, 3, 0, 0, 0, 1, 0x65, 4, 5}
+	nalus := SplitAnnexB(stream)
+	if len(nalus) != 3 {
+		t.Fatalf("got %d NAL units, want 3", len(nalus))
+	}
+	if !bytes.Equal(nalus[0], []byte{0x67, 1, 2}) || !bytes.Equal(nalus[1], []byte{0x68, 3}) || !bytes.Equal(nalus[2], []byte{0x65, 4, 5}) {
+		t.Errorf("nalus = %x", nalus)
+	}
+	if !IsKeyframe(H264, nalus) || IsKeyframe(H264, nalus[:2]) {
+		t.Error("keyframe detection wrong")
+	}
+	if avcc := AVCC(nalus[1:2]); !bytes.Equal(avcc, []byte{0, 0, 0, 2, 0x68, 3}) {
+		t.Errorf("avcc = %x", avcc)
+	}
+}
@@ -0,0 +1,48 @@
+package hlscrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	k, err := NewKeyring(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("not a real segment, but long enough to span blocks")
+	enc, err := k.Encrypt(25, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enc)%aes.BlockSize != 0 {
+		t.Fatalf("ciphertext not block aligned: %d", len(enc))
+	}
+
+	block, _ := aes.NewCipher(k.Key(2))
+	dec := make([]byte, len(enc))
+	cipher.NewCBCDecrypter(block, IV(25)).CryptBlocks(dec, enc)
+	dec = dec[:len(dec)-int(dec[len(dec)-1])]
+	if !bytes.Equal(dec, data) {
+		t.Errorf("decrypted = %q, want %q", dec, data)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	k, err := NewKeyring(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.KeyIndex(29) != 0 || k.KeyIndex(30) != 1 {
+		t.Errorf("unexpected key indexes: %d, %d", k.KeyIndex(29), k.KeyIndex(30))
+	}
+	if bytes.Equal(k.Key(0), k.Key(1)) {
+		t.Error("rotated keys should differ")
+	}
+	if !bytes.Equal(k.Key(1), k.Key(1)) {
+		t.Error("key derivation should be deterministic")
+	}
+}
@@ -0,0 +1,270 @@
+// Package logging wraps log/slog with per-module levels.
+//
+// Packages create their logger once with For("module"); Setup can run
+// later (after the config is loaded) and still changes the output format
+// and levels of every existing logger.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Config configures log output
+type Config struct {
+	Level   string            // debug, info, warn, error (default: info)
+	Format  string            // text (default), json
+	Modules map[string]string // Per-module level overrides, e.g. ffmpeg: debug
+	File    FileConfig        // Also write to a rotating file when File.Path is set
+}
+
+// recentLines is how many log records Recent keeps
+const recentLines = 2000
+
+var (
+	mu           sync.RWMutex
+	recent                    = &ringWriter{lines: make([][]byte, recentLines)}
+	base         slog.Handler = newHandler(os.Stderr, "text")
+	defaultLevel              = new(slog.LevelVar)
+	moduleLevels              = make(map[string]*slog.LevelVar)
+	overridden                = make(map[string]bool)
+	logFile      *RotatingFile
+)
+
+// Setup applies the log configuration, writing to stderr and the log file
+// if one is configured
+func Setup(cfg Config) error {
+	if cfg.File.Path == "" {
+		return SetupWriter(os.Stderr, cfg)
+	}
+
+	f, err := OpenRotatingFile(cfg.File)
+	if err != nil {
+		return err
+	}
+	if err := SetupWriter(io.MultiWriter(os.Stderr, f), cfg); err != nil {
+		f.Close()
+		return err
+	}
+
+	mu.Lock()
+	prev := logFile
+	logFile = f
+	mu.Unlock()
+	if prev != nil {
+		prev.Close()
+	}
+	return nil
+}
+
+// Close closes the log file opened by Setup, if any
+func Close() error {
+	mu.Lock()
+	f := logFile
+	logFile = nil
+	mu.Unlock()
+	if f == nil {
+		return nil
+	}
+	return f.Close()
+}
+
+// SetupWriter is Setup with a custom output
+func SetupWriter(w io.Writer, cfg Config) error {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	switch cfg.Format {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("unknown log format: %s", cfg.Format)
+	}
+
+	if err := apply(w, cfg, level); err != nil {
+		return err
+	}
+	slog.SetDefault(For("main"))
+	return nil
+}
+
+// SetHandler sends every module logger to h instead of stderr, for programs
+// that embed the agent and have their own logging. Module levels still
+// apply, and Recent keeps working. Log files set up by Setup are not closed.
+func SetHandler(h slog.Handler) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	mu.Lock()
+	base = teeHandler{h, slog.NewTextHandler(recent, opts)}
+	mu.Unlock()
+}
+
+// apply swaps the base handler and updates module levels
+func apply(w io.Writer, cfg Config, level slog.Level) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	base = newHandler(w, cfg.Format)
+	defaultLevel.Set(level)
+
+	// Reset previous overrides, then apply the new ones
+	for module := range overridden {
+		moduleLevels[module].Set(level)
+	}
+	clear(overridden)
+	for module, name := range cfg.Modules {
+		l, err := ParseLevel(name)
+		if err != nil {
+			return fmt.Errorf("module %s: %w", module, err)
+		}
+		levelVar(module).Set(l)
+		overridden[module] = true
+	}
+	for module, v := range moduleLevels {
+		if !overridden[module] {
+			v.Set(level)
+		}
+	}
+	return nil
+}
+
+// For returns the logger for a module
+func For(module string) *slog.Logger {
+	mu.Lock()
+	v := levelVar(module)
+	mu.Unlock()
+	return slog.New(&moduleHandler{module: module, level: v})
+}
+
+// Recent returns the most recent log records, oldest first, in the
+// configured format
+func Recent() []byte {
+	return recent.bytes()
+}
+
+// ParseLevel parses a level name (empty = info)
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return 0, fmt.Errorf("unknown log level: %s", name)
+	}
+	return l, nil
+}
+
+// levelVar returns the level for a module, creating it at the default level.
+// Callers hold mu.
+func levelVar(module string) *slog.LevelVar {
+	v, ok := moduleLevels[module]
+	if !ok {
+		v = new(slog.LevelVar)
+		v.Set(defaultLevel.Level())
+		moduleLevels[module] = v
+	}
+	return v
+}
+
+func newHandler(w io.Writer, format string) slog.Handler {
+	// Level filtering happens per module, so the base handler accepts everything
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	w = io.MultiWriter(w, recent)
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// moduleHandler filters by module level and forwards to the current base
+// handler, replaying any With/WithGroup calls made on the logger
+type moduleHandler struct {
+	module string
+	level  *slog.LevelVar
+	ops    []func(slog.Handler) slog.Handler
+}
+
+func (h *moduleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	mu.RLock()
+	handler := base
+	mu.RUnlock()
+
+	handler = handler.WithAttrs([]slog.Attr{slog.String("module", h.module)})
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *moduleHandler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)
+	return &moduleHandler{module: h.module, level: h.level, ops: ops}
+}
+
+// teeHandler sends records to two handlers
+type teeHandler [2]slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return t[0].Enabled(ctx, level) || t[1].Enabled(ctx, level)
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			err = errors.Join(err, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return err
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return teeHandler{t[0].WithAttrs(attrs), t[1].WithAttrs(attrs)}
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	return teeHandler{t[0].WithGroup(name), t[1].WithGroup(name)}
+}
+
+// ringWriter keeps the last records written to it. slog handlers write one
+// record per call.
+type ringWriter struct {
+	mu    sync.Mutex
+	lines [][]byte
+	next  int
+}
+
+func (r *ringWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	r.lines[r.next] = append(r.lines[r.next][:0], p...)
+	r.next = (r.next + 1) % len(r.lines)
+	r.mu.Unlock()
+	return len(p), nil
+}
+
+func (r *ringWriter) bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []byte
+	for i := range r.lines {
+		out = append(out, r.lines[(r.next+i)%len(r.lines)]...)
+	}
+	return out
+}
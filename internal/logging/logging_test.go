@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestModuleLevels(t *testing.T) {
+	api := For("api")
+	ff := For("ffmpeg").With("channel", "cam1")
+
+	var buf bytes.Buffer
+	if err := SetupWriter(&buf, Config{Level: "warn", Format: "json", Modules: map[string]string{"ffmpeg": "debug"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	api.Info("hidden")
+	ff.Debug("shown", "play_id", "p1")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("api info should be filtered at warn: %s", out)
+	}
+	for _, want := range []string{`"msg":"shown"`, `"module":"ffmpeg"`, `"channel":"cam1"`, `"play_id":"p1"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %s: %s", want, out)
+		}
+	}
+	if !strings.HasSuffix(string(Recent()), out) {
+		t.Errorf("Recent() missing latest records: %s", Recent())
+	}
+}
+
+func TestSetupRejectsBadLevel(t *testing.T) {
+	if err := SetupWriter(&bytes.Buffer{}, Config{Level: "loud"}); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
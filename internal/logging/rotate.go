@@ -0,0 +1,223 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedFormat is the timestamp added to rotated file names; it sorts
+// chronologically
+const rotatedFormat = "20060102T150405.000"
+
+// FileConfig configures log file output
+type FileConfig struct {
+	Path        string
+	MaxSizeMB   int           // Rotate when the file reaches this size (default: 100)
+	RotateEvery time.Duration // Also rotate after this long, e.g. 24h (0 = size only)
+	MaxAge      time.Duration // Delete rotated files older than this (0 = keep)
+	MaxBackups  int           // Keep at most this many rotated files (0 = keep all)
+	Compress    bool          // Gzip rotated files
+}
+
+// RotatingFile is an io.Writer that appends to a log file and rotates it by
+// size and age. Rotated files are renamed with a timestamp, then compressed
+// and pruned in the background.
+type RotatingFile struct {
+	cfg FileConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	// Compression and pruning run one at a time so a prune never removes a
+	// file that is still being compressed
+	bgMu sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// OpenRotatingFile opens (or creates) the log file for appending
+func OpenRotatingFile(cfg FileConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("log file path is required")
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = 100
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+		return nil, fmt.Errorf("create log dir: %w", err)
+	}
+
+	r := &RotatingFile{cfg: cfg}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write appends p, rotating first if the file is too large or too old
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	tooBig := r.size > 0 && r.size+int64(len(p)) > int64(r.cfg.MaxSizeMB)<<20
+	tooOld := r.cfg.RotateEvery > 0 && time.Since(r.opened) >= r.cfg.RotateEvery
+	if tooBig || tooOld {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and starts a new one
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+// Close closes the file and waits for background compression to finish
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	r.opened = time.Now()
+	return nil
+}
+
+// rotate renames the current file aside and opens a new one. Callers hold mu.
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+
+	ext := filepath.Ext(r.cfg.Path)
+	rotated := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(r.cfg.Path, ext), time.Now().Format(rotatedFormat), ext)
+	if err := os.Rename(r.cfg.Path, rotated); err != nil && !os.IsNotExist(err) {
+		// Keep logging to the existing file rather than losing output
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.bgMu.Lock()
+		defer r.bgMu.Unlock()
+		if r.cfg.Compress {
+			if err := compressFile(rotated); err != nil {
+				fmt.Fprintf(os.Stderr, "logging: compress %s: %v\n", rotated, err)
+			}
+		}
+		r.prune()
+	}()
+	return nil
+}
+
+// prune deletes rotated files beyond MaxBackups or older than MaxAge
+func (r *RotatingFile) prune() {
+	if r.cfg.MaxBackups <= 0 && r.cfg.MaxAge <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(r.cfg.Path)
+	prefix := filepath.Base(strings.TrimSuffix(r.cfg.Path, ext)) + "-"
+	dir := filepath.Dir(r.cfg.Path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, prefix) && (strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+".gz")) {
+			backups = append(backups, name)
+		}
+	}
+	// Newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, name := range backups {
+		path := filepath.Join(dir, name)
+		expired := false
+		if r.cfg.MaxAge > 0 {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > r.cfg.MaxAge {
+				expired = true
+			}
+		}
+		if expired || (r.cfg.MaxBackups > 0 && i >= r.cfg.MaxBackups) {
+			os.Remove(path)
+		}
+	}
+}
+
+// compressFile gzips path to path.gz and removes the original
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "capture.log")
+	f, err := OpenRotatingFile(FileConfig{Path: path, MaxSizeMB: 1, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := bytes.Repeat([]byte("x"), 1023)
+	line = append(line, '\n')
+	// Three rotations; rotated names are timestamped to the millisecond
+	for i := 0; i < 4*1024; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+		if i%1024 == 1023 {
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var gz, plain int
+	for _, e := range entries {
+		switch {
+		case e.Name() == "capture.log":
+			plain++
+		case strings.HasPrefix(e.Name(), "capture-") && strings.HasSuffix(e.Name(), ".log.gz"):
+			gz++
+		default:
+			t.Errorf("unexpected file %s", e.Name())
+		}
+	}
+	if plain != 1 || gz != 2 {
+		t.Errorf("got %d current and %d compressed backups, want 1 and 2", plain, gz)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() > 1<<20 {
+		t.Errorf("current file too large or missing: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package manifest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// MPD describes a live DASH presentation with a single video+audio
+// representation addressed by $Number$ with a SegmentTimeline
+type MPD struct {
+	AvailabilityStart time.Time     // Presentation anchor; segment times are relative to it
+	PublishTime       time.Time     // Defaults to now
+	BufferDepth       time.Duration // timeShiftBufferDepth
+	MinUpdatePeriod   time.Duration // Defaults to the longest segment duration
+	Segments          []Segment     // In sequence order
+	InitURI           string        // e.g. init.mp4
+	MediaTemplate     string        // e.g. segment_$Number%05d$.m4s
+	Bandwidth         int           // bits per second
+	Codecs            string
+	Width             int
+	Height            int
+	Ended             bool // Static MPD (no further updates)
+}
+
+type mpdXML struct {
+	XMLName                   xml.Name  `xml:"MPD"`
+	Xmlns                     string    `xml:"xmlns,attr"`
+	Profiles                  string    `xml:"profiles,attr"`
+	Type                      string    `xml:"type,attr"`
+	MinBufferTime             string    `xml:"minBufferTime,attr"`
+	AvailabilityStartTime     string    `xml:"availabilityStartTime,attr,omitempty"`
+	PublishTime               string    `xml:"publishTime,attr,omitempty"`
+	MinimumUpdatePeriod       string    `xml:"minimumUpdatePeriod,attr,omitempty"`
+	TimeShiftBufferDepth      string    `xml:"timeShiftBufferDepth,attr,omitempty"`
+	MediaPresentationDuration string    `xml:"mediaPresentationDuration,attr,omitempty"`
+	Period                    periodXML `xml:"Period"`
+}
+
+type periodXML struct {
+	ID            string           `xml:"id,attr"`
+	Start         string           `xml:"start,attr"`
+	AdaptationSet adaptationSetXML `xml:"AdaptationSet"`
+}
+
+type adaptationSetXML struct {
+	MimeType         string             `xml:"mimeType,attr"`
+	SegmentAlignment bool               `xml:"segmentAlignment,attr"`
+	StartWithSAP     int                `xml:"startWithSAP,attr"`
+	Template         segmentTemplateXML `xml:"SegmentTemplate"`
+	Representation   representationXML  `xml:"Representation"`
+}
+
+type segmentTemplateXML struct {
+	Timescale      int    `xml:"timescale,attr"`
+	Initialization string `xml:"initialization,attr"`
+	Media          string `xml:"media,attr"`
+	StartNumber    int    `xml:"startNumber,attr"`
+	Timeline       []sXML `xml:"SegmentTimeline>S"`
+}
+
+type sXML struct {
+	T int64 `xml:"t,attr,omitempty"`
+	D int64 `xml:"d,attr"`
+	R int   `xml:"r,attr,omitempty"`
+}
+
+type representationXML struct {
+	ID        string `xml:"id,attr"`
+	Bandwidth int    `xml:"bandwidth,attr"`
+	Codecs    string `xml:"codecs,attr,omitempty"`
+	Width     int    `xml:"width,attr,omitempty"`
+	Height    int    `xml:"height,attr,omitempty"`
+}
+
+// Encode renders the MPD as XML
+func (m *MPD) Encode() ([]byte, error) {
+	publish := m.PublishTime
+	if publish.IsZero() {
+		publish = time.Now()
+	}
+
+	doc := mpdXML{
+		Xmlns:         "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:      "urn:mpeg:dash:profile:isoff-live:2011",
+		Type:          "dynamic",
+		MinBufferTime: "PT2S",
+		Period: periodXML{
+			ID:    "0",
+			Start: "PT0S",
+			AdaptationSet: adaptationSetXML{
+				MimeType:         "video/mp4",
+				SegmentAlignment: true,
+				StartWithSAP:     1,
+				Template: segmentTemplateXML{
+					Timescale:      1000,
+					Initialization: m.InitURI,
+					Media:          m.MediaTemplate,
+				},
+				Representation: representationXML{
+					ID:        "0",
+					Bandwidth: m.Bandwidth,
+					Codecs:    m.Codecs,
+					Width:     m.Width,
+					Height:    m.Height,
+				},
+			},
+		},
+	}
+
+	// $Number$ addressing can't skip sequence numbers, so only the newest
+	// contiguous run of segments is listed
+	segments := m.Segments
+	for i := len(segments) - 1; i > 0; i-- {
+		if segments[i-1].Sequence+1 != segments[i].Sequence {
+			segments = segments[i:]
+			break
+		}
+	}
+
+	var longest, total time.Duration
+	for _, s := range segments {
+		longest = max(longest, s.Duration)
+		total += s.Duration
+	}
+
+	if m.Ended {
+		doc.Type = "static"
+		doc.MediaPresentationDuration = isoDuration(total)
+	} else {
+		update := m.MinUpdatePeriod
+		if update == 0 {
+			update = longest
+		}
+		doc.AvailabilityStartTime = m.AvailabilityStart.UTC().Format(time.RFC3339)
+		doc.PublishTime = publish.UTC().Format(time.RFC3339)
+		doc.MinimumUpdatePeriod = isoDuration(update)
+		if m.BufferDepth > 0 {
+			doc.TimeShiftBufferDepth = isoDuration(m.BufferDepth)
+		}
+	}
+
+	tmpl := &doc.Period.AdaptationSet.Template
+	if len(segments) > 0 {
+		tmpl.StartNumber = segments[0].Sequence
+	}
+	tmpl.Timeline = timeline(segments, m.AvailabilityStart)
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode mpd: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// timeline builds SegmentTimeline entries in milliseconds. Segments that
+// follow on from the previous one without an explicit time are merged
+// into runs of equal duration using the repeat count.
+func timeline(segments []Segment, anchor time.Time) []sXML {
+	var entries []sXML
+	var next int64 = -1
+	for _, s := range segments {
+		t := max(s.Start.Sub(anchor).Milliseconds(), 0)
+		d := s.Duration.Milliseconds()
+
+		// Allow 1ms of rounding drift before starting a new timed entry
+		if next >= 0 && t >= next-1 && t <= next+1 {
+			if last := &entries[len(entries)-1]; last.D == d {
+				last.R++
+			} else {
+				entries = append(entries, sXML{D: d})
+			}
+			next += d
+			continue
+		}
+		entries = append(entries, sXML{T: t, D: d})
+		next = t + d
+	}
+	return entries
+}
+
+// isoDuration formats a duration as an xs:duration (PT#.###S)
+func isoDuration(d time.Duration) string {
+	return fmt.Sprintf("PT%.3fS", d.Seconds())
+}
@@ -0,0 +1,258 @@
+// Package manifest generates HLS playlists and DASH MPDs from the ring
+// buffer index, so playback windows, timing and renditions don't depend on
+// the playlists FFmpeg writes.
+package manifest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Segment is a media segment listed in a manifest
+type Segment struct {
+	Sequence int
+	URI      string
+	Start    time.Time
+	Duration time.Duration
+	Parts    []Part // LL-HLS partial segments making up the segment
+	MapURI   string // Init segment when not the playlist's, e.g. from before an encode change
+}
+
+// Part is an LL-HLS partial segment
+type Part struct {
+	URI         string
+	Duration    time.Duration
+	Independent bool // Starts with a keyframe
+}
+
+// MediaPlaylist is an HLS media playlist
+type MediaPlaylist struct {
+	Segments        []Segment     // In sequence order
+	MapURI          string        // EXT-X-MAP init segment
+	Window          int           // Max segments listed, newest kept (0 = all)
+	ProgramDateTime bool          // Emit EXT-X-PROGRAM-DATE-TIME on each segment
+	Ended           bool          // Emit EXT-X-ENDLIST
+	ImagesOnly      bool          // Image media playlist (EXT-X-IMAGES-ONLY)
+	TargetDuration  time.Duration // Minimum target duration (raised to the longest segment)
+
+	// DiscontinuitySequence counts the discontinuities before Segments[0]
+	// (EXT-X-DISCONTINUITY-SEQUENCE). Those in segments cut by Window are
+	// added to it.
+	DiscontinuitySequence int
+
+	// KeyURI returns the EXT-X-KEY URI for a segment, or "" for clear segments.
+	// A new EXT-X-KEY tag is written whenever the URI changes. Segments are
+	// encrypted with their sequence number as IV, which players only assume
+	// while it matches the playlist position, so after a sequence gap each
+	// segment gets a tag with an explicit IV.
+	KeyURI func(seq int) string
+
+	// Low-Latency HLS. A non-zero PartTarget adds EXT-X-PART-INF and
+	// EXT-X-SERVER-CONTROL (blocking reload) and lists each segment's parts.
+	PartTarget  time.Duration // Minimum part target (raised to the longest part)
+	Partial     []Part        // Parts of the segment after the last one, still being written
+	PreloadHint string        // URI of the next part (EXT-X-PRELOAD-HINT)
+}
+
+// Encode renders the playlist
+func (p *MediaPlaylist) Encode() []byte {
+	segments := p.Segments
+	discontinuities := p.DiscontinuitySequence
+	if p.Window > 0 && len(segments) > p.Window {
+		cut := len(segments) - p.Window
+		for i := 1; i <= cut; i++ {
+			if p.discontinuous(segments[i-1], segments[i]) {
+				discontinuities++
+			}
+		}
+		segments = segments[cut:]
+	}
+
+	target := p.TargetDuration
+	partTarget := p.PartTarget
+	for _, s := range segments {
+		target = max(target, s.Duration)
+		for _, part := range s.Parts {
+			partTarget = max(partTarget, part.Duration)
+		}
+	}
+	for _, part := range p.Partial {
+		partTarget = max(partTarget, part.Duration)
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:7\n")
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(target.Seconds())))
+	if p.PartTarget > 0 {
+		// Players stay three parts behind the live edge
+		fmt.Fprintf(&b, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", 3*partTarget.Seconds())
+		fmt.Fprintf(&b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget.Seconds())
+	}
+	if len(segments) > 0 {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", segments[0].Sequence)
+	}
+	if discontinuities > 0 {
+		fmt.Fprintf(&b, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", discontinuities)
+	}
+	if p.ImagesOnly {
+		b.WriteString("#EXT-X-IMAGES-ONLY\n")
+	}
+	if p.MapURI != "" && len(segments) > 0 {
+		// Before any EXT-X-KEY, so the init segment is never encrypted
+		fmt.Fprintf(&b, "#EXT-X-MAP:URI=\"%s\"\n", p.mapURI(segments[0]))
+	}
+
+	lastKey := ""
+	for i, s := range segments {
+		if i > 0 && p.discontinuous(segments[i-1], s) {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if i > 0 && p.MapURI != "" && p.mapURI(s) != p.mapURI(segments[i-1]) {
+			if lastKey != "" {
+				// Clear the key so the init segment isn't taken as encrypted
+				b.WriteString("#EXT-X-KEY:METHOD=NONE\n")
+				lastKey = ""
+			}
+			fmt.Fprintf(&b, "#EXT-X-MAP:URI=\"%s\"\n", p.mapURI(s))
+		}
+
+		if p.KeyURI != nil {
+			// Players take the position, segments[0].Sequence + i, as IV. A
+			// gap only ever moves it further from the sequence number.
+			uri := p.KeyURI(s.Sequence)
+			explicitIV := uri != "" && s.Sequence != segments[0].Sequence+i
+			switch {
+			case explicitIV:
+				fmt.Fprintf(&b, "#EXT-X-KEY:METHOD=AES-128,URI=\"%s\",IV=0x%032X\n", uri, s.Sequence)
+			case uri == lastKey:
+			case uri == "":
+				b.WriteString("#EXT-X-KEY:METHOD=NONE\n")
+			default:
+				fmt.Fprintf(&b, "#EXT-X-KEY:METHOD=AES-128,URI=\"%s\"\n", uri)
+			}
+			lastKey = uri
+		}
+		if p.ProgramDateTime && !s.Start.IsZero() {
+			fmt.Fprintf(&b, "#EXT-X-PROGRAM-DATE-TIME:%s\n", s.Start.UTC().Format("2006-01-02T15:04:05.000Z"))
+		}
+		if p.PartTarget > 0 {
+			writeParts(&b, s.Parts)
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n", s.Duration.Seconds())
+		b.WriteString(s.URI + "\n")
+	}
+
+	if p.PartTarget > 0 && !p.Ended {
+		writeParts(&b, p.Partial)
+		if p.PreloadHint != "" {
+			fmt.Fprintf(&b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", p.PreloadHint)
+		}
+	}
+	if p.Ended {
+		b.WriteString("#EXT-X-ENDLIST\n")
+	}
+	return []byte(b.String())
+}
+
+// mapURI returns the init segment of s
+func (p *MediaPlaylist) mapURI(s Segment) string {
+	if s.MapURI != "" {
+		return s.MapURI
+	}
+	return p.MapURI
+}
+
+// discontinuous reports whether s doesn't follow prev seamlessly: numbers
+// were skipped or the init segment changed
+func (p *MediaPlaylist) discontinuous(prev, s Segment) bool {
+	return s.Sequence != prev.Sequence+1 || (p.MapURI != "" && p.mapURI(s) != p.mapURI(prev))
+}
+
+// writeParts writes EXT-X-PART tags
+func writeParts(b *strings.Builder, parts []Part) {
+	for _, part := range parts {
+		fmt.Fprintf(b, "#EXT-X-PART:DURATION=%.3f,URI=\"%s\"", part.Duration.Seconds(), part.URI)
+		if part.Independent {
+			b.WriteString(",INDEPENDENT=YES")
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Rendition is an EXT-X-MEDIA alternate rendition
+type Rendition struct {
+	Type     string // AUDIO, SUBTITLES
+	GroupID  string
+	Name     string
+	Language string
+	URI      string // Empty when the rendition is muxed into the variant
+	Default  bool
+}
+
+// Variant is an EXT-X-STREAM-INF entry
+type Variant struct {
+	URI        string
+	Bandwidth  int // bits per second
+	Codecs     string
+	Width      int
+	Height     int
+	AudioGroup string
+}
+
+// MasterPlaylist is an HLS multivariant playlist
+type MasterPlaylist struct {
+	Renditions   []Rendition
+	Variants     []Variant
+	ImageStreams []Variant // EXT-X-IMAGE-STREAM-INF thumbnail tracks
+}
+
+// Encode renders the playlist
+func (m *MasterPlaylist) Encode() []byte {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:7\n")
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	for _, r := range m.Renditions {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA:TYPE=%s,GROUP-ID=\"%s\",NAME=\"%s\"", r.Type, r.GroupID, r.Name)
+		if r.Language != "" {
+			fmt.Fprintf(&b, ",LANGUAGE=\"%s\"", r.Language)
+		}
+		if r.Default {
+			b.WriteString(",DEFAULT=YES,AUTOSELECT=YES")
+		} else {
+			b.WriteString(",DEFAULT=NO,AUTOSELECT=YES")
+		}
+		if r.URI != "" {
+			fmt.Fprintf(&b, ",URI=\"%s\"", r.URI)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, v := range m.Variants {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d", v.Bandwidth)
+		if v.Codecs != "" {
+			fmt.Fprintf(&b, ",CODECS=\"%s\"", v.Codecs)
+		}
+		if v.Width > 0 && v.Height > 0 {
+			fmt.Fprintf(&b, ",RESOLUTION=%dx%d", v.Width, v.Height)
+		}
+		if v.AudioGroup != "" {
+			fmt.Fprintf(&b, ",AUDIO=\"%s\"", v.AudioGroup)
+		}
+		b.WriteString("\n" + v.URI + "\n")
+	}
+
+	for _, v := range m.ImageStreams {
+		fmt.Fprintf(&b, "#EXT-X-IMAGE-STREAM-INF:BANDWIDTH=%d", v.Bandwidth)
+		if v.Width > 0 && v.Height > 0 {
+			fmt.Fprintf(&b, ",RESOLUTION=%dx%d", v.Width, v.Height)
+		}
+		fmt.Fprintf(&b, ",CODECS=\"jpeg\",URI=\"%s\"\n", v.URI)
+	}
+	return []byte(b.String())
+}
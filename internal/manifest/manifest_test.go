@@ -0,0 +1,176 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSegments(anchor time.Time, seqs ...int) []Segment {
+	var segs []Segment
+	for _, seq := range seqs {
+		segs = append(segs, Segment{
+			Sequence: seq,
+			URI:      "segment.m4s",
+			Start:    anchor.Add(time.Duration(seq) * 2 * time.Second),
+			Duration: 2 * time.Second,
+		})
+	}
+	return segs
+}
+
+func TestMediaPlaylistWindow(t *testing.T) {
+	anchor := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := MediaPlaylist{
+		Segments:        testSegments(anchor, 1, 2, 3, 5),
+		MapURI:          "init.mp4",
+		Window:          3,
+		ProgramDateTime: true,
+		KeyURI:          func(seq int) string { return "key.key" },
+	}
+	out := string(p.Encode())
+
+	for _, want := range []string{
+		"#EXT-X-MEDIA-SEQUENCE:2\n",
+		"#EXT-X-TARGETDURATION:2\n",
+		"#EXT-X-DISCONTINUITY\n",
+		"#EXT-X-PROGRAM-DATE-TIME:2026-01-01T00:00:04.000Z\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("playlist missing %q:\n%s", want, out)
+		}
+	}
+	// The segment after the gap no longer has its sequence number as IV
+	if strings.Count(out, "#EXT-X-KEY") != 2 || strings.Count(out, "IV=0x") != 1 {
+		t.Errorf("expected a key tag, then one with an explicit IV:\n%s", out)
+	}
+	if strings.Index(out, "#EXT-X-MAP") > strings.Index(out, "#EXT-X-KEY") {
+		t.Error("EXT-X-MAP must precede EXT-X-KEY")
+	}
+}
+
+func TestMPDTimeline(t *testing.T) {
+	anchor := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := MPD{
+		AvailabilityStart: anchor,
+		Segments:          testSegments(anchor, 3, 5, 6, 7),
+		InitURI:           "init.mp4",
+		MediaTemplate:     "segment_$Number%05d$.m4s",
+		Bandwidth:         5000000,
+	}
+	data, err := m.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+
+	// Only the contiguous run 5-7 is listed, as one repeated S element
+	if !strings.Contains(out, `startNumber="5"`) {
+		t.Errorf("expected startNumber 5:\n%s", out)
+	}
+	if !strings.Contains(out, `<S t="10000" d="2000" r="2"></S>`) {
+		t.Errorf("unexpected timeline:\n%s", out)
+	}
+}
+
+func TestMediaPlaylistLowLatency(t *testing.T) {
+	anchor := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	segs := testSegments(anchor, 1, 2)
+	segs[1].Parts = []Part{
+		{URI: "part_00002_0.m4s", Duration: time.Second, Independent: true},
+		{URI: "part_00002_1.m4s", Duration: time.Second},
+	}
+	p := MediaPlaylist{
+		Segments:       segs,
+		MapURI:         "init.mp4",
+		TargetDuration: 2 * time.Second,
+		PartTarget:     time.Second,
+		Partial:        []Part{{URI: "part_00003_0.m4s", Duration: 1100 * time.Millisecond, Independent: true}},
+		PreloadHint:    "part_00003_1.m4s",
+	}
+	out := string(p.Encode())
+
+	for _, want := range []string{
+		"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=3.300\n",
+		"#EXT-X-PART-INF:PART-TARGET=1.100\n",
+		"#EXT-X-PART:DURATION=1.000,URI=\"part_00002_0.m4s\",INDEPENDENT=YES\n#EXT-X-PART:DURATION=1.000,URI=\"part_00002_1.m4s\"\n#EXTINF:2.000,\n",
+		"segment.m4s\n#EXT-X-PART:DURATION=1.100,URI=\"part_00003_0.m4s\",INDEPENDENT=YES\n#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part_00003_1.m4s\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("playlist missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMediaPlaylistGapEncrypted(t *testing.T) {
+	anchor := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := MediaPlaylist{
+		Segments:              testSegments(anchor, 1, 2, 5, 6, 9),
+		MapURI:                "init.mp4",
+		Window:                4,
+		DiscontinuitySequence: 3,
+		KeyURI:                func(seq int) string { return "key.key" },
+	}
+	out := string(p.Encode())
+
+	for _, want := range []string{
+		"#EXT-X-MEDIA-SEQUENCE:2\n",
+		// Both gaps are within the window
+		"#EXT-X-DISCONTINUITY-SEQUENCE:3\n",
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.key\"\n",
+		// Position 3 holds sequence 5, position 4 sequence 6, 5 sequence 9
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.key\",IV=0x00000000000000000000000000000005\n",
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.key\",IV=0x00000000000000000000000000000006\n",
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.key\",IV=0x00000000000000000000000000000009\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("playlist missing %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "#EXT-X-KEY"); n != 4 {
+		t.Errorf("expected 4 key tags, got %d:\n%s", n, out)
+	}
+
+	// The window cuts the gap between 2 and 5
+	p.Window = 3
+	out = string(p.Encode())
+	if !strings.Contains(out, "#EXT-X-DISCONTINUITY-SEQUENCE:4\n") {
+		t.Errorf("expected the cut gap counted:\n%s", out)
+	}
+	// Sequence 5 is now first, so its position matches until the gap after 6
+	if strings.Count(out, "IV=0x") != 1 {
+		t.Errorf("expected one explicit IV:\n%s", out)
+	}
+}
+
+func TestMediaPlaylistInitChange(t *testing.T) {
+	anchor := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	segments := testSegments(anchor, 1, 2, 3, 4)
+	segments[0].MapURI = "init_00002.mp4"
+	segments[1].MapURI = "init_00002.mp4"
+	p := MediaPlaylist{
+		Segments: segments,
+		MapURI:   "init.mp4",
+		KeyURI:   func(seq int) string { return "key.key" },
+	}
+	out := string(p.Encode())
+
+	want := "#EXT-X-MAP:URI=\"init_00002.mp4\"\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.key\"\n" +
+		"#EXTINF:2.000,\nsegment.m4s\n" +
+		"#EXTINF:2.000,\nsegment.m4s\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		"#EXT-X-KEY:METHOD=NONE\n" +
+		"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.key\"\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("playlist missing the init change:\n%s", out)
+	}
+
+	// Cutting the old footage counts its discontinuity
+	p.Window = 2
+	out = string(p.Encode())
+	if !strings.Contains(out, "#EXT-X-DISCONTINUITY-SEQUENCE:1\n") || strings.Contains(out, "init_00002.mp4") {
+		t.Errorf("expected only the new init and the cut discontinuity:\n%s", out)
+	}
+}
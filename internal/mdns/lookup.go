@@ -0,0 +1,142 @@
+package mdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Instance is a discovered service instance
+type Instance struct {
+	Name string            // Instance name, e.g. "Capture Agent (studio-1)"
+	Host string            // Target host, e.g. "studio-1.local."
+	Port int               // Service port
+	IPs  []net.IP          // Host addresses from the responses
+	TXT  map[string]string // key=value TXT entries
+}
+
+// Lookup asks the LAN for instances of service (e.g. "_gocapture._tcp") and
+// collects answers until ctx is done. Queries are sent from an ephemeral
+// port, so responders answer by unicast (RFC 6762 section 6.7).
+func Lookup(ctx context.Context, service string) ([]Instance, error) {
+	name, err := dnsmessage.NewName(service + ".local.")
+	if err != nil {
+		return nil, fmt.Errorf("invalid service %q: %w", service, err)
+	}
+	query, err := (&dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.WriteToUDP(query, groupAddr); err != nil {
+		return nil, fmt.Errorf("send query: %w", err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(2 * time.Second)
+	}
+	conn.SetReadDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	b := newBrowser(name)
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break // deadline or cancel
+		}
+		b.add(buf[:n])
+	}
+	return b.instances(), nil
+}
+
+// browser assembles instances from response records
+type browser struct {
+	service string
+	ptrs    []string // Instance names as received
+	srv     map[string]dnsmessage.SRVResource
+	txt     map[string][]string
+	addrs   map[string][]net.IP
+}
+
+func newBrowser(service dnsmessage.Name) *browser {
+	return &browser{
+		service: strings.ToLower(service.String()),
+		srv:     make(map[string]dnsmessage.SRVResource),
+		txt:     make(map[string][]string),
+		addrs:   make(map[string][]net.IP),
+	}
+}
+
+// add records every answer and additional record in a response packet
+func (b *browser) add(packet []byte) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet); err != nil || !msg.Header.Response {
+		return
+	}
+	for _, r := range append(msg.Answers, msg.Additionals...) {
+		name := strings.ToLower(r.Header.Name.String())
+		switch body := r.Body.(type) {
+		case *dnsmessage.PTRResource:
+			target := body.PTR.String()
+			if name == b.service && r.Header.TTL > 0 && !slices.ContainsFunc(b.ptrs, func(p string) bool { return strings.EqualFold(p, target) }) {
+				b.ptrs = append(b.ptrs, target)
+			}
+		case *dnsmessage.SRVResource:
+			b.srv[name] = *body
+		case *dnsmessage.TXTResource:
+			b.txt[name] = body.TXT
+		case *dnsmessage.AResource:
+			b.addIP(name, net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			b.addIP(name, net.IP(body.AAAA[:]))
+		}
+	}
+}
+
+func (b *browser) addIP(host string, ip net.IP) {
+	if !slices.ContainsFunc(b.addrs[host], ip.Equal) {
+		b.addrs[host] = append(b.addrs[host], ip)
+	}
+}
+
+// instances returns the instances with a known port
+func (b *browser) instances() []Instance {
+	var out []Instance
+	for _, ptr := range b.ptrs {
+		key := strings.ToLower(ptr)
+		srv, ok := b.srv[key]
+		if !ok || !strings.HasSuffix(key, "."+b.service) {
+			continue
+		}
+		host := strings.ToLower(srv.Target.String())
+		inst := Instance{
+			Name: ptr[:len(ptr)-len(b.service)-1],
+			Host: srv.Target.String(),
+			Port: int(srv.Port),
+			IPs:  b.addrs[host],
+			TXT:  make(map[string]string),
+		}
+		for _, kv := range b.txt[key] {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				inst.TXT[k] = v
+			}
+		}
+		out = append(out, inst)
+	}
+	return out
+}
@@ -0,0 +1,325 @@
+// Package mdns advertises a DNS-SD service over multicast DNS (RFC 6762,
+// RFC 6763) so clients on the LAN can find it without configuration.
+//
+// The responder answers queries for its own service and announces it on
+// start and shutdown. Lookup is a one-shot browse; nothing is cached.
+package mdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mDNS multicast group
+var groupAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Record TTLs recommended by RFC 6762 section 10
+const (
+	hostTTL  = 120
+	otherTTL = 4500
+)
+
+// cacheFlush marks a record as the complete answer for its name and type
+const cacheFlush = 1 << 15
+
+// Service describes an advertised DNS-SD service
+type Service struct {
+	Instance string   // Instance name shown to users, e.g. "Capture Agent (studio-1)"
+	Service  string   // Service type, e.g. "_gocapture._tcp"
+	Host     string   // Host name without ".local" (default: os.Hostname)
+	Port     int      // Service port
+	TXT      []string // key=value pairs
+	IPs      []net.IP // Addresses to advertise (default: all up, non-loopback interfaces)
+}
+
+// Server answers mDNS queries for one service
+type Server struct {
+	conn *net.UDPConn
+
+	// Fully qualified names
+	service  dnsmessage.Name
+	instance dnsmessage.Name
+	host     dnsmessage.Name
+	browse   dnsmessage.Name
+
+	port uint16
+	txt  []string
+	ips  []net.IP
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Advertise starts answering queries for svc and announces it on the LAN.
+// Close withdraws the service.
+func Advertise(svc Service) (*Server, error) {
+	s, err := newServer(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return nil, fmt.Errorf("listen mdns: %w", err)
+	}
+	s.conn = conn
+
+	go s.serve()
+	go s.announce()
+	return s, nil
+}
+
+func newServer(svc Service) (*Server, error) {
+	if svc.Service == "" || svc.Port <= 0 || svc.Port > 65535 {
+		return nil, fmt.Errorf("invalid service %q port %d", svc.Service, svc.Port)
+	}
+	host := svc.Host
+	if host == "" {
+		h, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("hostname: %w", err)
+		}
+		host = h
+	}
+	// Labels can't contain dots here: dnsmessage has no escaping
+	host = strings.ReplaceAll(strings.TrimSuffix(host, ".local"), ".", "-")
+	instance := svc.Instance
+	if instance == "" {
+		instance = host
+	}
+	instance = strings.ReplaceAll(instance, ".", "-")
+
+	for _, t := range svc.TXT {
+		if len(t) > 255 {
+			return nil, fmt.Errorf("txt entry longer than 255 bytes: %.20s...", t)
+		}
+	}
+
+	ips := svc.IPs
+	if len(ips) == 0 {
+		ips = localIPs()
+	}
+
+	s := &Server{
+		port: uint16(svc.Port),
+		txt:  svc.TXT,
+		ips:  ips,
+		done: make(chan struct{}),
+	}
+	var err error
+	for _, n := range []struct {
+		name *dnsmessage.Name
+		s    string
+	}{
+		{&s.service, svc.Service + ".local."},
+		{&s.instance, instance + "." + svc.Service + ".local."},
+		{&s.host, host + ".local."},
+		{&s.browse, "_services._dns-sd._udp.local."},
+	} {
+		if *n.name, err = dnsmessage.NewName(n.s); err != nil {
+			return nil, fmt.Errorf("invalid name %q: %w", n.s, err)
+		}
+	}
+	return s, nil
+}
+
+// Close sends a goodbye so clients drop the service, and stops answering
+func (s *Server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		goodbye := s.all()
+		for i := range goodbye {
+			goodbye[i].Header.TTL = 0
+		}
+		if msg, perr := s.message(0, nil, goodbye, nil); perr == nil {
+			s.conn.WriteToUDP(msg, groupAddr)
+		}
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// announce sends unsolicited responses twice, one second apart (RFC 6762 8.3)
+func (s *Server) announce() {
+	for i := 0; i < 2; i++ {
+		if msg, err := s.message(0, nil, s.all(), nil); err == nil {
+			s.conn.WriteToUDP(msg, groupAddr)
+		}
+		select {
+		case <-s.done:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *Server) serve() {
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		resp, unicast := s.handle(buf[:n], from.Port != groupAddr.Port)
+		if resp == nil {
+			continue
+		}
+		to := groupAddr
+		if unicast {
+			to = from
+		}
+		s.conn.WriteToUDP(resp, to)
+	}
+}
+
+// handle answers a query packet. Legacy queries (not from port 5353) get a
+// unicast reply echoing the query ID and questions.
+func (s *Server) handle(packet []byte, legacy bool) (resp []byte, unicast bool) {
+	var query dnsmessage.Message
+	if err := query.Unpack(packet); err != nil || query.Header.Response {
+		return nil, false
+	}
+
+	var answers, extra []dnsmessage.Resource
+	for _, q := range query.Questions {
+		a, x := s.answer(q)
+		answers = append(answers, a...)
+		extra = append(extra, x...)
+	}
+	if len(answers) == 0 {
+		return nil, false
+	}
+
+	var id uint16
+	var questions []dnsmessage.Question
+	if legacy {
+		id, questions = query.Header.ID, query.Questions
+	}
+	msg, err := s.message(id, questions, answers, extra)
+	if err != nil {
+		return nil, false
+	}
+	return msg, legacy
+}
+
+// answer returns the records answering q and related additional records
+func (s *Server) answer(q dnsmessage.Question) (answers, extra []dnsmessage.Resource) {
+	matches := func(name dnsmessage.Name, t dnsmessage.Type) bool {
+		return strings.EqualFold(q.Name.String(), name.String()) && (q.Type == t || q.Type == dnsmessage.TypeALL)
+	}
+
+	switch {
+	case matches(s.browse, dnsmessage.TypePTR):
+		answers = append(answers, s.ptr(s.browse, s.service))
+	case matches(s.service, dnsmessage.TypePTR):
+		answers = append(answers, s.ptr(s.service, s.instance))
+		extra = append(append(extra, s.srv(), s.txtRecord()), s.addresses()...)
+	case strings.EqualFold(q.Name.String(), s.instance.String()):
+		if matches(s.instance, dnsmessage.TypeSRV) {
+			answers = append(answers, s.srv())
+			extra = append(extra, s.addresses()...)
+		}
+		if matches(s.instance, dnsmessage.TypeTXT) {
+			answers = append(answers, s.txtRecord())
+		}
+	case strings.EqualFold(q.Name.String(), s.host.String()):
+		for _, r := range s.addresses() {
+			if q.Type == r.Header.Type || q.Type == dnsmessage.TypeALL {
+				answers = append(answers, r)
+			}
+		}
+	}
+	return answers, extra
+}
+
+// all returns every record of the service, for announcements and goodbyes
+func (s *Server) all() []dnsmessage.Resource {
+	return append([]dnsmessage.Resource{s.ptr(s.service, s.instance), s.srv(), s.txtRecord()}, s.addresses()...)
+}
+
+// message packs a response
+func (s *Server) message(id uint16, questions []dnsmessage.Question, answers, extra []dnsmessage.Resource) ([]byte, error) {
+	msg := dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: id, Response: true, Authoritative: true},
+		Questions:   questions,
+		Answers:     answers,
+		Additionals: extra,
+	}
+	return msg.Pack()
+}
+
+func (s *Server) ptr(name, target dnsmessage.Name) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: otherTTL},
+		Body:   &dnsmessage.PTRResource{PTR: target},
+	}
+}
+
+func (s *Server) srv() dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: s.instance, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET | cacheFlush, TTL: hostTTL},
+		Body:   &dnsmessage.SRVResource{Target: s.host, Port: s.port},
+	}
+}
+
+func (s *Server) txtRecord() dnsmessage.Resource {
+	txt := s.txt
+	if len(txt) == 0 {
+		txt = []string{""} // A TXT record must hold at least one string
+	}
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: s.instance, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET | cacheFlush, TTL: otherTTL},
+		Body:   &dnsmessage.TXTResource{TXT: txt},
+	}
+}
+
+func (s *Server) addresses() []dnsmessage.Resource {
+	var out []dnsmessage.Resource
+	for _, ip := range s.ips {
+		h := dnsmessage.ResourceHeader{Name: s.host, Class: dnsmessage.ClassINET | cacheFlush, TTL: hostTTL}
+		if v4 := ip.To4(); v4 != nil {
+			h.Type = dnsmessage.TypeA
+			out = append(out, dnsmessage.Resource{Header: h, Body: &dnsmessage.AResource{A: [4]byte(v4)}})
+		} else if v6 := ip.To16(); v6 != nil {
+			h.Type = dnsmessage.TypeAAAA
+			out = append(out, dnsmessage.Resource{Header: h, Body: &dnsmessage.AAAAResource{AAAA: [16]byte(v6)}})
+		}
+	}
+	return out
+}
+
+// localIPs returns the addresses of up, multicast-capable, non-loopback
+// interfaces
+func localIPs() []net.IP {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLinkLocalUnicast() {
+				ips = append(ips, ipnet.IP)
+			}
+		}
+	}
+	return ips
+}
@@ -0,0 +1,121 @@
+package mdns
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestAnswerServiceQuery(t *testing.T) {
+	s, err := newServer(Service{
+		Instance: "Capture Agent (studio.1)",
+		Service:  "_gocapture._tcp",
+		Host:     "studio-1",
+		Port:     8080,
+		TXT:      []string{"version=1.0.0", "channels=cam1,cam2"},
+		IPs:      []net.IP{net.ParseIP("192.168.1.20")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 7},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName("_gocapture._tcp.local."),
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, unicast := s.handle(packet, true)
+	if resp == nil || !unicast {
+		t.Fatalf("expected a unicast reply to a legacy query, got %v %v", resp, unicast)
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(resp); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.ID != 7 || len(msg.Questions) != 1 {
+		t.Errorf("legacy reply should echo ID and question: %+v", msg.Header)
+	}
+	if len(msg.Answers) != 1 {
+		t.Fatalf("answers = %d, want 1", len(msg.Answers))
+	}
+	ptr := msg.Answers[0].Body.(*dnsmessage.PTRResource)
+	if got := ptr.PTR.String(); got != "Capture Agent (studio-1)._gocapture._tcp.local." {
+		t.Errorf("PTR = %s", got)
+	}
+
+	var srv *dnsmessage.SRVResource
+	var txt *dnsmessage.TXTResource
+	var a *dnsmessage.AResource
+	for _, r := range msg.Additionals {
+		switch b := r.Body.(type) {
+		case *dnsmessage.SRVResource:
+			srv = b
+		case *dnsmessage.TXTResource:
+			txt = b
+		case *dnsmessage.AResource:
+			a = b
+		}
+	}
+	if srv == nil || srv.Port != 8080 || srv.Target.String() != "studio-1.local." {
+		t.Errorf("SRV = %+v", srv)
+	}
+	if txt == nil || len(txt.TXT) != 2 || txt.TXT[1] != "channels=cam1,cam2" {
+		t.Errorf("TXT = %+v", txt)
+	}
+	if a == nil || net.IP(a.A[:]).String() != "192.168.1.20" {
+		t.Errorf("A = %+v", a)
+	}
+
+	query.Questions[0].Name = dnsmessage.MustNewName("_other._tcp.local.")
+	packet, _ = query.Pack()
+	if resp, _ := s.handle(packet, false); resp != nil {
+		t.Error("should ignore queries for other services")
+	}
+}
+
+func TestBrowserAssemblesInstance(t *testing.T) {
+	s, err := newServer(Service{
+		Instance: "Capture Agent (studio-2)",
+		Service:  "_gocapture._tcp",
+		Host:     "studio-2",
+		Port:     8080,
+		TXT:      []string{"id=agent-studio-2", "version=1.2.0"},
+		IPs:      []net.IP{net.ParseIP("192.168.1.21")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName("_gocapture._tcp.local."),
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packet, _ := query.Pack()
+	resp, _ := s.handle(packet, true)
+
+	b := newBrowser(dnsmessage.MustNewName("_gocapture._tcp.local."))
+	b.add(resp)
+	b.add(resp) // Duplicate answers are merged
+	got := b.instances()
+	if len(got) != 1 {
+		t.Fatalf("instances = %d, want 1", len(got))
+	}
+	inst := got[0]
+	if inst.Name != "Capture Agent (studio-2)" || inst.Port != 8080 || inst.Host != "studio-2.local." || inst.TXT["id"] != "agent-studio-2" {
+		t.Errorf("instance = %+v", inst)
+	}
+	if len(inst.IPs) != 1 || inst.IPs[0].String() != "192.168.1.21" {
+		t.Errorf("IPs = %v", inst.IPs)
+	}
+}
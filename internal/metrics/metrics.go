@@ -0,0 +1,152 @@
+// Package metrics keeps counters and histograms and writes them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing count, safe for concurrent use
+type Counter struct {
+	v atomic.Uint64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Value returns the current count
+func (c *Counter) Value() uint64 {
+	return c.v.Load()
+}
+
+// DefaultBuckets are histogram upper bounds in seconds, sized for clip
+// generation
+var DefaultBuckets = []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram creates a histogram with the given ascending upper bounds
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Labels are label name/value pairs, in output order
+type Labels []string
+
+// Writer writes metric families. The first write error is kept and
+// returned by Flush.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewWriter creates a writer for w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Header starts a metric family; typ is counter, gauge or histogram
+func (w *Writer) Header(name, typ, help string) {
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// Sample writes one value of the current family
+func (w *Writer) Sample(name string, labels Labels, v float64) {
+	w.printf("%s%s %s\n", name, formatLabels(labels), formatValue(v))
+}
+
+// Counter writes a counter's value
+func (w *Writer) Counter(name string, labels Labels, c *Counter) {
+	w.Sample(name, labels, float64(c.Value()))
+}
+
+// Histogram writes a histogram's buckets, sum and count
+func (w *Writer) Histogram(name string, labels Labels, h *Histogram) {
+	h.mu.Lock()
+	counts := append([]uint64{}, h.counts...)
+	sum, count := h.sum, h.count
+	h.mu.Unlock()
+
+	for i, b := range h.buckets {
+		w.Sample(name+"_bucket", append(labels[:len(labels):len(labels)], "le", formatValue(b)), float64(counts[i]))
+	}
+	w.Sample(name+"_bucket", append(labels[:len(labels):len(labels)], "le", "+Inf"), float64(count))
+	w.Sample(name+"_sum", labels, sum)
+	w.Sample(name+"_count", labels, float64(count))
+}
+
+// Flush writes buffered output and returns the first error
+func (w *Writer) Flush() error {
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	return w.err
+}
+
+func (w *Writer) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(labels[i])
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(labels[i+1]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
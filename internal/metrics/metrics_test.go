@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var c Counter
+	c.Inc()
+	c.Inc()
+	h := NewHistogram([]float64{1, 5})
+	h.Observe(0.5)
+	h.Observe(3)
+	h.Observe(10)
+
+	var sb strings.Builder
+	w := NewWriter(&sb)
+	w.Header("clips_total", "counter", "Clips generated.")
+	w.Counter("clips_total", Labels{"channel", `cam"1`}, &c)
+	w.Header("clip_seconds", "histogram", "Clip generation time.")
+	w.Histogram("clip_seconds", Labels{"channel", "cam2"}, h)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP clips_total Clips generated.
+# TYPE clips_total counter
+clips_total{channel="cam\"1"} 2
+# HELP clip_seconds Clip generation time.
+# TYPE clip_seconds histogram
+clip_seconds_bucket{channel="cam2",le="1"} 1
+clip_seconds_bucket{channel="cam2",le="5"} 2
+clip_seconds_bucket{channel="cam2",le="+Inf"} 3
+clip_seconds_sum{channel="cam2"} 13.5
+clip_seconds_count{channel="cam2"} 3
+`
+	if sb.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,310 @@
+// Package mpegts demuxes the video elementary stream of an MPEG transport
+// stream, as sent over SRT and UDP by contribution encoders.
+package mpegts
+
+import (
+	"errors"
+	"io"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+)
+
+// PacketSize is the size of a transport stream packet
+const PacketSize = 188
+
+const syncByte = 0x47
+
+// Stream types of video elementary streams in the PMT
+const (
+	StreamTypeMPEG1 = 0x01
+	StreamTypeMPEG2 = 0x02
+	StreamTypeMPEG4 = 0x10
+	StreamTypeH264  = 0x1b
+	StreamTypeH265  = 0x24
+)
+
+// ErrNoVideo is returned when the program has no video stream
+var ErrNoVideo = errors.New("no video stream in program")
+
+// Codec returns the codec name of a video stream type, as FFmpeg names it
+func Codec(streamType uint8) string {
+	switch streamType {
+	case StreamTypeMPEG1:
+		return "mpeg1video"
+	case StreamTypeMPEG2:
+		return "mpeg2video"
+	case StreamTypeMPEG4:
+		return "mpeg4"
+	case StreamTypeH264:
+		return h26x.H264
+	case StreamTypeH265:
+		return h26x.H265
+	}
+	return ""
+}
+
+// PES is one packetized elementary stream packet of the video stream,
+// usually one access unit
+type PES struct {
+	Data []byte
+	PTS  int64 // 90 kHz, unwrapped from the 33-bit timestamps
+	DTS  int64
+
+	// Packets were lost before this one, so decoding it may depend on data
+	// that never arrived
+	Discontinuity bool
+}
+
+// Demuxer reads the video of the first program of a transport stream. PAT
+// and PMT sections are expected to fit in one packet, as they do in
+// practice. A PES ends when the next one starts; packets lost to a
+// continuity counter gap take the PES they belong to with them.
+type Demuxer struct {
+	r   io.Reader
+	pkt [PacketSize]byte
+
+	pmtPID     int
+	videoPID   int
+	streamType uint8
+
+	cc     int // Last continuity counter of the video PID (-1 = none)
+	pes    []byte
+	pts    int64
+	dts    int64
+	inPES  bool
+	lost   bool // A packet of the current PES is missing
+	gapped bool // Mark the next PES as a discontinuity
+
+	// Timestamp unwrapping
+	started bool
+	lastRaw int64
+	lastPTS int64
+}
+
+// NewDemuxer creates a demuxer reading r
+func NewDemuxer(r io.Reader) *Demuxer {
+	return &Demuxer{r: r, pmtPID: -1, videoPID: -1, cc: -1}
+}
+
+// StreamType returns the stream type of the video, 0 until the PMT is read
+func (d *Demuxer) StreamType() uint8 {
+	return d.streamType
+}
+
+// ReadPMT reads until the program's video stream is known. Packets are
+// consumed only up to the PMT, so ReadPES sees all of the video.
+func (d *Demuxer) ReadPMT() error {
+	for d.videoPID < 0 {
+		if _, err := d.next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPES returns the next complete video PES
+func (d *Demuxer) ReadPES() (*PES, error) {
+	for {
+		pes, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if pes != nil {
+			return pes, nil
+		}
+	}
+}
+
+// next reads one packet and returns the PES it completes, if any
+func (d *Demuxer) next() (*PES, error) {
+	if err := d.readPacket(); err != nil {
+		return nil, err
+	}
+	b := d.pkt[:]
+	pusi := b[1]&0x40 != 0
+	pid := int(b[1]&0x1f)<<8 | int(b[2])
+	afc := b[3] >> 4 & 3
+	cc := int(b[3] & 0x0f)
+
+	payload := b[4:]
+	discontinuity := false
+	if afc&2 != 0 {
+		n := int(b[4])
+		if n > 0 && n <= PacketSize-5 {
+			discontinuity = b[5]&0x80 != 0
+		}
+		if 1+n > len(payload) {
+			return nil, nil
+		}
+		payload = payload[1+n:]
+	}
+	if afc&1 == 0 {
+		payload = nil
+	}
+
+	switch {
+	case pid == 0 && pusi:
+		d.parsePAT(payload)
+	case pid == d.pmtPID && pusi:
+		if err := d.parsePMT(payload); err != nil {
+			return nil, err
+		}
+	case pid == d.videoPID && payload != nil:
+		if d.cc >= 0 && !discontinuity {
+			switch cc {
+			case d.cc:
+				return nil, nil // Duplicate
+			case (d.cc + 1) & 0x0f:
+			default:
+				d.lost = true
+			}
+		}
+		d.cc = cc
+		if pusi {
+			pes := d.finishPES()
+			d.startPES(payload)
+			return pes, nil
+		}
+		if d.inPES {
+			d.pes = append(d.pes, payload...)
+		}
+	}
+	return nil, nil
+}
+
+// readPacket reads the next packet, skipping to the next sync byte when
+// the stream is misaligned
+func (d *Demuxer) readPacket() error {
+	if _, err := io.ReadFull(d.r, d.pkt[:1]); err != nil {
+		return err
+	}
+	for d.pkt[0] != syncByte {
+		if _, err := io.ReadFull(d.r, d.pkt[:1]); err != nil {
+			return err
+		}
+	}
+	_, err := io.ReadFull(d.r, d.pkt[1:])
+	return err
+}
+
+// section returns the section a PSI payload starts, without its CRC
+func section(payload []byte, tableID byte) []byte {
+	if len(payload) < 1 {
+		return nil
+	}
+	ptr := int(payload[0])
+	if 1+ptr+3 > len(payload) {
+		return nil
+	}
+	s := payload[1+ptr:]
+	if s[0] != tableID {
+		return nil
+	}
+	n := int(s[1]&0x0f)<<8 | int(s[2])
+	if n < 4 || 3+n > len(s) {
+		return nil
+	}
+	return s[:3+n-4]
+}
+
+func (d *Demuxer) parsePAT(payload []byte) {
+	s := section(payload, 0x00)
+	for i := 8; i+4 <= len(s); i += 4 {
+		program := int(s[i])<<8 | int(s[i+1])
+		if program != 0 { // 0 is the network PID
+			d.pmtPID = int(s[i+2]&0x1f)<<8 | int(s[i+3])
+			return
+		}
+	}
+}
+
+func (d *Demuxer) parsePMT(payload []byte) error {
+	s := section(payload, 0x02)
+	if len(s) < 12 {
+		return nil
+	}
+	i := 12 + (int(s[10]&0x0f)<<8 | int(s[11]))
+	for i+5 <= len(s) {
+		typ := s[i]
+		pid := int(s[i+1]&0x1f)<<8 | int(s[i+2])
+		if Codec(typ) != "" {
+			if pid != d.videoPID {
+				d.videoPID, d.cc = pid, -1
+				d.pes, d.inPES = nil, false
+			}
+			d.streamType = typ
+			return nil
+		}
+		i += 5 + (int(s[i+3]&0x0f)<<8 | int(s[i+4]))
+	}
+	return ErrNoVideo
+}
+
+// startPES begins collecting a PES from its first payload
+func (d *Demuxer) startPES(p []byte) {
+	d.inPES = false
+	if d.lost {
+		d.gapped = true
+	}
+	d.lost = false
+	if len(p) < 9 || p[0] != 0 || p[1] != 0 || p[2] != 1 {
+		return
+	}
+	flags := p[7] >> 6
+	hdr := 9 + int(p[8])
+	if hdr > len(p) || flags&2 == 0 || len(p) < 14 {
+		return
+	}
+	ptsRaw := timestamp(p[9:])
+	dtsRaw := ptsRaw
+	if flags == 3 && len(p) >= 19 {
+		dtsRaw = timestamp(p[14:])
+	}
+	d.pts = d.unwrap(ptsRaw)
+	d.dts = d.pts - wrapDelta(ptsRaw-dtsRaw)
+	d.pes = append([]byte(nil), p[hdr:]...)
+	d.inPES = true
+}
+
+// finishPES returns the PES being collected, nil when there's none or
+// part of it was lost
+func (d *Demuxer) finishPES() *PES {
+	if !d.inPES {
+		return nil
+	}
+	if d.lost {
+		d.gapped = true
+		return nil
+	}
+	pes := &PES{Data: d.pes, PTS: d.pts, DTS: d.dts, Discontinuity: d.gapped}
+	d.gapped = false
+	return pes
+}
+
+// timestamp reads a 33-bit PES timestamp
+func timestamp(b []byte) int64 {
+	return int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 | int64(b[3])<<7 | int64(b[4]>>1)
+}
+
+// wrapDelta interprets a difference of 33-bit timestamps as the shortest
+// signed distance
+func wrapDelta(d int64) int64 {
+	const period = 1 << 33
+	d &= period - 1
+	if d >= period/2 {
+		d -= period
+	}
+	return d
+}
+
+// unwrap extends a 33-bit PTS to a continuous 64-bit one
+func (d *Demuxer) unwrap(raw int64) int64 {
+	if !d.started {
+		d.started = true
+		d.lastRaw, d.lastPTS = raw, raw
+		return raw
+	}
+	d.lastPTS += wrapDelta(raw - d.lastRaw)
+	d.lastRaw = raw
+	return d.lastPTS
+}
@@ -0,0 +1,144 @@
+package mpegts
+
+import (
+	"bytes"
+	"testing"
+)
+
+// tsWriter builds a transport stream with a PAT, a PMT on PID 0x1000 and
+// video on PID 0x100
+type tsWriter struct {
+	buf bytes.Buffer
+	cc  map[int]int
+}
+
+func newTSWriter(streamType byte) *tsWriter {
+	w := &tsWriter{cc: map[int]int{}}
+	// PAT: program 1 on PID 0x1000
+	w.packet(0, true, []byte{0, 0x00, 0xb0, 13, 0, 1, 0xc1, 0, 0, 0, 1, 0xf0, 0x00, 0, 0, 0, 0})
+	// PMT: audio on 0x101, then video on 0x100
+	w.packet(0x1000, true, []byte{0, 0x02, 0xb0, 23, 0, 1, 0xc1, 0, 0, 0xe1, 0x00, 0xf0, 0,
+		0x0f, 0xe1, 0x01, 0xf0, 0,
+		streamType, 0xe1, 0x00, 0xf0, 0,
+		0, 0, 0, 0})
+	return w
+}
+
+// packet writes one packet, padding the payload with an adaptation field
+func (w *tsWriter) packet(pid int, pusi bool, payload []byte) {
+	p := make([]byte, PacketSize)
+	p[0] = syncByte
+	p[1] = byte(pid >> 8 & 0x1f)
+	if pusi {
+		p[1] |= 0x40
+	}
+	p[2] = byte(pid)
+	p[3] = 0x10 | byte(w.cc[pid]&0x0f)
+	w.cc[pid]++
+	pad := PacketSize - 4 - len(payload)
+	if pad > 0 {
+		p[3] |= 0x20
+		p[4] = byte(pad - 1)
+		if pad > 1 {
+			for i := 6; i < 4+pad; i++ {
+				p[i] = 0xff
+			}
+		}
+	}
+	copy(p[4+pad:], payload)
+	w.buf.Write(p)
+}
+
+// pes writes a video PES with a PTS and DTS, split across packets
+func (w *tsWriter) pes(pts, dts int64, data []byte) {
+	p := []byte{0, 0, 1, 0xe0, 0, 0, 0x80, 0xc0, 10}
+	p = append(p, tsBytes(3, pts)...)
+	p = append(p, tsBytes(1, dts)...)
+	p = append(p, data...)
+	for first := true; len(p) > 0; first = false {
+		n := min(len(p), PacketSize-4)
+		w.packet(0x100, first, p[:n])
+		p = p[n:]
+	}
+}
+
+func tsBytes(prefix byte, ts int64) []byte {
+	return []byte{
+		prefix<<4 | byte(ts>>29&0x0e) | 1,
+		byte(ts >> 22), byte(ts>>14) | 1,
+		byte(ts >> 7), byte(ts<<1) | 1,
+	}
+}
+
+func TestDemuxer(t *testing.T) {
+	w := newTSWriter(StreamTypeH264)
+	frame := bytes.Repeat([]byte{0, 0, 0, 1, 0x65, 0xaa}, 100) // Spans several packets
+	w.pes(3600, 0, frame)
+	w.pes(7200, 3600, []byte{0, 0, 0, 1, 0x41})
+	w.pes(10800, 7200, []byte{0, 0, 0, 1, 0x41}) // Ends the one before
+
+	d := NewDemuxer(&w.buf)
+	if err := d.ReadPMT(); err != nil {
+		t.Fatal(err)
+	}
+	if d.StreamType() != StreamTypeH264 {
+		t.Fatalf("stream type = %#x", d.StreamType())
+	}
+	pes, err := d.ReadPES()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pes.Data, frame) || pes.PTS != 3600 || pes.DTS != 0 {
+		t.Errorf("pes = pts %d dts %d, %d bytes", pes.PTS, pes.DTS, len(pes.Data))
+	}
+	if pes, err = d.ReadPES(); err != nil || pes.PTS != 7200 || pes.Discontinuity {
+		t.Errorf("second pes = %+v, %v", pes, err)
+	}
+}
+
+func TestDemuxerLoss(t *testing.T) {
+	w := newTSWriter(StreamTypeH265)
+	w.pes(0, 0, bytes.Repeat([]byte{1}, 400))
+	w.pes(3000, 3000, []byte{2})
+	w.pes(6000, 6000, []byte{3})
+	w.pes(9000, 9000, []byte{4})
+	ts := w.buf.Bytes()
+	// Drop the second packet of the first PES
+	lost := append(append([]byte(nil), ts[:3*PacketSize]...), ts[4*PacketSize:]...)
+
+	d := NewDemuxer(bytes.NewReader(lost))
+	pes, err := d.ReadPES()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pes.PTS != 3000 || !pes.Discontinuity {
+		t.Errorf("pes after loss = pts %d, discontinuity %v; want 3000, true", pes.PTS, pes.Discontinuity)
+	}
+	if pes, _ = d.ReadPES(); pes.Discontinuity {
+		t.Error("discontinuity repeated")
+	}
+}
+
+func TestDemuxerResync(t *testing.T) {
+	w := newTSWriter(StreamTypeMPEG2)
+	w.pes(0, 0, []byte{1})
+	w.pes(3000, 3000, []byte{2})
+	d := NewDemuxer(bytes.NewReader(append([]byte{0, 1, 2}, w.buf.Bytes()...)))
+	if err := d.ReadPMT(); err != nil {
+		t.Fatal(err)
+	}
+	if Codec(d.StreamType()) != "mpeg2video" {
+		t.Errorf("codec = %q", Codec(d.StreamType()))
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	d := NewDemuxer(nil)
+	d.unwrap(1<<33 - 1000)
+	if got := d.unwrap(2000); got != 1<<33+2000 {
+		t.Errorf("unwrapped = %d, want %d", got, int64(1<<33+2000))
+	}
+	if got := wrapDelta(100 - (1<<33 - 100)); got != 200 {
+		t.Errorf("wrapDelta across wrap = %d", got)
+	}
+}
@@ -0,0 +1,75 @@
+// Package recovery keeps a panic in a background goroutine from taking down
+// the whole agent, and remembers recent panics for diagnostics.
+package recovery
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+)
+
+var logger = logging.For("recovery")
+
+// maxPanics is how many recovered panics Panics keeps
+const maxPanics = 50
+
+// Panic is a recovered panic
+type Panic struct {
+	Time      time.Time `json:"time"`
+	Goroutine string    `json:"goroutine"`
+	Value     string    `json:"value"`
+	Stack     string    `json:"stack"`
+}
+
+var (
+	mu     sync.Mutex
+	panics []Panic
+)
+
+// Go runs fn in a new goroutine, recovering any panic
+func Go(name string, fn func()) {
+	go func() {
+		defer Handle(name, nil)
+		fn()
+	}()
+}
+
+// Handle recovers a panic in the calling goroutine, logs it with its stack
+// and calls onPanic (if non-nil). Use it directly in a defer statement:
+//
+//	defer recovery.Handle("segment watcher", nil)
+func Handle(name string, onPanic func(error)) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	p := Panic{
+		Time:      time.Now(),
+		Goroutine: name,
+		Value:     fmt.Sprint(v),
+		Stack:     string(debug.Stack()),
+	}
+	logger.Error("Recovered panic", "goroutine", name, "panic", p.Value, "stack", p.Stack)
+
+	mu.Lock()
+	panics = append(panics, p)
+	if len(panics) > maxPanics {
+		panics = panics[len(panics)-maxPanics:]
+	}
+	mu.Unlock()
+
+	if onPanic != nil {
+		onPanic(fmt.Errorf("panic in %s: %v", name, v))
+	}
+}
+
+// Panics returns recently recovered panics, oldest first
+func Panics() []Panic {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Panic(nil), panics...)
+}
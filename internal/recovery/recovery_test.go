@@ -0,0 +1,29 @@
+package recovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	var got error
+	func() {
+		defer Handle("worker", func(err error) { got = err })
+		var m map[string]int
+		m["boom"] = 1
+	}()
+
+	if got == nil || !strings.Contains(got.Error(), "panic in worker") {
+		t.Fatalf("onPanic got %v", got)
+	}
+	panics := Panics()
+	if len(panics) != 1 || panics[0].Goroutine != "worker" || !strings.Contains(panics[0].Stack, "TestHandle") {
+		t.Errorf("unexpected panics %+v", panics)
+	}
+}
+
+func TestGo(t *testing.T) {
+	done := make(chan struct{})
+	Go("fine", func() { close(done) })
+	<-done
+}
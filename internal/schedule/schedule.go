@@ -0,0 +1,84 @@
+// Package schedule decides whether a time falls in weekly recording windows.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily span of wall-clock time, on some weekdays. A window
+// whose end is before its start runs past midnight into the next day.
+type Window struct {
+	days       [7]bool // Indexed by time.Weekday; the day the window opens
+	start, end int     // Minutes after midnight
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWindow parses days (mon, tuesday, ...; empty means every day) and
+// HH:MM start and end times
+func ParseWindow(days []string, start, end string) (Window, error) {
+	var w Window
+	if len(days) == 0 {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	}
+	for _, d := range days {
+		name := strings.ToLower(strings.TrimSpace(d))
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		day, ok := dayNames[name]
+		if !ok {
+			return Window{}, fmt.Errorf("unknown day %q", d)
+		}
+		w.days[day] = true
+	}
+
+	var err error
+	if w.start, err = parseClock(start); err != nil {
+		return Window{}, fmt.Errorf("start: %w", err)
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return Window{}, fmt.Errorf("end: %w", err)
+	}
+	if w.start == w.end {
+		return Window{}, fmt.Errorf("start and end are both %s", start)
+	}
+	return w, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Active reports whether t falls in the window, in t's location
+func (w Window) Active(t time.Time) bool {
+	// A window opened yesterday may still be running past midnight
+	for _, offset := range []int{-1, 0} {
+		y, m, d := t.Date()
+		day := time.Date(y, m, d+offset, 0, 0, 0, 0, t.Location())
+		if !w.days[day.Weekday()] {
+			continue
+		}
+		open := time.Date(y, m, d+offset, w.start/60, w.start%60, 0, 0, t.Location())
+		closeDay := d + offset
+		if w.end < w.start {
+			closeDay++
+		}
+		closed := time.Date(y, m, closeDay, w.end/60, w.end%60, 0, 0, t.Location())
+		if !t.Before(open) && t.Before(closed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	// Friday and Saturday evenings, running past midnight
+	w, err := ParseWindow([]string{"fri", "Saturday"}, "18:30", "01:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day int, clock string) time.Time {
+		c, _ := time.Parse("15:04", clock)
+		// 2026-10-16 is a Friday
+		return time.Date(2026, 10, day, c.Hour(), c.Minute(), 0, 0, time.UTC)
+	}
+	for _, tc := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(16, "18:29"), false},
+		{at(16, "18:30"), true},
+		{at(16, "23:59"), true},
+		{at(17, "00:59"), true}, // Friday's window, on Saturday
+		{at(17, "01:00"), false},
+		{at(17, "20:00"), true},
+		{at(18, "00:30"), true}, // Saturday's window, on Sunday
+		{at(18, "20:00"), false},
+		{at(15, "20:00"), false},
+	} {
+		if got := w.Active(tc.t); got != tc.want {
+			t.Errorf("Active(%s) = %v, want %v", tc.t.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestParseWindowErrors(t *testing.T) {
+	for _, tc := range []struct {
+		days       []string
+		start, end string
+	}{
+		{[]string{"funday"}, "09:00", "10:00"},
+		{nil, "9am", "10:00"},
+		{nil, "09:00", "25:00"},
+		{nil, "09:00", "09:00"},
+	} {
+		if _, err := ParseWindow(tc.days, tc.start, tc.end); err == nil {
+			t.Errorf("ParseWindow(%v, %s, %s) succeeded", tc.days, tc.start, tc.end)
+		}
+	}
+}
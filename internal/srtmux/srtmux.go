@@ -0,0 +1,413 @@
+// Package srtmux shares one SRT listener port between several FFmpeg
+// listeners, routing each inbound caller by the stream ID in its handshake.
+//
+// FFmpeg's SRT listener accepts a single caller on its own port. The mux
+// binds the public port, answers the caller's induction handshake itself,
+// reads the stream ID from the conclusion handshake and then opens a
+// handshake with the matching backend listener on loopback, swapping in
+// the backend's SYN cookie. After that, packets are relayed unchanged in
+// both directions.
+package srtmux
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+)
+
+var logger = logging.For("srtmux")
+
+// Handshake layout: a 16-byte control packet header followed by the
+// handshake body, then extensions
+const (
+	headerLen    = 16
+	handshakeLen = headerLen + 48
+
+	ctrlHandshake = 0x8000
+	ctrlShutdown  = 0x8005
+
+	hsInduction  = 0x00000001
+	hsConclusion = 0xFFFFFFFF
+
+	srtMagic = 0x4A17 // Extension field of a v5 induction response
+	extSID   = 5      // Stream ID handshake extension
+
+	// Rejection handshake type: URQ_FAILURE_TYPES + SRT_REJX_NOTFOUND
+	rejectNotFound = 1000 + 1404
+)
+
+// IdleTimeout closes a session when neither side has sent anything for this
+// long
+var IdleTimeout = 30 * time.Second
+
+// Mux routes SRT callers on one port to backend listeners by stream ID
+type Mux struct {
+	conn *net.UDPConn
+
+	mu       sync.Mutex
+	routes   map[string]*net.UDPAddr
+	sessions map[string]*session // By caller address
+	closed   bool
+}
+
+type session struct {
+	caller   *net.UDPAddr
+	socketID uint32 // Ours, sent in the induction response
+	cookie   uint32
+
+	mu            sync.Mutex
+	upstream      *net.UDPConn // Nil until the caller's stream ID is routed
+	backendCookie uint32       // Zero until the backend answers induction
+	pending       []byte       // Latest conclusion waiting for the backend cookie
+	lastSeen      time.Time
+}
+
+// Listen binds addr (e.g. ":9000") for inbound SRT callers
+func Listen(addr string) (*Mux, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", addr, err)
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", addr, err)
+	}
+	return &Mux{
+		conn:     conn,
+		routes:   make(map[string]*net.UDPAddr),
+		sessions: make(map[string]*session),
+	}, nil
+}
+
+// Addr returns the bound address
+func (m *Mux) Addr() net.Addr {
+	return m.conn.LocalAddr()
+}
+
+// Route sends callers with streamID to the SRT listener at backend,
+// replacing any earlier route. Established sessions are not moved.
+func (m *Mux) Route(streamID string, backend *net.UDPAddr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes[streamID] = backend
+}
+
+// Unroute stops accepting callers with streamID
+func (m *Mux) Unroute(streamID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.routes, streamID)
+}
+
+// Routes returns the number of routed stream IDs
+func (m *Mux) Routes() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.routes)
+}
+
+// Close stops the mux and drops all sessions
+func (m *Mux) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	sessions := m.sessions
+	m.sessions = make(map[string]*session)
+	m.mu.Unlock()
+	for _, s := range sessions {
+		s.close()
+	}
+	return m.conn.Close()
+}
+
+// Serve relays packets until Close
+func (m *Mux) Serve() error {
+	buf := make([]byte, 65536)
+	for {
+		n, caller, err := m.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("read: %w", err)
+		}
+		m.handleCaller(buf[:n], caller)
+	}
+}
+
+func (m *Mux) handleCaller(p []byte, caller *net.UDPAddr) {
+	key := caller.String()
+	m.mu.Lock()
+	s := m.sessions[key]
+	m.mu.Unlock()
+
+	switch {
+	case isHandshake(p, hsInduction):
+		if s != nil && s.routed() {
+			// The caller reconnected from the same address
+			m.drop(key, s)
+			s = nil
+		}
+		if s == nil {
+			s = &session{caller: caller, socketID: random32(), cookie: random32()}
+			m.mu.Lock()
+			m.sessions[key] = s
+			m.mu.Unlock()
+		}
+		s.touch()
+		m.send(inductionResponse(p, s.socketID, s.cookie, caller), caller)
+
+	case isHandshake(p, hsConclusion):
+		if s == nil {
+			return
+		}
+		s.touch()
+		if !s.routed() {
+			if binary.BigEndian.Uint32(p[44:48]) != s.cookie {
+				return
+			}
+			m.route(key, s, p)
+			return
+		}
+		s.forwardConclusion(p)
+
+	default:
+		if s == nil {
+			return
+		}
+		s.touch()
+		s.forward(p)
+		if isControl(p, ctrlShutdown) {
+			m.drop(key, s)
+		}
+	}
+}
+
+// route connects a caller to the backend for its stream ID, or rejects it
+func (m *Mux) route(key string, s *session, conclusion []byte) {
+	streamID := StreamID(conclusion)
+	m.mu.Lock()
+	backend := m.routes[streamID]
+	m.mu.Unlock()
+	if backend == nil {
+		logger.Warn("Rejected SRT caller with unknown stream ID", "caller", s.caller, "streamid", streamID)
+		m.send(rejection(conclusion, s.socketID), s.caller)
+		m.drop(key, s)
+		return
+	}
+
+	upstream, err := net.DialUDP("udp", nil, backend)
+	if err != nil {
+		logger.Warn("Failed to reach SRT backend", "backend", backend, "error", err)
+		m.drop(key, s)
+		return
+	}
+	s.mu.Lock()
+	s.upstream = upstream
+	s.pending = append([]byte(nil), conclusion...)
+	s.mu.Unlock()
+
+	logger.Info("SRT caller routed", "caller", s.caller, "streamid", streamID, "backend", backend)
+	go m.relayBackend(key, s)
+	upstream.Write(inductionRequest(conclusion))
+}
+
+// relayBackend copies packets from the backend to the caller, swallowing the
+// backend's induction response
+func (m *Mux) relayBackend(key string, s *session) {
+	defer m.drop(key, s)
+	buf := make([]byte, 65536)
+	for {
+		s.upstream.SetReadDeadline(time.Now().Add(IdleTimeout))
+		n, err := s.upstream.Read(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() && !s.idle() {
+				continue
+			}
+			return
+		}
+		p := buf[:n]
+		if isHandshake(p, hsInduction) {
+			s.mu.Lock()
+			first := s.backendCookie == 0
+			s.backendCookie = binary.BigEndian.Uint32(p[44:48])
+			pending := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+			if first && pending != nil {
+				s.forwardConclusion(pending)
+			}
+			continue
+		}
+		m.send(p, s.caller)
+		if isControl(p, ctrlShutdown) {
+			return
+		}
+	}
+}
+
+func (m *Mux) send(p []byte, to *net.UDPAddr) {
+	m.conn.WriteToUDP(p, to)
+}
+
+func (m *Mux) drop(key string, s *session) {
+	m.mu.Lock()
+	if m.sessions[key] == s {
+		delete(m.sessions, key)
+	}
+	m.mu.Unlock()
+	s.close()
+}
+
+func (s *session) routed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.upstream != nil
+}
+
+func (s *session) touch() {
+	s.mu.Lock()
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *session) idle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Since(s.lastSeen) >= IdleTimeout
+}
+
+// forwardConclusion sends a caller's conclusion to the backend with the
+// backend's cookie, or holds it until the backend has answered induction
+func (s *session) forwardConclusion(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.upstream == nil {
+		return
+	}
+	if s.backendCookie == 0 {
+		s.pending = append(s.pending[:0], p...)
+		return
+	}
+	out := append([]byte(nil), p...)
+	binary.BigEndian.PutUint32(out[44:48], s.backendCookie)
+	s.upstream.Write(out)
+}
+
+func (s *session) forward(p []byte) {
+	s.mu.Lock()
+	upstream, ready := s.upstream, s.backendCookie != 0
+	s.mu.Unlock()
+	if upstream != nil && ready {
+		upstream.Write(p)
+	}
+}
+
+func (s *session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.upstream != nil {
+		s.upstream.Close()
+	}
+}
+
+func isControl(p []byte, typ uint16) bool {
+	return len(p) >= headerLen && binary.BigEndian.Uint16(p[0:2]) == typ
+}
+
+func isHandshake(p []byte, hsType uint32) bool {
+	return len(p) >= handshakeLen && isControl(p, ctrlHandshake) &&
+		binary.BigEndian.Uint32(p[36:40]) == hsType
+}
+
+// StreamID returns the stream ID extension of a conclusion handshake, or ""
+func StreamID(p []byte) string {
+	for off := handshakeLen; off+4 <= len(p); {
+		typ := binary.BigEndian.Uint16(p[off : off+2])
+		size := int(binary.BigEndian.Uint16(p[off+2:off+4])) * 4
+		off += 4
+		if off+size > len(p) {
+			return ""
+		}
+		if typ == extSID {
+			return decodeSID(p[off : off+size])
+		}
+		off += size
+	}
+	return ""
+}
+
+// decodeSID undoes the stream ID encoding: each 32-bit word is stored
+// byte-reversed, and the last one is padded with zeros
+func decodeSID(b []byte) string {
+	out := make([]byte, 0, len(b))
+	for i := 0; i+4 <= len(b); i += 4 {
+		out = append(out, b[i+3], b[i+2], b[i+1], b[i])
+	}
+	for len(out) > 0 && out[len(out)-1] == 0 {
+		out = out[:len(out)-1]
+	}
+	return string(out)
+}
+
+// inductionResponse answers a caller's induction request as a v5 listener
+func inductionResponse(req []byte, socketID, cookie uint32, caller *net.UDPAddr) []byte {
+	resp := append([]byte(nil), req[:handshakeLen]...)
+	copy(resp[12:16], req[40:44]) // Destination: the caller's socket
+	binary.BigEndian.PutUint32(resp[16:20], 5)
+	binary.BigEndian.PutUint16(resp[20:22], 0)
+	binary.BigEndian.PutUint16(resp[22:24], srtMagic)
+	binary.BigEndian.PutUint32(resp[40:44], socketID)
+	binary.BigEndian.PutUint32(resp[44:48], cookie)
+	clear(resp[48:64])
+	if ip4 := caller.IP.To4(); ip4 != nil {
+		copy(resp[48:52], ip4)
+	} else {
+		copy(resp[48:64], caller.IP.To16())
+	}
+	return resp
+}
+
+// inductionRequest starts a handshake with a backend on the caller's behalf,
+// keeping the caller's socket ID so the backend addresses it directly
+func inductionRequest(conclusion []byte) []byte {
+	req := append([]byte(nil), conclusion[:handshakeLen]...)
+	binary.BigEndian.PutUint32(req[12:16], 0)
+	binary.BigEndian.PutUint32(req[16:20], 4)
+	binary.BigEndian.PutUint16(req[20:22], 0)
+	binary.BigEndian.PutUint16(req[22:24], 2)
+	binary.BigEndian.PutUint32(req[36:40], hsInduction)
+	binary.BigEndian.PutUint32(req[44:48], 0)
+	clear(req[48:64])
+	return req
+}
+
+// rejection refuses a caller's conclusion with "not found"
+func rejection(conclusion []byte, socketID uint32) []byte {
+	resp := append([]byte(nil), conclusion[:handshakeLen]...)
+	copy(resp[12:16], conclusion[40:44])
+	binary.BigEndian.PutUint32(resp[16:20], 5)
+	binary.BigEndian.PutUint16(resp[22:24], 0)
+	binary.BigEndian.PutUint32(resp[36:40], rejectNotFound)
+	binary.BigEndian.PutUint32(resp[40:44], socketID)
+	return resp
+}
+
+func random32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	if v := binary.BigEndian.Uint32(b[:]); v != 0 {
+		return v
+	}
+	return 1
+}
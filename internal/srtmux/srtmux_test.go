@@ -0,0 +1,144 @@
+package srtmux
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// encodeSID builds a stream ID extension, the inverse of decodeSID
+func encodeSID(id string) []byte {
+	padded := make([]byte, (len(id)+3)/4*4)
+	copy(padded, id)
+	ext := make([]byte, 4, 4+len(padded))
+	binary.BigEndian.PutUint16(ext[0:2], extSID)
+	binary.BigEndian.PutUint16(ext[2:4], uint16(len(padded)/4))
+	for i := 0; i < len(padded); i += 4 {
+		ext = append(ext, padded[i+3], padded[i+2], padded[i+1], padded[i])
+	}
+	return ext
+}
+
+func handshake(hsType, socketID, cookie uint32) []byte {
+	p := make([]byte, handshakeLen)
+	binary.BigEndian.PutUint16(p[0:2], ctrlHandshake)
+	binary.BigEndian.PutUint32(p[36:40], hsType)
+	binary.BigEndian.PutUint32(p[40:44], socketID)
+	binary.BigEndian.PutUint32(p[44:48], cookie)
+	return p
+}
+
+func read(t *testing.T, conn *net.UDPConn) []byte {
+	t.Helper()
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf[:n]
+}
+
+func TestStreamID(t *testing.T) {
+	p := append(handshake(hsConclusion, 1, 2), encodeSID("cam-2")...)
+	if got := StreamID(p); got != "cam-2" {
+		t.Errorf("StreamID = %q, want cam-2", got)
+	}
+	if got := StreamID(handshake(hsConclusion, 1, 2)); got != "" {
+		t.Errorf("StreamID without extension = %q", got)
+	}
+}
+
+func TestRouteByStreamID(t *testing.T) {
+	backend, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	m, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	go m.Serve()
+	m.Route("cam1", backend.LocalAddr().(*net.UDPAddr))
+
+	caller, err := net.DialUDP("udp", nil, m.Addr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+
+	// Induction is answered by the mux
+	caller.Write(handshake(hsInduction, 0x1111, 0))
+	resp := read(t, caller)
+	if binary.BigEndian.Uint16(resp[22:24]) != srtMagic || binary.BigEndian.Uint32(resp[12:16]) != 0x1111 {
+		t.Fatalf("bad induction response % x", resp[:24])
+	}
+	cookie := binary.BigEndian.Uint32(resp[44:48])
+
+	// The conclusion triggers a backend induction, then arrives with the
+	// backend's cookie
+	caller.Write(append(handshake(hsConclusion, 0x1111, cookie), encodeSID("cam1")...))
+	req := read(t, backend)
+	if !isHandshake(req, hsInduction) || binary.BigEndian.Uint32(req[40:44]) != 0x1111 {
+		t.Fatalf("backend got % x, want induction for the caller's socket", req[:48])
+	}
+	buf := make([]byte, 2048)
+	backend.WriteToUDP(handshake(hsInduction, 0x2222, 0xBEEF), mustUpstream(t, m))
+	concl := read(t, backend)
+	if !isHandshake(concl, hsConclusion) || binary.BigEndian.Uint32(concl[44:48]) != 0xBEEF || StreamID(concl) != "cam1" {
+		t.Fatalf("backend got % x, want conclusion with its cookie", concl)
+	}
+
+	// Afterwards packets pass through both ways
+	caller.Write([]byte("data packet from caller"))
+	backend.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, from, err := backend.ReadFromUDP(buf)
+	if err != nil || string(buf[:n]) != "data packet from caller" {
+		t.Fatalf("backend got %q, %v", buf[:n], err)
+	}
+	backend.WriteToUDP([]byte("ack from backend"), from)
+	if got := read(t, caller); string(got) != "ack from backend" {
+		t.Fatalf("caller got %q", got)
+	}
+}
+
+// mustUpstream returns the address the mux uses to reach the backend
+func mustUpstream(t *testing.T, m *Mux) *net.UDPAddr {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.upstream != nil {
+			return s.upstream.LocalAddr().(*net.UDPAddr)
+		}
+	}
+	t.Fatal("no routed session")
+	return nil
+}
+
+func TestRejectUnknownStreamID(t *testing.T) {
+	m, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	go m.Serve()
+
+	caller, err := net.DialUDP("udp", nil, m.Addr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+	caller.Write(handshake(hsInduction, 0x1111, 0))
+	cookie := binary.BigEndian.Uint32(read(t, caller)[44:48])
+	caller.Write(append(handshake(hsConclusion, 0x1111, cookie), encodeSID("nope")...))
+	if got := binary.BigEndian.Uint32(read(t, caller)[36:40]); got != rejectNotFound {
+		t.Errorf("handshake type = %d, want rejection %d", got, rejectNotFound)
+	}
+}
@@ -0,0 +1,33 @@
+// Package sysstat reads host CPU and memory usage.
+package sysstat
+
+import "sync"
+
+// Memory is host memory in bytes
+type Memory struct {
+	Total     uint64
+	Available uint64 // Usable without swapping, including reclaimable cache
+}
+
+// CPU measures host CPU usage between calls to Usage
+type CPU struct {
+	mu          sync.Mutex
+	busy, total uint64
+}
+
+// Usage returns the percentage of CPU time spent busy, across all cores,
+// since the previous call. The first call covers the time since boot.
+func (c *CPU) Usage() (float64, error) {
+	busy, total, err := cpuTimes()
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dBusy, dTotal := busy-c.busy, total-c.total
+	c.busy, c.total = busy, total
+	if dTotal == 0 {
+		return 0, nil
+	}
+	return float64(dBusy) / float64(dTotal) * 100, nil
+}
@@ -0,0 +1,85 @@
+package sysstat
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cpuTimes returns busy and total jiffies from the aggregate line of
+// /proc/stat. Idle and iowait count as not busy.
+func cpuTimes() (busy, total uint64, err error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, fmt.Errorf("read cpu times: %w", err)
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil {
+		return 0, 0, fmt.Errorf("read cpu times: %w", err)
+	}
+	return parseCPULine(line)
+}
+
+func parseCPULine(line string) (busy, total uint64, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("unexpected /proc/stat line: %q", line)
+	}
+	var idle uint64
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parse /proc/stat: %w", err)
+		}
+		// guest and guest_nice are already counted in user and nice
+		if i >= 8 {
+			break
+		}
+		total += v
+		if i == 3 || i == 4 { // idle, iowait
+			idle += v
+		}
+	}
+	return total - idle, total, nil
+}
+
+// ReadMemory returns host memory from /proc/meminfo
+func ReadMemory() (Memory, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return Memory{}, fmt.Errorf("read meminfo: %w", err)
+	}
+	return parseMeminfo(string(data))
+}
+
+func parseMeminfo(data string) (Memory, error) {
+	var m Memory
+	for _, line := range strings.Split(data, "\n") {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		var dst *uint64
+		switch key {
+		case "MemTotal":
+			dst = &m.Total
+		case "MemAvailable":
+			dst = &m.Available
+		default:
+			continue
+		}
+		kb, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(rest), " kB"), 10, 64)
+		if err != nil {
+			return Memory{}, fmt.Errorf("parse meminfo %s: %w", key, err)
+		}
+		*dst = kb * 1024
+	}
+	if m.Total == 0 {
+		return Memory{}, fmt.Errorf("meminfo has no MemTotal")
+	}
+	return m, nil
+}
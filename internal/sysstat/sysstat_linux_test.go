@@ -0,0 +1,36 @@
+package sysstat
+
+import "testing"
+
+func TestParseCPULine(t *testing.T) {
+	busy, total, err := parseCPULine("cpu  100 5 50 800 40 3 2 0 7 0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if busy != 160 || total != 1000 {
+		t.Errorf("busy, total = %d, %d; want 160, 1000", busy, total)
+	}
+	if _, _, err := parseCPULine("intr 1 2 3"); err == nil {
+		t.Error("expected error for non-cpu line")
+	}
+}
+
+func TestParseMeminfo(t *testing.T) {
+	m, err := parseMeminfo("MemTotal:       16000 kB\nMemFree:         1000 kB\nMemAvailable:    4000 kB\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Total != 16000*1024 || m.Available != 4000*1024 {
+		t.Errorf("memory = %+v", m)
+	}
+}
+
+func TestCPUUsage(t *testing.T) {
+	var c CPU
+	if _, err := c.Usage(); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := c.Usage(); err != nil || u < 0 || u > 100 {
+		t.Errorf("usage = %v, %v", u, err)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sysstat
+
+import "errors"
+
+func cpuTimes() (busy, total uint64, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
+
+// ReadMemory is not supported on this platform
+func ReadMemory() (Memory, error) {
+	return Memory{}, errors.ErrUnsupported
+}
@@ -0,0 +1,65 @@
+// Package timeseries keeps short in-memory histories of measurements.
+package timeseries
+
+import (
+	"sync"
+	"time"
+)
+
+// Point is a single timestamped value
+type Point struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+// Series holds points newer than its retention, oldest first
+type Series struct {
+	retention time.Duration
+
+	mu     sync.RWMutex
+	points []Point
+}
+
+// New creates a series that keeps points for retention
+func New(retention time.Duration) *Series {
+	return &Series{retention: retention}
+}
+
+// Add records a value and drops points that have aged out
+func (s *Series) Add(t time.Time, v float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.points = append(s.points, Point{Time: t, Value: v})
+	cutoff := t.Add(-s.retention)
+	drop := 0
+	for drop < len(s.points) && s.points[drop].Time.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		s.points = append(s.points[:0], s.points[drop:]...)
+	}
+}
+
+// Since returns the points recorded at or after t
+func (s *Series) Since(t time.Time) []Point {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := len(s.points)
+	for i > 0 && !s.points[i-1].Time.Before(t) {
+		i--
+	}
+	return append([]Point{}, s.points[i:]...)
+}
+
+// Last returns the newest point, if any
+func (s *Series) Last() (Point, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.points) == 0 {
+		return Point{}, false
+	}
+	return s.points[len(s.points)-1], true
+}
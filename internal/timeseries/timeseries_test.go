@@ -0,0 +1,26 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeries(t *testing.T) {
+	s := New(10 * time.Second)
+	start := time.Unix(1000, 0)
+	for i := 0; i <= 20; i++ {
+		s.Add(start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+
+	all := s.Since(time.Time{})
+	if len(all) != 11 || all[0].Value != 10 || all[10].Value != 20 {
+		t.Fatalf("retention not applied: %+v", all)
+	}
+	recent := s.Since(start.Add(18 * time.Second))
+	if len(recent) != 3 || recent[0].Value != 18 {
+		t.Errorf("Since = %+v", recent)
+	}
+	if got := s.Since(start.Add(time.Minute)); got == nil || len(got) != 0 {
+		t.Errorf("Since future = %+v, want empty", got)
+	}
+}
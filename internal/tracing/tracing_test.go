@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx, root := Start(context.Background(), "clip")
+	_, child := Start(ctx, "ffmpeg.concat")
+	End(child, errors.New("exit status 1"))
+
+	header := http.Header{}
+	Inject(ctx, header)
+	if header.Get("traceparent") == "" {
+		t.Error("traceparent header not injected")
+	}
+	End(root, nil)
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	concat, clip := spans[0], spans[1]
+	if concat.Parent().SpanID() != clip.SpanContext().SpanID() {
+		t.Error("concat span is not a child of clip")
+	}
+	if concat.Status().Code != codes.Error {
+		t.Errorf("concat status = %v, want error", concat.Status().Code)
+	}
+	if clip.Status().Code != codes.Unset {
+		t.Errorf("clip status = %v, want unset", clip.Status().Code)
+	}
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,280 @@
+// Package whep serves a live stream to WebRTC players with WHEP (WebRTC-HTTP
+// Egress Protocol). FFmpeg packetizes the stream as RTP to local UDP ports;
+// each packet is forwarded to every connected peer.
+package whep
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v4"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+)
+
+var logger = logging.For("whep")
+
+// ErrSessionNotFound is returned for an unknown session ID
+var ErrSessionNotFound = errors.New("whep session not found")
+
+// ErrTooManySessions is returned when a source already has MaxSessions players
+var ErrTooManySessions = errors.New("too many whep sessions")
+
+// gatherTimeout bounds ICE candidate gathering for an answer
+const gatherTimeout = 5 * time.Second
+
+// Config configures a WHEP source
+type Config struct {
+	ICEServers  []string // STUN/TURN URLs used to gather candidates, e.g. stun:stun.l.google.com:19302
+	PublicIPs   []string // Addresses advertised instead of the host's, e.g. behind 1:1 NAT
+	MaxSessions int      // Concurrent players (0 = unlimited)
+	Audio       bool     // The stream carries Opus audio
+}
+
+// Source fans a channel's RTP stream out to WebRTC players
+type Source struct {
+	cfg    Config
+	api    *webrtc.API
+	pcCfg  webrtc.Configuration
+	video  *webrtc.TrackLocalStaticRTP
+	audio  *webrtc.TrackLocalStaticRTP
+	vconn  *net.UDPConn
+	aconn  *net.UDPConn
+	closed chan struct{}
+
+	mu       sync.Mutex
+	sessions map[string]*webrtc.PeerConnection
+}
+
+// NewSource creates a source and starts listening for RTP on loopback
+func NewSource(cfg Config) (*Source, error) {
+	m := &webrtc.MediaEngine{}
+	if err := m.RegisterDefaultCodecs(); err != nil {
+		return nil, fmt.Errorf("register codecs: %w", err)
+	}
+	// NACK responses and RTCP reports
+	registry := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(m, registry); err != nil {
+		return nil, fmt.Errorf("register interceptors: %w", err)
+	}
+	var settings webrtc.SettingEngine
+	if len(cfg.PublicIPs) > 0 {
+		settings.SetNAT1To1IPs(cfg.PublicIPs, webrtc.ICECandidateTypeHost)
+	}
+
+	s := &Source{
+		cfg:      cfg,
+		api:      webrtc.NewAPI(webrtc.WithMediaEngine(m), webrtc.WithInterceptorRegistry(registry), webrtc.WithSettingEngine(settings)),
+		closed:   make(chan struct{}),
+		sessions: make(map[string]*webrtc.PeerConnection),
+	}
+	if len(cfg.ICEServers) > 0 {
+		s.pcCfg.ICEServers = []webrtc.ICEServer{{URLs: cfg.ICEServers}}
+	}
+
+	var err error
+	s.video, err = webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{
+		MimeType:    webrtc.MimeTypeH264,
+		ClockRate:   90000,
+		SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+	}, "video", "capture")
+	if err != nil {
+		return nil, fmt.Errorf("create video track: %w", err)
+	}
+	if s.vconn, err = listenRTP(); err != nil {
+		return nil, err
+	}
+	if cfg.Audio {
+		s.audio, err = webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeOpus,
+			ClockRate: 48000,
+			Channels:  2,
+		}, "audio", "capture")
+		if err != nil {
+			s.vconn.Close()
+			return nil, fmt.Errorf("create audio track: %w", err)
+		}
+		if s.aconn, err = listenRTP(); err != nil {
+			s.vconn.Close()
+			return nil, err
+		}
+		recovery.Go("whep audio", func() { s.forward(s.aconn, s.audio) })
+	}
+	recovery.Go("whep video", func() { s.forward(s.vconn, s.video) })
+	return s, nil
+}
+
+// listenRTP opens a loopback UDP port for FFmpeg's RTP output
+func listenRTP() (*net.UDPConn, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, fmt.Errorf("listen rtp: %w", err)
+	}
+	return conn, nil
+}
+
+// VideoURL is where FFmpeg sends H.264 RTP
+func (s *Source) VideoURL() string {
+	return rtpURL(s.vconn)
+}
+
+// AudioURL is where FFmpeg sends Opus RTP, or "" without audio
+func (s *Source) AudioURL() string {
+	if s.aconn == nil {
+		return ""
+	}
+	return rtpURL(s.aconn)
+}
+
+// rtpURL keeps packets under a typical path MTU once SRTP overhead is added
+func rtpURL(conn *net.UDPConn) string {
+	return fmt.Sprintf("rtp://%s?pkt_size=1200", conn.LocalAddr())
+}
+
+// forward copies RTP packets from FFmpeg to every peer on a track
+func (s *Source) forward(conn *net.UDPConn, track *webrtc.TrackLocalStaticRTP) {
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.closed:
+			default:
+				logger.Warn("RTP read failed", "track", track.Kind(), "error", err)
+			}
+			return
+		}
+		// Fails only for peers that have gone away; they are removed on
+		// their state change
+		track.Write(buf[:n])
+	}
+}
+
+// Sessions returns the number of connected players
+func (s *Source) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// Subscribe answers a player's SDP offer, returning the SDP answer with all
+// ICE candidates (WHEP doesn't require trickle ICE) and the session ID
+func (s *Source) Subscribe(offer string) (answer, id string, err error) {
+	s.mu.Lock()
+	full := s.cfg.MaxSessions > 0 && len(s.sessions) >= s.cfg.MaxSessions
+	s.mu.Unlock()
+	if full {
+		return "", "", ErrTooManySessions
+	}
+
+	pc, err := s.api.NewPeerConnection(s.pcCfg)
+	if err != nil {
+		return "", "", fmt.Errorf("create peer connection: %w", err)
+	}
+	tracks := []*webrtc.TrackLocalStaticRTP{s.video}
+	if s.audio != nil {
+		tracks = append(tracks, s.audio)
+	}
+	for _, track := range tracks {
+		sender, err := pc.AddTrack(track)
+		if err != nil {
+			pc.Close()
+			return "", "", fmt.Errorf("add %s track: %w", track.Kind(), err)
+		}
+		// Read RTCP so the interceptors see NACKs and receiver reports
+		recovery.Go("whep rtcp", func() {
+			buf := make([]byte, 1500)
+			for {
+				if _, _, err := sender.Read(buf); err != nil {
+					return
+				}
+			}
+		})
+	}
+
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer}); err != nil {
+		pc.Close()
+		return "", "", fmt.Errorf("invalid offer: %w", err)
+	}
+	desc, err := pc.CreateAnswer(nil)
+	if err != nil {
+		pc.Close()
+		return "", "", fmt.Errorf("create answer: %w", err)
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(desc); err != nil {
+		pc.Close()
+		return "", "", fmt.Errorf("set answer: %w", err)
+	}
+	select {
+	case <-gathered:
+	case <-time.After(gatherTimeout):
+		logger.Warn("ICE gathering timed out; answering with the candidates found")
+	}
+
+	id = newSessionID()
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		switch state {
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			s.remove(id, pc)
+		}
+	})
+	s.mu.Lock()
+	s.sessions[id] = pc
+	s.mu.Unlock()
+	return pc.LocalDescription().SDP, id, nil
+}
+
+// Unsubscribe ends a player's session
+func (s *Source) Unsubscribe(id string) error {
+	s.mu.Lock()
+	pc, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		return ErrSessionNotFound
+	}
+	s.remove(id, pc)
+	return nil
+}
+
+// remove closes a session's peer connection once
+func (s *Source) remove(id string, pc *webrtc.PeerConnection) {
+	s.mu.Lock()
+	if s.sessions[id] != pc {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.sessions, id)
+	s.mu.Unlock()
+	pc.Close()
+}
+
+// Close ends every session and stops listening for RTP
+func (s *Source) Close() error {
+	close(s.closed)
+	s.vconn.Close()
+	if s.aconn != nil {
+		s.aconn.Close()
+	}
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*webrtc.PeerConnection)
+	s.mu.Unlock()
+	for _, pc := range sessions {
+		pc.Close()
+	}
+	return nil
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,66 @@
+package whep
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// testOffer creates a receive-only player offer
+func testOffer(t *testing.T) string {
+	t.Helper()
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	for _, kind := range []webrtc.RTPCodecType{webrtc.RTPCodecTypeVideo, webrtc.RTPCodecTypeAudio} {
+		if _, err := pc.AddTransceiverFromKind(kind, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionRecvonly}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return offer.SDP
+}
+
+func TestSubscribe(t *testing.T) {
+	s, err := NewSource(Config{MaxSessions: 1, Audio: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !strings.HasPrefix(s.VideoURL(), "rtp://127.0.0.1:") || s.AudioURL() == "" {
+		t.Fatalf("unexpected rtp urls %s, %s", s.VideoURL(), s.AudioURL())
+	}
+
+	answer, id, err := s.Subscribe(testOffer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"H264", "opus", "a=sendonly", "a=candidate:"} {
+		if !strings.Contains(answer, want) {
+			t.Errorf("answer missing %q:\n%s", want, answer)
+		}
+	}
+	if s.Sessions() != 1 {
+		t.Errorf("sessions = %d, want 1", s.Sessions())
+	}
+
+	if _, _, err := s.Subscribe(testOffer(t)); !errors.Is(err, ErrTooManySessions) {
+		t.Errorf("second subscribe: err = %v, want ErrTooManySessions", err)
+	}
+	if err := s.Unsubscribe(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unsubscribe(id); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("second unsubscribe: err = %v, want ErrSessionNotFound", err)
+	}
+	if s.Sessions() != 0 {
+		t.Errorf("sessions = %d, want 0", s.Sessions())
+	}
+}
@@ -0,0 +1,281 @@
+// Package agent runs a complete capture agent inside another Go program.
+//
+// An Agent wires together the capture manager, the HTTP API, platform
+// registration and heartbeats, the same way the capture command does:
+//
+//	cfg, err := capture.LoadConfig("capture.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	a, err := agent.New(cfg, agent.WithLogHandler(myHandler))
+//	if err != nil {
+//		return err
+//	}
+//	return a.Run(ctx) // blocks until ctx is cancelled
+//
+// The agent never exits the process or writes to stderr once a log handler
+// is set; every failure is returned from New or Run.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/aggregator"
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/capture"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/grpcapi"
+	"github.com/video-system/go-video-capture/pkg/jobs"
+)
+
+var logger = logging.For("agent")
+
+// shutdownTimeout bounds how long Run waits for the API and the final
+// heartbeat after ctx is cancelled
+const shutdownTimeout = 5 * time.Second
+
+// Option configures an Agent
+type Option func(*options)
+
+type options struct {
+	noAPI   bool
+	version string
+	sinks   []sinkOption
+}
+
+type sinkOption struct {
+	name  string
+	sink  events.Sink
+	types []events.Type
+}
+
+// WithoutAPI disables the HTTP API, for programs that drive the agent
+// through Manager directly
+func WithoutAPI() Option {
+	return func(o *options) { o.noAPI = true }
+}
+
+// WithLogHandler sends the agent's logs to h instead of stderr. The handler
+// is process-wide: it applies to every agent in the program.
+func WithLogHandler(h slog.Handler) Option {
+	return func(o *options) { logging.SetHandler(h) }
+}
+
+// WithVersion sets the version reported to the platform
+func WithVersion(v string) Option {
+	return func(o *options) { o.version = v }
+}
+
+// WithEventSink delivers events of the given types (all if none) to sink
+func WithEventSink(name string, sink events.Sink, types ...events.Type) Option {
+	return func(o *options) { o.sinks = append(o.sinks, sinkOption{name, sink, types}) }
+}
+
+// Agent is a capture agent
+type Agent struct {
+	cfg     *capture.Config
+	opts    options
+	manager *capture.Manager
+	api     *api.Server     // nil when disabled
+	grpc    *grpcapi.Server // nil when disabled
+	jobs    *jobs.Queue     // Clip exports, shared by the HTTP and gRPC APIs
+
+	// Front-end for peer agents, nil when disabled
+	aggregator       *aggregator.Aggregator
+	aggregatorServer *http.Server
+
+	mu      sync.Mutex
+	running bool
+}
+
+// New validates cfg and creates an agent. Nothing starts until Run.
+func New(cfg *capture.Config, opts ...Option) (_ *Agent, err error) {
+	o := options{version: "dev"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	manager, err := capture.NewManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create manager: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			manager.Stop()
+		}
+	}()
+	for _, s := range o.sinks {
+		manager.Events().Attach(s.name, s.sink, s.types...)
+	}
+
+	a := &Agent{cfg: cfg, opts: o, manager: manager}
+	a.jobs = jobs.New(jobs.Config{
+		Workers:   cfg.API.Jobs.Workers,
+		Retention: cfg.API.Jobs.Retention,
+	})
+	defer func() {
+		if err != nil {
+			a.jobs.Close()
+		}
+	}()
+	if !o.noAPI {
+		a.api = api.NewServer(api.ServerConfig{
+			Host:    cfg.API.Host,
+			Port:    cfg.API.Port,
+			Manager: manager,
+			APIKeys: cfg.API.Auth.Keys,
+			Events:  manager.Events(),
+
+			ProtectHLS: cfg.API.Auth.ProtectHLS,
+			EncryptHLS: cfg.HLS.Encryption.Enabled,
+			Jobs:       a.jobs,
+		})
+		if cfg.API.GRPC.Enabled {
+			a.grpc = grpcapi.New(grpcapi.Config{
+				Host:    cfg.API.Host,
+				Port:    cfg.API.GRPC.Port,
+				Manager: manager,
+				Events:  manager.Events(),
+				Jobs:    a.jobs,
+				APIKeys: cfg.API.Auth.Keys,
+			})
+		}
+	}
+	if cfg.Aggregator.Enabled {
+		if a.aggregator, a.aggregatorServer, err = newAggregator(cfg); err != nil {
+			return nil, fmt.Errorf("create aggregator: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// Manager returns the capture manager, for controlling channels in-process
+func (a *Agent) Manager() *capture.Manager {
+	return a.manager
+}
+
+// Events returns the event bus shared by all channels
+func (a *Agent) Events() *events.Bus {
+	return a.manager.Events()
+}
+
+// Run starts capture, the API and platform registration, and blocks until
+// ctx is cancelled or the API server fails. It then stops everything and
+// returns the first error. An Agent can only run once.
+func (a *Agent) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.running {
+		a.mu.Unlock()
+		return errors.New("agent already started")
+	}
+	a.running = true
+	a.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heartbeat := a.startPlatform(ctx)
+
+	if err := a.manager.Start(ctx); err != nil {
+		cancel()
+		a.manager.Stop()
+		a.jobs.Close()
+		<-heartbeat
+		return fmt.Errorf("start channels: %w", err)
+	}
+
+	apiErr := make(chan error, 1)
+	if a.api != nil {
+		go func() { apiErr <- a.api.Start() }()
+
+		if a.cfg.API.MDNS.Enabled {
+			// Discovery is a convenience; the API works without it
+			if srv, err := a.advertise(); err != nil {
+				logger.Warn("Failed to advertise API over mDNS", "error", err)
+			} else {
+				logger.Info("Advertising API over mDNS", "service", mdnsService)
+				defer srv.Close()
+			}
+		}
+	}
+
+	grpcErr := make(chan error, 1)
+	if a.grpc != nil {
+		go func() { grpcErr <- a.grpc.Start() }()
+	}
+
+	aggregatorErr := make(chan error, 1)
+	if a.aggregator != nil {
+		recovery.Go("aggregator", func() { a.aggregator.Run(ctx) })
+		go func() {
+			logger.Info("Aggregator starting", "addr", a.aggregatorServer.Addr)
+			aggregatorErr <- a.aggregatorServer.ListenAndServe()
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-apiErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		} else {
+			err = fmt.Errorf("api server: %w", err)
+		}
+	case err = <-grpcErr:
+		if errors.Is(err, grpc.ErrServerStopped) {
+			err = nil
+		} else {
+			err = fmt.Errorf("grpc server: %w", err)
+		}
+	case err = <-aggregatorErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		} else {
+			err = fmt.Errorf("aggregator server: %w", err)
+		}
+	}
+	cancel()
+
+	a.manager.Stop()
+	if a.api != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if serr := a.api.Shutdown(shutdownCtx); serr != nil {
+			err = errors.Join(err, fmt.Errorf("stop api server: %w", serr))
+		}
+		shutdownCancel()
+	}
+	if a.grpc != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if serr := a.grpc.Shutdown(shutdownCtx); serr != nil {
+			err = errors.Join(err, fmt.Errorf("stop grpc server: %w", serr))
+		}
+		shutdownCancel()
+	}
+	if a.aggregatorServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if serr := a.aggregatorServer.Shutdown(shutdownCtx); serr != nil {
+			err = errors.Join(err, fmt.Errorf("stop aggregator server: %w", serr))
+		}
+		shutdownCancel()
+	}
+	a.jobs.Close()
+	<-heartbeat
+
+	logger.Info("Agent stopped")
+	return err
+}
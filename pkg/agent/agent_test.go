@@ -0,0 +1,15 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/video-system/go-video-capture/pkg/capture"
+)
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	cfg := &capture.Config{}
+	cfg.Alerts.Rules = []capture.AlertRule{{Name: "x", Metric: "nope"}}
+	if _, err := New(cfg, WithoutAPI()); err == nil {
+		t.Fatal("expected error for invalid config")
+	}
+}
@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/video-system/go-video-capture/pkg/aggregator"
+	"github.com/video-system/go-video-capture/pkg/capture"
+)
+
+// newAggregator creates the front-end serving the merged API of the
+// configured peers, and of any agents found over mDNS
+func newAggregator(cfg *capture.Config) (*aggregator.Aggregator, *http.Server, error) {
+	peers := make([]aggregator.Peer, len(cfg.Aggregator.Peers))
+	for i, p := range cfg.Aggregator.Peers {
+		peers[i] = aggregator.Peer{Name: p.Name, URL: p.URL, APIKey: p.APIKey}
+	}
+	agg, err := aggregator.New(aggregator.Config{
+		Peers:    peers,
+		Discover: cfg.Aggregator.Discover,
+		Interval: cfg.Aggregator.Interval,
+		APIKeys:  cfg.API.Auth.Keys,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Aggregator.Host, cfg.Aggregator.Port),
+		Handler: agg.Handler(),
+	}
+	return agg, srv, nil
+}
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+// commandWait is how long each poll waits for commands
+const commandWait = 30 * time.Second
+
+// runCommands long-polls the platform for commands and runs them until ctx
+// is cancelled
+func (a *Agent) runCommands(ctx context.Context, client *platform.Client, agentID string) {
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	backoff := time.Second
+	for ctx.Err() == nil {
+		commands, err := client.PollCommands(ctx, agentID, commandWait)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			platformLog.Warn("Command poll failed", "agent_id", agentID, "error", err, "retry_in", backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+
+		dispatchCommands(commands, &running, func(cmd platform.Command) {
+			result := platform.CommandResult{Status: "ok"}
+			out, err := a.runCommand(ctx, cmd)
+			if err != nil {
+				result = platform.CommandResult{Status: "error", Error: err.Error()}
+				platformLog.Warn("Platform command failed", "id", cmd.ID, "type", cmd.Type, "channel", cmd.ChannelID, "error", err)
+			} else {
+				result.Result = out
+				platformLog.Info("Platform command done", "id", cmd.ID, "type", cmd.Type, "channel", cmd.ChannelID)
+			}
+			if err := client.ReportCommandResult(ctx, agentID, cmd.ID, result); err != nil {
+				platformLog.Warn("Failed to report command result", "id", cmd.ID, "error", err)
+			}
+		})
+	}
+}
+
+// dispatchCommands runs commands in order, except clip generation and
+// channel restarts: those take a while, so they run alongside the rest,
+// tracked by running, and a mark-in queued behind them isn't stamped late
+func dispatchCommands(commands []platform.Command, running *sync.WaitGroup, run func(platform.Command)) {
+	for _, cmd := range commands {
+		if !slowCommand(cmd) {
+			run(cmd)
+			continue
+		}
+		running.Add(1)
+		recovery.Go("platform command", func() {
+			defer running.Done()
+			run(cmd)
+		})
+	}
+}
+
+// slowCommand reports whether a command generates a clip or restarts a
+// channel
+func slowCommand(cmd platform.Command) bool {
+	switch cmd.Type {
+	case platform.CommandGenerateClip, platform.CommandRestartChannel:
+		return true
+	case platform.CommandMarkOut:
+		var p struct {
+			GenerateClip bool                   `json:"generate_clip"`
+			Tags         map[string]interface{} `json:"tags,omitempty"`
+		}
+		return decodeParams(cmd, &p) == nil && (p.GenerateClip || p.Tags != nil)
+	}
+	return false
+}
+
+// runCommand runs one platform command against the manager
+func (a *Agent) runCommand(ctx context.Context, cmd platform.Command) (interface{}, error) {
+	if cmd.Type == platform.CommandSetSession {
+		var p struct {
+			SessionID string `json:"session_id"`
+		}
+		if err := decodeParams(cmd, &p); err != nil {
+			return nil, err
+		}
+		a.manager.SetSession(p.SessionID)
+		return nil, nil
+	}
+
+	var ch api.ChannelInterface
+	var ok bool
+	if cmd.ChannelID == "" {
+		ch, ok = a.manager.GetDefaultChannel()
+	} else {
+		ch, ok = a.manager.GetChannel(cmd.ChannelID)
+	}
+	if !ok {
+		return nil, fmt.Errorf("channel not found: %s", cmd.ChannelID)
+	}
+
+	switch cmd.Type {
+	case platform.CommandMarkIn:
+		var p struct {
+			PlayID string `json:"play_id"`
+		}
+		if err := decodeParams(cmd, &p); err != nil {
+			return nil, err
+		}
+		return nil, ch.StartGhostClip(p.PlayID)
+
+	case platform.CommandMarkOut:
+		var p struct {
+			PlayID       string                 `json:"play_id"`
+			GenerateClip bool                   `json:"generate_clip"`
+			Tags         map[string]interface{} `json:"tags,omitempty"`
+			api.ClipOptions
+		}
+		if err := decodeParams(cmd, &p); err != nil {
+			return nil, err
+		}
+		if err := p.ClipOptions.Validate(); err != nil {
+			return nil, err
+		}
+		if p.GenerateClip || p.Tags != nil {
+			return ch.EndGhostClipAndGenerate(ctx, p.PlayID, p.Tags, p.ClipOptions)
+		}
+		return nil, ch.EndGhostClip(p.PlayID)
+
+	case platform.CommandGenerateClip:
+		var p struct {
+			StartTime int64  `json:"start_time"`
+			EndTime   int64  `json:"end_time"`
+			PlayID    string `json:"play_id"`
+			api.ClipOptions
+		}
+		if err := decodeParams(cmd, &p); err != nil {
+			return nil, err
+		}
+		if err := p.ClipOptions.Validate(); err != nil {
+			return nil, err
+		}
+		return ch.GenerateClip(ctx, p.StartTime, p.EndTime, p.PlayID, p.ClipOptions)
+
+	case platform.CommandRestartChannel:
+		return nil, a.manager.RestartChannel(ch.ID())
+	}
+	return nil, fmt.Errorf("unknown command type: %s", cmd.Type)
+}
+
+func decodeParams(cmd platform.Command, v interface{}) error {
+	if len(cmd.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(cmd.Params, v); err != nil {
+		return fmt.Errorf("%s params: %w", cmd.Type, err)
+	}
+	return nil
+}
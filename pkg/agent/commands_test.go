@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+func TestDispatchCommands(t *testing.T) {
+	commands := []platform.Command{
+		{ID: "1", Type: platform.CommandGenerateClip},
+		{ID: "2", Type: platform.CommandMarkOut, Params: json.RawMessage(`{"play_id":"p1","generate_clip":true}`)},
+		{ID: "3", Type: platform.CommandMarkIn, Params: json.RawMessage(`{"play_id":"p2"}`)},
+		{ID: "4", Type: platform.CommandMarkOut, Params: json.RawMessage(`{"play_id":"p2"}`)},
+	}
+
+	// Clips block until released; the mark-in and mark-out behind them
+	// must still run straight away, in order
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	var running sync.WaitGroup
+	dispatched := make(chan struct{})
+	go func() {
+		dispatchCommands(commands, &running, func(cmd platform.Command) {
+			if slowCommand(cmd) {
+				<-release
+			}
+			mu.Lock()
+			order = append(order, cmd.ID)
+			mu.Unlock()
+		})
+		close(dispatched)
+	}()
+
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("quick commands waited for clip generation")
+	}
+	mu.Lock()
+	if len(order) != 2 || order[0] != "3" || order[1] != "4" {
+		t.Errorf("ran %v before clips finished, want [3 4]", order)
+	}
+	mu.Unlock()
+
+	close(release)
+	running.Wait()
+	if len(order) != 4 {
+		t.Errorf("ran %v, want all four", order)
+	}
+}
@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/video-system/go-video-capture/internal/mdns"
+)
+
+// mdnsService is the DNS-SD service type operator consoles browse for
+const mdnsService = "_gocapture._tcp"
+
+// advertise announces the API on the LAN. TXT records carry what a console
+// needs to list the agent before connecting: ID, version and channels.
+func (a *Agent) advertise() (*mdns.Server, error) {
+	hostname, id, name := a.identity()
+	if a.cfg.API.MDNS.Instance != "" {
+		name = a.cfg.API.MDNS.Instance
+	}
+
+	channels := a.manager.ListChannels()
+	slices.Sort(channels)
+	list := strings.Join(channels, ",")
+	if len(list) > 255-len("channels=") {
+		list = list[:255-len("channels=")] // Consoles fetch the full list from the API
+	}
+
+	auth := "none"
+	if len(a.cfg.API.Auth.Keys) > 0 {
+		auth = "bearer"
+	}
+
+	return mdns.Advertise(mdns.Service{
+		Instance: name,
+		Service:  mdnsService,
+		Host:     hostname,
+		Port:     a.cfg.API.Port,
+		TXT: []string{
+			"id=" + id,
+			"version=" + a.opts.version,
+			"api=/api/v1",
+			"auth=" + auth,
+			"channels=" + list,
+		},
+	})
+}
@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/ndi"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+var platformLog = logging.For("platform")
+
+// startPlatform registers with the platform and starts the heartbeat. The
+// returned channel closes once the heartbeat has sent its offline status
+// after ctx is cancelled, or immediately if the platform is disabled.
+func (a *Agent) startPlatform(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	cfg := a.cfg
+	if !cfg.Platform.Enabled || cfg.Platform.URL == "" {
+		close(done)
+		return done
+	}
+
+	client, err := cfg.Platform.NewClient()
+	if err != nil {
+		platformLog.Warn("Failed to create platform client", "error", err)
+		close(done)
+		return done
+	}
+
+	// Registration failures are not fatal: capture works without the platform
+	agentID, err := a.register(ctx, client)
+	if err != nil {
+		platformLog.Warn("Failed to register with platform", "error", err)
+		close(done)
+		return done
+	}
+	platformLog.Info("Registered with platform", "agent_id", agentID)
+
+	recovery.Go("platform heartbeat", func() {
+		defer close(done)
+		a.runHeartbeat(ctx, client, agentID)
+	})
+	if cfg.Platform.Commands {
+		recovery.Go("platform commands", func() { a.runCommands(ctx, client, agentID) })
+	}
+	return done
+}
+
+// identity returns the host name and the agent's configured or generated
+// ID and display name
+func (a *Agent) identity() (hostname, id, name string) {
+	hostname, _ = os.Hostname()
+
+	// Generate agent ID if not specified
+	id = a.cfg.Platform.AgentID
+	if id == "" {
+		id = fmt.Sprintf("agent-%s", hostname)
+	}
+
+	// Generate agent name if not specified
+	name = a.cfg.Platform.AgentName
+	if name == "" {
+		name = fmt.Sprintf("Capture Agent (%s)", hostname)
+	}
+	return hostname, id, name
+}
+
+// register registers this capture agent with the video platform
+func (a *Agent) register(ctx context.Context, client *platform.Client) (string, error) {
+	cfg := a.cfg
+	hostname, agentID, agentName := a.identity()
+
+	// Determine API URL for this agent
+	agentURL := fmt.Sprintf("http://%s:%d", hostname, cfg.API.Port)
+	if cfg.API.Host != "" && cfg.API.Host != "0.0.0.0" {
+		agentURL = fmt.Sprintf("http://%s:%d", cfg.API.Host, cfg.API.Port)
+	}
+
+	// Check NDI support dynamically
+	ndiSupported := ndi.CheckSupport(ctx)
+
+	// Detect codecs (including AV1) from the encoders compiled into FFmpeg
+	codecs, hwEncoders := a.manager.SupportedCodecs(ctx)
+
+	// Build capabilities based on config and system detection
+	capabilities := platform.AgentCapabilities{
+		CanCaptureSRT:   true, // Supported via FFmpeg
+		CanCaptureRTSP:  true,
+		CanCaptureRTMP:  true,
+		CanCaptureNDI:   ndiSupported,
+		CanCaptureUSB:   true,
+		SupportedCodecs: codecs,
+		MaxResolution:   "3840x2160",
+		MaxBitrate:      50000,
+
+		HardwareEncoders: hwEncoders,
+	}
+
+	req := platform.RegisterAgentRequest{
+		ID:           agentID,
+		Name:         agentName,
+		URL:          agentURL,
+		ChannelID:    cfg.Session.ChannelID,
+		Capabilities: capabilities,
+		Version:      a.opts.version,
+		Hostname:     hostname,
+	}
+
+	agent, err := client.RegisterAgent(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	return agent.ID, nil
+}
+
+// runHeartbeat runs a periodic heartbeat to keep the platform updated
+func (a *Agent) runHeartbeat(ctx context.Context, client *platform.Client, agentID string) {
+	cfg := a.cfg
+	interval := 10 * time.Second
+	if cfg.Platform.HeartbeatSecs > 0 {
+		interval = time.Duration(cfg.Platform.HeartbeatSecs) * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Send final offline heartbeat
+			offlineCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			_, _ = client.Heartbeat(offlineCtx, agentID, platform.AgentHeartbeatRequest{
+				Status:    platform.AgentStatusOffline,
+				ChannelID: cfg.Session.ChannelID,
+			})
+			cancel()
+			return
+		case <-ticker.C:
+			// Determine current status based on manager state
+			status := platform.AgentStatusOnline
+			var errorMsg string
+
+			// Check if any channels are recording
+			if a.manager.IsRecording() {
+				status = platform.AgentStatusRecording
+			}
+
+			// Check for errors
+			if err := a.manager.GetError(); err != nil {
+				status = platform.AgentStatusError
+				errorMsg = err.Error()
+			}
+
+			req := platform.AgentHeartbeatRequest{
+				Status:       status,
+				SessionID:    cfg.Session.SessionID,
+				ChannelID:    cfg.Session.ChannelID,
+				ErrorMessage: errorMsg,
+				Metrics:      a.manager.HeartbeatMetrics(),
+			}
+
+			_, err := client.Heartbeat(ctx, agentID, req)
+			if err != nil {
+				platformLog.Warn("Heartbeat failed", "agent_id", agentID, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,447 @@
+// Package aggregator fronts a rack of capture agents with a single API.
+//
+// It polls each peer agent's channel list, merges them, and reverse-proxies
+// channel requests (/api/v1/channels/{id}/...) and HLS (/hls/{id}/...) to
+// the agent that owns the channel. Channel IDs that collide across agents
+// are qualified with the agent name, e.g. "studio-2.cam1".
+package aggregator
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/mdns"
+)
+
+var logger = logging.For("aggregator")
+
+// Service is the DNS-SD service type agents advertise
+const Service = "_gocapture._tcp"
+
+// Discovered peers are dropped after this many polls without an answer
+const forgetAfter = 12
+
+// Peer is an agent behind the aggregator
+type Peer struct {
+	Name   string // Prefix for colliding channel IDs
+	URL    string // Agent API base URL
+	APIKey string // Bearer token sent on behalf of authorized clients
+}
+
+// Config configures an Aggregator
+type Config struct {
+	Peers    []Peer
+	Discover bool          // Add peers found over mDNS
+	Interval time.Duration // Poll interval (default: 5s)
+
+	// Clients presenting one of these keys have their Authorization replaced
+	// with the peer's APIKey. Other requests are forwarded unchanged, so the
+	// peer decides.
+	APIKeys []string
+}
+
+// Aggregator merges and proxies the APIs of several agents
+type Aggregator struct {
+	cfg    Config
+	client *http.Client
+
+	mu     sync.RWMutex
+	peers  map[string]*peer // By base URL
+	routes map[string]route // Public channel ID -> owner
+}
+
+type peer struct {
+	Peer
+	target     *url.URL
+	proxy      *httputil.ReverseProxy
+	discovered bool
+	version    string
+
+	online   bool
+	lastSeen time.Time
+	misses   int
+	err      string
+	channels []string
+	statuses map[string]map[string]interface{}
+}
+
+type route struct {
+	peer    *peer
+	channel string
+}
+
+// New creates an aggregator for the configured peers. Nothing is polled
+// until Run.
+func New(cfg Config) (*Aggregator, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	a := &Aggregator{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 3 * time.Second},
+		peers:  make(map[string]*peer),
+		routes: make(map[string]route),
+	}
+	for _, p := range cfg.Peers {
+		if _, err := a.addPeer(p, false); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// addPeer registers a peer unless one with the same URL exists. Must be
+// called with a.mu held or before Run.
+func (a *Aggregator) addPeer(p Peer, discovered bool) (*peer, error) {
+	target, err := url.Parse(strings.TrimSuffix(p.URL, "/"))
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("peer %s: invalid url %q", p.Name, p.URL)
+	}
+	if existing, ok := a.peers[target.String()]; ok {
+		return existing, nil
+	}
+	pr := &peer{Peer: p, target: target, discovered: discovered}
+	pr.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Warn("Proxy request failed", "peer", p.Name, "path", r.URL.Path, "error", err)
+			http.Error(w, fmt.Sprintf("Agent %s unavailable", p.Name), http.StatusBadGateway)
+		},
+	}
+	a.peers[target.String()] = pr
+	return pr, nil
+}
+
+// Run polls peers, and discovers new ones if enabled, until ctx is cancelled
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if a.cfg.Discover {
+			a.discover(ctx)
+		}
+		a.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discover adds agents answering an mDNS query
+func (a *Aggregator) discover(ctx context.Context) {
+	lookupCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	found, err := mdns.Lookup(lookupCtx, Service)
+	if err != nil {
+		logger.Warn("mDNS discovery failed", "error", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, inst := range found {
+		host := strings.TrimSuffix(inst.Host, ".")
+		for _, ip := range inst.IPs {
+			if ip.To4() != nil {
+				host = ip.String()
+				break
+			}
+		}
+		name := inst.TXT["id"]
+		if name == "" {
+			name = inst.Name
+		}
+		p := Peer{Name: name, URL: "http://" + net.JoinHostPort(host, strconv.Itoa(inst.Port))}
+		pr, err := a.addPeer(p, true)
+		if err != nil {
+			continue
+		}
+		if pr.discovered {
+			pr.misses = 0
+			pr.version = inst.TXT["version"]
+		}
+	}
+}
+
+// poll refreshes every peer's channels concurrently and rebuilds the routes
+func (a *Aggregator) poll(ctx context.Context) {
+	a.mu.RLock()
+	peers := make([]*peer, 0, len(a.peers))
+	for _, p := range a.peers {
+		peers = append(peers, p)
+	}
+	a.mu.RUnlock()
+
+	type result struct {
+		channels []string
+		statuses map[string]map[string]interface{}
+		err      error
+	}
+	results := make([]result, len(peers))
+	var wg sync.WaitGroup
+	for i, p := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &results[i]
+			r.channels, r.statuses, r.err = a.fetchChannels(ctx, p)
+		}()
+	}
+	wg.Wait()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for i, p := range peers {
+		r := results[i]
+		if r.err != nil {
+			if p.online {
+				logger.Warn("Agent unreachable", "peer", p.Name, "error", r.err)
+			}
+			p.online, p.err = false, r.err.Error()
+			p.misses++
+			if p.discovered && p.misses >= forgetAfter {
+				delete(a.peers, p.target.String())
+			}
+			continue
+		}
+		if !p.online {
+			logger.Info("Agent online", "peer", p.Name, "channels", len(r.channels))
+		}
+		p.online, p.err, p.lastSeen, p.misses = true, "", now, 0
+		p.channels, p.statuses = r.channels, r.statuses
+	}
+	a.rebuildRoutes()
+}
+
+// fetchChannels reads a peer's channel list
+func (a *Aggregator) fetchChannels(ctx context.Context, p *peer) ([]string, map[string]map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.target.String()+"/api/v1/channels", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("list channels: %s", resp.Status)
+	}
+
+	var body struct {
+		Channels []string                          `json:"channels"`
+		Statuses map[string]map[string]interface{} `json:"statuses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, nil, fmt.Errorf("decode channels: %w", err)
+	}
+	return body.Channels, body.Statuses, nil
+}
+
+// rebuildRoutes maps public channel IDs to peers. Must be called with a.mu
+// held. Channels of offline peers keep their routes so requests fail with
+// 502 rather than 404.
+func (a *Aggregator) rebuildRoutes() {
+	count := make(map[string]int)
+	for _, p := range a.peers {
+		for _, id := range p.channels {
+			count[id]++
+		}
+	}
+	routes := make(map[string]route)
+	for _, p := range a.peers {
+		for _, id := range p.channels {
+			public := id
+			if count[id] > 1 {
+				public = p.Name + "." + id
+			}
+			routes[public] = route{peer: p, channel: id}
+		}
+	}
+	a.routes = routes
+}
+
+// Handler returns the aggregated API
+func (a *Aggregator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", cors(a.handleHealthz))
+	mux.HandleFunc("/api/v1/agents", cors(a.handleAgents))
+	mux.HandleFunc("/api/v1/channels", cors(a.handleListChannels))
+
+	// Proxied; the peer adds its own CORS headers
+	mux.HandleFunc("/api/v1/channels/", a.proxyHandler("/api/v1/channels/"))
+	mux.HandleFunc("/hls/", a.proxyHandler("/hls/"))
+	return mux
+}
+
+// cors wraps a local handler with the same CORS headers agents send
+func cors(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Max-Age", "86400")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (a *Aggregator) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	online := 0
+	for _, p := range a.peers {
+		if p.online {
+			online++
+		}
+	}
+	total := len(a.peers)
+	a.mu.RUnlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":        "ok",
+		"agents":        total,
+		"agents_online": online,
+	})
+}
+
+// AgentStatus describes a peer in /api/v1/agents
+type AgentStatus struct {
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	Discovered bool      `json:"discovered"`
+	Version    string    `json:"version,omitempty"`
+	Online     bool      `json:"online"`
+	LastSeen   time.Time `json:"last_seen,omitzero"`
+	Error      string    `json:"error,omitempty"`
+	Channels   []string  `json:"channels"`
+}
+
+// Agents returns the status of every peer, sorted by name
+func (a *Aggregator) Agents() []AgentStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	out := make([]AgentStatus, 0, len(a.peers))
+	for _, p := range a.peers {
+		out = append(out, AgentStatus{
+			Name:       p.Name,
+			URL:        p.target.String(),
+			Discovered: p.discovered,
+			Version:    p.version,
+			Online:     p.online,
+			LastSeen:   p.lastSeen,
+			Error:      p.err,
+			Channels:   p.channels,
+		})
+	}
+	slices.SortFunc(out, func(x, y AgentStatus) int { return strings.Compare(x.Name, y.Name) })
+	return out
+}
+
+func (a *Aggregator) handleAgents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"agents": a.Agents()})
+}
+
+// handleListChannels returns the merged channel list in the agent format.
+// Each status gains "agent" and "agent_online" fields.
+func (a *Aggregator) handleListChannels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.mu.RLock()
+	channels := make([]string, 0, len(a.routes))
+	statuses := make(map[string]interface{}, len(a.routes))
+	for id, rt := range a.routes {
+		channels = append(channels, id)
+		status := map[string]interface{}{}
+		for k, v := range rt.peer.statuses[rt.channel] {
+			status[k] = v
+		}
+		status["agent"] = rt.peer.Name
+		status["agent_online"] = rt.peer.online
+		statuses[id] = status
+	}
+	a.mu.RUnlock()
+	slices.Sort(channels)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"channels": channels,
+		"statuses": statuses,
+	})
+}
+
+// proxyHandler forwards prefix/{id}/... to the channel's agent as
+// prefix/{agent channel id}/...
+func (a *Aggregator) proxyHandler(prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, prefix)
+		id, _, _ := strings.Cut(rest, "/")
+		if id == "" {
+			http.Error(w, "Channel ID required", http.StatusBadRequest)
+			return
+		}
+
+		a.mu.RLock()
+		rt, ok := a.routes[id]
+		a.mu.RUnlock()
+		if !ok {
+			http.Error(w, fmt.Sprintf("Channel not found: %s", id), http.StatusNotFound)
+			return
+		}
+
+		out := r.Clone(r.Context())
+		out.URL.Path = prefix + rt.channel + strings.TrimPrefix(rest, id)
+		out.URL.RawPath = ""
+		if rt.peer.APIKey != "" && a.authorized(r) {
+			out.Header.Set("Authorization", "Bearer "+rt.peer.APIKey)
+		}
+		rt.peer.proxy.ServeHTTP(w, out)
+	}
+}
+
+// authorized reports whether the request carries one of the aggregator's
+// API keys
+func (a *Aggregator) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	for _, key := range a.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package aggregator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// fakeAgent serves a channel list and records proxied requests
+func fakeAgent(t *testing.T, channels []string, seen chan<- *http.Request) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels" {
+			statuses := map[string]interface{}{}
+			for _, id := range channels {
+				statuses[id] = map[string]interface{}{"recording": true}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"channels": channels, "statuses": statuses})
+			return
+		}
+		seen <- r.Clone(context.Background())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAggregateAndProxy(t *testing.T) {
+	seen := make(chan *http.Request, 1)
+	a1 := fakeAgent(t, []string{"cam1", "cam2"}, seen)
+	a2 := fakeAgent(t, []string{"cam1"}, seen)
+
+	agg, err := New(Config{
+		Peers: []Peer{
+			{Name: "studio-1", URL: a1.URL},
+			{Name: "studio-2", URL: a2.URL + "/", APIKey: "peer-key"},
+		},
+		APIKeys: []string{"front-key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agg.poll(context.Background())
+
+	front := httptest.NewServer(agg.Handler())
+	defer front.Close()
+
+	resp, err := http.Get(front.URL + "/api/v1/channels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Channels []string                          `json:"channels"`
+		Statuses map[string]map[string]interface{} `json:"statuses"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+
+	want := []string{"cam2", "studio-1.cam1", "studio-2.cam1"}
+	if !slices.Equal(list.Channels, want) {
+		t.Errorf("channels = %v, want %v", list.Channels, want)
+	}
+	if st := list.Statuses["studio-2.cam1"]; st["agent"] != "studio-2" || st["recording"] != true {
+		t.Errorf("status = %v", st)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, front.URL+"/api/v1/channels/studio-2.cam1/mark/in", nil)
+	req.Header.Set("Authorization", "Bearer front-key")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	got := <-seen
+	if got.URL.Path != "/api/v1/channels/cam1/mark/in" || got.Method != http.MethodPost {
+		t.Errorf("proxied %s %s", got.Method, got.URL.Path)
+	}
+	if auth := got.Header.Get("Authorization"); auth != "Bearer peer-key" {
+		t.Errorf("Authorization = %q, want the peer key", auth)
+	}
+
+	// Unauthorized clients don't get the peer's key
+	req, _ = http.NewRequest(http.MethodGet, front.URL+"/hls/studio-2.cam1/live.m3u8", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	got = <-seen
+	if got.URL.Path != "/hls/cam1/live.m3u8" || got.Header.Get("Authorization") != "Bearer wrong" {
+		t.Errorf("proxied %s with %q", got.URL.Path, got.Header.Get("Authorization"))
+	}
+
+	resp, err = http.Get(front.URL + "/hls/nope/live.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown channel status = %d", resp.StatusCode)
+	}
+}
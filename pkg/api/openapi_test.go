@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPIRoutes checks every route the server registers, and every
+// channel action, is described in openapi.json
+func TestOpenAPIRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("parse openapi.json: %v", err)
+	}
+	described := func(route string) bool {
+		if !strings.HasSuffix(route, "/") {
+			_, ok := spec.Paths[route]
+			return ok
+		}
+		// Subtree patterns need a path beneath them
+		for path := range spec.Paths {
+			if strings.HasPrefix(path, route) {
+				return true
+			}
+		}
+		return false
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "server.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var routes, actions []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if ok && sel.Sel.Name == "HandleFunc" && isIdent(sel.X, "mux") && len(n.Args) > 0 {
+				routes = append(routes, stringLit(t, n.Args[0]))
+			}
+		case *ast.FuncDecl:
+			if n.Name.Name != "handleChannelRoute" {
+				return true
+			}
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if cmp, ok := n.(*ast.BinaryExpr); ok && cmp.Op == token.EQL && isIdent(cmp.X, "action") {
+					actions = append(actions, stringLit(t, cmp.Y))
+				}
+				return true
+			})
+			return false
+		}
+		return true
+	})
+	if len(routes) == 0 || len(actions) == 0 {
+		t.Fatal("no routes found in server.go")
+	}
+
+	for _, route := range routes {
+		if !described(route) {
+			t.Errorf("route %s missing from openapi.json", route)
+		}
+	}
+	for _, action := range actions {
+		path := strings.TrimSuffix("/api/v1/channels/{channel_id}/"+action, "/")
+		if !described(path) {
+			t.Errorf("channel action %q missing from openapi.json (%s)", action, path)
+		}
+	}
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+func stringLit(t *testing.T, e ast.Expr) string {
+	t.Helper()
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		t.Fatalf("expected a string literal, got %T", e)
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
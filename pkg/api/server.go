@@ -1,17 +1,31 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/jobs"
 	"github.com/video-system/go-video-capture/pkg/ndi"
 )
 
+var logger = logging.For("api")
+
 // ChannelInterface defines operations available on a single channel
 type ChannelInterface interface {
 	ID() string
@@ -19,11 +33,127 @@ type ChannelInterface interface {
 	SetSession(sessionID string)
 	StartGhostClip(playID string) error
 	EndGhostClip(playID string) error
-	EndGhostClipAndGenerate(ctx context.Context, playID string, tags map[string]interface{}) (interface{}, error)
-	GenerateClip(ctx context.Context, startTime, endTime int64, playID string) (interface{}, error)
+	CancelGhostClip(playID string) error
+	EndGhostClipAndGenerate(ctx context.Context, playID string, tags map[string]interface{}, opts ClipOptions) (interface{}, error)
+	GenerateClip(ctx context.Context, startTime, endTime int64, playID string, opts ClipOptions) (interface{}, error)
+	ImportRecording(ctx context.Context, path string, start time.Time) (interface{}, error)
+	ListClips() interface{}
+	GetClip(playID string) (interface{}, bool)
+	ClipFilePath(playID, variant string) (string, error)
+	DeleteClip(playID string) error
 	GetHLSPlaylist() ([]byte, error)
+	WaitHLSPlaylist(ctx context.Context, msn, part int) error // LL-HLS blocking reload (part -1 = whole segment)
+	GetHLSPart(ctx context.Context, seq, part int) ([]byte, error)
+	WHEPSubscribe(offer string) (answer, sessionID string, err error)
+	WHEPUnsubscribe(sessionID string) error
+	GetHLSMasterPlaylist() ([]byte, error)
+	GetDASHManifest() ([]byte, error)
+	GetThumbnailPlaylist() ([]byte, error)
+	GetLatestThumbnailPath() (string, error)
+	PreviewFrame() ([]byte, time.Time, error)
+	ExportTimeline(format string) ([]byte, string, error)
+	ExportRange(ctx context.Context, startTime, endTime int64, format string) (interface{}, error)
+	ExportFilePath(name string) (string, error)
+	GetStats(window time.Duration) interface{}
+	GetAudioPlaylist(name string) ([]byte, error)
 	GetSegmentPath() string
+	SegmentData(seq int) ([]byte, bool) // Segments in memory or offloaded, not on local disk
 	GetInitSegmentPath() string
+	GetHLSKey(index int) ([]byte, bool)
+	HLSEncrypted() bool
+	EncryptHLSSegment(seq int, data []byte) ([]byte, error)
+	UpdateEncode(update EncodeUpdate) (interface{}, error)
+	Restreams() interface{}
+	StartRestream(name string) error
+	StopRestream(name string) error
+}
+
+// ErrRestreamNotFound is returned for a restream name the channel doesn't
+// have
+var ErrRestreamNotFound = errors.New("restream not found")
+
+// ErrPlaylistAhead is returned for an LL-HLS blocking reload more than two
+// segments past the live edge
+var ErrPlaylistAhead = errors.New("requested segment too far ahead of the live edge")
+
+// ErrPartNotFound is returned for an LL-HLS part that doesn't exist
+var ErrPartNotFound = errors.New("part not found")
+
+// ErrWHEPNotEnabled is returned for WebRTC playback on a channel without it
+var ErrWHEPNotEnabled = errors.New("webrtc playback not enabled")
+
+// ErrWHEPBusy is returned when a channel already has its maximum players
+var ErrWHEPBusy = errors.New("too many webrtc players")
+
+// ErrWHEPSessionNotFound is returned for an unknown WHEP session
+var ErrWHEPSessionNotFound = errors.New("whep session not found")
+
+// EncodeUpdate changes a channel's live encode; unset fields keep their
+// current value
+type EncodeUpdate struct {
+	Codec   string `json:"codec,omitempty"`   // h264, hevc, av1
+	Preset  string `json:"preset,omitempty"`  // e.g. fast, medium; p1-p7 for nvenc
+	Bitrate int    `json:"bitrate,omitempty"` // Target bitrate in kbps
+}
+
+// ClipOptions are per-request clip export settings
+type ClipOptions struct {
+	PlaybackRate float64 `json:"playback_rate,omitempty"` // Slow motion, e.g. 0.5 (0 = normal speed)
+	Interpolate  bool    `json:"interpolate,omitempty"`   // Interpolate frames when slowing down
+
+	// Audio: mute, pick one track, or mix several (default: the track muxed
+	// with video). Track names are the configured audio_tracks names.
+	Mute       bool           `json:"mute,omitempty"`
+	AudioTrack string         `json:"audio_track,omitempty"`
+	AudioMix   []ClipAudioMix `json:"audio_mix,omitempty"`
+}
+
+// ClipAudioMix is one track in a clip's audio mix
+type ClipAudioMix struct {
+	Track  string  `json:"track"`             // Empty = the track muxed with video
+	GainDB float64 `json:"gain_db,omitempty"` // Level in dB, e.g. -12 to duck natural sound
+}
+
+// Validate rejects playback rates FFmpeg can't produce and conflicting
+// audio options
+func (o ClipOptions) Validate() error {
+	audioOpts := 0
+	for _, set := range []bool{o.Mute, o.AudioTrack != "", len(o.AudioMix) > 0} {
+		if set {
+			audioOpts++
+		}
+	}
+	if audioOpts > 1 {
+		return fmt.Errorf("set only one of mute, audio_track and audio_mix")
+	}
+	if o.AudioTrack != "" && !validTrackName(o.AudioTrack) {
+		return fmt.Errorf("invalid audio_track %q (use a-z, 0-9, _ and -)", o.AudioTrack)
+	}
+	for _, m := range o.AudioMix {
+		if m.Track != "" && !validTrackName(m.Track) {
+			return fmt.Errorf("invalid audio_mix track %q (use a-z, 0-9, _ and -)", m.Track)
+		}
+		if m.GainDB > 24 || m.GainDB < -60 {
+			return fmt.Errorf("audio_mix %q: gain_db must be between -60 and 24", m.Track)
+		}
+	}
+
+	if o.PlaybackRate == 0 {
+		if o.Interpolate {
+			return fmt.Errorf("interpolate requires playback_rate")
+		}
+		return nil
+	}
+	if o.PlaybackRate < ffmpeg.MinSlowMotionRate || o.PlaybackRate >= ffmpeg.MaxSlowMotionRate {
+		return fmt.Errorf("playback_rate must be at least %g and below %g", ffmpeg.MinSlowMotionRate, ffmpeg.MaxSlowMotionRate)
+	}
+	return nil
+}
+
+// validTrackName reports whether name is a usable audio rendition name, the
+// same rule config validation applies to encode.audio_tracks
+func validTrackName(name string) bool {
+	return strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789_-") == ""
 }
 
 // ChannelManager defines operations for managing multiple channels
@@ -33,6 +163,79 @@ type ChannelManager interface {
 	ListChannels() []string
 	GetAllStatuses() map[string]interface{}
 	SetSession(sessionID string)
+	StorageStatus() interface{}
+	UploadsStatus() interface{}
+	Devices() interface{}
+	DiagnosticsBundle(w io.Writer) error
+	Readiness(ctx context.Context) (bool, interface{})
+	SessionReport(format string) ([]byte, string, error)
+	EndSession(ctx context.Context) (interface{}, error)
+	BuildReel(ctx context.Context, req ReelRequest) (interface{}, error)
+	StartGhostClips(playID string) (interface{}, error)
+	ReloadConfig() (interface{}, error)
+	EndGhostClips(ctx context.Context, playID string, tags map[string]interface{}, opts ClipOptions) (interface{}, error)
+	RedundancyStatus() interface{} // nil when not paired
+	WriteMetrics(w io.Writer) error
+}
+
+// ReelRequest asks for a highlight reel built from clips and time ranges
+type ReelRequest struct {
+	ReelID             string                 `json:"reel_id"`
+	Items              []ReelItem             `json:"items"`
+	Transition         string                 `json:"transition,omitempty"`          // cut (default), fade, dissolve, wipeleft, ...
+	TransitionDuration float64                `json:"transition_duration,omitempty"` // Seconds (default 0.5)
+	Title              string                 `json:"title,omitempty"`               // Title slate text
+	TitleDuration      float64                `json:"title_duration,omitempty"`      // Seconds (default 3)
+	Tags               map[string]interface{} `json:"tags,omitempty"`
+}
+
+// ReelItem is one part of a reel: an existing clip or a time range
+type ReelItem struct {
+	ChannelID string `json:"channel_id,omitempty"` // Default channel when empty
+	ClipID    string `json:"clip_id,omitempty"`    // Play ID of a generated clip
+	StartTime int64  `json:"start_time,omitempty"` // Unix ms, with EndTime instead of ClipID
+	EndTime   int64  `json:"end_time,omitempty"`
+}
+
+// validate checks the request shape and fills in defaults
+func (r *ReelRequest) validate() error {
+	if len(r.Items) == 0 {
+		return fmt.Errorf("items is required")
+	}
+	if r.ReelID != "" && !validFileID(r.ReelID) {
+		return fmt.Errorf("invalid reel_id %q", r.ReelID)
+	}
+	for i, item := range r.Items {
+		if item.ClipID != "" && !validFileID(item.ClipID) {
+			return fmt.Errorf("items[%d]: invalid clip_id %q", i, item.ClipID)
+		}
+		hasRange := item.StartTime != 0 || item.EndTime != 0
+		switch {
+		case item.ClipID != "" && hasRange:
+			return fmt.Errorf("items[%d]: set clip_id or start_time/end_time, not both", i)
+		case item.ClipID == "" && item.EndTime <= item.StartTime:
+			return fmt.Errorf("items[%d]: end_time must be after start_time", i)
+		}
+	}
+	if r.Transition == "" {
+		r.Transition = "cut"
+	}
+	if !slices.Contains(ffmpeg.ReelTransitions, r.Transition) {
+		return fmt.Errorf("unknown transition %q", r.Transition)
+	}
+	if r.TransitionDuration == 0 {
+		r.TransitionDuration = 0.5
+	}
+	if r.Title != "" && r.TitleDuration == 0 {
+		r.TitleDuration = 3
+	}
+	if r.TransitionDuration < 0 || r.TitleDuration < 0 {
+		return fmt.Errorf("durations must be positive")
+	}
+	if r.ReelID == "" {
+		r.ReelID = fmt.Sprintf("reel-%d", time.Now().UnixMilli())
+	}
+	return nil
 }
 
 // ServerConfig holds API server configuration
@@ -40,19 +243,28 @@ type ServerConfig struct {
 	Host    string
 	Port    int
 	Manager ChannelManager
+	APIKeys []string    // Bearer tokens; with any set, mutating requests need one
+	Events  *events.Bus // Source for the event stream (optional)
+
+	ProtectHLS bool // Also require a token for HLS/DASH playback
+	EncryptHLS bool // HLS segments are encrypted; WHEP playback isn't, so it needs a token
+
+	Jobs *jobs.Queue // Background clip export queue; the caller closes it
 }
 
 // Server is the HTTP API server
 type Server struct {
-	cfg    ServerConfig
-	server *http.Server
+	cfg     ServerConfig
+	server  *http.Server
+	jobs    *jobs.Queue
+	started time.Time
 }
 
 // corsMiddleware wraps a handler with CORS headers
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
@@ -65,14 +277,66 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// authorized reports whether the request carries one of the configured API keys
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	for _, key := range s.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware requires an API key on mutating requests, and on HLS, DASH
+// and WHEP playback when ProtectHLS is set, and on WHEP playback when HLS is
+// encrypted. Without configured keys everything passes.
+// Read-only endpoints that expose sensitive data check keys themselves.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.cfg.APIKeys) == 0 || r.Method == http.MethodOptions || !s.requiresAuth(r) || s.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Let browser clients read the rejection
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="go-video-capture"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// requiresAuth reports whether the request needs an API key
+func (s *Server) requiresAuth(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/whep/") {
+		// Playback, like HLS, but in the clear
+		return s.cfg.ProtectHLS || s.cfg.EncryptHLS
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return s.cfg.ProtectHLS && strings.HasPrefix(r.URL.Path, "/hls/")
+	}
+	return true
+}
+
 // NewServer creates a new API server
 func NewServer(cfg ServerConfig) *Server {
-	s := &Server{cfg: cfg}
+	s := &Server{cfg: cfg, jobs: cfg.Jobs, started: time.Now()}
+	if s.jobs == nil {
+		s.jobs = jobs.New(jobs.Config{})
+	}
 
 	mux := http.NewServeMux()
 
-	// Health check
+	// Health checks: /healthz is liveness, /readyz checks dependencies
 	mux.HandleFunc("/health", corsMiddleware(s.handleHealth))
+	mux.HandleFunc("/healthz", corsMiddleware(s.handleHealthz))
+	mux.HandleFunc("/readyz", corsMiddleware(s.handleReadyz))
+
+	// API description for client generators
+	mux.HandleFunc("/api/v1/openapi.json", corsMiddleware(s.handleOpenAPI))
 
 	// List all channels
 	mux.HandleFunc("/api/v1/channels", corsMiddleware(s.handleListChannels))
@@ -80,9 +344,51 @@ func NewServer(cfg ServerConfig) *Server {
 	// Channel-specific routes (must come before legacy routes for proper matching)
 	mux.HandleFunc("/api/v1/channels/", corsMiddleware(s.handleChannelRoute))
 
+	// Support bundle
+	mux.HandleFunc("/api/v1/diagnostics/bundle", corsMiddleware(s.handleDiagnosticsBundle))
+
+	// Session report
+	mux.HandleFunc("/api/v1/session/report", corsMiddleware(s.handleSessionReport))
+	mux.HandleFunc("/api/v1/session/end", corsMiddleware(s.handleSessionEnd))
+
+	// Re-read the config file and apply channel changes
+	mux.HandleFunc("/api/v1/config/reload", corsMiddleware(s.handleConfigReload))
+
+	// Highlight reels
+	mux.HandleFunc("/api/v1/reels", corsMiddleware(s.handleReel))
+
+	// Ghost clips on every channel at once (multi-angle)
+	mux.HandleFunc("/api/v1/ghost/start", corsMiddleware(s.handleGhostStart))
+	mux.HandleFunc("/api/v1/ghost/end", corsMiddleware(s.handleGhostEnd))
+
+	// Background clip and reel exports
+	mux.HandleFunc("/api/v1/clips/jobs", corsMiddleware(s.handleJobs))
+	mux.HandleFunc("/api/v1/clips/jobs/", corsMiddleware(s.handleJobs))
+
+	// Redundant pair status, polled by the peer agent
+	mux.HandleFunc("/api/v1/redundancy", corsMiddleware(s.handleRedundancy))
+
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", s.handleMetrics)
+
+	// Storage performance
+	mux.HandleFunc("/api/v1/storage", corsMiddleware(s.handleStorage))
+
+	// Platform uploads waiting for a retry
+	mux.HandleFunc("/api/v1/uploads", corsMiddleware(s.handleUploads))
+
+	// Capture devices, updated as they are plugged in and out
+	mux.HandleFunc("/api/v1/devices", corsMiddleware(s.handleDevices))
+
+	// Event stream (SSE)
+	mux.HandleFunc("/api/v1/events", corsMiddleware(s.handleEvents))
+
 	// HLS per-channel routes
 	mux.HandleFunc("/hls/", corsMiddleware(s.handleHLS))
 
+	// WebRTC playback (WHEP) per channel
+	mux.HandleFunc("/whep/", corsMiddleware(s.handleWHEP))
+
 	// Legacy single-channel routes (backwards compatible)
 	mux.HandleFunc("/api/v1/status", corsMiddleware(s.handleLegacyStatus))
 	mux.HandleFunc("/api/v1/config", corsMiddleware(s.handleLegacyConfig))
@@ -98,7 +404,7 @@ func NewServer(cfg ServerConfig) *Server {
 
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Handler: mux,
+		Handler: s.authMiddleware(mux),
 	}
 
 	return s
@@ -106,7 +412,7 @@ func NewServer(cfg ServerConfig) *Server {
 
 // Start starts the API server
 func (s *Server) Start() error {
-	log.Printf("API server starting on %s", s.server.Addr)
+	logger.Info("API server starting", "addr", s.server.Addr)
 	return s.server.ListenAndServe()
 }
 
@@ -114,7 +420,13 @@ func (s *Server) Start() error {
 func (s *Server) Stop() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	s.server.Shutdown(ctx)
+	s.Shutdown(ctx)
+}
+
+// Shutdown stops the API server, waiting for open requests until ctx is
+// done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -126,6 +438,420 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleHealthz reports that the process is alive and serving requests
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         "alive",
+		"uptime_seconds": int(time.Since(s.started).Seconds()),
+	})
+}
+
+// openAPISpec describes the control API. Update it with any route or
+// payload change.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// handleOpenAPI serves the OpenAPI 3 description of the API
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// handleReadyz reports whether the agent can capture, with per-dependency
+// detail. Responds 503 when any required dependency is failing.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready, checks := s.cfg.Manager.Readiness(r.Context())
+	status := "ready"
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		status = "not_ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// handleDiagnosticsBundle downloads a zip of logs, redacted config, status
+// and FFmpeg output. Requires an API key when keys are configured.
+func (s *Server) handleDiagnosticsBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if len(s.cfg.APIKeys) > 0 && !s.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	name := fmt.Sprintf("capture-diagnostics-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	if err := s.cfg.Manager.DiagnosticsBundle(w); err != nil {
+		// Headers are already sent; the truncated zip is the only signal
+		logger.Error("Diagnostics bundle failed", "error", err)
+	}
+}
+
+// handleSessionReport returns the current session's report so far
+// GET /api/v1/session/report?format=json|csv
+func (s *Server) handleSessionReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, contentType, err := s.cfg.Manager.SessionReport(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// handleSessionEnd ends the session, saving (and optionally uploading) its report
+// POST /api/v1/session/end
+func (s *Server) handleSessionEnd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := s.cfg.Manager.EndSession(r.Context())
+	if err != nil && report == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]interface{}{"report": report}
+	if err != nil {
+		// The session ended; only saving the report failed
+		resp["error"] = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// validFileID reports whether id can be used as a file name
+func validFileID(id string) bool {
+	return id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
+
+// handleReel renders a highlight reel from existing clips and time ranges
+func (s *Server) handleReel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ReelRequest
+		Async bool `json:"async,omitempty"` // Return a job to poll instead of waiting
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Async {
+		s.submitJob(w, jobs.Spec{Kind: "reel", PlayID: req.ReelID}, func(ctx context.Context) (interface{}, error) {
+			return s.cfg.Manager.BuildReel(ctx, req.ReelRequest)
+		})
+		return
+	}
+
+	result, err := s.cfg.Manager.BuildReel(r.Context(), req.ReelRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleConfigReload re-reads the config file, starting added channels,
+// stopping removed ones and restarting changed ones
+// POST /api/v1/config/reload
+func (s *Server) handleConfigReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	result, err := s.cfg.Manager.ReloadConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleChannelEncode changes a channel's bitrate, preset or codec; capture
+// restarts with the new settings and the buffer carries on
+// POST /api/v1/channels/{id}/encode
+func (s *Server) handleChannelEncode(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req EncodeUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Bitrate < 0 {
+		http.Error(w, "bitrate must be positive", http.StatusBadRequest)
+		return
+	}
+	if req == (EncodeUpdate{}) {
+		http.Error(w, "set codec, preset or bitrate", http.StatusBadRequest)
+		return
+	}
+
+	result, err := ch.UpdateEncode(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleChannelRestreams lists a channel's RTMP, SRT and UDP restream
+// destinations or starts and stops one
+// GET /api/v1/channels/{id}/restreams
+// POST /api/v1/channels/{id}/restreams/{name}/start
+// POST /api/v1/channels/{id}/restreams/{name}/stop
+func (s *Server) handleChannelRestreams(w http.ResponseWriter, r *http.Request, ch ChannelInterface, rest string) {
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ch.Restreams())
+		return
+	}
+
+	name, action, _ := strings.Cut(rest, "/")
+	var apply func(string) error
+	switch action {
+	case "start":
+		apply = ch.StartRestream
+	case "stop":
+		apply = ch.StopRestream
+	default:
+		http.Error(w, fmt.Sprintf("Unknown action: %s", action), http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := apply(name); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, ErrRestreamNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ch.Restreams())
+}
+
+// handleGhostStart marks in on every channel with one timestamp
+// POST /api/v1/ghost/start
+func (s *Server) handleGhostStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		PlayID string `json:"play_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.PlayID == "" {
+		http.Error(w, "play_id is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.cfg.Manager.StartGhostClips(req.PlayID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleGhostEnd marks out every channel with one timestamp and generates
+// the multi-angle clip bundle
+// POST /api/v1/ghost/end
+func (s *Server) handleGhostEnd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		PlayID string                 `json:"play_id"`
+		Tags   map[string]interface{} `json:"tags,omitempty"`
+		ClipOptions
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.ClipOptions.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.cfg.Manager.EndGhostClips(r.Context(), req.PlayID, req.Tags, req.ClipOptions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleRedundancy returns this agent's side of its redundant pair, which
+// the peer polls as a heartbeat. Requires an API key when keys are configured.
+func (s *Server) handleRedundancy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if len(s.cfg.APIKeys) > 0 && !s.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	status := s.cfg.Manager.RedundancyStatus()
+	if status == nil {
+		http.Error(w, "Redundancy not enabled", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleStorage reports buffer volume write performance against the capture load
+func (s *Server) handleStorage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cfg.Manager.StorageStatus())
+}
+
+// handleUploads lists clip uploads that failed and are queued for retry or
+// were given up on
+func (s *Server) handleUploads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cfg.Manager.UploadsStatus())
+}
+
+// handleDevices lists the capture devices as of the last hotplug poll
+func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cfg.Manager.Devices())
+}
+
+// handleMetrics serves per-channel metrics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.cfg.Manager.WriteMetrics(w); err != nil {
+		logger.Warn("Failed to write metrics", "error", err)
+	}
+}
+
+// handleEvents streams bus events as Server-Sent Events.
+// GET /api/v1/events?type=clip.generated&type=error&channel=cam1
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.cfg.Events == nil {
+		http.Error(w, "Events not available", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var types []events.Type
+	for _, t := range r.URL.Query()["type"] {
+		types = append(types, events.Type(t))
+	}
+	channel := r.URL.Query().Get("channel")
+
+	stream, unsubscribe := s.cfg.Events.Subscribe("sse "+r.RemoteAddr, types...)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case e := <-stream:
+			if channel != "" && e.Channel != channel {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			flusher.Flush()
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
 // handleListChannels returns all channel IDs and their statuses
 func (s *Server) handleListChannels(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -174,23 +900,203 @@ func (s *Server) handleChannelRoute(w http.ResponseWriter, r *http.Request) {
 		s.handleChannelMarkIn(w, r, ch)
 	case action == "mark/out":
 		s.handleChannelMarkOut(w, r, ch)
+	case action == "mark/cancel":
+		s.handleChannelMarkCancel(w, r, ch)
 	case action == "clip":
 		s.handleChannelClip(w, r, ch)
 	case action == "clip/quick":
 		s.handleChannelQuickClip(w, r, ch)
 	case action == "buffer/status":
 		s.handleChannelStatus(w, r, ch)
+	case action == "export":
+		s.handleChannelExport(w, r, ch)
+	case action == "archive" || strings.HasPrefix(action, "archive/"):
+		s.handleChannelArchive(w, r, ch, strings.TrimPrefix(strings.TrimPrefix(action, "archive"), "/"))
+	case action == "stats":
+		s.handleChannelStats(w, r, ch)
+	case action == "health/stream":
+		s.handleChannelHealthStream(w, r, ch)
+	case action == "import":
+		s.handleChannelImport(w, r, ch)
+	case action == "encode":
+		s.handleChannelEncode(w, r, ch)
+	case action == "restreams" || strings.HasPrefix(action, "restreams/"):
+		s.handleChannelRestreams(w, r, ch, strings.TrimPrefix(strings.TrimPrefix(action, "restreams"), "/"))
+	case action == "clips" || strings.HasPrefix(action, "clips/"):
+		s.handleChannelClips(w, r, ch, strings.TrimPrefix(strings.TrimPrefix(action, "clips"), "/"))
 	default:
 		http.Error(w, fmt.Sprintf("Unknown action: %s", action), http.StatusNotFound)
 	}
 }
 
-func (s *Server) handleChannelStatus(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
-	if r.Method != http.MethodGet {
+func (s *Server) handleChannelStatus(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(w).Encode(ch.GetStatus())
+}
+
+// handleChannelStats returns recent fps, bitrate, segment latency and buffer
+// health history
+// GET /api/v1/channels/{id}/stats?window=5m (max 15m)
+func (s *Server) handleChannelStats(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	window := 5 * time.Minute
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+		window = d
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ch.GetStats(window))
+}
+
+// handleChannelHealthStream pushes the channel status (buffer health,
+// oldest/newest time, capture state) as Server-Sent Events whenever it
+// changes, checking on every segment and state change and at least every
+// interval.
+// GET /api/v1/channels/{id}/health/stream?interval=1s (min 250ms)
+func (s *Server) handleChannelHealthStream(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	interval := time.Second
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 250*time.Millisecond {
+			http.Error(w, "Invalid interval (min 250ms)", http.StatusBadRequest)
+			return
+		}
+		interval = d
+	}
+
+	var changes <-chan events.Event
+	if s.cfg.Events != nil {
+		stream, unsubscribe := s.cfg.Events.Subscribe("health sse "+r.RemoteAddr, events.SegmentReady, events.StateChanged)
+		defer unsubscribe()
+		changes = stream
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+
+	var last []byte
+	for {
+		if data, err := json.Marshal(ch.GetStatus()); err == nil && !bytes.Equal(data, last) {
+			fmt.Fprintf(w, "event: health\ndata: %s\n\n", data)
+			last = data
+		}
+		flusher.Flush()
+
+		select {
+		case <-changes: // Any channel's; unchanged statuses aren't sent
+		case <-ticker.C:
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleChannelExport exports session marks and clips for an NLE
+// GET /api/v1/channels/{id}/export?format=edl|fcpxml|otio
+func (s *Server) handleChannelExport(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "edl"
+	}
+	data, contentType, err := ch.ExportTimeline(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ext := map[string]string{"edl": "edl", "fcpxml": "fcpxml", "otio": "otio"}[format]
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", ch.ID(), ext))
+	w.Write(data)
+}
+
+// handleChannelArchive exports a long stretch of the buffer to one file in the
+// background and serves finished exports
+// POST /api/v1/channels/{id}/archive         - start an export job
+// GET  /api/v1/channels/{id}/archive/{name}  - download a finished export
+func (s *Server) handleChannelArchive(w http.ResponseWriter, r *http.Request, ch ChannelInterface, name string) {
+	if name != "" {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		path, err := ch.ExportFilePath(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		http.ServeFile(w, r, path)
+		return
+	}
+
+	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	json.NewEncoder(w).Encode(ch.GetStatus())
+	var req struct {
+		StartTime int64  `json:"start_time,omitempty"` // Unix ms (0 = oldest footage)
+		EndTime   int64  `json:"end_time,omitempty"`   // Unix ms (0 = newest footage)
+		Format    string `json:"format,omitempty"`     // mp4 (default) or mkv
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Format == "" {
+		req.Format = "mp4"
+	}
+	if req.Format != "mp4" && req.Format != "mkv" {
+		http.Error(w, fmt.Sprintf("Unsupported format: %s", req.Format), http.StatusBadRequest)
+		return
+	}
+	if req.EndTime > 0 && req.EndTime <= req.StartTime {
+		http.Error(w, "end_time must be after start_time", http.StatusBadRequest)
+		return
+	}
+
+	spec := jobs.Spec{Kind: "export", ChannelID: ch.ID()}
+	s.submitJob(w, spec, func(ctx context.Context) (interface{}, error) {
+		return ch.ExportRange(ctx, req.StartTime, req.EndTime, req.Format)
+	})
 }
 
 func (s *Server) handleChannelMarkIn(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
@@ -230,14 +1136,19 @@ func (s *Server) handleChannelMarkOut(w http.ResponseWriter, r *http.Request, ch
 		PlayID       string                 `json:"play_id"`
 		GenerateClip bool                   `json:"generate_clip"`
 		Tags         map[string]interface{} `json:"tags,omitempty"`
+		ClipOptions
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := req.ClipOptions.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if req.GenerateClip || req.Tags != nil {
-		result, err := ch.EndGhostClipAndGenerate(r.Context(), req.PlayID, req.Tags)
+		result, err := ch.EndGhostClipAndGenerate(r.Context(), req.PlayID, req.Tags, req.ClipOptions)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -266,6 +1177,35 @@ func (s *Server) handleChannelMarkOut(w http.ResponseWriter, r *http.Request, ch
 	})
 }
 
+// handleChannelMarkCancel discards an active ghost clip without generating it
+// POST /api/v1/channels/{id}/mark/cancel
+func (s *Server) handleChannelMarkCancel(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		PlayID string `json:"play_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.CancelGhostClip(req.PlayID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "cancelled",
+		"channel_id": ch.ID(),
+		"play_id":    req.PlayID,
+		"timestamp":  time.Now().UnixMilli(),
+	})
+}
+
 func (s *Server) handleChannelClip(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -276,13 +1216,24 @@ func (s *Server) handleChannelClip(w http.ResponseWriter, r *http.Request, ch Ch
 		StartTime int64  `json:"start_time"`
 		EndTime   int64  `json:"end_time"`
 		PlayID    string `json:"play_id"`
+		Async     bool   `json:"async,omitempty"` // Return a job to poll instead of waiting
+		ClipOptions
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := req.ClipOptions.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Async {
+		s.submitClipJob(w, ch, req.StartTime, req.EndTime, req.PlayID, req.ClipOptions)
+		return
+	}
 
-	result, err := ch.GenerateClip(r.Context(), req.StartTime, req.EndTime, req.PlayID)
+	result, err := ch.GenerateClip(r.Context(), req.StartTime, req.EndTime, req.PlayID, req.ClipOptions)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -300,11 +1251,17 @@ func (s *Server) handleChannelQuickClip(w http.ResponseWriter, r *http.Request,
 	var req struct {
 		DurationSeconds int    `json:"duration_seconds"`
 		PlayID          string `json:"play_id"`
+		Async           bool   `json:"async,omitempty"` // Return a job to poll instead of waiting
+		ClipOptions
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := req.ClipOptions.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if req.DurationSeconds <= 0 {
 		req.DurationSeconds = 15
@@ -313,7 +1270,12 @@ func (s *Server) handleChannelQuickClip(w http.ResponseWriter, r *http.Request,
 	endTime := time.Now().UnixMilli()
 	startTime := endTime - int64(req.DurationSeconds*1000)
 
-	result, err := ch.GenerateClip(r.Context(), startTime, endTime, req.PlayID)
+	if req.Async {
+		s.submitClipJob(w, ch, startTime, endTime, req.PlayID, req.ClipOptions)
+		return
+	}
+
+	result, err := ch.GenerateClip(r.Context(), startTime, endTime, req.PlayID, req.ClipOptions)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -322,8 +1284,186 @@ func (s *Server) handleChannelQuickClip(w http.ResponseWriter, r *http.Request,
 	json.NewEncoder(w).Encode(result)
 }
 
+// submitClipJob queues a time-range clip export and responds with the job
+func (s *Server) submitClipJob(w http.ResponseWriter, ch ChannelInterface, startTime, endTime int64, playID string, opts ClipOptions) {
+	spec := jobs.Spec{Kind: "clip", ChannelID: ch.ID(), PlayID: playID}
+	s.submitJob(w, spec, func(ctx context.Context) (interface{}, error) {
+		return ch.GenerateClip(ctx, startTime, endTime, playID, opts)
+	})
+}
+
+// submitJob queues fn and responds 202 with the job and where to poll it
+func (s *Server) submitJob(w http.ResponseWriter, spec jobs.Spec, fn jobs.Func) {
+	job := s.jobs.Submit(spec, fn)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/clips/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleJobs reports on background clip and reel exports
+// GET    /api/v1/clips/jobs       - all jobs, newest first
+// GET    /api/v1/clips/jobs/{id}  - status, stage, progress (0-1) and result
+// DELETE /api/v1/clips/jobs/{id}  - cancel a queued or running job
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/clips/jobs"), "/")
+	if id == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.jobs.List())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		if !s.jobs.Cancel(id) {
+			if _, ok := s.jobs.Get(id); ok {
+				http.Error(w, "Job already finished", http.StatusConflict)
+				return
+			}
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	job, ok := s.jobs.Get(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Job not found: %s", id), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleChannelClips serves the channel's clip library
+// GET    /api/v1/channels/{id}/clips                  - list clips
+// GET    /api/v1/channels/{id}/clips/{play_id}        - clip metadata
+// GET    /api/v1/channels/{id}/clips/{play_id}/file   - clip file (?variant=name, ?download=1)
+// DELETE /api/v1/channels/{id}/clips/{play_id}        - delete a clip and its variants
+func (s *Server) handleChannelClips(w http.ResponseWriter, r *http.Request, ch ChannelInterface, rest string) {
+	playID, sub, _ := strings.Cut(rest, "/")
+	if playID == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ch.ListClips())
+		return
+	}
+
+	clip, ok := ch.GetClip(playID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Clip not found: %s", playID), http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(clip)
+	case sub == "" && r.Method == http.MethodDelete:
+		if err := ch.DeleteClip(playID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case sub == "file" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		path, err := ch.ClipFilePath(playID, r.URL.Query().Get("variant"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		if r.URL.Query().Get("download") != "" {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+		}
+		http.ServeFile(w, r, path)
+	case sub == "" || sub == "file":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, fmt.Sprintf("Unknown action: clips/%s", rest), http.StatusNotFound)
+	}
+}
+
+// handleChannelImport backfills the buffer from an external recording.
+// Either JSON {"path": "/media/cam1.mkv", "start_time": ms} naming a file
+// on the agent, or the file itself as the body with ?start_time=ms.
+// Requires an API key when keys are configured.
+func (s *Server) handleChannelImport(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if len(s.cfg.APIKeys) > 0 && !s.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Path      string `json:"path"`
+		StartTime int64  `json:"start_time"`
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Path == "" {
+			http.Error(w, "path is required", http.StatusBadRequest)
+			return
+		}
+	} else {
+		start, err := strconv.ParseInt(r.URL.Query().Get("start_time"), 10, 64)
+		if err != nil {
+			http.Error(w, "start_time query parameter is required", http.StatusBadRequest)
+			return
+		}
+		req.StartTime = start
+
+		// Spool the upload; FFmpeg needs a seekable file for MP4
+		f, err := os.CreateTemp("", "capture-import-*")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(f.Name())
+		_, err = io.Copy(f, r.Body)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("read upload: %v", err), http.StatusBadRequest)
+			return
+		}
+		req.Path = f.Name()
+	}
+	if req.StartTime <= 0 {
+		http.Error(w, "start_time is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := ch.ImportRecording(r.Context(), req.Path, time.UnixMilli(req.StartTime))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 // handleHLS routes HLS requests to the appropriate channel
 // Supports: /hls/{channelID}/live.m3u8, /hls/{channelID}/init.mp4, /hls/{channelID}/segment_*.m4s
+// Alternate audio: /hls/{channelID}/master.m3u8, /hls/{channelID}/audio/{name}/live.m3u8
+// LL-HLS parts: /hls/{channelID}/part_{seq}_{n}.m4s, blocking reload with live.m3u8?_HLS_msn=&_HLS_part=
+// DASH: /hls/{channelID}/manifest.mpd
+// Thumbnails: /hls/{channelID}/thumbs.m3u8, /hls/{channelID}/thumb.jpg (latest)
+// NDI preview: /hls/{channelID}/preview.jpg (latest), /hls/{channelID}/preview.mjpeg
 // Also supports legacy: /hls/live.m3u8 (uses default channel)
 func (s *Server) handleHLS(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -337,15 +1477,16 @@ func (s *Server) handleHLS(w http.ResponseWriter, r *http.Request) {
 	var ch ChannelInterface
 	var segName string
 
-	if len(parts) == 1 {
-		// Legacy: /hls/live.m3u8 or /hls/segment_00001.m4s
+	if len(parts) == 1 || parts[0] == "audio" || parts[0] == "thumbs" {
+		// Legacy: /hls/live.m3u8 or /hls/segment_00001.m4s. Config
+		// validation keeps "audio" and "thumbs" from being channel IDs.
 		var ok bool
 		ch, ok = s.cfg.Manager.GetDefaultChannel()
 		if !ok {
 			http.Error(w, "No default channel available", http.StatusNotFound)
 			return
 		}
-		segName = parts[0]
+		segName = path
 	} else {
 		// Multi-channel: /hls/{channelID}/live.m3u8
 		channelID := parts[0]
@@ -358,9 +1499,80 @@ func (s *Server) handleHLS(w http.ResponseWriter, r *http.Request) {
 		segName = parts[1]
 	}
 
-	// Handle playlist
-	if segName == "live.m3u8" {
-		playlist, err := ch.GetHLSPlaylist()
+	// Handle DASH manifest
+	if segName == "manifest.mpd" {
+		mpd, err := ch.GetDASHManifest()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/dash+xml")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.Write(mpd)
+		return
+	}
+
+	// Thumbnails are previews too, so they need auth when segments are encrypted
+	isThumb := segName == "thumb.jpg" || segName == "thumbs.m3u8" || strings.HasPrefix(segName, "thumbs/") ||
+		segName == "preview.jpg" || segName == "preview.mjpeg"
+	if isThumb && ch.HLSEncrypted() && !s.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if segName == "thumb.jpg" {
+		thumbPath, err := ch.GetLatestThumbnailPath()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeFile(w, r, thumbPath)
+		return
+	}
+
+	if segName == "preview.jpg" {
+		frame, _, err := ch.PreviewFrame()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(frame)
+		return
+	}
+	if segName == "preview.mjpeg" {
+		s.servePreviewStream(w, r, ch)
+		return
+	}
+
+	// Handle playlists
+	var audioName string
+	if segName == "live.m3u8" || segName == "master.m3u8" || segName == "thumbs.m3u8" || isAudioPlaylist(segName, &audioName) {
+		var playlist []byte
+		var err error
+		switch {
+		case segName == "master.m3u8":
+			playlist, err = ch.GetHLSMasterPlaylist()
+		case segName == "thumbs.m3u8":
+			playlist, err = ch.GetThumbnailPlaylist()
+		case audioName != "":
+			playlist, err = ch.GetAudioPlaylist(audioName)
+		default:
+			if err := s.waitHLSPlaylist(r, ch); err != nil {
+				status := http.StatusInternalServerError
+				switch {
+				case errors.Is(err, ErrPlaylistAhead), errors.Is(err, strconv.ErrSyntax):
+					status = http.StatusBadRequest
+				case errors.Is(err, context.DeadlineExceeded):
+					status = http.StatusServiceUnavailable
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			playlist, err = ch.GetHLSPlaylist()
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -372,8 +1584,36 @@ func (s *Server) handleHLS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Handle encryption keys (always requires API auth)
+	var keyIndex int
+	if n, _ := fmt.Sscanf(segName, "key_%05d.key", &keyIndex); n == 1 {
+		s.handleHLSKey(w, r, ch, keyIndex)
+		return
+	}
+
+	// Handle LL-HLS parts
+	var partSeq, partIndex int
+	if n, _ := fmt.Sscanf(segName, "part_%05d_%d.m4s", &partSeq, &partIndex); n == 2 {
+		data, err := ch.GetHLSPart(r.Context(), partSeq, partIndex)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, ErrPartNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, context.DeadlineExceeded):
+				status = http.StatusServiceUnavailable
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "video/iso.segment")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		http.ServeContent(w, r, segName, time.Time{}, bytes.NewReader(data))
+		return
+	}
+
 	// Handle segments
-	if len(segName) == 0 || segName[0] == '/' || segName[0] == '.' {
+	if len(segName) == 0 || segName[0] == '/' || segName[0] == '.' || strings.Contains(segName, "..") {
 		http.Error(w, "Invalid segment name", http.StatusBadRequest)
 		return
 	}
@@ -388,13 +1628,163 @@ func (s *Server) handleHLS(w http.ResponseWriter, r *http.Request) {
 	contentType := "video/mp4"
 	if strings.HasSuffix(segName, ".m4s") {
 		contentType = "video/iso.segment"
+	} else if strings.HasSuffix(segName, ".jpg") {
+		contentType = "image/jpeg"
 	}
 
 	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	var seq int
+	if n, _ := fmt.Sscanf(filepath.Base(segName), "segment_%05d.m4s", &seq); n == 1 {
+		// Video segments held in memory or offloaded are served from the
+		// buffer; audio renditions are always on disk
+		var data []byte
+		inMemory := false
+		if !strings.Contains(segName, "/") {
+			data, inMemory = ch.SegmentData(seq)
+		}
+		if ch.HLSEncrypted() {
+			if !inMemory {
+				var err error
+				if data, err = os.ReadFile(filePath); err != nil {
+					http.Error(w, "Segment not found", http.StatusNotFound)
+					return
+				}
+			}
+			s.serveEncryptedSegment(w, ch, seq, data)
+			return
+		}
+		if inMemory {
+			http.ServeContent(w, r, segName, time.Time{}, bytes.NewReader(data))
+			return
+		}
+	}
 	http.ServeFile(w, r, filePath)
 }
 
+// handleWHEP serves WebRTC playback with WHEP. A player POSTs its SDP offer
+// and gets the answer with every ICE candidate (no trickle ICE); the
+// Location header is the session to DELETE when done.
+// POST /whep/{channelID}
+// DELETE /whep/{channelID}/{sessionID}
+func (s *Server) handleWHEP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Expose-Headers", "Location")
+	channelID, sessionID, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/whep/"), "/")
+	ch, ok := s.cfg.Manager.GetChannel(channelID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Channel not found: %s", channelID), http.StatusNotFound)
+		return
+	}
+
+	whepError := func(err error, status int) {
+		switch {
+		case errors.Is(err, ErrWHEPNotEnabled), errors.Is(err, ErrWHEPSessionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrWHEPBusy):
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, err.Error(), status)
+	}
+
+	if sessionID != "" {
+		switch r.Method {
+		case http.MethodDelete:
+			if err := ch.WHEPUnsubscribe(sessionID); err != nil {
+				whepError(err, http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case http.MethodPatch:
+			// Trickle ICE and ICE restarts aren't supported
+			http.Error(w, "Trickle ICE not supported", http.StatusMethodNotAllowed)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); strings.TrimSpace(ct) != "application/sdp" {
+		http.Error(w, "Content-Type must be application/sdp", http.StatusUnsupportedMediaType)
+		return
+	}
+	offer, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	answer, id, err := ch.WHEPSubscribe(string(offer))
+	if err != nil {
+		whepError(err, http.StatusBadRequest) // Otherwise an unusable offer
+		return
+	}
+	w.Header().Set("Content-Type", "application/sdp")
+	w.Header().Set("Location", "/whep/"+channelID+"/"+id)
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, answer)
+}
+
+// waitHLSPlaylist holds an LL-HLS playlist request with _HLS_msn (and
+// optionally _HLS_part) until the playlist contains that segment or part
+func (s *Server) waitHLSPlaylist(r *http.Request, ch ChannelInterface) error {
+	q := r.URL.Query()
+	if !q.Has("_HLS_msn") {
+		return nil
+	}
+	msn, err := strconv.Atoi(q.Get("_HLS_msn"))
+	if err != nil {
+		return fmt.Errorf("_HLS_msn: %w", err)
+	}
+	part := -1
+	if q.Has("_HLS_part") {
+		if part, err = strconv.Atoi(q.Get("_HLS_part")); err != nil {
+			return fmt.Errorf("_HLS_part: %w", err)
+		}
+	}
+	return ch.WaitHLSPlaylist(r.Context(), msn, part)
+}
+
+// isAudioPlaylist matches audio/{name}/live.m3u8 and extracts the rendition name
+func isAudioPlaylist(segName string, name *string) bool {
+	parts := strings.Split(segName, "/")
+	if len(parts) != 3 || parts[0] != "audio" || parts[2] != "live.m3u8" {
+		return false
+	}
+	*name = parts[1]
+	return true
+}
+
+// serveEncryptedSegment writes an AES-128 encrypted copy of a segment
+func (s *Server) serveEncryptedSegment(w http.ResponseWriter, ch ChannelInterface, seq int, data []byte) {
+	enc, err := ch.EncryptHLSSegment(seq, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(enc)))
+	w.Write(enc)
+}
+
+// handleHLSKey serves an HLS content key to authorized clients
+func (s *Server) handleHLSKey(w http.ResponseWriter, r *http.Request, ch ChannelInterface, index int) {
+	if !s.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	key, ok := ch.GetHLSKey(index)
+	if !ok {
+		http.Error(w, "Encryption not enabled", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(key)
+}
+
 // Legacy handlers - delegate to default channel
 
 func (s *Server) handleLegacyStatus(w http.ResponseWriter, r *http.Request) {
@@ -470,6 +1860,52 @@ func (s *Server) handleLegacyBufferStatus(w http.ResponseWriter, r *http.Request
 	s.handleChannelStatus(w, r, ch)
 }
 
+// servePreviewStream streams the NDI preview as multipart MJPEG, which
+// browsers show in a plain <img> tag
+func (s *Server) servePreviewStream(w http.ResponseWriter, r *http.Request, ch ChannelInterface) {
+	if _, _, err := ch.PreviewFrame(); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	const boundary = "frame"
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	// Poll well above the preview rate; only new frames are sent. The
+	// stream ends once the preview stalls, e.g. when capture stops.
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	var last time.Time
+	lastSent := time.Now()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		frame, at, err := ch.PreviewFrame()
+		if err != nil || !at.After(last) {
+			if time.Since(lastSent) > 10*time.Second {
+				return
+			}
+			continue
+		}
+		last, lastSent = at, time.Now()
+		fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", boundary, len(frame))
+		if _, err := w.Write(frame); err != nil {
+			return
+		}
+		io.WriteString(w, "\r\n")
+		flusher.Flush()
+	}
+}
+
 // handleNDISources discovers NDI sources on the network
 func (s *Server) handleNDISources(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -487,8 +1923,16 @@ func (s *Server) handleNDISources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Discover NDI sources
-	sources, err := ndi.DiscoverSources(r.Context())
+	// Discover NDI sources; groups and extra_ips override the configured ones
+	finder := ndi.DefaultFinderConfig()
+	q := r.URL.Query()
+	if q.Has("groups") {
+		finder.Groups = q.Get("groups")
+	}
+	if q.Has("extra_ips") {
+		finder.ExtraIPs = q.Get("extra_ips")
+	}
+	sources, err := ndi.DiscoverSources(r.Context(), finder)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("NDI discovery failed: %v", err), http.StatusInternalServerError)
 		return
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		keys       []string
+		protectHLS bool
+		encryptHLS bool
+		method     string
+		path       string
+		token      string
+		want       int
+	}{
+		{"no keys", nil, false, false, http.MethodPost, "/api/v1/channels/cam1/mark-in", "", http.StatusOK},
+		{"get open", []string{"k1"}, false, false, http.MethodGet, "/api/v1/channels", "", http.StatusOK},
+		{"head open", []string{"k1"}, false, false, http.MethodHead, "/api/v1/status", "", http.StatusOK},
+		{"post without key", []string{"k1"}, false, false, http.MethodPost, "/api/v1/channels/cam1/mark-in", "", http.StatusUnauthorized},
+		{"post with wrong key", []string{"k1"}, false, false, http.MethodPost, "/api/v1/channels/cam1/mark-in", "k2", http.StatusUnauthorized},
+		{"post with key", []string{"k1", "k2"}, false, false, http.MethodPost, "/api/v1/channels/cam1/mark-in", "k2", http.StatusOK},
+		{"delete without key", []string{"k1"}, false, false, http.MethodDelete, "/api/v1/channels/cam1/clips/c1", "", http.StatusUnauthorized},
+		{"options preflight", []string{"k1"}, false, false, http.MethodOptions, "/api/v1/channels/cam1/mark-in", "", http.StatusOK},
+		{"hls open", []string{"k1"}, false, false, http.MethodGet, "/hls/cam1/playlist.m3u8", "", http.StatusOK},
+		{"whep open", []string{"k1"}, false, false, http.MethodPost, "/whep/cam1", "", http.StatusOK},
+		{"protected hls without key", []string{"k1"}, true, false, http.MethodGet, "/hls/cam1/playlist.m3u8", "", http.StatusUnauthorized},
+		{"protected hls with key", []string{"k1"}, true, false, http.MethodGet, "/hls/cam1/playlist.m3u8", "k1", http.StatusOK},
+		{"protected whep without key", []string{"k1"}, true, false, http.MethodPost, "/whep/cam1", "", http.StatusUnauthorized},
+		{"encrypted hls whep without key", []string{"k1"}, false, true, http.MethodPost, "/whep/cam1", "", http.StatusUnauthorized},
+		{"encrypted hls whep with key", []string{"k1"}, false, true, http.MethodPost, "/whep/cam1", "k1", http.StatusOK},
+		{"encrypted hls leaves hls open", []string{"k1"}, false, true, http.MethodGet, "/hls/cam1/playlist.m3u8", "", http.StatusOK},
+		{"protected hls leaves api reads open", []string{"k1"}, true, false, http.MethodGet, "/api/v1/channels", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{cfg: ServerConfig{APIKeys: tt.keys, ProtectHLS: tt.protectHLS, EncryptHLS: tt.encryptHLS}}
+			h := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+
+	// Only Bearer tokens count
+	s := &Server{cfg: ServerConfig{APIKeys: []string{"k1"}}}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/session/end", nil)
+	req.Header.Set("Authorization", "Basic k1")
+	if s.authorized(req) {
+		t.Error("Basic credentials accepted as an API key")
+	}
+}
+
+func TestClipOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ClipOptions
+		ok   bool
+	}{
+		{"defaults", ClipOptions{}, true},
+		{"mute", ClipOptions{Mute: true}, true},
+		{"audio track", ClipOptions{AudioTrack: "commentary_2"}, true},
+		{"mix with muxed track", ClipOptions{AudioMix: []ClipAudioMix{{Track: ""}, {Track: "crowd-left", GainDB: -6}}}, true},
+		{"mute and track", ClipOptions{Mute: true, AudioTrack: "crowd"}, false},
+		{"traversing track", ClipOptions{AudioTrack: "../../etc"}, false},
+		{"uppercase track", ClipOptions{AudioTrack: "Crowd"}, false},
+		{"traversing mix track", ClipOptions{AudioMix: []ClipAudioMix{{Track: "a/b"}}}, false},
+		{"mix gain too high", ClipOptions{AudioMix: []ClipAudioMix{{Track: "crowd", GainDB: 30}}}, false},
+		{"interpolate without rate", ClipOptions{Interpolate: true}, false},
+		{"slow motion", ClipOptions{PlaybackRate: 0.5, Interpolate: true}, true},
+		{"rate too high", ClipOptions{PlaybackRate: 4}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// statusChannel is a channel whose status is a counter. Only GetStatus is
+// implemented.
+type statusChannel struct {
+	ChannelInterface
+	n atomic.Int64
+}
+
+func (c *statusChannel) GetStatus() interface{} {
+	return map[string]int64{"n": c.n.Load()}
+}
+
+func TestHealthStreamRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		want   int
+	}{
+		{"post", http.MethodPost, "", http.StatusMethodNotAllowed},
+		{"bad interval", http.MethodGet, "?interval=soon", http.StatusBadRequest},
+		{"interval too short", http.MethodGet, "?interval=100ms", http.StatusBadRequest},
+	}
+	s := &Server{}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/v1/channels/cam1/health/stream"+tt.query, nil)
+		rec := httptest.NewRecorder()
+		s.handleChannelHealthStream(rec, req, &statusChannel{})
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestHealthStream(t *testing.T) {
+	bus := events.NewBus()
+	defer bus.Close()
+	s := &Server{cfg: ServerConfig{Events: bus}}
+	ch := &statusChannel{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.handleChannelHealthStream(w, r, ch)
+	}))
+	defer srv.Close()
+
+	// A long interval, so only events trigger updates
+	resp, err := http.Get(srv.URL + "?interval=1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+
+	lines := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if strings.HasPrefix(sc.Text(), "data: ") {
+				lines <- strings.TrimPrefix(sc.Text(), "data: ")
+			}
+		}
+		close(lines)
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case l := <-lines:
+			return l
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a health event")
+			return ""
+		}
+	}
+
+	tests := []struct {
+		name    string
+		publish []int64 // Status values to publish an event at
+		want    string
+	}{
+		{"initial status", nil, `{"n":0}`},
+		// An unchanged status isn't resent; the next change is
+		{"unchanged then changed", []int64{0, 2}, `{"n":2}`},
+		{"changed", []int64{3}, `{"n":3}`},
+	}
+	for _, tt := range tests {
+		for _, n := range tt.publish {
+			ch.n.Store(n)
+			bus.Publish(events.Event{Type: events.SegmentReady, Channel: "cam1"})
+			time.Sleep(50 * time.Millisecond) // Let the handler see each status
+		}
+		if got := next(); got != tt.want {
+			t.Errorf("%s: data = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/diskio"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+// alertState tracks one rule for one channel ("" for volume-wide rules)
+type alertState struct {
+	since  time.Time // When the condition started holding (zero = not holding)
+	firing bool
+}
+
+// alerter evaluates the configured alert rules on an interval
+type alerter struct {
+	cfg   AlertsConfig
+	state map[string]*alertState
+}
+
+func newAlerter(cfg AlertsConfig) *alerter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	return &alerter{cfg: cfg, state: make(map[string]*alertState)}
+}
+
+// monitorAlerts evaluates alert rules until ctx is done
+func (m *Manager) monitorAlerts(ctx context.Context) {
+	if len(m.alerts.cfg.Rules) == 0 {
+		return
+	}
+	ticker := time.NewTicker(m.alerts.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.evaluateAlerts(time.Now())
+		}
+	}
+}
+
+// evaluateAlerts checks every rule once. Only the monitor goroutine calls
+// it, so alerter state needs no lock.
+func (m *Manager) evaluateAlerts(now time.Time) {
+	m.mu.RLock()
+	channels := make(map[string]*Channel, len(m.channels))
+	for id, ch := range m.channels {
+		channels[id] = ch
+	}
+	m.mu.RUnlock()
+
+	for _, rule := range m.alerts.cfg.Rules {
+		if rule.Metric == "disk_free_gb" {
+			free, err := diskio.Free(m.storage.path)
+			if err != nil {
+				continue
+			}
+			m.updateAlert(rule, "", float64(free)/1e9, now)
+			continue
+		}
+		for id, ch := range channels {
+			if len(rule.Channels) > 0 && !slices.Contains(rule.Channels, id) {
+				continue
+			}
+			if v, ok := ch.alertMetric(rule.Metric, now); ok {
+				m.updateAlert(rule, id, v, now)
+			}
+		}
+	}
+}
+
+// updateAlert applies one measurement to a rule, publishing an event when
+// the rule starts or stops firing
+func (m *Manager) updateAlert(rule AlertRule, channelID string, value float64, now time.Time) {
+	key := rule.Name + "/" + channelID
+	st, ok := m.alerts.state[key]
+	if !ok {
+		st = &alertState{}
+		m.alerts.state[key] = st
+	}
+
+	threshold, breached := 0.0, false
+	if rule.Below != nil {
+		threshold, breached = *rule.Below, value < *rule.Below
+	} else {
+		threshold, breached = *rule.Above, value > *rule.Above
+	}
+
+	if !breached {
+		st.since = time.Time{}
+		if st.firing {
+			st.firing = false
+			logger.Info("Alert resolved", "rule", rule.Name, "channel", channelID, "metric", rule.Metric, "value", value)
+			m.publishAlert(events.AlertResolved, rule, channelID, value, threshold)
+		}
+		return
+	}
+	if st.since.IsZero() {
+		st.since = now
+	}
+	if !st.firing && now.Sub(st.since) >= rule.For {
+		st.firing = true
+		logger.Warn("Alert firing", "rule", rule.Name, "channel", channelID, "metric", rule.Metric, "value", value, "threshold", threshold)
+		m.publishAlert(events.AlertFired, rule, channelID, value, threshold)
+	}
+}
+
+func (m *Manager) publishAlert(t events.Type, rule AlertRule, channelID string, value, threshold float64) {
+	condition := "below"
+	if rule.Above != nil {
+		condition = "above"
+	}
+	m.events.Publish(events.Event{
+		Type:    t,
+		Channel: channelID,
+		Data: map[string]interface{}{
+			"rule":      rule.Name,
+			"metric":    rule.Metric,
+			"value":     math.Round(value*1000) / 1000,
+			"threshold": threshold,
+			"condition": condition,
+		},
+	})
+}
+
+// alertMetric returns a channel's current value for an alert metric. Channels
+// that aren't running or have no input report nothing.
+func (ch *Channel) alertMetric(metric string, now time.Time) (float64, bool) {
+	ch.mu.RLock()
+	running, startedAt, writer := ch.isRunning, ch.startedAt, ch.writer
+	if ch.windowOpenedAt.After(startedAt) {
+		startedAt = ch.windowOpenedAt
+	}
+	offSchedule := ch.offSchedule
+	ch.mu.RUnlock()
+	if !running || offSchedule || ch.cfg.Input.Type == "" {
+		return 0, false
+	}
+
+	switch metric {
+	case "buffer_health":
+		return ch.buffer.GetStatus().Health, true
+	case "segment_age":
+		// Time since the latest segment, or since start if none arrived yet
+		last := ch.history.lastSegmentTime()
+		if last.Before(startedAt) {
+			last = startedAt
+		}
+		return now.Sub(last).Seconds(), true
+	case "av_drift_ms":
+		return math.Abs(ch.avsync.status().DriftMs), true
+	case "dropped_frames":
+		if writer == nil {
+			return 0, false
+		}
+		return float64(writer.Stats().Dropped), true
+	case "encoder_speed":
+		if writer == nil {
+			return 0, false
+		}
+		speed := writer.Stats().Speed
+		return speed, speed > 0 // Nothing reported yet
+	}
+	return 0, false
+}
+
+// platformAlerter forwards alert events to the platform
+type platformAlerter struct {
+	client *platform.Client
+}
+
+// Send implements events.Sink
+func (p platformAlerter) Send(ctx context.Context, e events.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rule, _ := e.Data["rule"].(string)
+	metric, _ := e.Data["metric"].(string)
+	value, _ := e.Data["value"].(float64)
+	threshold, _ := e.Data["threshold"].(float64)
+	if err := p.client.NotifyAlert(ctx, platform.AlertNotification{
+		Rule:      rule,
+		ChannelID: e.Channel,
+		Metric:    metric,
+		Value:     value,
+		Threshold: threshold,
+		Firing:    e.Type == events.AlertFired,
+		Timestamp: e.Time.UnixMilli(),
+	}); err != nil {
+		return fmt.Errorf("notify alert %s: %w", rule, err)
+	}
+	return nil
+}
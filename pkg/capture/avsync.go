@@ -0,0 +1,151 @@
+package capture
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/fmp4"
+)
+
+// AVSyncStatus reports audio/video drift and frame drops for a channel
+type AVSyncStatus struct {
+	OffsetMs         float64 `json:"offset_ms"`    // Audio start minus video start in the latest segment
+	DriftMs          float64 `json:"drift_ms"`     // Change in offset since capture started
+	MaxDriftMs       float64 `json:"max_drift_ms"` // Largest absolute drift seen
+	DroppedFrames    int64   `json:"dropped_frames"`
+	DuplicatedFrames int64   `json:"duplicated_frames"`
+	Alert            bool    `json:"alert"` // Drift is above the configured threshold
+}
+
+// avMonitor measures A/V drift from the decode times of the audio and video
+// tracks at the start of each segment. The offset at the first segment is
+// the baseline; drift is how far the offset has moved since then.
+type avMonitor struct {
+	threshold time.Duration
+
+	mu       sync.Mutex
+	initPath string
+	video    fmp4.TrackInfo
+	audio    fmp4.TrackInfo
+	hasAudio bool
+	baseline float64
+	started  bool
+	offset   float64 // seconds
+	drift    float64
+	maxDrift float64
+	alert    bool
+}
+
+func newAVMonitor(threshold time.Duration) *avMonitor {
+	if threshold <= 0 {
+		threshold = 100 * time.Millisecond
+	}
+	return &avMonitor{threshold: threshold}
+}
+
+// reset starts a new measurement, e.g. after the encoder restarts
+func (m *avMonitor) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initPath = ""
+	m.hasAudio = false
+	m.started = false
+	m.offset, m.drift, m.maxDrift = 0, 0, 0
+	m.alert = false
+}
+
+// observe measures a new segment. It returns the current drift and whether
+// the alert state changed.
+func (m *avMonitor) observe(initPath, segPath string) (time.Duration, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if initPath != m.initPath {
+		if err := m.loadTracks(initPath); err != nil {
+			return 0, false, err
+		}
+	}
+	if !m.hasAudio {
+		return 0, false, nil
+	}
+
+	f, err := os.Open(segPath)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+	times, err := fmp4.ReadDecodeTimes(f)
+	if err != nil {
+		return 0, false, fmt.Errorf("read %s: %w", segPath, err)
+	}
+	vt, vok := times[m.video.ID]
+	at, aok := times[m.audio.ID]
+	if !vok || !aok {
+		return 0, false, nil
+	}
+
+	m.offset = float64(at)/float64(m.audio.Timescale) - float64(vt)/float64(m.video.Timescale)
+	if !m.started {
+		m.baseline = m.offset
+		m.started = true
+	}
+	m.drift = m.offset - m.baseline
+	m.maxDrift = math.Max(m.maxDrift, math.Abs(m.drift))
+
+	// Clear the alert only once drift is well under the threshold, so a
+	// value hovering at the limit doesn't flap
+	abs := time.Duration(math.Abs(m.drift) * float64(time.Second))
+	changed := false
+	if !m.alert && abs > m.threshold {
+		m.alert, changed = true, true
+	} else if m.alert && abs < m.threshold/2 {
+		m.alert, changed = false, true
+	}
+	return time.Duration(m.drift * float64(time.Second)), changed, nil
+}
+
+// loadTracks reads track IDs and timescales from the init segment.
+// Callers hold mu.
+func (m *avMonitor) loadTracks(initPath string) error {
+	f, err := os.Open(initPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tracks, err := fmp4.ReadTracks(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", initPath, err)
+	}
+
+	var hasVideo bool
+	m.hasAudio = false
+	for _, t := range tracks {
+		if t.Timescale == 0 {
+			continue
+		}
+		switch {
+		case t.Handler == "vide" && !hasVideo:
+			m.video, hasVideo = t, true
+		case t.Handler == "soun" && !m.hasAudio:
+			m.audio, m.hasAudio = t, true
+		}
+	}
+	m.hasAudio = m.hasAudio && hasVideo
+	m.initPath = initPath
+	return nil
+}
+
+// status returns the drift figures (frame counters are filled in by the caller)
+func (m *avMonitor) status() AVSyncStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return AVSyncStatus{
+		OffsetMs:   math.Round(m.offset * 1000),
+		DriftMs:    math.Round(m.drift * 1000),
+		MaxDriftMs: math.Round(m.maxDrift * 1000),
+		Alert:      m.alert,
+	}
+}
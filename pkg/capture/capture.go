@@ -1,5 +1,7 @@
 package capture
 
+import "github.com/video-system/go-video-capture/pkg/ringbuffer"
+
 // Status represents the current engine status (deprecated, use ChannelStatus)
 type Status struct {
 	IsRunning    bool    `json:"is_running"`
@@ -26,6 +28,9 @@ type ClipResult struct {
 	Duration      float64 `json:"duration"`
 	FileSizeBytes int64   `json:"file_size_bytes"`
 	SegmentCount  int     `json:"segment_count"`
+	PlaybackRate  float64 `json:"playback_rate,omitempty"`
+
+	Variants []ringbuffer.ClipVariantResult `json:"variants,omitempty"`
 }
 
 // ClipResultWithTags includes clip result plus metadata
@@ -2,16 +2,36 @@ package capture
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/video-system/go-video-capture/internal/diskio"
 	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/hlscrypt"
+	"github.com/video-system/go-video-capture/internal/manifest"
+	"github.com/video-system/go-video-capture/internal/objstore"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"github.com/video-system/go-video-capture/internal/whep"
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/encode"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/input"
 	"github.com/video-system/go-video-capture/pkg/ndi"
 	"github.com/video-system/go-video-capture/pkg/platform"
 	"github.com/video-system/go-video-capture/pkg/ringbuffer"
+	"github.com/video-system/go-video-capture/pkg/timeline"
+	"github.com/video-system/go-video-capture/pkg/uploads"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Channel represents a single video capture channel
@@ -21,17 +41,93 @@ type Channel struct {
 	cfg      ChannelConfig
 	ffmpeg   *ffmpeg.FFmpeg
 	buffer   *ringbuffer.Buffer
-	writer   *ffmpeg.SegmentWriter
+	writer   segmentWriter
 	platform *platform.Client
+	events   *events.Bus
+	logger   *slog.Logger
+
+	// Where segments go besides the ring buffer: CMAF push, the NDI
+	// monitor, plugins from the outputs config and running restreams. Set
+	// before the writer starts and cleared after it stops.
+	outMu       sync.Mutex
+	outputs     []*channelOutput
+	outputsOpen bool                 // A writer is feeding outputs
+	outCodec    string               // Video codec of that writer
+	restreams   map[string]*restream // Restream destinations by name
+
+	// Session marks and clips for NLE export
+	timeline *timeline.Log
+
+	// Open ghost clip spans, from mark-in until the clip is generated
+	spanMu     sync.Mutex
+	ghostSpans map[string]trace.Span
+
+	// HLS segment encryption (nil when disabled)
+	keys *hlscrypt.Keyring
+
+	// LL-HLS partial segments of the segments being written (nil when disabled)
+	parts *partTracker
+
+	// WebRTC players; outlives writer restarts (nil when disabled)
+	whep *whep.Source
+
+	// A/V drift measured per segment
+	avsync *avMonitor
+
+	// Frame capture to playlist delay
+	latency *latencyMonitor
+
+	// Segment write load: landing latency (ms) and write rate (MB/s)
+	segLatency *diskio.Window
+	segRate    *diskio.Window
+
+	// Recent fps, bitrate, latency and buffer health for the stats API
+	history *channelHistory
+
+	// Counters for the /metrics endpoint
+	metrics *channelMetrics
+
+	// FFmpeg restarts after the capture process exits
+	restarts *restartTracker
+
+	// Input plugin of the running writer
+	input    input.Input
+	noSignal atomic.Bool // The native input reports no signal
 
 	// Native NDI capture (used when input type is "ndi")
 	ndiCapture *ndi.Capture
-
-	mu          sync.RWMutex
-	isRunning   bool
-	isCapturing bool
-	sessionID   string
-	basePath    string // Base path for segments (channel subdir added)
+	ndiPreview *ndi.Preview // Low-bandwidth JPEG preview (nil when disabled)
+
+	// Redundant pair this agent belongs to (nil when disabled)
+	pairing *pairing
+
+	// Retries failed platform uploads (nil without a platform)
+	uploads *uploads.Queue
+
+	// NVENC sessions shared with the other channels (nil unless encoding
+	// with NVENC on a detected GPU), the one capture holds and whether
+	// capture is waiting for one
+	nvenc        *nvencSessions
+	nvencSession *encode.Session
+	nvencQueued  bool
+
+	// Loopback address the SRT listener binds when callers are routed to
+	// this channel by stream ID (empty = bind the device URL)
+	srtBackend string
+
+	// Held by the restart supervisor, device hotplug, the NVENC queue, the
+	// recording schedule and operator restarts from their idle check until
+	// capture has started, so only one of them starts it
+	startMu sync.Mutex
+
+	mu             sync.RWMutex
+	isRunning      bool
+	isCapturing    bool
+	offSchedule    bool // Outside its recording windows; capture stays stopped
+	windowOpenedAt time.Time
+	startedAt      time.Time
+	sessionID      string
+	basePath       string // Channel directory FFmpeg writes to (under buffer path or tmpfs_path)
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -43,68 +139,221 @@ type ChannelConfig struct {
 	Input  InputConfig  `yaml:"input"`
 	Buffer BufferConfig `yaml:"buffer"`
 	Encode EncodeConfig `yaml:"encode"`
+
+	// RTMP(S), SRT and UDP destinations the stream can be re-pushed to
+	Restream []RestreamConfig `yaml:"restream"`
+
+	// Plugin outputs of this channel, after the top-level ones. Paths are
+	// used as given; top-level output paths get the channel ID appended.
+	Outputs []OutputConfig `yaml:"outputs"`
+
+	// Shared settings, copied from the top-level config
+	CMAF    CMAFConfig      `yaml:"-"`
+	NDI     NDIOutputConfig `yaml:"-"`
+	WHEP    WHEPConfig      `yaml:"-"`
+	HLS     HLSConfig       `yaml:"-"`
+	Monitor MonitorConfig   `yaml:"-"`
 }
 
 // NewChannel creates a new capture channel
-func NewChannel(id string, cfg ChannelConfig, ff *ffmpeg.FFmpeg, platformClient *platform.Client, sessionID string, basePath string) (*Channel, error) {
+func NewChannel(id string, cfg ChannelConfig, ff *ffmpeg.FFmpeg, platformClient *platform.Client, bus *events.Bus, sessionID string, basePath string) (*Channel, error) {
 	// Channel gets its own subdirectory
 	channelPath := filepath.Join(basePath, id)
+	workPath := channelPath
+	if cfg.Buffer.TmpfsPath != "" {
+		workPath = filepath.Join(cfg.Buffer.TmpfsPath, id)
+	}
+
+	// Resolve clip export encoder (empty = stream copy)
+	var clipEncoder string
+	if cfg.Encode.ClipCodec != "" && cfg.Encode.ClipCodec != "copy" {
+		name, err := ffmpeg.EncoderName(cfg.Encode.Type, cfg.Encode.ClipCodec)
+		if err != nil {
+			return nil, fmt.Errorf("clip codec for channel %s: %w", id, err)
+		}
+		clipEncoder = name
+	}
+
+	var clipVariants []ringbuffer.ClipVariant
+	for _, v := range cfg.Encode.ClipVariants {
+		clipVariants = append(clipVariants, ringbuffer.ClipVariant{Name: v.Name, Aspect: v.Aspect, Anchor: v.Anchor})
+	}
+
+	var primaryAudio string
+	if len(cfg.Encode.AudioTracks) > 0 {
+		primaryAudio = cfg.Encode.AudioTracks[0].Name
+	}
+
+	var clipOverlay *ringbuffer.ClipOverlay
+	if cfg.Encode.ClipOverlay.Template != "" {
+		clipOverlay = &ringbuffer.ClipOverlay{Template: cfg.Encode.ClipOverlay.Template, Style: cfg.Encode.ClipOverlay.style()}
+	}
+
+	// Frame-accurate cuts re-encode boundaries in the buffer's own codec so
+	// they join the copied middle; software encoding works on any host
+	var trimEncoder string
+	if cfg.Encode.ClipTrim == "frame" {
+		name, err := ffmpeg.EncoderName("software", cfg.Encode.Codec)
+		if err != nil {
+			return nil, fmt.Errorf("clip trim encoder for channel %s: %w", id, err)
+		}
+		trimEncoder = name
+	}
+
+	maxBytes, err := cfg.Buffer.maxBytes()
+	if err != nil {
+		return nil, fmt.Errorf("channel %s: %w", id, err)
+	}
+
+	var offload ringbuffer.ObjectStore
+	if o := cfg.Buffer.Offload; o.Enabled {
+		store, err := objstore.New(objstore.Config{
+			Endpoint:  o.Endpoint,
+			Region:    o.Region,
+			Bucket:    o.Bucket,
+			Prefix:    o.Prefix + "/" + id,
+			AccessKey: o.AccessKey,
+			SecretKey: o.SecretKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("offload storage for channel %s: %w", id, err)
+		}
+		offload = store
+	}
 
 	// Create ring buffer for this channel
 	bufferCfg := ringbuffer.Config{
-		Duration:    cfg.Buffer.Duration,
-		SegmentSize: cfg.Buffer.SegmentSize,
-		Path:        channelPath,
-		ChannelID:   id,
+		Duration:     cfg.Buffer.Duration,
+		MaxBytes:     maxBytes,
+		SegmentSize:  cfg.Buffer.SegmentSize,
+		Path:         channelPath,
+		WorkPath:     workPath,
+		InMemory:     cfg.Buffer.Storage == "memory",
+		Offload:      offload,
+		OffloadAfter: cfg.Buffer.Offload.After,
+		ChannelID:    id,
+		ClipEncoder:  clipEncoder,
+		ClipBitrate:  cfg.Encode.ClipBitrate,
+		TrimEncoder:  trimEncoder,
+		TrimBitrate:  cfg.Encode.Bitrate,
+		ClipVariants: clipVariants,
+		ClipOverlay:  clipOverlay,
+		PrimaryAudio: primaryAudio,
 	}
 	buffer, err := ringbuffer.New(bufferCfg, ff)
 	if err != nil {
 		return nil, fmt.Errorf("create ring buffer for channel %s: %w", id, err)
 	}
 
+	marks, err := timeline.NewLog(filepath.Join(channelPath, "timeline.json"))
+	if err != nil {
+		logger.Warn("Timeline kept in memory", "channel", id, "error", err)
+		marks, _ = timeline.NewLog("")
+	}
+
 	ch := &Channel{
-		id:        id,
-		cfg:       cfg,
-		ffmpeg:    ff,
-		buffer:    buffer,
-		platform:  platformClient,
-		sessionID: sessionID,
-		basePath:  channelPath,
+		id:         id,
+		cfg:        cfg,
+		ffmpeg:     ff,
+		buffer:     buffer,
+		platform:   platformClient,
+		events:     bus,
+		sessionID:  sessionID,
+		basePath:   workPath,
+		timeline:   marks,
+		ghostSpans: make(map[string]trace.Span),
+		avsync:     newAVMonitor(cfg.Monitor.DriftThreshold),
+		latency:    newLatencyMonitor(),
+		segLatency: diskio.NewWindow(30),
+		segRate:    diskio.NewWindow(30),
+		history:    newChannelHistory(),
+		metrics:    newChannelMetrics(),
+		restarts:   &restartTracker{cfg: cfg.Monitor.Restart},
+		logger:     logger.With("channel", id),
 	}
 
 	// Set up segment callback
 	buffer.OnSegment(func(seg *ringbuffer.Segment) {
-		log.Printf("[%s] Segment %d ready: %s (%.2f KB)",
-			id, seg.Sequence, seg.FilePath, float64(seg.SizeBytes)/1024)
+		ch.logger.Debug("Segment ready", "seq", seg.Sequence, "path", seg.FilePath, "size_bytes", seg.SizeBytes)
+		ch.metrics.segments.Inc()
+		ch.publish(events.SegmentReady, map[string]interface{}{
+			"seq":        seg.Sequence,
+			"path":       seg.FilePath,
+			"start_time": seg.StartTime.UnixMilli(),
+			"duration":   seg.Duration.Seconds(),
+			"size_bytes": seg.SizeBytes,
+		})
+		if cfg.HLS.Thumbnails.Enabled {
+			ch.pruneThumbnails()
+		}
+		if ch.parts != nil {
+			ch.parts.segmentDone(seg.Sequence)
+		}
+		ch.checkAVSync(seg)
+		ch.recordSegmentIO(seg)
+		ch.recordLatency(seg)
+		ch.recordStats(seg)
+	})
+
+	buffer.OnGap(func(gap ringbuffer.Gap) {
+		ch.publish(events.BufferGap, map[string]interface{}{
+			"after_seq":        gap.AfterSeq,
+			"before_seq":       gap.BeforeSeq,
+			"missing_segments": gap.Missing,
+			"start_time":       gap.Start,
+			"end_time":         gap.End,
+			"duration":         gap.Duration,
+		})
 	})
 
 	// Set up ghost segment callback - notify platform of each segment during ghost clip
 	buffer.OnGhostSegment(func(playID string, seg *ringbuffer.Segment) {
-		log.Printf("[%s] Ghost segment for %s: seq=%d", id, playID, seg.Sequence)
-
-		// Notify platform of segment (non-blocking)
-		if ch.platform != nil && ch.platform.IsConfigured() {
-			go func() {
-				notifyCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer cancel()
-
-				// Build segment URL (HLS path on this capture machine)
-				segmentURL := fmt.Sprintf("/hls/%s/segment_%05d.m4s", id, seg.Sequence)
-
-				if err := ch.platform.NotifySegmentReady(notifyCtx, platform.SegmentNotification{
-					PlayID:     playID,
-					ChannelID:  id,
-					SegmentURL: segmentURL,
-					Sequence:   seg.Sequence,
-					Timestamp:  seg.StartTime.UnixMilli(),
-					IsFinal:    false,
-				}); err != nil {
-					log.Printf("[%s] Failed to notify platform of segment: %v", id, err)
-				}
-			}()
+		ch.logger.Debug("Ghost segment", "play_id", playID, "seq", seg.Sequence)
+		ch.spanMu.Lock()
+		if span, ok := ch.ghostSpans[playID]; ok {
+			span.AddEvent("segment", trace.WithAttributes(
+				attribute.Int("seq", seg.Sequence),
+				attribute.Int64("size_bytes", seg.SizeBytes)))
 		}
+		ch.spanMu.Unlock()
+
+		ch.publish(events.GhostSegment, map[string]interface{}{
+			"play_id":    playID,
+			"seq":        seg.Sequence,
+			"start_time": seg.StartTime.UnixMilli(),
+		})
 	})
 
+	if cfg.HLS.Encryption.Enabled {
+		keys, err := hlscrypt.NewKeyring(cfg.HLS.Encryption.RotateSegments)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", id, err)
+		}
+		ch.keys = keys
+	}
+
+	// Native NDI capture writes its own segments without chunks
+	if cfg.HLS.LowLatency.Enabled && cfg.Input.Type != "ndi" {
+		ch.parts = newPartTracker(cfg.HLS.LowLatency.PartDuration)
+	}
+	if cfg.WHEP.Enabled && cfg.Input.Type != "ndi" {
+		source, err := whep.NewSource(whep.Config{
+			ICEServers:  cfg.WHEP.ICEServers,
+			PublicIPs:   cfg.WHEP.PublicIPs,
+			MaxSessions: cfg.WHEP.MaxSessions,
+			Audio:       cfg.Encode.Audio.Codec != "none",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: whep: %w", id, err)
+		}
+		ch.whep = source
+	}
+
+	ch.restreams = make(map[string]*restream, len(cfg.Restream))
+	for _, rc := range cfg.Restream {
+		ch.restreams[rc.Name] = &restream{cfg: rc, want: rc.AutoStart}
+	}
+
 	return ch, nil
 }
 
@@ -121,20 +370,31 @@ func (ch *Channel) Start(ctx context.Context) error {
 		return fmt.Errorf("channel %s already running", ch.id)
 	}
 	ch.isRunning = true
+	ch.startedAt = time.Now()
 	ch.ctx, ch.cancel = context.WithCancel(ctx)
 	ch.mu.Unlock()
 
-	log.Printf("[%s] Starting channel", ch.id)
+	ch.logger.Info("Starting channel")
 
 	// Start ring buffer
 	if err := ch.buffer.Start(ch.ctx); err != nil {
 		return fmt.Errorf("start buffer: %w", err)
 	}
 
+	// Ghost clips still open before a restart carry on from the index
+	for _, playID := range ch.buffer.GetActiveGhostClips() {
+		ch.publish(events.GhostStarted, map[string]interface{}{"play_id": playID, "resumed": true})
+	}
+
+	ch.mu.RLock()
+	offSchedule := ch.offSchedule
+	ch.mu.RUnlock()
+
 	// Start capture if input is configured
-	if ch.cfg.Input.Type != "" && ch.cfg.Input.Device != "" {
+	if ch.cfg.Input.Type != "" && ch.cfg.Input.Device != "" && !offSchedule {
 		if err := ch.startCapture(); err != nil {
-			log.Printf("[%s] Warning: failed to start capture: %v", ch.id, err)
+			ch.logger.Warn("Failed to start capture", "error", err)
+			ch.publish(events.Error, map[string]interface{}{"error": err.Error()})
 		}
 	}
 
@@ -150,71 +410,193 @@ func (ch *Channel) Stop() {
 		ch.cancel()
 	}
 	ch.stopCapture()
+	ch.closeRestreams()
+	if ch.whep != nil {
+		ch.whep.Close()
+	}
 	ch.buffer.Stop()
 	ch.isRunning = false
-	log.Printf("[%s] Channel stopped", ch.id)
+	ch.logger.Info("Channel stopped")
+}
+
+// RestartCapture stops capture and starts it again, e.g. to pick up a
+// source that came back in a different format
+func (ch *Channel) RestartCapture() error {
+	ch.startMu.Lock()
+	defer ch.startMu.Unlock()
+	ch.mu.Lock()
+	if !ch.isRunning {
+		ch.mu.Unlock()
+		return fmt.Errorf("channel %s not running", ch.id)
+	}
+	if ch.offSchedule {
+		ch.mu.Unlock()
+		return fmt.Errorf("channel %s is outside its recording windows", ch.id)
+	}
+	ch.stopCapture()
+	ch.mu.Unlock()
+
+	ch.logger.Info("Restarting capture")
+	if err := ch.startCapture(); err != nil {
+		ch.publish(events.Error, map[string]interface{}{"error": err.Error()})
+		return fmt.Errorf("restart capture: %w", err)
+	}
+	return nil
+}
+
+// EncodeSettings are a channel's live encode settings
+type EncodeSettings struct {
+	Type      string `json:"type"`
+	Codec     string `json:"codec"`
+	Preset    string `json:"preset"`
+	Bitrate   int    `json:"bitrate"`
+	Restarted bool   `json:"restarted"` // Capture restarted to apply them
 }
 
-// startCapture starts the FFmpeg segment writer or native NDI capture
+// UpdateEncode changes the live encode and restarts capture with it. The
+// next segment writer carries on the buffer's sequence numbers; if it fails
+// to start, the previous settings are restored. A stopped channel keeps the
+// settings for its next start. (implements api.ChannelInterface)
+func (ch *Channel) UpdateEncode(u api.EncodeUpdate) (interface{}, error) {
+	ch.startMu.Lock()
+	defer ch.startMu.Unlock()
+	ch.mu.Lock()
+	prev := ch.cfg.Encode
+	enc := prev
+	if u.Codec != "" {
+		enc.Codec = u.Codec
+	}
+	if u.Preset != "" {
+		enc.Preset = u.Preset
+	}
+	if u.Bitrate > 0 {
+		enc.Bitrate = u.Bitrate
+	}
+	if _, err := ffmpeg.EncoderName(enc.Type, enc.Codec); err != nil {
+		ch.mu.Unlock()
+		return nil, err
+	}
+	if enc.Codec != prev.Codec && enc.ClipTrim == "frame" {
+		ch.mu.Unlock()
+		return nil, fmt.Errorf("codec can't change while clip_trim is frame")
+	}
+	ch.cfg.Encode = enc
+	restart := ch.isRunning && ch.isCapturing
+	if restart {
+		ch.stopCapture()
+	}
+	ch.mu.Unlock()
+
+	// The next writer replaces init.mp4; segments already captured keep the
+	// one they were encoded with
+	if enc.Codec != prev.Codec || enc.Preset != prev.Preset || enc.Bitrate != prev.Bitrate {
+		if err := ch.buffer.KeepInitSegment(); err != nil {
+			ch.logger.Warn("Failed to keep init segment, earlier footage may not play", "error", err)
+		}
+	}
+
+	ch.logger.Info("Encode settings changed", "codec", enc.Codec, "preset", enc.Preset, "bitrate", enc.Bitrate, "restart", restart)
+	if restart {
+		if err := ch.startCapture(); err != nil {
+			ch.logger.Warn("Failed to start capture with new encode settings, reverting", "error", err)
+			ch.mu.Lock()
+			ch.cfg.Encode = prev
+			ch.mu.Unlock()
+			if err := ch.startCapture(); err != nil {
+				ch.publish(events.Error, map[string]interface{}{"error": err.Error()})
+			}
+			return nil, fmt.Errorf("apply encode settings: %w", err)
+		}
+	}
+	return &EncodeSettings{Type: enc.Type, Codec: enc.Codec, Preset: enc.Preset, Bitrate: enc.Bitrate, Restarted: restart}, nil
+}
+
+// encodeConfig returns the channel's encode settings, which UpdateEncode may
+// change while capture runs
+func (ch *Channel) encodeConfig() EncodeConfig {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	return ch.cfg.Encode
+}
+
+// startCapture starts the FFmpeg segment writer or native NDI capture. A
+// channel encoding with NVENC takes a GPU session first; while all are in
+// use it is queued, and capture starts once one frees up.
 func (ch *Channel) startCapture() error {
-	cfg := ch.cfg
+	session, err := ch.acquireNVENC()
+	if errors.Is(err, errNVENCQueued) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ch.startCaptureWith(session)
+}
 
-	// Handle NDI with native capture
-	if cfg.Input.Type == "ndi" {
+// startCaptureWith starts capture holding session, which may be nil
+func (ch *Channel) startCaptureWith(session *encode.Session) error {
+	ch.mu.Lock()
+	ch.nvencSession = session
+	ch.mu.Unlock()
+	if err := ch.startWriter(); err != nil {
+		ch.releaseNVENC(session)
+		return err
+	}
+	return nil
+}
+
+// startWriter starts the segment writer or native NDI capture
+func (ch *Channel) startWriter() error {
+	ch.mu.RLock()
+	cfg := ch.cfg // UpdateEncode may change the encode settings meanwhile
+	ch.mu.RUnlock()
+
+	// Handle NDI with native capture, unless an encode plugin takes its frames
+	if cfg.Input.Type == "ndi" && (!cfg.Encode.Plugin || !ndi.IsAvailable()) {
 		return ch.startNDICapture()
 	}
 
-	// Build input string based on type
-	var input string
-	var inputFormat string
+	in, src, err := ch.openInput()
+	if err != nil {
+		return err
+	}
 
-	switch cfg.Input.Type {
-	case "file":
-		input = cfg.Input.Device
-	case "srt":
-		// SRT input - Device should be full URL like srt://host:port
-		input = cfg.Input.Device
-		// No inputFormat needed - FFmpeg auto-detects from URL
-	case "rtsp":
-		// RTSP input - Device should be full URL like rtsp://host:port/path
-		input = cfg.Input.Device
-	case "rtmp":
-		// RTMP input - Device should be full URL like rtmp://host:port/app/stream
-		input = cfg.Input.Device
-	case "screen":
-		input = "0:none"
-		inputFormat = "avfoundation"
-	case "avfoundation":
-		input = cfg.Input.Device
-		inputFormat = "avfoundation"
-	case "v4l2":
-		input = cfg.Input.Device
-		inputFormat = "v4l2"
-	case "dshow":
-		input = cfg.Input.Device
-		inputFormat = "dshow"
-	case "decklink":
-		input = cfg.Input.Device
-		inputFormat = "decklink"
-	default:
-		return fmt.Errorf("unknown input type: %s", cfg.Input.Type)
-	}
-
-	// Create segment writer
-	ch.writer = ch.ffmpeg.NewSegmentWriter(ffmpeg.SegmentConfig{
-		Input:           input,
-		InputFormat:     inputFormat,
-		Codec:           cfg.Encode.Codec,
-		Preset:          cfg.Encode.Preset,
-		Bitrate:         cfg.Encode.Bitrate,
-		GOP:             cfg.Encode.GOP,
-		BFrames:         cfg.Encode.BFrames,
-		SegmentDuration: cfg.Buffer.SegmentSize.Seconds(),
-		OutputDir:       ch.basePath,
-	})
+	// Compressed packets are muxed as they are; the rest is encoded, by an
+	// encode plugin or FFmpeg
+	var writer segmentWriter
+	codec := "copy"
+	if src.Packets != nil {
+		writer = newRemuxWriter(src.Packets, ch.basePath, cfg.Buffer.SegmentSize, ch.chunkDuration())
+	} else if src.Frames != nil {
+		enc, encCfg, err := ch.newEncoder(cfg, src.Frames)
+		if err != nil {
+			in.Close()
+			src.closeFiles()
+			return err
+		}
+		writer = newEncodeWriter(src.Frames, enc, encCfg, ch.basePath)
+		codec = enc.Name()
+	} else if codec, err = ch.resolveEncoder(cfg.Encode); err != nil {
+		in.Close()
+		src.closeFiles()
+		return err
+	}
+	if err := ch.openOutputs(cfg); err != nil {
+		in.Close()
+		src.closeFiles()
+		return err
+	}
+	if writer == nil {
+		writer = ch.newSegmentWriter(cfg, src, codec)
+	}
 
 	// Wire up segment callback
-	ch.writer.OnSegment(func(info ffmpeg.SegmentInfo) {
+	ch.avsync.reset()
+	ch.latency.reset()
+	writer.OnError(func(err error) {
+		ch.publish(events.Error, map[string]interface{}{"error": err.Error()})
+	})
+	writer.OnSegment(func(info ffmpeg.SegmentInfo) {
 		ch.buffer.AddSegment(&ringbuffer.Segment{
 			Sequence:  info.Sequence,
 			FilePath:  info.Path,
@@ -222,10 +604,16 @@ func (ch *Channel) startCapture() error {
 			Duration:  info.Duration,
 			SizeBytes: info.Size,
 		})
+		ch.sendSegment(info)
 	})
+	if ch.parts != nil || ch.hasStreamer() {
+		writer.OnSegmentStart(ch.segmentStarted)
+	}
 
 	// Start writing segments
-	if err := ch.writer.Start(ch.ctx); err != nil {
+	if err := writer.Start(ch.ctx); err != nil {
+		ch.closeOutputs()
+		in.Close()
 		return fmt.Errorf("start segment writer: %w", err)
 	}
 
@@ -233,32 +621,172 @@ func (ch *Channel) startCapture() error {
 	ch.buffer.SetInitSegment(filepath.Join(ch.basePath, "init.mp4"))
 
 	ch.mu.Lock()
+	if err := ch.ctx.Err(); err != nil {
+		ch.mu.Unlock()
+		writer.Stop() // Channel stopped while starting
+		in.Close()
+		return err
+	}
+	ch.writer = writer
+	ch.input = in
 	ch.isCapturing = true
 	ch.mu.Unlock()
+	ch.publish(events.StateChanged, map[string]interface{}{"capturing": true})
+	ch.metrics.encoderStarted()
+	recovery.Go("capture supervisor", func() { ch.superviseWriter(writer) })
+	switch cfg.Input.Type {
+	case "srt", "rtsp", "rtmp":
+		if timeout := cfg.Monitor.Restart.StallTimeout; timeout > 0 {
+			recovery.Go("stall watchdog", func() { ch.watchStall(writer, timeout) })
+		}
+	}
 
-	log.Printf("[%s] Capture started: %s -> %s", ch.id, input, ch.basePath)
+	ch.logger.Info("Capture started", "input", redactURL(src.URL), "plugin", in.Name(), "path", ch.basePath, "encoder", codec)
 	return nil
 }
 
+// resolveEncoder resolves the FFmpeg encoder of enc and makes sure this
+// FFmpeg build has it
+func (ch *Channel) resolveEncoder(enc EncodeConfig) (string, error) {
+	codec, err := ffmpeg.EncoderName(enc.Type, enc.Codec)
+	if err != nil {
+		return "", err
+	}
+	if encoders, err := ch.ffmpeg.ListEncoders(ch.ctx); err == nil && !encoders[codec] {
+		return "", fmt.Errorf("encoder %s not available in this FFmpeg build", codec)
+	}
+	if err := checkRenderDevice(enc); err != nil {
+		return "", err
+	}
+	return codec, nil
+}
+
+// checkRenderDevice checks the GPU a vaapi or qsv encoder runs on is there
+// and usable, so a missing one fails with a clear error instead of FFmpeg's
+func checkRenderDevice(enc EncodeConfig) error {
+	switch enc.Type {
+	case "vaapi", "qsv":
+		return ffmpeg.CheckRenderDevice(enc.Device)
+	}
+	return nil
+}
+
+// newSegmentWriter creates the FFmpeg segment writer that encodes src
+func (ch *Channel) newSegmentWriter(cfg ChannelConfig, src inputSource, codec string) *ffmpeg.SegmentWriter {
+	// First audio track is muxed with video, the rest are alternate renditions
+	var primaryTrack int
+	primarySelect := cfg.Encode.Audio.Select
+	var renditions []ffmpeg.AudioRendition
+	for i, t := range cfg.Encode.AudioTracks {
+		if i == 0 {
+			primaryTrack = t.Track
+			if len(t.Select) > 0 {
+				primarySelect = t.Select
+			}
+			continue
+		}
+		renditions = append(renditions, ffmpeg.AudioRendition{Name: t.Name, Track: t.Track, Select: t.Select})
+	}
+
+	// Audio of a native input's raw video comes as a single separate
+	// stream, if at all
+	audioCodec := cfg.Encode.Audio.Codec
+	if src.Stdin != nil && src.Format == "rawvideo" {
+		renditions = nil
+		if src.Audio.URL == "" {
+			audioCodec = "none"
+		}
+	}
+
+	var thumbDir string
+	if cfg.HLS.Thumbnails.Enabled {
+		thumbDir = ch.thumbnailDir()
+	}
+	hwArgs, hwUpload := ffmpeg.HWEncodeArgs(cfg.Encode.Type, cfg.Encode.Device)
+
+	// Timecode burn-in counts output frames
+	framerate := cfg.Input.Framerate
+	if src.Framerate > 0 {
+		framerate = src.Framerate
+	}
+	if cfg.Encode.Deinterlace.Rate == "field" {
+		framerate *= 2
+	}
+	return ch.ffmpeg.NewSegmentWriter(ffmpeg.SegmentConfig{
+		Input:             src.URL,
+		InputFormat:       src.Format,
+		InputOptions:      src.Options,
+		Stdin:             src.Stdin,
+		AudioInput:        src.Audio.URL,
+		AudioInputFormat:  src.Audio.Format,
+		AudioInputOptions: src.Audio.Options,
+		ExtraFiles:        src.ExtraFiles,
+		Codec:             codec,
+		EncoderOptions:    ch.nvencOptions(),
+		Preset:            ffmpeg.EncoderPreset(cfg.Encode.Type, codec, cfg.Encode.Preset),
+		HWArgs:            hwArgs,
+		HWUpload:          hwUpload,
+		Bitrate:           cfg.Encode.Bitrate,
+		GOP:               cfg.Encode.GOP,
+		BFrames:           cfg.Encode.BFrames,
+		Deinterlace:       cfg.Encode.Deinterlace.Filter,
+		FieldOrder:        cfg.Encode.Deinterlace.FieldOrder,
+		DeinterlaceFields: cfg.Encode.Deinterlace.Rate == "field",
+		BurnIn:            cfg.Encode.Overlay.burnIn(cfg.ID, framerate),
+		ChunkDuration:     ch.chunkDuration().Seconds(),
+		PrimaryAudioTrack: primaryTrack,
+		AudioRenditions:   renditions,
+		RenditionWindow:   int(cfg.Buffer.Duration / cfg.Buffer.SegmentSize),
+		ThumbnailDir:      thumbDir,
+		ThumbnailWidth:    cfg.HLS.Thumbnails.Width,
+		AudioCodec:        audioCodec,
+		AudioBitrate:      cfg.Encode.Audio.Bitrate,
+		AudioChannels:     cfg.Encode.Audio.Channels,
+		AudioLayout:       cfg.Encode.Audio.Layout,
+		AudioSampleRate:   cfg.Encode.Audio.SampleRate,
+		AudioSelect:       primarySelect,
+		SegmentDuration:   cfg.Buffer.SegmentSize.Seconds(),
+		OutputDir:         ch.basePath,
+	})
+}
+
 // startNDICapture starts native NDI capture
 func (ch *Channel) startNDICapture() error {
+	ch.mu.RLock()
 	cfg := ch.cfg
+	ch.mu.RUnlock()
 
 	// Check if NDI SDK is available
 	if !ndi.IsAvailable() {
 		return fmt.Errorf("NDI SDK not available - please install NDI SDK from https://ndi.video/tools/")
 	}
 
-	log.Printf("[%s] Starting native NDI capture: %s", ch.id, cfg.Input.Device)
+	codec, err := ffmpeg.EncoderName(cfg.Encode.Type, cfg.Encode.Codec)
+	if err != nil {
+		return err
+	}
+	if encoders, err := ch.ffmpeg.ListEncoders(ch.ctx); err == nil && !encoders[codec] {
+		return fmt.Errorf("encoder %s not available in this FFmpeg build", codec)
+	}
+	if err := checkRenderDevice(cfg.Encode); err != nil {
+		return err
+	}
+
+	ch.logger.Info("Starting native NDI capture", "source", cfg.Input.Device, "encoder", codec)
 
 	// Create NDI capture
 	capture, err := ndi.NewCapture(ndi.CaptureConfig{
 		SourceName:      cfg.Input.Device,
 		OutputDir:       ch.basePath,
 		SegmentDuration: cfg.Buffer.SegmentSize.Seconds(),
+		EncoderType:     cfg.Encode.Type,
 		Codec:           cfg.Encode.Codec,
 		Preset:          cfg.Encode.Preset,
+		EncoderOptions:  ch.nvencOptions(),
+		Device:          cfg.Encode.Device,
 		Bitrate:         cfg.Encode.Bitrate,
+		AudioCodec:      cfg.Encode.Audio.Codec,
+		AudioBitrate:    cfg.Encode.Audio.Bitrate,
 	})
 	if err != nil {
 		return fmt.Errorf("create NDI capture: %w", err)
@@ -271,14 +799,45 @@ func (ch *Channel) startNDICapture() error {
 		return fmt.Errorf("start NDI capture: %w", err)
 	}
 
+	// The preview only monitors, so it never stops the capture
+	if cfg.Input.NDI.Preview.Enabled {
+		if err := ch.startNDIPreview(); err != nil {
+			ch.logger.Warn("NDI preview disabled", "error", err)
+		}
+	}
+
 	// Set init segment path
 	ch.buffer.SetInitSegment(filepath.Join(ch.basePath, "init.mp4"))
 
 	ch.mu.Lock()
 	ch.isCapturing = true
 	ch.mu.Unlock()
+	ch.publish(events.StateChanged, map[string]interface{}{"capturing": true})
+	ch.metrics.encoderStarted()
 
-	log.Printf("[%s] NDI capture started: %s -> %s", ch.id, cfg.Input.Device, ch.basePath)
+	ch.logger.Info("NDI capture started", "source", cfg.Input.Device, "path", ch.basePath)
+	return nil
+}
+
+// startNDIPreview opens a second receiver on the source's low-bandwidth
+// stream for the JPEG preview
+func (ch *Channel) startNDIPreview() error {
+	cfg := ch.cfg.Input.NDI.Preview
+	preview, err := ndi.NewPreview(ndi.PreviewConfig{
+		SourceName: ch.cfg.Input.Device,
+		Width:      cfg.Width,
+		Framerate:  cfg.Framerate,
+		Quality:    cfg.Quality,
+	})
+	if err != nil {
+		return err
+	}
+	if err := preview.Start(ch.ctx); err != nil {
+		return err
+	}
+	ch.mu.Lock()
+	ch.ndiPreview = preview
+	ch.mu.Unlock()
 	return nil
 }
 
@@ -288,10 +847,26 @@ func (ch *Channel) stopCapture() {
 		ch.writer.Stop()
 		ch.writer = nil
 	}
+	if ch.input != nil {
+		ch.input.Close()
+		ch.input = nil
+	}
 	if ch.ndiCapture != nil {
 		ch.ndiCapture.Stop()
 		ch.ndiCapture = nil
 	}
+	if ch.ndiPreview != nil {
+		ch.ndiPreview.Stop()
+		ch.ndiPreview = nil
+	}
+	if ch.nvencSession != nil {
+		ch.nvencSession.Release()
+		ch.nvencSession = nil
+	}
+	ch.closeOutputs()
+	if ch.isCapturing {
+		ch.publish(events.StateChanged, map[string]interface{}{"capturing": false})
+	}
 	ch.isCapturing = false
 }
 
@@ -299,79 +874,292 @@ func (ch *Channel) stopCapture() {
 func (ch *Channel) SetSession(sessionID string) {
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
+	if ch.sessionID != "" && ch.sessionID != sessionID {
+		ch.timeline.Clear()
+	}
 	ch.sessionID = sessionID
 }
 
 // GetStatus returns the channel status (implements api.ChannelInterface)
 func (ch *Channel) GetStatus() interface{} {
+	outputs := ch.outputStatuses()
 	ch.mu.RLock()
 	defer ch.mu.RUnlock()
 
 	bufferStatus := ch.buffer.GetStatus()
 
+	var avsync *AVSyncStatus
+	var encoder *ffmpeg.EncoderStats
+	if ch.writer != nil {
+		s := ch.avsync.status()
+		stats := ch.writer.Stats()
+		s.DroppedFrames = stats.Dropped
+		s.DuplicatedFrames = stats.Duplicated
+		avsync = &s
+		encoder = &stats
+	}
+
+	var nvencGPU *int
+	if ch.nvencSession != nil {
+		gpu := ch.nvencSession.GPU()
+		nvencGPU = &gpu
+	}
+
 	return ChannelStatus{
 		ChannelID:    ch.id,
 		IsRunning:    ch.isRunning,
 		IsCapturing:  ch.isCapturing,
+		OffSchedule:  ch.offSchedule,
 		SessionID:    ch.sessionID,
 		BufferHealth: bufferStatus.Health,
 		OldestTime:   bufferStatus.OldestTime,
 		NewestTime:   bufferStatus.NewestTime,
 		SegmentCount: bufferStatus.SegmentCount,
 		InitSegment:  bufferStatus.InitSegment,
+		Gaps:         bufferStatus.Gaps,
+		AVSync:       avsync,
+		Encoder:      encoder,
+		Latency:      ch.latency.status(),
+		Restart:      ch.restarts.snapshot(),
+		Outputs:      outputs,
+		NoSignal:     ch.noSignal.Load(),
+		NVENCGPU:     nvencGPU,
+		NVENCQueued:  ch.nvencQueued,
 	}
 }
 
+// encoderStats returns the FFmpeg writer's latest progress, zero when not
+// capturing with FFmpeg
+func (ch *Channel) encoderStats() ffmpeg.EncoderStats {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	if ch.writer == nil {
+		return ffmpeg.EncoderStats{}
+	}
+	return ch.writer.Stats()
+}
+
+// ndiStats returns the native NDI pipeline's counters, zero when not
+// capturing from NDI
+func (ch *Channel) ndiStats() ndi.ReceiverStats {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	if ch.ndiCapture == nil {
+		return ndi.ReceiverStats{}
+	}
+	return ch.ndiCapture.Stats()
+}
+
+// FFmpegLog returns recent stderr output of the channel's FFmpeg process
+func (ch *Channel) FFmpegLog() []string {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	if ch.writer == nil {
+		return nil
+	}
+	return ch.writer.StderrTail()
+}
+
+// recordSegmentIO tracks how late each segment lands on disk relative to
+// its nominal end time, and the write rate it represents
+func (ch *Channel) recordSegmentIO(seg *ringbuffer.Segment) {
+	if seg.Duration <= 0 {
+		return
+	}
+	ch.segLatency.Add(float64(segmentLatency(seg).Milliseconds()))
+	ch.segRate.Add(float64(seg.SizeBytes) / seg.Duration.Seconds() / 1e6)
+}
+
+// recordLatency measures how long after capture a new segment reached the
+// playlist
+func (ch *Channel) recordLatency(seg *ringbuffer.Segment) {
+	now := time.Now()
+	first := ch.latency.observe(ch.buffer.GetInitSegment(), seg, now)
+	ch.history.glassToGlass.Add(now, float64(first.Milliseconds()))
+}
+
+// recordStats adds a segment to the channel's stats history
+func (ch *Channel) recordStats(seg *ringbuffer.Segment) {
+	var frames int64
+	ch.mu.RLock()
+	if ch.writer != nil {
+		frames = ch.writer.Stats().Frames
+	}
+	ch.mu.RUnlock()
+	ch.history.record(seg, frames, ch.buffer.GetStatus().Health)
+}
+
+// ioStatus summarizes the channel's segment write load
+func (ch *Channel) ioStatus() ChannelIOStatus {
+	latency := ch.segLatency.Summary()
+	return ChannelIOStatus{
+		SegmentLatencyMs: latency,
+		WriteMBps:        math.Round(ch.segRate.Summary().Mean*100) / 100,
+		Behind:           latency.Count >= 5 && latency.P95 > float64(ch.cfg.Buffer.SegmentSize.Milliseconds()),
+	}
+}
+
+// checkAVSync measures drift on a new segment and raises or clears the
+// drift alert
+func (ch *Channel) checkAVSync(seg *ringbuffer.Segment) {
+	initPath := ch.buffer.GetInitSegment()
+	if initPath == "" {
+		return
+	}
+	drift, changed, err := ch.avsync.observe(initPath, seg.FilePath)
+	if err != nil {
+		ch.logger.Debug("A/V sync check failed", "seq", seg.Sequence, "error", err)
+		return
+	}
+	if !changed {
+		return
+	}
+
+	alert := ch.avsync.status().Alert
+	if alert {
+		ch.logger.Warn("A/V drift above threshold", "drift", drift, "threshold", ch.avsync.threshold)
+	} else {
+		ch.logger.Info("A/V drift back within threshold", "drift", drift)
+	}
+	ch.publish(events.AVDrift, map[string]interface{}{
+		"drift_ms":     drift.Milliseconds(),
+		"threshold_ms": ch.avsync.threshold.Milliseconds(),
+		"alert":        alert,
+		"seq":          seg.Sequence,
+	})
+}
+
 // StartGhostClip starts ghost-clipping mode for a play
 func (ch *Channel) StartGhostClip(playID string) error {
-	return ch.buffer.StartGhostClip(playID)
+	if err := ch.buffer.StartGhostClip(playID); err != nil {
+		return err
+	}
+	_, span := tracing.Start(context.Background(), "ghost_clip",
+		attribute.String("channel", ch.id),
+		attribute.String("play_id", playID))
+	ch.spanMu.Lock()
+	ch.ghostSpans[playID] = span
+	ch.spanMu.Unlock()
+	ch.publish(events.GhostStarted, map[string]interface{}{"play_id": playID})
+	ch.timeline.Add(timeline.Event{Kind: timeline.KindMarker, Name: playID, Start: time.Now()})
+	return nil
+}
+
+// resumeGhostClip takes over a ghost clip marked in on the failed active
+// agent of a redundant pair
+func (ch *Channel) resumeGhostClip(playID string, start time.Time) error {
+	return ch.startGhostClipAt(playID, start, true)
+}
+
+// startGhostClipAt starts a ghost clip marked in at start, which may be a
+// moment ago, including the buffered segments since then
+func (ch *Channel) startGhostClipAt(playID string, start time.Time, resumed bool) error {
+	if err := ch.buffer.ResumeGhostClip(playID, start); err != nil {
+		return err
+	}
+	_, span := tracing.Start(context.Background(), "ghost_clip",
+		attribute.String("channel", ch.id),
+		attribute.String("play_id", playID),
+		attribute.Bool("resumed", resumed))
+	ch.spanMu.Lock()
+	ch.ghostSpans[playID] = span
+	ch.spanMu.Unlock()
+	data := map[string]interface{}{"play_id": playID, "start_time": start.UnixMilli()}
+	if resumed {
+		data["resumed"] = true
+	}
+	ch.publish(events.GhostStarted, data)
+	ch.timeline.Add(timeline.Event{Kind: timeline.KindMarker, Name: playID, Start: start})
+	return nil
+}
+
+// uploadsEnabled reports whether clips go to the platform: it is configured
+// and this agent is not the standby of a redundant pair
+func (ch *Channel) uploadsEnabled() bool {
+	return ch.platform != nil && ch.platform.IsConfigured() && (ch.pairing == nil || ch.pairing.isActive())
 }
 
 // EndGhostClip ends ghost-clipping mode
 func (ch *Channel) EndGhostClip(playID string) error {
-	_, err := ch.buffer.EndGhostClip(playID)
-	return err
+	_, span := ch.ghostSpan(context.Background(), playID)
+	result, err := ch.buffer.EndGhostClip(playID)
+	if err != nil {
+		tracing.End(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.Int("segments", result.SegmentCount))
+	tracing.End(span, nil)
+	ch.publishGhostEnded(result)
+	ch.timeline.Add(timeline.Event{
+		Kind:  timeline.KindGhost,
+		Name:  playID,
+		Start: result.StartTime,
+		End:   result.EndTime,
+	})
+	return nil
+}
+
+// CancelGhostClip discards a ghost clip marked in by mistake: nothing is
+// generated or sent to the platform (implements api.ChannelInterface)
+func (ch *Channel) CancelGhostClip(playID string) error {
+	_, span := ch.ghostSpan(context.Background(), playID)
+	err := ch.buffer.CancelGhostClip(playID)
+	span.SetAttributes(attribute.Bool("cancelled", true))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	ch.timeline.RemoveMarker(playID)
+	ch.publish(events.GhostCancelled, map[string]interface{}{"play_id": playID})
+	return nil
 }
 
 // EndGhostClipAndGenerate ends ghost-clipping and generates the clip (implements api.ChannelInterface)
-func (ch *Channel) EndGhostClipAndGenerate(ctx context.Context, playID string, tags map[string]interface{}) (interface{}, error) {
-	ch.mu.RLock()
-	sessionID := ch.sessionID
-	ch.mu.RUnlock()
+func (ch *Channel) EndGhostClipAndGenerate(ctx context.Context, playID string, tags map[string]interface{}, opts api.ClipOptions) (_ interface{}, err error) {
+	ctx, span := ch.ghostSpan(ctx, playID)
+	defer func() { tracing.End(span, err) }()
 
-	// End ghost clip to get segment info
-	ghostResult, err := ch.buffer.EndGhostClip(playID)
+	result, err := ch.generateGhostClip(ctx, playID, time.Time{}, tags, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// Send final segment notification to platform (IsFinal = true)
-	if ch.platform != nil && ch.platform.IsConfigured() {
+	// Upload to platform if configured
+	if ch.uploadsEnabled() {
+		uploadCtx, cancel := context.WithTimeout(trace.ContextWithSpan(context.Background(), span), 5*time.Minute)
 		go func() {
-			notifyCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer recovery.Handle("clip upload", nil)
 			defer cancel()
+			ch.uploadClip(uploadCtx, result, "", nil)
+		}()
+	}
 
-			// Use the last segment's sequence for the final notification
-			lastSeq := 0
-			if len(ghostResult.Segments) > 0 {
-				lastSeq = ghostResult.Segments[len(ghostResult.Segments)-1]
-			}
+	return result, nil
+}
 
-			if err := ch.platform.NotifySegmentReady(notifyCtx, platform.SegmentNotification{
-				PlayID:     playID,
-				ChannelID:  ch.id,
-				SegmentURL: fmt.Sprintf("/hls/%s/segment_%05d.m4s", ch.id, lastSeq),
-				Sequence:   lastSeq,
-				Timestamp:  ghostResult.EndTime.UnixMilli(),
-				IsFinal:    true,
-			}); err != nil {
-				log.Printf("[%s] Failed to send final segment notification: %v", ch.id, err)
-			}
-		}()
+// generateGhostClip ends a ghost clip marked out at end (zero for now) and
+// generates it, leaving the upload to the caller
+func (ch *Channel) generateGhostClip(ctx context.Context, playID string, end time.Time, tags map[string]interface{}, opts api.ClipOptions) (_ *ClipResultWithTags, err error) {
+	defer func(start time.Time) { ch.metrics.clipDone(start, err) }(time.Now())
+
+	ch.mu.RLock()
+	sessionID := ch.sessionID
+	tracks := ch.cfg.Encode.AudioTracks
+	ch.mu.RUnlock()
+	if err := checkClipAudio(opts, tracks); err != nil {
+		return nil, err
 	}
 
+	// End ghost clip to get segment info
+	ghostResult, err := ch.buffer.EndGhostClipAt(playID, end)
+	if err != nil {
+		return nil, err
+	}
+
+	ch.publishGhostEnded(ghostResult)
+
 	// Generate clip from the tracked segments
-	clipResult, err := ch.buffer.GenerateClipFromSegments(ctx, ghostResult.Segments, playID)
+	clipResult, err := ch.buffer.GenerateClipFromSegments(ctx, ghostResult.Segments, playID, clipOptions(opts, sessionID, tags))
 	if err != nil {
 		return nil, fmt.Errorf("generate clip: %w", err)
 	}
@@ -379,12 +1167,24 @@ func (ch *Channel) EndGhostClipAndGenerate(ctx context.Context, playID string, t
 	startMs := ghostResult.StartTime.UnixMilli()
 	endMs := ghostResult.EndTime.UnixMilli()
 
+	ch.timeline.Add(timeline.Event{
+		Kind:     timeline.KindClip,
+		Name:     playID,
+		Start:    ghostResult.StartTime,
+		End:      ghostResult.EndTime,
+		FilePath: clipResult.FilePath,
+		Tags:     tags,
+	})
+	ch.publishClip(playID, startMs, endMs, clipResult)
+
 	result := &ClipResultWithTags{
 		ClipResult: ClipResult{
 			FilePath:      clipResult.FilePath,
 			Duration:      clipResult.Duration,
 			FileSizeBytes: clipResult.FileSizeBytes,
 			SegmentCount:  clipResult.SegmentCount,
+			PlaybackRate:  clipResult.PlaybackRate,
+			Variants:      clipResult.Variants,
 		},
 		PlayID:    playID,
 		StartTime: startMs,
@@ -393,52 +1193,113 @@ func (ch *Channel) EndGhostClipAndGenerate(ctx context.Context, playID string, t
 		ChannelID: ch.id,
 		SessionID: sessionID,
 	}
+	return result, nil
+}
 
-	// Upload to platform if configured
-	if ch.platform != nil && ch.platform.IsConfigured() {
-		uploadCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-		go func() {
-			defer cancel()
-			ch.uploadClipToPlatform(uploadCtx, clipResult.FilePath, platform.ClipMetadata{
-				SessionID:       sessionID,
-				ChannelID:       ch.id,
-				PlayID:          playID,
-				StartTime:       startMs,
-				EndTime:         endMs,
-				DurationSeconds: clipResult.Duration,
-				FileSizeBytes:   clipResult.FileSizeBytes,
-				Tags:            tags,
-			})
-		}()
+// uploadClip uploads a generated ghost clip; bundleID and angles are set
+// when it is one angle of a multi-channel clip
+func (ch *Channel) uploadClip(ctx context.Context, result *ClipResultWithTags, bundleID string, angles []string) {
+	ch.uploadClipToPlatform(ctx, result.FilePath, result.Variants, platform.ClipMetadata{
+		SessionID:       result.SessionID,
+		ChannelID:       ch.id,
+		PlayID:          result.PlayID,
+		StartTime:       result.StartTime,
+		EndTime:         result.EndTime,
+		DurationSeconds: result.Duration,
+		FileSizeBytes:   result.FileSizeBytes,
+		Tags:            result.Tags,
+		BundleID:        bundleID,
+		Angles:          angles,
+	})
+}
+
+// clipOptions converts API clip options for the ring buffer, adding the
+// session and tags for the clip overlay
+func clipOptions(opts api.ClipOptions, sessionID string, tags map[string]interface{}) ringbuffer.ClipOptions {
+	fields := map[string]string{"session": sessionID}
+	for k, v := range tags {
+		fields["tags."+k] = fmt.Sprint(v)
+	}
+	clip := ringbuffer.ClipOptions{
+		Rate:          opts.PlaybackRate,
+		Interpolate:   opts.Interpolate,
+		Mute:          opts.Mute,
+		OverlayFields: fields,
+	}
+	if opts.AudioTrack != "" {
+		clip.Audio = []ringbuffer.ClipAudioTrack{{Track: opts.AudioTrack}}
+	}
+	for _, m := range opts.AudioMix {
+		clip.Audio = append(clip.Audio, ringbuffer.ClipAudioTrack{Track: m.Track, GainDB: m.GainDB})
 	}
+	return clip
+}
 
-	return result, nil
+// checkClipAudio rejects clip audio that names a track the channel doesn't
+// encode as a rendition
+func checkClipAudio(opts api.ClipOptions, tracks []AudioTrackConfig) error {
+	configured := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		configured[t.Name] = true
+	}
+	names := []string{opts.AudioTrack}
+	for _, m := range opts.AudioMix {
+		names = append(names, m.Track)
+	}
+	for _, name := range names {
+		if name != "" && !configured[name] {
+			return fmt.Errorf("audio track %q is not configured in encode.audio_tracks", name)
+		}
+	}
+	return nil
 }
 
 // GenerateClip generates a clip from the ring buffer by time range (implements api.ChannelInterface)
-func (ch *Channel) GenerateClip(ctx context.Context, startTime, endTime int64, playID string) (interface{}, error) {
+func (ch *Channel) GenerateClip(ctx context.Context, startTime, endTime int64, playID string, opts api.ClipOptions) (_ interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "clip",
+		attribute.String("channel", ch.id),
+		attribute.String("play_id", playID))
+	defer func() { tracing.End(span, err) }()
+	defer func(start time.Time) { ch.metrics.clipDone(start, err) }(time.Now())
+
 	ch.mu.RLock()
 	sessionID := ch.sessionID
+	tracks := ch.cfg.Encode.AudioTracks
 	ch.mu.RUnlock()
+	if err := checkClipAudio(opts, tracks); err != nil {
+		return nil, err
+	}
 
-	result, err := ch.buffer.GenerateClip(ctx, startTime, endTime, playID)
+	result, err := ch.buffer.GenerateClip(ctx, startTime, endTime, playID, clipOptions(opts, sessionID, nil))
 	if err != nil {
 		return nil, err
 	}
 
+	ch.timeline.Add(timeline.Event{
+		Kind:     timeline.KindClip,
+		Name:     playID,
+		Start:    time.UnixMilli(startTime),
+		End:      time.UnixMilli(endTime),
+		FilePath: result.FilePath,
+	})
+	ch.publishClip(playID, startTime, endTime, result)
+
 	clipResult := &ClipResult{
 		FilePath:      result.FilePath,
 		Duration:      result.Duration,
 		FileSizeBytes: result.FileSizeBytes,
 		SegmentCount:  result.SegmentCount,
+		PlaybackRate:  result.PlaybackRate,
+		Variants:      result.Variants,
 	}
 
 	// Upload to platform if configured
-	if ch.platform != nil && ch.platform.IsConfigured() {
-		uploadCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	if ch.uploadsEnabled() {
+		uploadCtx, cancel := context.WithTimeout(trace.ContextWithSpan(context.Background(), span), 5*time.Minute)
 		go func() {
+			defer recovery.Handle("clip upload", nil)
 			defer cancel()
-			ch.uploadClipToPlatform(uploadCtx, result.FilePath, platform.ClipMetadata{
+			ch.uploadClipToPlatform(uploadCtx, result.FilePath, result.Variants, platform.ClipMetadata{
 				SessionID:       sessionID,
 				ChannelID:       ch.id,
 				PlayID:          playID,
@@ -453,42 +1314,412 @@ func (ch *Channel) GenerateClip(ctx context.Context, startTime, endTime int64, p
 	return clipResult, nil
 }
 
-// uploadClipToPlatform uploads a clip to the video-platform
-func (ch *Channel) uploadClipToPlatform(ctx context.Context, filePath string, metadata platform.ClipMetadata) {
+// uploadClipToPlatform uploads a clip and its aspect-ratio variants to the video-platform
+func (ch *Channel) uploadClipToPlatform(ctx context.Context, filePath string, variants []ringbuffer.ClipVariantResult, metadata platform.ClipMetadata) {
+	ctx, span := tracing.Start(ctx, "clip.upload", attribute.Int("variants", len(variants)))
+	defer span.End()
+
 	result, err := ch.platform.UploadClip(ctx, filePath, metadata)
 	if err != nil {
-		log.Printf("[%s] Failed to upload clip to platform: %v", ch.id, err)
+		ch.logger.Warn("Failed to upload clip to platform", "play_id", metadata.PlayID, "error", err)
+		ch.metrics.uploadFailures.Inc()
+		tracing.End(span, err)
+		// Variants wait for the master so the platform never has orphans
+		ch.queueUpload(filePath, metadata, err)
+		for _, v := range variants {
+			ch.queueUpload(v.FilePath, variantMetadata(metadata, v), err)
+		}
 		return
 	}
-	log.Printf("[%s] Clip uploaded to platform: %s (size: %d bytes)", ch.id, result.FilePath, result.FileSize)
+	ch.clipUploaded(metadata, result)
+
+	for _, v := range variants {
+		variantMeta := variantMetadata(metadata, v)
+		result, err := ch.platform.UploadClip(ctx, v.FilePath, variantMeta)
+		if err != nil {
+			ch.logger.Warn("Failed to upload clip variant", "play_id", metadata.PlayID, "variant", v.Name, "error", err)
+			ch.queueUpload(v.FilePath, variantMeta, err)
+			continue
+		}
+		ch.clipUploaded(variantMeta, result)
+	}
+}
+
+// variantMetadata returns a clip's upload metadata for one of its variants
+func variantMetadata(metadata platform.ClipMetadata, v ringbuffer.ClipVariantResult) platform.ClipMetadata {
+	metadata.Variant = v.Name
+	metadata.AspectRatio = v.AspectRatio
+	metadata.FileSizeBytes = v.FileSizeBytes
+	return metadata
+}
+
+// queueUpload hands a failed upload to the retry queue
+func (ch *Channel) queueUpload(filePath string, metadata platform.ClipMetadata, err error) {
+	if ch.uploads != nil {
+		ch.uploads.Add(filePath, metadata, err)
+	}
+}
+
+// clipUploaded records a successful upload; metrics and events cover
+// masters only
+func (ch *Channel) clipUploaded(metadata platform.ClipMetadata, result *platform.UploadResult) {
+	if metadata.Variant != "" {
+		ch.logger.Info("Clip variant uploaded to platform", "play_id", metadata.PlayID, "variant", metadata.Variant)
+		return
+	}
+	ch.metrics.uploads.Inc()
+	ch.logger.Info("Clip uploaded to platform", "play_id", metadata.PlayID, "path", result.FilePath, "size_bytes", result.FileSize)
+	ch.publish(events.ClipUploaded, map[string]interface{}{
+		"play_id":     metadata.PlayID,
+		"remote_path": result.FilePath,
+		"size_bytes":  result.FileSize,
+	})
+}
+
+// publish sends a channel event to the bus
+func (ch *Channel) publish(t events.Type, data map[string]interface{}) {
+	ch.events.Publish(events.Event{Type: t, Channel: ch.id, Data: data})
+}
+
+// publishGhostEnded announces the end of a ghost clip with its last segment
+func (ch *Channel) publishGhostEnded(result *ringbuffer.GhostClipResult) {
+	lastSeq := 0
+	if len(result.Segments) > 0 {
+		lastSeq = result.Segments[len(result.Segments)-1]
+	}
+	ch.publish(events.GhostEnded, map[string]interface{}{
+		"play_id":    result.PlayID,
+		"seq":        lastSeq,
+		"segments":   result.SegmentCount,
+		"start_time": result.StartTime.UnixMilli(),
+		"end_time":   result.EndTime.UnixMilli(),
+	})
+}
+
+// publishClip announces a generated clip
+func (ch *Channel) publishClip(playID string, startMs, endMs int64, result *ringbuffer.ClipResult) {
+	ch.publish(events.ClipGenerated, map[string]interface{}{
+		"play_id":    playID,
+		"file_path":  result.FilePath,
+		"start_time": startMs,
+		"end_time":   endMs,
+		"duration":   result.Duration,
+		"size_bytes": result.FileSizeBytes,
+		"variants":   len(result.Variants),
+	})
+}
+
+// ghostSpan removes and returns the span opened at mark-in, with ctx carrying
+// it. A fresh span is started when none is open (e.g. after a restart).
+func (ch *Channel) ghostSpan(ctx context.Context, playID string) (context.Context, trace.Span) {
+	ch.spanMu.Lock()
+	span, ok := ch.ghostSpans[playID]
+	delete(ch.ghostSpans, playID)
+	ch.spanMu.Unlock()
+	if !ok {
+		return tracing.Start(ctx, "ghost_clip",
+			attribute.String("channel", ch.id),
+			attribute.String("play_id", playID))
+	}
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// ExportTimeline exports the session's marks and clips as edl, fcpxml or otio
+// (implements api.ChannelInterface)
+func (ch *Channel) ExportTimeline(format string) ([]byte, string, error) {
+	ch.mu.RLock()
+	title := ch.sessionID
+	ch.mu.RUnlock()
+	if title == "" {
+		title = "capture"
+	}
+
+	name := ch.id
+	if name == "" {
+		name = "capture"
+	}
+	url := ch.cfg.Buffer.RecordingURL
+	if url == "" {
+		url = fmt.Sprintf("/hls/%s/live.m3u8", ch.id)
+	}
+
+	return timeline.Export(format, title, timeline.Source{
+		Name:  name,
+		URL:   url,
+		Start: ch.buffer.StartTime(),
+		Rate:  ch.cfg.Input.Framerate,
+	}, ch.timeline.Events())
 }
 
-// GetHLSPlaylist generates a live HLS playlist
+// GetHLSPlaylist generates a live HLS playlist from the buffer index
 func (ch *Channel) GetHLSPlaylist() ([]byte, error) {
+	// Read before listing, so a gap aging out in between isn't counted twice
+	discontinuities := ch.buffer.DiscontinuitySequence()
+	segments := ch.manifestSegments()
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments available")
+	}
+
+	playlist := manifest.MediaPlaylist{
+		Segments:              segments,
+		MapURI:                "init.mp4",
+		Window:                ch.playlistWindow(),
+		ProgramDateTime:       true,
+		TargetDuration:        ch.cfg.Buffer.SegmentSize,
+		DiscontinuitySequence: discontinuities,
+	}
+	if ch.keys != nil {
+		playlist.KeyURI = func(seq int) string {
+			return fmt.Sprintf("key_%05d.key", ch.keys.KeyIndex(seq))
+		}
+	}
+	if ch.parts != nil {
+		ch.addParts(&playlist)
+	}
+	return playlist.Encode(), nil
+}
+
+// GetHLSMasterPlaylist generates a master playlist listing the audio renditions
+// (implements api.ChannelInterface)
+func (ch *Channel) GetHLSMasterPlaylist() ([]byte, error) {
+	tracks := ch.encodeConfig().AudioTracks
+
+	master := manifest.MasterPlaylist{
+		Variants: []manifest.Variant{{URI: "live.m3u8", Bandwidth: ch.bandwidth()}},
+	}
+	if ch.cfg.HLS.Thumbnails.Enabled {
+		master.ImageStreams = []manifest.Variant{{URI: "thumbs.m3u8", Bandwidth: 20000}}
+	}
+	for i, t := range tracks {
+		name := t.Label
+		if name == "" {
+			name = t.Name
+		}
+		r := manifest.Rendition{
+			Type:     "AUDIO",
+			GroupID:  "audio",
+			Name:     name,
+			Language: t.Language,
+			Default:  i == 0,
+		}
+		// Primary track is muxed into the main stream, so it has no URI
+		if i > 0 {
+			r.URI = ffmpeg.AudioRenditionDir(t.Name) + "/live.m3u8"
+		}
+		master.Renditions = append(master.Renditions, r)
+	}
+	if len(tracks) > 0 {
+		master.Variants[0].AudioGroup = "audio"
+	}
+	return master.Encode(), nil
+}
+
+// GetDASHManifest generates a live DASH MPD from the buffer index
+// (implements api.ChannelInterface)
+func (ch *Channel) GetDASHManifest() ([]byte, error) {
+	// Whole-segment AES-128 has no DASH equivalent, so don't leak clear segments
+	if ch.keys != nil {
+		return nil, fmt.Errorf("dash manifest not available with hls encryption")
+	}
+
+	segments := ch.manifestSegments()
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments available")
+	}
+	if window := ch.playlistWindow(); window > 0 && len(segments) > window {
+		segments = segments[len(segments)-window:]
+	}
+	// The period has a single init segment, so footage from before an
+	// encode change is left out
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i].MapURI != "" {
+			segments = segments[i+1:]
+			break
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments available")
+	}
+
+	mpd := manifest.MPD{
+		AvailabilityStart: ch.buffer.StartTime(),
+		BufferDepth:       time.Duration(ch.playlistWindow()) * ch.cfg.Buffer.SegmentSize,
+		Segments:          segments,
+		InitURI:           "init.mp4",
+		MediaTemplate:     "segment_$Number%05d$.m4s",
+		Bandwidth:         ch.bandwidth(),
+	}
+	if mpd.BufferDepth == 0 {
+		mpd.BufferDepth = ch.cfg.Buffer.Duration
+	}
+	return mpd.Encode()
+}
+
+// GetThumbnailPlaylist generates an image media playlist for the thumbnail
+// track (implements api.ChannelInterface)
+func (ch *Channel) GetThumbnailPlaylist() ([]byte, error) {
+	if !ch.cfg.HLS.Thumbnails.Enabled {
+		return nil, fmt.Errorf("thumbnails not enabled")
+	}
+	segments := ch.manifestSegments()
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments available")
+	}
+	for i := range segments {
+		segments[i].URI = fmt.Sprintf("thumbs/thumb_%05d.jpg", segments[i].Sequence)
+	}
+
+	playlist := manifest.MediaPlaylist{
+		Segments:       segments,
+		Window:         ch.playlistWindow(),
+		ImagesOnly:     true,
+		TargetDuration: ch.cfg.Buffer.SegmentSize,
+	}
+	return playlist.Encode(), nil
+}
+
+// GetLatestThumbnailPath returns the newest thumbnail for multiview
+// (implements api.ChannelInterface)
+func (ch *Channel) GetLatestThumbnailPath() (string, error) {
+	if !ch.cfg.HLS.Thumbnails.Enabled {
+		return "", fmt.Errorf("thumbnails not enabled")
+	}
 	status := ch.buffer.GetStatus()
 	if status.SegmentCount == 0 {
-		return nil, fmt.Errorf("no segments available")
+		return "", fmt.Errorf("no thumbnails available")
 	}
+	return filepath.Join(ch.thumbnailDir(), fmt.Sprintf("thumb_%05d.jpg", status.LastSeq)), nil
+}
 
-	segmentDuration := ch.cfg.Buffer.SegmentSize.Seconds()
+// PreviewFrame returns the latest NDI preview JPEG and when it arrived
+// (implements api.ChannelInterface)
+func (ch *Channel) PreviewFrame() ([]byte, time.Time, error) {
+	ch.mu.RLock()
+	preview := ch.ndiPreview
+	ch.mu.RUnlock()
+	if preview == nil {
+		return nil, time.Time{}, fmt.Errorf("preview not enabled")
+	}
+	frame, at := preview.Frame()
+	if frame == nil {
+		return nil, time.Time{}, fmt.Errorf("no preview frame yet")
+	}
+	return frame, at, nil
+}
 
-	var playlist string
-	playlist += "#EXTM3U\n"
-	playlist += "#EXT-X-VERSION:7\n"
-	playlist += fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", int(segmentDuration)+1)
-	playlist += fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", status.FirstSeq)
-	playlist += "#EXT-X-MAP:URI=\"init.mp4\"\n"
+// thumbnailDir is where the segment writer puts thumbnails
+func (ch *Channel) thumbnailDir() string {
+	return filepath.Join(ch.basePath, "thumbs")
+}
 
-	for seq := status.FirstSeq; seq <= status.LastSeq; seq++ {
-		seg, ok := ch.buffer.GetSegment(seq)
-		if !ok {
-			continue
+// pruneThumbnails removes thumbnails for segments that left the buffer
+func (ch *Channel) pruneThumbnails() {
+	firstSeq := ch.buffer.GetStatus().FirstSeq
+	files, err := filepath.Glob(filepath.Join(ch.thumbnailDir(), "thumb_*.jpg"))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		var seq int
+		if n, _ := fmt.Sscanf(filepath.Base(f), "thumb_%05d.jpg", &seq); n == 1 && seq < firstSeq {
+			os.Remove(f)
+		}
+	}
+}
+
+// manifestSegments lists buffered segments for manifest generation
+func (ch *Channel) manifestSegments() []manifest.Segment {
+	buffered := ch.buffer.ListSegments()
+	segments := make([]manifest.Segment, 0, len(buffered))
+	for _, seg := range buffered {
+		var mapURI string
+		if seg.InitPath != "" {
+			// Kept from before an encode change, next to the segments
+			mapURI = filepath.Base(seg.InitPath)
+		}
+		segments = append(segments, manifest.Segment{
+			Sequence: seg.Sequence,
+			URI:      fmt.Sprintf("segment_%05d.m4s", seg.Sequence),
+			Start:    seg.StartTime,
+			Duration: seg.Duration,
+			MapURI:   mapURI,
+		})
+	}
+	return segments
+}
+
+// playlistWindow returns the number of segments listed in live manifests
+// (0 = whole buffer)
+func (ch *Channel) playlistWindow() int {
+	if ch.cfg.HLS.Window <= 0 || ch.cfg.Buffer.SegmentSize <= 0 {
+		return 0
+	}
+	return int(ch.cfg.HLS.Window / ch.cfg.Buffer.SegmentSize)
+}
+
+// bandwidth estimates the stream bitrate in bits per second for manifests
+func (ch *Channel) bandwidth() int {
+	enc := ch.encodeConfig()
+	bandwidth := enc.Bitrate
+	if bandwidth == 0 {
+		bandwidth = 5000
+	}
+	audioBitrate := enc.Audio.Bitrate
+	if audioBitrate == 0 {
+		audioBitrate = 128
+	}
+	if enc.Audio.Codec == "none" {
+		audioBitrate = 0
+	}
+	return (bandwidth + audioBitrate) * 1000
+}
+
+// GetAudioPlaylist returns the live playlist for an alternate audio rendition
+// (implements api.ChannelInterface)
+func (ch *Channel) GetAudioPlaylist(name string) ([]byte, error) {
+	found := false
+	tracks := ch.encodeConfig().AudioTracks
+	for _, t := range tracks[min(1, len(tracks)):] {
+		if t.Name == name {
+			found = true
+			break
 		}
-		playlist += fmt.Sprintf("#EXTINF:%.3f,\n", seg.Duration.Seconds())
-		playlist += fmt.Sprintf("segment_%05d.m4s\n", seg.Sequence)
+	}
+	if !found {
+		return nil, fmt.Errorf("audio rendition not found: %s", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(ch.basePath, ffmpeg.AudioRenditionDir(name), "playlist.m3u8"))
+	if err != nil {
+		return nil, fmt.Errorf("read audio playlist: %w", err)
+	}
+	if ch.keys == nil {
+		return data, nil
 	}
 
-	return []byte(playlist), nil
+	// Add EXT-X-KEY tags so rendition segments use the same rotating keys
+	lines := strings.Split(string(data), "\n")
+	out := make([]string, 0, len(lines))
+	keyIndex := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#EXTINF") {
+			for _, next := range lines[i+1:] {
+				var seq int
+				if n, _ := fmt.Sscanf(next, "segment_%05d.m4s", &seq); n == 1 {
+					if idx := ch.keys.KeyIndex(seq); idx != keyIndex {
+						keyIndex = idx
+						out = append(out, fmt.Sprintf("#EXT-X-KEY:METHOD=AES-128,URI=\"../../key_%05d.key\"", idx))
+					}
+					break
+				}
+				if !strings.HasPrefix(next, "#") {
+					break
+				}
+			}
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n")), nil
 }
 
 // GetSegmentPath returns the path where segments are stored
@@ -496,11 +1727,38 @@ func (ch *Channel) GetSegmentPath() string {
 	return ch.basePath
 }
 
+// SegmentData returns a segment held in memory or offloaded to object
+// storage (implements api.ChannelInterface)
+func (ch *Channel) SegmentData(seq int) ([]byte, bool) {
+	return ch.buffer.SegmentData(seq)
+}
+
 // GetInitSegmentPath returns the path to the init segment
 func (ch *Channel) GetInitSegmentPath() string {
 	return ch.buffer.GetInitSegment()
 }
 
+// GetHLSKey returns the content key for a key index (implements api.ChannelInterface)
+func (ch *Channel) GetHLSKey(index int) ([]byte, bool) {
+	if ch.keys == nil || index < 0 {
+		return nil, false
+	}
+	return ch.keys.Key(index), true
+}
+
+// HLSEncrypted reports whether served segments are encrypted (implements api.ChannelInterface)
+func (ch *Channel) HLSEncrypted() bool {
+	return ch.keys != nil
+}
+
+// EncryptHLSSegment encrypts a served segment (implements api.ChannelInterface)
+func (ch *Channel) EncryptHLSSegment(seq int, data []byte) ([]byte, error) {
+	if ch.keys == nil {
+		return nil, fmt.Errorf("hls encryption not enabled")
+	}
+	return ch.keys.Encrypt(seq, data)
+}
+
 // IsRecording returns true if the channel is actively capturing
 func (ch *Channel) IsRecording() bool {
 	ch.mu.RLock()
@@ -525,10 +1783,23 @@ type ChannelStatus struct {
 	ChannelID    string  `json:"channel_id"`
 	IsRunning    bool    `json:"is_running"`
 	IsCapturing  bool    `json:"is_capturing"`
+	OffSchedule  bool    `json:"off_schedule,omitempty"` // Capture stopped outside recording windows
 	SessionID    string  `json:"session_id"`
 	BufferHealth float64 `json:"buffer_health"`
 	OldestTime   int64   `json:"oldest_time"`
 	NewestTime   int64   `json:"newest_time"`
 	SegmentCount int     `json:"segment_count"`
 	InitSegment  string  `json:"init_segment"`
+
+	Gaps    []ringbuffer.Gap     `json:"gaps,omitempty"` // Holes in the buffered footage
+	AVSync  *AVSyncStatus        `json:"av_sync,omitempty"`
+	Encoder *ffmpeg.EncoderStats `json:"encoder,omitempty"` // Live FFmpeg progress
+	Latency *LatencyStatus       `json:"latency,omitempty"`
+	Restart *RestartStatus       `json:"restart,omitempty"`
+	Outputs []OutputStatus       `json:"outputs,omitempty"` // Plugin outputs of the current writer
+
+	NoSignal bool `json:"no_signal,omitempty"` // The input is connected but has no signal, e.g. an empty SDI port
+
+	NVENCGPU    *int `json:"nvenc_gpu,omitempty"`    // GPU of the NVENC session capture holds
+	NVENCQueued bool `json:"nvenc_queued,omitempty"` // Waiting for an NVENC session to start capture
 }
@@ -0,0 +1,30 @@
+package capture
+
+import (
+	"testing"
+
+	"github.com/video-system/go-video-capture/pkg/api"
+)
+
+func TestCheckClipAudio(t *testing.T) {
+	tracks := []AudioTrackConfig{{Name: "commentary"}, {Name: "crowd"}}
+	tests := []struct {
+		name string
+		opts api.ClipOptions
+		ok   bool
+	}{
+		{"no audio options", api.ClipOptions{}, true},
+		{"configured track", api.ClipOptions{AudioTrack: "crowd"}, true},
+		{"unknown track", api.ClipOptions{AudioTrack: "referee"}, false},
+		{"mix with muxed track", api.ClipOptions{AudioMix: []api.ClipAudioMix{{Track: ""}, {Track: "commentary"}}}, true},
+		{"mix with unknown track", api.ClipOptions{AudioMix: []api.ClipAudioMix{{Track: "crowd"}, {Track: "referee"}}}, false},
+	}
+	for _, tt := range tests {
+		if err := checkClipAudio(tt.opts, tracks); (err == nil) != tt.ok {
+			t.Errorf("%s: checkClipAudio() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+	if err := checkClipAudio(api.ClipOptions{AudioTrack: "crowd"}, nil); err == nil {
+		t.Error("expected an error for a track on a channel without renditions")
+	}
+}
@@ -0,0 +1,44 @@
+package capture
+
+import (
+	"fmt"
+)
+
+// ListClips returns the channel's clip library, newest first (implements
+// api.ChannelInterface)
+func (ch *Channel) ListClips() interface{} {
+	return ch.buffer.Clips()
+}
+
+// GetClip returns a clip's metadata (implements api.ChannelInterface)
+func (ch *Channel) GetClip(playID string) (interface{}, bool) {
+	clip, ok := ch.buffer.Clip(playID)
+	if !ok {
+		return nil, false
+	}
+	return clip, true
+}
+
+// ClipFilePath returns the file of a clip, or of one of its aspect-ratio
+// variants (implements api.ChannelInterface)
+func (ch *Channel) ClipFilePath(playID, variant string) (string, error) {
+	clip, ok := ch.buffer.Clip(playID)
+	if !ok {
+		return "", fmt.Errorf("clip not found: %s", playID)
+	}
+	if variant == "" {
+		return clip.FilePath, nil
+	}
+	for _, v := range clip.Variants {
+		if v.Name == variant {
+			return v.FilePath, nil
+		}
+	}
+	return "", fmt.Errorf("clip %s has no %q variant", playID, variant)
+}
+
+// DeleteClip removes a clip and its variants from disk (implements
+// api.ChannelInterface)
+func (ch *Channel) DeleteClip(playID string) error {
+	return ch.buffer.DeleteClip(playID)
+}
@@ -2,27 +2,291 @@ package capture
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/schedule"
+	"github.com/video-system/go-video-capture/pkg/encode"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/output"
+	"github.com/video-system/go-video-capture/pkg/platform"
+	"github.com/video-system/go-video-capture/pkg/screen"
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds all capture configuration
 type Config struct {
 	// Single-channel mode (backwards compatible)
-	Input  InputConfig  `yaml:"input"`
-	Buffer BufferConfig `yaml:"buffer"`
-	Encode EncodeConfig `yaml:"encode"`
+	Input    InputConfig      `yaml:"input"`
+	Buffer   BufferConfig     `yaml:"buffer"`
+	Encode   EncodeConfig     `yaml:"encode"`
+	Restream []RestreamConfig `yaml:"restream"`
 
 	// Multi-channel mode
 	Channels []ChannelConfig `yaml:"channels"`
 
 	// Shared configuration
-	HLS      HLSConfig      `yaml:"hls"`
-	API      APIConfig      `yaml:"api"`
-	Platform PlatformConfig `yaml:"platform"`
-	Session  SessionConfig  `yaml:"session"`
+	HLS          HLSConfig          `yaml:"hls"`
+	CMAF         CMAFConfig         `yaml:"cmaf"`
+	NDI          NDIOutputConfig    `yaml:"ndi_output"`
+	WHEP         WHEPConfig         `yaml:"whep"`
+	Outputs      []OutputConfig     `yaml:"outputs"` // Plugin outputs for every channel
+	NDIDiscovery NDIDiscoveryConfig `yaml:"ndi_discovery"`
+	API          APIConfig          `yaml:"api"`
+	Platform     PlatformConfig     `yaml:"platform"`
+	Session      SessionConfig      `yaml:"session"`
+	Log          LogConfig          `yaml:"log"`
+	Tracing      TracingConfig      `yaml:"tracing"`
+	Events       EventsConfig       `yaml:"events"`
+	Monitor      MonitorConfig      `yaml:"monitor"`
+	Alerts       AlertsConfig       `yaml:"alerts"`
+	Report       ReportConfig       `yaml:"report"`
+	MQTT         MQTTConfig         `yaml:"mqtt"`
+	Schedule     ScheduleConfig     `yaml:"schedule"`
+	NVENC        NVENCConfig        `yaml:"nvenc"`
+
+	// Front-end for a rack of agents
+	Aggregator AggregatorConfig `yaml:"aggregator"`
+
+	// Active/passive pairing with a second agent on the same inputs
+	Redundancy RedundancyConfig `yaml:"redundancy"`
+
+	// File the config was loaded from, re-read by Manager.ReloadConfig
+	path string
+}
+
+// MQTTConfig configures status and event publishing to an MQTT broker
+type MQTTConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Broker         string        `yaml:"broker"`    // tcp://host:1883, ssl://host:8883, ws://host/mqtt
+	ClientID       string        `yaml:"client_id"` // default: capture-{agent}
+	Username       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+	QoS            byte          `yaml:"qos"`             // 0 (default), 1 or 2
+	StatusInterval time.Duration `yaml:"status_interval"` // How often status is published (default: 10s)
+	Events         []string      `yaml:"events"`          // Event types to publish (empty = all)
+	Topics         MQTTTopics    `yaml:"topics"`
+}
+
+// MQTTTopics are topic templates; {agent}, {channel} and {type} are replaced.
+// {agent} is platform.agent_id, or the hostname.
+type MQTTTopics struct {
+	Status        string `yaml:"status"`         // Agent status, retained (default: capture/{agent}/status)
+	ChannelStatus string `yaml:"channel_status"` // Channel status, retained (default: capture/{agent}/channels/{channel}/status)
+	Events        string `yaml:"events"`         // default: capture/{agent}/events/{type}
+}
+
+// ScheduleConfig limits capture to recording windows, so installed agents
+// only buffer during events. Channels no window applies to always capture.
+type ScheduleConfig struct {
+	Timezone string           `yaml:"timezone"` // IANA name, e.g. America/Chicago (default: local time)
+	Windows  []ScheduleWindow `yaml:"windows"`
+}
+
+// ScheduleWindow is a weekly recording window
+type ScheduleWindow struct {
+	Channels []string `yaml:"channels"` // Channels it applies to (empty = all)
+	Days     []string `yaml:"days"`     // mon, tue, ... (empty = every day)
+	Start    string   `yaml:"start"`    // HH:MM
+	End      string   `yaml:"end"`      // HH:MM; before start runs past midnight
+}
+
+// NVENCConfig shares NVIDIA encode sessions between the channels with
+// encode.type nvenc. GeForce drivers cap the sessions a GPU runs at once;
+// a channel starting past the cap waits for one to free up, or fails.
+type NVENCConfig struct {
+	MaxSessions int    `yaml:"max_sessions"` // Per GPU (default: detected from the GPU and driver, negative = unlimited)
+	WhenFull    string `yaml:"when_full"`    // queue (default): start once a session frees; reject: fail to start
+}
+
+// validate applies defaults
+func (n *NVENCConfig) validate() error {
+	switch n.WhenFull {
+	case "":
+		n.WhenFull = "queue"
+	case "queue", "reject":
+	default:
+		return fmt.Errorf("nvenc: when_full must be queue or reject")
+	}
+	return nil
+}
+
+// ReportConfig configures end-of-session reports
+type ReportConfig struct {
+	Path   string `yaml:"path"`   // Directory for JSON and CSV reports (default: <buffer path>/reports)
+	Upload bool   `yaml:"upload"` // Also upload reports to the platform
+}
+
+// MonitorConfig configures capture health monitoring
+type MonitorConfig struct {
+	DriftThreshold time.Duration `yaml:"drift_threshold"` // A/V drift that raises an alert (default: 100ms)
+	DiskInterval   time.Duration `yaml:"disk_interval"`   // Storage benchmark interval (default: 30s, negative disables)
+	DiskHeadroom   float64       `yaml:"disk_headroom"`   // Required ratio of storage throughput to write load (default: 1.5)
+	DeviceInterval time.Duration `yaml:"device_interval"` // Capture device hotplug polling interval (default: 5s, negative disables)
+	Restart        RestartConfig `yaml:"restart"`
+}
+
+// RestartConfig restarts a channel's FFmpeg process when it exits on its
+// own, e.g. after an SRT or RTSP source drops. The wait doubles after each
+// consecutive failure.
+type RestartConfig struct {
+	MaxRetries     int           `yaml:"max_retries"`     // Consecutive failures before giving up (default: 10, negative disables restarts)
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // Wait before the first restart (default: 1s)
+	MaxBackoff     time.Duration `yaml:"max_backoff"`     // Longest wait between restarts (default: 1m)
+	ResetAfter     time.Duration `yaml:"reset_after"`     // Run time after which earlier failures are forgotten (default: 1m)
+	Jitter         float64       `yaml:"jitter"`          // Random spread of each wait, as a fraction (default: 0.2, negative disables)
+	StallTimeout   time.Duration `yaml:"stall_timeout"`   // srt/rtsp/rtmp: restart when segments stop this long after arriving (default: 15s, negative disables)
+}
+
+// validate applies defaults
+func (r *RestartConfig) validate() error {
+	if r.MaxRetries == 0 {
+		r.MaxRetries = 10
+	}
+	if r.InitialBackoff == 0 {
+		r.InitialBackoff = time.Second
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = time.Minute
+	}
+	if r.ResetAfter == 0 {
+		r.ResetAfter = time.Minute
+	}
+	if r.Jitter == 0 {
+		r.Jitter = 0.2
+	}
+	if r.Jitter >= 1 {
+		return fmt.Errorf("monitor.restart: jitter must be below 1")
+	}
+	if r.StallTimeout == 0 {
+		r.StallTimeout = 15 * time.Second
+	}
+	if r.InitialBackoff < 0 || r.MaxBackoff < r.InitialBackoff {
+		return fmt.Errorf("monitor.restart: max_backoff must be at least initial_backoff")
+	}
+	return nil
+}
+
+// AlertsConfig configures threshold alerts. Alerts are published as
+// alert.fired and alert.resolved events, so webhooks receive them.
+type AlertsConfig struct {
+	Interval time.Duration `yaml:"interval"` // How often rules are evaluated (default: 5s)
+	Platform bool          `yaml:"platform"` // Also send alerts to the platform
+	Rules    []AlertRule   `yaml:"rules"`
+}
+
+// AlertRule fires when a metric stays below or above a threshold for a
+// duration
+type AlertRule struct {
+	Name     string        `yaml:"name"`
+	Metric   string        `yaml:"metric"` // buffer_health, segment_age, av_drift_ms, dropped_frames, encoder_speed, disk_free_gb
+	Below    *float64      `yaml:"below"`
+	Above    *float64      `yaml:"above"`
+	For      time.Duration `yaml:"for"`      // Condition must hold this long before firing (default: immediately)
+	Channels []string      `yaml:"channels"` // Channels to check (empty = all; ignored for disk_free_gb)
+}
+
+// alertMetrics are the metrics alert rules can use
+var alertMetrics = map[string]bool{
+	"buffer_health":  true,
+	"segment_age":    true,
+	"av_drift_ms":    true,
+	"dropped_frames": true,
+	"encoder_speed":  true,
+	"disk_free_gb":   true,
+}
+
+// validateAlertRules checks rule names are unique and each rule has a known
+// metric and exactly one threshold
+func validateAlertRules(rules []AlertRule) error {
+	seen := make(map[string]bool)
+	for i, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("alerts.rules[%d]: name is required", i)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate alert rule name %q", r.Name)
+		}
+		if !alertMetrics[r.Metric] {
+			return fmt.Errorf("alert rule %s: unknown metric %q", r.Name, r.Metric)
+		}
+		if (r.Below == nil) == (r.Above == nil) {
+			return fmt.Errorf("alert rule %s: set exactly one of below or above", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return nil
+}
+
+func (cfg *Config) validateSchedule() error {
+	if _, err := time.LoadLocation(cfg.Schedule.Timezone); err != nil {
+		return fmt.Errorf("schedule: timezone: %w", err)
+	}
+	ids := []string{cfg.Session.ChannelID}
+	if len(cfg.Channels) > 0 {
+		ids = ids[:0]
+		for _, ch := range cfg.Channels {
+			ids = append(ids, ch.ID)
+		}
+	}
+	for i, w := range cfg.Schedule.Windows {
+		if _, err := schedule.ParseWindow(w.Days, w.Start, w.End); err != nil {
+			return fmt.Errorf("schedule.windows[%d]: %w", i, err)
+		}
+		for _, id := range w.Channels {
+			if !slices.Contains(ids, id) {
+				return fmt.Errorf("schedule.windows[%d]: unknown channel %q", i, id)
+			}
+		}
+	}
+	return nil
+}
+
+// EventsConfig configures where capture events are sent
+type EventsConfig struct {
+	Log      bool            `yaml:"log"` // Log every event at debug level (module "events")
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+}
+
+// WebhookConfig is an HTTP endpoint that receives events as JSON POSTs
+type WebhookConfig struct {
+	URL     string            `yaml:"url"`
+	Events  []string          `yaml:"events"` // Event types to send, e.g. clip.generated (empty = all)
+	Headers map[string]string `yaml:"headers"`
+	Format  string            `yaml:"format"` // json (default) or slack
+}
+
+// TracingConfig configures OpenTelemetry span export over OTLP/HTTP
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Endpoint    string            `yaml:"endpoint"`     // e.g. http://collector:4318 (default: OTEL_EXPORTER_OTLP_ENDPOINT)
+	Headers     map[string]string `yaml:"headers"`      // Extra export headers, e.g. auth
+	ServiceName string            `yaml:"service_name"` // default: go-video-capture
+	SampleRatio float64           `yaml:"sample_ratio"` // 0-1 (default: 1)
+}
+
+// LogConfig configures logging
+type LogConfig struct {
+	Level   string            `yaml:"level"`   // debug, info (default), warn, error
+	Format  string            `yaml:"format"`  // text (default), json
+	Modules map[string]string `yaml:"modules"` // Per-module levels: api, capture, events, ffmpeg, ndi, ringbuffer, platform
+	File    LogFileConfig     `yaml:"file"`
+}
+
+// LogFileConfig configures log file output with rotation
+type LogFileConfig struct {
+	Path        string        `yaml:"path"`         // Log file (empty = stderr only)
+	MaxSizeMB   int           `yaml:"max_size_mb"`  // Rotate at this size (default: 100)
+	RotateEvery time.Duration `yaml:"rotate_every"` // Also rotate on this interval, e.g. 24h
+	MaxAge      time.Duration `yaml:"max_age"`      // Delete rotated files older than this, e.g. 720h
+	MaxBackups  int           `yaml:"max_backups"`  // Keep at most this many rotated files
+	Compress    bool          `yaml:"compress"`     // Gzip rotated files
 }
 
 // IsMultiChannel returns true if multiple channels are configured
@@ -32,10 +296,181 @@ func (c *Config) IsMultiChannel() bool {
 
 // InputConfig configures the video input source
 type InputConfig struct {
-	Type       string `yaml:"type"`       // srt, rtsp, rtmp, file, decklink, v4l2, avfoundation, dshow, screen
-	Device     string `yaml:"device"`     // Device identifier or URL (e.g., srt://host:port)
+	Type       string `yaml:"type"`       // srt, rtsp, rtmp, file, decklink, v4l2, avfoundation, dshow, screen, testsrc, ndi, or a registered input plugin
+	Device     string `yaml:"device"`     // Device identifier or URL (e.g., srt://host:port); pattern for testsrc
 	Resolution string `yaml:"resolution"` // 1920x1080, 3840x2160
 	Framerate  int    `yaml:"framerate"`  // 30, 60
+
+	// Start capture when the device is plugged back in, after FFmpeg has
+	// given up on it or it was missing at startup (v4l2, avfoundation,
+	// dshow and decklink inputs)
+	AutoStart bool `yaml:"auto_start"`
+
+	RTSP   RTSPConfig     `yaml:"rtsp"`   // Options for rtsp inputs
+	SRT    SRTConfig      `yaml:"srt"`    // Options for srt inputs
+	NDI    NDIInputConfig `yaml:"ndi"`    // Options for ndi inputs
+	Screen ScreenConfig   `yaml:"screen"` // Options for screen inputs
+}
+
+// validateAutoStart checks that auto_start is set on a device input only
+func (in *InputConfig) validateAutoStart() error {
+	if in.AutoStart && !slices.Contains(hotplugTypes, in.Type) {
+		return fmt.Errorf("input.auto_start: %s inputs have no device to plug in (want %s)", in.Type, strings.Join(hotplugTypes, ", "))
+	}
+	return nil
+}
+
+// testSource fills in defaults for a testsrc input: SMPTE HD bars at
+// 1080p30, with a timecode burned in unless the overlay shows a clock
+func (in *InputConfig) testSource(overlay *OverlayConfig) error {
+	if in.Type != "testsrc" {
+		return nil
+	}
+	if in.Device == "" {
+		in.Device = "smptehdbars"
+	}
+	if in.Resolution == "" {
+		in.Resolution = "1920x1080"
+	}
+	if in.Framerate == 0 {
+		in.Framerate = 30
+	}
+	if _, err := ffmpeg.TestSource(in.Device, in.Resolution, in.Framerate); err != nil {
+		return fmt.Errorf("input: %w", err)
+	}
+	if overlay.Clock == "" {
+		overlay.Clock = "timecode"
+	}
+	return nil
+}
+
+// NDIInputConfig tunes NDI inputs
+type NDIInputConfig struct {
+	// JPEG preview from a second receiver on the source's low-bandwidth
+	// stream, served as MJPEG for monitoring many channels at once
+	Preview NDIPreviewConfig `yaml:"preview"`
+}
+
+// NDIPreviewConfig configures the NDI preview
+type NDIPreviewConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	Width     int  `yaml:"width"`     // Scaled width (default: 640)
+	Framerate int  `yaml:"framerate"` // Frames per second (default: 10)
+	Quality   int  `yaml:"quality"`   // JPEG qscale, 2 (best) to 31 (default: 8)
+}
+
+// validate checks NDI options
+func (n *NDIInputConfig) validate() error {
+	p := n.Preview
+	if p.Width < 0 || p.Framerate < 0 || p.Framerate > 60 {
+		return fmt.Errorf("input.ndi.preview: width must not be negative and framerate must be 0-60")
+	}
+	if p.Quality != 0 && (p.Quality < 2 || p.Quality > 31) {
+		return fmt.Errorf("input.ndi.preview: quality must be 2-31")
+	}
+	return nil
+}
+
+// ScreenConfig selects what a screen input captures. Builds with cgo
+// capture natively: ScreenCaptureKit on macOS supports all of it, DXGI
+// desktop duplication on Windows the display, crop and cursor. Built with
+// the pipewire tag, Wayland sessions share a monitor or window picked in
+// the desktop's portal dialog, remembered per display or window setting.
+// Otherwise FFmpeg captures the first display without audio (x11grab on
+// Linux).
+type ScreenConfig struct {
+	Display    string `yaml:"display"`     // Display index or ID (default: main display)
+	Window     string `yaml:"window"`      // Capture only the window with this title, or whose title or app name contains it
+	Crop       string `yaml:"crop"`        // Region of the display or window, WxH+X+Y in points (pixels on Windows and Wayland), e.g. 1280x720+0+0
+	Audio      bool   `yaml:"audio"`       // Capture system audio (macOS 13+)
+	HideCursor bool   `yaml:"hide_cursor"` // Leave the cursor out of the picture
+}
+
+// validate checks screen options
+func (s *ScreenConfig) validate() error {
+	if s.Crop != "" {
+		if _, err := screen.ParseCrop(s.Crop); err != nil {
+			return fmt.Errorf("input.screen: %w", err)
+		}
+	}
+	return nil
+}
+
+// params returns the options as screen input params
+func (s *ScreenConfig) params() map[string]string {
+	return map[string]string{
+		screen.ParamDisplay: s.Display,
+		screen.ParamWindow:  s.Window,
+		screen.ParamCrop:    s.Crop,
+		screen.ParamAudio:   strconv.FormatBool(s.Audio),
+		screen.ParamCursor:  strconv.FormatBool(!s.HideCursor),
+	}
+}
+
+// RTSPConfig tunes RTSP camera inputs
+type RTSPConfig struct {
+	Transport  string        `yaml:"transport"`   // tcp, udp, udp_multicast, http (empty = FFmpeg default, tries udp first)
+	Timeout    time.Duration `yaml:"timeout"`     // Socket I/O timeout before FFmpeg gives up on the camera (0 = none)
+	BufferSize int           `yaml:"buffer_size"` // Socket receive buffer in bytes (0 = OS default)
+	Username   string        `yaml:"username"`    // Credentials added to the URL unless it already has them
+	Password   string        `yaml:"password"`
+
+	// Native receives the camera in-process and remuxes its H.264/H.265 into
+	// segments as is: no FFmpeg process, no re-encode, no audio. Encode
+	// settings don't apply. Only TCP transport is supported.
+	Native bool `yaml:"native"`
+}
+
+// validate checks RTSP options
+func (r *RTSPConfig) validate() error {
+	switch r.Transport {
+	case "", "tcp", "udp", "udp_multicast", "http":
+	default:
+		return fmt.Errorf("input.rtsp: unknown transport %q (want tcp, udp, udp_multicast or http)", r.Transport)
+	}
+	if r.Timeout < 0 || r.BufferSize < 0 {
+		return fmt.Errorf("input.rtsp: timeout and buffer_size must not be negative")
+	}
+	if r.Password != "" && r.Username == "" {
+		return fmt.Errorf("input.rtsp: password requires username")
+	}
+	if r.Native && r.Transport != "" && r.Transport != "tcp" {
+		return fmt.Errorf("input.rtsp: native input only supports tcp transport")
+	}
+	return nil
+}
+
+// SRTConfig tunes SRT inputs and restream destinations. In listener mode the device URL is the local
+// address to bind (srt://:9000?mode=listener) and encoders push to it.
+// Listeners with a stream ID may share a port: callers are routed to the
+// channel whose stream ID they send, and others are rejected.
+type SRTConfig struct {
+	Mode       string        `yaml:"mode"`       // caller, listener, rendezvous (default: caller)
+	Latency    time.Duration `yaml:"latency"`    // Receiver latency buffer (0 = SRT default, 120ms)
+	Passphrase string        `yaml:"passphrase"` // Encryption passphrase, 10-79 characters (empty = unencrypted)
+	StreamID   string        `yaml:"streamid"`   // Stream ID to send (caller) or to route on (listener)
+
+	// Native receives SRT in-process through libsrt (builds with -tags
+	// srt). Video already in the channel's codec is remuxed as is, without
+	// audio, unless burn-in or deinterlacing is set; anything else goes to
+	// FFmpeg to encode as usual.
+	Native bool `yaml:"native"`
+}
+
+// validate checks SRT options
+func (s *SRTConfig) validate() error {
+	switch s.Mode {
+	case "", "caller", "listener", "rendezvous":
+	default:
+		return fmt.Errorf("srt: unknown mode %q (want caller, listener or rendezvous)", s.Mode)
+	}
+	if s.Latency < 0 {
+		return fmt.Errorf("srt: latency must not be negative")
+	}
+	if n := len(s.Passphrase); n > 0 && (n < 10 || n > 79) {
+		return fmt.Errorf("srt: passphrase must be 10-79 characters")
+	}
+	return nil
 }
 
 // BufferConfig configures the ring buffer
@@ -43,17 +478,406 @@ type BufferConfig struct {
 	Duration    time.Duration `yaml:"duration"`     // How long to keep (30m)
 	SegmentSize time.Duration `yaml:"segment_size"` // Segment duration (2s)
 	Path        string        `yaml:"path"`         // Buffer storage path
-	MaxSize     string        `yaml:"max_size"`     // Max storage size (8GB)
+	MaxSize     string        `yaml:"max_size"`     // Quota for segments per channel, e.g. 8GB (empty = none)
+
+	// Where finished segments are kept: "disk" (default) or "memory". In
+	// memory mode each segment is read into RAM once complete and its file
+	// removed; HLS and clips are served from RAM and max_size bounds memory.
+	Storage string `yaml:"storage"`
+	// Directory for FFmpeg's working files (init segment, the segment being
+	// written, audio renditions, thumbnails), e.g. a tmpfs such as /dev/shm
+	// on machines with slow disks. Clips and the index stay under path.
+	TmpfsPath string `yaml:"tmpfs_path"`
+
+	// Move aged segments to S3-compatible object storage
+	Offload OffloadConfig `yaml:"offload"`
+
+	// Continuous recording referenced by EDL/FCPXML/OTIO exports
+	// (default: the channel's live HLS playlist)
+	RecordingURL string `yaml:"recording_url"`
+}
+
+// OffloadConfig moves segments older than After to an S3 or MinIO bucket,
+// keeping long buffers without large local disks. Clips fetch offloaded
+// segments back as needed.
+type OffloadConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	After     time.Duration `yaml:"after"`      // Segment age at which it moves (default: 10m)
+	Endpoint  string        `yaml:"endpoint"`   // e.g. https://s3.us-east-1.amazonaws.com or http://minio:9000
+	Region    string        `yaml:"region"`     // Default: us-east-1
+	Bucket    string        `yaml:"bucket"`
+	Prefix    string        `yaml:"prefix"`     // Key prefix; the channel ID is appended
+	AccessKey string        `yaml:"access_key"`
+	SecretKey string        `yaml:"secret_key"`
+}
+
+// validate sets defaults and checks offloading can work with the buffer
+func (o *OffloadConfig) validate(duration time.Duration) error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.Endpoint == "" || o.Bucket == "" {
+		return fmt.Errorf("buffer.offload: endpoint and bucket are required")
+	}
+	if o.After == 0 {
+		o.After = 10 * time.Minute
+	}
+	if o.After >= duration {
+		return fmt.Errorf("buffer.offload.after (%s) must be shorter than buffer.duration (%s)", o.After, duration)
+	}
+	return nil
+}
+
+// reservedChannelIDs name default-channel paths under /hls/ (alternate
+// audio and thumbnail tiles), so a channel with one of these IDs couldn't
+// be reached there
+var reservedChannelIDs = []string{"audio", "thumbs"}
+
+// validateChannelID rejects channel IDs the HLS routes can't serve
+func validateChannelID(id string) error {
+	if slices.Contains(reservedChannelIDs, id) {
+		return fmt.Errorf("id %q is reserved for HLS paths", id)
+	}
+	return nil
+}
+
+// maxBytes returns MaxSize in bytes, 0 if unset
+func (b BufferConfig) maxBytes() (int64, error) {
+	n, err := parseSize(b.MaxSize)
+	if err != nil {
+		return 0, fmt.Errorf("buffer.max_size: %w", err)
+	}
+	return n, nil
+}
+
+// parseSize parses a size such as "8GB", 0 if empty. Units are binary: "8GB"
+// is 8 GiB. A bare number is bytes.
+func parseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return 0, nil
+	}
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if n, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, mult = strings.TrimSpace(n), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return int64(n * float64(mult)), nil
 }
 
 // EncodeConfig configures the encoder
 type EncodeConfig struct {
-	Type    string `yaml:"type"`    // software, nvenc, qsv, videotoolbox
-	Codec   string `yaml:"codec"`   // h264, hevc
+	Type    string `yaml:"type"`    // software, nvenc, qsv, vaapi, videotoolbox
+	Codec   string `yaml:"codec"`   // h264, hevc, av1
 	Preset  string `yaml:"preset"`  // ultrafast, fast, medium
 	Bitrate int    `yaml:"bitrate"` // Target bitrate in kbps
 	GOP     int    `yaml:"gop"`     // Keyframe interval (frames)
 	BFrames int    `yaml:"bframes"` // Number of B-frames (0 = disabled for cleaner cuts)
+
+	// DRM render node of the GPU that vaapi and qsv encode on, for hosts
+	// with several (default: VAAPI_DEVICE, else /dev/dri/renderD128)
+	Device string `yaml:"device"`
+
+	// Encode the frames of native inputs (DeckLink, screen, NDI) with the
+	// encode plugin registered for the type, instead of FFmpeg's segment
+	// writer. Video only: no audio, deinterlacing, overlay or thumbnails.
+	Plugin bool `yaml:"plugin"`
+
+	// Deinterlacing for interlaced sources such as 1080i SDI or RTSP feeds
+	Deinterlace DeinterlaceConfig `yaml:"deinterlace"`
+
+	// Text, clock and logo burned into the live encode, and so into HLS
+	// and clips, e.g. for review copies or rights marking
+	Overlay OverlayConfig `yaml:"overlay"`
+
+	// Clip export (default: stream copy from the buffer)
+	ClipCodec   string `yaml:"clip_codec"`   // copy, h264, hevc, av1
+	ClipBitrate int    `yaml:"clip_bitrate"` // Clip export bitrate in kbps
+
+	// Where time-range clips are cut: "keyframe" (default) snaps to the
+	// nearest keyframe, up to a GOP off; "frame" re-encodes the partial GOPs
+	// at each end and copies the rest
+	ClipTrim string `yaml:"clip_trim"`
+
+	// Alternate aspect-ratio exports generated alongside each clip
+	ClipVariants []ClipVariantConfig `yaml:"clip_variants"`
+
+	// Text burned into exported clips, e.g. play ID, score and clock
+	ClipOverlay ClipOverlayConfig `yaml:"clip_overlay"`
+
+	Audio AudioConfig `yaml:"audio"`
+
+	// Audio renditions published in the HLS master playlist. The first
+	// track is muxed with video (and used for clips); the rest are
+	// alternates the viewer can switch to.
+	AudioTracks []AudioTrackConfig `yaml:"audio_tracks"`
+}
+
+// AudioTrackConfig describes one input audio track published as an HLS rendition
+type AudioTrackConfig struct {
+	Name     string `yaml:"name"`     // Rendition id, used in URLs (e.g. "program", "natural")
+	Label    string `yaml:"label"`    // Display name (default: name)
+	Language string `yaml:"language"` // BCP 47 language tag (e.g. "en")
+	Track    int    `yaml:"track"`    // Input audio stream index
+	Select   []int  `yaml:"select"`   // Input channels kept from the track, e.g. [2, 3] (default: all)
+}
+
+// validateStorage checks the buffer storage mode
+func validateStorage(mode string) error {
+	switch mode {
+	case "", "disk", "memory":
+		return nil
+	}
+	return fmt.Errorf("buffer.storage must be disk or memory, got %q", mode)
+}
+
+// validateClipTrim checks the clip cut mode
+func validateClipTrim(mode string) error {
+	switch mode {
+	case "", "keyframe", "frame":
+		return nil
+	}
+	return fmt.Errorf("encode.clip_trim must be keyframe or frame, got %q", mode)
+}
+
+// validatePlugin checks that an encode plugin is registered for the type
+// and that nothing needs FFmpeg's filters, which plugins don't run
+func (e EncodeConfig) validatePlugin(channelID string) error {
+	if !e.Plugin {
+		return nil
+	}
+	typ := e.Type
+	if typ == "" {
+		typ = "software"
+	}
+	if _, ok := encode.Registry[typ]; !ok {
+		return fmt.Errorf("encode.plugin: no encoder plugin for type %s", typ)
+	}
+	if e.Deinterlace.Filter != "" || e.Overlay.burnIn(channelID, 0).Enabled() {
+		return fmt.Errorf("encode.plugin cannot be combined with deinterlace or overlay")
+	}
+	return nil
+}
+
+// validateDevice checks a render node is only set for the encoders that
+// use one
+func (e EncodeConfig) validateDevice() error {
+	if e.Device != "" && e.Type != "vaapi" && e.Type != "qsv" {
+		return fmt.Errorf("encode.device applies to type vaapi or qsv")
+	}
+	return nil
+}
+
+// validateClipCodec rejects re-encoding clips on a vaapi or qsv channel:
+// clip exports don't upload frames to a render node
+func (e EncodeConfig) validateClipCodec() error {
+	if e.ClipCodec != "" && e.ClipCodec != "copy" && (e.Type == "vaapi" || e.Type == "qsv") {
+		return fmt.Errorf("encode.clip_codec is not supported with type %s (use copy)", e.Type)
+	}
+	return nil
+}
+
+// validateAudioTracks checks rendition names are unique and URL-safe and
+// that the audio codec can publish them
+func validateAudioTracks(tracks []AudioTrackConfig, audio AudioConfig) error {
+	if audio.Codec == "none" && len(tracks) > 0 {
+		return fmt.Errorf("audio_tracks require audio (codec is none)")
+	}
+	seen := make(map[string]bool)
+	for _, t := range tracks {
+		if t.Name == "" || strings.Trim(t.Name, "abcdefghijklmnopqrstuvwxyz0123456789_-") != "" {
+			return fmt.Errorf("invalid audio track name %q (use a-z, 0-9, _ and -)", t.Name)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate audio track name %q", t.Name)
+		}
+		if t.Track < 0 {
+			return fmt.Errorf("audio track %s: invalid track index %d", t.Name, t.Track)
+		}
+		if err := validateAudioSelect(t.Select); err != nil {
+			return fmt.Errorf("audio track %s: %w", t.Name, err)
+		}
+		if audio.Codec == "copy" && len(t.Select) > 0 {
+			return fmt.Errorf("audio track %s: select needs re-encoding (codec is copy)", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return nil
+}
+
+// ClipVariantConfig configures a cropped aspect-ratio clip export
+type ClipVariantConfig struct {
+	Name   string `yaml:"name"`   // File suffix, e.g. "vertical" -> {play_id}_vertical.mp4
+	Aspect string `yaml:"aspect"` // 9:16, 1:1, 4:5
+	Anchor string `yaml:"anchor"` // center (default), left, right, top, bottom, or 0-1
+}
+
+// ClipOverlayConfig configures text burned into exported clips
+type ClipOverlayConfig struct {
+	// Text with {field} placeholders: {play_id}, {channel}, {session},
+	// {clock}, {date} and {tags.NAME} for mark-out tags. Empty = no overlay.
+	Template string `yaml:"template"`
+	Position string `yaml:"position"`  // top-left, top, top-right, bottom-left (default), bottom, bottom-right
+	FontSize int    `yaml:"font_size"` // Pixels (default: 1/24 of the frame height)
+	FontFile string `yaml:"font_file"` // TrueType font (default: system default)
+	Box      bool   `yaml:"box"`       // Translucent box behind the text
+}
+
+// style returns the FFmpeg overlay style
+func (o ClipOverlayConfig) style() ffmpeg.OverlayStyle {
+	return ffmpeg.OverlayStyle{Position: o.Position, FontSize: o.FontSize, FontFile: o.FontFile, Box: o.Box}
+}
+
+// validate checks the overlay position and font
+func (o ClipOverlayConfig) validate() error {
+	if o.Template == "" {
+		return nil
+	}
+	if _, err := ffmpeg.OverlayFilter(o.style(), "overlay.txt"); err != nil {
+		return fmt.Errorf("clip overlay: %w", err)
+	}
+	if o.FontFile != "" {
+		if _, err := os.Stat(o.FontFile); err != nil {
+			return fmt.Errorf("clip overlay font: %w", err)
+		}
+	}
+	return nil
+}
+
+// OverlayConfig configures burn-in on the live encode
+
+type OverlayConfig struct {
+	Text         string  `yaml:"text"`          // Static label; {channel} is replaced with the channel ID
+	Clock        string  `yaml:"clock"`         // wallclock (HH:MM:SS) or timecode (HH:MM:SS:FF); empty = none
+	Position     string  `yaml:"position"`      // Text position: top-left, top, top-right, bottom-left (default), bottom, bottom-right
+	FontSize     int     `yaml:"font_size"`     // Pixels (default: 1/24 of the frame height)
+	FontFile     string  `yaml:"font_file"`     // TrueType font (default: system default)
+	Box          bool    `yaml:"box"`           // Translucent box behind the text
+	Logo         string  `yaml:"logo"`          // Watermark PNG
+	LogoPosition string  `yaml:"logo_position"` // Same positions as text (default: top-right)
+	LogoOpacity  float64 `yaml:"logo_opacity"`  // 0-1 (default: opaque)
+}
+
+// burnIn returns the FFmpeg burn-in for a channel
+func (o OverlayConfig) burnIn(channelID string, framerate int) ffmpeg.BurnIn {
+	return ffmpeg.BurnIn{
+		Text:        ffmpeg.OverlayText(strings.ReplaceAll(o.Text, "{channel}", channelID)),
+		Clock:       o.Clock,
+		Rate:        framerate,
+		Style:       ffmpeg.OverlayStyle{Position: o.Position, FontSize: o.FontSize, FontFile: o.FontFile, Box: o.Box},
+		Logo:        o.Logo,
+		LogoAt:      o.LogoPosition,
+		LogoOpacity: o.LogoOpacity,
+	}
+}
+
+// validate checks the overlay settings and that the font and logo exist
+func (o OverlayConfig) validate() error {
+	if err := o.burnIn("", 0).Validate(); err != nil {
+		return fmt.Errorf("overlay: %w", err)
+	}
+	for _, path := range []string{o.FontFile, o.Logo} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("overlay: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateClipVariants checks variant names are unique and crops are valid
+func validateClipVariants(variants []ClipVariantConfig) error {
+	seen := make(map[string]bool)
+	for _, v := range variants {
+		if v.Name == "" || strings.Trim(v.Name, "abcdefghijklmnopqrstuvwxyz0123456789_-") != "" {
+			return fmt.Errorf("invalid clip variant name %q (use a-z, 0-9, _ and -)", v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate clip variant name %q", v.Name)
+		}
+		seen[v.Name] = true
+		if _, err := ffmpeg.CropFilter(v.Aspect, v.Anchor); err != nil {
+			return fmt.Errorf("clip variant %s: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// DeinterlaceConfig configures the deinterlace filter applied before encoding
+type DeinterlaceConfig struct {
+	Filter     string `yaml:"filter"`      // yadif, bwdif (empty = off)
+	FieldOrder string `yaml:"field_order"` // auto (default), tff, bff
+	Rate       string `yaml:"rate"`        // frame (default) or field: one frame per field, doubling the frame rate
+}
+
+// validate checks the filter, field order and rate
+func (d DeinterlaceConfig) validate() error {
+	switch d.Filter {
+	case "", "yadif", "bwdif":
+	default:
+		return fmt.Errorf("deinterlace: unknown filter %q (want yadif or bwdif)", d.Filter)
+	}
+	switch d.FieldOrder {
+	case "", "auto", "tff", "bff":
+	default:
+		return fmt.Errorf("deinterlace: unknown field_order %q (want auto, tff or bff)", d.FieldOrder)
+	}
+	switch d.Rate {
+	case "", "frame", "field":
+	default:
+		return fmt.Errorf("deinterlace: unknown rate %q (want frame or field)", d.Rate)
+	}
+	return nil
+}
+
+// AudioConfig configures audio encoding
+type AudioConfig struct {
+	Codec      string `yaml:"codec"`       // aac (default), opus, copy (no re-encode), none (no audio)
+	Bitrate    int    `yaml:"bitrate"`     // kbps (default: 128)
+	Channels   int    `yaml:"channels"`    // Output channel count (0 = source)
+	Layout     string `yaml:"layout"`      // mono, stereo, 5.1, 7.1
+	SampleRate int    `yaml:"sample_rate"` // Hz (0 = source)
+	Select     []int  `yaml:"select"`      // Input channels kept, in order, e.g. [2, 3] for the second SDI pair (default: all)
+}
+
+// isZero reports whether no audio settings are configured
+func (a AudioConfig) isZero() bool {
+	return a.Codec == "" && a.Bitrate == 0 && a.Channels == 0 && a.Layout == "" && a.SampleRate == 0 && len(a.Select) == 0
+}
+
+// validate checks the audio codec is one the segment writer supports
+func (a AudioConfig) validate() error {
+	switch a.Codec {
+	case "", "aac", "opus", "none":
+	case "copy":
+		if a.Channels != 0 || a.Layout != "" || a.SampleRate != 0 || len(a.Select) > 0 {
+			return fmt.Errorf("audio codec copy can't change channels, layout, sample_rate or select")
+		}
+	default:
+		return fmt.Errorf("unsupported audio codec: %s", a.Codec)
+	}
+	return validateAudioSelect(a.Select)
+}
+
+// validateAudioSelect checks input channel indices
+func validateAudioSelect(sel []int) error {
+	for _, c := range sel {
+		if c < 0 || c > 63 {
+			return fmt.Errorf("invalid audio channel %d in select", c)
+		}
+	}
+	return nil
 }
 
 // HLSConfig configures local HLS output
@@ -61,12 +885,269 @@ type HLSConfig struct {
 	Enabled bool   `yaml:"enabled"`
 	Path    string `yaml:"path"` // HLS output path
 	Port    int    `yaml:"port"` // HTTP server port
+
+	// Live playlist window (default: whole buffer)
+	Window time.Duration `yaml:"window"`
+
+	Encryption HLSEncryptionConfig `yaml:"encryption"`
+	Thumbnails ThumbnailConfig     `yaml:"thumbnails"`
+	LowLatency LowLatencyConfig    `yaml:"low_latency"`
+}
+
+// LowLatencyConfig enables Low-Latency HLS on the live playlist: segments
+// are written as CMAF chunks that are listed as partial segments while the
+// segment is still being written, and players block on playlist reloads
+// instead of polling
+type LowLatencyConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PartDuration time.Duration `yaml:"part_duration"` // Partial segment duration (default: 500ms)
+}
+
+// validate applies defaults and checks the part duration against the
+// segment duration
+func (l *LowLatencyConfig) validate(segment time.Duration) error {
+	if !l.Enabled {
+		return nil
+	}
+	if l.PartDuration == 0 {
+		l.PartDuration = 500 * time.Millisecond
+	}
+	if l.PartDuration < 100*time.Millisecond || l.PartDuration >= segment {
+		return fmt.Errorf("hls.low_latency: part_duration must be at least 100ms and shorter than a segment (%s)", segment)
+	}
+	return nil
+}
+
+// ThumbnailConfig configures the rolling JPEG thumbnail track
+type ThumbnailConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Width   int  `yaml:"width"` // Thumbnail width in pixels (default: 320)
+}
+
+// HLSEncryptionConfig configures encryption of served HLS segments
+type HLSEncryptionConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Method         string `yaml:"method"`          // aes-128 (default)
+	RotateSegments int    `yaml:"rotate_segments"` // Segments per key (default: 30, -1 = never rotate)
+}
+
+// validate checks the encryption method and applies defaults
+func (e *HLSEncryptionConfig) validate() error {
+	if !e.Enabled {
+		return nil
+	}
+	switch e.Method {
+	case "", "aes-128":
+		e.Method = "aes-128"
+	case "sample-aes":
+		return fmt.Errorf("hls encryption: sample-aes is not supported for fMP4 segments, use aes-128")
+	default:
+		return fmt.Errorf("hls encryption: unknown method %s", e.Method)
+	}
+	if e.RotateSegments == 0 {
+		e.RotateSegments = 30
+	}
+	return nil
+}
+
+// CMAFConfig configures low-latency CMAF push to an HTTP origin
+type CMAFConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	URL           string        `yaml:"url"`            // Origin base URL; the channel ID is appended
+	ChunkDuration time.Duration `yaml:"chunk_duration"` // CMAF chunk duration (default: 200ms)
+	APIKey        string        `yaml:"api_key"`        // Optional bearer token for the origin
+}
+
+// NDIOutputConfig re-sends each channel's video as an NDI source for
+// monitoring in production tools
+type NDIOutputConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Name       string `yaml:"name"`       // Source name; {channel} is the channel ID (default: "Capture {channel}")
+	Groups     string `yaml:"groups"`     // NDI groups to publish in (empty = default)
+	Resolution string `yaml:"resolution"` // Frame size (default: 1280x720)
+	Framerate  int    `yaml:"framerate"`  // Frame rate (default: 30)
+}
+
+// size parses the resolution
+func (n NDIOutputConfig) size() (width, height int, err error) {
+	if _, err := fmt.Sscanf(n.Resolution, "%dx%d", &width, &height); err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid resolution %q (want WIDTHxHEIGHT)", n.Resolution)
+	}
+	return width, height, nil
+}
+
+// sourceName returns the NDI source name for a channel
+func (n NDIOutputConfig) sourceName(channelID string) string {
+	return strings.ReplaceAll(n.Name, "{channel}", channelID)
+}
+
+// WHEPConfig serves each channel's live stream to browsers over WebRTC with
+// WHEP at /whep/{channel}. Needs H.264 video; keep B-frames disabled (bframes: 0)
+// for smooth playback.
+type WHEPConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	ICEServers  []string `yaml:"ice_servers"`  // STUN/TURN URLs for gathering candidates
+	PublicIPs   []string `yaml:"public_ips"`   // Addresses advertised to players, e.g. behind 1:1 NAT
+	MaxSessions int      `yaml:"max_sessions"` // Players per channel (default: 10)
+}
+
+// OutputConfig sends each channel's segments to an output plugin registered
+// in pkg/output
+type OutputConfig struct {
+	Name     string            `yaml:"name"`      // Identifies the output in logs and status (default: type)
+	Type     string            `yaml:"type"`      // Plugin name: hls (playlist and segments in a directory), cmaf (HTTP push), archive (dated directories of segments), s3 (playlist and segments in a bucket)
+	Path     string            `yaml:"path"`      // Directory or URL; the channel ID is appended
+	ListSize int               `yaml:"list_size"` // hls: segments in the live playlist (default: 10)
+	Headers  map[string]string `yaml:"headers"`   // HTTP outputs: extra request headers
+
+	// archive: kept independently of the ring buffer
+	Retention time.Duration `yaml:"retention"` // Delete files older than this (0 = keep forever)
+	MaxSize   string        `yaml:"max_size"`  // Delete the oldest files above this size, e.g. "500GB"
+
+	// s3: path is endpoint/bucket/prefix, e.g. https://s3.us-east-1.amazonaws.com/live
+	Region    string `yaml:"region"` // Default: us-east-1
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// channelPath returns the output path for a channel
+func (o OutputConfig) channelPath(channelID string) string {
+	return strings.TrimRight(o.Path, "/") + "/" + channelID
+}
+
+// validateOutputs sets output names and checks a list of outputs. shared
+// are the top-level outputs a channel's own outputs are added to.
+func validateOutputs(outputs, shared []OutputConfig) error {
+	names := make(map[string]bool)
+	for _, o := range shared {
+		names[o.Name] = true
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		if _, ok := output.Registry[o.Type]; !ok {
+			return fmt.Errorf("outputs[%d]: unknown type %q (want one of %s)", i, o.Type,
+				strings.Join(slices.Sorted(maps.Keys(output.Registry)), ", "))
+		}
+		if o.Name == "" {
+			o.Name = o.Type
+		}
+		if names[o.Name] {
+			return fmt.Errorf("outputs[%d]: duplicate name %q; name outputs of the same type", i, o.Name)
+		}
+		names[o.Name] = true
+		if o.Path == "" {
+			return fmt.Errorf("outputs[%d]: path is required", i)
+		}
+		if o.ListSize < 0 {
+			return fmt.Errorf("outputs[%d]: list_size must not be negative", i)
+		}
+		if o.Retention < 0 {
+			return fmt.Errorf("outputs[%d]: retention must not be negative", i)
+		}
+		if _, err := parseSize(o.MaxSize); err != nil {
+			return fmt.Errorf("outputs[%d]: max_size: %w", i, err)
+		}
+		if o.Type == "s3" {
+			if u, err := url.Parse(o.Path); err != nil || (u.Scheme != "http" && u.Scheme != "https") || strings.Trim(u.Path, "/") == "" {
+				return fmt.Errorf("outputs[%d]: s3 path must be an http(s) url with a bucket, e.g. https://s3.us-east-1.amazonaws.com/live", i)
+			}
+		}
+	}
+	return nil
+}
+
+// NDIDiscoveryConfig configures how NDI sources are found, for inputs on
+// other subnets than the agent
+type NDIDiscoveryConfig struct {
+	Groups          []string `yaml:"groups"`           // NDI groups to search (default: public)
+	ExtraIPs        []string `yaml:"extra_ips"`        // Hosts to query directly when mDNS does not reach them
+	DiscoveryServer string   `yaml:"discovery_server"` // NDI discovery server, host or host:port
 }
 
 // APIConfig configures the control API
 type APIConfig struct {
 	Port int    `yaml:"port"`
 	Host string `yaml:"host"`
+
+	Auth AuthConfig `yaml:"auth"`
+	MDNS MDNSConfig `yaml:"mdns"`
+	Jobs JobsConfig `yaml:"jobs"`
+	GRPC GRPCConfig `yaml:"grpc"`
+}
+
+// GRPCConfig serves the control API over gRPC as well, on api.host
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"` // Default: 9090
+}
+
+// JobsConfig sizes the queue for clip and reel exports requested with
+// "async": true
+type JobsConfig struct {
+	Workers   int           `yaml:"workers"`   // Exports run at once (default: 2)
+	Retention time.Duration `yaml:"retention"` // How long finished jobs can be polled (default: 1h)
+}
+
+// MDNSConfig advertises the API on the LAN as _gocapture._tcp
+type MDNSConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Instance string `yaml:"instance"` // Name shown in consoles (default: platform agent name or "Capture Agent (host)")
+}
+
+// AggregatorConfig serves one API in front of several agents: their channel
+// lists are merged and channel and HLS requests are proxied to the owner
+type AggregatorConfig struct {
+	Enabled  bool             `yaml:"enabled"`
+	Host     string           `yaml:"host"`
+	Port     int              `yaml:"port"`     // Listener for the merged API (default: 8090)
+	Discover bool             `yaml:"discover"` // Find peers advertising _gocapture._tcp over mDNS
+	Interval time.Duration    `yaml:"interval"` // Peer poll interval (default: 5s)
+	Peers    []AggregatorPeer `yaml:"peers"`
+}
+
+// AggregatorPeer is a statically configured agent
+type AggregatorPeer struct {
+	Name   string `yaml:"name"`    // Prefix for channel IDs that collide across agents
+	URL    string `yaml:"url"`     // Agent API base URL, e.g. http://studio-2:8080
+	APIKey string `yaml:"api_key"` // Sent to the agent for requests authorized by api.auth.keys
+}
+
+// RedundancyConfig pairs two agents capturing the same inputs. Only the
+// active agent uploads clips; the standby takes over when it stops answering.
+type RedundancyConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Role          string        `yaml:"role"`           // primary or standby
+	PeerURL       string        `yaml:"peer_url"`       // Other agent's API, e.g. http://capture-b:8080
+	PeerAPIKey    string        `yaml:"peer_api_key"`   // Bearer token for the peer's API
+	Interval      time.Duration `yaml:"interval"`       // Heartbeat interval (default: 2s)
+	FailoverAfter time.Duration `yaml:"failover_after"` // Take over after this long without a heartbeat (default: 3 intervals)
+}
+
+// AuthConfig configures API authentication. With any key configured,
+// mutating requests need one as a Bearer token.
+type AuthConfig struct {
+	Keys       []string `yaml:"keys"`        // Accepted Bearer tokens
+	TokenFile  string   `yaml:"token_file"`  // More tokens, one per line (# comments)
+	ProtectHLS bool     `yaml:"protect_hls"` // Also require a token for HLS/DASH playback (default: open)
+}
+
+// load adds the tokens in TokenFile to Keys
+func (a *AuthConfig) load() error {
+	if a.TokenFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(a.TokenFile)
+	if err != nil {
+		return fmt.Errorf("api.auth: read token file: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		token := strings.TrimSpace(line)
+		if token == "" || strings.HasPrefix(token, "#") || slices.Contains(a.Keys, token) {
+			continue
+		}
+		a.Keys = append(a.Keys, token)
+	}
+	return nil
 }
 
 // PlatformConfig configures optional platform integration
@@ -75,10 +1156,51 @@ type PlatformConfig struct {
 	URL     string `yaml:"url"`
 	APIKey  string `yaml:"api_key"`
 
+	// Clip upload path: multipart (through the platform API, default) or
+	// presigned (straight to object storage at a URL the platform signs)
+	UploadMode string `yaml:"upload_mode"`
+
 	// Agent registration
 	AgentID        string `yaml:"agent_id"`        // Unique agent identifier
 	AgentName      string `yaml:"agent_name"`      // Human-readable agent name
 	HeartbeatSecs  int    `yaml:"heartbeat_secs"`  // Heartbeat interval (default: 10)
+
+	// Long-poll the platform for commands (mark in/out, clips, session,
+	// restarts), for agents the platform can't reach directly
+	Commands bool `yaml:"commands"`
+
+	// Report not ready (/readyz) while the platform is unreachable
+	Required bool `yaml:"required"`
+
+	TLS PlatformTLSConfig `yaml:"tls"`
+}
+
+// PlatformTLSConfig configures TLS, including client certificates, for
+// platform API requests
+type PlatformTLSConfig struct {
+	CertFile           string `yaml:"cert_file"`            // Client certificate (PEM) for mTLS
+	KeyFile            string `yaml:"key_file"`             // Client key (PEM)
+	CAFile             string `yaml:"ca_file"`              // CA bundle (PEM) instead of the system roots
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // Lab use only
+}
+
+// NewClient creates a platform client from the config
+func (c PlatformConfig) NewClient() (*platform.Client, error) {
+	client, err := platform.New(platform.Config{
+		URL:        c.URL,
+		APIKey:     c.APIKey,
+		UploadMode: c.UploadMode,
+		TLS: platform.TLSConfig{
+			CertFile:           c.TLS.CertFile,
+			KeyFile:            c.TLS.KeyFile,
+			CAFile:             c.TLS.CAFile,
+			InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("platform: %w", err)
+	}
+	return client, nil
 }
 
 // SessionConfig holds runtime session info (set by operator-console)
@@ -102,6 +1224,16 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.path = path
+	return &cfg, nil
+}
+
+// Validate fills in defaults and checks the configuration. LoadConfig calls
+// it; call it directly when building a Config in code.
+func (cfg *Config) Validate() error {
 	// Set defaults for single-channel mode
 	if cfg.Buffer.Duration == 0 {
 		cfg.Buffer.Duration = 30 * time.Minute
@@ -109,6 +1241,15 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.Buffer.SegmentSize == 0 {
 		cfg.Buffer.SegmentSize = 2 * time.Second
 	}
+	if _, err := cfg.Buffer.maxBytes(); err != nil {
+		return err
+	}
+	if err := validateStorage(cfg.Buffer.Storage); err != nil {
+		return err
+	}
+	if err := cfg.Buffer.Offload.validate(cfg.Buffer.Duration); err != nil {
+		return err
+	}
 	if cfg.Encode.Preset == "" {
 		cfg.Encode.Preset = "fast"
 	}
@@ -118,10 +1259,233 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.API.Port == 0 {
 		cfg.API.Port = 8080
 	}
+	if err := validateClipTrim(cfg.Encode.ClipTrim); err != nil {
+		return err
+	}
+	if cfg.API.Jobs.Workers == 0 {
+		cfg.API.Jobs.Workers = 2
+	}
+	if cfg.API.Jobs.Workers < 0 {
+		return fmt.Errorf("api.jobs.workers must be positive")
+	}
+	if cfg.API.Jobs.Retention == 0 {
+		cfg.API.Jobs.Retention = time.Hour
+	}
+	if cfg.API.GRPC.Enabled {
+		if cfg.API.GRPC.Port == 0 {
+			cfg.API.GRPC.Port = 9090
+		}
+		if cfg.API.GRPC.Port == cfg.API.Port {
+			return fmt.Errorf("api.grpc.port must differ from api.port")
+		}
+	}
+	if err := cfg.Encode.Audio.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Encode.Deinterlace.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Encode.Overlay.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Encode.validatePlugin(cfg.Session.ChannelID); err != nil {
+		return err
+	}
+	if err := cfg.Encode.validateDevice(); err != nil {
+		return err
+	}
+	if err := cfg.Encode.validateClipCodec(); err != nil {
+		return err
+	}
+	if err := validateAudioTracks(cfg.Encode.AudioTracks, cfg.Encode.Audio); err != nil {
+		return err
+	}
+	if err := cfg.Encode.ClipOverlay.validate(); err != nil {
+		return err
+	}
+	if err := validateClipVariants(cfg.Encode.ClipVariants); err != nil {
+		return err
+	}
+	if err := cfg.HLS.Encryption.validate(); err != nil {
+		return err
+	}
+	if err := cfg.HLS.LowLatency.validate(cfg.Buffer.SegmentSize); err != nil {
+		return err
+	}
+	if cfg.HLS.LowLatency.Enabled && cfg.HLS.Encryption.Enabled {
+		// Parts can't be decrypted on their own with whole-segment AES-128
+		return fmt.Errorf("hls.low_latency cannot be combined with hls encryption")
+	}
+	if err := cfg.API.Auth.load(); err != nil {
+		return err
+	}
+	if cfg.API.Auth.ProtectHLS && len(cfg.API.Auth.Keys) == 0 {
+		return fmt.Errorf("api.auth: protect_hls requires keys or a token_file")
+	}
+	if cfg.HLS.Encryption.Enabled && len(cfg.API.Auth.Keys) == 0 {
+		return fmt.Errorf("hls encryption requires api.auth.keys to protect key delivery")
+	}
+	if cfg.Monitor.DriftThreshold == 0 {
+		cfg.Monitor.DriftThreshold = 100 * time.Millisecond
+	}
+	if cfg.Monitor.DiskInterval == 0 {
+		cfg.Monitor.DiskInterval = 30 * time.Second
+	}
+	if cfg.Monitor.DiskHeadroom == 0 {
+		cfg.Monitor.DiskHeadroom = 1.5
+	}
+	if cfg.Monitor.DeviceInterval == 0 {
+		cfg.Monitor.DeviceInterval = 5 * time.Second
+	}
+	if err := cfg.Monitor.Restart.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Input.validateAutoStart(); err != nil {
+		return err
+	}
+	if err := cfg.Input.RTSP.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Input.SRT.validate(); err != nil {
+		return fmt.Errorf("input: %w", err)
+	}
+	if err := cfg.Input.NDI.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Input.Screen.validate(); err != nil {
+		return err
+	}
+	if len(cfg.Channels) == 0 {
+		if err := cfg.Input.testSource(&cfg.Encode.Overlay); err != nil {
+			return err
+		}
+		if err := validateRestreams(cfg.Restream); err != nil {
+			return err
+		}
+	}
+	switch cfg.Platform.UploadMode {
+	case "", platform.UploadMultipart, platform.UploadPresigned:
+	default:
+		return fmt.Errorf("platform.upload_mode must be %s or %s", platform.UploadMultipart, platform.UploadPresigned)
+	}
+	for i, hook := range cfg.Events.Webhooks {
+		if hook.URL == "" {
+			return fmt.Errorf("events.webhooks[%d]: url is required", i)
+		}
+		switch hook.Format {
+		case "", events.WebhookJSON, events.WebhookSlack:
+		default:
+			return fmt.Errorf("events.webhooks[%d]: format must be %s or %s", i, events.WebhookJSON, events.WebhookSlack)
+		}
+		for _, t := range hook.Events {
+			if !slices.Contains(events.Types, events.Type(t)) {
+				return fmt.Errorf("events.webhooks[%d]: unknown event %q", i, t)
+			}
+		}
+	}
+	if cfg.Alerts.Interval == 0 {
+		cfg.Alerts.Interval = 5 * time.Second
+	}
+	if err := validateAlertRules(cfg.Alerts.Rules); err != nil {
+		return err
+	}
+	if err := cfg.validateSchedule(); err != nil {
+		return err
+	}
+	if err := cfg.NVENC.validate(); err != nil {
+		return err
+	}
+	if cfg.MQTT.Enabled {
+		if cfg.MQTT.Broker == "" {
+			return fmt.Errorf("mqtt: broker is required")
+		}
+		if cfg.MQTT.QoS > 2 {
+			return fmt.Errorf("mqtt: qos must be 0, 1 or 2")
+		}
+		if cfg.MQTT.StatusInterval == 0 {
+			cfg.MQTT.StatusInterval = 10 * time.Second
+		}
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing: sample_ratio must be between 0 and 1")
+	}
+	if cfg.Aggregator.Enabled {
+		if cfg.Aggregator.Port == 0 {
+			cfg.Aggregator.Port = 8090
+		}
+		if cfg.Aggregator.Port == cfg.API.Port {
+			return fmt.Errorf("aggregator: port must differ from api.port")
+		}
+		if cfg.Aggregator.Interval == 0 {
+			cfg.Aggregator.Interval = 5 * time.Second
+		}
+		if len(cfg.Aggregator.Peers) == 0 && !cfg.Aggregator.Discover {
+			return fmt.Errorf("aggregator: configure peers or enable discover")
+		}
+		for i, p := range cfg.Aggregator.Peers {
+			if p.Name == "" || p.URL == "" {
+				return fmt.Errorf("aggregator.peers[%d]: name and url are required", i)
+			}
+		}
+	}
+	if cfg.Redundancy.Enabled {
+		if cfg.Redundancy.Role != "primary" && cfg.Redundancy.Role != "standby" {
+			return fmt.Errorf("redundancy: role must be primary or standby")
+		}
+		if cfg.Redundancy.PeerURL == "" {
+			return fmt.Errorf("redundancy: peer_url is required")
+		}
+		if cfg.Redundancy.Interval == 0 {
+			cfg.Redundancy.Interval = 2 * time.Second
+		}
+		if cfg.Redundancy.FailoverAfter == 0 {
+			cfg.Redundancy.FailoverAfter = 3 * cfg.Redundancy.Interval
+		}
+	}
+	if cfg.CMAF.Enabled {
+		if cfg.CMAF.URL == "" {
+			return fmt.Errorf("cmaf: url is required")
+		}
+		if cfg.CMAF.ChunkDuration == 0 {
+			cfg.CMAF.ChunkDuration = 200 * time.Millisecond
+		}
+	}
+	if cfg.WHEP.Enabled && cfg.WHEP.MaxSessions == 0 {
+		cfg.WHEP.MaxSessions = 10
+	}
+	if cfg.NDI.Enabled {
+		if cfg.NDI.Name == "" {
+			cfg.NDI.Name = "Capture {channel}"
+		}
+		if cfg.NDI.Resolution == "" {
+			cfg.NDI.Resolution = "1280x720"
+		}
+		if cfg.NDI.Framerate == 0 {
+			cfg.NDI.Framerate = 30
+		}
+		if _, _, err := cfg.NDI.size(); err != nil {
+			return fmt.Errorf("ndi_output: %w", err)
+		}
+		if cfg.NDI.Framerate < 0 {
+			return fmt.Errorf("ndi_output: invalid framerate: %d", cfg.NDI.Framerate)
+		}
+	}
+	if err := validateOutputs(cfg.Outputs, nil); err != nil {
+		return err
+	}
+
+	if len(cfg.Channels) == 0 {
+		if err := validateChannelID(cfg.Session.ChannelID); err != nil {
+			return fmt.Errorf("session.channel_id: %w", err)
+		}
+	}
 
 	// Set defaults for multi-channel mode
 	for i := range cfg.Channels {
 		ch := &cfg.Channels[i]
+		if err := validateChannelID(ch.ID); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
 		if ch.Buffer.Duration == 0 {
 			ch.Buffer.Duration = cfg.Buffer.Duration
 			if ch.Buffer.Duration == 0 {
@@ -134,6 +1498,42 @@ func LoadConfig(path string) (*Config, error) {
 				ch.Buffer.SegmentSize = 2 * time.Second
 			}
 		}
+		if ch.Buffer.MaxSize == "" {
+			ch.Buffer.MaxSize = cfg.Buffer.MaxSize
+		}
+		if _, err := ch.Buffer.maxBytes(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Input.validateAutoStart(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Input.RTSP.validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Input.SRT.validate(); err != nil {
+			return fmt.Errorf("channel %s: input: %w", ch.ID, err)
+		}
+		if err := ch.Input.NDI.validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Input.Screen.validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if ch.Buffer.Storage == "" {
+			ch.Buffer.Storage = cfg.Buffer.Storage
+		}
+		if err := validateStorage(ch.Buffer.Storage); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if ch.Buffer.TmpfsPath == "" {
+			ch.Buffer.TmpfsPath = cfg.Buffer.TmpfsPath
+		}
+		if ch.Buffer.Offload == (OffloadConfig{}) {
+			ch.Buffer.Offload = cfg.Buffer.Offload
+		}
+		if err := ch.Buffer.Offload.validate(ch.Buffer.Duration); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
 		if ch.Encode.Preset == "" {
 			ch.Encode.Preset = cfg.Encode.Preset
 			if ch.Encode.Preset == "" {
@@ -146,7 +1546,81 @@ func LoadConfig(path string) (*Config, error) {
 				ch.Encode.GOP = 60
 			}
 		}
+		if ch.Encode.Deinterlace == (DeinterlaceConfig{}) {
+			ch.Encode.Deinterlace = cfg.Encode.Deinterlace
+		}
+		if err := ch.Encode.Deinterlace.validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if ch.Encode.Overlay == (OverlayConfig{}) {
+			ch.Encode.Overlay = cfg.Encode.Overlay
+		}
+		if err := ch.Input.testSource(&ch.Encode.Overlay); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Encode.Overlay.validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Encode.validatePlugin(ch.ID); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if ch.Encode.Device == "" && ch.Encode.Type == cfg.Encode.Type {
+			ch.Encode.Device = cfg.Encode.Device
+		}
+		if err := ch.Encode.validateDevice(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Encode.validateClipCodec(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if ch.Encode.Audio.isZero() {
+			ch.Encode.Audio = cfg.Encode.Audio
+		}
+		if err := ch.Encode.Audio.validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if len(ch.Encode.AudioTracks) == 0 {
+			ch.Encode.AudioTracks = cfg.Encode.AudioTracks
+		}
+		if err := validateAudioTracks(ch.Encode.AudioTracks, ch.Encode.Audio); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if len(ch.Encode.ClipVariants) == 0 {
+			ch.Encode.ClipVariants = cfg.Encode.ClipVariants
+		}
+		if err := validateClipVariants(ch.Encode.ClipVariants); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if ch.Encode.ClipOverlay.Template == "" {
+			ch.Encode.ClipOverlay = cfg.Encode.ClipOverlay
+		}
+		if ch.Encode.ClipTrim == "" {
+			ch.Encode.ClipTrim = cfg.Encode.ClipTrim
+		}
+		if err := validateClipTrim(ch.Encode.ClipTrim); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := ch.Encode.ClipOverlay.validate(); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := validateRestreams(ch.Restream); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
+		if err := validateOutputs(ch.Outputs, cfg.Outputs); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.ID, err)
+		}
 	}
 
-	return &cfg, nil
+	inputs := map[string]InputConfig{cfg.Session.ChannelID: cfg.Input}
+	if len(cfg.Channels) > 0 {
+		clear(inputs)
+		for _, ch := range cfg.Channels {
+			inputs[ch.ID] = ch.Input
+		}
+	}
+	if err := validateSRTListeners(inputs); err != nil {
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,207 @@
+package capture
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthConfigLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	data := "# Operators\ntoken-a\n\n  token-b  \n# token-c\ntoken-a\ntoken-d\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := AuthConfig{Keys: []string{"token-b", "yaml"}, TokenFile: path}
+	if err := a.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if want := []string{"token-b", "yaml", "token-a", "token-d"}; !slices.Equal(a.Keys, want) {
+		t.Errorf("keys = %q, want %q", a.Keys, want)
+	}
+
+	a = AuthConfig{Keys: []string{"yaml"}}
+	if err := a.load(); err != nil || !slices.Equal(a.Keys, []string{"yaml"}) {
+		t.Errorf("without a token file: keys %q, error %v", a.Keys, err)
+	}
+
+	a = AuthConfig{TokenFile: filepath.Join(t.TempDir(), "missing")}
+	if err := a.load(); err == nil {
+		t.Error("expected an error for a missing token file")
+	}
+}
+
+func TestBufferMaxBytes(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"", 0},
+		{"1024", 1024},
+		{"100B", 100},
+		{"8GB", 8 << 30},
+		{"8g", 8 << 30},
+		{" 512 MB ", 512 << 20},
+		{"1.5K", 1536},
+		{"2TB", 2 << 40},
+	}
+	for _, tt := range tests {
+		got, err := BufferConfig{MaxSize: tt.size}.maxBytes()
+		if err != nil || got != tt.want {
+			t.Errorf("maxBytes(%q) = %d, %v; want %d", tt.size, got, err, tt.want)
+		}
+	}
+	for _, size := range []string{"lots", "GB", "-1GB", "0", "8PB"} {
+		if _, err := (BufferConfig{MaxSize: size}).maxBytes(); err == nil {
+			t.Errorf("maxBytes(%q): expected an error", size)
+		}
+	}
+}
+
+func TestValidateReservedChannelIDs(t *testing.T) {
+	cfg := &Config{Channels: []ChannelConfig{{ID: "cam1", Input: InputConfig{Type: "test"}}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("channel cam1: %v", err)
+	}
+	for _, id := range reservedChannelIDs {
+		cfg := &Config{Channels: []ChannelConfig{{ID: id, Input: InputConfig{Type: "test"}}}}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "reserved") {
+			t.Errorf("channel %s: Validate = %v, want reserved", id, err)
+		}
+	}
+	cfg = &Config{Session: SessionConfig{ChannelID: "audio"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("single-channel ID audio: Validate = %v, want reserved", err)
+	}
+}
+
+func TestValidateClipCodec(t *testing.T) {
+	tests := []struct {
+		typ, clipCodec string
+		ok             bool
+	}{
+		{"software", "av1", true},
+		{"nvenc", "hevc", true},
+		{"vaapi", "", true},
+		{"qsv", "copy", true},
+		{"vaapi", "h264", false},
+		{"qsv", "av1", false},
+	}
+	for _, tt := range tests {
+		enc := EncodeConfig{Type: tt.typ, ClipCodec: tt.clipCodec}
+		if err := enc.validateClipCodec(); (err == nil) != tt.ok {
+			t.Errorf("type %s, clip_codec %q: validateClipCodec = %v, want ok %v", tt.typ, tt.clipCodec, err, tt.ok)
+		}
+	}
+
+	cfg := &Config{Channels: []ChannelConfig{{ID: "cam1", Input: InputConfig{Type: "test"}, Encode: EncodeConfig{Type: "qsv", ClipCodec: "h264"}}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "clip_codec") {
+		t.Errorf("qsv channel with clip_codec: Validate = %v, want clip_codec error", err)
+	}
+}
+
+func TestBufferStorage(t *testing.T) {
+	for _, mode := range []string{"", "disk", "memory"} {
+		if err := validateStorage(mode); err != nil {
+			t.Errorf("storage %q: %v", mode, err)
+		}
+	}
+	if err := validateStorage("ram"); err == nil {
+		t.Error("expected an error for storage ram")
+	}
+
+	// Channels inherit the global storage mode and tmpfs path
+	cfg := &Config{
+		Buffer:   BufferConfig{Storage: "memory", TmpfsPath: "/dev/shm/capture"},
+		Channels: []ChannelConfig{{ID: "cam1", Input: InputConfig{Type: "test"}}, {ID: "cam2", Input: InputConfig{Type: "test"}, Buffer: BufferConfig{Storage: "disk"}}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if b := cfg.Channels[0].Buffer; b.Storage != "memory" || b.TmpfsPath != "/dev/shm/capture" {
+		t.Errorf("cam1 buffer = %q in %q, want the global memory storage in /dev/shm/capture", b.Storage, b.TmpfsPath)
+	}
+	if got := cfg.Channels[1].Buffer.Storage; got != "disk" {
+		t.Errorf("cam2 storage = %q, want its own disk", got)
+	}
+}
+
+func TestValidatePlugin(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  EncodeConfig
+		ok   bool
+	}{
+		{"off", EncodeConfig{Type: "unknown"}, true},
+		{"software by default", EncodeConfig{Plugin: true}, true},
+		{"registered type", EncodeConfig{Plugin: true, Type: "nvenc"}, true},
+		{"unregistered type", EncodeConfig{Plugin: true, Type: "unknown"}, false},
+		{"deinterlace", EncodeConfig{Plugin: true, Deinterlace: DeinterlaceConfig{Filter: "yadif"}}, false},
+		{"burn-in", EncodeConfig{Plugin: true, Overlay: OverlayConfig{Text: "{channel}"}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.enc.validatePlugin("cam1"); (err == nil) != tt.ok {
+			t.Errorf("%s: validatePlugin = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestAudioConfigValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		audio AudioConfig
+		ok    bool
+	}{
+		{"default", AudioConfig{}, true},
+		{"opus", AudioConfig{Codec: "opus", Bitrate: 96, Layout: "stereo", SampleRate: 48000}, true},
+		{"none", AudioConfig{Codec: "none"}, true},
+		{"copy", AudioConfig{Codec: "copy"}, true},
+		{"copy with layout", AudioConfig{Codec: "copy", Layout: "mono"}, false},
+		{"copy with select", AudioConfig{Codec: "copy", Select: []int{0, 1}}, false},
+		{"mp3", AudioConfig{Codec: "mp3"}, false},
+		{"bad select", AudioConfig{Select: []int{64}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.audio.validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: validate = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+
+	// Channels without their own audio settings take the global ones
+	cfg := &Config{
+		Encode:   EncodeConfig{Audio: AudioConfig{Codec: "opus", Bitrate: 96}},
+		Channels: []ChannelConfig{{ID: "cam1", Input: InputConfig{Type: "test"}}, {ID: "cam2", Input: InputConfig{Type: "test"}, Encode: EncodeConfig{Audio: AudioConfig{Codec: "none"}}}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if a := cfg.Channels[0].Encode.Audio; a.Codec != "opus" || a.Bitrate != 96 {
+		t.Errorf("cam1 audio = %+v, want the global opus settings", a)
+	}
+	if a := cfg.Channels[1].Encode.Audio; a.Codec != "none" {
+		t.Errorf("cam2 audio = %+v, want its own", a)
+	}
+}
+
+func TestValidateArchiveOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		out  OutputConfig
+		ok   bool
+	}{
+		{"defaults", OutputConfig{Type: "archive", Path: "/archive"}, true},
+		{"retention and size cap", OutputConfig{Type: "archive", Path: "/archive", Retention: 720 * time.Hour, MaxSize: "500GB"}, true},
+		{"negative retention", OutputConfig{Type: "archive", Path: "/archive", Retention: -time.Hour}, false},
+		{"bad size", OutputConfig{Type: "archive", Path: "/archive", MaxSize: "lots"}, false},
+		{"no path", OutputConfig{Type: "archive"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateOutputs([]OutputConfig{tt.out}, nil); (err == nil) != tt.ok {
+			t.Errorf("%s: validateOutputs = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
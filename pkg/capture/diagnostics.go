@@ -0,0 +1,158 @@
+package capture
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"gopkg.in/yaml.v3"
+)
+
+// secretKeys are config key fragments whose values are redacted
+var secretKeys = []string{"key", "secret", "token", "password", "headers"}
+
+// Redacted returns the config as YAML with credentials masked
+func (c *Config) Redacted() ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	redactNode(&node)
+	return yaml.Marshal(&node)
+}
+
+// redactNode masks the values of secret-looking mapping keys
+func redactNode(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if isSecretKey(key.Value) {
+				maskNode(value)
+				continue
+			}
+			redactNode(value)
+		}
+		return
+	}
+	for _, c := range n.Content {
+		redactNode(c)
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskNode replaces every non-empty scalar under n
+func maskNode(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode {
+		if n.Value != "" && n.Tag != "!!null" {
+			n.Value, n.Tag, n.Style = "REDACTED", "!!str", 0
+		}
+		return
+	}
+	for i, c := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue // keep header names, mask their values
+		}
+		maskNode(c)
+	}
+}
+
+// DiagnosticsBundle writes a zip of recent logs, the redacted config, status
+// snapshots, recovered panics and FFmpeg stderr for support tickets
+// (implements api.ChannelManager)
+func (m *Manager) DiagnosticsBundle(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	add := func(name string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	addJSON := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", name, err)
+		}
+		return add(name, data)
+	}
+
+	hostname, _ := os.Hostname()
+	if err := addJSON("system.json", map[string]interface{}{
+		"time":       time.Now(),
+		"hostname":   hostname,
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"go_version": runtime.Version(),
+		"goroutines": runtime.NumGoroutine(),
+		"cpus":       runtime.NumCPU(),
+	}); err != nil {
+		return err
+	}
+
+	cfg, err := m.cfg.Redacted()
+	if err != nil {
+		return err
+	}
+	if err := add("config.yaml", cfg); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	sessionID := m.sessionID
+	channels := make(map[string]*Channel, len(m.channels))
+	for id, ch := range m.channels {
+		channels[id] = ch
+	}
+	m.mu.RUnlock()
+
+	if err := addJSON("status.json", map[string]interface{}{
+		"session_id": sessionID,
+		"channels":   m.GetAllStatuses(),
+		"storage":    m.StorageStatus(),
+	}); err != nil {
+		return err
+	}
+	if err := addJSON("panics.json", recovery.Panics()); err != nil {
+		return err
+	}
+	if err := add("logs.txt", logging.Recent()); err != nil {
+		return err
+	}
+
+	for id, ch := range channels {
+		if lines := ch.FFmpegLog(); len(lines) > 0 {
+			if err := add("ffmpeg/"+id+".log", []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+				return err
+			}
+		}
+	}
+
+	f, err := zw.Create("goroutines.txt")
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(f, 2); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,350 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/encode"
+	"github.com/video-system/go-video-capture/pkg/input"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// flushTimeout bounds how long a stopping encoder may take to hand over the
+// segments it holds
+const flushTimeout = 5 * time.Second
+
+// framePipe hands a native input's frames to an encode plugin as io.Pipe
+// hands their bytes to FFmpeg: a send waits until the frame is taken, and
+// the reader sees the error the input ended on
+type framePipe struct {
+	cfg input.Config // Size, format and rate the input was opened with
+
+	frames    chan *input.Frame
+	done      chan struct{} // Closed when the input ended; err is set
+	err       error
+	closed    chan struct{} // Closed when the reader is gone
+	closeOnce sync.Once
+}
+
+func newFramePipe(cfg input.Config) *framePipe {
+	return &framePipe{
+		cfg:    cfg,
+		frames: make(chan *input.Frame),
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// send waits for the reader to take frame
+func (p *framePipe) send(frame *input.Frame) error {
+	select {
+	case p.frames <- frame:
+		return nil
+	case <-p.closed:
+		return io.ErrClosedPipe
+	}
+}
+
+// closeWithError ends the frames, with the error reads return
+func (p *framePipe) closeWithError(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+	p.err = err
+	close(p.done)
+}
+
+// next returns the next frame
+func (p *framePipe) next(ctx context.Context) (*input.Frame, error) {
+	select {
+	case frame := <-p.frames:
+		return frame, nil
+	case <-p.done:
+		return nil, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// close ends the pipe from the reader's side, failing sends
+func (p *framePipe) close() {
+	p.closeOnce.Do(func() { close(p.closed) })
+}
+
+// newEncoder returns the encode plugin registered for the channel's
+// encoder type, and its config for frames of the opened input
+func (ch *Channel) newEncoder(cfg ChannelConfig, frames *framePipe) (encode.Encoder, encode.Config, error) {
+	enc := cfg.Encode
+	typ := enc.Type
+	if typ == "" {
+		typ = "software"
+	}
+	e, ok := encode.Get(typ)
+	if !ok {
+		return nil, encode.Config{}, fmt.Errorf("no encoder plugin for type %s", typ)
+	}
+	if err := checkRenderDevice(enc); err != nil {
+		return nil, encode.Config{}, err
+	}
+	return e, encode.Config{
+		Codec:      enc.Codec,
+		Width:      frames.cfg.Width,
+		Height:     frames.cfg.Height,
+		Framerate:  frames.cfg.Framerate,
+		Bitrate:    enc.Bitrate,
+		Preset:     enc.Preset,
+		GOP:        enc.GOP,
+		BFrames:    enc.BFrames,
+		SegmentDur: cfg.Buffer.SegmentSize.Seconds(),
+		Device:     ch.encodeDevice(enc),
+	}, nil
+}
+
+// encodeDevice returns the device the plugin encodes on: the GPU of the
+// NVENC session, or the configured render node
+func (ch *Channel) encodeDevice(enc EncodeConfig) string {
+	if gpu := ch.nvencGPU(); gpu != "" {
+		return gpu
+	}
+	return enc.Device
+}
+
+// encodeWriter encodes the frames of a native input with an encode plugin
+// and lays the segments out as FFmpeg's segment writer does: init.mp4 and
+// segment_NNNNN.m4s in the output directory. Plugins return whole
+// segments, so each is written at once and there are no chunks to stream
+// early; there is no audio.
+type encodeWriter struct {
+	frames     *framePipe
+	enc        encode.Encoder
+	cfg        encode.Config
+	outputPath string
+
+	onSegment      func(ffmpeg.SegmentInfo)
+	onSegmentStart func(seq int, path string)
+	onError        func(error)
+
+	cancel  context.CancelCauseFunc
+	exited  chan struct{}
+	exitErr error
+
+	errMu   sync.RWMutex
+	lastErr error
+
+	statsMu sync.RWMutex
+	stats   ffmpeg.EncoderStats
+
+	// Only the run goroutine touches these
+	seq       int
+	wroteInit bool
+	segFrames int
+	segWall   time.Time
+}
+
+// newEncodeWriter creates a writer encoding frames with enc into outputDir
+func newEncodeWriter(frames *framePipe, enc encode.Encoder, cfg encode.Config, outputDir string) *encodeWriter {
+	return &encodeWriter{
+		frames:     frames,
+		enc:        enc,
+		cfg:        cfg,
+		outputPath: outputDir,
+	}
+}
+
+// OnSegment sets a callback for when segments are complete
+func (w *encodeWriter) OnSegment(fn func(ffmpeg.SegmentInfo)) { w.onSegment = fn }
+
+// OnSegmentStart sets a callback for when a segment file is written
+func (w *encodeWriter) OnSegmentStart(fn func(seq int, path string)) { w.onSegmentStart = fn }
+
+// OnError sets a callback for the error encoding stops on
+func (w *encodeWriter) OnError(fn func(error)) { w.onError = fn }
+
+// Start opens the encoder and starts encoding frames
+func (w *encodeWriter) Start(ctx context.Context) error {
+	if err := os.MkdirAll(w.outputPath, 0755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+	if err := w.enc.Open(w.cfg); err != nil {
+		w.frames.close()
+		return fmt.Errorf("open %s encoder: %w", w.enc.Name(), err)
+	}
+	w.seq = ffmpeg.NextSegment(w.outputPath)
+	w.segWall = time.Now()
+
+	ctx, w.cancel = context.WithCancelCause(ctx)
+	w.exited = make(chan struct{})
+	recovery.Go("encode", func() {
+		defer close(w.exited)
+		w.exitErr = w.run(ctx)
+		if w.exitErr != nil {
+			w.setError(w.exitErr)
+			if w.onError != nil {
+				w.onError(w.exitErr)
+			}
+		}
+	})
+	return nil
+}
+
+// Stop ends encoding, writing the segments the encoder still holds
+func (w *encodeWriter) Stop() error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel(nil)
+	select {
+	case <-w.exited:
+	case <-time.After(flushTimeout + time.Second):
+	}
+	return nil
+}
+
+// Abort stops encoding as failed with err, e.g. when the input stalled
+func (w *encodeWriter) Abort(err error) {
+	if w.cancel != nil {
+		w.cancel(err)
+	}
+}
+
+// Wait waits for encoding to end and returns why it did, nil when stopped
+func (w *encodeWriter) Wait() error {
+	if w.exited == nil {
+		return nil
+	}
+	<-w.exited
+	return w.exitErr
+}
+
+// Exited returns a channel that is closed when encoding has ended
+func (w *encodeWriter) Exited() <-chan struct{} {
+	return w.exited
+}
+
+// Stats returns frame and bitrate counters as of the last segment
+func (w *encodeWriter) Stats() ffmpeg.EncoderStats {
+	w.statsMu.RLock()
+	defer w.statsMu.RUnlock()
+	return w.stats
+}
+
+// StderrTail returns nothing: plugins have no FFmpeg log to show
+func (w *encodeWriter) StderrTail() []string {
+	return nil
+}
+
+// GetError returns the error encoding stopped on
+func (w *encodeWriter) GetError() error {
+	w.errMu.RLock()
+	defer w.errMu.RUnlock()
+	return w.lastErr
+}
+
+func (w *encodeWriter) setError(err error) {
+	w.errMu.Lock()
+	w.lastErr = err
+	w.errMu.Unlock()
+}
+
+// run encodes frames until the input fails or the writer stops. The
+// encoder outlives ctx so that stopping can still flush it.
+func (w *encodeWriter) run(ctx context.Context) error {
+	defer w.enc.Close()
+	defer w.frames.close()
+	encCtx := context.WithoutCancel(ctx)
+	for {
+		frame, err := w.frames.next(ctx)
+		if err != nil {
+			ferr := w.flush(encCtx)
+			if ctx.Err() != nil {
+				if cause := context.Cause(ctx); !errors.Is(cause, context.Canceled) {
+					return cause
+				}
+				return ferr
+			}
+			return err
+		}
+		seg, err := w.enc.Encode(encCtx, frame)
+		if err != nil {
+			return fmt.Errorf("encode: %w", err)
+		}
+		w.segFrames++
+		if seg != nil {
+			if err := w.write(seg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flush writes the segments the encoder holds
+func (w *encodeWriter) flush(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+	segs, err := w.enc.Flush(ctx)
+	for _, seg := range segs {
+		if werr := w.write(seg); werr != nil {
+			return werr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+	return nil
+}
+
+// write writes a segment the encoder returned, after the init segment for
+// the first, and reports it. The sequence follows the output directory's,
+// not the encoder's.
+func (w *encodeWriter) write(seg *output.Segment) error {
+	if !w.wroteInit {
+		init := w.enc.GetInitSegment()
+		if init == nil {
+			return fmt.Errorf("%s encoder has no init segment", w.enc.Name())
+		}
+		if err := writeFileAtomic(filepath.Join(w.outputPath, "init.mp4"), init.Data); err != nil {
+			return fmt.Errorf("write init segment: %w", err)
+		}
+		w.wroteInit = true
+	}
+
+	path := filepath.Join(w.outputPath, fmt.Sprintf("segment_%05d.m4s", w.seq))
+	if err := writeFileAtomic(path, seg.Data); err != nil {
+		return fmt.Errorf("write segment: %w", err)
+	}
+	if w.onSegmentStart != nil {
+		w.onSegmentStart(w.seq, path)
+	}
+	info := ffmpeg.SegmentInfo{
+		Sequence:  w.seq,
+		Path:      path,
+		StartTime: time.UnixMilli(seg.StartTime),
+		Duration:  time.Duration(seg.Duration * float64(time.Second)),
+		Size:      int64(len(seg.Data)),
+	}
+	w.seq++
+
+	now := time.Now()
+	if secs := now.Sub(w.segWall).Seconds(); secs > 0 && seg.Duration > 0 {
+		w.statsMu.Lock()
+		w.stats.Frames += int64(w.segFrames)
+		w.stats.FPS = float64(w.segFrames) / secs
+		w.stats.BitrateKbps = float64(len(seg.Data)) * 8 / seg.Duration / 1000
+		w.stats.Speed = 1
+		w.statsMu.Unlock()
+	}
+	w.segFrames, w.segWall = 0, now
+
+	if w.onSegment != nil {
+		w.onSegment(info)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func TestFramePipe(t *testing.T) {
+	p := newFramePipe(input.Config{Width: 1280, Height: 720})
+	go func() {
+		for seq := int64(1); seq <= 2; seq++ {
+			p.send(&input.Frame{Sequence: seq})
+		}
+		p.closeWithError(nil)
+	}()
+	for seq := int64(1); seq <= 2; seq++ {
+		frame, err := p.next(context.Background())
+		if err != nil || frame.Sequence != seq {
+			t.Fatalf("next = %+v, %v; want frame %d", frame, err, seq)
+		}
+	}
+	if _, err := p.next(context.Background()); err != io.EOF {
+		t.Errorf("next after the input ended = %v, want EOF", err)
+	}
+
+	// The input's error reaches the encoder, and a closed pipe fails sends
+	p = newFramePipe(input.Config{})
+	errInput := errors.New("signal lost")
+	p.closeWithError(errInput)
+	if _, err := p.next(context.Background()); err != errInput {
+		t.Errorf("next = %v, want the input's error", err)
+	}
+	p.close()
+	p.close()
+	if err := p.send(&input.Frame{}); err != io.ErrClosedPipe {
+		t.Errorf("send to a closed pipe = %v, want ErrClosedPipe", err)
+	}
+}
@@ -0,0 +1,107 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+// attachSinks attaches the configured sinks to the manager's event bus
+func (m *Manager) attachSinks() {
+	cfg, bus, platformClient := m.cfg.Events, m.events, m.platform
+
+	if cfg.Log {
+		bus.Attach("log", events.LogSink{Logger: logging.For("events")})
+	}
+	for i, hook := range cfg.Webhooks {
+		var types []events.Type
+		for _, t := range hook.Events {
+			types = append(types, events.Type(t))
+		}
+		sink := events.NewWebhookSink(hook.URL, hook.Headers)
+		sink.Format = hook.Format
+		bus.Attach(fmt.Sprintf("webhook-%d", i), sink, types...)
+	}
+	if m.pairing != nil {
+		bus.Attach("redundancy", m.pairing, events.GhostStarted, events.GhostEnded, events.ClipGenerated, events.ClipUploaded)
+	}
+	if platformClient != nil && platformClient.IsConfigured() {
+		m.spool = newNotifySpool(filepath.Join(m.cfg.Buffer.Path, "segment_notifications.json"), platformClient,
+			func(n platform.SegmentNotification) {
+				if !n.IsFinal {
+					m.segmentNotified(n.ChannelID, n.Sequence, time.Now())
+				}
+			})
+		notifier := platformNotifier{client: platformClient, onNotified: m.segmentNotified, spool: m.spool}
+		if m.pairing != nil {
+			notifier.active = m.pairing.isActive
+		}
+		bus.Attach("platform", notifier, events.GhostSegment, events.GhostEnded)
+		if m.cfg.Alerts.Platform {
+			bus.Attach("platform-alerts", platformAlerter{client: platformClient}, events.AlertFired, events.AlertResolved)
+		}
+	}
+}
+
+// segmentNotified records platform notification latency for a segment
+func (m *Manager) segmentNotified(channelID string, seq int, at time.Time) {
+	m.mu.RLock()
+	ch, ok := m.channels[channelID]
+	m.mu.RUnlock()
+	if ok {
+		ch.latency.notified(seq, at)
+	}
+}
+
+// platformNotifier tells the platform about each segment of a ghost clip as
+// it is recorded, so it can start previewing before the clip is generated
+type platformNotifier struct {
+	client     *platform.Client
+	onNotified func(channelID string, seq int, at time.Time)
+	active     func() bool // Redundant pair: only the active agent notifies (nil = always)
+	spool      *notifySpool
+}
+
+// Send implements events.Sink
+func (p platformNotifier) Send(ctx context.Context, e events.Event) error {
+	if p.active != nil && !p.active() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	playID, _ := e.Data["play_id"].(string)
+	seq, _ := e.Data["seq"].(int)
+	final := e.Type == events.GhostEnded
+	timestamp, _ := e.Data["start_time"].(int64)
+	if final {
+		timestamp, _ = e.Data["end_time"].(int64)
+	}
+
+	n := platform.SegmentNotification{
+		PlayID:     playID,
+		ChannelID:  e.Channel,
+		SegmentURL: fmt.Sprintf("/hls/%s/segment_%05d.m4s", e.Channel, seq),
+		Sequence:   seq,
+		Timestamp:  timestamp,
+		IsFinal:    final,
+	}
+	// Queue behind earlier failures so the platform sees segments in order
+	if !p.spool.empty() {
+		p.spool.add(n)
+		return nil
+	}
+	if err := p.client.NotifySegmentReady(ctx, n); err != nil {
+		p.spool.add(n)
+		return fmt.Errorf("spooled for replay: %w", err)
+	}
+	if !final && p.onNotified != nil {
+		p.onNotified(e.Channel, seq, time.Now())
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ExportRange writes the buffered footage between startMs and endMs (0 = the
+// whole buffer) to one mp4 or mkv file (implements api.ChannelInterface)
+func (ch *Channel) ExportRange(ctx context.Context, startMs, endMs int64, format string) (interface{}, error) {
+	var start, end time.Time
+	if startMs > 0 {
+		start = time.UnixMilli(startMs)
+	}
+	if endMs > 0 {
+		end = time.UnixMilli(endMs)
+	}
+	return ch.buffer.ExportRange(ctx, start, end, format)
+}
+
+// ExportFilePath returns a finished export by file name (implements
+// api.ChannelInterface)
+func (ch *Channel) ExportFilePath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) {
+		return "", fmt.Errorf("invalid export name: %s", name)
+	}
+	path := filepath.Join(ch.buffer.ExportsPath(), name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("export not found: %s", name)
+	}
+	return path, nil
+}
@@ -0,0 +1,132 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"github.com/video-system/go-video-capture/pkg/api"
+)
+
+// GhostBundle is a ghost clip taken on every channel with the same in and
+// out points, one clip per camera angle
+type GhostBundle struct {
+	PlayID    string                         `json:"play_id"`
+	StartTime int64                          `json:"start_time"`
+	EndTime   int64                          `json:"end_time,omitempty"`
+	Channels  []string                       `json:"channels"`
+	Clips     map[string]*ClipResultWithTags `json:"clips,omitempty"`
+	Errors    map[string]string              `json:"errors,omitempty"` // Channels whose clip failed
+}
+
+// StartGhostClips marks in on every channel at the same instant. If any
+// channel fails, the others are cancelled.
+func (m *Manager) StartGhostClips(playID string) (interface{}, error) {
+	if playID == "" {
+		return nil, fmt.Errorf("play_id is required")
+	}
+	start := time.Now()
+	var ids []string
+	var started []*Channel
+	for _, ch := range m.channelList() {
+		if err := ch.startGhostClipAt(playID, start, false); err != nil {
+			for _, s := range started {
+				s.CancelGhostClip(playID)
+			}
+			return nil, fmt.Errorf("channel %s: %w", ch.id, err)
+		}
+		ids = append(ids, ch.id)
+		started = append(started, ch)
+	}
+	logger.Info("Ghost bundle started", "play_id", playID, "channels", len(ids))
+	return &GhostBundle{PlayID: playID, StartTime: start.UnixMilli(), Channels: ids}, nil
+}
+
+// EndGhostClips marks out every channel with the ghost clip open at the same
+// instant, generates the clips in parallel and uploads them as one bundle
+func (m *Manager) EndGhostClips(ctx context.Context, playID string, tags map[string]interface{}, opts api.ClipOptions) (interface{}, error) {
+	end := time.Now()
+	var ids []string
+	var channels []*Channel
+	for _, ch := range m.channelList() {
+		if slices.Contains(ch.buffer.GetActiveGhostClips(), playID) {
+			ids = append(ids, ch.id)
+			channels = append(channels, ch)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("ghost clip not found: %s", playID)
+	}
+
+	type result struct {
+		clip *ClipResultWithTags
+		err  error
+	}
+	results := make([]result, len(ids))
+	var wg sync.WaitGroup
+	for i, ch := range channels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer recovery.Handle("ghost bundle", nil)
+			ctx, span := ch.ghostSpan(ctx, playID)
+			r := &results[i]
+			r.clip, r.err = ch.generateGhostClip(ctx, playID, end, tags, opts)
+			tracing.End(span, r.err)
+		}()
+	}
+	wg.Wait()
+
+	bundle := &GhostBundle{
+		PlayID:   playID,
+		EndTime:  end.UnixMilli(),
+		Channels: ids,
+		Clips:    make(map[string]*ClipResultWithTags),
+	}
+	var errs []error
+	var angles []string
+	for i, id := range ids {
+		r := results[i]
+		if r.err != nil {
+			logger.Warn("Failed to generate bundle clip", "channel", id, "play_id", playID, "error", r.err)
+			if bundle.Errors == nil {
+				bundle.Errors = make(map[string]string)
+			}
+			bundle.Errors[id] = r.err.Error()
+			errs = append(errs, fmt.Errorf("channel %s: %w", id, r.err))
+			continue
+		}
+		if r.clip == nil {
+			continue // Recovered from a panic
+		}
+		bundle.Clips[id] = r.clip
+		angles = append(angles, id)
+		if bundle.StartTime == 0 || r.clip.StartTime < bundle.StartTime {
+			bundle.StartTime = r.clip.StartTime
+		}
+	}
+	if len(angles) == 0 {
+		return nil, fmt.Errorf("generate ghost bundle: %w", errors.Join(errs...))
+	}
+
+	recovery.Go("bundle upload", func() { m.uploadBundle(bundle, angles) })
+	logger.Info("Ghost bundle ended", "play_id", playID, "clips", len(angles), "failed", len(errs))
+	return bundle, nil
+}
+
+// uploadBundle uploads a bundle's clips once all of them are generated, each
+// tagged with the bundle and its angles so the platform can group them
+func (m *Manager) uploadBundle(bundle *GhostBundle, angles []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute*time.Duration(len(angles)))
+	defer cancel()
+	for _, id := range angles {
+		if ch := m.channel(id); ch != nil && ch.uploadsEnabled() {
+			ch.uploadClip(ctx, bundle.Clips[id], bundle.PlayID, angles)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package capture
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/video-system/go-video-capture/pkg/api"
+)
+
+func TestGhostBundle(t *testing.T) {
+	m, _ := newReloadTestManager(t, reloadConfigYAML(t.TempDir(), "copy"))
+
+	res, err := m.StartGhostClips("p1")
+	if err != nil {
+		t.Fatalf("StartGhostClips: %v", err)
+	}
+	bundle := res.(*GhostBundle)
+	if !slices.Equal(bundle.Channels, []string{"cam1", "cam2"}) || bundle.StartTime == 0 {
+		t.Errorf("bundle = %+v, want both channels marked in", bundle)
+	}
+	for _, ch := range m.channelList() {
+		if !slices.Contains(ch.buffer.GetActiveGhostClips(), "p1") {
+			t.Errorf("channel %s: ghost clip p1 not active", ch.id)
+		}
+	}
+
+	// A channel failing to mark in cancels the ones already marked
+	if err := m.channel("cam2").StartGhostClip("p2"); err != nil {
+		t.Fatalf("StartGhostClip: %v", err)
+	}
+	if _, err := m.StartGhostClips("p2"); err == nil {
+		t.Fatal("expected an error for a play already open on cam2")
+	}
+	if slices.Contains(m.channel("cam1").buffer.GetActiveGhostClips(), "p2") {
+		t.Error("cam1 kept its mark-in after cam2 failed")
+	}
+
+	if _, err := m.StartGhostClips(""); err == nil {
+		t.Error("expected an error without a play_id")
+	}
+	if _, err := m.EndGhostClips(context.Background(), "p9", nil, api.ClipOptions{}); err == nil {
+		t.Error("expected an error ending a play no channel has open")
+	}
+}
@@ -0,0 +1,95 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// platformCheckTimeout bounds the platform reachability check so a slow
+// platform can't stall readiness probes
+const platformCheckTimeout = 3 * time.Second
+
+// DependencyCheck is the result of one readiness check
+type DependencyCheck struct {
+	OK       bool   `json:"ok"`
+	Required bool   `json:"required"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Readiness reports whether the agent can serve capture: FFmpeg is present,
+// the buffer is writable, at least one channel is capturing and, when
+// required, the platform is reachable (implements api.ChannelManager)
+func (m *Manager) Readiness(ctx context.Context) (bool, interface{}) {
+	checks := map[string]DependencyCheck{
+		"ffmpeg":   m.checkFFmpeg(),
+		"buffer":   m.checkBuffer(),
+		"channels": m.checkChannels(),
+	}
+	if m.platform != nil {
+		checks["platform"] = m.checkPlatform(ctx)
+	}
+
+	ready := true
+	for _, c := range checks {
+		if c.Required && !c.OK {
+			ready = false
+		}
+	}
+	return ready, checks
+}
+
+func (m *Manager) checkFFmpeg() DependencyCheck {
+	if err := m.ffmpeg.Check(); err != nil {
+		return DependencyCheck{Required: true, Detail: err.Error()}
+	}
+	return DependencyCheck{OK: true, Required: true}
+}
+
+// checkBuffer creates and removes a file in the buffer directory
+func (m *Manager) checkBuffer() DependencyCheck {
+	dir := m.storage.path
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return DependencyCheck{Required: true, Detail: err.Error()}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return DependencyCheck{OK: true, Required: true, Detail: dir}
+}
+
+func (m *Manager) checkChannels() DependencyCheck {
+	m.mu.RLock()
+	total := len(m.channels)
+	capturing, idle := 0, 0
+	for _, ch := range m.channels {
+		if ch.IsRecording() {
+			capturing++
+		} else if ch.isOffSchedule() {
+			idle++
+		}
+	}
+	m.mu.RUnlock()
+
+	detail := fmt.Sprintf("%d of %d capturing", capturing, total)
+	if idle > 0 {
+		detail += fmt.Sprintf(", %d outside recording windows", idle)
+	}
+	return DependencyCheck{
+		OK:       capturing > 0 || idle == total,
+		Required: true,
+		Detail:   detail,
+	}
+}
+
+func (m *Manager) checkPlatform(ctx context.Context) DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, platformCheckTimeout)
+	defer cancel()
+
+	required := m.cfg.Platform.Required
+	if err := m.platform.CheckHealth(ctx); err != nil {
+		return DependencyCheck{Required: required, Detail: err.Error()}
+	}
+	return DependencyCheck{OK: true, Required: required}
+}
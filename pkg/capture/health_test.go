@@ -0,0 +1,72 @@
+package capture
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckChannels(t *testing.T) {
+	tests := []struct {
+		name       string
+		capturing  [2]bool // cam1, cam2
+		idle       [2]bool
+		wantOK     bool
+		wantDetail string
+	}{
+		{"none capturing", [2]bool{}, [2]bool{}, false, "0 of 2 capturing"},
+		{"one capturing", [2]bool{true, false}, [2]bool{}, true, "1 of 2 capturing"},
+		{"all capturing", [2]bool{true, true}, [2]bool{}, true, "2 of 2 capturing"},
+		{"all outside windows", [2]bool{}, [2]bool{true, true}, true, "0 of 2 capturing, 2 outside recording windows"},
+		{"one outside windows", [2]bool{}, [2]bool{true, false}, false, "0 of 2 capturing, 1 outside recording windows"},
+	}
+	for _, tt := range tests {
+		m, _ := newReloadTestManager(t, reloadConfigYAML(t.TempDir(), "copy"))
+		for i, id := range []string{"cam1", "cam2"} {
+			m.channels[id].isCapturing = tt.capturing[i]
+			m.channels[id].offSchedule = tt.idle[i]
+		}
+		got := m.checkChannels()
+		if got.OK != tt.wantOK {
+			t.Errorf("%s: OK = %v, want %v", tt.name, got.OK, tt.wantOK)
+		}
+		if got.Detail != tt.wantDetail {
+			t.Errorf("%s: Detail = %q, want %q", tt.name, got.Detail, tt.wantDetail)
+		}
+		if !got.Required {
+			t.Errorf("%s: channels check not required", tt.name)
+		}
+	}
+}
+
+func TestCheckBuffer(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		wantOK bool
+	}{
+		{"writable", dir, true},
+		{"missing", filepath.Join(dir, "missing"), false},
+		{"not a directory", file, false},
+	}
+	for _, tt := range tests {
+		m := &Manager{storage: &storageMonitor{path: tt.path}}
+		got := m.checkBuffer()
+		if got.OK != tt.wantOK {
+			t.Errorf("%s: OK = %v, want %v (%s)", tt.name, got.OK, tt.wantOK, got.Detail)
+		}
+		if !got.Required {
+			t.Errorf("%s: buffer check not required", tt.name)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("buffer check left %d entries, want 1", len(entries))
+	}
+}
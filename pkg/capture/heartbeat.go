@@ -0,0 +1,64 @@
+package capture
+
+import (
+	"math"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/diskio"
+	"github.com/video-system/go-video-capture/internal/sysstat"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+// HeartbeatMetrics returns host and channel health for the platform
+// heartbeat. CPU usage covers the time since the previous call. Values
+// that can't be read on this host are left zero.
+func (m *Manager) HeartbeatMetrics() *platform.AgentMetrics {
+	metrics := &platform.AgentMetrics{Channels: make(map[string]platform.ChannelMetrics)}
+	if cpu, err := m.cpu.Usage(); err == nil {
+		metrics.CPUPercent = round(cpu, 1)
+	}
+	if mem, err := sysstat.ReadMemory(); err == nil {
+		metrics.MemoryTotalBytes = mem.Total
+		metrics.MemoryUsedBytes = mem.Total - mem.Available
+	}
+	if free, err := diskio.Free(m.cfg.Buffer.Path); err == nil {
+		metrics.DiskFreeBytes = free
+	}
+
+	m.mu.RLock()
+	channels := make(map[string]*Channel, len(m.channels))
+	for id, ch := range m.channels {
+		channels[id] = ch
+	}
+	m.mu.RUnlock()
+
+	now := time.Now()
+	for id, ch := range channels {
+		metrics.Channels[id] = ch.heartbeatMetrics(now)
+	}
+	return metrics
+}
+
+// heartbeatMetrics returns the channel's capture health
+func (ch *Channel) heartbeatMetrics(now time.Time) platform.ChannelMetrics {
+	ch.mu.RLock()
+	capturing, writer := ch.isCapturing, ch.writer
+	ch.mu.RUnlock()
+
+	cm := platform.ChannelMetrics{Capturing: capturing}
+	if v, ok := ch.alertMetric("buffer_health", now); ok {
+		cm.BufferHealth = round(v, 3)
+	}
+	if v, ok := ch.alertMetric("segment_age", now); ok {
+		cm.LastSegmentAgeSec = round(v, 1)
+	}
+	if writer != nil {
+		cm.CaptureFPS = writer.Stats().FPS
+	}
+	return cm
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
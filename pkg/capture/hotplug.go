@@ -0,0 +1,190 @@
+package capture
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/pkg/decklink"
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+// hotplugTypes are the input types whose devices can be plugged in and out
+var hotplugTypes = []string{"v4l2", "avfoundation", "dshow", "decklink"}
+
+// DeviceStatus is the device list as of the last poll
+type DeviceStatus struct {
+	Devices   []ffmpeg.InputDevice `json:"devices"`
+	UpdatedAt time.Time            `json:"updated_at"` // Last time the list changed
+	LastError string               `json:"last_error,omitempty"`
+}
+
+// deviceWatcher polls the capture devices for arrivals and removals
+type deviceWatcher struct {
+	interval time.Duration
+	status   DeviceStatus
+	polled   bool // The first poll sets the baseline without events
+}
+
+// watchDevices polls the devices until ctx is done
+func (m *Manager) watchDevices(ctx context.Context) {
+	if m.devices.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.devices.interval)
+	defer ticker.Stop()
+
+	for {
+		m.pollDevices(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listDevices lists FFmpeg's capture devices and the DeckLink cards
+func (m *Manager) listDevices(ctx context.Context) ([]ffmpeg.InputDevice, error) {
+	devices, err := m.ffmpeg.InputDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if decklink.IsAvailable() {
+		names, err := decklink.Devices()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			d := ffmpeg.InputDevice{Format: "decklink", Kind: "video", ID: name, Name: name}
+			if !slices.Contains(devices, d) { // Also listed by FFmpeg builds with decklink
+				devices = append(devices, d)
+			}
+		}
+	}
+	return devices, nil
+}
+
+// pollDevices lists the devices once and publishes what changed since the
+// last poll, starting channels whose device came back
+func (m *Manager) pollDevices(ctx context.Context) {
+	w := m.devices
+	devices, err := m.listDevices(ctx)
+
+	m.mu.Lock()
+	if err != nil {
+		w.status.LastError = err.Error()
+		m.mu.Unlock()
+		logger.Warn("Device listing failed", "error", err)
+		return
+	}
+	w.status.LastError = ""
+	added, removed := diffDevices(w.status.Devices, devices)
+	first := !w.polled
+	w.polled = true
+	if first || len(added) > 0 || len(removed) > 0 {
+		w.status.Devices = devices
+		w.status.UpdatedAt = time.Now()
+	}
+	m.mu.Unlock()
+	if first {
+		return
+	}
+
+	for _, d := range removed {
+		logger.Warn("Device removed", "format", d.Format, "device", d.ID, "name", d.Name)
+		m.publishDevice(events.DeviceRemoved, d, devices)
+	}
+	for _, d := range added {
+		logger.Info("Device added", "format", d.Format, "device", d.ID, "name", d.Name)
+		m.publishDevice(events.DeviceAdded, d, devices)
+	}
+	if len(added) == 0 {
+		return
+	}
+	for _, ch := range m.channelList() {
+		in := ch.cfg.Input
+		if in.AutoStart && slices.ContainsFunc(added, func(d ffmpeg.InputDevice) bool { return deviceMatches(in, d) }) {
+			ch.startReconnected()
+		}
+	}
+}
+
+// publishDevice publishes a device arrival or removal with the new list
+func (m *Manager) publishDevice(typ events.Type, d ffmpeg.InputDevice, devices []ffmpeg.InputDevice) {
+	m.events.Publish(events.Event{
+		Type: typ,
+		Data: map[string]interface{}{
+			"format":  d.Format,
+			"kind":    d.Kind,
+			"id":      d.ID,
+			"name":    d.Name,
+			"devices": devices,
+		},
+	})
+}
+
+// diffDevices returns the devices in cur but not prev, and in prev but not cur
+func diffDevices(prev, cur []ffmpeg.InputDevice) (added, removed []ffmpeg.InputDevice) {
+	for _, d := range cur {
+		if !slices.Contains(prev, d) {
+			added = append(added, d)
+		}
+	}
+	for _, d := range prev {
+		if !slices.Contains(cur, d) {
+			removed = append(removed, d)
+		}
+	}
+	return added, removed
+}
+
+// deviceMatches reports whether an input captures from device d, named by
+// its ID or its name. AVFoundation inputs may name a video and an audio
+// device ("0:1"); the video device is matched.
+func deviceMatches(in InputConfig, d ffmpeg.InputDevice) bool {
+	if in.Type != d.Format {
+		return false
+	}
+	device := in.Device
+	if in.Type == "avfoundation" {
+		device, _, _ = strings.Cut(device, ":")
+	}
+	return device != "" && (device == d.ID || device == d.Name)
+}
+
+// startReconnected starts capture again when the channel's device is back,
+// unless capture is running, waiting to restart, stopped by the schedule
+// or the channel itself is stopped
+func (ch *Channel) startReconnected() {
+	ch.startMu.Lock()
+	defer ch.startMu.Unlock()
+	ch.mu.RLock()
+	idle := ch.isRunning && ch.ctx.Err() == nil && ch.writer == nil && ch.ndiCapture == nil && !ch.offSchedule
+	ch.mu.RUnlock()
+	if !idle || ch.restarts.pending() {
+		return
+	}
+
+	ch.logger.Info("Device reconnected, starting capture", "device", ch.cfg.Input.Device)
+	ch.restarts.reset()
+	if err := ch.startCapture(); err != nil {
+		ch.logger.Warn("Failed to start capture", "error", err)
+		ch.publish(events.Error, map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// Devices returns the device list as of the last poll (implements
+// api.ChannelManager)
+func (m *Manager) Devices() interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := m.devices.status
+	status.Devices = slices.Clone(status.Devices)
+	if status.Devices == nil {
+		status.Devices = []ffmpeg.InputDevice{}
+	}
+	return status
+}
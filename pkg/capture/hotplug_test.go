@@ -0,0 +1,44 @@
+package capture
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func TestDiffDevices(t *testing.T) {
+	cam := ffmpeg.InputDevice{Format: "v4l2", Kind: "video", ID: "/dev/video0", Name: "USB Camera"}
+	card := ffmpeg.InputDevice{Format: "decklink", Kind: "video", ID: "DeckLink Mini Recorder", Name: "DeckLink Mini Recorder"}
+	mic := ffmpeg.InputDevice{Format: "alsa", Kind: "audio", ID: "hw:1", Name: "USB Audio"}
+
+	added, removed := diffDevices([]ffmpeg.InputDevice{cam, mic}, []ffmpeg.InputDevice{mic, card})
+	if !slices.Equal(added, []ffmpeg.InputDevice{card}) || !slices.Equal(removed, []ffmpeg.InputDevice{cam}) {
+		t.Errorf("added %v, removed %v; want the card added and the camera removed", added, removed)
+	}
+	if added, removed := diffDevices([]ffmpeg.InputDevice{cam}, []ffmpeg.InputDevice{cam}); added != nil || removed != nil {
+		t.Errorf("unchanged list: added %v, removed %v", added, removed)
+	}
+}
+
+func TestDeviceMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		in   InputConfig
+		d    ffmpeg.InputDevice
+		want bool
+	}{
+		{"by id", InputConfig{Type: "v4l2", Device: "/dev/video0"}, ffmpeg.InputDevice{Format: "v4l2", ID: "/dev/video0"}, true},
+		{"by name", InputConfig{Type: "dshow", Device: "Cam Link 4K"}, ffmpeg.InputDevice{Format: "dshow", ID: "@device_pnp_1", Name: "Cam Link 4K"}, true},
+		{"other format", InputConfig{Type: "dshow", Device: "/dev/video0"}, ffmpeg.InputDevice{Format: "v4l2", ID: "/dev/video0"}, false},
+		{"other device", InputConfig{Type: "v4l2", Device: "/dev/video1"}, ffmpeg.InputDevice{Format: "v4l2", ID: "/dev/video0"}, false},
+		{"avfoundation video of a pair", InputConfig{Type: "avfoundation", Device: "0:1"}, ffmpeg.InputDevice{Format: "avfoundation", ID: "0"}, true},
+		{"avfoundation audio of a pair", InputConfig{Type: "avfoundation", Device: "0:1"}, ffmpeg.InputDevice{Format: "avfoundation", ID: "1"}, false},
+		{"no device", InputConfig{Type: "v4l2"}, ffmpeg.InputDevice{Format: "v4l2", ID: ""}, false},
+	}
+	for _, tt := range tests {
+		if got := deviceMatches(tt.in, tt.d); got != tt.want {
+			t.Errorf("%s: deviceMatches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
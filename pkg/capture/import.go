@@ -0,0 +1,45 @@
+package capture
+
+import (
+	"context"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+// ImportRecording backfills the buffer from an external recording (e.g. a
+// camera's own MP4/MKV) that started at start. It is re-encoded with the
+// channel's encode settings; only parts covering gaps in the buffer are kept.
+func (ch *Channel) ImportRecording(ctx context.Context, path string, start time.Time) (interface{}, error) {
+	ch.mu.RLock()
+	cfg := ch.cfg
+	ch.mu.RUnlock()
+	codec, err := ffmpeg.EncoderName(cfg.Encode.Type, cfg.Encode.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkRenderDevice(cfg.Encode); err != nil {
+		return nil, err
+	}
+	hwArgs, hwUpload := ffmpeg.HWEncodeArgs(cfg.Encode.Type, cfg.Encode.Device)
+
+	return ch.buffer.Import(ctx, path, start, ffmpeg.SegmentConfig{
+		Codec:             codec,
+		Preset:            ffmpeg.EncoderPreset(cfg.Encode.Type, codec, cfg.Encode.Preset),
+		HWArgs:            hwArgs,
+		HWUpload:          hwUpload,
+		Bitrate:           cfg.Encode.Bitrate,
+		GOP:               cfg.Encode.GOP,
+		BFrames:           cfg.Encode.BFrames,
+		Deinterlace:       cfg.Encode.Deinterlace.Filter,
+		FieldOrder:        cfg.Encode.Deinterlace.FieldOrder,
+		DeinterlaceFields: cfg.Encode.Deinterlace.Rate == "field",
+		AudioCodec:        cfg.Encode.Audio.Codec,
+		AudioBitrate:      cfg.Encode.Audio.Bitrate,
+		AudioChannels:     cfg.Encode.Audio.Channels,
+		AudioLayout:       cfg.Encode.Audio.Layout,
+		AudioSampleRate:   cfg.Encode.Audio.SampleRate,
+		SegmentDuration:   cfg.Buffer.SegmentSize.Seconds(),
+	})
+}
@@ -0,0 +1,328 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/input"
+	"github.com/video-system/go-video-capture/pkg/rtsp"
+	"github.com/video-system/go-video-capture/pkg/srt"
+
+	// Native DeckLink input, registered in builds with -tags decklink
+	_ "github.com/video-system/go-video-capture/pkg/decklink"
+)
+
+// modeProbeTimeout bounds how long opening an input that detects its mode
+// waits for a frame with signal before settling for a no-signal frame
+const modeProbeTimeout = 2 * time.Second
+
+// inputSource is how FFmpeg reads an opened input, or the packets of an
+// input that is remuxed without FFmpeg
+type inputSource struct {
+	input.FFmpegInput
+	Stdin      io.Reader          // Raw frames of a native input, fed to pipe:0
+	Audio      input.FFmpegInput  // Raw audio of a native input (URL empty when none)
+	ExtraFiles []*os.File         // Pipes FFmpeg reads Audio from
+	Framerate  int                // Detected by the input (0 = as configured)
+	Packets    input.PacketSource // Compressed video to remux (FFmpeg isn't used)
+	Frames     *framePipe         // Frames for an encode plugin (FFmpeg isn't used)
+}
+
+// closeFiles closes the pipes when FFmpeg or the encoder won't be started
+// to take them
+func (s inputSource) closeFiles() {
+	for _, f := range s.ExtraFiles {
+		f.Close()
+	}
+	if s.Frames != nil {
+		s.Frames.close()
+	}
+}
+
+// inputConfig builds the input plugin config, with the URL and protocol
+// options of network inputs resolved
+func (ch *Channel) inputConfig() (input.Config, error) {
+	in := ch.cfg.Input
+	cfg := input.Config{Device: in.Device, Framerate: in.Framerate}
+	if in.Resolution != "" {
+		if n, err := fmt.Sscanf(in.Resolution, "%dx%d", &cfg.Width, &cfg.Height); err != nil || n != 2 {
+			return cfg, fmt.Errorf("input: invalid resolution %q", in.Resolution)
+		}
+	}
+	switch in.Type {
+	case "srt":
+		opts := in.SRT
+		if ch.srtBackend != "" {
+			// Callers reach us through the shared port's stream ID mux,
+			// which has already matched the stream ID
+			opts.Mode, opts.StreamID = "", ""
+			cfg.Device = "srt://" + ch.srtBackend + "?mode=listener"
+		}
+		cfg.Options = srtOptions(opts)
+		if opts.Native {
+			cfg.Params = srtParams(opts, ch.remuxCodec())
+		}
+	case "rtsp":
+		var err error
+		if cfg.Device, cfg.Options, err = rtspInput(in.Device, in.RTSP); err != nil {
+			return cfg, err
+		}
+		if in.RTSP.Native && in.RTSP.Timeout > 0 {
+			cfg.Params = map[string]string{rtsp.ParamTimeout: in.RTSP.Timeout.String()}
+		}
+	case "screen":
+		cfg.Params = in.Screen.params()
+	case "decklink":
+		cfg.AudioChannels = ch.embeddedChannels()
+		if cfg.AudioChannels > 2 {
+			cfg.Options = []string{"-channels", strconv.Itoa(cfg.AudioChannels)}
+		}
+	}
+	return cfg, nil
+}
+
+// embeddedChannels returns how many SDI embedded channels to capture: 2
+// unless the audio selection reaches past them, then 8 or 16
+func (ch *Channel) embeddedChannels() int {
+	enc := ch.encodeConfig()
+	highest := slices.Max(append([]int{0}, enc.Audio.Select...))
+	for _, t := range enc.AudioTracks {
+		highest = max(highest, slices.Max(append([]int{0}, t.Select...)))
+	}
+	switch {
+	case highest >= 8:
+		return 16
+	case highest >= 2:
+		return 8
+	}
+	return 2
+}
+
+// inputType returns the input plugin to open for the channel's input
+func (ch *Channel) inputType() string {
+	in := ch.cfg.Input
+	switch {
+	case in.Type == "rtsp" && in.RTSP.Native:
+		return rtsp.Type
+	case in.Type == "srt" && in.SRT.Native:
+		return srt.Type
+	}
+	return in.Type
+}
+
+// openInput opens the channel's input plugin from the input registry and
+// returns how FFmpeg reads it. FFmpeg reads most inputs itself; the frames
+// of native plugins are piped to it as raw video through stdin, and their
+// audio, if any, as raw PCM through an extra pipe. Plugins that deliver
+// compressed packets are remuxed without FFmpeg, unless they hand FFmpeg
+// their stream through stdin instead. Opening those may wait on the
+// network, so it ends when the channel stops. With encode.plugin, native
+// frames go to the encode plugin rather than FFmpeg.
+func (ch *Channel) openInput() (input.Input, inputSource, error) {
+	in, ok := input.Get(ch.inputType())
+	if !ok {
+		return nil, inputSource{}, fmt.Errorf("unknown input type: %s", ch.cfg.Input.Type)
+	}
+	cfg, err := ch.inputConfig()
+	if err != nil {
+		return nil, inputSource{}, err
+	}
+	if r, ok := in.(input.FFmpegReader); ok {
+		if ch.encodeConfig().Plugin {
+			return nil, inputSource{}, fmt.Errorf("encode.plugin: %s input has no native frames to encode", in.Type())
+		}
+		if err := in.Open(cfg); err != nil {
+			return nil, inputSource{}, fmt.Errorf("open %s input: %w", in.Type(), err)
+		}
+		return in, inputSource{FFmpegInput: r.FFmpegInput()}, nil
+	}
+	if ps, ok := in.(input.PacketSource); ok {
+		stop := context.AfterFunc(ch.ctx, func() { in.Close() })
+		err := in.Open(cfg)
+		stop()
+		if err != nil {
+			return nil, inputSource{}, fmt.Errorf("open %s input: %w", in.Type(), err)
+		}
+		if cs, ok := in.(input.ContainerSource); ok {
+			if r, format := cs.Container(); r != nil {
+				return in, inputSource{FFmpegInput: input.FFmpegInput{URL: "pipe:0", Format: format}, Stdin: r}, nil
+			}
+		}
+		return in, inputSource{Packets: ps}, nil
+	}
+
+	detector, detects := in.(input.ModeDetector)
+	if !detects && (cfg.Width == 0 || cfg.Framerate == 0) {
+		return nil, inputSource{}, fmt.Errorf("%s input: resolution and framerate are required", in.Type())
+	}
+	cfg.Format = input.FormatYUV420P
+	if formats := in.Capabilities().SupportedFormats; len(formats) > 0 {
+		cfg.Format = formats[0]
+	}
+	if err := in.Open(cfg); err != nil {
+		return nil, inputSource{}, fmt.Errorf("open %s input: %w", in.Type(), err)
+	}
+
+	// Raw video has no header: the mode FFmpeg is told must be the mode of
+	// the first frame
+	rate := strconv.Itoa(cfg.Framerate)
+	var first *input.Frame
+	var mode input.VideoMode
+	if detects {
+		if first, err = probeMode(ch.ctx, in); err != nil {
+			in.Close()
+			return nil, inputSource{}, fmt.Errorf("%s input: %w", in.Type(), err)
+		}
+		mode = detector.DetectedMode()
+		cfg.Width, cfg.Height, cfg.Format = first.Width, first.Height, first.Format
+		cfg.Framerate = int(math.Round(mode.Framerate))
+		rate = frameRate(mode.Framerate)
+	}
+
+	if ch.encodeConfig().Plugin {
+		frames := newFramePipe(cfg)
+		recovery.Go("input frames", func() {
+			frames.closeWithError(ch.pumpFrames(in, cfg, mode, first, frames.send))
+		})
+		return in, inputSource{Frames: frames}, nil
+	}
+
+	r, w := io.Pipe()
+	recovery.Go("input frames", func() {
+		w.CloseWithError(ch.pumpFrames(in, cfg, mode, first, func(frame *input.Frame) error {
+			_, err := w.Write(frame.Data)
+			return err
+		}))
+	})
+	src := inputSource{
+		FFmpegInput: input.FFmpegInput{
+			URL:    "pipe:0",
+			Format: "rawvideo",
+			Options: []string{
+				"-pixel_format", string(cfg.Format),
+				"-video_size", strconv.Itoa(cfg.Width) + "x" + strconv.Itoa(cfg.Height),
+				"-framerate", rate,
+			},
+		},
+		Stdin: r,
+	}
+	if detects {
+		src.Framerate = cfg.Framerate
+	}
+
+	if a, ok := in.(input.AudioSource); ok && a.AudioFormat().Channels > 0 {
+		af := a.AudioFormat()
+		ar, aw, err := os.Pipe()
+		if err != nil {
+			in.Close()
+			r.Close()
+			return nil, inputSource{}, fmt.Errorf("audio pipe: %w", err)
+		}
+		recovery.Go("input audio", func() {
+			defer aw.Close()
+			if err := pumpAudio(ch.ctx, a, aw); err != nil && ch.ctx.Err() == nil {
+				ch.logger.Debug("Input audio ended", "error", err)
+			}
+		})
+		src.Audio = input.FFmpegInput{
+			URL:     "pipe:3",
+			Format:  af.Sample,
+			Options: []string{"-ar", strconv.Itoa(af.SampleRate), "-ac", strconv.Itoa(af.Channels)},
+		}
+		src.ExtraFiles = []*os.File{ar}
+	}
+	return in, src, nil
+}
+
+// probeMode reads frames until one has signal, or modeProbeTimeout passes
+// and the last no-signal frame has to do
+func probeMode(ctx context.Context, in input.Input) (*input.Frame, error) {
+	ctx, cancel := context.WithTimeout(ctx, modeProbeTimeout)
+	defer cancel()
+	var last *input.Frame
+	for {
+		frame, err := in.ReadFrame(ctx)
+		switch {
+		case err == nil && !frame.NoSignal:
+			return frame, nil
+		case err == nil:
+			last = frame
+		case last != nil && errors.Is(err, context.DeadlineExceeded):
+			return last, nil
+		default:
+			return nil, fmt.Errorf("detect mode: %w", err)
+		}
+	}
+}
+
+// frameRate formats a detected frame rate for FFmpeg, exactly for the
+// NTSC rates such as 29.97 (30000/1001)
+func frameRate(fps float64) string {
+	if n := math.Round(fps * 1.001); math.Abs(fps-n/1.001) < 0.001 && math.Abs(fps-n) > 0.001 {
+		return strconv.Itoa(int(n)*1000) + "/1001"
+	}
+	return strconv.FormatFloat(fps, 'f', -1, 64)
+}
+
+// pumpFrames passes a native input's frames to write, starting with first
+// when the mode was probed, until the input fails, closes or the channel
+// stops. Raw video has no header, so every frame must keep the opened size,
+// format and detected mode; a change ends capture to restart in the new
+// mode. Signal loss and return are published as they happen.
+func (ch *Channel) pumpFrames(in input.Input, cfg input.Config, mode input.VideoMode, first *input.Frame, write func(*input.Frame) error) error {
+	detector, _ := in.(input.ModeDetector)
+	defer ch.noSignal.Store(false)
+	frame := first
+	for {
+		if frame == nil {
+			var err error
+			if frame, err = in.ReadFrame(ch.ctx); err != nil {
+				return fmt.Errorf("read frame: %w", err)
+			}
+		}
+		if frame.Width != cfg.Width || frame.Height != cfg.Height || frame.Format != cfg.Format {
+			return fmt.Errorf("frame is %dx%d %s, opened as %dx%d %s",
+				frame.Width, frame.Height, frame.Format, cfg.Width, cfg.Height, cfg.Format)
+		}
+		if detector != nil {
+			if m := detector.DetectedMode(); m.Framerate != mode.Framerate {
+				return fmt.Errorf("input mode changed to %gfps, opened as %gfps", m.Framerate, mode.Framerate)
+			}
+		}
+		if ch.noSignal.Swap(frame.NoSignal) != frame.NoSignal {
+			if frame.NoSignal {
+				ch.logger.Warn("Input signal lost")
+				ch.publish(events.SignalLost, map[string]interface{}{"input": in.Type()})
+			} else {
+				ch.logger.Info("Input signal restored")
+				ch.publish(events.SignalRestored, map[string]interface{}{"input": in.Type()})
+			}
+		}
+		if err := write(frame); err != nil {
+			return err
+		}
+		frame = nil
+	}
+}
+
+// pumpAudio writes a native input's audio to FFmpeg until either fails
+func pumpAudio(ctx context.Context, a input.AudioSource, w io.Writer) error {
+	for {
+		data, err := a.ReadAudio(ctx)
+		if err != nil {
+			return fmt.Errorf("read audio: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+}
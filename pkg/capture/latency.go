@@ -0,0 +1,161 @@
+package capture
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/diskio"
+	"github.com/video-system/go-video-capture/internal/fmp4"
+	"github.com/video-system/go-video-capture/pkg/ringbuffer"
+)
+
+// latencyWindow is how many segments latency figures are summarized over
+const latencyWindow = 30
+
+// LatencyStatus is a channel's glass-to-glass delay: from frame capture to
+// the segment holding it being listed in the HLS playlist
+type LatencyStatus struct {
+	FirstFrameMs    diskio.Summary `json:"first_frame_ms"` // Oldest frame in each segment (what a viewer waits for)
+	LastFrameMs     diskio.Summary `json:"last_frame_ms"`  // Newest frame in each segment (muxing and write delay)
+	NotifyMs        diskio.Summary `json:"notify_ms"`      // Frame capture to platform segment notification
+	SegmentDuration float64        `json:"segment_duration"`
+}
+
+// latencyMonitor estimates when each segment's first frame was captured from
+// the video track's decode time. The media timeline is anchored to the wall
+// clock at the first segment, so the estimate doesn't drift when actual
+// segment durations differ from the configured one.
+type latencyMonitor struct {
+	mu       sync.Mutex
+	initPath string
+	video    fmp4.TrackInfo
+	hasVideo bool
+	anchor   time.Time
+	captured map[int]time.Time // Capture time by sequence, for notification latency
+	first    *diskio.Window
+	last     *diskio.Window
+	notify   *diskio.Window
+	segDur   time.Duration
+}
+
+func newLatencyMonitor() *latencyMonitor {
+	return &latencyMonitor{
+		captured: make(map[int]time.Time),
+		first:    diskio.NewWindow(latencyWindow),
+		last:     diskio.NewWindow(latencyWindow),
+		notify:   diskio.NewWindow(latencyWindow),
+	}
+}
+
+// reset re-anchors the media timeline, e.g. after the encoder restarts
+func (m *latencyMonitor) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initPath = ""
+	m.hasVideo = false
+	m.anchor = time.Time{}
+	clear(m.captured)
+}
+
+// observe records a segment that became available at availableAt and
+// returns the first-frame latency
+func (m *latencyMonitor) observe(initPath string, seg *ringbuffer.Segment, availableAt time.Time) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Fall back to the nominal start time if decode times can't be read
+	start := seg.StartTime
+	if media, err := m.mediaTime(initPath, seg.FilePath); err == nil {
+		if m.anchor.IsZero() {
+			m.anchor = seg.StartTime.Add(-media)
+		}
+		start = m.anchor.Add(media)
+	}
+
+	first := availableAt.Sub(start)
+	m.first.Add(float64(first.Milliseconds()))
+	m.last.Add(float64((first - seg.Duration).Milliseconds()))
+	m.segDur = seg.Duration
+
+	m.captured[seg.Sequence] = start
+	delete(m.captured, seg.Sequence-latencyWindow)
+	return first
+}
+
+// notified records a platform notification for a segment
+func (m *latencyMonitor) notified(seq int, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if start, ok := m.captured[seq]; ok {
+		m.notify.Add(float64(at.Sub(start).Milliseconds()))
+	}
+}
+
+// mediaTime returns the video decode time at the start of a segment.
+// Callers hold mu.
+func (m *latencyMonitor) mediaTime(initPath, segPath string) (time.Duration, error) {
+	if initPath != m.initPath {
+		if err := m.loadTrack(initPath); err != nil {
+			return 0, err
+		}
+	}
+	if !m.hasVideo {
+		return 0, fmt.Errorf("no video track")
+	}
+
+	f, err := os.Open(segPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	times, err := fmp4.ReadDecodeTimes(f)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", segPath, err)
+	}
+	dt, ok := times[m.video.ID]
+	if !ok {
+		return 0, fmt.Errorf("no video fragment in %s", segPath)
+	}
+	return time.Duration(float64(dt) / float64(m.video.Timescale) * float64(time.Second)), nil
+}
+
+// loadTrack finds the video track in the init segment. Callers hold mu.
+func (m *latencyMonitor) loadTrack(initPath string) error {
+	f, err := os.Open(initPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tracks, err := fmp4.ReadTracks(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", initPath, err)
+	}
+
+	m.hasVideo = false
+	for _, t := range tracks {
+		if t.Handler == "vide" && t.Timescale > 0 {
+			m.video, m.hasVideo = t, true
+			break
+		}
+	}
+	m.initPath = initPath
+	return nil
+}
+
+func (m *latencyMonitor) status() *LatencyStatus {
+	first := m.first.Summary()
+	if first.Count == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	segDur := m.segDur
+	m.mu.Unlock()
+	return &LatencyStatus{
+		FirstFrameMs:    first,
+		LastFrameMs:     m.last.Summary(),
+		NotifyMs:        m.notify.Summary(),
+		SegmentDuration: segDur.Seconds(),
+	}
+}
@@ -0,0 +1,85 @@
+package capture
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/fmp4"
+	"github.com/video-system/go-video-capture/pkg/ringbuffer"
+)
+
+func TestLatencyMonitor(t *testing.T) {
+	dir := t.TempDir()
+	initPath := filepath.Join(dir, "init.mp4")
+	init := fmp4.InitSegment(fmp4.VideoTrack{Width: 1280, Height: 720, Timescale: 90000, SPS: []byte{0x67}, PPS: []byte{0x68}})
+	if err := os.WriteFile(initPath, init, 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeSegment := func(seq int, decodeTime uint64) string {
+		path := filepath.Join(dir, fmt.Sprintf("seg%d.m4s", seq))
+		data := fmp4.MediaSegment(uint32(seq), decodeTime, []fmp4.Sample{{Data: []byte{0, 0, 0, 1, 0x65}, Duration: 3000, Keyframe: true}})
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		path        string
+		seq         int
+		start       time.Duration // Nominal segment start
+		available   time.Duration
+		wantLatency time.Duration
+	}{
+		{"first segment anchors", writeSegment(1, 0), 1, 0, 3 * time.Second, 3 * time.Second},
+		// The nominal start drifted half a second; the decode time wins
+		{"decode time over nominal start", writeSegment(2, 180000), 2, 2500 * time.Millisecond, 4500 * time.Millisecond, 2500 * time.Millisecond},
+		{"missing file falls back", filepath.Join(dir, "missing.m4s"), 3, 4 * time.Second, 5 * time.Second, time.Second},
+	}
+
+	m := newLatencyMonitor()
+	for _, tt := range tests {
+		seg := &ringbuffer.Segment{Sequence: tt.seq, FilePath: tt.path, StartTime: t0.Add(tt.start), Duration: 2 * time.Second}
+		if got := m.observe(initPath, seg, t0.Add(tt.available)); got != tt.wantLatency {
+			t.Errorf("%s: latency = %v, want %v", tt.name, got, tt.wantLatency)
+		}
+	}
+
+	// Notifications are measured from the capture time of known segments
+	m.notified(2, t0.Add(5*time.Second))
+	m.notified(99, t0.Add(5*time.Second))
+	status := m.status()
+	if status == nil {
+		t.Fatal("no status after observing segments")
+	}
+	if status.FirstFrameMs.Count != 3 || status.FirstFrameMs.Max != 3000 {
+		t.Errorf("first frame summary = %+v", status.FirstFrameMs)
+	}
+	if status.LastFrameMs.Max != 1000 {
+		t.Errorf("last frame max = %v, want 1000", status.LastFrameMs.Max)
+	}
+	if status.NotifyMs.Count != 1 || status.NotifyMs.Max != 3000 {
+		t.Errorf("notify summary = %+v, want one 3000ms sample", status.NotifyMs)
+	}
+	if status.SegmentDuration != 2 {
+		t.Errorf("segment duration = %v, want 2", status.SegmentDuration)
+	}
+
+	// After a reset the next segment re-anchors the timeline
+	m.reset()
+	seg := &ringbuffer.Segment{Sequence: 1, FilePath: writeSegment(4, 900000), StartTime: t0.Add(time.Minute), Duration: 2 * time.Second}
+	if got := m.observe(initPath, seg, t0.Add(time.Minute+time.Second)); got != time.Second {
+		t.Errorf("after reset: latency = %v, want 1s", got)
+	}
+}
+
+func TestLatencyMonitorEmpty(t *testing.T) {
+	if status := newLatencyMonitor().status(); status != nil {
+		t.Errorf("status = %+v, want nil before any segment", status)
+	}
+}
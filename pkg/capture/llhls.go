@@ -0,0 +1,296 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/fmp4"
+	"github.com/video-system/go-video-capture/internal/manifest"
+	"github.com/video-system/go-video-capture/pkg/api"
+)
+
+// keptPartSegments is how many finished segments keep their parts listed,
+// covering the three target durations LL-HLS players need
+const keptPartSegments = 3
+
+// hlsPart is an LL-HLS partial segment: one CMAF chunk of a segment
+type hlsPart struct {
+	offset      int
+	size        int
+	duration    time.Duration
+	independent bool
+}
+
+// partList is the parts found so far in one segment
+type partList struct {
+	parts    []hlsPart
+	complete bool // FFmpeg has moved on to the next segment
+}
+
+// partTracker follows the segments FFmpeg writes and records their CMAF
+// chunks as partial segments, waking blocked playlist and part requests as
+// they appear
+type partTracker struct {
+	partDur time.Duration // Configured part duration, used when a chunk has no timing
+
+	mu      sync.Mutex
+	lists   map[int]*partList // By segment sequence; recent segments only
+	done    int               // Newest segment in the buffer (-1 = none)
+	changed chan struct{}     // Closed and replaced whenever parts or segments are added
+}
+
+func newPartTracker(partDur time.Duration) *partTracker {
+	return &partTracker{
+		partDur: partDur,
+		lists:   make(map[int]*partList),
+		done:    -1,
+		changed: make(chan struct{}),
+	}
+}
+
+// partURI is the playlist URI of a part
+func partURI(seq, part int) string {
+	return fmt.Sprintf("part_%05d_%d.m4s", seq, part)
+}
+
+// notify wakes waiters; the caller holds t.mu
+func (t *partTracker) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// track reads a segment while FFmpeg writes it and records each chunk once
+// complete. Called when a new segment file appears; returns once the
+// segment is finished.
+func (t *partTracker) track(ctx context.Context, seq int, path string) error {
+	tracks, err := readInitTracks(filepath.Join(filepath.Dir(path), "init.mp4"))
+	if err != nil {
+		return err
+	}
+	var video fmp4.TrackInfo
+	for _, tr := range tracks {
+		if tr.Handler == "vide" {
+			video = tr
+			break
+		}
+	}
+
+	tail, err := ffmpeg.TailSegment(ctx, path, seq)
+	if err != nil {
+		return err
+	}
+	defer tail.Close()
+
+	t.mu.Lock()
+	list := &partList{}
+	t.lists[seq] = list
+	t.mu.Unlock()
+
+	var data []byte
+	buf := make([]byte, 64*1024)
+	parsed := 0 // Bytes covered by recorded parts
+	for {
+		n, err := tail.Read(buf)
+		data = append(data, buf[:n]...)
+		if frags := fmp4.Fragments(data[parsed:]); len(frags) > 0 {
+			t.mu.Lock()
+			for _, f := range frags {
+				part := hlsPart{
+					offset:      parsed + f.Offset,
+					size:        f.Size,
+					duration:    t.partDur,
+					independent: len(list.parts) == 0 || f.Independent[video.ID],
+				}
+				if d := f.Durations[video.ID]; d > 0 && video.Timescale > 0 {
+					part.duration = time.Duration(d) * time.Second / time.Duration(video.Timescale)
+				}
+				list.parts = append(list.parts, part)
+			}
+			t.notify()
+			t.mu.Unlock()
+			last := frags[len(frags)-1]
+			parsed += last.Offset + last.Size
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	list.complete = true
+	t.notify()
+	t.mu.Unlock()
+	return nil
+}
+
+// readInitTracks lists the tracks in the writer's init segment
+func readInitTracks(path string) ([]fmp4.TrackInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open init segment: %w", err)
+	}
+	defer f.Close()
+	return fmp4.ReadTracks(f)
+}
+
+// segmentDone records a segment added to the buffer and forgets the parts of
+// segments that have left the live edge
+func (t *partTracker) segmentDone(seq int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done = max(t.done, seq)
+	for s := range t.lists {
+		if s <= t.done-keptPartSegments {
+			delete(t.lists, s)
+		}
+	}
+	t.notify()
+}
+
+// parts returns the parts recorded for a segment and whether it is complete
+func (t *partTracker) parts(seq int) ([]hlsPart, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list, ok := t.lists[seq]
+	if !ok {
+		return nil, false
+	}
+	return list.parts[:len(list.parts):len(list.parts)], list.complete
+}
+
+// wait blocks until ready reports true, checked under t.mu whenever the
+// tracker changes
+func (t *partTracker) wait(ctx context.Context, ready func() bool) error {
+	for {
+		t.mu.Lock()
+		ok := ready()
+		changed := t.changed
+		t.mu.Unlock()
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// addParts lists recent segments' parts, the parts of the segment being
+// written and a preload hint for the next part in a live playlist
+func (ch *Channel) addParts(playlist *manifest.MediaPlaylist) {
+	playlist.PartTarget = ch.cfg.HLS.LowLatency.PartDuration
+	segments := playlist.Segments
+	for i := max(0, len(segments)-keptPartSegments); i < len(segments); i++ {
+		parts, _ := ch.parts.parts(segments[i].Sequence)
+		segments[i].Parts = manifestParts(segments[i].Sequence, parts)
+	}
+
+	next := segments[len(segments)-1].Sequence + 1
+	parts, complete := ch.parts.parts(next)
+	playlist.Partial = manifestParts(next, parts)
+	if complete {
+		// About to be listed as a segment; the next part starts the one after
+		playlist.PreloadHint = partURI(next+1, 0)
+	} else {
+		playlist.PreloadHint = partURI(next, len(parts))
+	}
+}
+
+// manifestParts converts a segment's parts for the playlist
+func manifestParts(seq int, parts []hlsPart) []manifest.Part {
+	var out []manifest.Part
+	for i, p := range parts {
+		out = append(out, manifest.Part{URI: partURI(seq, i), Duration: p.duration, Independent: p.independent})
+	}
+	return out
+}
+
+// WaitHLSPlaylist blocks an LL-HLS playlist reload until the playlist lists
+// segment msn, or part of it when part >= 0. It waits at most three target
+// durations. (implements api.ChannelInterface)
+func (ch *Channel) WaitHLSPlaylist(ctx context.Context, msn, part int) error {
+	t := ch.parts
+	if t == nil {
+		return nil // Not low-latency: players don't block
+	}
+	t.mu.Lock()
+	live := t.done
+	for seq := range t.lists {
+		live = max(live, seq)
+	}
+	t.mu.Unlock()
+	if msn > live+2 {
+		return fmt.Errorf("%w: _HLS_msn=%d, live edge at %d", api.ErrPlaylistAhead, msn, live)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*ch.cfg.Buffer.SegmentSize)
+	defer cancel()
+	return t.wait(ctx, func() bool {
+		if t.done >= msn {
+			return true
+		}
+		if part < 0 || t.done < msn-1 {
+			return false
+		}
+		list, ok := t.lists[msn]
+		return ok && len(list.parts) > part
+	})
+}
+
+// GetHLSPart returns an LL-HLS partial segment, waiting for it while it is
+// the next part to be written (implements api.ChannelInterface)
+func (ch *Channel) GetHLSPart(ctx context.Context, seq, part int) ([]byte, error) {
+	t := ch.parts
+	if t == nil {
+		return nil, fmt.Errorf("%w: low-latency hls not enabled", api.ErrPartNotFound)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*ch.cfg.Buffer.SegmentSize)
+	defer cancel()
+	var p hlsPart
+	found := false
+	err := t.wait(ctx, func() bool {
+		list, ok := t.lists[seq]
+		if ok && len(list.parts) > part {
+			p, found = list.parts[part], true
+			return true
+		}
+		// Gone, or a hint for a segment that ended first
+		return (ok && list.complete) || seq <= t.done-keptPartSegments
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", api.ErrPartNotFound, partURI(seq, part))
+	}
+
+	// In-memory segments are removed from disk once complete
+	if data, ok := ch.buffer.SegmentData(seq); ok && len(data) >= p.offset+p.size {
+		return data[p.offset : p.offset+p.size], nil
+	}
+	f, err := os.Open(filepath.Join(ch.basePath, fmt.Sprintf("segment_%05d.m4s", seq)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", api.ErrPartNotFound, partURI(seq, part))
+	}
+	defer f.Close()
+	data := make([]byte, p.size)
+	if _, err := f.ReadAt(data, int64(p.offset)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %s", api.ErrPartNotFound, partURI(seq, part))
+		}
+		return nil, fmt.Errorf("read part: %w", err)
+	}
+	return data, nil
+}
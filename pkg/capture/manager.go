@@ -2,25 +2,58 @@ package capture
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/internal/srtmux"
+	"github.com/video-system/go-video-capture/internal/sysstat"
 	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/ndi"
 	"github.com/video-system/go-video-capture/pkg/platform"
+	"github.com/video-system/go-video-capture/pkg/uploads"
 )
 
+var logger = logging.For("capture")
+
 // Manager orchestrates multiple capture channels
 type Manager struct {
 	cfg      *Config
 	ffmpeg   *ffmpeg.FFmpeg
 	platform *platform.Client
+	events   *events.Bus
+	storage  *storageMonitor
+	devices  *deviceWatcher
+	alerts   *alerter
+	report   *sessionRecorder
+	mqtt     *mqttPublisher     // nil when disabled
+	pairing  *pairing           // nil when redundancy is disabled
+	uploads  *uploads.Queue     // nil without a platform
+	spool    *notifySpool       // nil without a platform
+	cpu      sysstat.CPU        // Usage between heartbeats
+	schedule *recordingSchedule // nil without recording windows
+	nvenc    *nvencSessions     // Set on first use; nil without an NVIDIA GPU
 	channels map[string]*Channel
+	srtMuxes map[string]*srtmux.Mux // SRT listener ports shared by stream ID
+
+	// Channel configs in effect, compared against the file on reload
+	channelCfgs map[string]ChannelConfig
+	reloadMu    sync.Mutex
+
+	nvencOnce sync.Once
 
 	mu        sync.RWMutex
 	sessionID string
 	basePath  string
+	startErrs map[string]error // Channels that failed to start, by ID
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -39,88 +72,260 @@ func NewManager(cfg *Config) (*Manager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("get ffmpeg version: %w", err)
 	}
-	log.Printf("FFmpeg: %s", version)
+	logger.Info("FFmpeg found", "version", version)
+
+	// Before any NDI finder or receiver initializes the SDK
+	if err := ndi.Configure(ndi.DiscoveryConfig{
+		Groups:          strings.Join(cfg.NDIDiscovery.Groups, ","),
+		ExtraIPs:        strings.Join(cfg.NDIDiscovery.ExtraIPs, ","),
+		DiscoveryServer: cfg.NDIDiscovery.DiscoveryServer,
+	}); err != nil {
+		return nil, fmt.Errorf("configure ndi discovery: %w", err)
+	}
 
 	// Create platform client if configured (shared across all channels)
 	var platformClient *platform.Client
 	if cfg.Platform.Enabled && cfg.Platform.URL != "" {
-		platformClient = platform.New(platform.Config{
-			URL:    cfg.Platform.URL,
-			APIKey: cfg.Platform.APIKey,
-		})
-		log.Printf("Platform integration enabled: %s", cfg.Platform.URL)
+		if platformClient, err = cfg.Platform.NewClient(); err != nil {
+			return nil, err
+		}
+		logger.Info("Platform integration enabled", "url", cfg.Platform.URL)
 	}
 
+	bus := events.NewBus()
+
 	m := &Manager{
 		cfg:       cfg,
 		ffmpeg:    ff,
 		platform:  platformClient,
+		events:    bus,
+		storage:   newStorageMonitor(cfg.Buffer.Path, cfg.Monitor),
+		devices:   &deviceWatcher{interval: cfg.Monitor.DeviceInterval},
+		alerts:    newAlerter(cfg.Alerts),
+		report:    newSessionRecorder(bus),
+		schedule:  newRecordingSchedule(cfg.Schedule),
 		channels:  make(map[string]*Channel),
+		srtMuxes:  make(map[string]*srtmux.Mux),
 		sessionID: cfg.Session.SessionID,
 		basePath:  cfg.Buffer.Path,
 	}
+	if cfg.Redundancy.Enabled {
+		m.pairing = newPairing(m, cfg.Redundancy)
+	}
+	m.attachSinks()
 
-	// Create channels based on config
-	if len(cfg.Channels) > 0 {
-		// Multi-channel mode
-		for _, chCfg := range cfg.Channels {
-			ch, err := NewChannel(chCfg.ID, chCfg, ff, platformClient, cfg.Session.SessionID, cfg.Buffer.Path)
-			if err != nil {
-				return nil, fmt.Errorf("create channel %s: %w", chCfg.ID, err)
-			}
-			m.channels[chCfg.ID] = ch
-			log.Printf("Channel configured: %s", chCfg.ID)
+	if platformClient != nil {
+		m.uploads, err = m.newUploadQueue()
+		if err != nil {
+			return nil, fmt.Errorf("init upload queue: %w", err)
 		}
-	} else {
-		// Single-channel mode (backwards compatible)
-		chCfg := ChannelConfig{
-			ID:     cfg.Session.ChannelID,
-			Input:  cfg.Input,
-			Buffer: cfg.Buffer,
-			Encode: cfg.Encode,
+	}
+
+	if cfg.MQTT.Enabled {
+		m.mqtt, err = newMQTTPublisher(cfg, bus)
+		if err != nil {
+			return nil, fmt.Errorf("init mqtt: %w", err)
 		}
-		ch, err := NewChannel(chCfg.ID, chCfg, ff, platformClient, cfg.Session.SessionID, cfg.Buffer.Path)
+		logger.Info("MQTT publishing enabled", "broker", cfg.MQTT.Broker)
+	}
+
+	// Create channels based on config
+	m.channelCfgs = channelConfigs(cfg)
+	for id, chCfg := range m.channelCfgs {
+		ch, err := m.newChannel(chCfg, cfg.Session.SessionID)
 		if err != nil {
-			return nil, fmt.Errorf("create channel %s: %w", chCfg.ID, err)
+			m.closeSRTMuxes()
+			return nil, err
 		}
-		m.channels[chCfg.ID] = ch
-		log.Printf("Single channel mode: %s", chCfg.ID)
+		m.channels[id] = ch
+		logger.Info("Channel configured", "channel", id, "single", len(cfg.Channels) == 0)
 	}
 
 	return m, nil
 }
 
-// Start starts all channels
+// channelConfigs returns the config of each channel by ID, with the shared
+// settings copied in
+func channelConfigs(cfg *Config) map[string]ChannelConfig {
+	if len(cfg.Channels) == 0 {
+		// Single-channel mode (backwards compatible)
+		return map[string]ChannelConfig{cfg.Session.ChannelID: {
+			ID:       cfg.Session.ChannelID,
+			Input:    cfg.Input,
+			Buffer:   cfg.Buffer,
+			Encode:   cfg.Encode,
+			Restream: cfg.Restream,
+			CMAF:     cfg.CMAF,
+			NDI:      cfg.NDI,
+			WHEP:     cfg.WHEP,
+			HLS:      cfg.HLS,
+			Outputs:  channelOutputs(cfg.Outputs, nil, cfg.Session.ChannelID),
+			Monitor:  cfg.Monitor,
+		}}
+	}
+	cfgs := make(map[string]ChannelConfig, len(cfg.Channels))
+	for _, chCfg := range cfg.Channels {
+		chCfg.CMAF = cfg.CMAF
+		chCfg.NDI = cfg.NDI
+		chCfg.WHEP = cfg.WHEP
+		chCfg.HLS = cfg.HLS
+		chCfg.Outputs = channelOutputs(cfg.Outputs, chCfg.Outputs, chCfg.ID)
+		chCfg.Monitor = cfg.Monitor
+		cfgs[chCfg.ID] = chCfg
+	}
+	return cfgs
+}
+
+// channelOutputs returns the shared outputs, with the channel ID appended to
+// their paths, followed by the channel's own
+func channelOutputs(shared, own []OutputConfig, channelID string) []OutputConfig {
+	outputs := make([]OutputConfig, 0, len(shared)+len(own))
+	for _, o := range shared {
+		o.Path = o.channelPath(channelID)
+		outputs = append(outputs, o)
+	}
+	return append(outputs, own...)
+}
+
+// newChannel creates a channel sharing the manager's FFmpeg, platform client,
+// event bus and upload queue
+func (m *Manager) newChannel(chCfg ChannelConfig, sessionID string) (*Channel, error) {
+	ch, err := NewChannel(chCfg.ID, chCfg, m.ffmpeg, m.platform, m.events, sessionID, m.cfg.Buffer.Path)
+	if err != nil {
+		return nil, fmt.Errorf("create channel %s: %w", chCfg.ID, err)
+	}
+	if ch.srtBackend, err = m.srtRoute(chCfg); err != nil {
+		return nil, fmt.Errorf("create channel %s: %w", chCfg.ID, err)
+	}
+	ch.pairing = m.pairing
+	ch.uploads = m.uploads
+	if chCfg.Encode.Type == "nvenc" {
+		ch.nvenc = m.nvencSessions()
+	}
+	ch.offSchedule = !m.schedule.onSchedule(chCfg.ID, time.Now())
+	return ch, nil
+}
+
+// channel returns a channel by ID, nil if there is none
+func (m *Manager) channel(id string) *Channel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.channels[id]
+}
+
+// channelList returns the channels sorted by ID
+func (m *Manager) channelList() []*Channel {
+	m.mu.RLock()
+	channels := make([]*Channel, 0, len(m.channels))
+	for _, ch := range m.channels {
+		channels = append(channels, ch)
+	}
+	m.mu.RUnlock()
+	slices.SortFunc(channels, func(a, b *Channel) int { return strings.Compare(a.id, b.id) })
+	return channels
+}
+
+// Start starts all channels. A channel that fails to start is logged and the
+// others carry on; Start only fails if no channel started, and otherwise
+// StartErrors lists the channels that didn't. A channel whose capture fails
+// to start still counts as started: it keeps serving its buffer and
+// publishes the failure as an error event.
 func (m *Manager) Start(ctx context.Context) error {
 	m.mu.Lock()
 	m.ctx, m.cancel = context.WithCancel(ctx)
 	m.mu.Unlock()
 
-	log.Printf("Starting %d channel(s)", len(m.channels))
+	channels := m.channelList()
+	logger.Info("Starting channels", "count", len(channels))
+	m.cpu.Usage() // So the first heartbeat covers one interval, not the uptime
+	recovery.Go("storage monitor", func() { m.monitorStorage(m.ctx) })
+	recovery.Go("alert monitor", func() { m.monitorAlerts(m.ctx) })
+	recovery.Go("device watcher", func() { m.watchDevices(m.ctx) })
+	if m.mqtt != nil {
+		recovery.Go("mqtt status", func() { m.publishMQTTStatus(m.ctx) })
+	}
+	if m.pairing != nil {
+		recovery.Go("redundancy", func() { m.pairing.run(m.ctx) })
+	}
+	if m.schedule != nil {
+		recovery.Go("recording schedule", func() { m.runSchedule(m.ctx) })
+	}
+
+	return m.startChannels(channels)
+}
 
-	// Start all channels
-	for id, ch := range m.channels {
+// startChannels starts each channel, recording the ones that fail; it fails
+// only if all of them did
+func (m *Manager) startChannels(channels []*Channel) error {
+	var errs []error
+	failed := make(map[string]error)
+	for _, ch := range channels {
 		if err := ch.Start(m.ctx); err != nil {
-			log.Printf("Warning: failed to start channel %s: %v", id, err)
+			logger.Warn("Failed to start channel", "channel", ch.id, "error", err)
+			errs = append(errs, fmt.Errorf("channel %s: %w", ch.id, err))
+			failed[ch.id] = err
 			// Continue with other channels
 		}
 	}
-
+	m.mu.Lock()
+	m.startErrs = failed
+	m.mu.Unlock()
+	if len(errs) > 0 && len(errs) == len(channels) {
+		return errors.Join(errs...)
+	}
 	return nil
 }
 
+// StartErrors returns why channels failed to start, by channel ID, as of the
+// last Start. Empty when all started.
+func (m *Manager) StartErrors() map[string]error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.startErrs)
+}
+
 // Stop stops all channels
 func (m *Manager) Stop() {
+	m.reloadMu.Lock() // Waits for a reload swapping channels
+	defer m.reloadMu.Unlock()
+
 	m.mu.Lock()
 	if m.cancel != nil {
 		m.cancel()
 	}
 	m.mu.Unlock()
 
-	for _, ch := range m.channels {
+	for _, ch := range m.channelList() {
 		ch.Stop()
 	}
-	log.Printf("All channels stopped")
+	m.closeSRTMuxes()
+
+	// Capture has stopped, so the report covers the whole session
+	m.mu.RLock()
+	sessionID := m.sessionID
+	m.mu.RUnlock()
+	if report, ok := m.report.report(sessionID, true); ok && report.active() {
+		if err := m.saveReport(context.Background(), &report); err != nil {
+			logger.Warn("Failed to save session report", "session", sessionID, "error", err)
+		}
+	}
+	m.report.stop()
+	if m.uploads != nil {
+		m.uploads.Close()
+	}
+	m.events.Close()
+	if m.spool != nil {
+		m.spool.close()
+	}
+	if m.mqtt != nil {
+		m.mqtt.close()
+	}
+	logger.Info("All channels stopped")
+}
+
+// Events returns the event bus shared by all channels
+func (m *Manager) Events() *events.Bus {
+	return m.events
 }
 
 // Wait blocks until context is cancelled
@@ -163,16 +368,29 @@ func (m *Manager) GetAllStatuses() map[string]interface{} {
 	return statuses
 }
 
-// SetSession updates the session ID for all channels
+// RestartChannel restarts a channel's capture
+func (m *Manager) RestartChannel(id string) error {
+	ch := m.channel(id)
+	if ch == nil {
+		return fmt.Errorf("channel not found: %s", id)
+	}
+	return ch.RestartCapture()
+}
+
+// SetSession updates the session ID for all channels and saves the previous
+// session's report
 func (m *Manager) SetSession(sessionID string) {
-	m.mu.Lock()
-	m.sessionID = sessionID
-	m.mu.Unlock()
+	m.mu.RLock()
+	same := m.sessionID == sessionID
+	m.mu.RUnlock()
+	if same {
+		return
+	}
 
-	for _, ch := range m.channels {
-		ch.SetSession(sessionID)
+	if report, ok := m.swapSession(sessionID); ok {
+		m.saveReportAsync(report)
 	}
-	log.Printf("Session updated for all channels: %s", sessionID)
+	logger.Info("Session updated for all channels", "session", sessionID)
 }
 
 // GetDefaultChannel returns the first/only channel (for backwards compatibility)
@@ -203,6 +421,12 @@ func (m *Manager) GetDefaultChannel() (api.ChannelInterface, bool) {
 	return nil, false
 }
 
+// SupportedCodecs returns the codecs this agent can encode and the hardware
+// encoders detected in the FFmpeg build
+func (m *Manager) SupportedCodecs(ctx context.Context) ([]string, []string) {
+	return m.ffmpeg.SupportedCodecs(ctx)
+}
+
 // ChannelCount returns the number of configured channels
 func (m *Manager) ChannelCount() int {
 	m.mu.RLock()
@@ -0,0 +1,63 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStartChannelsPartialFailure(t *testing.T) {
+	m, _ := newReloadTestManager(t, reloadConfigYAML(t.TempDir(), "copy"))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m.ctx = ctx
+	channels := m.channelList()
+	t.Cleanup(func() {
+		for _, ch := range channels {
+			ch.Stop()
+		}
+	})
+	if len(m.StartErrors()) != 0 {
+		t.Error("start errors before Start")
+	}
+
+	// A channel that fails doesn't stop the others, and is reported
+	if err := m.channel("cam1").Start(ctx); err != nil {
+		t.Fatalf("Start cam1: %v", err)
+	}
+	if err := m.startChannels(channels); err != nil {
+		t.Fatalf("startChannels with one channel started: %v", err)
+	}
+	errs := m.StartErrors()
+	if len(errs) != 1 || errs["cam1"] == nil {
+		t.Errorf("start errors = %v, want cam1's", errs)
+	}
+	if status := m.channel("cam2").GetStatus().(ChannelStatus); !status.IsRunning {
+		t.Error("cam2 not started")
+	}
+
+	// With none started Start fails, naming each channel
+	err := m.startChannels(channels)
+	if err == nil {
+		t.Fatal("expected an error when no channel starts")
+	}
+	for _, id := range []string{"cam1", "cam2"} {
+		if want := fmt.Sprintf("channel %s:", id); !containsLine(err.Error(), want) {
+			t.Errorf("error %q doesn't name %s", err, id)
+		}
+	}
+	if errs := m.StartErrors(); len(errs) != 2 {
+		t.Errorf("start errors = %v, want both channels", errs)
+	}
+}
+
+// containsLine reports whether a line of s starts with prefix
+func containsLine(s, prefix string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package capture
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/metrics"
+)
+
+// channelMetrics are a channel's counters for the /metrics endpoint
+type channelMetrics struct {
+	segments       metrics.Counter
+	clips          metrics.Counter
+	clipFailures   metrics.Counter
+	uploads        metrics.Counter
+	uploadFailures metrics.Counter
+	restarts       metrics.Counter
+	clipSeconds    *metrics.Histogram
+
+	started atomic.Bool
+}
+
+func newChannelMetrics() *channelMetrics {
+	return &channelMetrics{clipSeconds: metrics.NewHistogram(metrics.DefaultBuckets)}
+}
+
+// encoderStarted counts every capture start after the first as a restart
+func (m *channelMetrics) encoderStarted() {
+	if m.started.Swap(true) {
+		m.restarts.Inc()
+	}
+}
+
+// clipDone records a clip generation attempt that began at start
+func (m *channelMetrics) clipDone(start time.Time, err error) {
+	if err != nil {
+		m.clipFailures.Inc()
+		return
+	}
+	m.clips.Inc()
+	m.clipSeconds.Observe(time.Since(start).Seconds())
+}
+
+// WriteMetrics writes per-channel metrics in the Prometheus text format
+// (implements api.ChannelManager)
+func (m *Manager) WriteMetrics(w io.Writer) error {
+	m.mu.RLock()
+	channels := make([]*Channel, 0, len(m.channels))
+	for _, ch := range m.channels {
+		channels = append(channels, ch)
+	}
+	m.mu.RUnlock()
+	slices.SortFunc(channels, func(a, b *Channel) int { return strings.Compare(a.id, b.id) })
+
+	type gauge struct {
+		name, help string
+		value      func(ch *Channel) float64
+	}
+	type counter struct {
+		name, help string
+		value      func(m *channelMetrics) *metrics.Counter
+	}
+
+	gauges := []gauge{
+		{"capture_up", "Whether the channel is capturing.", func(ch *Channel) float64 {
+			ch.mu.RLock()
+			defer ch.mu.RUnlock()
+			if ch.isCapturing {
+				return 1
+			}
+			return 0
+		}},
+		{"capture_buffer_health", "Fraction of the buffer duration currently held.", func(ch *Channel) float64 {
+			return ch.buffer.GetStatus().Health
+		}},
+		{"capture_buffer_segments", "Segments in the ring buffer.", func(ch *Channel) float64 {
+			return float64(ch.buffer.GetStatus().SegmentCount)
+		}},
+		{"capture_fps", "Encoder frame rate measured over the last segment.", func(ch *Channel) float64 {
+			p, _ := ch.history.fps.Last()
+			return p.Value
+		}},
+		{"capture_encoder_fps", "Encoding rate reported by FFmpeg.", func(ch *Channel) float64 {
+			return ch.encoderStats().FPS
+		}},
+		{"capture_encoder_speed", "Encoding speed relative to real time reported by FFmpeg; below 1 the encoder falls behind.", func(ch *Channel) float64 {
+			return ch.encoderStats().Speed
+		}},
+		{"capture_encoder_bitrate_kbps", "Output bitrate reported by FFmpeg.", func(ch *Channel) float64 {
+			return ch.encoderStats().BitrateKbps
+		}},
+		{"capture_encoder_dropped_frames", "Input frames FFmpeg dropped since it started.", func(ch *Channel) float64 {
+			return float64(ch.encoderStats().Dropped)
+		}},
+		{"capture_ndi_frames_received", "Video frames received from the NDI source since capture started.", func(ch *Channel) float64 {
+			return float64(ch.ndiStats().FramesReceived)
+		}},
+		{"capture_ndi_frames_dropped", "NDI frames dropped because FFmpeg fell behind.", func(ch *Channel) float64 {
+			return float64(ch.ndiStats().FramesDropped)
+		}},
+		{"capture_ndi_frames_duplicated", "NDI frames repeated to fill gaps in the source timestamps.", func(ch *Channel) float64 {
+			return float64(ch.ndiStats().FramesDuplicated)
+		}},
+		{"capture_ndi_frames_skipped", "NDI frames skipped because they arrived ahead of the source timeline.", func(ch *Channel) float64 {
+			return float64(ch.ndiStats().FramesSkipped)
+		}},
+		{"capture_ndi_reconnects", "Times the NDI source was rediscovered after it stopped sending.", func(ch *Channel) float64 {
+			return float64(ch.ndiStats().Reconnects)
+		}},
+		{"capture_ndi_buffers_allocated", "NDI frame buffers allocated; flat once the buffer pool is warm.", func(ch *Channel) float64 {
+			return float64(ch.ndiStats().BuffersAllocated)
+		}},
+		{"capture_ndi_buffers_reused", "NDI frame buffers reused from the pool.", func(ch *Channel) float64 {
+			return float64(ch.ndiStats().BuffersReused)
+		}},
+	}
+	counters := []counter{
+		{"capture_segments_written_total", "Segments written to the buffer.", func(m *channelMetrics) *metrics.Counter { return &m.segments }},
+		{"capture_clips_generated_total", "Clips generated.", func(m *channelMetrics) *metrics.Counter { return &m.clips }},
+		{"capture_clip_failures_total", "Clip generations that failed.", func(m *channelMetrics) *metrics.Counter { return &m.clipFailures }},
+		{"capture_uploads_total", "Clips uploaded to the platform.", func(m *channelMetrics) *metrics.Counter { return &m.uploads }},
+		{"capture_upload_failures_total", "Clip uploads to the platform that failed.", func(m *channelMetrics) *metrics.Counter { return &m.uploadFailures }},
+		{"capture_ffmpeg_restarts_total", "Times the capture encoder was restarted.", func(m *channelMetrics) *metrics.Counter { return &m.restarts }},
+	}
+
+	mw := metrics.NewWriter(w)
+	for _, g := range gauges {
+		mw.Header(g.name, "gauge", g.help)
+		for _, ch := range channels {
+			mw.Sample(g.name, metrics.Labels{"channel", ch.id}, g.value(ch))
+		}
+	}
+	for _, c := range counters {
+		mw.Header(c.name, "counter", c.help)
+		for _, ch := range channels {
+			mw.Counter(c.name, metrics.Labels{"channel", ch.id}, c.value(ch.metrics))
+		}
+	}
+	mw.Header("capture_clip_generation_seconds", "histogram", "Time to generate a clip.")
+	for _, ch := range channels {
+		mw.Histogram("capture_clip_generation_seconds", metrics.Labels{"channel", ch.id}, ch.metrics.clipSeconds)
+	}
+	return mw.Flush()
+}
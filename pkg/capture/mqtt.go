@@ -0,0 +1,125 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+// mqttPublisher publishes retained agent and channel status on an interval;
+// events go through the same connection as a bus sink
+type mqttPublisher struct {
+	sink         *events.MQTTSink
+	interval     time.Duration
+	statusTopic  string
+	channelTopic string // still contains {channel}
+}
+
+// newMQTTPublisher connects to the broker and attaches the event sink
+func newMQTTPublisher(cfg *Config, bus *events.Bus) (*mqttPublisher, error) {
+	agent := cfg.Platform.AgentID
+	if agent == "" {
+		agent, _ = os.Hostname()
+	}
+	topic := func(tmpl, def string) string {
+		if tmpl == "" {
+			tmpl = def
+		}
+		return strings.ReplaceAll(tmpl, "{agent}", agent)
+	}
+	clientID := cfg.MQTT.ClientID
+	if clientID == "" {
+		clientID = "capture-" + agent
+	}
+
+	p := &mqttPublisher{
+		interval:     max(cfg.MQTT.StatusInterval, time.Second),
+		statusTopic:  topic(cfg.MQTT.Topics.Status, "capture/{agent}/status"),
+		channelTopic: topic(cfg.MQTT.Topics.ChannelStatus, "capture/{agent}/channels/{channel}/status"),
+	}
+	offline, _ := json.Marshal(map[string]interface{}{"status": platform.AgentStatusOffline})
+	sink, err := events.NewMQTTSink(events.MQTTConfig{
+		Broker:      cfg.MQTT.Broker,
+		ClientID:    clientID,
+		Username:    cfg.MQTT.Username,
+		Password:    cfg.MQTT.Password,
+		QoS:         cfg.MQTT.QoS,
+		Topic:       topic(cfg.MQTT.Topics.Events, "capture/{agent}/events/{type}"),
+		WillTopic:   p.statusTopic,
+		WillPayload: offline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.sink = sink
+
+	var types []events.Type
+	for _, t := range cfg.MQTT.Events {
+		types = append(types, events.Type(t))
+	}
+	bus.Attach("mqtt", sink, types...)
+	return p, nil
+}
+
+// close marks the agent offline and disconnects
+func (p *mqttPublisher) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	p.sink.Publish(ctx, p.statusTopic, map[string]interface{}{
+		"status": platform.AgentStatusOffline,
+		"time":   time.Now(),
+	}, true)
+	p.sink.Close()
+}
+
+// publishMQTTStatus publishes status until ctx is done
+func (m *Manager) publishMQTTStatus(ctx context.Context) {
+	ticker := time.NewTicker(m.mqtt.interval)
+	defer ticker.Stop()
+
+	for {
+		m.publishStatus(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishStatus publishes the agent status and each channel's status once
+func (m *Manager) publishStatus(ctx context.Context) {
+	p := m.mqtt
+	status := platform.AgentStatusOnline
+	if m.IsRecording() {
+		status = platform.AgentStatusRecording
+	}
+	agent := map[string]interface{}{
+		"status":   status,
+		"channels": m.ListChannels(),
+		"time":     time.Now(),
+	}
+	if err := m.GetError(); err != nil {
+		agent["status"] = platform.AgentStatusError
+		agent["error"] = err.Error()
+	}
+	m.mu.RLock()
+	agent["session_id"] = m.sessionID
+	m.mu.RUnlock()
+
+	if err := p.sink.Publish(ctx, p.statusTopic, agent, true); err != nil {
+		logger.Debug("MQTT status publish failed", "error", err)
+		return
+	}
+	for id, st := range m.GetAllStatuses() {
+		topic := strings.ReplaceAll(p.channelTopic, "{channel}", id)
+		if err := p.sink.Publish(ctx, topic, st, true); err != nil {
+			logger.Debug("MQTT status publish failed", "channel", id, "error", err)
+		}
+	}
+}
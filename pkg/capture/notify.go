@@ -0,0 +1,160 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+// maxSpooled caps the notifications kept while the platform is unreachable;
+// the oldest are dropped beyond it
+const maxSpooled = 10000
+
+// notifySpool holds segment notifications the platform didn't get and
+// replays them in order once it is reachable again, so its segment lists
+// for ghost clips have no holes. The spool is kept on disk across restarts.
+type notifySpool struct {
+	path   string
+	client *platform.Client
+	sent   func(n platform.SegmentNotification) // Called after each replayed notification
+
+	mu      sync.Mutex
+	pending []platform.SegmentNotification
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newNotifySpool loads the spool from path and starts replaying
+func newNotifySpool(path string, client *platform.Client, sent func(platform.SegmentNotification)) *notifySpool {
+	s := &notifySpool{
+		path:   path,
+		client: client,
+		sent:   sent,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &s.pending); err != nil {
+			logger.Warn("Ignoring unreadable notification spool", "path", path, "error", err)
+		} else if len(s.pending) > 0 {
+			logger.Info("Replaying spooled segment notifications", "count", len(s.pending))
+		}
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	recovery.Go("notification replay", s.run)
+	return s
+}
+
+// close stops replaying; spooled notifications stay on disk
+func (s *notifySpool) close() {
+	s.cancel()
+	<-s.done
+}
+
+// empty reports whether nothing is waiting, so new notifications can be
+// sent directly without overtaking spooled ones
+func (s *notifySpool) empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending) == 0
+}
+
+// add spools a notification behind any already waiting
+func (s *notifySpool) add(n platform.SegmentNotification) {
+	s.mu.Lock()
+	s.pending = append(s.pending, n)
+	if over := len(s.pending) - maxSpooled; over > 0 {
+		logger.Warn("Notification spool full, dropping oldest", "dropped", over)
+		s.pending = s.pending[over:]
+	}
+	s.saveLocked()
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run replays the spool oldest first, backing off while the platform is
+// still unreachable
+func (s *notifySpool) run() {
+	defer close(s.done)
+	backoff := time.Second
+	for {
+		s.mu.Lock()
+		var next *platform.SegmentNotification
+		if len(s.pending) > 0 {
+			next = &s.pending[0]
+		}
+		s.mu.Unlock()
+
+		if next == nil {
+			select {
+			case <-s.wake:
+				// Wait before the first retry; the failure is fresh
+			case <-s.ctx.Done():
+				return
+			}
+		} else {
+			ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+			err := s.client.NotifySegmentReady(ctx, *next)
+			cancel()
+			if err == nil {
+				s.mu.Lock()
+				n := s.pending[0]
+				s.pending = s.pending[1:]
+				s.saveLocked()
+				left := len(s.pending)
+				s.mu.Unlock()
+				if s.sent != nil {
+					s.sent(n)
+				}
+				if left == 0 {
+					logger.Info("Spooled segment notifications delivered")
+				}
+				backoff = time.Second
+				continue
+			}
+			if s.ctx.Err() != nil {
+				return
+			}
+			logger.Debug("Notification replay failed", "error", err, "retry_in", backoff)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// saveLocked writes the spool, removing the file once it is empty
+func (s *notifySpool) saveLocked() {
+	if len(s.pending) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to remove notification spool", "path", s.path, "error", err)
+		}
+		return
+	}
+	data, err := json.Marshal(s.pending)
+	if err == nil {
+		tmp := s.path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, s.path)
+		}
+	}
+	if err != nil {
+		logger.Warn("Failed to save notification spool", "path", s.path, "error", err)
+	}
+}
@@ -0,0 +1,102 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+func TestNotifySpool(t *testing.T) {
+	var up atomic.Bool
+	var mu sync.Mutex
+	var got []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var n platform.SegmentNotification
+		json.NewDecoder(r.Body).Decode(&n)
+		mu.Lock()
+		got = append(got, n.Sequence)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	client, err := platform.New(platform.Config{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "segment_notifications.json")
+	replayed := make(chan int, 10)
+	spool := newNotifySpool(path, client, func(n platform.SegmentNotification) { replayed <- n.Sequence })
+	defer spool.close()
+	notifier := platformNotifier{client: client, spool: spool}
+	send := func(seq int) error {
+		return notifier.Send(context.Background(), events.Event{Type: events.GhostSegment, Channel: "cam1", Data: map[string]interface{}{"play_id": "p1", "seq": seq}})
+	}
+
+	// The first failure is spooled; later ones queue behind it even though
+	// the platform is back, so nothing overtakes
+	if err := send(1); err == nil {
+		t.Error("expected the failed notification to be reported")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("spool not saved: %v", err)
+	}
+	up.Store(true)
+	for seq := 2; seq <= 3; seq++ {
+		if err := send(seq); err != nil {
+			t.Errorf("send %d: %v", seq, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-replayed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("replayed %d of 3 notifications", i)
+		}
+	}
+	mu.Lock()
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("platform got %v, want [1 2 3] in order", got)
+	}
+	mu.Unlock()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("empty spool not removed: %v", err)
+	}
+
+	// With the spool drained, notifications go out directly
+	if err := send(4); err != nil || !spool.empty() {
+		t.Errorf("send 4: %v, spool empty %v", err, spool.empty())
+	}
+}
+
+func TestNotifySpoolLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "segment_notifications.json")
+	data, _ := json.Marshal([]platform.SegmentNotification{{ChannelID: "cam1", Sequence: 7}, {ChannelID: "cam1", Sequence: 8}})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Unreachable platform: the loaded notifications wait
+	client, err := platform.New(platform.Config{URL: "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spool := newNotifySpool(path, client, nil)
+	spool.close()
+	if spool.empty() || len(spool.pending) != 2 || spool.pending[0].Sequence != 7 {
+		t.Errorf("pending = %+v, want sequences 7 and 8 from disk", spool.pending)
+	}
+}
@@ -0,0 +1,141 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/encode"
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+// errNVENCQueued means capture starts once an NVENC session frees up
+var errNVENCQueued = errors.New("queued for an NVENC session")
+
+// nvencSessions shares the encode sessions of the agent's NVIDIA GPUs
+// between its channels
+type nvencSessions struct {
+	pool   *encode.SessionPool
+	reject bool // Fail to start when all are in use, rather than queue
+}
+
+// nvencSessions returns the agent's NVENC sessions, detecting the GPUs the
+// first time a channel encodes with NVENC. Nil when no GPU was found, in
+// which case sessions are not limited.
+func (m *Manager) nvencSessions() *nvencSessions {
+	m.nvencOnce.Do(func() { m.nvenc = newNVENCSessions(m.cfg.NVENC) })
+	return m.nvenc
+}
+
+func newNVENCSessions(cfg NVENCConfig) *nvencSessions {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	gpus, err := encode.DetectGPUs(ctx)
+	if len(gpus) == 0 {
+		if cfg.MaxSessions <= 0 {
+			logger.Warn("No NVIDIA GPU detected, NVENC sessions are not limited", "error", err)
+			return nil
+		}
+		// Configured limit without nvidia-smi, e.g. in a container
+		logger.Warn("No NVIDIA GPU detected, assuming one", "error", err, "max_sessions", cfg.MaxSessions)
+		gpus = []encode.GPU{{Index: 0}}
+	}
+	for i := range gpus {
+		switch {
+		case cfg.MaxSessions > 0:
+			gpus[i].MaxSessions = cfg.MaxSessions
+		case cfg.MaxSessions < 0:
+			gpus[i].MaxSessions = 0
+		}
+		logger.Info("NVIDIA GPU found", "gpu", gpus[i].Index, "name", gpus[i].Name, "driver", gpus[i].Driver, "max_sessions", gpus[i].MaxSessions)
+	}
+	return &nvencSessions{
+		pool:   encode.NewSessionPool(gpus),
+		reject: cfg.WhenFull == "reject",
+	}
+}
+
+// acquireNVENC takes an NVENC session for capture, nil when the channel
+// doesn't need one. With all in use it fails when configured to reject;
+// otherwise the channel is queued and errNVENCQueued returned.
+func (ch *Channel) acquireNVENC() (*encode.Session, error) {
+	if ch.nvenc == nil || ch.encodeConfig().Type != "nvenc" {
+		return nil, nil
+	}
+	session, err := ch.nvenc.pool.TryAcquire()
+	if !errors.Is(err, encode.ErrNoSession) || ch.nvenc.reject {
+		return session, err
+	}
+
+	ch.mu.Lock()
+	queued := ch.nvencQueued
+	ch.nvencQueued = true
+	ctx := ch.ctx
+	ch.mu.Unlock()
+	if !queued {
+		ch.logger.Warn("All NVENC sessions in use, capture starts when one frees up")
+		ch.publish(events.EncoderQueued, map[string]interface{}{"encoder": "nvenc"})
+		recovery.Go("nvenc queue", func() { ch.awaitNVENC(ctx) })
+	}
+	return nil, errNVENCQueued
+}
+
+// awaitNVENC waits for an NVENC session and starts capture with it, unless
+// the channel stopped or started capturing meanwhile
+func (ch *Channel) awaitNVENC(ctx context.Context) {
+	session, err := ch.nvenc.pool.Acquire(ctx)
+	ch.startMu.Lock()
+	defer ch.startMu.Unlock()
+	ch.mu.Lock()
+	ch.nvencQueued = false
+	idle := ch.isRunning && !ch.isCapturing && !ch.offSchedule && ctx.Err() == nil
+	ch.mu.Unlock()
+	if err != nil {
+		return
+	}
+	if !idle {
+		session.Release()
+		return
+	}
+
+	ch.logger.Info("NVENC session free, starting capture", "gpu", session.GPU())
+	if err := ch.startCaptureWith(session); err != nil {
+		ch.logger.Warn("Failed to start capture", "error", err)
+		ch.publish(events.Error, map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// releaseNVENC gives back a session capture failed to start with
+func (ch *Channel) releaseNVENC(session *encode.Session) {
+	if session == nil {
+		return
+	}
+	ch.mu.Lock()
+	if ch.nvencSession == session {
+		ch.nvencSession = nil
+	}
+	ch.mu.Unlock()
+	session.Release()
+}
+
+// nvencGPU returns the GPU index of the channel's NVENC session, empty
+// without one
+func (ch *Channel) nvencGPU() string {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	if ch.nvencSession == nil {
+		return ""
+	}
+	return strconv.Itoa(ch.nvencSession.GPU())
+}
+
+// nvencOptions returns the FFmpeg encoder options that put NVENC on the
+// session's GPU
+func (ch *Channel) nvencOptions() []string {
+	if gpu := ch.nvencGPU(); gpu != "" {
+		return []string{"-gpu", gpu}
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// channelOutput is an output fed this channel's segments. The segment
+// writer's watcher feeds it while capture may be stopping, so writes hold
+// mu and stop once the output is detached.
+type channelOutput struct {
+	out  output.Output
+	keep bool // Outlives the writer (restreams); not closed with it
+
+	ctx    context.Context // Ends when detached, aborting writes
+	cancel context.CancelFunc
+
+	codec string // Video codec of the writer feeding it
+
+	mu       sync.Mutex
+	init     bool           // Init segment sent for the current writer
+	detached bool           // No longer fed
+	streams  sync.WaitGroup // Segments being streamed
+}
+
+// newChannelOutput wraps out to be fed by the current writer; the caller
+// holds ch.outMu
+func (ch *Channel) newChannelOutput(out output.Output, keep bool) *channelOutput {
+	ctx, cancel := context.WithCancel(ch.ctx)
+	return &channelOutput{out: out, keep: keep, codec: ch.outCodec, ctx: ctx, cancel: cancel}
+}
+
+// detach stops feeding the output, waiting for writes in progress, so it
+// can be closed
+func (o *channelOutput) detach() {
+	o.cancel()
+	o.mu.Lock()
+	o.detached = true
+	o.mu.Unlock()
+	o.streams.Wait()
+}
+
+// openOutputs opens the outputs for a new segment writer. CMAF push failing
+// stops the capture; the NDI monitor failing is only logged, and plugin
+// outputs open and retry on their own.
+func (ch *Channel) openOutputs(cfg ChannelConfig) error {
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	ch.outCodec = cfg.Encode.Codec
+	if cfg.CMAF.Enabled {
+		push, err := ch.openPush()
+		if err != nil {
+			return err
+		}
+		ch.addOutput(push)
+	}
+	if cfg.NDI.Enabled {
+		if out, err := ch.openNDIOutput(cfg.Encode); err != nil {
+			ch.logger.Warn("NDI output disabled", "error", err)
+		} else {
+			ch.addOutput(out)
+		}
+	}
+	if ch.whep != nil {
+		if out, err := ch.openWHEPOutput(cfg.Encode.Codec); err != nil {
+			ch.logger.Warn("WHEP output disabled", "error", err)
+		} else {
+			ch.addOutput(out)
+		}
+	}
+	for _, oc := range cfg.Outputs {
+		maxBytes, _ := parseSize(oc.MaxSize) // Checked by Validate
+		ch.addOutput(newPluginOutput(ch.ctx, ch.logger, oc, output.Config{
+			Path:       oc.Path,
+			Format:     oc.Type,
+			SegmentDur: cfg.Buffer.SegmentSize.Seconds(),
+			Headers:    oc.Headers,
+			ListSize:   oc.ListSize,
+			Retention:  oc.Retention,
+			MaxBytes:   maxBytes,
+			Region:     oc.Region,
+			AccessKey:  oc.AccessKey,
+			SecretKey:  oc.SecretKey,
+		}))
+	}
+	ch.openRestreams()
+	ch.outputsOpen = true
+	return nil
+}
+
+// addOutput adds an output; the caller holds ch.outMu
+func (ch *Channel) addOutput(out output.Output) {
+	ch.outputs = append(ch.outputs, ch.newChannelOutput(out, false))
+}
+
+// closeOutputs closes the writer's outputs once it has stopped
+func (ch *Channel) closeOutputs() {
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	for _, o := range ch.outputs {
+		o.detach()
+		if o.keep {
+			continue
+		}
+		if err := o.out.Close(); err != nil {
+			ch.logger.Warn("Failed to close output", "output", o.out.Name(), "error", err)
+		}
+	}
+	ch.outputs = nil
+	ch.outputsOpen = false
+}
+
+// outputStatuses returns the status of the plugin outputs
+func (ch *Channel) outputStatuses() []OutputStatus {
+	var statuses []OutputStatus
+	for _, o := range ch.outputList() {
+		if p, ok := o.out.(*pluginOutput); ok {
+			statuses = append(statuses, p.Status())
+		}
+	}
+	return statuses
+}
+
+// outputList returns the current outputs
+func (ch *Channel) outputList() []*channelOutput {
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	return slices.Clone(ch.outputs)
+}
+
+// hasStreamer reports whether an output wants segments while they are written
+func (ch *Channel) hasStreamer() bool {
+	for _, o := range ch.outputList() {
+		if _, ok := o.out.(output.Streamer); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeInit sends the writer's init segment to an output the first time it
+// is needed; the caller holds o.mu
+func (ch *Channel) writeInit(o *channelOutput) bool {
+	if o.init {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(ch.basePath, "init.mp4"))
+	if err != nil {
+		ch.logger.Warn("Output: read init segment", "output", o.out.Name(), "error", err)
+		return false
+	}
+	if err := o.out.WriteInit(o.ctx, &output.InitSegment{Data: data, Codec: o.codec}); err != nil {
+		ch.logger.Warn("Output failed", "output", o.out.Name(), "error", err)
+		return false
+	}
+	o.init = true
+	return true
+}
+
+// sendSegment passes a finished segment to the outputs that take whole
+// segments. Called from the segment writer's watcher.
+func (ch *Channel) sendSegment(info ffmpeg.SegmentInfo) {
+	var seg *output.Segment
+	for _, o := range ch.outputList() {
+		if _, ok := o.out.(output.Streamer); ok {
+			continue
+		}
+		if seg == nil {
+			data, err := os.ReadFile(info.Path)
+			if err != nil {
+				ch.logger.Warn("Output: read segment", "seq", info.Sequence, "error", err)
+				return
+			}
+			seg = &output.Segment{
+				Sequence:  info.Sequence,
+				Data:      data,
+				StartTime: info.StartTime.UnixMilli(),
+				Duration:  info.Duration.Seconds(),
+			}
+		}
+		ch.writeSegment(o, seg)
+	}
+}
+
+// writeSegment passes a finished segment to an output unless it has been
+// detached
+func (ch *Channel) writeSegment(o *channelOutput, seg *output.Segment) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.detached || !ch.writeInit(o) {
+		return
+	}
+	if err := o.out.WriteSegment(o.ctx, seg); err != nil && o.ctx.Err() == nil {
+		ch.logger.Warn("Output failed", "output", o.out.Name(), "seq", seg.Sequence, "error", err)
+	}
+}
+
+// segmentStarted tracks LL-HLS parts and streams to the streaming outputs
+// as FFmpeg writes a new segment. Called from the segment writer's watcher
+// when a new segment file appears.
+func (ch *Channel) segmentStarted(seq int, path string) {
+	if ch.parts != nil {
+		recovery.Go("ll-hls parts", func() {
+			if err := ch.parts.track(ch.ctx, seq, path); err != nil && ch.ctx.Err() == nil {
+				ch.logger.Warn("LL-HLS: tracking parts failed", "seq", seq, "error", err)
+			}
+		})
+	}
+	ch.streamSegment(seq, path)
+}
+
+// streamSegment streams a segment to the streaming outputs while FFmpeg
+// writes it
+func (ch *Channel) streamSegment(seq int, path string) {
+	for _, o := range ch.outputList() {
+		if streamer, ok := o.out.(output.Streamer); ok {
+			ch.startStream(o, streamer, seq, path)
+		}
+	}
+}
+
+// startStream streams a segment to an output unless it has been detached,
+// which waits for the stream to end
+func (ch *Channel) startStream(o *channelOutput, streamer output.Streamer, seq int, path string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.detached || !ch.writeInit(o) {
+		return
+	}
+	tail, err := ffmpeg.TailSegment(o.ctx, path, seq)
+	if err != nil {
+		ch.logger.Warn("Output failed", "output", o.out.Name(), "seq", seq, "error", err)
+		return
+	}
+	o.streams.Add(1)
+	go func() {
+		defer o.streams.Done()
+		defer recovery.Handle("output stream", nil)
+		defer tail.Close()
+		if err := streamer.StreamSegment(o.ctx, seq, tail); err != nil && o.ctx.Err() == nil {
+			ch.logger.Warn("Output failed", "output", o.out.Name(), "seq", seq, "error", err)
+		}
+	}()
+}
+
+// openPush opens the low-latency CMAF push output for this channel
+func (ch *Channel) openPush() (*output.CMAFPush, error) {
+	cfg := ch.cfg.CMAF
+
+	url := strings.TrimRight(cfg.URL, "/")
+	if ch.id != "" {
+		url += "/" + ch.id
+	}
+	var headers map[string]string
+	if cfg.APIKey != "" {
+		headers = map[string]string{"Authorization": "Bearer " + cfg.APIKey}
+	}
+
+	push := output.NewCMAFPush()
+	if err := push.Open(output.Config{
+		Path:       url,
+		Format:     "cmaf",
+		SegmentDur: ch.cfg.Buffer.SegmentSize.Seconds(),
+		Headers:    headers,
+	}); err != nil {
+		return nil, fmt.Errorf("open cmaf push: %w", err)
+	}
+	ch.logger.Info("CMAF push enabled", "url", url, "chunk", cfg.ChunkDuration)
+	return push, nil
+}
+
+// chunkDuration returns the CMAF chunk duration for the segment writer: the
+// shortest wanted by CMAF push, LL-HLS parts and WHEP (0 = one chunk per
+// segment)
+func (ch *Channel) chunkDuration() time.Duration {
+	var chunk time.Duration
+	want := func(d time.Duration) {
+		if chunk == 0 || d < chunk {
+			chunk = d
+		}
+	}
+	if ch.cfg.CMAF.Enabled {
+		want(ch.cfg.CMAF.ChunkDuration)
+	}
+	if ch.parts != nil {
+		want(ch.cfg.HLS.LowLatency.PartDuration)
+	}
+	if ch.whep != nil {
+		want(whepChunkDuration)
+	}
+	return chunk
+}
+
+// openWHEPOutput feeds this channel's WebRTC players from the new writer
+func (ch *Channel) openWHEPOutput(codec string) (*output.WHEPSend, error) {
+	if codec != "" && codec != "h264" {
+		return nil, fmt.Errorf("webrtc playback needs h264 video, channel encodes %s", codec)
+	}
+	out := output.NewWHEPSend(ch.ffmpeg, ch.whep)
+	if err := out.Open(output.Config{Path: ch.id, Format: "whep"}); err != nil {
+		return nil, err
+	}
+	ch.logger.Info("WHEP output enabled", "path", "/whep/"+ch.id)
+	return out, nil
+}
+
+// openNDIOutput creates the NDI source this channel's video is re-sent on
+func (ch *Channel) openNDIOutput(enc EncodeConfig) (*output.NDISend, error) {
+	cfg := ch.cfg.NDI
+	width, height, err := cfg.size()
+	if err != nil {
+		return nil, err
+	}
+
+	out := output.NewNDISend(ch.ffmpeg, output.NDIOptions{
+		Groups:    cfg.Groups,
+		Width:     width,
+		Height:    height,
+		Framerate: cfg.Framerate,
+		Audio:     enc.Audio.Codec != "none",
+	})
+	name := cfg.sourceName(ch.id)
+	if err := out.Open(output.Config{Path: name, Format: "ndi"}); err != nil {
+		return nil, err
+	}
+	ch.logger.Info("NDI output enabled", "name", name, "resolution", cfg.Resolution)
+	return out, nil
+}
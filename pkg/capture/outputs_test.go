@@ -0,0 +1,175 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// fakeOutput records what it is sent. With block set, WriteSegment waits
+// for its context to end; with release set, until it is closed.
+type fakeOutput struct {
+	block    bool
+	release  chan struct{}
+	entered  chan struct{} // Signalled when a write starts, if not already
+	openErrs int           // Opens that fail before one succeeds
+	panicAt  int           // Sequence whose write panics
+
+	mu       sync.Mutex
+	opens    int
+	inits    int
+	segments []int
+	writing  bool
+	closed   bool
+	lateCall bool // Written to after Close
+}
+
+func (f *fakeOutput) Name() string { return "fake" }
+func (f *fakeOutput) Type() string { return "fake" }
+
+func (f *fakeOutput) Open(cfg output.Config) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opens++
+	if f.opens <= f.openErrs {
+		return errors.New("unreachable")
+	}
+	f.closed = false
+	return nil
+}
+
+func (f *fakeOutput) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.writing {
+		f.lateCall = true
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *fakeOutput) WriteInit(ctx context.Context, init *output.InitSegment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lateCall = f.lateCall || f.closed
+	f.inits++
+	return nil
+}
+
+func (f *fakeOutput) WriteSegment(ctx context.Context, seg *output.Segment) error {
+	f.mu.Lock()
+	f.lateCall = f.lateCall || f.closed
+	f.writing = true
+	f.mu.Unlock()
+	if f.entered != nil {
+		select {
+		case f.entered <- struct{}{}:
+		default:
+		}
+	}
+	if f.block {
+		<-ctx.Done()
+	}
+	if f.release != nil {
+		<-f.release
+	}
+	if f.panicAt != 0 && seg.Sequence == f.panicAt {
+		f.mu.Lock()
+		f.writing = false
+		f.mu.Unlock()
+		panic("fake output panic")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writing = false
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	f.segments = append(f.segments, seg.Sequence)
+	return nil
+}
+
+func newOutputTestChannel(t *testing.T) *Channel {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "init.mp4"), []byte("init"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"segment_00001.m4s", "segment_00002.m4s"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("moof"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &Channel{ctx: ctx, basePath: dir, logger: logger}
+}
+
+func testSegmentInfo(ch *Channel, seq int) ffmpeg.SegmentInfo {
+	return ffmpeg.SegmentInfo{
+		Sequence: seq,
+		Path:     filepath.Join(ch.basePath, fmt.Sprintf("segment_%05d.m4s", seq)),
+		Duration: 2 * time.Second,
+	}
+}
+
+func TestOutputsFedAndClosed(t *testing.T) {
+	ch := newOutputTestChannel(t)
+	out := &fakeOutput{}
+	ch.outMu.Lock()
+	ch.addOutput(out)
+	ch.outMu.Unlock()
+
+	ch.sendSegment(testSegmentInfo(ch, 1))
+	ch.sendSegment(testSegmentInfo(ch, 2))
+	stale := ch.outputList()
+	ch.closeOutputs()
+
+	// The watcher may still hold the outputs of a stopped writer
+	ch.writeSegment(stale[0], &output.Segment{Sequence: 3})
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if out.inits != 1 || len(out.segments) != 2 || !out.closed {
+		t.Errorf("output got %d inits, segments %v, closed %v; want 1 init, [1 2], closed", out.inits, out.segments, out.closed)
+	}
+	if out.lateCall {
+		t.Error("output written after Close")
+	}
+}
+
+func TestCloseOutputsWaitsForWrite(t *testing.T) {
+	ch := newOutputTestChannel(t)
+	out := &fakeOutput{block: true, entered: make(chan struct{}, 1)}
+	ch.outMu.Lock()
+	ch.addOutput(out)
+	ch.outMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ch.sendSegment(testSegmentInfo(ch, 1))
+		close(done)
+	}()
+	<-out.entered
+
+	// Closing aborts the write in progress and closes the output after it
+	ch.closeOutputs()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write not aborted")
+	}
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if !out.closed || out.lateCall {
+		t.Errorf("closed %v, written during or after Close %v", out.closed, out.lateCall)
+	}
+}
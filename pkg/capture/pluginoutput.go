@@ -0,0 +1,225 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// pluginQueueSize is how many segments an output may fall behind before
+// segments are dropped for it
+const pluginQueueSize = 8
+
+// Variables so tests can shorten them
+var (
+	// pluginRetryDelay is how long an output that failed to open, or
+	// panicked, waits before it is reopened
+	pluginRetryDelay = 30 * time.Second
+
+	// pluginCloseTimeout bounds how long a stopping writer waits for an
+	// output to finish its queue
+	pluginCloseTimeout = 10 * time.Second
+)
+
+// OutputStatus describes one of a channel's configured outputs
+type OutputStatus struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Path        string `json:"path"`
+	Open        bool   `json:"open"`     // Opened and accepting segments
+	Segments    int    `json:"segments"` // Written since the writer started
+	Dropped     int    `json:"dropped"`  // Skipped while the output was behind or closed
+	Errors      int    `json:"errors"`   // Failed opens and writes
+	LastError   string `json:"last_error,omitempty"`
+	LastErrorAt int64  `json:"last_error_at,omitempty"`
+}
+
+// pluginOutput runs one configured output in its own goroutine with its own
+// queue, so a slow, failing or panicking output doesn't hold up capture or
+// the channel's other outputs. An output that fails to open, or panics, is
+// reopened after pluginRetryDelay.
+type pluginOutput struct {
+	cfg    OutputConfig
+	open   output.Config
+	ctx    context.Context
+	logger *slog.Logger
+	queue  chan *output.Segment
+	done   chan struct{}
+
+	// Owned by the run goroutine
+	out      output.Output // nil while not open
+	sentInit *output.InitSegment
+	failedAt time.Time
+
+	mu     sync.Mutex
+	init   *output.InitSegment
+	closed bool
+	status OutputStatus
+}
+
+// newPluginOutput starts running a configured output; it opens in the
+// background
+func newPluginOutput(ctx context.Context, logger *slog.Logger, cfg OutputConfig, open output.Config) *pluginOutput {
+	p := &pluginOutput{
+		cfg:    cfg,
+		open:   open,
+		ctx:    ctx,
+		logger: logger.With("output", cfg.Name, "type", cfg.Type),
+		queue:  make(chan *output.Segment, pluginQueueSize),
+		done:   make(chan struct{}),
+		status: OutputStatus{Name: cfg.Name, Type: cfg.Type, Path: open.Path},
+	}
+	recovery.Go("output "+cfg.Name, p.run)
+	return p
+}
+
+func (p *pluginOutput) Name() string { return p.cfg.Name }
+func (p *pluginOutput) Type() string { return p.cfg.Type }
+
+// Open is a no-op: the output is opened by its own goroutine
+func (p *pluginOutput) Open(cfg output.Config) error { return nil }
+
+// Close finishes the queued segments and closes the output, giving up
+// after pluginCloseTimeout
+func (p *pluginOutput) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(pluginCloseTimeout):
+		return fmt.Errorf("output %s: still writing after %s", p.cfg.Name, pluginCloseTimeout)
+	}
+}
+
+// WriteInit records the writer's init segment; it is sent to the output
+// before its next segment
+func (p *pluginOutput) WriteInit(ctx context.Context, init *output.InitSegment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.init = init
+	return nil
+}
+
+// WriteSegment queues a segment, dropping it if the output is behind
+func (p *pluginOutput) WriteSegment(ctx context.Context, seg *output.Segment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	select {
+	case p.queue <- seg:
+		return nil
+	default:
+		p.status.Dropped++
+		return fmt.Errorf("output behind, dropped segment %d", seg.Sequence)
+	}
+}
+
+// Status returns the output's status
+func (p *pluginOutput) Status() OutputStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// run opens the output and writes queued segments until the queue is closed
+func (p *pluginOutput) run() {
+	defer close(p.done)
+	p.protect(func() { p.reopen() })
+	for seg := range p.queue {
+		p.protect(func() { p.write(seg) })
+	}
+	p.protect(func() {
+		if p.out != nil {
+			if err := p.out.Close(); err != nil {
+				p.logger.Warn("Failed to close output", "error", err)
+			}
+		}
+	})
+}
+
+// protect calls fn, abandoning the output until it is reopened if it panics
+func (p *pluginOutput) protect(fn func()) {
+	defer recovery.Handle("output "+p.cfg.Name, func(err error) {
+		p.out = nil
+		p.fail(err)
+	})
+	fn()
+}
+
+// write sends a segment, preceded by the init segment when it changed
+func (p *pluginOutput) write(seg *output.Segment) {
+	if p.out == nil && !p.reopen() {
+		p.mu.Lock()
+		p.status.Dropped++
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Lock()
+	init := p.init
+	p.mu.Unlock()
+	if init == nil {
+		return // Sent before the writer's init segment was read
+	}
+	if init != p.sentInit {
+		if err := p.out.WriteInit(p.ctx, init); err != nil {
+			p.fail(err)
+			return
+		}
+		p.sentInit = init
+	}
+	if err := p.out.WriteSegment(p.ctx, seg); err != nil {
+		p.fail(err)
+		return
+	}
+	p.mu.Lock()
+	p.status.Segments++
+	p.mu.Unlock()
+}
+
+// reopen opens the output unless it failed within pluginRetryDelay,
+// reporting whether it is open
+func (p *pluginOutput) reopen() bool {
+	if time.Since(p.failedAt) < pluginRetryDelay {
+		return false
+	}
+	out, ok := output.Get(p.cfg.Type)
+	if !ok {
+		return false // Rejected by Validate
+	}
+	if err := out.Open(p.open); err != nil {
+		p.fail(err)
+		return false
+	}
+	p.out, p.sentInit = out, nil
+	p.mu.Lock()
+	p.status.Open = true
+	p.mu.Unlock()
+	p.logger.Info("Output enabled", "path", p.open.Path)
+	return true
+}
+
+// fail records an error; the output stays closed if it isn't open
+func (p *pluginOutput) fail(err error) {
+	if p.out == nil {
+		p.failedAt = time.Now()
+	}
+	p.logger.Warn("Output failed", "error", err)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status.Open = p.out != nil
+	p.status.Errors++
+	p.status.LastError = err.Error()
+	p.status.LastErrorAt = time.Now().UnixMilli()
+}
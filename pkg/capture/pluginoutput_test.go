@@ -0,0 +1,164 @@
+package capture
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// startPluginOutput runs fake as a configured output of its own type
+func startPluginOutput(t *testing.T, fake *fakeOutput) *pluginOutput {
+	t.Helper()
+	typ := "fake-" + t.Name()
+	output.Register(typ, func() output.Output { return fake })
+	t.Cleanup(func() { delete(output.Registry, typ) })
+	p := newPluginOutput(context.Background(), logger, OutputConfig{Name: "fake", Type: typ}, output.Config{Path: t.TempDir()})
+	p.WriteInit(context.Background(), &output.InitSegment{Data: []byte("init")})
+	return p
+}
+
+// shortenPluginDelays sets the retry delay and close timeout for a test
+func shortenPluginDelays(t *testing.T, retry, close time.Duration) {
+	retryDelay, closeTimeout := pluginRetryDelay, pluginCloseTimeout
+	pluginRetryDelay, pluginCloseTimeout = retry, close
+	t.Cleanup(func() { pluginRetryDelay, pluginCloseTimeout = retryDelay, closeTimeout })
+}
+
+// awaitStatus waits for the output's status to satisfy cond
+func awaitStatus(t *testing.T, p *pluginOutput, cond func(OutputStatus) bool) OutputStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status := p.Status()
+		if cond(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status %+v never reached", status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPluginOutputQueue(t *testing.T) {
+	fake := &fakeOutput{release: make(chan struct{}), entered: make(chan struct{}, 1)}
+	p := startPluginOutput(t, fake)
+	ctx := context.Background()
+
+	// A slow output falls behind by a queue's worth before segments drop
+	if err := p.WriteSegment(ctx, &output.Segment{Sequence: 1}); err != nil {
+		t.Fatalf("WriteSegment: %v", err)
+	}
+	<-fake.entered
+	for seq := 2; seq <= pluginQueueSize+1; seq++ {
+		if err := p.WriteSegment(ctx, &output.Segment{Sequence: seq}); err != nil {
+			t.Fatalf("WriteSegment %d: %v", seq, err)
+		}
+	}
+	if err := p.WriteSegment(ctx, &output.Segment{Sequence: pluginQueueSize + 2}); err == nil {
+		t.Error("expected an error for a segment past the queue")
+	}
+
+	close(fake.release)
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := p.WriteSegment(ctx, &output.Segment{Sequence: 99}); err != nil {
+		t.Errorf("WriteSegment after Close: %v", err)
+	}
+
+	status := p.Status()
+	if !status.Open || status.Segments != pluginQueueSize+1 || status.Dropped != 1 || status.Errors != 0 {
+		t.Errorf("status = %+v", status)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.inits != 1 || len(fake.segments) != pluginQueueSize+1 || !fake.closed || fake.lateCall {
+		t.Errorf("output got %d inits, segments %v, closed %v, late calls %v", fake.inits, fake.segments, fake.closed, fake.lateCall)
+	}
+}
+
+func TestPluginOutputRetry(t *testing.T) {
+	shortenPluginDelays(t, 50*time.Millisecond, time.Second)
+	fake := &fakeOutput{openErrs: 1}
+	p := startPluginOutput(t, fake)
+	ctx := context.Background()
+
+	status := awaitStatus(t, p, func(s OutputStatus) bool { return s.Errors == 1 })
+	if status.Open || status.LastError != "unreachable" {
+		t.Errorf("status after a failed open = %+v", status)
+	}
+
+	// Segments are dropped until the retry delay has passed
+	p.WriteSegment(ctx, &output.Segment{Sequence: 1})
+	awaitStatus(t, p, func(s OutputStatus) bool { return s.Dropped+s.Segments == 1 })
+	time.Sleep(pluginRetryDelay)
+	p.WriteSegment(ctx, &output.Segment{Sequence: 2})
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	status = p.Status()
+	if !status.Open || status.Errors != 1 {
+		t.Errorf("status after reopening = %+v", status)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.opens != 2 || fake.inits != 1 || !slices.Contains(fake.segments, 2) {
+		t.Errorf("output opened %d times, got %d inits and segments %v; want reopened with segment 2", fake.opens, fake.inits, fake.segments)
+	}
+}
+
+func TestPluginOutputPanic(t *testing.T) {
+	shortenPluginDelays(t, 50*time.Millisecond, time.Second)
+	fake := &fakeOutput{panicAt: 2}
+	p := startPluginOutput(t, fake)
+	ctx := context.Background()
+
+	p.WriteSegment(ctx, &output.Segment{Sequence: 1})
+	p.WriteSegment(ctx, &output.Segment{Sequence: 2})
+	status := awaitStatus(t, p, func(s OutputStatus) bool { return s.Errors == 1 })
+	if status.Open || !strings.Contains(status.LastError, "fake output panic") {
+		t.Errorf("status after a panic = %+v", status)
+	}
+
+	// Reopened after the retry delay, with the init segment sent again
+	time.Sleep(pluginRetryDelay)
+	p.WriteSegment(ctx, &output.Segment{Sequence: 3})
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.opens != 2 || fake.inits != 2 || !slices.Equal(fake.segments, []int{1, 3}) {
+		t.Errorf("output opened %d times, got %d inits and segments %v; want reopened with segment 3", fake.opens, fake.inits, fake.segments)
+	}
+	if status := p.Status(); !status.Open || status.Segments != 2 {
+		t.Errorf("status after reopening = %+v", status)
+	}
+}
+
+func TestPluginOutputCloseTimeout(t *testing.T) {
+	shortenPluginDelays(t, time.Second, 20*time.Millisecond)
+	fake := &fakeOutput{release: make(chan struct{}), entered: make(chan struct{}, 1)}
+	p := startPluginOutput(t, fake)
+
+	p.WriteSegment(context.Background(), &output.Segment{Sequence: 1})
+	<-fake.entered
+	if err := p.Close(); err == nil || !strings.Contains(err.Error(), "still writing") {
+		t.Errorf("Close of a stuck output = %v, want a timeout", err)
+	}
+
+	// The output is still closed once its write returns
+	close(fake.release)
+	<-p.done
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !fake.closed || fake.lateCall {
+		t.Errorf("closed %v, written during or after Close %v", fake.closed, fake.lateCall)
+	}
+}
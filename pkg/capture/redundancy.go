@@ -0,0 +1,279 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+var pairLog = logging.For("redundancy")
+
+// PendingClip is a clip the active agent has not delivered yet: a ghost clip
+// still open, or a generated clip not uploaded to the platform
+type PendingClip struct {
+	Channel   string `json:"channel"`
+	PlayID    string `json:"play_id"`
+	StartTime int64  `json:"start_time"`         // Unix ms
+	EndTime   int64  `json:"end_time,omitempty"` // Unix ms; 0 while the ghost clip is open
+}
+
+// RedundancyStatus is an agent's side of a redundant pair, served to the
+// peer as its heartbeat
+type RedundancyStatus struct {
+	Role         string        `json:"role"`
+	Active       bool          `json:"active"`
+	Since        time.Time     `json:"since"`
+	PeerURL      string        `json:"peer_url"`
+	PeerOnline   bool          `json:"peer_online"`
+	PeerLastSeen time.Time     `json:"peer_last_seen,omitzero"`
+	Pending      []PendingClip `json:"pending"`
+}
+
+// pairing runs one side of an active/passive pair. Both agents capture; only
+// the active one uploads clips and notifies the platform. The standby polls
+// the active and takes over when it stops answering, replaying clips the
+// active had not delivered from its own buffer.
+type pairing struct {
+	m      *Manager
+	cfg    RedundancyConfig
+	client *http.Client
+	active atomic.Bool
+
+	mu          sync.Mutex
+	since       time.Time
+	peerSeen    time.Time
+	peerOnline  bool
+	journal     map[string]*PendingClip // This agent's undelivered clips by channel/play ID
+	peerPending []PendingClip           // Last journal reported by the active peer
+}
+
+func newPairing(m *Manager, cfg RedundancyConfig) *pairing {
+	return &pairing{
+		m:       m,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Interval},
+		since:   time.Now(),
+		journal: make(map[string]*PendingClip),
+	}
+}
+
+// isActive reports whether this agent delivers clips
+func (p *pairing) isActive() bool {
+	return p.active.Load()
+}
+
+// status returns this side of the pair
+func (p *pairing) status() RedundancyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending := make([]PendingClip, 0, len(p.journal))
+	for _, c := range p.journal {
+		pending = append(pending, *c)
+	}
+	return RedundancyStatus{
+		Role:         p.cfg.Role,
+		Active:       p.isActive(),
+		Since:        p.since,
+		PeerURL:      p.cfg.PeerURL,
+		PeerOnline:   p.peerOnline,
+		PeerLastSeen: p.peerSeen,
+		Pending:      pending,
+	}
+}
+
+// Send implements events.Sink, keeping the journal of undelivered clips.
+// Clips are only journaled while active, and generated clips only when
+// there is a platform to upload to.
+func (p *pairing) Send(ctx context.Context, e events.Event) error {
+	playID, _ := e.Data["play_id"].(string)
+	if playID == "" {
+		return nil
+	}
+	if !p.isActive() && (e.Type == events.GhostStarted || e.Type == events.ClipGenerated) {
+		return nil
+	}
+	key := e.Channel + "/" + playID
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch e.Type {
+	case events.GhostStarted:
+		start, ok := e.Data["start_time"].(int64) // Set on resumed ghost clips
+		if !ok {
+			start = e.Time.UnixMilli()
+		}
+		p.journal[key] = &PendingClip{Channel: e.Channel, PlayID: playID, StartTime: start}
+	case events.GhostEnded:
+		delete(p.journal, key) // Pending again once generated
+	case events.ClipGenerated:
+		if p.m.platform == nil || !p.m.platform.IsConfigured() {
+			return nil
+		}
+		start, _ := e.Data["start_time"].(int64)
+		end, _ := e.Data["end_time"].(int64)
+		p.journal[key] = &PendingClip{Channel: e.Channel, PlayID: playID, StartTime: start, EndTime: end}
+	case events.ClipUploaded:
+		delete(p.journal, key)
+	}
+
+	// Clips older than the buffer can't be replayed
+	cutoff := time.Now().Add(-p.m.cfg.Buffer.Duration).UnixMilli()
+	for k, c := range p.journal {
+		if c.StartTime < cutoff {
+			delete(p.journal, k)
+		}
+	}
+	return nil
+}
+
+// run exchanges heartbeats with the peer until ctx is cancelled
+func (p *pairing) run(ctx context.Context) {
+	pairLog.Info("Redundant pairing started", "role", p.cfg.Role, "peer", p.cfg.PeerURL)
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		p.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check polls the peer once and changes role if needed:
+//   - either takes over when the peer has been silent for failover_after,
+//     so one missed heartbeat doesn't make both active
+//   - a primary takes over when the peer answers but is passive, e.g. at
+//     startup, and stays passive if the standby took over while it was down
+//   - if both are active after a partition heals, the standby steps down
+func (p *pairing) check(ctx context.Context) {
+	peer, err := p.fetchPeer(ctx)
+
+	p.mu.Lock()
+	now := time.Now()
+	if err == nil {
+		if !p.peerOnline {
+			pairLog.Info("Peer online", "peer", p.cfg.PeerURL, "active", peer.Active)
+		}
+		p.peerOnline, p.peerSeen = true, now
+		if peer.Active {
+			p.peerPending = peer.Pending
+		}
+	} else if p.peerOnline {
+		pairLog.Warn("Peer heartbeat failed", "peer", p.cfg.PeerURL, "error", err)
+		p.peerOnline = false
+	}
+	lastSeen := p.peerSeen
+	if lastSeen.IsZero() {
+		lastSeen = p.since
+	}
+	pending := p.peerPending
+	p.mu.Unlock()
+
+	active := p.isActive()
+	primary := p.cfg.Role == "primary"
+	switch {
+	case !active && err != nil && now.Sub(lastSeen) >= p.cfg.FailoverAfter:
+		p.promote(ctx, "peer unreachable", pending)
+	case !active && err == nil && !peer.Active && primary:
+		p.promote(ctx, "peer passive", nil)
+	case active && err == nil && peer.Active && !primary:
+		p.setActive(false, "primary is active")
+	}
+}
+
+// fetchPeer reads the peer's redundancy status
+func (p *pairing) fetchPeer(ctx context.Context) (*RedundancyStatus, error) {
+	url := strings.TrimSuffix(p.cfg.PeerURL, "/") + "/api/v1/redundancy"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.cfg.PeerAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.PeerAPIKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer status: %s", resp.Status)
+	}
+	var status RedundancyStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("decode peer status: %w", err)
+	}
+	return &status, nil
+}
+
+func (p *pairing) setActive(active bool, reason string) {
+	if p.active.Swap(active) == active {
+		return
+	}
+	p.mu.Lock()
+	p.since = time.Now()
+	p.mu.Unlock()
+	if active {
+		pairLog.Warn("Promoted to active", "role", p.cfg.Role, "reason", reason)
+	} else {
+		pairLog.Warn("Demoted to standby", "role", p.cfg.Role, "reason", reason)
+	}
+	p.m.events.Publish(events.Event{Type: events.StateChanged, Data: map[string]interface{}{
+		"redundancy_active": active,
+		"reason":            reason,
+	}})
+}
+
+// promote makes this agent active and replays the clips the old active had
+// not delivered: open ghost clips resume from their mark-in, generated
+// clips are cut again from this agent's buffer and uploaded
+func (p *pairing) promote(ctx context.Context, reason string, pending []PendingClip) {
+	p.setActive(true, reason)
+
+	p.mu.Lock()
+	p.peerPending = nil
+	p.mu.Unlock()
+
+	for _, c := range pending {
+		ch, ok := p.m.GetChannel(c.Channel)
+		if !ok {
+			pairLog.Warn("Cannot replay clip for unknown channel", "channel", c.Channel, "play_id", c.PlayID)
+			continue
+		}
+		channel := ch.(*Channel)
+		if c.EndTime == 0 {
+			if err := channel.resumeGhostClip(c.PlayID, time.UnixMilli(c.StartTime)); err != nil {
+				pairLog.Warn("Failed to resume ghost clip", "channel", c.Channel, "play_id", c.PlayID, "error", err)
+				continue
+			}
+			pairLog.Info("Resumed ghost clip from peer", "channel", c.Channel, "play_id", c.PlayID)
+			continue
+		}
+		if _, err := channel.GenerateClip(ctx, c.StartTime, c.EndTime, c.PlayID, api.ClipOptions{}); err != nil {
+			pairLog.Warn("Failed to replay clip", "channel", c.Channel, "play_id", c.PlayID, "error", err)
+			continue
+		}
+		pairLog.Info("Replayed clip from peer", "channel", c.Channel, "play_id", c.PlayID)
+	}
+}
+
+// RedundancyStatus returns this agent's side of its redundant pair, or nil
+// when redundancy is disabled (implements api.ChannelManager)
+func (m *Manager) RedundancyStatus() interface{} {
+	if m.pairing == nil {
+		return nil
+	}
+	return m.pairing.status()
+}
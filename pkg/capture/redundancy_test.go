@@ -0,0 +1,102 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+// fakePeer serves a redundancy status; a nil status means unreachable
+func fakePeer(t *testing.T, status *RedundancyStatus) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(status)
+	}))
+	if status == nil {
+		srv.Close()
+	} else {
+		t.Cleanup(srv.Close)
+	}
+	return srv.URL
+}
+
+func newTestPairing(role, peerURL string) *pairing {
+	m := &Manager{cfg: &Config{}}
+	m.cfg.Buffer.Duration = time.Hour
+	return newPairing(m, RedundancyConfig{
+		Role:          role,
+		PeerURL:       peerURL,
+		Interval:      time.Second,
+		FailoverAfter: 6 * time.Second,
+	})
+}
+
+func TestPairingCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		active     bool
+		peer       *RedundancyStatus
+		silentFor  time.Duration
+		wantActive bool
+	}{
+		{"standby waits out a missed heartbeat", "standby", false, nil, 2 * time.Second, false},
+		{"standby takes over from a silent peer", "standby", false, nil, 10 * time.Second, true},
+		{"primary waits out a missed heartbeat", "primary", false, nil, 2 * time.Second, false},
+		{"primary takes over from a silent peer", "primary", false, nil, 10 * time.Second, true},
+		{"primary takes over from a passive peer", "primary", false, &RedundancyStatus{Role: "standby"}, 0, true},
+		{"primary stays passive behind an active standby", "primary", false, &RedundancyStatus{Role: "standby", Active: true}, 0, false},
+		{"standby stays passive behind an active primary", "standby", false, &RedundancyStatus{Role: "primary", Active: true}, 0, false},
+		{"standby steps down when both are active", "standby", true, &RedundancyStatus{Role: "primary", Active: true}, 0, false},
+		{"primary stays active when both are", "primary", true, &RedundancyStatus{Role: "standby", Active: true}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPairing(tt.role, fakePeer(t, tt.peer))
+			p.active.Store(tt.active)
+			p.since = time.Now().Add(-tt.silentFor)
+			p.check(context.Background())
+			if got := p.isActive(); got != tt.wantActive {
+				t.Errorf("active = %v, want %v", got, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestPairingSend(t *testing.T) {
+	ctx := context.Background()
+	ghost := func(typ events.Type, playID string) events.Event {
+		return events.Event{Type: typ, Channel: "cam1", Time: time.Now(), Data: map[string]interface{}{"play_id": playID}}
+	}
+
+	// A standby journals nothing of its own
+	p := newTestPairing("standby", "")
+	p.Send(ctx, ghost(events.GhostStarted, "p1"))
+	if n := len(p.status().Pending); n != 0 {
+		t.Errorf("standby journaled %d clips", n)
+	}
+
+	p.active.Store(true)
+	p.Send(ctx, ghost(events.GhostStarted, "p1"))
+	p.Send(ctx, ghost(events.GhostStarted, "p2"))
+	p.Send(ctx, ghost(events.GhostEnded, "p1"))
+	// Without a platform, generated clips are delivered when written
+	p.Send(ctx, ghost(events.ClipGenerated, "p1"))
+	pending := p.status().Pending
+	if len(pending) != 1 || pending[0].PlayID != "p2" || pending[0].EndTime != 0 {
+		t.Errorf("pending = %+v, want the open ghost clip p2", pending)
+	}
+
+	// Clips older than the buffer can't be replayed
+	old := ghost(events.GhostStarted, "p3")
+	old.Data["start_time"] = time.Now().Add(-2 * time.Hour).UnixMilli()
+	p.Send(ctx, old)
+	if n := len(p.status().Pending); n != 1 {
+		t.Errorf("%d clips pending, want the clip older than the buffer dropped", n)
+	}
+}
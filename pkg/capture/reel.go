@@ -0,0 +1,185 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/platform"
+	"github.com/video-system/go-video-capture/pkg/ringbuffer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ReelResult describes a rendered highlight reel
+type ReelResult struct {
+	ReelID        string  `json:"reel_id"`
+	FilePath      string  `json:"file_path"`
+	Duration      float64 `json:"duration"`
+	FileSizeBytes int64   `json:"file_size_bytes"`
+	ClipCount     int     `json:"clip_count"`
+	ChannelID     string  `json:"channel_id"` // Channel the reel is filed under
+	SessionID     string  `json:"session_id"`
+}
+
+// reelPart is a resolved reel item ready to render
+type reelPart struct {
+	ch   *Channel
+	path string
+	info *ffmpeg.VideoInfo
+}
+
+// BuildReel renders a highlight reel from existing clips and buffer time
+// ranges, across channels, and uploads it like a clip (implements
+// api.ChannelManager). The reel takes the size and frame rate of its first
+// part and is filed under the first part's channel.
+func (m *Manager) BuildReel(ctx context.Context, req api.ReelRequest) (_ interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "reel",
+		attribute.String("reel_id", req.ReelID),
+		attribute.Int("items", len(req.Items)))
+	defer func() { tracing.End(span, err) }()
+
+	var temps []string
+	defer func() {
+		for _, path := range temps {
+			os.Remove(path)
+		}
+	}()
+
+	parts := make([]reelPart, 0, len(req.Items))
+	for i, item := range req.Items {
+		part, temp, err := m.resolveReelItem(ctx, req.ReelID, i, item)
+		if temp {
+			temps = append(temps, part.path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("items[%d]: %w", i, err)
+		}
+		parts = append(parts, part)
+	}
+
+	first := parts[0]
+	clips := make([]ffmpeg.ReelClip, len(parts))
+	for i, p := range parts {
+		clips[i] = ffmpeg.ReelClip{Path: p.path, Duration: p.info.Duration, HasAudio: p.info.HasAudio}
+	}
+	enc := first.ch.encodeConfig()
+	bitrate := enc.ClipBitrate
+	if bitrate == 0 {
+		bitrate = enc.Bitrate
+	}
+	cfg := ffmpeg.ReelConfig{
+		Width:              first.info.Width,
+		Height:             first.info.Height,
+		FPS:                first.info.Framerate,
+		Transition:         req.Transition,
+		TransitionDuration: req.TransitionDuration,
+		Title:              req.Title,
+		TitleDuration:      req.TitleDuration,
+		Bitrate:            bitrate,
+	}
+
+	dir := filepath.Join(m.basePath, "reels")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create reels dir: %w", err)
+	}
+	outputPath := filepath.Join(dir, req.ReelID+".mp4")
+	_, renderSpan := tracing.Start(ctx, "ffmpeg.reel", attribute.String("transition", req.Transition))
+	err = m.ffmpeg.RenderReel(ctx, clips, cfg, outputPath)
+	tracing.End(renderSpan, err)
+	if err != nil {
+		return nil, fmt.Errorf("render reel: %w", err)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("stat reel: %w", err)
+	}
+	first.ch.mu.RLock()
+	sessionID := first.ch.sessionID
+	first.ch.mu.RUnlock()
+	result := &ReelResult{
+		ReelID:        req.ReelID,
+		FilePath:      outputPath,
+		FileSizeBytes: info.Size(),
+		ClipCount:     len(parts),
+		ChannelID:     first.ch.id,
+		SessionID:     sessionID,
+	}
+	if probe, err := m.ffmpeg.GetVideoInfo(ctx, outputPath); err == nil {
+		result.Duration = probe.Duration
+	}
+
+	first.ch.publish(events.ClipGenerated, map[string]interface{}{
+		"play_id":    req.ReelID,
+		"file_path":  outputPath,
+		"duration":   result.Duration,
+		"size_bytes": result.FileSizeBytes,
+		"reel":       true,
+		"clips":      len(parts),
+	})
+	logger.Info("Highlight reel rendered", "reel_id", req.ReelID, "clips", len(parts), "path", outputPath)
+
+	if first.ch.uploadsEnabled() {
+		uploadCtx, cancel := context.WithTimeout(trace.ContextWithSpan(context.Background(), span), 5*time.Minute)
+		go func() {
+			defer recovery.Handle("reel upload", nil)
+			defer cancel()
+			first.ch.uploadClipToPlatform(uploadCtx, outputPath, nil, platform.ClipMetadata{
+				SessionID:       sessionID,
+				ChannelID:       first.ch.id,
+				PlayID:          req.ReelID,
+				Title:           req.Title,
+				DurationSeconds: result.Duration,
+				FileSizeBytes:   result.FileSizeBytes,
+				Tags:            req.Tags,
+			})
+		}()
+	}
+
+	return result, nil
+}
+
+// resolveReelItem finds the clip file for an item, cutting one from the
+// buffer for time ranges. temp reports whether the file should be removed
+// after rendering.
+func (m *Manager) resolveReelItem(ctx context.Context, reelID string, i int, item api.ReelItem) (part reelPart, temp bool, err error) {
+	var ci api.ChannelInterface
+	var ok bool
+	if item.ChannelID == "" {
+		ci, ok = m.GetDefaultChannel()
+	} else {
+		ci, ok = m.GetChannel(item.ChannelID)
+	}
+	if !ok {
+		return part, false, fmt.Errorf("channel %q not found", item.ChannelID)
+	}
+	part.ch = ci.(*Channel)
+
+	if item.ClipID != "" {
+		part.path = part.ch.buffer.ClipPath(item.ClipID)
+		if _, err := os.Stat(part.path); err != nil {
+			return part, false, fmt.Errorf("clip %s: %w", item.ClipID, err)
+		}
+	} else {
+		playID := fmt.Sprintf("%s_part%d", reelID, i)
+		clip, err := part.ch.buffer.GenerateClip(ctx, item.StartTime, item.EndTime, playID, ringbuffer.ClipOptions{NoVariants: true})
+		if err != nil {
+			return part, false, err
+		}
+		part.path, temp = clip.FilePath, true
+	}
+
+	part.info, err = m.ffmpeg.GetVideoInfo(ctx, part.path)
+	if err != nil {
+		return part, temp, fmt.Errorf("probe %s: %w", part.path, err)
+	}
+	return part, temp, nil
+}
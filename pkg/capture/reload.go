@@ -0,0 +1,160 @@
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ReloadResult lists the channels a config reload touched
+type ReloadResult struct {
+	Added     []string          `json:"added,omitempty"`
+	Removed   []string          `json:"removed,omitempty"`
+	Restarted []string          `json:"restarted,omitempty"`
+	Unchanged []string          `json:"unchanged,omitempty"`
+	Failed    map[string]string `json:"failed,omitempty"`   // Channels that could not be created or started
+	Reverted  []string          `json:"reverted,omitempty"` // Failed channels running their previous config again
+
+	// Settings outside the channels changed; they apply on the next agent
+	// restart
+	RestartRequired bool `json:"restart_required,omitempty"`
+}
+
+// ReloadConfig re-reads the config file and applies channel changes: added
+// channels start, removed ones stop, and channels whose input, buffer or
+// encode settings changed restart. Untouched channels keep capturing. A
+// changed channel shares its buffer and device with its replacement, so it
+// stops first; if the new config fails, the previous one is restored.
+// (implements api.ChannelManager)
+func (m *Manager) ReloadConfig() (interface{}, error) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	if m.cfg.path == "" {
+		return nil, errors.New("config was not loaded from a file")
+	}
+	m.mu.RLock()
+	ctx := m.ctx
+	sessionID := m.sessionID
+	m.mu.RUnlock()
+	if ctx != nil && ctx.Err() != nil {
+		return nil, errors.New("agent is stopping")
+	}
+
+	cfg, err := LoadConfig(m.cfg.path)
+	if err != nil {
+		return nil, fmt.Errorf("reload config: %w", err)
+	}
+	if cfg.Buffer.Path != m.cfg.Buffer.Path {
+		return nil, fmt.Errorf("buffer.path changed from %s to %s; restart the agent instead", m.cfg.Buffer.Path, cfg.Buffer.Path)
+	}
+
+	result := &ReloadResult{RestartRequired: !sharedConfigEqual(m.cfg, cfg)}
+	next := channelConfigs(cfg)
+	for _, id := range slices.Sorted(maps.Keys(m.channelCfgs)) {
+		chCfg, ok := next[id]
+		switch {
+		case !ok:
+			m.removeChannel(id)
+			result.Removed = append(result.Removed, id)
+		case reflect.DeepEqual(chCfg, m.channelCfgs[id]):
+			result.Unchanged = append(result.Unchanged, id)
+		default:
+			prev := m.channelCfgs[id]
+			m.removeChannel(id)
+			if err := m.addChannel(chCfg, sessionID); err != nil {
+				result.fail(id, err)
+				if err := m.addChannel(prev, sessionID); err != nil {
+					logger.Warn("Failed to restore channel config", "channel", id, "error", err)
+					continue
+				}
+				result.Reverted = append(result.Reverted, id)
+				continue
+			}
+			result.Restarted = append(result.Restarted, id)
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(next)) {
+		if _, ok := m.channelCfgs[id]; ok {
+			continue
+		}
+		if err := m.addChannel(next[id], sessionID); err != nil {
+			result.fail(id, err)
+			continue
+		}
+		result.Added = append(result.Added, id)
+	}
+
+	logger.Info("Config reloaded", "path", m.cfg.path, "added", result.Added, "removed", result.Removed,
+		"restarted", result.Restarted, "failed", len(result.Failed), "reverted", result.Reverted)
+	if result.RestartRequired {
+		logger.Warn("Config changes outside channels need an agent restart to apply")
+	}
+	return result, nil
+}
+
+// removeChannel stops a channel and forgets it
+func (m *Manager) removeChannel(id string) {
+	m.mu.Lock()
+	ch := m.channels[id]
+	delete(m.channels, id)
+	m.mu.Unlock()
+	m.srtUnroute(m.channelCfgs[id])
+	delete(m.channelCfgs, id)
+	if ch != nil {
+		ch.Stop()
+		logger.Info("Channel removed", "channel", id)
+	}
+}
+
+// addChannel creates a channel and starts it if the manager is running
+func (m *Manager) addChannel(chCfg ChannelConfig, sessionID string) error {
+	ch, err := m.newChannel(chCfg, sessionID)
+	if err != nil {
+		return err
+	}
+	m.mu.RLock()
+	ctx := m.ctx
+	m.mu.RUnlock()
+	if ctx != nil {
+		if err := ch.Start(ctx); err != nil {
+			ch.Stop()
+			m.srtUnroute(chCfg)
+			return fmt.Errorf("start channel %s: %w", chCfg.ID, err)
+		}
+	}
+	m.mu.Lock()
+	m.channels[chCfg.ID] = ch
+	m.mu.Unlock()
+	m.channelCfgs[chCfg.ID] = chCfg
+	logger.Info("Channel configured", "channel", chCfg.ID)
+	return nil
+}
+
+func (r *ReloadResult) fail(id string, err error) {
+	logger.Warn("Failed to apply channel config", "channel", id, "error", err)
+	if r.Failed == nil {
+		r.Failed = make(map[string]string)
+	}
+	r.Failed[id] = err.Error()
+}
+
+// sharedConfigEqual reports whether two configs match outside the
+// per-channel settings that a reload applies
+func sharedConfigEqual(a, b *Config) bool {
+	strip := func(cfg Config) Config {
+		cfg.Channels = nil
+		cfg.Input, cfg.Encode, cfg.Restream = InputConfig{}, EncodeConfig{}, nil
+		cfg.HLS, cfg.CMAF, cfg.NDI, cfg.WHEP = HLSConfig{}, CMAFConfig{}, NDIOutputConfig{}, WHEPConfig{}
+		cfg.Outputs = nil
+		cfg.Session.ChannelID = ""
+		cfg.path = ""
+		if len(a.Channels) == 0 && len(b.Channels) == 0 {
+			cfg.Buffer = BufferConfig{} // Single-channel buffer settings
+		}
+		return cfg
+	}
+	return reflect.DeepEqual(strip(*a), strip(*b))
+}
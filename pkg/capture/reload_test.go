@@ -0,0 +1,155 @@
+package capture
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// reloadConfigYAML is a two-channel config; codec sets cam2's clip codec
+func reloadConfigYAML(buffer, cam2ClipCodec string) string {
+	return fmt.Sprintf(`buffer:
+  path: %s
+encode:
+  codec: h264
+channels:
+  - id: cam1
+    input:
+      type: test
+  - id: cam2
+    input:
+      type: test
+    encode:
+      clip_codec: %s
+`, buffer, cam2ClipCodec)
+}
+
+// newReloadTestManager loads a config file into a manager that hasn't
+// started, so reloads create channels without capturing
+func newReloadTestManager(t *testing.T, yaml string) (*Manager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "capture.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	m := &Manager{cfg: cfg, channels: make(map[string]*Channel), channelCfgs: channelConfigs(cfg)}
+	for id, chCfg := range m.channelCfgs {
+		ch, err := m.newChannel(chCfg, "")
+		if err != nil {
+			t.Fatalf("newChannel %s: %v", id, err)
+		}
+		m.channels[id] = ch
+	}
+	return m, path
+}
+
+func TestReloadConfig(t *testing.T) {
+	buffer := t.TempDir()
+	m, path := newReloadTestManager(t, reloadConfigYAML(buffer, "copy"))
+	reload := func(yaml string) *ReloadResult {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res, err := m.ReloadConfig()
+		if err != nil {
+			t.Fatalf("ReloadConfig: %v", err)
+		}
+		return res.(*ReloadResult)
+	}
+
+	res := reload(reloadConfigYAML(buffer, "h264"))
+	if !slices.Equal(res.Restarted, []string{"cam2"}) || !slices.Equal(res.Unchanged, []string{"cam1"}) {
+		t.Errorf("changed clip codec: %+v, want cam2 restarted and cam1 unchanged", res)
+	}
+	if res.RestartRequired {
+		t.Error("a channel change shouldn't need an agent restart")
+	}
+	if got := m.channel("cam2").cfg.Encode.ClipCodec; got != "h264" {
+		t.Errorf("cam2 clip codec = %q, want h264", got)
+	}
+
+	// A config the channel can't be created with keeps the running one
+	old := m.channel("cam2")
+	res = reload(reloadConfigYAML(buffer, "bogus"))
+	if res.Failed["cam2"] == "" || !slices.Equal(res.Reverted, []string{"cam2"}) || len(res.Restarted) != 0 {
+		t.Errorf("invalid clip codec: %+v, want cam2 failed and reverted", res)
+	}
+	ch := m.channel("cam2")
+	if ch == nil || ch == old {
+		t.Fatal("cam2 wasn't recreated with its previous config")
+	}
+	if got := ch.cfg.Encode.ClipCodec; got != "h264" {
+		t.Errorf("reverted cam2 clip codec = %q, want h264", got)
+	}
+	if got := m.channelCfgs["cam2"].Encode.ClipCodec; got != "h264" {
+		t.Errorf("cam2 config in effect has clip codec %q, want h264", got)
+	}
+
+	res = reload(fmt.Sprintf("buffer:\n  path: %s\napi:\n  port: 9090\nchannels:\n  - id: cam3\n    input:\n      type: test\n", buffer))
+	if !slices.Equal(res.Removed, []string{"cam1", "cam2"}) || !slices.Equal(res.Added, []string{"cam3"}) {
+		t.Errorf("replaced channels: %+v, want cam1 and cam2 removed and cam3 added", res)
+	}
+	if !res.RestartRequired {
+		t.Error("a changed API port should need an agent restart")
+	}
+	if m.channel("cam1") != nil || m.channel("cam3") == nil {
+		t.Error("channels not replaced")
+	}
+
+	if _, err := m.ReloadConfig(); err != nil {
+		t.Errorf("reload of an unchanged file: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("buffer:\n  path: %s\n", t.TempDir())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ReloadConfig(); err == nil {
+		t.Error("expected an error when buffer.path changes")
+	}
+}
+
+func TestSharedConfigEqual(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Buffer:   BufferConfig{Path: "/var/capture", Duration: 30},
+			Channels: []ChannelConfig{{ID: "cam1"}},
+		}
+	}
+	tests := []struct {
+		name   string
+		change func(*Config)
+		equal  bool
+	}{
+		{"same", func(*Config) {}, true},
+		{"channels", func(c *Config) { c.Channels = append(c.Channels, ChannelConfig{ID: "cam2"}) }, true},
+		{"shared encode", func(c *Config) { c.Encode.Codec = "hevc" }, true},
+		{"hls", func(c *Config) { c.HLS.Window = 10 }, true},
+		{"outputs", func(c *Config) { c.Outputs = []OutputConfig{{Type: "hls"}} }, true},
+		{"file path", func(c *Config) { c.path = "other.yaml" }, true},
+		{"api", func(c *Config) { c.API.Port = 9090 }, false},
+		{"multi-channel buffer", func(c *Config) { c.Buffer.Duration = 60 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := base(), base()
+			tt.change(b)
+			if got := sharedConfigEqual(a, b); got != tt.equal {
+				t.Errorf("sharedConfigEqual = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+
+	// Single-channel buffer settings are the channel's, which a reload applies
+	a, b := base(), base()
+	a.Channels, b.Channels = nil, nil
+	b.Buffer.Duration = 60
+	if !sharedConfigEqual(a, b) {
+		t.Error("single-channel buffer change should be applied by reload")
+	}
+}
@@ -0,0 +1,387 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/fmp4"
+	"github.com/video-system/go-video-capture/internal/h26x"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+// segmentWriter writes a channel's segments: FFmpeg's segment writer, or
+// the remuxer for inputs that deliver compressed packets
+type segmentWriter interface {
+	OnSegment(fn func(ffmpeg.SegmentInfo))
+	OnSegmentStart(fn func(seq int, path string))
+	OnError(fn func(error))
+	Start(ctx context.Context) error
+	Stop() error
+	Abort(err error)
+	Wait() error
+	Exited() <-chan struct{}
+	Stats() ffmpeg.EncoderStats
+	StderrTail() []string
+	GetError() error
+}
+
+// remuxTimescale is the timescale of packet timestamps and of the track
+const remuxTimescale = 90000
+
+// remuxWriter muxes the packets of a compressed input into CMAF segments
+// in-process, laid out as FFmpeg's segment writer lays them out: init.mp4
+// and segment_NNNNN.m4s in the output directory, each segment starting on
+// a keyframe once the last has reached the segment duration, and written
+// chunk by chunk when a chunk duration is set. The video is not re-encoded
+// and there is no audio.
+type remuxWriter struct {
+	src        input.PacketSource
+	outputPath string
+	segmentDur uint64 // In ticks
+	chunkDur   uint64 // In ticks (0 = one chunk per segment)
+
+	onSegment      func(ffmpeg.SegmentInfo)
+	onSegmentStart func(seq int, path string)
+	onError        func(error)
+
+	cancel  context.CancelCauseFunc
+	exited  chan struct{}
+	exitErr error
+
+	errMu   sync.RWMutex
+	lastErr error
+
+	statsMu sync.RWMutex
+	stats   ffmpeg.EncoderStats
+
+	// Segment being written; only the run goroutine touches these
+	seq        int
+	file       *os.File
+	segStart   time.Time
+	segTicks   uint64
+	segFrames  int
+	segBytes   int
+	chunk      []fmp4.Sample
+	chunkTicks uint64
+	chunkBase  uint64
+	decodeTime uint64 // Ticks of all samples so far
+	fragments  uint32
+}
+
+// newRemuxWriter creates a remuxer of src's packets into outputDir
+func newRemuxWriter(src input.PacketSource, outputDir string, segment, chunk time.Duration) *remuxWriter {
+	if segment <= 0 {
+		segment = 2 * time.Second
+	}
+	return &remuxWriter{
+		src:        src,
+		outputPath: outputDir,
+		segmentDur: uint64(segment.Seconds() * remuxTimescale),
+		chunkDur:   uint64(chunk.Seconds() * remuxTimescale),
+	}
+}
+
+// OnSegment sets a callback for when segments are complete
+func (w *remuxWriter) OnSegment(fn func(ffmpeg.SegmentInfo)) { w.onSegment = fn }
+
+// OnSegmentStart sets a callback for when a segment file is opened
+func (w *remuxWriter) OnSegmentStart(fn func(seq int, path string)) { w.onSegmentStart = fn }
+
+// OnError sets a callback for the error remuxing stops on
+func (w *remuxWriter) OnError(fn func(error)) { w.onError = fn }
+
+// Start writes the init segment and starts muxing packets
+func (w *remuxWriter) Start(ctx context.Context) error {
+	if err := os.MkdirAll(w.outputPath, 0755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+	s := w.src.Stream()
+	init := fmp4.InitSegment(fmp4.VideoTrack{
+		Codec: s.Codec, Width: s.Width, Height: s.Height, Timescale: remuxTimescale,
+		VPS: s.VPS, SPS: s.SPS, PPS: s.PPS,
+	})
+	if err := writeFileAtomic(filepath.Join(w.outputPath, "init.mp4"), init); err != nil {
+		return fmt.Errorf("write init segment: %w", err)
+	}
+	w.seq = ffmpeg.NextSegment(w.outputPath)
+
+	ctx, w.cancel = context.WithCancelCause(ctx)
+	w.exited = make(chan struct{})
+	recovery.Go("remux", func() {
+		defer close(w.exited)
+		w.exitErr = w.run(ctx)
+		if w.exitErr != nil {
+			w.setError(w.exitErr)
+			if w.onError != nil {
+				w.onError(w.exitErr)
+			}
+		}
+	})
+	return nil
+}
+
+// Stop ends muxing, finishing the segment being written
+func (w *remuxWriter) Stop() error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel(nil)
+	select {
+	case <-w.exited:
+	case <-time.After(5 * time.Second):
+	}
+	return nil
+}
+
+// Abort stops muxing as failed with err, e.g. when the input stalled
+func (w *remuxWriter) Abort(err error) {
+	if w.cancel != nil {
+		w.cancel(err)
+	}
+}
+
+// Wait waits for muxing to end and returns why it did, nil when stopped
+func (w *remuxWriter) Wait() error {
+	if w.exited == nil {
+		return nil
+	}
+	<-w.exited
+	return w.exitErr
+}
+
+// Exited returns a channel that is closed when muxing has ended
+func (w *remuxWriter) Exited() <-chan struct{} {
+	return w.exited
+}
+
+// Stats returns frame and bitrate counters as of the last segment
+func (w *remuxWriter) Stats() ffmpeg.EncoderStats {
+	w.statsMu.RLock()
+	defer w.statsMu.RUnlock()
+	return w.stats
+}
+
+// StderrTail returns nothing: there is no FFmpeg process
+func (w *remuxWriter) StderrTail() []string {
+	return nil
+}
+
+// GetError returns the error muxing stopped on
+func (w *remuxWriter) GetError() error {
+	w.errMu.RLock()
+	defer w.errMu.RUnlock()
+	return w.lastErr
+}
+
+func (w *remuxWriter) setError(err error) {
+	w.errMu.Lock()
+	w.lastErr = err
+	w.errMu.Unlock()
+}
+
+// run muxes packets until the input fails or the writer stops. A packet is
+// written once the next arrives, as that gives its duration.
+func (w *remuxWriter) run(ctx context.Context) error {
+	stream := w.src.Stream()
+	var prev *input.Packet
+	var lastDur uint64 = remuxTimescale / 30
+	for {
+		pkt, err := w.src.ReadPacket(ctx)
+		if err != nil {
+			if prev != nil {
+				w.add(prev, lastDur)
+			}
+			w.finish()
+			if ctx.Err() != nil {
+				if cause := context.Cause(ctx); !errors.Is(cause, context.Canceled) {
+					return cause
+				}
+				return nil
+			}
+			return fmt.Errorf("read packet: %w", err)
+		}
+		if pkt.Keyframe {
+			if _, sps, _ := h26x.ParamSets(stream.Codec, pkt.NALUs); sps != nil && !bytes.Equal(sps, stream.SPS) {
+				w.finish()
+				return fmt.Errorf("stream parameters changed")
+			}
+		}
+		if prev != nil {
+			// Keep the previous duration across timestamp jumps
+			if d := pkt.DTS - prev.DTS; d > 0 && d < remuxTimescale {
+				lastDur = uint64(d)
+			}
+			if err := w.add(prev, lastDur); err != nil {
+				return err
+			}
+		}
+		prev = pkt
+	}
+}
+
+// add writes one access unit, cutting a new segment on a keyframe once the
+// current one is long enough
+func (w *remuxWriter) add(pkt *input.Packet, dur uint64) error {
+	if pkt.Keyframe && (w.file == nil || w.segTicks >= w.segmentDur) {
+		if err := w.cut(pkt); err != nil {
+			return err
+		}
+	}
+	if w.file == nil {
+		return nil // Not started on a keyframe yet
+	}
+
+	// Access unit delimiters have no place in MP4 samples
+	codec := w.src.Stream().Codec
+	aud := h26x.H264NALAUD
+	if codec == h26x.H265 {
+		aud = h26x.H265NALAUD
+	}
+	nalus := make([][]byte, 0, len(pkt.NALUs))
+	for _, n := range pkt.NALUs {
+		if h26x.NALType(codec, n) != aud {
+			nalus = append(nalus, n)
+		}
+	}
+	data := h26x.AVCC(nalus)
+	if len(w.chunk) == 0 {
+		w.chunkBase = w.decodeTime
+	}
+	w.chunk = append(w.chunk, fmp4.Sample{
+		Data:              data,
+		Duration:          uint32(dur),
+		CompositionOffset: int32(pkt.PTS - pkt.DTS),
+		Keyframe:          pkt.Keyframe,
+	})
+	w.chunkTicks += dur
+	w.segTicks += dur
+	w.decodeTime += dur
+	w.segFrames++
+	w.segBytes += len(data)
+	if w.chunkDur > 0 && w.chunkTicks >= w.chunkDur {
+		return w.flush()
+	}
+	return nil
+}
+
+// cut finishes the current segment and opens the next, starting with pkt.
+// The next file exists before the finished one is reported, which is how
+// tails of the finished one know it's complete.
+func (w *remuxWriter) cut(pkt *input.Packet) error {
+	prev := w.finished()
+	if err := w.closeSegment(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(w.outputPath, fmt.Sprintf("segment_%05d.m4s", w.seq))
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create segment: %w", err)
+	}
+	if _, err := f.Write(fmp4.SegmentType()); err != nil {
+		f.Close()
+		return fmt.Errorf("write segment: %w", err)
+	}
+	w.file = f
+	w.segStart = time.Unix(0, pkt.Timestamp)
+	w.segTicks, w.segFrames, w.segBytes = 0, 0, 0
+	if w.onSegmentStart != nil {
+		w.onSegmentStart(w.seq, path)
+	}
+	w.seq++
+
+	if prev != nil {
+		w.report(*prev)
+	}
+	return nil
+}
+
+// finish closes and reports the segment being written, at the end of muxing
+func (w *remuxWriter) finish() error {
+	prev := w.finished()
+	if err := w.closeSegment(); err != nil {
+		return err
+	}
+	if prev != nil {
+		w.report(*prev)
+	}
+	return nil
+}
+
+// finished describes the segment being written, nil when there's none
+func (w *remuxWriter) finished() *ffmpeg.SegmentInfo {
+	if w.file == nil {
+		return nil
+	}
+	info := &ffmpeg.SegmentInfo{
+		Sequence:  w.seq - 1,
+		Path:      w.file.Name(),
+		StartTime: w.segStart,
+		Duration:  time.Duration(w.segTicks) * time.Second / remuxTimescale,
+	}
+	if info.Duration > 0 {
+		secs := info.Duration.Seconds()
+		w.statsMu.Lock()
+		w.stats.Frames += int64(w.segFrames)
+		w.stats.FPS = float64(w.segFrames) / secs
+		w.stats.BitrateKbps = float64(w.segBytes) * 8 / secs / 1000
+		w.stats.Speed = 1
+		w.statsMu.Unlock()
+	}
+	return info
+}
+
+// closeSegment writes the last chunk of the segment and closes its file
+func (w *remuxWriter) closeSegment() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.flush()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	w.file = nil
+	return err
+}
+
+// flush writes the pending samples as one chunk
+func (w *remuxWriter) flush() error {
+	if len(w.chunk) == 0 {
+		return nil
+	}
+	w.fragments++
+	_, err := w.file.Write(fmp4.Chunk(w.fragments, w.chunkBase, w.chunk))
+	w.chunk, w.chunkTicks = nil, 0
+	if err != nil {
+		return fmt.Errorf("write segment: %w", err)
+	}
+	return nil
+}
+
+// report passes a finished segment to the callback
+func (w *remuxWriter) report(info ffmpeg.SegmentInfo) {
+	st, err := os.Stat(info.Path)
+	if err != nil || w.onSegment == nil {
+		return
+	}
+	info.Size = st.Size()
+	w.onSegment(info)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
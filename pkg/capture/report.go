@@ -0,0 +1,368 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+// maxReportErrors is how many error messages each channel report keeps
+const maxReportErrors = 20
+
+// gapTolerance is how late a segment can start after the previous one ends
+// before it counts as a gap
+const gapTolerance = 500 * time.Millisecond
+
+// SessionReport summarizes a capture session for QC
+type SessionReport struct {
+	SessionID       string          `json:"session_id"`
+	Start           time.Time       `json:"start"`
+	End             time.Time       `json:"end"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Channels        []ChannelReport `json:"channels"`
+}
+
+// ChannelReport is one channel's activity during a session
+type ChannelReport struct {
+	ChannelID      string   `json:"channel_id"`
+	CaptureSeconds float64  `json:"capture_seconds"`
+	Segments       int      `json:"segments"`
+	SegmentBytes   int64    `json:"segment_bytes"`
+	Gaps           int      `json:"gaps"`
+	GapSeconds     float64  `json:"gap_seconds"`
+	ClipsGenerated int      `json:"clips_generated"`
+	ClipsUploaded  int      `json:"clips_uploaded"`
+	Errors         int      `json:"errors"`
+	ErrorMessages  []string `json:"error_messages,omitempty"` // First errors seen, up to 20
+}
+
+// active reports whether anything happened during the session
+func (r *SessionReport) active() bool {
+	for _, c := range r.Channels {
+		if c.CaptureSeconds > 0 || c.Segments > 0 || c.ClipsGenerated > 0 || c.Errors > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CSV renders the report with one row per channel
+func (r *SessionReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"session_id", "channel_id", "capture_seconds", "segments", "segment_bytes",
+		"gaps", "gap_seconds", "clips_generated", "clips_uploaded", "errors"})
+	for _, c := range r.Channels {
+		w.Write([]string{
+			r.SessionID,
+			c.ChannelID,
+			strconv.FormatFloat(c.CaptureSeconds, 'f', 1, 64),
+			strconv.Itoa(c.Segments),
+			strconv.FormatInt(c.SegmentBytes, 10),
+			strconv.Itoa(c.Gaps),
+			strconv.FormatFloat(c.GapSeconds, 'f', 1, 64),
+			strconv.Itoa(c.ClipsGenerated),
+			strconv.Itoa(c.ClipsUploaded),
+			strconv.Itoa(c.Errors),
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// channelTally accumulates a channel's events
+type channelTally struct {
+	report         ChannelReport
+	capturingSince time.Time // zero when not capturing
+	lastSegmentEnd time.Time
+}
+
+// reportRequest asks the recorder for the report so far
+type reportRequest struct {
+	sessionID string
+	reset     bool // Start a new session after building the report
+	reply     chan SessionReport
+}
+
+// sessionRecorder tallies session activity from the event bus. Its state is
+// owned by the run goroutine; callers go through requests.
+type sessionRecorder struct {
+	events      <-chan events.Event
+	unsubscribe func()
+	requests    chan reportRequest
+	done        chan struct{}
+
+	start    time.Time
+	channels map[string]*channelTally
+}
+
+func newSessionRecorder(bus *events.Bus) *sessionRecorder {
+	ch, unsubscribe := bus.Subscribe("session report",
+		events.SegmentReady, events.StateChanged, events.ClipGenerated, events.ClipUploaded, events.Error)
+	r := &sessionRecorder{
+		events:      ch,
+		unsubscribe: unsubscribe,
+		requests:    make(chan reportRequest),
+		done:        make(chan struct{}),
+		start:       time.Now(),
+		channels:    make(map[string]*channelTally),
+	}
+	recovery.Go("session recorder", r.run)
+	return r
+}
+
+func (r *sessionRecorder) run() {
+	defer close(r.done)
+	for {
+		select {
+		case e, ok := <-r.events:
+			if !ok {
+				return
+			}
+			r.apply(e)
+		case req := <-r.requests:
+			// Everything published before the request is already queued
+			r.drain()
+			now := time.Now()
+			req.reply <- r.build(req.sessionID, now)
+			if req.reset {
+				r.resetAt(now)
+			}
+		}
+	}
+}
+
+func (r *sessionRecorder) drain() {
+	for {
+		select {
+		case e, ok := <-r.events:
+			if !ok {
+				return
+			}
+			r.apply(e)
+		default:
+			return
+		}
+	}
+}
+
+// report returns the session so far, starting a new one if reset is set.
+// Returns false once the recorder is stopped.
+func (r *sessionRecorder) report(sessionID string, reset bool) (SessionReport, bool) {
+	req := reportRequest{sessionID: sessionID, reset: reset, reply: make(chan SessionReport, 1)}
+	select {
+	case r.requests <- req:
+		return <-req.reply, true
+	case <-r.done:
+		return SessionReport{}, false
+	}
+}
+
+// stop ends the run goroutine
+func (r *sessionRecorder) stop() {
+	r.unsubscribe()
+	<-r.done
+}
+
+func (r *sessionRecorder) tally(id string) *channelTally {
+	t, ok := r.channels[id]
+	if !ok {
+		t = &channelTally{report: ChannelReport{ChannelID: id}}
+		r.channels[id] = t
+	}
+	return t
+}
+
+func (r *sessionRecorder) apply(e events.Event) {
+	if e.Channel == "" {
+		return
+	}
+	t := r.tally(e.Channel)
+	c := &t.report
+
+	switch e.Type {
+	case events.SegmentReady:
+		c.Segments++
+		size, _ := e.Data["size_bytes"].(int64)
+		c.SegmentBytes += size
+		startMs, _ := e.Data["start_time"].(int64)
+		dur, _ := e.Data["duration"].(float64)
+		start := time.UnixMilli(startMs)
+		if !t.lastSegmentEnd.IsZero() {
+			if gap := start.Sub(t.lastSegmentEnd); gap > gapTolerance {
+				c.Gaps++
+				c.GapSeconds += gap.Seconds()
+			}
+		}
+		t.lastSegmentEnd = start.Add(time.Duration(dur * float64(time.Second)))
+	case events.StateChanged:
+		capturing, _ := e.Data["capturing"].(bool)
+		if capturing && t.capturingSince.IsZero() {
+			t.capturingSince = e.Time
+			// Don't count the restart itself as a gap
+			t.lastSegmentEnd = time.Time{}
+		} else if !capturing && !t.capturingSince.IsZero() {
+			c.CaptureSeconds += e.Time.Sub(t.capturingSince).Seconds()
+			t.capturingSince = time.Time{}
+		}
+	case events.ClipGenerated:
+		c.ClipsGenerated++
+	case events.ClipUploaded:
+		c.ClipsUploaded++
+	case events.Error:
+		c.Errors++
+		if msg, _ := e.Data["error"].(string); msg != "" && len(c.ErrorMessages) < maxReportErrors {
+			c.ErrorMessages = append(c.ErrorMessages, msg)
+		}
+	}
+}
+
+// build snapshots the tallies, counting open captures up to now
+func (r *sessionRecorder) build(sessionID string, now time.Time) SessionReport {
+	report := SessionReport{
+		SessionID:       sessionID,
+		Start:           r.start,
+		End:             now,
+		DurationSeconds: math.Round(now.Sub(r.start).Seconds()*10) / 10,
+		Channels:        make([]ChannelReport, 0, len(r.channels)),
+	}
+	for _, t := range r.channels {
+		c := t.report
+		c.ErrorMessages = append([]string(nil), c.ErrorMessages...)
+		if !t.capturingSince.IsZero() {
+			c.CaptureSeconds += now.Sub(t.capturingSince).Seconds()
+		}
+		c.CaptureSeconds = math.Round(c.CaptureSeconds*10) / 10
+		c.GapSeconds = math.Round(c.GapSeconds*10) / 10
+		report.Channels = append(report.Channels, c)
+	}
+	sort.Slice(report.Channels, func(i, j int) bool {
+		return report.Channels[i].ChannelID < report.Channels[j].ChannelID
+	})
+	return report
+}
+
+// resetAt starts a new session, carrying over channels that are capturing
+func (r *sessionRecorder) resetAt(now time.Time) {
+	r.start = now
+	for id, t := range r.channels {
+		next := &channelTally{report: ChannelReport{ChannelID: id}, lastSegmentEnd: t.lastSegmentEnd}
+		if !t.capturingSince.IsZero() {
+			next.capturingSince = now
+		}
+		r.channels[id] = next
+	}
+}
+
+// SessionReport returns the current session's report so far as json
+// (default) or csv (implements api.ChannelManager)
+func (m *Manager) SessionReport(format string) ([]byte, string, error) {
+	m.mu.RLock()
+	sessionID := m.sessionID
+	m.mu.RUnlock()
+
+	report, ok := m.report.report(sessionID, false)
+	if !ok {
+		return nil, "", fmt.Errorf("session recorder stopped")
+	}
+	return encodeReport(&report, format)
+}
+
+// EndSession ends the current session, saving its report, and clears the
+// session ID (implements api.ChannelManager)
+func (m *Manager) EndSession(ctx context.Context) (interface{}, error) {
+	report, ok := m.swapSession("")
+	if !ok {
+		return nil, fmt.Errorf("session recorder stopped")
+	}
+	if err := m.saveReport(ctx, &report); err != nil {
+		return &report, err
+	}
+	return &report, nil
+}
+
+func encodeReport(report *SessionReport, format string) ([]byte, string, error) {
+	switch format {
+	case "", "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		return data, "application/json", err
+	case "csv":
+		data, err := report.CSV()
+		return data, "text/csv", err
+	}
+	return nil, "", fmt.Errorf("unknown report format: %s", format)
+}
+
+// swapSession sets the session ID on all channels and returns the report for
+// the session it replaces
+func (m *Manager) swapSession(sessionID string) (SessionReport, bool) {
+	m.mu.Lock()
+	old := m.sessionID
+	m.sessionID = sessionID
+	m.mu.Unlock()
+
+	for _, ch := range m.channelList() {
+		ch.SetSession(sessionID)
+	}
+	return m.report.report(old, true)
+}
+
+// saveReportAsync saves the report in the background if anything happened
+func (m *Manager) saveReportAsync(report SessionReport) {
+	if !report.active() {
+		return
+	}
+	recovery.Go("session report", func() {
+		if err := m.saveReport(context.Background(), &report); err != nil {
+			logger.Warn("Failed to save session report", "session", report.SessionID, "error", err)
+		}
+	})
+}
+
+// saveReport writes the report as JSON and CSV and uploads it if configured
+func (m *Manager) saveReport(ctx context.Context, report *SessionReport) error {
+	dir := m.cfg.Report.Path
+	if dir == "" {
+		dir = filepath.Join(m.basePath, "reports")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create report dir: %w", err)
+	}
+
+	name := report.SessionID
+	if name == "" {
+		name = "unnamed"
+	}
+	base := filepath.Join(dir, fmt.Sprintf("session-%s-%s", name, report.End.UTC().Format("20060102-150405")))
+	for _, format := range []string{"json", "csv"} {
+		data, _, err := encodeReport(report, format)
+		if err != nil {
+			return fmt.Errorf("encode %s report: %w", format, err)
+		}
+		if err := os.WriteFile(base+"."+format, data, 0644); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+	}
+	logger.Info("Session report saved", "session", report.SessionID, "path", base+".json")
+
+	if m.cfg.Report.Upload && m.platform != nil && report.SessionID != "" {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if err := m.platform.UploadSessionReport(ctx, report.SessionID, report); err != nil {
+			return fmt.Errorf("upload report: %w", err)
+		}
+		logger.Info("Session report uploaded", "session", report.SessionID)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package capture
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+func TestSessionRecorderApply(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	segment := func(offset time.Duration) events.Event {
+		return events.Event{Type: events.SegmentReady, Channel: "cam1", Data: map[string]interface{}{
+			"size_bytes": int64(1000),
+			"start_time": t0.Add(offset).UnixMilli(),
+			"duration":   2.0,
+		}}
+	}
+	state := func(offset time.Duration, capturing bool) events.Event {
+		return events.Event{Type: events.StateChanged, Channel: "cam1", Time: t0.Add(offset),
+			Data: map[string]interface{}{"capturing": capturing}}
+	}
+
+	tests := []struct {
+		name   string
+		events []events.Event
+		want   ChannelReport
+	}{
+		{
+			name:   "contiguous segments",
+			events: []events.Event{state(0, true), segment(0), segment(2 * time.Second), segment(4 * time.Second)},
+			want:   ChannelReport{ChannelID: "cam1", CaptureSeconds: 10, Segments: 3, SegmentBytes: 3000},
+		},
+		{
+			name:   "late segment within tolerance",
+			events: []events.Event{state(0, true), segment(0), segment(2400 * time.Millisecond)},
+			want:   ChannelReport{ChannelID: "cam1", CaptureSeconds: 10, Segments: 2, SegmentBytes: 2000},
+		},
+		{
+			name:   "gap",
+			events: []events.Event{state(0, true), segment(0), segment(5 * time.Second)},
+			want:   ChannelReport{ChannelID: "cam1", CaptureSeconds: 10, Segments: 2, SegmentBytes: 2000, Gaps: 1, GapSeconds: 3},
+		},
+		{
+			name: "restart is not a gap",
+			events: []events.Event{state(0, true), segment(0), state(2*time.Second, false),
+				state(6*time.Second, true), segment(6 * time.Second)},
+			want: ChannelReport{ChannelID: "cam1", CaptureSeconds: 6, Segments: 2, SegmentBytes: 2000},
+		},
+		{
+			name: "clips and errors",
+			events: []events.Event{
+				{Type: events.ClipGenerated, Channel: "cam1"},
+				{Type: events.ClipGenerated, Channel: "cam1"},
+				{Type: events.ClipUploaded, Channel: "cam1"},
+				{Type: events.Error, Channel: "cam1", Data: map[string]interface{}{"error": "boom"}},
+				{Type: events.Error, Channel: "cam1"},
+			},
+			want: ChannelReport{ChannelID: "cam1", ClipsGenerated: 2, ClipsUploaded: 1, Errors: 2, ErrorMessages: []string{"boom"}},
+		},
+		{
+			name:   "events without a channel are ignored",
+			events: []events.Event{{Type: events.ClipGenerated}},
+		},
+	}
+	for _, tt := range tests {
+		r := &sessionRecorder{start: t0, channels: make(map[string]*channelTally)}
+		for _, e := range tt.events {
+			r.apply(e)
+		}
+		report := r.build("s1", t0.Add(10*time.Second))
+		if tt.want.ChannelID == "" {
+			if len(report.Channels) != 0 {
+				t.Errorf("%s: %d channels, want none", tt.name, len(report.Channels))
+			}
+			continue
+		}
+		if len(report.Channels) != 1 {
+			t.Errorf("%s: %d channels, want 1", tt.name, len(report.Channels))
+			continue
+		}
+		if got := report.Channels[0]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: report = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSessionRecorderReset(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &sessionRecorder{start: t0, channels: make(map[string]*channelTally)}
+	r.apply(events.Event{Type: events.StateChanged, Channel: "cam1", Time: t0, Data: map[string]interface{}{"capturing": true}})
+	r.apply(events.Event{Type: events.StateChanged, Channel: "cam2", Time: t0, Data: map[string]interface{}{"capturing": true}})
+	r.apply(events.Event{Type: events.StateChanged, Channel: "cam2", Time: t0.Add(time.Second), Data: map[string]interface{}{"capturing": false}})
+	r.apply(events.Event{Type: events.ClipGenerated, Channel: "cam1"})
+
+	mid := t0.Add(10 * time.Second)
+	r.resetAt(mid)
+	report := r.build("s2", mid.Add(5*time.Second))
+
+	tests := []struct {
+		channel     string
+		wantCapture float64
+	}{
+		{"cam1", 5}, // Still capturing, counted from the reset
+		{"cam2", 0},
+	}
+	if report.Start != mid || report.DurationSeconds != 5 {
+		t.Errorf("report start %v duration %v, want %v and 5", report.Start, report.DurationSeconds, mid)
+	}
+	for i, tt := range tests {
+		c := report.Channels[i]
+		if c.ChannelID != tt.channel {
+			t.Errorf("channel %d = %s, want %s", i, c.ChannelID, tt.channel)
+		}
+		if c.CaptureSeconds != tt.wantCapture {
+			t.Errorf("%s: capture seconds = %v, want %v", tt.channel, c.CaptureSeconds, tt.wantCapture)
+		}
+		if c.ClipsGenerated != 0 {
+			t.Errorf("%s: clips carried over the reset", tt.channel)
+		}
+	}
+}
+
+func TestEncodeReport(t *testing.T) {
+	report := &SessionReport{SessionID: "s1", Channels: []ChannelReport{
+		{ChannelID: "cam1", CaptureSeconds: 12.5, Segments: 6, SegmentBytes: 6000, Gaps: 1, GapSeconds: 1.5},
+	}}
+
+	tests := []struct {
+		format      string
+		wantType    string
+		wantContain string
+		wantErr     bool
+	}{
+		{"", "application/json", `"session_id": "s1"`, false},
+		{"json", "application/json", `"capture_seconds": 12.5`, false},
+		{"csv", "text/csv", "s1,cam1,12.5,6,6000,1,1.5,0,0,0", false},
+		{"xml", "", "", true},
+	}
+	for _, tt := range tests {
+		data, contentType, err := encodeReport(report, tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.format, err, tt.wantErr)
+			continue
+		}
+		if contentType != tt.wantType {
+			t.Errorf("%q: content type = %q, want %q", tt.format, contentType, tt.wantType)
+		}
+		if !strings.Contains(string(data), tt.wantContain) {
+			t.Errorf("%q: output missing %q:\n%s", tt.format, tt.wantContain, data)
+		}
+	}
+
+	data, _, _ := encodeReport(report, "json")
+	var decoded SessionReport
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Channels[0].Segments != 6 {
+		t.Errorf("json round trip = %+v, %v", decoded, err)
+	}
+}
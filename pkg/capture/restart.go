@@ -0,0 +1,185 @@
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+// RestartStatus reports restarts of a channel's FFmpeg process
+type RestartStatus struct {
+	Restarts    int    `json:"restarts"`               // Since the channel started
+	Failures    int    `json:"consecutive_failures"`   // Exits since capture last ran for reset_after
+	LastError   string `json:"last_error,omitempty"`   // Why FFmpeg last exited
+	LastExit    int64  `json:"last_exit,omitempty"`    // Unix ms
+	NextRestart int64  `json:"next_restart,omitempty"` // Unix ms, while waiting to restart
+	GaveUp      bool   `json:"gave_up,omitempty"`      // max_retries reached; capture stays stopped
+}
+
+// restartTracker holds a channel's restart state and backoff
+type restartTracker struct {
+	cfg RestartConfig
+
+	mu     sync.Mutex
+	status RestartStatus
+}
+
+// failed records an exit or failed start and returns how long to wait
+// before the next attempt, or false once retries are used up
+func (r *restartTracker) failed(err error, ranFor time.Duration) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ranFor >= r.cfg.ResetAfter {
+		r.status.Failures = 0
+	}
+	r.status.LastError = err.Error()
+	r.status.LastExit = time.Now().UnixMilli()
+	r.status.NextRestart = 0
+	r.status.GaveUp = r.cfg.MaxRetries < 0 || r.status.Failures >= r.cfg.MaxRetries
+	if r.status.GaveUp {
+		return 0, false
+	}
+
+	delay := r.cfg.InitialBackoff
+	for i := 0; i < r.status.Failures && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, r.cfg.MaxBackoff)
+	if r.cfg.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + r.cfg.Jitter*(2*rand.Float64()-1)))
+	}
+	r.status.Failures++
+	r.status.NextRestart = time.Now().Add(delay).UnixMilli()
+	return delay, true
+}
+
+// restarting records a restart attempt
+func (r *restartTracker) restarting() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Restarts++
+	r.status.NextRestart = 0
+}
+
+// pending reports whether a restart is waiting on its backoff
+func (r *restartTracker) pending() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status.NextRestart != 0
+}
+
+// reset forgets earlier failures, for a start that isn't a retry
+func (r *restartTracker) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Failures = 0
+	r.status.GaveUp = false
+}
+
+// snapshot returns the status, nil before FFmpeg has ever exited
+func (r *restartTracker) snapshot() *RestartStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.LastExit == 0 {
+		return nil
+	}
+	s := r.status
+	return &s
+}
+
+// superviseWriter waits for a segment writer's FFmpeg process to exit and,
+// unless capture was stopped deliberately, restarts it
+func (ch *Channel) superviseWriter(w segmentWriter) {
+	started := time.Now()
+	<-w.Exited()
+	err := w.Wait()
+
+	ch.mu.Lock()
+	if ch.writer != w || ch.ctx.Err() != nil {
+		ch.mu.Unlock()
+		return // Stopped or replaced
+	}
+	stderr := w.GetError()
+	ch.stopCapture()
+	ch.mu.Unlock()
+
+	if err == nil && ch.cfg.Input.Type == "file" {
+		ch.logger.Info("Input file finished")
+		return
+	}
+	if err == nil {
+		err = errors.New("ffmpeg exited")
+	}
+	if stderr != nil {
+		err = errors.Join(err, stderr)
+	}
+	ch.restartCapture(err, time.Since(started))
+}
+
+// watchStall aborts a network input's FFmpeg when segments stop arriving,
+// as it can wait on a dead connection without exiting. The supervisor then
+// restarts it. Only stalls after the first segment count, so a listener
+// waiting for its caller is left alone.
+func (ch *Channel) watchStall(w segmentWriter, timeout time.Duration) {
+	started := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.Exited():
+			return
+		case <-ticker.C:
+		}
+		last := ch.history.lastSegmentTime()
+		if last.Before(started) || time.Since(last) < timeout {
+			continue
+		}
+		err := fmt.Errorf("input stalled: no segment for %s", time.Since(last).Round(time.Second))
+		ch.logger.Warn("Input stalled, reconnecting", "last_segment", last, "timeout", timeout)
+		w.Abort(err)
+		return
+	}
+}
+
+// restartCapture starts capture again after it failed, backing off between
+// attempts until one succeeds, retries are used up or the recording window
+// closes
+func (ch *Channel) restartCapture(cause error, ranFor time.Duration) {
+	for {
+		delay, ok := ch.restarts.failed(cause, ranFor)
+		if !ok {
+			ch.logger.Error("FFmpeg exited, giving up on restarts", "error", cause, "max_retries", ch.restarts.cfg.MaxRetries)
+			ch.publish(events.Error, map[string]interface{}{"error": cause.Error(), "restart": false})
+			return
+		}
+		ch.logger.Warn("FFmpeg exited, restarting", "error", cause, "in", delay)
+		ch.publish(events.Error, map[string]interface{}{"error": cause.Error(), "restart": true, "restart_in_ms": delay.Milliseconds()})
+
+		select {
+		case <-time.After(delay):
+		case <-ch.ctx.Done():
+			return
+		}
+		ch.startMu.Lock()
+		ch.mu.RLock()
+		stopped := ch.ctx.Err() != nil || ch.writer != nil || ch.offSchedule
+		ch.mu.RUnlock()
+		if stopped {
+			ch.startMu.Unlock()
+			return
+		}
+
+		ch.restarts.restarting()
+		cause = ch.startCapture()
+		ch.startMu.Unlock()
+		if cause == nil {
+			return // The new writer has its own supervisor
+		}
+		ranFor = 0
+	}
+}
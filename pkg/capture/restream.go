@@ -0,0 +1,218 @@
+package capture
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// RestreamConfig is a destination a channel's stream is re-pushed to
+// alongside buffering: an RTMP(S) server such as YouTube or Twitch, an SRT
+// contribution feed into a production switcher, or MPEG-TS over UDP to
+// in-venue decoders
+type RestreamConfig struct {
+	Name      string    `yaml:"name"`       // Identifies the destination in the API, e.g. youtube
+	URL       string    `yaml:"url"`        // rtmp://a.rtmp.youtube.com/live2, srt://switcher:9000, srt://:9000 in listener mode, or udp://239.10.0.1:5000
+	StreamKey string    `yaml:"stream_key"` // RTMP only: appended to the URL as the last path element
+	SRT       SRTConfig `yaml:"srt"`        // SRT only: mode, latency, passphrase, stream ID
+	AutoStart bool      `yaml:"auto_start"` // Push as soon as the channel captures (default: start via the API)
+}
+
+// isSRT reports whether the destination is an SRT feed
+func (r RestreamConfig) isSRT() bool {
+	return strings.HasPrefix(r.URL, "srt://")
+}
+
+// isUDP reports whether the destination is MPEG-TS over UDP
+func (r RestreamConfig) isUDP() bool {
+	return strings.HasPrefix(r.URL, "udp://")
+}
+
+// ingestURL returns the URL pushed to, with the stream key
+func (r RestreamConfig) ingestURL() string {
+	if r.StreamKey == "" || r.isSRT() || r.isUDP() {
+		return r.URL
+	}
+	return strings.TrimRight(r.URL, "/") + "/" + r.StreamKey
+}
+
+// validateRestreams checks a channel's restream destinations
+func validateRestreams(restreams []RestreamConfig) error {
+	seen := make(map[string]bool)
+	for i, r := range restreams {
+		if r.Name == "" {
+			return fmt.Errorf("restream[%d]: name is required", i)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("restream[%d]: duplicate name %q", i, r.Name)
+		}
+		seen[r.Name] = true
+		u, err := url.Parse(r.URL)
+		if err != nil || (u.Scheme != "rtmp" && u.Scheme != "rtmps" && u.Scheme != "srt" && u.Scheme != "udp") {
+			return fmt.Errorf("restream %s: url must be rtmp://, rtmps://, srt:// or udp://", r.Name)
+		}
+		if u.Scheme == "udp" && u.Port() == "" {
+			return fmt.Errorf("restream %s: udp url needs a port", r.Name)
+		}
+		if err := r.SRT.validate(); err != nil {
+			return fmt.Errorf("restream %s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// restreamOutput is the push a restream feeds, RTMP, SRT or UDP
+type restreamOutput interface {
+	output.Output
+	Stats() output.PushStats
+}
+
+// restream is a configured destination; out is nil while stopped
+type restream struct {
+	cfg       RestreamConfig
+	want      bool // Started, so it is pushed whenever capture runs
+	out       restreamOutput
+	startedAt time.Time
+}
+
+// RestreamStatus describes a restream destination
+type RestreamStatus struct {
+	Name      string            `json:"name"`
+	URL       string            `json:"url"` // Without the stream key
+	Running   bool              `json:"running"`
+	StartedAt int64             `json:"started_at,omitempty"`
+	Stats     *output.PushStats `json:"stats,omitempty"`
+}
+
+// Restreams lists the channel's restream destinations
+// (implements api.ChannelInterface)
+func (ch *Channel) Restreams() interface{} {
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	statuses := make([]RestreamStatus, 0, len(ch.restreams))
+	for _, name := range slices.Sorted(maps.Keys(ch.restreams)) {
+		r := ch.restreams[name]
+		status := RestreamStatus{Name: name, URL: r.cfg.URL, Running: r.want}
+		if r.want {
+			status.StartedAt = r.startedAt.UnixMilli()
+		}
+		if r.out != nil {
+			stats := r.out.Stats()
+			status.Stats = &stats
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// StartRestream starts pushing to a destination. It keeps pushing across
+// capture restarts until stopped. (implements api.ChannelInterface)
+func (ch *Channel) StartRestream(name string) error {
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	r, ok := ch.restreams[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", api.ErrRestreamNotFound, name)
+	}
+	if r.want {
+		return fmt.Errorf("restream %s already running", name)
+	}
+	r.want = true
+	r.startedAt = time.Now()
+	if ch.outputsOpen {
+		if err := ch.openRestream(r); err != nil {
+			r.want = false
+			return err
+		}
+	}
+	ch.logger.Info("Restream started", "name", name, "url", r.cfg.URL)
+	return nil
+}
+
+// StopRestream stops pushing to a destination (implements api.ChannelInterface)
+func (ch *Channel) StopRestream(name string) error {
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	r, ok := ch.restreams[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", api.ErrRestreamNotFound, name)
+	}
+	if !r.want {
+		return fmt.Errorf("restream %s not running", name)
+	}
+	r.want = false
+	ch.closeRestream(r)
+	ch.logger.Info("Restream stopped", "name", name)
+	return nil
+}
+
+// openRestreams adds the started restreams to a new writer's outputs; the
+// caller holds ch.outMu
+func (ch *Channel) openRestreams() {
+	for _, name := range slices.Sorted(maps.Keys(ch.restreams)) {
+		r := ch.restreams[name]
+		if !r.want {
+			continue
+		}
+		if r.startedAt.IsZero() {
+			r.startedAt = time.Now() // Auto-started
+		}
+		if err := ch.openRestream(r); err != nil {
+			ch.logger.Warn("Restream disabled", "name", name, "error", err)
+		}
+	}
+}
+
+// openRestream opens a restream's push if needed and feeds it from the
+// current writer; the caller holds ch.outMu
+func (ch *Channel) openRestream(r *restream) error {
+	if r.out == nil {
+		var out restreamOutput
+		format := "rtmp"
+		switch {
+		case r.cfg.isSRT():
+			out = output.NewSRTPush(ch.ffmpeg, srtOptions(r.cfg.SRT))
+			format = "srt"
+		case r.cfg.isUDP():
+			out = output.NewUDPPush(ch.ffmpeg)
+			format = "udp"
+		default:
+			out = output.NewRTMPPush(ch.ffmpeg)
+		}
+		if err := out.Open(output.Config{Path: r.cfg.ingestURL(), Format: format}); err != nil {
+			return fmt.Errorf("open restream %s: %w", r.cfg.Name, err)
+		}
+		r.out = out
+	}
+	ch.outputs = append(ch.outputs, ch.newChannelOutput(r.out, true))
+	return nil
+}
+
+// closeRestream disconnects a restream and stops feeding it; the caller
+// holds ch.outMu
+func (ch *Channel) closeRestream(r *restream) {
+	if r.out == nil {
+		return
+	}
+	if i := slices.IndexFunc(ch.outputs, func(o *channelOutput) bool { return o.out == output.Output(r.out) }); i >= 0 {
+		ch.outputs[i].detach()
+		ch.outputs = slices.Delete(ch.outputs, i, i+1)
+	}
+	r.out.Close()
+	r.out = nil
+}
+
+// closeRestreams disconnects every restream when the channel stops
+func (ch *Channel) closeRestreams() {
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	for _, r := range ch.restreams {
+		ch.closeRestream(r)
+	}
+}
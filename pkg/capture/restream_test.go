@@ -0,0 +1,41 @@
+package capture
+
+import "testing"
+
+func TestRestreamIngestURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RestreamConfig
+		want string
+	}{
+		{"rtmp with key", RestreamConfig{URL: "rtmp://a.rtmp.youtube.com/live2/", StreamKey: "abcd"}, "rtmp://a.rtmp.youtube.com/live2/abcd"},
+		{"rtmp without key", RestreamConfig{URL: "rtmp://server/live"}, "rtmp://server/live"},
+		{"srt ignores key", RestreamConfig{URL: "srt://switcher:9000", StreamKey: "abcd"}, "srt://switcher:9000"},
+		{"udp ignores key", RestreamConfig{URL: "udp://239.10.0.1:5000", StreamKey: "abcd"}, "udp://239.10.0.1:5000"},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.ingestURL(); got != tt.want {
+			t.Errorf("%s: ingestURL = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRestreams(t *testing.T) {
+	tests := []struct {
+		name      string
+		restreams []RestreamConfig
+		ok        bool
+	}{
+		{"none", nil, true},
+		{"rtmp and udp", []RestreamConfig{{Name: "yt", URL: "rtmps://a.rtmp.youtube.com/live2"}, {Name: "venue", URL: "udp://239.10.0.1:5000?ttl=4"}}, true},
+		{"udp without port", []RestreamConfig{{Name: "venue", URL: "udp://239.10.0.1"}}, false},
+		{"unknown scheme", []RestreamConfig{{Name: "venue", URL: "rtp://239.10.0.1:5000"}}, false},
+		{"no name", []RestreamConfig{{URL: "udp://239.10.0.1:5000"}}, false},
+		{"duplicate name", []RestreamConfig{{Name: "a", URL: "udp://239.10.0.1:5000"}, {Name: "a", URL: "udp://239.10.0.2:5000"}}, false},
+	}
+	for _, tt := range tests {
+		if err := validateRestreams(tt.restreams); (err == nil) != tt.ok {
+			t.Errorf("%s: validateRestreams = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
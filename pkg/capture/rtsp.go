@@ -0,0 +1,45 @@
+package capture
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// rtspInput returns the URL and FFmpeg input options for an RTSP camera.
+// Forcing tcp avoids the frame drops many IP cameras show over UDP.
+func rtspInput(device string, cfg RTSPConfig) (string, []string, error) {
+	input := device
+	if cfg.Username != "" {
+		u, err := url.Parse(device)
+		if err != nil {
+			return "", nil, fmt.Errorf("parse rtsp url: %w", err)
+		}
+		if u.User == nil {
+			u.User = url.UserPassword(cfg.Username, cfg.Password)
+			input = u.String()
+		}
+	}
+
+	var opts []string
+	if cfg.Transport != "" {
+		opts = append(opts, "-rtsp_transport", cfg.Transport)
+	}
+	if cfg.Timeout > 0 {
+		// Microseconds; FFmpeg before 5.0 called this -stimeout
+		opts = append(opts, "-timeout", strconv.FormatInt(cfg.Timeout.Microseconds(), 10))
+	}
+	if cfg.BufferSize > 0 {
+		opts = append(opts, "-buffer_size", strconv.Itoa(cfg.BufferSize))
+	}
+	return input, opts, nil
+}
+
+// redactURL hides credentials in an input URL for logging
+func redactURL(input string) string {
+	u, err := url.Parse(input)
+	if err != nil || u.User == nil {
+		return input
+	}
+	return u.Redacted()
+}
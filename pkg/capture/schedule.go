@@ -0,0 +1,114 @@
+package capture
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/schedule"
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+// scheduleInterval is how often recording windows are checked
+const scheduleInterval = 15 * time.Second
+
+// recordingSchedule decides which channels capture now
+type recordingSchedule struct {
+	loc     *time.Location
+	windows []scheduledWindow
+}
+
+type scheduledWindow struct {
+	channels []string // Empty = all
+	window   schedule.Window
+}
+
+// newRecordingSchedule returns nil when no windows are configured. The
+// config has been validated.
+func newRecordingSchedule(cfg ScheduleConfig) *recordingSchedule {
+	if len(cfg.Windows) == 0 {
+		return nil
+	}
+	loc, _ := time.LoadLocation(cfg.Timezone)
+	s := &recordingSchedule{loc: loc}
+	for _, w := range cfg.Windows {
+		window, _ := schedule.ParseWindow(w.Days, w.Start, w.End)
+		s.windows = append(s.windows, scheduledWindow{channels: w.Channels, window: window})
+	}
+	return s
+}
+
+// onSchedule reports whether a channel should capture at t: it has no
+// windows, or one of them is open
+func (s *recordingSchedule) onSchedule(channelID string, t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	scheduled := false
+	for _, w := range s.windows {
+		if len(w.channels) > 0 && !slices.Contains(w.channels, channelID) {
+			continue
+		}
+		if w.window.Active(t.In(s.loc)) {
+			return true
+		}
+		scheduled = true
+	}
+	return !scheduled
+}
+
+// runSchedule starts and stops capture as recording windows open and close
+func (m *Manager) runSchedule(ctx context.Context) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, ch := range m.channelList() {
+				ch.setOnSchedule(m.schedule.onSchedule(ch.id, now))
+			}
+		}
+	}
+}
+
+func (ch *Channel) isOffSchedule() bool {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	return ch.offSchedule
+}
+
+// setOnSchedule stops capture when the channel's recording windows close
+// and starts it again when one opens. The ring buffer keeps running, so
+// clips from earlier windows stay available.
+func (ch *Channel) setOnSchedule(on bool) {
+	ch.startMu.Lock()
+	defer ch.startMu.Unlock()
+	ch.mu.Lock()
+	if ch.offSchedule == !on {
+		ch.mu.Unlock()
+		return
+	}
+	ch.offSchedule = !on
+	if on {
+		ch.windowOpenedAt = time.Now()
+	}
+	running := ch.isRunning
+	if !on && running {
+		ch.stopCapture()
+	}
+	ch.mu.Unlock()
+
+	if !on {
+		ch.logger.Info("Recording window closed")
+		return
+	}
+	ch.logger.Info("Recording window opened")
+	if running && ch.cfg.Input.Type != "" && ch.cfg.Input.Device != "" {
+		if err := ch.startCapture(); err != nil {
+			ch.logger.Warn("Failed to start capture", "error", err)
+			ch.publish(events.Error, map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
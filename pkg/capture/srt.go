@@ -0,0 +1,203 @@
+package capture
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/internal/srtmux"
+	"github.com/video-system/go-video-capture/pkg/srt"
+)
+
+// srtOptions returns FFmpeg input options for an SRT source. They are passed
+// as options rather than URL parameters so the passphrase stays out of logs.
+func srtOptions(cfg SRTConfig) []string {
+	var opts []string
+	if cfg.Mode != "" {
+		opts = append(opts, "-mode", cfg.Mode)
+	}
+	if cfg.Latency > 0 {
+		// Microseconds
+		opts = append(opts, "-latency", strconv.FormatInt(cfg.Latency.Microseconds(), 10))
+	}
+	if cfg.Passphrase != "" {
+		opts = append(opts, "-passphrase", cfg.Passphrase)
+	}
+	if cfg.StreamID != "" {
+		opts = append(opts, "-streamid", cfg.StreamID)
+	}
+	return opts
+}
+
+// srtParams returns the native SRT input's settings, remuxing video in
+// the given codec (none when empty)
+func srtParams(cfg SRTConfig, remux string) map[string]string {
+	params := map[string]string{srt.ParamRemux: remux}
+	if cfg.Mode != "" {
+		params[srt.ParamMode] = cfg.Mode
+	}
+	if cfg.Latency > 0 {
+		params[srt.ParamLatency] = cfg.Latency.String()
+	}
+	if cfg.Passphrase != "" {
+		params[srt.ParamPassphrase] = cfg.Passphrase
+	}
+	if cfg.StreamID != "" {
+		params[srt.ParamStreamID] = cfg.StreamID
+	}
+	return params
+}
+
+// remuxCodec returns the codec whose video a native input may mux as is:
+// the one the channel encodes to, unless burn-in or deinterlacing needs
+// the video decoded
+func (ch *Channel) remuxCodec() string {
+	enc := ch.encodeConfig()
+	if enc.Deinterlace.Filter != "" || enc.Overlay.burnIn(ch.cfg.ID, 0).Enabled() {
+		return ""
+	}
+	switch enc.Codec {
+	case "", "h264":
+		return h26x.H264
+	case "h265", "hevc":
+		return h26x.H265
+	}
+	return ""
+}
+
+// srtListenAddr returns the local address an SRT listener input binds, taken
+// from the device URL (srt://:9000?mode=listener). ok is false for other
+// inputs.
+func srtListenAddr(in InputConfig) (addr string, ok bool, err error) {
+	if in.Type != "srt" || in.Device == "" {
+		return "", false, nil
+	}
+	u, err := url.Parse(in.Device)
+	if err != nil {
+		return "", false, fmt.Errorf("input.device: %w", err)
+	}
+	mode := in.SRT.Mode
+	if mode == "" {
+		mode = u.Query().Get("mode")
+	}
+	if mode != "listener" {
+		return "", false, nil
+	}
+	if u.Port() == "" {
+		return "", false, fmt.Errorf("input.device: listener %q needs a port", in.Device)
+	}
+	return net.JoinHostPort(u.Hostname(), u.Port()), true, nil
+}
+
+// validateSRTListeners checks that listener inputs sharing a port can be
+// told apart: each needs its own stream ID
+func validateSRTListeners(inputs map[string]InputConfig) error {
+	byPort := make(map[string][]string) // Port -> stream IDs
+	for id, in := range inputs {
+		addr, ok, err := srtListenAddr(in)
+		if err != nil {
+			return fmt.Errorf("channel %s: %w", id, err)
+		}
+		if !ok {
+			continue
+		}
+		_, port, _ := net.SplitHostPort(addr)
+		byPort[port] = append(byPort[port], in.SRT.StreamID)
+	}
+	for port, ids := range byPort {
+		if len(ids) < 2 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, id := range ids {
+			if id == "" {
+				return fmt.Errorf("input.srt: %d listeners share port %s; each needs a streamid", len(ids), port)
+			}
+			if seen[id] {
+				return fmt.Errorf("input.srt: streamid %q is used twice on port %s", id, port)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+// srtRoute sends callers for a listener channel with a stream ID through
+// the mux on its port, starting the mux if needed. It returns the loopback
+// address the channel's FFmpeg listener binds instead, or "" when the
+// channel binds its port directly.
+func (m *Manager) srtRoute(chCfg ChannelConfig) (string, error) {
+	addr, ok, err := srtListenAddr(chCfg.Input)
+	if err != nil || !ok || chCfg.Input.SRT.StreamID == "" {
+		return "", err
+	}
+	_, port, _ := net.SplitHostPort(addr)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mux := m.srtMuxes[port]
+	if mux == nil {
+		if mux, err = srtmux.Listen(addr); err != nil {
+			return "", fmt.Errorf("srt listener: %w", err)
+		}
+		m.srtMuxes[port] = mux
+		recovery.Go("srt mux", func() {
+			if err := mux.Serve(); err != nil {
+				logger.Warn("SRT listener stopped", "addr", addr, "error", err)
+			}
+		})
+		logger.Info("SRT listener routing by stream ID", "addr", addr)
+	}
+
+	backend, err := freeUDPPort()
+	if err != nil {
+		return "", fmt.Errorf("srt listener: %w", err)
+	}
+	mux.Route(chCfg.Input.SRT.StreamID, backend)
+	return backend.String(), nil
+}
+
+// srtUnroute removes a channel's stream ID route, closing the mux once no
+// channel uses its port
+func (m *Manager) srtUnroute(chCfg ChannelConfig) {
+	addr, ok, _ := srtListenAddr(chCfg.Input)
+	if !ok || chCfg.Input.SRT.StreamID == "" {
+		return
+	}
+	_, port, _ := net.SplitHostPort(addr)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mux := m.srtMuxes[port]
+	if mux == nil {
+		return
+	}
+	mux.Unroute(chCfg.Input.SRT.StreamID)
+	if mux.Routes() == 0 {
+		mux.Close()
+		delete(m.srtMuxes, port)
+	}
+}
+
+// closeSRTMuxes stops every stream ID listener
+func (m *Manager) closeSRTMuxes() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for port, mux := range m.srtMuxes {
+		mux.Close()
+		delete(m.srtMuxes, port)
+	}
+}
+
+// freeUDPPort picks an unused loopback UDP port
+func freeUDPPort() (*net.UDPAddr, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr), nil
+}
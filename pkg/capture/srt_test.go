@@ -0,0 +1,62 @@
+package capture
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/srt"
+)
+
+func TestSRTConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SRTConfig
+		wantErr bool
+	}{
+		{"empty", SRTConfig{}, false},
+		{"listener", SRTConfig{Mode: "listener", Latency: 200 * time.Millisecond, StreamID: "cam1"}, false},
+		{"rendezvous", SRTConfig{Mode: "rendezvous"}, false},
+		{"unknown mode", SRTConfig{Mode: "server"}, true},
+		{"negative latency", SRTConfig{Latency: -time.Millisecond}, true},
+		{"passphrase ok", SRTConfig{Passphrase: "0123456789"}, false},
+		{"passphrase too short", SRTConfig{Passphrase: "short"}, true},
+		{"passphrase too long", SRTConfig{Passphrase: string(make([]byte, 80))}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSRTOptions(t *testing.T) {
+	full := SRTConfig{Mode: "listener", Latency: 250 * time.Millisecond, Passphrase: "0123456789", StreamID: "cam1"}
+	tests := []struct {
+		name       string
+		cfg        SRTConfig
+		wantOpts   []string
+		wantParams map[string]string
+	}{
+		{"empty", SRTConfig{}, nil, map[string]string{srt.ParamRemux: "h264"}},
+		{
+			"all options", full,
+			[]string{"-mode", "listener", "-latency", "250000", "-passphrase", "0123456789", "-streamid", "cam1"},
+			map[string]string{
+				srt.ParamRemux:      "h264",
+				srt.ParamMode:       "listener",
+				srt.ParamLatency:    "250ms",
+				srt.ParamPassphrase: "0123456789",
+				srt.ParamStreamID:   "cam1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := srtOptions(tt.cfg); !reflect.DeepEqual(got, tt.wantOpts) {
+			t.Errorf("%s: srtOptions = %v, want %v", tt.name, got, tt.wantOpts)
+		}
+		if got := srtParams(tt.cfg, "h264"); !reflect.DeepEqual(got, tt.wantParams) {
+			t.Errorf("%s: srtParams = %v, want %v", tt.name, got, tt.wantParams)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package capture
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/timeseries"
+	"github.com/video-system/go-video-capture/pkg/ringbuffer"
+)
+
+// statsRetention is the longest window GetStats can return
+const statsRetention = 15 * time.Minute
+
+// ChannelStats is a channel's recent history for console graphs
+type ChannelStats struct {
+	ChannelID        string             `json:"channel_id"`
+	Window           string             `json:"window"`
+	FPS              []timeseries.Point `json:"fps"`
+	BitrateKbps      []timeseries.Point `json:"bitrate_kbps"`
+	SegmentLatencyMs []timeseries.Point `json:"segment_latency_ms"`
+	BufferHealth     []timeseries.Point `json:"buffer_health"`
+	GlassToGlassMs   []timeseries.Point `json:"glass_to_glass_ms"` // Capture to playlist, oldest frame of each segment
+}
+
+// channelHistory records one point per series for every new segment
+type channelHistory struct {
+	fps          *timeseries.Series
+	bitrate      *timeseries.Series
+	latency      *timeseries.Series
+	bufferHealth *timeseries.Series
+	glassToGlass *timeseries.Series
+
+	mu          sync.Mutex
+	lastFrames  int64
+	lastTime    time.Time
+	lastSegment time.Time
+}
+
+func newChannelHistory() *channelHistory {
+	return &channelHistory{
+		fps:          timeseries.New(statsRetention),
+		bitrate:      timeseries.New(statsRetention),
+		latency:      timeseries.New(statsRetention),
+		bufferHealth: timeseries.New(statsRetention),
+		glassToGlass: timeseries.New(statsRetention),
+	}
+}
+
+// record adds a segment's measurements. frames is the encoder's running
+// frame count (0 when unknown).
+func (h *channelHistory) record(seg *ringbuffer.Segment, frames int64, health float64) {
+	now := time.Now()
+	if seg.Duration > 0 {
+		h.bitrate.Add(now, math.Round(float64(seg.SizeBytes)*8/seg.Duration.Seconds()/1000))
+	}
+	h.latency.Add(now, float64(segmentLatency(seg).Milliseconds()))
+	h.bufferHealth.Add(now, health)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSegment = now
+	// A lower frame count means the encoder restarted; start over
+	if frames > h.lastFrames && !h.lastTime.IsZero() {
+		fps := float64(frames-h.lastFrames) / now.Sub(h.lastTime).Seconds()
+		h.fps.Add(now, math.Round(fps*100)/100)
+	}
+	h.lastFrames, h.lastTime = frames, now
+}
+
+// lastSegmentTime returns when the latest segment was recorded
+func (h *channelHistory) lastSegmentTime() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastSegment
+}
+
+// segmentLatency is how long after its nominal end a segment was complete
+func segmentLatency(seg *ringbuffer.Segment) time.Duration {
+	return max(time.Since(seg.StartTime.Add(seg.Duration)), 0)
+}
+
+// GetStats returns the channel's history over the last window, up to 15
+// minutes (implements api.ChannelInterface)
+func (ch *Channel) GetStats(window time.Duration) interface{} {
+	window = min(window, statsRetention)
+	since := time.Now().Add(-window)
+	return ChannelStats{
+		ChannelID:        ch.id,
+		Window:           window.String(),
+		FPS:              ch.history.fps.Since(since),
+		BitrateKbps:      ch.history.bitrate.Since(since),
+		SegmentLatencyMs: ch.history.latency.Since(since),
+		BufferHealth:     ch.history.bufferHealth.Since(since),
+		GlassToGlassMs:   ch.history.glassToGlass.Since(since),
+	}
+}
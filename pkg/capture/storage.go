@@ -0,0 +1,159 @@
+package capture
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/diskio"
+	"github.com/video-system/go-video-capture/pkg/events"
+)
+
+// benchmarkSize is the test file written by each storage benchmark
+const benchmarkSize = 8 << 20
+
+// StorageStatus reports whether the buffer volume keeps up with capture
+type StorageStatus struct {
+	Path           string                     `json:"path"`
+	WriteLatencyMs diskio.Summary             `json:"write_latency_ms"` // Benchmark write+fsync time
+	ThroughputMBps diskio.Summary             `json:"throughput_mbps"`  // Benchmark throughput
+	RequiredMBps   float64                    `json:"required_mbps"`    // Combined segment write rate of all channels
+	Headroom       float64                    `json:"headroom"`         // Mean throughput / required
+	Sufficient     bool                       `json:"sufficient"`
+	LastError      string                     `json:"last_error,omitempty"`
+	Channels       map[string]ChannelIOStatus `json:"channels"`
+}
+
+// ChannelIOStatus is a channel's segment write load
+type ChannelIOStatus struct {
+	SegmentLatencyMs diskio.Summary `json:"segment_latency_ms"` // Time from a segment's nominal end until it is complete on disk
+	WriteMBps        float64        `json:"write_mbps"`
+	Behind           bool           `json:"behind"` // p95 segment latency exceeds the segment duration
+}
+
+// storageMonitor benchmarks the buffer volume on an interval
+type storageMonitor struct {
+	path       string
+	interval   time.Duration
+	headroom   float64
+	latency    *diskio.Window // ms
+	throughput *diskio.Window // MB/s
+	lastErr    error
+	sufficient bool
+}
+
+func newStorageMonitor(path string, cfg MonitorConfig) *storageMonitor {
+	if path == "" {
+		path = "." // Channel directories are relative to the working directory
+	}
+	return &storageMonitor{
+		path:       path,
+		interval:   cfg.DiskInterval,
+		headroom:   cfg.DiskHeadroom,
+		latency:    diskio.NewWindow(20),
+		throughput: diskio.NewWindow(20),
+		sufficient: true,
+	}
+}
+
+// monitorStorage benchmarks the buffer volume until ctx is done
+func (m *Manager) monitorStorage(ctx context.Context) {
+	if m.storage.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.storage.interval)
+	defer ticker.Stop()
+
+	for {
+		m.benchmarkStorage()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// benchmarkStorage runs one benchmark and raises or clears the slow-storage flag
+func (m *Manager) benchmarkStorage() {
+	s := m.storage
+	sample, err := diskio.Benchmark(s.path, benchmarkSize)
+
+	m.mu.Lock()
+	s.lastErr = err
+	if err == nil {
+		s.latency.Add(float64(sample.Latency.Milliseconds()))
+		s.throughput.Add(sample.Throughput / 1e6)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		logger.Warn("Storage benchmark failed", "path", s.path, "error", err)
+		return
+	}
+
+	status := m.StorageStatus().(StorageStatus)
+	m.mu.Lock()
+	changed := status.Sufficient != s.sufficient
+	s.sufficient = status.Sufficient
+	m.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if status.Sufficient {
+		logger.Info("Storage keeping up with capture again", "path", s.path, "headroom", status.Headroom)
+	} else {
+		logger.Warn("Storage cannot sustain capture",
+			"path", s.path,
+			"channels", len(status.Channels),
+			"throughput_mbps", status.ThroughputMBps.Mean,
+			"required_mbps", status.RequiredMBps)
+	}
+	m.events.Publish(events.Event{
+		Type: events.StorageSlow,
+		Data: map[string]interface{}{
+			"path":            s.path,
+			"slow":            !status.Sufficient,
+			"throughput_mbps": status.ThroughputMBps.Mean,
+			"required_mbps":   status.RequiredMBps,
+		},
+	})
+}
+
+// StorageStatus reports buffer volume performance against the capture load
+// (implements api.ChannelManager)
+func (m *Manager) StorageStatus() interface{} {
+	m.mu.RLock()
+	s := m.storage
+	status := StorageStatus{
+		Path:           s.path,
+		WriteLatencyMs: s.latency.Summary(),
+		ThroughputMBps: s.throughput.Summary(),
+		Channels:       make(map[string]ChannelIOStatus, len(m.channels)),
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	channels := make(map[string]*Channel, len(m.channels))
+	for id, ch := range m.channels {
+		channels[id] = ch
+	}
+	m.mu.RUnlock()
+
+	status.Sufficient = true
+	for id, ch := range channels {
+		chIO := ch.ioStatus()
+		status.Channels[id] = chIO
+		status.RequiredMBps += chIO.WriteMBps
+		if chIO.Behind {
+			status.Sufficient = false
+		}
+	}
+	if status.RequiredMBps > 0 && status.ThroughputMBps.Count > 0 {
+		status.Headroom = math.Round(status.ThroughputMBps.Mean/status.RequiredMBps*100) / 100
+		if status.ThroughputMBps.Mean < status.RequiredMBps*s.headroom {
+			status.Sufficient = false
+		}
+	}
+	return status
+}
@@ -0,0 +1,61 @@
+package capture
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/video-system/go-video-capture/pkg/platform"
+	"github.com/video-system/go-video-capture/pkg/uploads"
+)
+
+// UploadsStatus lists clip uploads waiting for a retry or given up on
+type UploadsStatus struct {
+	Enabled bool             `json:"enabled"`
+	Pending int              `json:"pending"`
+	Failed  int              `json:"failed"`
+	Uploads []uploads.Upload `json:"uploads"`
+}
+
+// newUploadQueue opens the retry queue kept next to the buffer
+func (m *Manager) newUploadQueue() (*uploads.Queue, error) {
+	if err := os.MkdirAll(m.cfg.Buffer.Path, 0755); err != nil {
+		return nil, err
+	}
+	return uploads.New(uploads.Config{Path: filepath.Join(m.cfg.Buffer.Path, "uploads.json")}, m.retryUpload)
+}
+
+// retryUpload is the queue's upload function; it reports the outcome on the
+// clip's channel like a first attempt
+func (m *Manager) retryUpload(ctx context.Context, filePath string, metadata platform.ClipMetadata) error {
+	result, err := m.platform.UploadClip(ctx, filePath, metadata)
+	ch := m.channel(metadata.ChannelID)
+	if ch == nil {
+		return err
+	}
+	if err != nil {
+		if metadata.Variant == "" {
+			ch.metrics.uploadFailures.Inc()
+		}
+		return err
+	}
+	ch.clipUploaded(metadata, result)
+	return nil
+}
+
+// UploadsStatus reports queued clip uploads (implements api.ChannelManager)
+func (m *Manager) UploadsStatus() interface{} {
+	status := UploadsStatus{Enabled: m.uploads != nil, Uploads: []uploads.Upload{}}
+	if m.uploads == nil {
+		return status
+	}
+	status.Uploads = m.uploads.List()
+	for _, u := range status.Uploads {
+		if u.Status == uploads.Failed {
+			status.Failed++
+		} else {
+			status.Pending++
+		}
+	}
+	return status
+}
@@ -0,0 +1,43 @@
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/whep"
+	"github.com/video-system/go-video-capture/pkg/api"
+)
+
+// whepChunkDuration is the CMAF chunk duration when WHEP is enabled; players
+// receive each chunk as soon as it is written
+const whepChunkDuration = 200 * time.Millisecond
+
+// WHEPSubscribe answers a WebRTC player's SDP offer, returning the SDP
+// answer and the session ID (implements api.ChannelInterface)
+func (ch *Channel) WHEPSubscribe(offer string) (string, string, error) {
+	if ch.whep == nil {
+		return "", "", fmt.Errorf("%w on channel %s", api.ErrWHEPNotEnabled, ch.id)
+	}
+	answer, id, err := ch.whep.Subscribe(offer)
+	if errors.Is(err, whep.ErrTooManySessions) {
+		return "", "", fmt.Errorf("%w: %d players", api.ErrWHEPBusy, ch.cfg.WHEP.MaxSessions)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	ch.logger.Info("WHEP player connected", "session", id, "players", ch.whep.Sessions())
+	return answer, id, nil
+}
+
+// WHEPUnsubscribe ends a WebRTC player's session (implements api.ChannelInterface)
+func (ch *Channel) WHEPUnsubscribe(sessionID string) error {
+	if ch.whep == nil {
+		return fmt.Errorf("%w on channel %s", api.ErrWHEPNotEnabled, ch.id)
+	}
+	if err := ch.whep.Unsubscribe(sessionID); err != nil {
+		return fmt.Errorf("%w: %s", api.ErrWHEPSessionNotFound, sessionID)
+	}
+	ch.logger.Info("WHEP player disconnected", "session", sessionID)
+	return nil
+}
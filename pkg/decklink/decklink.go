@@ -0,0 +1,228 @@
+//go:build decklink && linux
+
+package decklink
+
+/*
+#cgo CXXFLAGS: -std=c++11 -I${SRCDIR}/include
+#cgo LDFLAGS: -ldl -lpthread
+
+#include <stdlib.h>
+#include "shim.h"
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func init() {
+	// Replaces FFmpeg's decklink input where the driver is installed
+	if IsAvailable() {
+		input.Register("decklink", func() input.Input { return New() })
+	}
+}
+
+// readInterval is how often a blocked read checks its context
+const readInterval = 100 * time.Millisecond
+
+// audioQueueSize is how many frames' audio may wait for ReadAudio before
+// it is dropped
+const audioQueueSize = 64
+
+var errClosed = errors.New("decklink input closed")
+
+// IsAvailable reports whether the Desktop Video driver can be loaded
+func IsAvailable() bool {
+	return C.dl_available() != 0
+}
+
+// Devices lists the DeckLink devices with inputs, in index order
+func Devices() ([]string, error) {
+	var names **C.char
+	n := int(C.dl_device_names(&names))
+	defer C.free(unsafe.Pointer(names))
+	list := unsafe.Slice(names, n)
+	devices := make([]string, n)
+	for i, name := range list {
+		devices[i] = C.GoString(name)
+		C.free(unsafe.Pointer(name))
+	}
+	return devices, nil
+}
+
+// Input captures a DeckLink card's video, its embedded audio and whether
+// a signal is present. The card detects the incoming mode and restarts
+// capture in it; frames then arrive in the new size, format or rate.
+type Input struct {
+	mu     sync.RWMutex // Held for reading by calls into the driver; Close waits for them
+	handle *C.dl_input
+	audio  chan []byte
+	format input.AudioFormat
+
+	// Owned by the goroutine reading frames
+	mode input.VideoMode
+	seq  int64
+}
+
+// New creates a DeckLink input
+func New() *Input {
+	return &Input{}
+}
+
+func (d *Input) Name() string { return "decklink-native" }
+func (d *Input) Type() string { return "decklink" }
+
+func (d *Input) Capabilities() input.Capabilities {
+	return input.Capabilities{
+		SupportsAudio:    true,
+		SupportsVideo:    true,
+		SupportedFormats: []input.PixelFormat{input.FormatUYVY, input.FormatBGRA},
+	}
+}
+
+// Open starts capturing. The configured size and rate select the mode of
+// cards that can't detect it, 1080i50 if unset; the device is a name as
+// listed by Devices or an index.
+func (d *Input) Open(cfg input.Config) error {
+	names, _ := Devices()
+	index, err := deviceIndex(cfg.Device, names)
+	if err != nil {
+		return fmt.Errorf("decklink: %w", err)
+	}
+	channels := audioChannels(cfg.AudioChannels)
+	var cerr *C.char
+	h := C.dl_open(C.int(index), C.int(cfg.Width), C.int(cfg.Height), C.double(cfg.Framerate), C.int(channels), &cerr)
+	if h == nil {
+		return fmt.Errorf("decklink %s: %s", names[index], C.GoString(cerr))
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handle = h
+	d.audio = make(chan []byte, audioQueueSize)
+	d.format = input.AudioFormat{SampleRate: sampleRate, Channels: channels, Sample: "s16le"}
+	return nil
+}
+
+// Close stops capture, ending blocked reads, and releases the device
+func (d *Input) Close() error {
+	d.mu.RLock()
+	h := d.handle
+	d.mu.RUnlock()
+	if h == nil {
+		return nil
+	}
+	C.dl_stop(h)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.handle != nil {
+		C.dl_free(d.handle)
+		d.handle = nil
+		close(d.audio)
+	}
+	return nil
+}
+
+// ReadFrame returns the next frame; without signal it is black and
+// flagged NoSignal
+func (d *Input) ReadFrame(ctx context.Context) (*input.Frame, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.handle == nil {
+		return nil, errClosed
+	}
+	for {
+		var f C.dl_frame
+		switch C.dl_read(d.handle, C.int(readInterval/time.Millisecond), &f) {
+		case C.DL_OK:
+			return d.frame(&f), nil
+		case C.DL_CLOSED:
+			return nil, errClosed
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// frame copies a captured frame to Go, queueing its audio for ReadAudio
+func (d *Input) frame(f *C.dl_frame) *input.Frame {
+	defer C.dl_frame_free(f)
+	format := input.FormatUYVY
+	if f.rgb != 0 {
+		format = input.FormatBGRA
+	}
+	d.seq++
+	d.mode = input.VideoMode{
+		Width:     int(f.width),
+		Height:    int(f.height),
+		Framerate: frameRate(int64(f.duration), int64(f.scale)),
+		Format:    format,
+	}
+	if f.audio != nil {
+		select {
+		case d.audio <- C.GoBytes(f.audio, f.audio_size):
+		default:
+		}
+	}
+	return &input.Frame{
+		Data:      C.GoBytes(f.data, f.size),
+		Width:     d.mode.Width,
+		Height:    d.mode.Height,
+		Format:    format,
+		Timestamp: time.Now().UnixNano(),
+		Sequence:  d.seq,
+		NoSignal:  f.no_signal != 0,
+	}
+}
+
+// DetectedMode returns the mode of the last frame read
+func (d *Input) DetectedMode() input.VideoMode {
+	return d.mode
+}
+
+// AudioFormat describes the embedded audio: 48kHz s16le with 2, 8 or 16
+// channels
+func (d *Input) AudioFormat() input.AudioFormat {
+	return d.format
+}
+
+// ReadAudio returns the audio of the next frame read. It arrives with its
+// frame, so audio and video stay in step through dropped frames.
+func (d *Input) ReadAudio(ctx context.Context) ([]byte, error) {
+	d.mu.RLock()
+	audio := d.audio
+	d.mu.RUnlock()
+	if audio == nil {
+		return nil, errClosed
+	}
+	select {
+	case data, ok := <-audio:
+		if !ok {
+			return nil, errClosed
+		}
+		return data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ListDevices lists the cards' inputs
+func (d *Input) ListDevices() ([]input.Device, error) {
+	names, err := Devices()
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]input.Device, len(names))
+	for i, name := range names {
+		devices[i] = input.Device{ID: strconv.Itoa(i), Name: name, Type: "decklink"}
+	}
+	return devices, nil
+}
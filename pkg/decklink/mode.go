@@ -0,0 +1,51 @@
+// Package decklink captures SDI and HDMI from Blackmagic DeckLink cards
+// natively through the Desktop Video driver, for FFmpeg builds without
+// decklink support. Build with -tags decklink on Linux, with the DeckLink
+// SDK headers in include/; without it the FFmpeg decklink input is used.
+package decklink
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// sampleRate is the rate DeckLink cards capture embedded audio at
+const sampleRate = 48000
+
+// frameRate returns the frames per second of a mode whose frames last
+// duration units of a scale-per-second clock, e.g. 1001/30000 for 29.97
+func frameRate(duration, scale int64) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	return float64(scale) / float64(duration)
+}
+
+// audioChannels rounds a channel count up to one the cards capture: 2, 8
+// or 16
+func audioChannels(n int) int {
+	switch {
+	case n <= 2:
+		return 2
+	case n <= 8:
+		return 8
+	}
+	return 16
+}
+
+// deviceIndex resolves input.device, a device name as listed or an index,
+// to the index of one of the named devices
+func deviceIndex(device string, names []string) (int, error) {
+	for i, name := range names {
+		if name == device {
+			return i, nil
+		}
+	}
+	if device == "" && len(names) > 0 {
+		return 0, nil
+	}
+	if i, err := strconv.Atoi(device); err == nil && i >= 0 && i < len(names) {
+		return i, nil
+	}
+	return 0, fmt.Errorf("device %q not found (%d present)", device, len(names))
+}
@@ -0,0 +1,55 @@
+package decklink
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFrameRate(t *testing.T) {
+	tests := []struct {
+		duration, scale int64
+		want            float64
+	}{
+		{1000, 25000, 25},
+		{1001, 30000, 29.97},
+		{1001, 60000, 59.94},
+		{0, 25000, 0},
+	}
+	for _, tt := range tests {
+		if got := frameRate(tt.duration, tt.scale); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("frameRate(%d, %d) = %g, want %g", tt.duration, tt.scale, got, tt.want)
+		}
+	}
+}
+
+func TestAudioChannels(t *testing.T) {
+	for n, want := range map[int]int{0: 2, 2: 2, 3: 8, 8: 8, 12: 16, 16: 16} {
+		if got := audioChannels(n); got != want {
+			t.Errorf("audioChannels(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestDeviceIndex(t *testing.T) {
+	names := []string{"DeckLink Duo (1)", "DeckLink Duo (2)"}
+	tests := []struct {
+		device string
+		want   int
+		ok     bool
+	}{
+		{"DeckLink Duo (2)", 1, true},
+		{"1", 1, true},
+		{"", 0, true},
+		{"2", 0, false},
+		{"UltraStudio", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := deviceIndex(tt.device, names)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("deviceIndex(%q) = %d, %v", tt.device, got, err)
+		}
+	}
+	if _, err := deviceIndex("", nil); err == nil {
+		t.Error("expected error with no devices")
+	}
+}
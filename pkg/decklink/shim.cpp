@@ -0,0 +1,349 @@
+//go:build decklink && linux
+
+#include "shim.h"
+
+#include <errno.h>
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+#include <time.h>
+
+#include <atomic>
+#include <deque>
+
+#include <DeckLinkAPI.h>
+
+// Loads libDeckLinkAPI.so at runtime, so the binary also starts on
+// machines without Desktop Video installed
+#include <DeckLinkAPIDispatch.cpp>
+
+// Frames queued while the reader is behind; the oldest are dropped
+static const size_t kQueueSize = 8;
+
+class Callback;
+
+struct dl_input {
+	IDeckLink *device;
+	IDeckLinkInput *input;
+	Callback *callback;
+	int channels;
+
+	pthread_mutex_t mu;
+	pthread_cond_t cond;
+	std::deque<dl_frame> queue;
+	bool closed;
+	BMDPixelFormat pixel;
+	BMDTimeValue duration;
+	BMDTimeScale scale;
+};
+
+// fill_black writes black UYVY or BGRA
+static void fill_black(dl_frame *f) {
+	if (f->rgb) {
+		uint32_t *p = (uint32_t *)f->data;
+		for (int i = 0; i < f->size / 4; i++) {
+			p[i] = 0xff000000;
+		}
+		return;
+	}
+	uint8_t *p = (uint8_t *)f->data;
+	for (int i = 0; i < f->size; i += 2) {
+		p[i] = 0x80;
+		p[i + 1] = 0x10;
+	}
+}
+
+class Callback : public IDeckLinkInputCallback {
+public:
+	explicit Callback(dl_input *in) : in_(in), refs_(1) {}
+	virtual ~Callback() {}
+
+	HRESULT STDMETHODCALLTYPE QueryInterface(REFIID, LPVOID *ppv) override {
+		*ppv = NULL;
+		return E_NOINTERFACE;
+	}
+	ULONG STDMETHODCALLTYPE AddRef() override { return ++refs_; }
+	ULONG STDMETHODCALLTYPE Release() override {
+		ULONG n = --refs_;
+		if (n == 0) {
+			delete this;
+		}
+		return n;
+	}
+
+	// Restarts the streams in the detected mode and pixel format
+	HRESULT STDMETHODCALLTYPE VideoInputFormatChanged(BMDVideoInputFormatChangedEvents events,
+			IDeckLinkDisplayMode *mode, BMDDetectedVideoInputFormatFlags flags) override {
+		BMDPixelFormat pixel = (flags & bmdDetectedVideoInputRGB444) ? bmdFormat8BitBGRA : bmdFormat8BitYUV;
+		BMDTimeValue duration;
+		BMDTimeScale scale;
+		mode->GetFrameRate(&duration, &scale);
+
+		in_->input->PauseStreams();
+		in_->input->EnableVideoInput(mode->GetDisplayMode(), pixel, bmdVideoInputEnableFormatDetection);
+		in_->input->FlushStreams();
+
+		pthread_mutex_lock(&in_->mu);
+		in_->pixel = pixel;
+		in_->duration = duration;
+		in_->scale = scale;
+		pthread_mutex_unlock(&in_->mu);
+
+		in_->input->StartStreams();
+		return S_OK;
+	}
+
+	HRESULT STDMETHODCALLTYPE VideoInputFrameArrived(IDeckLinkVideoInputFrame *video,
+			IDeckLinkAudioInputPacket *audio) override {
+		if (video == NULL) {
+			return S_OK;
+		}
+		dl_frame f;
+		memset(&f, 0, sizeof(f));
+		f.width = (int)video->GetWidth();
+		f.height = (int)video->GetHeight();
+		f.rgb = video->GetPixelFormat() == bmdFormat8BitBGRA;
+		f.no_signal = (video->GetFlags() & bmdFrameHasNoInputSource) != 0;
+
+		int row = f.width * (f.rgb ? 4 : 2);
+		long stride = video->GetRowBytes();
+		void *bytes = NULL;
+		f.size = row * f.height;
+		f.data = malloc(f.size);
+		if (f.data == NULL || video->GetBytes(&bytes) != S_OK) {
+			free(f.data);
+			return S_OK;
+		}
+		if (f.no_signal) {
+			fill_black(&f);
+		} else {
+			for (int y = 0; y < f.height; y++) {
+				memcpy((uint8_t *)f.data + (size_t)y * row, (uint8_t *)bytes + (size_t)y * stride, row);
+			}
+		}
+
+		void *samples = NULL;
+		if (audio != NULL && audio->GetBytes(&samples) == S_OK) {
+			f.audio_size = (int)audio->GetSampleFrameCount() * in_->channels * 2;
+			f.audio = malloc(f.audio_size);
+			if (f.audio != NULL) {
+				memcpy(f.audio, samples, f.audio_size);
+			} else {
+				f.audio_size = 0;
+			}
+		}
+
+		pthread_mutex_lock(&in_->mu);
+		f.duration = in_->duration;
+		f.scale = in_->scale;
+		if (in_->closed) {
+			pthread_mutex_unlock(&in_->mu);
+			dl_frame_free(&f);
+			return S_OK;
+		}
+		if (in_->queue.size() >= kQueueSize) {
+			dl_frame_free(&in_->queue.front());
+			in_->queue.pop_front();
+		}
+		in_->queue.push_back(f);
+		pthread_cond_broadcast(&in_->cond);
+		pthread_mutex_unlock(&in_->mu);
+		return S_OK;
+	}
+
+private:
+	dl_input *in_;
+	std::atomic<ULONG> refs_;
+};
+
+// each_device calls fn with every device that has an input until it
+// returns true, releasing the others
+template <typename F>
+static void each_device(F fn) {
+	IDeckLinkIterator *it = CreateDeckLinkIteratorInstance();
+	if (it == NULL) {
+		return;
+	}
+	IDeckLink *device;
+	while (it->Next(&device) == S_OK) {
+		IDeckLinkInput *input = NULL;
+		if (device->QueryInterface(IID_IDeckLinkInput, (void **)&input) != S_OK) {
+			device->Release();
+			continue;
+		}
+		if (!fn(device, input)) {
+			input->Release();
+			device->Release();
+			continue;
+		}
+		break;
+	}
+	it->Release();
+}
+
+// find_mode returns the display mode matching a configured size and rate,
+// or 1080i50 when none is configured
+static BMDDisplayMode find_mode(IDeckLinkInput *input, int width, int height, double fps,
+		BMDTimeValue *duration, BMDTimeScale *scale) {
+	BMDDisplayMode found = bmdModeHD1080i50;
+	*duration = 1000;
+	*scale = 25000;
+	IDeckLinkDisplayModeIterator *it = NULL;
+	if (input->GetDisplayModeIterator(&it) != S_OK) {
+		return found;
+	}
+	IDeckLinkDisplayMode *mode;
+	while (it->Next(&mode) == S_OK) {
+		BMDTimeValue d;
+		BMDTimeScale s;
+		mode->GetFrameRate(&d, &s);
+		bool match = width > 0
+			? mode->GetWidth() == width && mode->GetHeight() == height && (fps <= 0 || ((double)s / d - fps < 0.01 && fps - (double)s / d < 0.01))
+			: mode->GetDisplayMode() == bmdModeHD1080i50;
+		if (match) {
+			found = mode->GetDisplayMode();
+			*duration = d;
+			*scale = s;
+			mode->Release();
+			break;
+		}
+		mode->Release();
+	}
+	it->Release();
+	return found;
+}
+
+int dl_available(void) {
+	IDeckLinkIterator *it = CreateDeckLinkIteratorInstance();
+	if (it == NULL) {
+		return 0;
+	}
+	it->Release();
+	return 1;
+}
+
+int dl_device_names(char ***names) {
+	std::deque<char *> found;
+	each_device([&](IDeckLink *device, IDeckLinkInput *) {
+		const char *name = NULL;
+		if (device->GetDisplayName(&name) == S_OK) {
+			found.push_back(strdup(name));
+			free((void *)name);
+		} else {
+			found.push_back(strdup("DeckLink"));
+		}
+		return false;
+	});
+	*names = (char **)malloc(sizeof(char *) * (found.size() + 1));
+	for (size_t i = 0; i < found.size(); i++) {
+		(*names)[i] = found[i];
+	}
+	return (int)found.size();
+}
+
+dl_input *dl_open(int index, int width, int height, double fps, int channels, const char **err) {
+	IDeckLink *device = NULL;
+	IDeckLinkInput *input = NULL;
+	int i = 0;
+	each_device([&](IDeckLink *d, IDeckLinkInput *in) {
+		if (i++ != index) {
+			return false;
+		}
+		device = d;
+		input = in;
+		return true;
+	});
+	if (input == NULL) {
+		*err = "device not found";
+		return NULL;
+	}
+
+	dl_input *in = new dl_input();
+	in->device = device;
+	in->input = input;
+	in->channels = channels;
+	in->closed = false;
+	in->pixel = bmdFormat8BitYUV;
+	pthread_mutex_init(&in->mu, NULL);
+	pthread_cond_init(&in->cond, NULL);
+	BMDDisplayMode mode = find_mode(input, width, height, fps, &in->duration, &in->scale);
+
+	in->callback = new Callback(in);
+	input->SetCallback(in->callback);
+	// Cards without format detection capture the configured mode only
+	if (input->EnableVideoInput(mode, in->pixel, bmdVideoInputEnableFormatDetection) != S_OK &&
+			input->EnableVideoInput(mode, in->pixel, bmdVideoInputFlagDefault) != S_OK) {
+		*err = "enable video input failed (in use by another application?)";
+	} else if (input->EnableAudioInput(bmdAudioSampleRate48kHz, bmdAudioSampleType16bitInteger, channels) != S_OK) {
+		*err = "enable audio input failed";
+	} else if (input->StartStreams() != S_OK) {
+		*err = "start streams failed";
+	} else {
+		return in;
+	}
+	dl_stop(in);
+	dl_free(in);
+	return NULL;
+}
+
+int dl_read(dl_input *in, int timeout_ms, dl_frame *frame) {
+	struct timespec deadline;
+	clock_gettime(CLOCK_REALTIME, &deadline);
+	deadline.tv_sec += timeout_ms / 1000;
+	deadline.tv_nsec += (long)(timeout_ms % 1000) * 1000000;
+	if (deadline.tv_nsec >= 1000000000) {
+		deadline.tv_sec++;
+		deadline.tv_nsec -= 1000000000;
+	}
+
+	pthread_mutex_lock(&in->mu);
+	while (in->queue.empty() && !in->closed) {
+		if (pthread_cond_timedwait(&in->cond, &in->mu, &deadline) == ETIMEDOUT) {
+			break;
+		}
+	}
+	int rc = DL_TIMEOUT;
+	if (in->closed) {
+		rc = DL_CLOSED;
+	} else if (!in->queue.empty()) {
+		*frame = in->queue.front();
+		in->queue.pop_front();
+		rc = DL_OK;
+	}
+	pthread_mutex_unlock(&in->mu);
+	return rc;
+}
+
+void dl_frame_free(dl_frame *frame) {
+	free(frame->data);
+	free(frame->audio);
+	frame->data = NULL;
+	frame->audio = NULL;
+}
+
+void dl_stop(dl_input *in) {
+	pthread_mutex_lock(&in->mu);
+	bool closed = in->closed;
+	in->closed = true;
+	pthread_cond_broadcast(&in->cond);
+	pthread_mutex_unlock(&in->mu);
+	if (!closed) {
+		// Outside the lock: StopStreams waits for a callback in progress
+		in->input->StopStreams();
+	}
+}
+
+void dl_free(dl_input *in) {
+	in->input->DisableVideoInput();
+	in->input->DisableAudioInput();
+	in->input->SetCallback(NULL);
+	in->callback->Release();
+	in->input->Release();
+	in->device->Release();
+	for (size_t i = 0; i < in->queue.size(); i++) {
+		dl_frame_free(&in->queue[i]);
+	}
+	pthread_cond_destroy(&in->cond);
+	pthread_mutex_destroy(&in->mu);
+	delete in;
+}
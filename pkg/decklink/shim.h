@@ -0,0 +1,55 @@
+//go:build decklink && linux
+
+#ifndef DECKLINK_SHIM_H
+#define DECKLINK_SHIM_H
+
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// dl_read results
+#define DL_OK 0
+#define DL_TIMEOUT 1
+#define DL_CLOSED 2
+
+typedef struct dl_input dl_input;
+
+// A captured frame, packed rows, with the audio that arrived with it
+typedef struct {
+	void *data;
+	int size;
+	int width;
+	int height;
+	int rgb;       // BGRA rather than UYVY
+	int no_signal; // No input source; data is black
+	int64_t duration; // Mode frame duration in units of scale
+	int64_t scale;
+	void *audio;   // Interleaved s16le, NULL when none
+	int audio_size;
+} dl_frame;
+
+int dl_available(void);
+
+// dl_device_names returns the number of devices with inputs, setting
+// names to a malloc'd array of malloc'd names
+int dl_device_names(char ***names);
+
+// dl_open starts capturing, in the given mode if the card can't detect it
+dl_input *dl_open(int index, int width, int height, double fps, int channels, const char **err);
+
+// dl_read waits up to timeout_ms for the next frame
+int dl_read(dl_input *in, int timeout_ms, dl_frame *frame);
+void dl_frame_free(dl_frame *frame);
+
+// dl_stop stops capture and wakes readers; dl_free releases the device
+// once no reader is left
+void dl_stop(dl_input *in);
+void dl_free(dl_input *in);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
@@ -0,0 +1,13 @@
+//go:build !decklink || !linux
+
+package decklink
+
+import "errors"
+
+var errNotAvailable = errors.New("DeckLink SDK not available - build with -tags decklink on Linux")
+
+// IsAvailable reports whether the Desktop Video driver can be loaded
+func IsAvailable() bool { return false }
+
+// Devices lists the DeckLink devices with inputs
+func Devices() ([]string, error) { return nil, errNotAvailable }
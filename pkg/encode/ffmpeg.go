@@ -0,0 +1,336 @@
+package encode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/pkg/input"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// pipeEncoder drives an FFmpeg child process that reads raw frames on stdin
+// and writes CMAF segments to a scratch directory. Hardware plugins build on
+// it by supplying the codec name and device arguments.
+type pipeEncoder struct {
+	name    string
+	encType string
+	caps    Capabilities
+
+	// codecFor maps a config codec (h264, hevc) to an FFmpeg encoder name
+	codecFor func(codec string) (string, error)
+	// hwArgs returns global device args and the -vf upload chain
+	hwArgs func(cfg Config) ([]string, string)
+	// codecArgs returns encoder options placed after -c:v
+	codecArgs func(cfg Config) []string
+	// preset translates the config preset, empty to omit
+	preset func(preset string) string
+
+	mu        sync.Mutex
+	cfg       Config
+	ffmpeg    *ffmpeg.FFmpeg
+	proc      *ffmpeg.Process
+	workDir   string
+	codec     string
+	startTime time.Time
+	emitted   map[int]bool
+	pending   []*output.Segment
+}
+
+// Name returns the plugin name
+func (e *pipeEncoder) Name() string {
+	return e.name
+}
+
+// Type returns the encoder type
+func (e *pipeEncoder) Type() string {
+	return e.encType
+}
+
+// Capabilities returns what the encoder supports
+func (e *pipeEncoder) Capabilities() Capabilities {
+	return e.caps
+}
+
+// Open validates the config and prepares the scratch directory.
+// The FFmpeg process is started lazily on the first frame, once the
+// input pixel format is known.
+func (e *pipeEncoder) Open(cfg Config) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if cfg.Codec == "" {
+		cfg.Codec = "h264"
+	}
+	codec, err := e.codecFor(cfg.Codec)
+	if err != nil {
+		return err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return fmt.Errorf("%s: width and height are required", e.name)
+	}
+	if e.caps.MaxWidth > 0 && (cfg.Width > e.caps.MaxWidth || cfg.Height > e.caps.MaxHeight) {
+		return fmt.Errorf("%s: %dx%d exceeds max %dx%d", e.name, cfg.Width, cfg.Height, e.caps.MaxWidth, e.caps.MaxHeight)
+	}
+	if cfg.Framerate == 0 {
+		cfg.Framerate = 30
+	}
+	if cfg.SegmentDur == 0 {
+		cfg.SegmentDur = 2.0
+	}
+	if cfg.GOP == 0 {
+		cfg.GOP = int(float64(cfg.Framerate) * cfg.SegmentDur)
+	}
+	if cfg.Bitrate == 0 {
+		cfg.Bitrate = 6000
+	}
+
+	ff, err := ffmpeg.New()
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.name, err)
+	}
+
+	workDir, err := os.MkdirTemp("", e.name+"_*")
+	if err != nil {
+		return fmt.Errorf("create work dir: %w", err)
+	}
+
+	e.cfg = cfg
+	e.ffmpeg = ff
+	e.codec = codec
+	e.workDir = workDir
+	e.emitted = make(map[int]bool)
+	return nil
+}
+
+// Close stops the FFmpeg process and removes the scratch directory
+func (e *pipeEncoder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.proc != nil {
+		e.proc.Kill()
+		<-e.proc.Done()
+		e.proc = nil
+	}
+	if e.workDir != "" {
+		os.RemoveAll(e.workDir)
+		e.workDir = ""
+	}
+	return nil
+}
+
+// Encode pipes a frame to FFmpeg and returns the next completed segment, if any
+func (e *pipeEncoder) Encode(ctx context.Context, frame *input.Frame) (*output.Segment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.workDir == "" {
+		return nil, fmt.Errorf("%s: encoder not open", e.name)
+	}
+
+	if e.proc == nil {
+		if err := e.start(ctx, frame); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case err := <-e.proc.Done():
+		e.proc = nil
+		return nil, fmt.Errorf("%s: ffmpeg exited: %v", e.name, err)
+	default:
+	}
+
+	if _, err := e.proc.Write(frame.Data); err != nil {
+		return nil, fmt.Errorf("%s: write frame: %w", e.name, err)
+	}
+
+	segs, err := e.collect(false)
+	if err != nil {
+		return nil, err
+	}
+	e.pending = append(e.pending, segs...)
+	if len(e.pending) == 0 {
+		return nil, nil
+	}
+	seg := e.pending[0]
+	e.pending = e.pending[1:]
+	return seg, nil
+}
+
+// Flush closes FFmpeg's input and returns all remaining segments
+func (e *pipeEncoder) Flush(ctx context.Context) ([]*output.Segment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.proc == nil {
+		segs := e.pending
+		e.pending = nil
+		return segs, nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- e.proc.Close() }()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		e.proc.Kill()
+		return nil, ctx.Err()
+	}
+	e.proc = nil
+
+	segs, err := e.collect(true)
+	segs = append(e.pending, segs...)
+	e.pending = nil
+	return segs, err
+}
+
+// GetInitSegment returns the init segment once FFmpeg has written it
+func (e *pipeEncoder) GetInitSegment() *output.InitSegment {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.workDir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(e.workDir, "init.mp4"))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return &output.InitSegment{
+		Data:      data,
+		Codec:     e.cfg.Codec,
+		Width:     e.cfg.Width,
+		Height:    e.cfg.Height,
+		Framerate: e.cfg.Framerate,
+	}
+}
+
+// start launches FFmpeg using the first frame's pixel format
+func (e *pipeEncoder) start(ctx context.Context, frame *input.Frame) error {
+	pixFmt, err := ffmpegPixelFormat(frame.Format)
+	if err != nil {
+		return err
+	}
+
+	var hwArgs []string
+	var filter string
+	if e.hwArgs != nil {
+		hwArgs, filter = e.hwArgs(e.cfg)
+	}
+	var codecArgs []string
+	if e.codecArgs != nil {
+		codecArgs = e.codecArgs(e.cfg)
+	}
+	var preset string
+	if e.preset != nil {
+		preset = e.preset(e.cfg.Preset)
+	}
+
+	width, height := e.cfg.Width, e.cfg.Height
+	if frame.Width > 0 && frame.Height > 0 {
+		width, height = frame.Width, frame.Height
+	}
+
+	proc, err := e.ffmpeg.StartEncoder(ctx, ffmpeg.EncoderConfig{
+		InputFormat:     "rawvideo",
+		PixelFormat:     pixFmt,
+		Width:           width,
+		Height:          height,
+		Framerate:       e.cfg.Framerate,
+		HWArgs:          hwArgs,
+		VideoFilter:     filter,
+		Codec:           e.codec,
+		EncoderOptions:  codecArgs,
+		Preset:          preset,
+		Bitrate:         e.cfg.Bitrate,
+		GOP:             e.cfg.GOP,
+		OutputPath:      e.workDir,
+		SegmentDuration: e.cfg.SegmentDur,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.name, err)
+	}
+
+	e.proc = proc
+	e.startTime = time.Unix(0, frame.Timestamp)
+	if frame.Timestamp == 0 {
+		e.startTime = time.Now()
+	}
+	return nil
+}
+
+// collect reads finished segments from the scratch directory in sequence order.
+// While FFmpeg is running the newest file may still be open, so it is only
+// returned when final is set.
+func (e *pipeEncoder) collect(final bool) ([]*output.Segment, error) {
+	files, err := filepath.Glob(filepath.Join(e.workDir, "segment_*.m4s"))
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []int
+	paths := make(map[int]string)
+	for _, f := range files {
+		var seq int
+		if _, err := fmt.Sscanf(filepath.Base(f), "segment_%05d.m4s", &seq); err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+		paths[seq] = f
+	}
+	sort.Ints(seqs)
+	if !final && len(seqs) > 0 {
+		seqs = seqs[:len(seqs)-1]
+	}
+
+	segDur := time.Duration(e.cfg.SegmentDur * float64(time.Second))
+
+	var result []*output.Segment
+	for _, seq := range seqs {
+		if e.emitted[seq] {
+			continue
+		}
+		data, err := os.ReadFile(paths[seq])
+		if err != nil {
+			return result, fmt.Errorf("read segment %d: %w", seq, err)
+		}
+		e.emitted[seq] = true
+		os.Remove(paths[seq])
+
+		result = append(result, &output.Segment{
+			Sequence:  seq,
+			Data:      data,
+			StartTime: e.startTime.Add(time.Duration(seq-1) * segDur).UnixMilli(),
+			Duration:  e.cfg.SegmentDur,
+		})
+	}
+	return result, nil
+}
+
+// ffmpegPixelFormat maps an input pixel format to FFmpeg's name for it
+func ffmpegPixelFormat(f input.PixelFormat) (string, error) {
+	switch f {
+	case input.FormatYUV420P, "":
+		return "yuv420p", nil
+	case input.FormatYUYV:
+		return "yuyv422", nil
+	case input.FormatUYVY:
+		return "uyvy422", nil
+	case input.FormatNV12:
+		return "nv12", nil
+	case input.FormatRGB24:
+		return "rgb24", nil
+	case input.FormatBGRA:
+		return "bgra", nil
+	default:
+		return "", fmt.Errorf("unsupported pixel format: %s", f)
+	}
+}
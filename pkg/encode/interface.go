@@ -46,6 +46,7 @@ type Config struct {
 	GOP        int    // Keyframe interval in frames
 	BFrames    int
 	SegmentDur float64 // Target segment duration in seconds
+	Device     string  // GPU index for NVENC, DRM render node for VA-API and QSV (empty = default)
 }
 
 // Registry holds registered encoder plugins
@@ -0,0 +1,246 @@
+package encode
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func init() {
+	Register("nvenc", func() Encoder { return NewNVENC() })
+}
+
+// NewNVENC creates an encoder for NVIDIA GPUs run by FFmpeg. Config.Device
+// picks the GPU by index. GeForce cards take a limited number of encode
+// sessions at once; a SessionPool shares them out.
+func NewNVENC() Encoder {
+	e := &pipeEncoder{
+		name:    "nvenc",
+		encType: "nvenc",
+		caps: Capabilities{
+			SupportedCodecs:   []string{"h264", "hevc", "av1"},
+			SupportsHardware:  true,
+			MaxWidth:          8192,
+			MaxHeight:         8192,
+			SupportsBFrames:   true,
+			SupportsLookahead: true,
+		},
+		codecFor: func(codec string) (string, error) {
+			return ffmpeg.EncoderName("nvenc", codec)
+		},
+		codecArgs: func(cfg Config) []string {
+			if cfg.Device == "" {
+				return nil
+			}
+			return []string{"-gpu", cfg.Device}
+		},
+	}
+	// NVENC names its presets p1 (fastest) to p7
+	e.preset = func(preset string) string { return ffmpeg.EncoderPreset("nvenc", e.codec, preset) }
+	return e
+}
+
+// GPU is an NVIDIA GPU that can encode
+type GPU struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Driver      string `json:"driver"`
+	MaxSessions int    `json:"max_sessions"` // Concurrent encode sessions (0 = unlimited)
+}
+
+// DetectGPUs lists the NVIDIA GPUs with nvidia-smi, with the session limit
+// of each
+func DetectGPUs(ctx context.Context) ([]GPU, error) {
+	out, err := exec.CommandContext(ctx, "nvidia-smi",
+		"--query-gpu=index,name,driver_version", "--format=csv,noheader").Output()
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi: %w", err)
+	}
+	return parseGPUs(string(out))
+}
+
+// parseGPUs parses nvidia-smi's CSV output
+//
+//	0, NVIDIA GeForce RTX 4090, 550.54.14
+func parseGPUs(output string) ([]GPU, error) {
+	var gpus []GPU
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("nvidia-smi: unexpected line %q", line)
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("nvidia-smi: bad GPU index in %q", line)
+		}
+		name := strings.TrimSpace(strings.Join(fields[1:len(fields)-1], ","))
+		driver := strings.TrimSpace(fields[len(fields)-1])
+		gpus = append(gpus, GPU{
+			Index:       index,
+			Name:        name,
+			Driver:      driver,
+			MaxSessions: sessionLimit(name, driver),
+		})
+	}
+	return gpus, scanner.Err()
+}
+
+// sessionLimit returns how many encode sessions the driver allows a GPU at
+// once. The cap applies to consumer cards only and has been raised over
+// driver releases; Quadro, RTX professional and data center cards have
+// none.
+func sessionLimit(name, driver string) int {
+	if !strings.Contains(name, "GeForce") && !strings.Contains(name, "TITAN") {
+		return 0
+	}
+	major, _ := strconv.Atoi(strings.SplitN(driver, ".", 2)[0])
+	switch {
+	case major >= 550:
+		return 8
+	case major >= 530:
+		return 5
+	default:
+		return 3
+	}
+}
+
+// ErrNoSession is returned when every GPU has all the sessions it allows
+var ErrNoSession = errors.New("nvenc: all encode sessions in use")
+
+// SessionPool shares the encode sessions of a set of GPUs. A session goes
+// to the GPU with the fewest in use; waiters get freed sessions in the
+// order they asked.
+type SessionPool struct {
+	mu      sync.Mutex
+	gpus    []GPU
+	inUse   []int           // By position in gpus
+	waiters []chan *Session // Oldest first
+}
+
+// NewSessionPool creates a pool over gpus
+func NewSessionPool(gpus []GPU) *SessionPool {
+	return &SessionPool{gpus: gpus, inUse: make([]int, len(gpus))}
+}
+
+// TryAcquire takes a session without waiting, or returns ErrNoSession
+func (p *SessionPool) TryAcquire() (*Session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.waiters) > 0 {
+		return nil, ErrNoSession
+	}
+	return p.take()
+}
+
+// Acquire takes a session, waiting for one to be released if all are in
+// use
+func (p *SessionPool) Acquire(ctx context.Context) (*Session, error) {
+	p.mu.Lock()
+	if len(p.waiters) == 0 {
+		if s, err := p.take(); !errors.Is(err, ErrNoSession) {
+			p.mu.Unlock()
+			return s, err
+		}
+	}
+	wait := make(chan *Session, 1)
+	p.waiters = append(p.waiters, wait)
+	p.mu.Unlock()
+
+	select {
+	case s := <-wait:
+		return s, nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		if i := slices.Index(p.waiters, wait); i >= 0 {
+			p.waiters = slices.Delete(p.waiters, i, i+1)
+			p.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		p.mu.Unlock()
+		// Handed a session as ctx ended
+		(<-wait).Release()
+		return nil, ctx.Err()
+	}
+}
+
+// take starts a session on the least loaded GPU with one free
+func (p *SessionPool) take() (*Session, error) {
+	if len(p.gpus) == 0 {
+		return nil, errors.New("nvenc: no GPUs")
+	}
+	best := -1
+	for i, gpu := range p.gpus {
+		if gpu.MaxSessions > 0 && p.inUse[i] >= gpu.MaxSessions {
+			continue
+		}
+		if best < 0 || p.inUse[i] < p.inUse[best] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, ErrNoSession
+	}
+	p.inUse[best]++
+	return &Session{pool: p, slot: best, gpu: p.gpus[best].Index}, nil
+}
+
+// release ends a session on the GPU at slot, passing it to the oldest waiter
+func (p *SessionPool) release(slot int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.waiters) > 0 {
+		wait := p.waiters[0]
+		p.waiters = p.waiters[1:]
+		wait <- &Session{pool: p, slot: slot, gpu: p.gpus[slot].Index}
+		return
+	}
+	p.inUse[slot]--
+}
+
+// GPUStatus is a GPU's session use
+type GPUStatus struct {
+	GPU
+	InUse int `json:"in_use"`
+}
+
+// Status returns the session use of each GPU and how many are waiting
+func (p *SessionPool) Status() (gpus []GPUStatus, waiting int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	gpus = make([]GPUStatus, len(p.gpus))
+	for i, gpu := range p.gpus {
+		gpus[i] = GPUStatus{GPU: gpu, InUse: p.inUse[i]}
+	}
+	return gpus, len(p.waiters)
+}
+
+// Session is an encode session held on a GPU until released
+type Session struct {
+	pool *SessionPool
+	slot int
+	gpu  int
+	once sync.Once
+}
+
+// GPU returns the index of the GPU the session is on
+func (s *Session) GPU() int {
+	return s.gpu
+}
+
+// Release gives the session back; later calls do nothing
+func (s *Session) Release() {
+	s.once.Do(func() { s.pool.release(s.slot) })
+}
@@ -0,0 +1,89 @@
+package encode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseGPUs(t *testing.T) {
+	gpus, err := parseGPUs("0, NVIDIA GeForce RTX 4090, 550.54.14\n1, NVIDIA RTX A4000, 535.104.05\n2, NVIDIA GeForce GTX 1660, 470.82.01\n")
+	if err != nil {
+		t.Fatalf("parseGPUs: %v", err)
+	}
+	want := []GPU{
+		{Index: 0, Name: "NVIDIA GeForce RTX 4090", Driver: "550.54.14", MaxSessions: 8},
+		{Index: 1, Name: "NVIDIA RTX A4000", Driver: "535.104.05", MaxSessions: 0},
+		{Index: 2, Name: "NVIDIA GeForce GTX 1660", Driver: "470.82.01", MaxSessions: 3},
+	}
+	if len(gpus) != len(want) {
+		t.Fatalf("got %d GPUs, want %d", len(gpus), len(want))
+	}
+	for i := range want {
+		if gpus[i] != want[i] {
+			t.Errorf("GPU %d = %+v, want %+v", i, gpus[i], want[i])
+		}
+	}
+
+	if _, err := parseGPUs("garbage\n"); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestSessionPool(t *testing.T) {
+	pool := NewSessionPool([]GPU{{Index: 0, MaxSessions: 1}, {Index: 1, MaxSessions: 1}})
+
+	a, err := pool.TryAcquire()
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	b, err := pool.TryAcquire()
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	if a.GPU() == b.GPU() {
+		t.Errorf("both sessions on GPU %d, want one per GPU", a.GPU())
+	}
+	if _, err := pool.TryAcquire(); !errors.Is(err, ErrNoSession) {
+		t.Fatalf("TryAcquire on a full pool = %v, want ErrNoSession", err)
+	}
+
+	// A waiter gets the next session released, once
+	got := make(chan *Session)
+	go func() {
+		s, err := pool.Acquire(context.Background())
+		if err != nil {
+			t.Errorf("Acquire: %v", err)
+		}
+		got <- s
+	}()
+	for {
+		if _, waiting := pool.Status(); waiting == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	a.Release()
+	a.Release()
+	c := <-got
+	if c.GPU() != a.GPU() {
+		t.Errorf("waiter got GPU %d, want the released GPU %d", c.GPU(), a.GPU())
+	}
+	if _, err := pool.TryAcquire(); !errors.Is(err, ErrNoSession) {
+		t.Errorf("TryAcquire after hand-over = %v, want ErrNoSession", err)
+	}
+
+	// A cancelled wait leaves the queue
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire = %v, want deadline exceeded", err)
+	}
+	b.Release()
+	c.Release()
+	status, waiting := pool.Status()
+	if waiting != 0 || status[0].InUse != 0 || status[1].InUse != 0 {
+		t.Errorf("status after releasing all = %+v, %d waiting", status, waiting)
+	}
+}
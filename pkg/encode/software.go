@@ -0,0 +1,30 @@
+package encode
+
+import (
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func init() {
+	Register("software", func() Encoder { return NewSoftware() })
+}
+
+// NewSoftware creates a CPU encoder run by FFmpeg: libx264, libx265 or
+// SVT-AV1. It needs nothing beyond FFmpeg, unlike the in-process x264
+// plugin, so it is always registered.
+func NewSoftware() Encoder {
+	e := &pipeEncoder{
+		name:    "software",
+		encType: "software",
+		caps: Capabilities{
+			SupportedCodecs:   []string{"h264", "hevc", "av1"},
+			SupportsBFrames:   true,
+			SupportsLookahead: true,
+		},
+		codecFor: func(codec string) (string, error) {
+			return ffmpeg.EncoderName("software", codec)
+		},
+	}
+	// SVT-AV1 numbers its presets
+	e.preset = func(preset string) string { return ffmpeg.PresetFor(e.codec, preset) }
+	return e
+}
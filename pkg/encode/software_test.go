@@ -0,0 +1,33 @@
+package encode
+
+import "testing"
+
+func TestSoftwareEncoder(t *testing.T) {
+	enc, ok := Get("software")
+	if !ok {
+		t.Fatal("software encoder not registered")
+	}
+	p := enc.(*pipeEncoder)
+	tests := []struct {
+		codec, encoder, preset, want string
+	}{
+		{"h264", "libx264", "fast", "fast"},
+		{"h265", "libx265", "veryslow", "veryslow"},
+		{"av1", "libsvtav1", "fast", "8"},
+		{"av1", "libsvtav1", "ultrafast", "12"},
+	}
+	for _, tt := range tests {
+		name, err := p.codecFor(tt.codec)
+		if err != nil || name != tt.encoder {
+			t.Errorf("codecFor(%s) = %q, %v; want %s", tt.codec, name, err, tt.encoder)
+			continue
+		}
+		p.codec = name
+		if got := p.preset(tt.preset); got != tt.want {
+			t.Errorf("%s preset %s = %q, want %q", name, tt.preset, got, tt.want)
+		}
+	}
+	if _, err := p.codecFor("vp9"); err == nil {
+		t.Error("expected an error for vp9")
+	}
+}
@@ -0,0 +1,88 @@
+package encode
+
+import (
+	"fmt"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func init() {
+	Register("vaapi", func() Encoder { return NewVAAPI() })
+	Register("qsv", func() Encoder { return NewQSV() })
+}
+
+// NewVAAPI creates an encoder for Intel/AMD GPUs via VA-API, on the DRM
+// render node in Config.Device
+func NewVAAPI() Encoder {
+	return &pipeEncoder{
+		name:    "vaapi",
+		encType: "vaapi",
+		caps: Capabilities{
+			SupportedCodecs:   []string{"h264", "hevc", "av1"},
+			SupportsHardware:  true,
+			MaxWidth:          4096,
+			MaxHeight:         4096,
+			SupportsBFrames:   false,
+			SupportsLookahead: false,
+		},
+		codecFor: func(codec string) (string, error) {
+			switch codec {
+			case "h264":
+				return "h264_vaapi", nil
+			case "hevc", "h265":
+				return "hevc_vaapi", nil
+			case "av1":
+				return "av1_vaapi", nil
+			default:
+				return "", fmt.Errorf("vaapi: unsupported codec %s", codec)
+			}
+		},
+		hwArgs: func(cfg Config) ([]string, string) {
+			return ffmpeg.HWEncodeArgs("vaapi", cfg.Device)
+		},
+		// VA-API encoders have no x264-style presets
+		preset: func(string) string { return "" },
+	}
+}
+
+// NewQSV creates an encoder for Intel Quick Sync Video
+func NewQSV() Encoder {
+	return &pipeEncoder{
+		name:    "qsv",
+		encType: "qsv",
+		caps: Capabilities{
+			SupportedCodecs:   []string{"h264", "hevc", "av1"},
+			SupportsHardware:  true,
+			MaxWidth:          4096,
+			MaxHeight:         4096,
+			SupportsBFrames:   true,
+			SupportsLookahead: true,
+		},
+		codecFor: func(codec string) (string, error) {
+			switch codec {
+			case "h264":
+				return "h264_qsv", nil
+			case "hevc", "h265":
+				return "hevc_qsv", nil
+			case "av1":
+				return "av1_qsv", nil
+			default:
+				return "", fmt.Errorf("qsv: unsupported codec %s", codec)
+			}
+		},
+		hwArgs: func(cfg Config) ([]string, string) {
+			return ffmpeg.HWEncodeArgs("qsv", cfg.Device)
+		},
+		// QSV accepts veryfast..veryslow; map the x264-only extremes
+		preset: func(preset string) string {
+			switch preset {
+			case "ultrafast", "superfast":
+				return "veryfast"
+			case "placebo":
+				return "veryslow"
+			default:
+				return preset
+			}
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package encode
+
+import "testing"
+
+func TestVAAPICodecs(t *testing.T) {
+	for _, enc := range []Encoder{NewVAAPI(), NewQSV()} {
+		p := enc.(*pipeEncoder)
+		for _, codec := range p.caps.SupportedCodecs {
+			name, err := p.codecFor(codec)
+			if err != nil || name != codec+"_"+p.encType {
+				t.Errorf("%s: codecFor(%s) = %q, %v", p.name, codec, name, err)
+			}
+		}
+		if _, err := p.codecFor("vp9"); err == nil {
+			t.Errorf("%s: expected an error for vp9", p.name)
+		}
+	}
+}
+
+func TestVAAPIArgs(t *testing.T) {
+	tests := []struct {
+		enc    Encoder
+		preset string
+		want   string
+		hw     string // Device argument
+	}{
+		{NewVAAPI(), "fast", "", "/dev/dri/renderD129"},
+		{NewQSV(), "ultrafast", "veryfast", "qsv=hw:/dev/dri/renderD129"},
+		{NewQSV(), "placebo", "veryslow", "qsv=hw:/dev/dri/renderD129"},
+		{NewQSV(), "medium", "medium", "qsv=hw:/dev/dri/renderD129"},
+	}
+	for _, tt := range tests {
+		p := tt.enc.(*pipeEncoder)
+		if got := p.preset(tt.preset); got != tt.want {
+			t.Errorf("%s: preset %s = %q, want %q", p.name, tt.preset, got, tt.want)
+		}
+		args, upload := p.hwArgs(Config{Device: "/dev/dri/renderD129"})
+		if len(args) < 2 || args[1] != tt.hw || upload == "" {
+			t.Errorf("%s: hwArgs = %q, %q; want %s and an upload filter", p.name, args, upload, tt.hw)
+		}
+		if !p.caps.SupportsHardware {
+			t.Errorf("%s: not marked as hardware", p.name)
+		}
+	}
+	for _, typ := range []string{"vaapi", "qsv"} {
+		if enc, ok := Get(typ); !ok || enc.Type() != typ {
+			t.Errorf("%s: not registered", typ)
+		}
+	}
+}
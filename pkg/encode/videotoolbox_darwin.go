@@ -0,0 +1,642 @@
+//go:build cgo
+
+package encode
+
+/*
+#cgo LDFLAGS: -framework VideoToolbox -framework CoreMedia -framework CoreVideo -framework CoreFoundation
+
+#include <stdlib.h>
+#include <string.h>
+#include <pthread.h>
+#include <VideoToolbox/VideoToolbox.h>
+
+#define VT_TIMESCALE 90000
+#define VT_MAX_PARAMS 4
+
+// vt_frame is an encoded frame, AVCC with 4-byte lengths
+typedef struct vt_frame {
+    uint8_t* data;
+    size_t size;
+    int64_t pts, dts;
+    int keyframe;
+    struct vt_frame* next;
+} vt_frame;
+
+typedef struct {
+    VTCompressionSessionRef session;
+    CMVideoCodecType codec;
+    int width, height;
+    CVPixelBufferPoolRef pool;
+    OSType format;
+
+    // Guards the rest: the output callback runs on a VideoToolbox thread
+    pthread_mutex_t mu;
+    vt_frame *head, *tail;
+    OSStatus err;
+    uint8_t* params[VT_MAX_PARAMS];
+    size_t param_sizes[VT_MAX_PARAMS];
+    int nparams;
+} vt_enc;
+
+// vt_params copies the parameter sets of the first keyframe's format
+static void vt_params(vt_enc* e, CMFormatDescriptionRef fd) {
+    size_t count = 0;
+    int hevc = e->codec == kCMVideoCodecType_HEVC;
+    OSStatus st = hevc
+        ? CMVideoFormatDescriptionGetHEVCParameterSetAtIndex(fd, 0, NULL, NULL, &count, NULL)
+        : CMVideoFormatDescriptionGetH264ParameterSetAtIndex(fd, 0, NULL, NULL, &count, NULL);
+    if (st != noErr) {
+        return;
+    }
+    if (count > VT_MAX_PARAMS) {
+        count = VT_MAX_PARAMS;
+    }
+    for (size_t i = 0; i < count; i++) {
+        const uint8_t* p = NULL;
+        size_t n = 0;
+        st = hevc
+            ? CMVideoFormatDescriptionGetHEVCParameterSetAtIndex(fd, i, &p, &n, NULL, NULL)
+            : CMVideoFormatDescriptionGetH264ParameterSetAtIndex(fd, i, &p, &n, NULL, NULL);
+        if (st != noErr) {
+            return;
+        }
+        e->params[i] = malloc(n);
+        memcpy(e->params[i], p, n);
+        e->param_sizes[i] = n;
+    }
+    e->nparams = (int)count;
+}
+
+static void vt_output(void* ref, void* frameRef, OSStatus status, VTEncodeInfoFlags flags, CMSampleBufferRef sb) {
+    vt_enc* e = ref;
+    if (status != noErr) {
+        pthread_mutex_lock(&e->mu);
+        e->err = status;
+        pthread_mutex_unlock(&e->mu);
+        return;
+    }
+    if (sb == NULL) {
+        return; // Dropped
+    }
+
+    int key = 1;
+    CFArrayRef att = CMSampleBufferGetSampleAttachmentsArray(sb, false);
+    if (att != NULL && CFArrayGetCount(att) > 0) {
+        CFDictionaryRef d = CFArrayGetValueAtIndex(att, 0);
+        CFBooleanRef notSync;
+        if (CFDictionaryGetValueIfPresent(d, kCMSampleAttachmentKey_NotSync, (const void**)&notSync) && CFBooleanGetValue(notSync)) {
+            key = 0;
+        }
+    }
+
+    CMBlockBufferRef bb = CMSampleBufferGetDataBuffer(sb);
+    size_t size = CMBlockBufferGetDataLength(bb);
+    vt_frame* f = calloc(1, sizeof *f);
+    f->data = malloc(size);
+    f->size = size;
+    CMBlockBufferCopyDataBytes(bb, 0, size, f->data);
+    CMTime pts = CMSampleBufferGetPresentationTimeStamp(sb);
+    CMTime dts = CMSampleBufferGetDecodeTimeStamp(sb);
+    if (!CMTIME_IS_VALID(dts)) {
+        dts = pts;
+    }
+    f->pts = CMTimeConvertScale(pts, VT_TIMESCALE, kCMTimeRoundingMethod_Default).value;
+    f->dts = CMTimeConvertScale(dts, VT_TIMESCALE, kCMTimeRoundingMethod_Default).value;
+    f->keyframe = key;
+
+    pthread_mutex_lock(&e->mu);
+    if (key && e->nparams == 0) {
+        vt_params(e, CMSampleBufferGetFormatDescription(sb));
+    }
+    if (e->tail != NULL) {
+        e->tail->next = f;
+    } else {
+        e->head = f;
+    }
+    e->tail = f;
+    pthread_mutex_unlock(&e->mu);
+}
+
+static void vt_setint(VTCompressionSessionRef s, CFStringRef key, int v) {
+    CFNumberRef n = CFNumberCreate(NULL, kCFNumberIntType, &v);
+    VTSessionSetProperty(s, key, n);
+    CFRelease(n);
+}
+
+// vt_open creates a hardware compression session. profile is 1 for
+// baseline, 2 for main and 3 for high (0 = high for H.264, main for HEVC).
+static vt_enc* vt_open(int width, int height, int hevc, int fps, int bitrate, int gop, int bframes, int profile, OSStatus* status) {
+    vt_enc* e = calloc(1, sizeof *e);
+    pthread_mutex_init(&e->mu, NULL);
+    e->codec = hevc ? kCMVideoCodecType_HEVC : kCMVideoCodecType_H264;
+    e->width = width;
+    e->height = height;
+
+    // Fail rather than fall back to the software encoder
+    const void* keys[] = {kVTVideoEncoderSpecification_RequireHardwareAcceleratedVideoEncoder};
+    const void* vals[] = {kCFBooleanTrue};
+    CFDictionaryRef spec = CFDictionaryCreate(NULL, keys, vals, 1, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+    *status = VTCompressionSessionCreate(NULL, width, height, e->codec, spec, NULL, NULL, vt_output, e, &e->session);
+    CFRelease(spec);
+    if (*status != noErr) {
+        pthread_mutex_destroy(&e->mu);
+        free(e);
+        return NULL;
+    }
+
+    VTCompressionSessionRef s = e->session;
+    VTSessionSetProperty(s, kVTCompressionPropertyKey_RealTime, kCFBooleanTrue);
+    VTSessionSetProperty(s, kVTCompressionPropertyKey_AllowFrameReordering, bframes > 0 ? kCFBooleanTrue : kCFBooleanFalse);
+    vt_setint(s, kVTCompressionPropertyKey_AverageBitRate, bitrate * 1000);
+    vt_setint(s, kVTCompressionPropertyKey_MaxKeyFrameInterval, gop);
+    vt_setint(s, kVTCompressionPropertyKey_ExpectedFrameRate, fps);
+
+    CFStringRef level = kVTProfileLevel_H264_High_AutoLevel;
+    if (hevc) {
+        level = kVTProfileLevel_HEVC_Main_AutoLevel;
+    } else if (profile == 1) {
+        level = kVTProfileLevel_H264_Baseline_AutoLevel;
+    } else if (profile == 2) {
+        level = kVTProfileLevel_H264_Main_AutoLevel;
+    }
+    VTSessionSetProperty(s, kVTCompressionPropertyKey_ProfileLevel, level);
+
+    *status = VTCompressionSessionPrepareToEncodeFrames(s);
+    if (*status != noErr) {
+        VTCompressionSessionInvalidate(s);
+        CFRelease(s);
+        pthread_mutex_destroy(&e->mu);
+        free(e);
+        return NULL;
+    }
+    return e;
+}
+
+// vt_bpp returns the bytes per pixel of a plane of a packed frame
+static size_t vt_bpp(OSType format, size_t plane) {
+    switch (format) {
+    case kCVPixelFormatType_32BGRA:
+        return 4;
+    case kCVPixelFormatType_24RGB:
+        return 3;
+    case kCVPixelFormatType_422YpCbCr8:
+    case kCVPixelFormatType_422YpCbCr8_yuvs:
+        return 2;
+    case kCVPixelFormatType_420YpCbCr8BiPlanarVideoRange:
+        return plane ? 2 : 1;
+    default:
+        return 1;
+    }
+}
+
+// vt_pool creates the pool of IOSurface-backed buffers frames are copied to
+static OSStatus vt_pool(vt_enc* e, OSType format) {
+    if (e->pool != NULL) {
+        CVPixelBufferPoolRelease(e->pool);
+        e->pool = NULL;
+    }
+    CFNumberRef nf = CFNumberCreate(NULL, kCFNumberSInt32Type, &format);
+    CFNumberRef nw = CFNumberCreate(NULL, kCFNumberIntType, &e->width);
+    CFNumberRef nh = CFNumberCreate(NULL, kCFNumberIntType, &e->height);
+    CFDictionaryRef io = CFDictionaryCreate(NULL, NULL, NULL, 0, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+    const void* keys[] = {kCVPixelBufferPixelFormatTypeKey, kCVPixelBufferWidthKey, kCVPixelBufferHeightKey, kCVPixelBufferIOSurfacePropertiesKey};
+    const void* vals[] = {nf, nw, nh, io};
+    CFDictionaryRef attrs = CFDictionaryCreate(NULL, keys, vals, 4, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+    OSStatus st = CVPixelBufferPoolCreate(NULL, NULL, attrs, &e->pool);
+    CFRelease(attrs);
+    CFRelease(io);
+    CFRelease(nf);
+    CFRelease(nw);
+    CFRelease(nh);
+    if (st == kCVReturnSuccess) {
+        e->format = format;
+    }
+    return st;
+}
+
+// vt_fill copies a packed frame into the buffer's planes, honouring strides
+static void vt_fill(CVPixelBufferRef pb, const uint8_t* src, OSType format) {
+    CVPixelBufferLockBaseAddress(pb, 0);
+    if (!CVPixelBufferIsPlanar(pb)) {
+        size_t h = CVPixelBufferGetHeight(pb);
+        size_t stride = CVPixelBufferGetBytesPerRow(pb);
+        size_t line = CVPixelBufferGetWidth(pb) * vt_bpp(format, 0);
+        uint8_t* dst = CVPixelBufferGetBaseAddress(pb);
+        for (size_t y = 0; y < h; y++) {
+            memcpy(dst + y * stride, src + y * line, line);
+        }
+    } else {
+        for (size_t i = 0; i < CVPixelBufferGetPlaneCount(pb); i++) {
+            size_t h = CVPixelBufferGetHeightOfPlane(pb, i);
+            size_t stride = CVPixelBufferGetBytesPerRowOfPlane(pb, i);
+            size_t line = CVPixelBufferGetWidthOfPlane(pb, i) * vt_bpp(format, i);
+            uint8_t* dst = CVPixelBufferGetBaseAddressOfPlane(pb, i);
+            for (size_t y = 0; y < h; y++) {
+                memcpy(dst + y * stride, src + y * line, line);
+            }
+            src += line * h;
+        }
+    }
+    CVPixelBufferUnlockBaseAddress(pb, 0);
+}
+
+// vt_encode submits a frame; its output is queued by the callback
+static OSStatus vt_encode(vt_enc* e, const uint8_t* src, OSType format, int64_t pts, int64_t dur) {
+    OSStatus st;
+    if (e->pool == NULL || e->format != format) {
+        if ((st = vt_pool(e, format)) != kCVReturnSuccess) {
+            return st;
+        }
+    }
+    CVPixelBufferRef pb = NULL;
+    if ((st = CVPixelBufferPoolCreatePixelBuffer(NULL, e->pool, &pb)) != kCVReturnSuccess) {
+        return st;
+    }
+    vt_fill(pb, src, format);
+    st = VTCompressionSessionEncodeFrame(e->session, pb, CMTimeMake(pts, VT_TIMESCALE), CMTimeMake(dur, VT_TIMESCALE), NULL, NULL, NULL);
+    CVPixelBufferRelease(pb);
+    if (st == noErr) {
+        pthread_mutex_lock(&e->mu);
+        st = e->err;
+        pthread_mutex_unlock(&e->mu);
+    }
+    return st;
+}
+
+// vt_complete waits for the frames submitted to be output
+static OSStatus vt_complete(vt_enc* e) {
+    return VTCompressionSessionCompleteFrames(e->session, kCMTimeInvalid);
+}
+
+// vt_pop takes the oldest queued frame, NULL when there is none
+static vt_frame* vt_pop(vt_enc* e) {
+    pthread_mutex_lock(&e->mu);
+    vt_frame* f = e->head;
+    if (f != NULL) {
+        e->head = f->next;
+        if (e->head == NULL) {
+            e->tail = NULL;
+        }
+    }
+    pthread_mutex_unlock(&e->mu);
+    return f;
+}
+
+static void vt_frame_free(vt_frame* f) {
+    free(f->data);
+    free(f);
+}
+
+// vt_param returns parameter set i, NULL until a keyframe is output
+static const uint8_t* vt_param(vt_enc* e, int i, size_t* size) {
+    pthread_mutex_lock(&e->mu);
+    const uint8_t* p = NULL;
+    if (i < e->nparams) {
+        p = e->params[i];
+        *size = e->param_sizes[i];
+    }
+    pthread_mutex_unlock(&e->mu);
+    return p;
+}
+
+static void vt_close(vt_enc* e) {
+    VTCompressionSessionInvalidate(e->session);
+    CFRelease(e->session);
+    if (e->pool != NULL) {
+        CVPixelBufferPoolRelease(e->pool);
+    }
+    for (vt_frame* f = e->head; f != NULL;) {
+        vt_frame* next = f->next;
+        vt_frame_free(f);
+        f = next;
+    }
+    for (int i = 0; i < e->nparams; i++) {
+        free(e->params[i]);
+    }
+    pthread_mutex_destroy(&e->mu);
+    free(e);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/video-system/go-video-capture/internal/fmp4"
+	"github.com/video-system/go-video-capture/internal/h26x"
+	"github.com/video-system/go-video-capture/pkg/input"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+// vtTimescale is the track timescale, VT_TIMESCALE above
+const vtTimescale = 90000
+
+func init() {
+	Register("videotoolbox", func() Encoder { return NewVideoToolbox() })
+}
+
+// VideoToolbox encodes frames in-process on the Apple media engine and
+// fragments the output into CMAF segments, cutting only on keyframes.
+// Frames are copied into IOSurface-backed buffers in their own pixel
+// format; the encoder converts them, so UYVY from DeckLink or NDI and BGRA
+// from screen capture cost no CPU conversion.
+type VideoToolbox struct {
+	mu    sync.Mutex
+	cfg   Config
+	enc   *C.vt_enc
+	codec string
+
+	track     fmp4.VideoTrack
+	frameDur  uint32 // Ticks per frame
+	pts       int64
+	startTime time.Time
+
+	// Current fragment
+	samples    []fmp4.Sample
+	decodeTime uint64 // Ticks of all samples emitted so far
+	fragDur    uint64
+	fragBase   uint64
+	fragTime   time.Time
+	seq        int
+	pending    []*output.Segment
+}
+
+// NewVideoToolbox creates a VideoToolbox encoder
+func NewVideoToolbox() Encoder {
+	return &VideoToolbox{}
+}
+
+// Name returns the plugin name
+func (e *VideoToolbox) Name() string {
+	return "videotoolbox"
+}
+
+// Type returns the encoder type
+func (e *VideoToolbox) Type() string {
+	return "videotoolbox"
+}
+
+// Capabilities returns what the encoder supports
+func (e *VideoToolbox) Capabilities() Capabilities {
+	return Capabilities{
+		SupportedCodecs:   []string{"h264", "hevc"},
+		SupportsHardware:  true,
+		MaxWidth:          8192,
+		MaxHeight:         4320,
+		SupportsBFrames:   true,
+		SupportsLookahead: false,
+	}
+}
+
+// Open creates the compression session; it fails on Macs without a
+// hardware encoder for the codec
+func (e *VideoToolbox) Open(cfg Config) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc != nil {
+		return fmt.Errorf("videotoolbox: encoder already open")
+	}
+	var codec string
+	switch cfg.Codec {
+	case "", "h264":
+		codec = h26x.H264
+	case "hevc", "h265":
+		codec = h26x.H265
+	default:
+		return fmt.Errorf("videotoolbox: unsupported codec %s", cfg.Codec)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width%2 != 0 || cfg.Height%2 != 0 {
+		return fmt.Errorf("videotoolbox: invalid resolution %dx%d", cfg.Width, cfg.Height)
+	}
+	if caps := e.Capabilities(); cfg.Width > caps.MaxWidth || cfg.Height > caps.MaxHeight {
+		return fmt.Errorf("videotoolbox: %dx%d exceeds max %dx%d", cfg.Width, cfg.Height, caps.MaxWidth, caps.MaxHeight)
+	}
+	if cfg.Framerate == 0 {
+		cfg.Framerate = 30
+	}
+	if cfg.SegmentDur == 0 {
+		cfg.SegmentDur = 2.0
+	}
+	if cfg.GOP == 0 {
+		cfg.GOP = int(float64(cfg.Framerate) * cfg.SegmentDur)
+	}
+	if cfg.Bitrate == 0 {
+		cfg.Bitrate = 6000
+	}
+	var profile C.int
+	switch cfg.Profile {
+	case "baseline":
+		profile = 1
+	case "main":
+		profile = 2
+	case "high":
+		profile = 3
+	}
+	hevc := C.int(0)
+	if codec == h26x.H265 {
+		hevc = 1
+	}
+
+	var status C.OSStatus
+	enc := C.vt_open(C.int(cfg.Width), C.int(cfg.Height), hevc, C.int(cfg.Framerate),
+		C.int(cfg.Bitrate), C.int(cfg.GOP), C.int(cfg.BFrames), profile, &status)
+	if enc == nil {
+		return fmt.Errorf("videotoolbox: no hardware %s encoder for %dx%d (status %d)", codec, cfg.Width, cfg.Height, int(status))
+	}
+
+	e.cfg = cfg
+	e.enc = enc
+	e.codec = codec
+	e.track = fmp4.VideoTrack{Codec: codec, Width: cfg.Width, Height: cfg.Height, Timescale: vtTimescale}
+	e.frameDur = uint32(vtTimescale / cfg.Framerate)
+	e.pts = 0
+	e.startTime = time.Time{}
+	e.seq = 0
+	e.samples = nil
+	e.pending = nil
+	e.decodeTime = 0
+	e.fragDur = 0
+	return nil
+}
+
+// Close releases the session and any frames still queued
+func (e *VideoToolbox) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc != nil {
+		C.vt_close(e.enc)
+		e.enc = nil
+	}
+	return nil
+}
+
+// Encode submits a frame and returns a segment when a fragment completes
+func (e *VideoToolbox) Encode(ctx context.Context, frame *input.Frame) (*output.Segment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc == nil {
+		return nil, fmt.Errorf("videotoolbox: encoder not open")
+	}
+	format, size, err := vtPixelFormat(frame.Format, e.cfg.Width, e.cfg.Height)
+	if err != nil {
+		return nil, err
+	}
+	if len(frame.Data) < size {
+		return nil, fmt.Errorf("videotoolbox: short frame (%d bytes, need %d)", len(frame.Data), size)
+	}
+
+	if e.startTime.IsZero() {
+		e.startTime = time.Unix(0, frame.Timestamp)
+		if frame.Timestamp == 0 {
+			e.startTime = time.Now()
+		}
+	}
+
+	status := C.vt_encode(e.enc, (*C.uint8_t)(unsafe.Pointer(&frame.Data[0])), format,
+		C.int64_t(e.pts), C.int64_t(e.frameDur))
+	if status != 0 {
+		return nil, fmt.Errorf("videotoolbox: encode failed (status %d)", int(status))
+	}
+	e.pts += int64(e.frameDur)
+	e.drain()
+
+	if len(e.pending) == 0 {
+		return nil, nil
+	}
+	seg := e.pending[0]
+	e.pending = e.pending[1:]
+	return seg, nil
+}
+
+// Flush waits for the frames in flight and returns the remaining segments
+func (e *VideoToolbox) Flush(ctx context.Context) ([]*output.Segment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc == nil {
+		return nil, nil
+	}
+	if status := C.vt_complete(e.enc); status != 0 {
+		return nil, fmt.Errorf("videotoolbox: flush failed (status %d)", int(status))
+	}
+	e.drain()
+	segs := e.pending
+	e.pending = nil
+	if seg := e.cutFragment(); seg != nil {
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+// GetInitSegment returns the ftyp+moov init segment, once the first
+// keyframe has given the parameter sets
+func (e *VideoToolbox) GetInitSegment() *output.InitSegment {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc == nil || !e.readParams() {
+		return nil
+	}
+	return &output.InitSegment{
+		Data:      fmp4.InitSegment(e.track),
+		Codec:     e.codec,
+		Width:     e.cfg.Width,
+		Height:    e.cfg.Height,
+		Framerate: e.cfg.Framerate,
+	}
+}
+
+// readParams takes the parameter sets into the track, reporting whether
+// they are known yet
+func (e *VideoToolbox) readParams() bool {
+	if e.track.SPS != nil {
+		return true
+	}
+	var params [][]byte
+	for i := 0; ; i++ {
+		var size C.size_t
+		p := C.vt_param(e.enc, C.int(i), &size)
+		if p == nil {
+			break
+		}
+		params = append(params, C.GoBytes(unsafe.Pointer(p), C.int(size)))
+	}
+	e.track.VPS, e.track.SPS, e.track.PPS = h26x.ParamSets(e.codec, params)
+	return e.track.SPS != nil
+}
+
+// drain adds the frames the encoder has output to the fragment, queueing
+// the segments they complete
+func (e *VideoToolbox) drain() {
+	for f := C.vt_pop(e.enc); f != nil; f = C.vt_pop(e.enc) {
+		data := C.GoBytes(unsafe.Pointer(f.data), C.int(f.size))
+		keyframe := f.keyframe != 0
+		pts, dts := int64(f.pts), int64(f.dts)
+		C.vt_frame_free(f)
+
+		// Close the current fragment on a keyframe once it is long enough
+		if keyframe && e.fragDur >= uint64(e.cfg.SegmentDur*vtTimescale) {
+			if seg := e.cutFragment(); seg != nil {
+				e.pending = append(e.pending, seg)
+			}
+		}
+		if len(e.samples) == 0 {
+			e.fragBase = e.decodeTime
+			e.fragTime = e.startTime.Add(time.Duration(e.fragBase) * time.Second / vtTimescale)
+		}
+		e.samples = append(e.samples, fmp4.Sample{
+			Data:              data,
+			Duration:          e.frameDur,
+			CompositionOffset: int32(pts - dts),
+			Keyframe:          keyframe,
+		})
+		e.fragDur += uint64(e.frameDur)
+		e.decodeTime += uint64(e.frameDur)
+	}
+}
+
+// cutFragment turns the accumulated samples into a media segment
+func (e *VideoToolbox) cutFragment() *output.Segment {
+	if len(e.samples) == 0 {
+		return nil
+	}
+	e.seq++
+	data := fmp4.MediaSegment(uint32(e.seq), e.fragBase, e.samples)
+	seg := &output.Segment{
+		Sequence:  e.seq,
+		Data:      data,
+		StartTime: e.fragTime.UnixMilli(),
+		Duration:  float64(e.fragDur) / vtTimescale,
+	}
+	e.samples = nil
+	e.fragDur = 0
+	return seg
+}
+
+// vtPixelFormat maps an input pixel format to its Core Video type and the
+// size of a packed frame
+func vtPixelFormat(f input.PixelFormat, width, height int) (C.OSType, int, error) {
+	switch f {
+	case input.FormatYUV420P, "":
+		return C.kCVPixelFormatType_420YpCbCr8Planar, width * height * 3 / 2, nil
+	case input.FormatNV12:
+		return C.kCVPixelFormatType_420YpCbCr8BiPlanarVideoRange, width * height * 3 / 2, nil
+	case input.FormatUYVY:
+		return C.kCVPixelFormatType_422YpCbCr8, width * height * 2, nil
+	case input.FormatYUYV:
+		return C.kCVPixelFormatType_422YpCbCr8_yuvs, width * height * 2, nil
+	case input.FormatRGB24:
+		return C.kCVPixelFormatType_24RGB, width * height * 3, nil
+	case input.FormatBGRA:
+		return C.kCVPixelFormatType_32BGRA, width * height * 4, nil
+	default:
+		return 0, 0, fmt.Errorf("videotoolbox: unsupported pixel format %s", f)
+	}
+}
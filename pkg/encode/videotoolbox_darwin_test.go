@@ -0,0 +1,68 @@
+//go:build cgo
+
+package encode
+
+import (
+	"testing"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func TestVideoToolboxOpenRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"unsupported codec", Config{Codec: "av1", Width: 1920, Height: 1080}},
+		{"no resolution", Config{Codec: "h264"}},
+		{"odd width", Config{Codec: "h264", Width: 1921, Height: 1080}},
+		{"too large", Config{Codec: "hevc", Width: 16384, Height: 8640}},
+	}
+	for _, tt := range tests {
+		e := NewVideoToolbox()
+		if err := e.Open(tt.cfg); err == nil {
+			e.Close()
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if enc, ok := Get("videotoolbox"); !ok || enc.Type() != "videotoolbox" || !enc.Capabilities().SupportsHardware {
+		t.Error("videotoolbox: not registered as a hardware encoder")
+	}
+}
+
+func TestVideoToolboxOpen(t *testing.T) {
+	e := NewVideoToolbox()
+	if err := e.Open(Config{Codec: "h264", Width: 1280, Height: 720}); err != nil {
+		t.Skipf("no hardware encoder: %v", err)
+	}
+	defer e.Close()
+	if err := e.Open(Config{Codec: "h264", Width: 1280, Height: 720}); err == nil {
+		t.Error("expected an error opening twice")
+	}
+	if init := e.GetInitSegment(); init != nil {
+		t.Error("init segment before any frame was encoded")
+	}
+}
+
+func TestVTPixelFormat(t *testing.T) {
+	tests := []struct {
+		format   input.PixelFormat
+		wantSize int
+		wantErr  bool
+	}{
+		{"", 1280 * 720 * 3 / 2, false},
+		{input.FormatYUV420P, 1280 * 720 * 3 / 2, false},
+		{input.FormatNV12, 1280 * 720 * 3 / 2, false},
+		{input.FormatUYVY, 1280 * 720 * 2, false},
+		{input.FormatYUYV, 1280 * 720 * 2, false},
+		{input.FormatRGB24, 1280 * 720 * 3, false},
+		{input.FormatBGRA, 1280 * 720 * 4, false},
+		{"p010", 0, true},
+	}
+	for _, tt := range tests {
+		_, size, err := vtPixelFormat(tt.format, 1280, 720)
+		if (err != nil) != tt.wantErr || size != tt.wantSize {
+			t.Errorf("%q: size %d, err %v; want %d, wantErr %v", tt.format, size, err, tt.wantSize, tt.wantErr)
+		}
+	}
+}
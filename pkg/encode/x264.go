@@ -0,0 +1,363 @@
+//go:build x264
+
+package encode
+
+/*
+#cgo pkg-config: x264
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+#include <x264.h>
+
+static x264_t* gx_open(int width, int height, int fps, int bitrate, int keyint, int bframes,
+                       const char* preset, const char* profile) {
+    x264_param_t p;
+    if (x264_param_default_preset(&p, preset, "zerolatency") < 0) {
+        return NULL;
+    }
+    p.i_width = width;
+    p.i_height = height;
+    p.i_csp = X264_CSP_I420;
+    p.i_fps_num = fps;
+    p.i_fps_den = 1;
+    p.i_timebase_num = 1;
+    p.i_timebase_den = 90000;
+    p.i_keyint_max = keyint;
+    p.i_keyint_min = keyint;
+    p.i_scenecut_threshold = 0;
+    p.i_bframe = bframes;
+    p.b_repeat_headers = 0;
+    p.b_annexb = 0; // 4-byte length prefixes, ready for mdat
+    p.rc.i_rc_method = X264_RC_ABR;
+    p.rc.i_bitrate = bitrate;
+    if (profile != NULL && profile[0] != 0 && x264_param_apply_profile(&p, profile) < 0) {
+        return NULL;
+    }
+    return x264_encoder_open(&p);
+}
+
+// gx_fill copies a packed I420 frame into the picture planes, honouring strides
+static void gx_fill(x264_picture_t* pic, const uint8_t* src, int width, int height) {
+    int cw = width / 2, ch = height / 2;
+    for (int y = 0; y < height; y++) {
+        memcpy(pic->img.plane[0] + y * pic->img.i_stride[0], src + y * width, width);
+    }
+    src += width * height;
+    for (int y = 0; y < ch; y++) {
+        memcpy(pic->img.plane[1] + y * pic->img.i_stride[1], src + y * cw, cw);
+    }
+    src += cw * ch;
+    for (int y = 0; y < ch; y++) {
+        memcpy(pic->img.plane[2] + y * pic->img.i_stride[2], src + y * cw, cw);
+    }
+}
+
+static x264_nal_t* gx_nal(x264_nal_t* nals, int i) {
+    return &nals[i];
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/video-system/go-video-capture/internal/fmp4"
+	"github.com/video-system/go-video-capture/pkg/input"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+const x264Timescale = 90000
+
+// X264 encodes frames in-process with libx264 and fragments the output
+// into CMAF segments, cutting only on keyframes
+type X264 struct {
+	mu      sync.Mutex
+	cfg     Config
+	enc     *C.x264_t
+	pic     C.x264_picture_t
+	picInit bool
+
+	track     fmp4.VideoTrack
+	frameDur  uint32 // ticks per frame
+	pts       int64
+	startTime time.Time
+
+	// Current fragment
+	samples    []fmp4.Sample
+	decodeTime uint64 // ticks of all samples emitted so far
+	fragDur    uint64
+	fragBase   uint64
+	fragTime   time.Time
+	seq        int
+}
+
+// NewX264 creates an in-process libx264 encoder
+func NewX264() Encoder {
+	return &X264{}
+}
+
+// Name returns the plugin name
+func (e *X264) Name() string {
+	return "x264"
+}
+
+// Type returns the encoder type
+func (e *X264) Type() string {
+	return "software"
+}
+
+// Capabilities returns what the encoder supports
+func (e *X264) Capabilities() Capabilities {
+	return Capabilities{
+		SupportedCodecs:   []string{"h264"},
+		SupportsHardware:  false,
+		MaxWidth:          8192,
+		MaxHeight:         4320,
+		SupportsBFrames:   true,
+		SupportsLookahead: true,
+	}
+}
+
+// Open creates the libx264 encoder and reads its parameter sets
+func (e *X264) Open(cfg Config) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc != nil {
+		return fmt.Errorf("x264: encoder already open")
+	}
+	if cfg.Codec != "" && cfg.Codec != "h264" {
+		return fmt.Errorf("x264: unsupported codec %s", cfg.Codec)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width%2 != 0 || cfg.Height%2 != 0 {
+		return fmt.Errorf("x264: invalid resolution %dx%d", cfg.Width, cfg.Height)
+	}
+	if cfg.Framerate == 0 {
+		cfg.Framerate = 30
+	}
+	if cfg.SegmentDur == 0 {
+		cfg.SegmentDur = 2.0
+	}
+	if cfg.GOP == 0 {
+		cfg.GOP = int(float64(cfg.Framerate) * cfg.SegmentDur)
+	}
+	if cfg.Bitrate == 0 {
+		cfg.Bitrate = 6000
+	}
+	if cfg.Preset == "" {
+		cfg.Preset = "fast"
+	}
+
+	cPreset := C.CString(cfg.Preset)
+	defer C.free(unsafe.Pointer(cPreset))
+	cProfile := C.CString(cfg.Profile)
+	defer C.free(unsafe.Pointer(cProfile))
+
+	enc := C.gx_open(C.int(cfg.Width), C.int(cfg.Height), C.int(cfg.Framerate),
+		C.int(cfg.Bitrate), C.int(cfg.GOP), C.int(cfg.BFrames), cPreset, cProfile)
+	if enc == nil {
+		return fmt.Errorf("x264: failed to open encoder (preset=%s profile=%s)", cfg.Preset, cfg.Profile)
+	}
+
+	// Extract SPS/PPS for the init segment
+	var nals *C.x264_nal_t
+	var numNals C.int
+	if C.x264_encoder_headers(enc, &nals, &numNals) < 0 {
+		C.x264_encoder_close(enc)
+		return fmt.Errorf("x264: failed to read headers")
+	}
+	track := fmp4.VideoTrack{Width: cfg.Width, Height: cfg.Height, Timescale: x264Timescale}
+	for i := 0; i < int(numNals); i++ {
+		nal := C.gx_nal(nals, C.int(i))
+		payload := C.GoBytes(unsafe.Pointer(nal.p_payload), nal.i_payload)
+		if len(payload) <= 4 {
+			continue
+		}
+		switch nal.i_type {
+		case C.NAL_SPS:
+			track.SPS = payload[4:]
+		case C.NAL_PPS:
+			track.PPS = payload[4:]
+		}
+	}
+
+	if C.x264_picture_alloc(&e.pic, C.X264_CSP_I420, C.int(cfg.Width), C.int(cfg.Height)) < 0 {
+		C.x264_encoder_close(enc)
+		return fmt.Errorf("x264: failed to allocate picture")
+	}
+
+	e.cfg = cfg
+	e.enc = enc
+	e.picInit = true
+	e.track = track
+	e.frameDur = uint32(x264Timescale / cfg.Framerate)
+	e.pts = 0
+	e.startTime = time.Time{}
+	e.seq = 0
+	e.samples = nil
+	e.decodeTime = 0
+	e.fragDur = 0
+	e.fragBase = 0
+	e.fragTime = time.Time{}
+	return nil
+}
+
+// Close releases the encoder and picture buffers
+func (e *X264) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.picInit {
+		C.x264_picture_clean(&e.pic)
+		e.picInit = false
+	}
+	if e.enc != nil {
+		C.x264_encoder_close(e.enc)
+		e.enc = nil
+	}
+	return nil
+}
+
+// Encode encodes one I420 frame and returns a segment when a fragment completes
+func (e *X264) Encode(ctx context.Context, frame *input.Frame) (*output.Segment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc == nil {
+		return nil, fmt.Errorf("x264: encoder not open")
+	}
+	if frame.Format != input.FormatYUV420P && frame.Format != "" {
+		return nil, fmt.Errorf("x264: unsupported pixel format %s (need yuv420p)", frame.Format)
+	}
+	want := e.cfg.Width * e.cfg.Height * 3 / 2
+	if len(frame.Data) < want {
+		return nil, fmt.Errorf("x264: short frame (%d bytes, need %d)", len(frame.Data), want)
+	}
+
+	if e.startTime.IsZero() {
+		e.startTime = time.Unix(0, frame.Timestamp)
+		if frame.Timestamp == 0 {
+			e.startTime = time.Now()
+		}
+	}
+
+	C.gx_fill(&e.pic, (*C.uint8_t)(unsafe.Pointer(&frame.Data[0])), C.int(e.cfg.Width), C.int(e.cfg.Height))
+	e.pic.i_pts = C.int64_t(e.pts)
+	e.pts += int64(e.frameDur)
+
+	return e.encode(&e.pic)
+}
+
+// Flush drains delayed frames and returns the final partial fragment
+func (e *X264) Flush(ctx context.Context) ([]*output.Segment, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc == nil {
+		return nil, nil
+	}
+
+	var segs []*output.Segment
+	for C.x264_encoder_delayed_frames(e.enc) > 0 {
+		if err := ctx.Err(); err != nil {
+			return segs, err
+		}
+		seg, err := e.encode(nil)
+		if err != nil {
+			return segs, err
+		}
+		if seg != nil {
+			segs = append(segs, seg)
+		}
+	}
+	if seg := e.cutFragment(); seg != nil {
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+// GetInitSegment returns the ftyp+moov init segment
+func (e *X264) GetInitSegment() *output.InitSegment {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.enc == nil {
+		return nil
+	}
+	return &output.InitSegment{
+		Data:      fmp4.InitSegment(e.track),
+		Codec:     "h264",
+		Width:     e.cfg.Width,
+		Height:    e.cfg.Height,
+		Framerate: e.cfg.Framerate,
+	}
+}
+
+// encode runs one x264_encoder_encode call (pic may be nil to drain)
+func (e *X264) encode(pic *C.x264_picture_t) (*output.Segment, error) {
+	var nals *C.x264_nal_t
+	var numNals C.int
+	var picOut C.x264_picture_t
+
+	size := C.x264_encoder_encode(e.enc, &nals, &numNals, pic, &picOut)
+	if size < 0 {
+		return nil, fmt.Errorf("x264: encode failed")
+	}
+	if size == 0 {
+		return nil, nil // Frame buffered by lookahead / B-frames
+	}
+
+	// Payloads of one frame are contiguous in memory
+	data := C.GoBytes(unsafe.Pointer(nals.p_payload), size)
+	keyframe := picOut.b_keyframe != 0
+	dts := int64(picOut.i_dts)
+	pts := int64(picOut.i_pts)
+
+	// Close the current fragment on a keyframe once it is long enough
+	var seg *output.Segment
+	if keyframe && e.fragDur >= uint64(e.cfg.SegmentDur*x264Timescale) {
+		seg = e.cutFragment()
+	}
+
+	if len(e.samples) == 0 {
+		e.fragBase = e.decodeTime
+		e.fragTime = e.startTime.Add(time.Duration(e.fragBase) * time.Second / x264Timescale)
+	}
+
+	e.samples = append(e.samples, fmp4.Sample{
+		Data:              data,
+		Duration:          e.frameDur,
+		CompositionOffset: int32(pts - dts),
+		Keyframe:          keyframe,
+	})
+	e.fragDur += uint64(e.frameDur)
+	e.decodeTime += uint64(e.frameDur)
+
+	return seg, nil
+}
+
+// cutFragment turns the accumulated samples into a media segment
+func (e *X264) cutFragment() *output.Segment {
+	if len(e.samples) == 0 {
+		return nil
+	}
+	e.seq++
+	data := fmp4.MediaSegment(uint32(e.seq), e.fragBase, e.samples)
+	seg := &output.Segment{
+		Sequence:  e.seq,
+		Data:      data,
+		StartTime: e.fragTime.UnixMilli(),
+		Duration:  float64(e.fragDur) / x264Timescale,
+	}
+	e.samples = nil
+	e.fragDur = 0
+	return seg
+}
+
+func init() {
+	Register("x264", NewX264)
+}
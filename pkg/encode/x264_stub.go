@@ -0,0 +1,65 @@
+//go:build !x264
+
+package encode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+	"github.com/video-system/go-video-capture/pkg/output"
+)
+
+var errX264NotAvailable = errors.New("libx264 not available - build with -tags x264")
+
+// X264 stub
+type X264 struct{}
+
+// NewX264 returns the stub encoder
+func NewX264() Encoder {
+	return &X264{}
+}
+
+// Name returns the plugin name
+func (e *X264) Name() string {
+	return "x264"
+}
+
+// Type returns the encoder type
+func (e *X264) Type() string {
+	return "software"
+}
+
+// Capabilities returns empty capabilities
+func (e *X264) Capabilities() Capabilities {
+	return Capabilities{}
+}
+
+// Open returns an error
+func (e *X264) Open(config Config) error {
+	return errX264NotAvailable
+}
+
+// Close is a no-op
+func (e *X264) Close() error {
+	return nil
+}
+
+// Encode returns an error
+func (e *X264) Encode(ctx context.Context, frame *input.Frame) (*output.Segment, error) {
+	return nil, errX264NotAvailable
+}
+
+// Flush returns nothing
+func (e *X264) Flush(ctx context.Context) ([]*output.Segment, error) {
+	return nil, nil
+}
+
+// GetInitSegment returns nil
+func (e *X264) GetInitSegment() *output.InitSegment {
+	return nil
+}
+
+func init() {
+	Register("x264", NewX264)
+}
@@ -0,0 +1,183 @@
+// Package events is the capture agent's internal event bus.
+//
+// Channels and the manager publish events (segments, clips, errors, state
+// changes) to a Bus; sinks such as the log, webhooks and streaming API
+// clients subscribe to the types they care about. Every subscriber has its
+// own queue, so a slow sink never blocks capture or other sinks.
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+)
+
+var logger = logging.For("events")
+
+// Type identifies an event
+type Type string
+
+const (
+	SegmentReady   Type = "segment.ready"
+	GhostStarted   Type = "ghost.started"
+	GhostSegment   Type = "ghost.segment"
+	GhostEnded     Type = "ghost.ended"
+	GhostCancelled Type = "ghost.cancelled"
+	ClipGenerated  Type = "clip.generated"
+	ClipUploaded   Type = "clip.uploaded"
+	StateChanged   Type = "channel.state"
+	AVDrift        Type = "av.drift"
+	StorageSlow    Type = "storage.slow"
+	BufferGap      Type = "buffer.gap"
+	AlertFired     Type = "alert.fired"
+	AlertResolved  Type = "alert.resolved"
+	SignalLost     Type = "input.signal_lost"
+	SignalRestored Type = "input.signal_restored"
+	DeviceAdded    Type = "device.added"
+	DeviceRemoved  Type = "device.removed"
+	EncoderQueued  Type = "encoder.queued"
+	Error          Type = "error"
+)
+
+// Types lists every event type, for validating configured filters
+var Types = []Type{
+	SegmentReady, GhostStarted, GhostSegment, GhostEnded, GhostCancelled,
+	ClipGenerated, ClipUploaded, StateChanged, AVDrift, StorageSlow,
+	BufferGap, AlertFired, AlertResolved, SignalLost, SignalRestored,
+	DeviceAdded, DeviceRemoved, EncoderQueued, Error,
+}
+
+// queueSize is the per-subscriber backlog before events are dropped
+const queueSize = 256
+
+// Event is a single published event
+type Event struct {
+	Type    Type                   `json:"type"`
+	Time    time.Time              `json:"time"`
+	Channel string                 `json:"channel,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// Sink receives events from the bus
+type Sink interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// Bus fans published events out to subscribers
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*subscription]struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type subscription struct {
+	name    string
+	types   map[Type]bool // nil = all
+	queue   chan Event
+	dropped atomic.Uint64
+}
+
+// NewBus creates an event bus
+func NewBus() *Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Bus{
+		subs:   make(map[*subscription]struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Publish sends an event to every matching subscriber without blocking.
+// Events are dropped for subscribers whose queue is full.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if sub.types != nil && !sub.types[e.Type] {
+			continue
+		}
+		select {
+		case sub.queue <- e:
+		default:
+			if sub.dropped.Add(1) == 1 {
+				logger.Warn("Event queue full, dropping events", "subscriber", sub.name)
+			}
+		}
+	}
+}
+
+// Attach delivers events of the given types (all if none) to sink until the
+// bus is closed. Send errors are logged and do not stop delivery.
+func (b *Bus) Attach(name string, sink Sink, types ...Type) {
+	sub := b.add(name, types)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for {
+			select {
+			case e := <-sub.queue:
+				deliver(b.ctx, name, sink, e)
+			case <-b.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// deliver sends one event, recovering a panicking sink so later events still
+// go out
+func deliver(ctx context.Context, name string, sink Sink, e Event) {
+	defer recovery.Handle("event sink "+name, nil)
+	if err := sink.Send(ctx, e); err != nil {
+		logger.Warn("Event sink failed", "sink", name, "type", e.Type, "error", err)
+	}
+}
+
+// Subscribe returns a channel of events of the given types (all if none) and
+// a func that unsubscribes and closes the channel
+func (b *Bus) Subscribe(name string, types ...Type) (<-chan Event, func()) {
+	sub := b.add(name, types)
+	var once sync.Once
+	return sub.queue, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+			close(sub.queue)
+		})
+	}
+}
+
+// Close stops all attached sinks
+func (b *Bus) Close() {
+	b.cancel()
+	b.wg.Wait()
+}
+
+func (b *Bus) add(name string, types []Type) *subscription {
+	sub := &subscription{name: name, queue: make(chan Event, queueSize)}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
@@ -0,0 +1,219 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSubscribeFilters(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	clips, unsubscribe := bus.Subscribe("test", ClipGenerated)
+	bus.Publish(Event{Type: SegmentReady, Channel: "cam1"})
+	bus.Publish(Event{Type: ClipGenerated, Channel: "cam1", Data: map[string]interface{}{"play_id": "p1"}})
+
+	select {
+	case e := <-clips:
+		if e.Type != ClipGenerated || e.Data["play_id"] != "p1" || e.Time.IsZero() {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+
+	unsubscribe()
+	bus.Publish(Event{Type: ClipGenerated})
+	if _, ok := <-clips; ok {
+		t.Error("channel still open after unsubscribe")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		if r.Header.Get("X-Token") != "secret" {
+			t.Error("missing custom header")
+		}
+		received <- e
+	}))
+	defer srv.Close()
+
+	bus := NewBus()
+	defer bus.Close()
+	bus.Attach("webhook", NewWebhookSink(srv.URL, map[string]string{"X-Token": "secret"}), Error)
+	bus.Publish(Event{Type: SegmentReady, Channel: "cam1"})
+	bus.Publish(Event{Type: Error, Channel: "cam1", Data: map[string]interface{}{"error": "boom"}})
+
+	select {
+	case e := <-received:
+		if e.Type != Error || e.Channel != "cam1" || e.Data["error"] != "boom" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not called")
+	}
+}
+
+func TestSlackWebhook(t *testing.T) {
+	received := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]string
+		json.NewDecoder(r.Body).Decode(&msg)
+		received <- msg
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, nil)
+	sink.Format = WebhookSlack
+	e := Event{Type: ClipGenerated, Channel: "cam1", Data: map[string]interface{}{"play_id": "p1", "duration": 12.5}}
+	if err := sink.Send(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if msg := <-received; msg["text"] != "*clip.generated* on cam1: duration=12.5, play_id=p1" {
+		t.Errorf("text = %q", msg["text"])
+	}
+}
+
+func TestSinkErrorKeepsDelivering(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	calls := make(chan struct{}, 2)
+	bus.Attach("flaky", SinkFunc(func(ctx context.Context, e Event) error {
+		calls <- struct{}{}
+		return context.DeadlineExceeded
+	}))
+	bus.Publish(Event{Type: Error})
+	bus.Publish(Event{Type: Error})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d deliveries", i)
+		}
+	}
+}
+
+// readPacket reads one MQTT control packet, returning its type and body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, mult := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * mult
+		if b&0x80 == 0 {
+			break
+		}
+		mult *= 128
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header >> 4, body, err
+}
+
+func TestMQTTSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	topics := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			typ, body, err := readPacket(r)
+			if err != nil {
+				return
+			}
+			switch typ {
+			case 1: // CONNECT
+				conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+			case 3: // PUBLISH (QoS 0): topic length, topic, payload
+				n := int(body[0])<<8 | int(body[1])
+				topics <- string(body[2 : 2+n])
+			}
+		}
+	}()
+
+	sink, err := NewMQTTSink(MQTTConfig{Broker: "tcp://" + ln.Addr().String(), Topic: "capture/{channel}/{type}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !sink.client.IsConnectionOpen() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := sink.Send(context.Background(), Event{Type: ClipGenerated, Channel: "cam1"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case topic := <-topics:
+		if topic != "capture/cam1/clip.generated" {
+			t.Errorf("topic = %q", topic)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing published")
+	}
+
+	if _, err := NewMQTTSink(MQTTConfig{Broker: "localhost"}); err == nil {
+		t.Error("expected error for broker without scheme")
+	}
+}
+
+func TestTypesComplete(t *testing.T) {
+	// Every Type constant declared in this package must be in Types
+	file, err := parser.ParseFile(token.NewFileSet(), "events.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if ident, ok := vs.Type.(*ast.Ident); !ok || ident.Name != "Type" {
+				continue
+			}
+			for i, name := range vs.Names {
+				value, _ := strconv.Unquote(vs.Values[i].(*ast.BasicLit).Value)
+				if !slices.Contains(Types, Type(value)) {
+					t.Errorf("%s (%q) missing from Types", name.Name, value)
+				}
+			}
+		}
+	}
+}
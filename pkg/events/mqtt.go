@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTTConfig configures an MQTT broker connection
+type MQTTConfig struct {
+	Broker   string // tcp://host:1883, ssl://host:8883, ws://host/mqtt
+	ClientID string
+	Username string
+	Password string
+	QoS      byte
+	Topic    string // Event topic; {channel} and {type} are replaced (default: events/{type})
+
+	// Published (retained) by the broker if the connection is lost
+	WillTopic   string
+	WillPayload []byte
+}
+
+// MQTTSink publishes events to an MQTT broker as JSON. The connection is
+// re-established automatically if the broker goes away.
+type MQTTSink struct {
+	cfg    MQTTConfig
+	client mqtt.Client
+}
+
+// NewMQTTSink starts connecting to the broker. Connecting retries in the
+// background, so an unreachable broker at startup is not an error.
+func NewMQTTSink(cfg MQTTConfig) (*MQTTSink, error) {
+	u, err := url.Parse(cfg.Broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid mqtt broker %q", cfg.Broker)
+	}
+	if cfg.Topic == "" {
+		cfg.Topic = "events/{type}"
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetOnConnectHandler(func(mqtt.Client) {
+			logger.Info("MQTT connected", "broker", cfg.Broker)
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Warn("MQTT connection lost", "broker", cfg.Broker, "error", err)
+		})
+	if cfg.WillTopic != "" {
+		opts.SetBinaryWill(cfg.WillTopic, cfg.WillPayload, cfg.QoS, true)
+	}
+
+	client := mqtt.NewClient(opts)
+	client.Connect() // Completes once connected; retries until then
+	return &MQTTSink{cfg: cfg, client: client}, nil
+}
+
+// Send implements Sink
+func (s *MQTTSink) Send(ctx context.Context, e Event) error {
+	topic := strings.NewReplacer("{channel}", e.Channel, "{type}", string(e.Type)).Replace(s.cfg.Topic)
+	return s.Publish(ctx, topic, e, false)
+}
+
+// Publish sends v as JSON to topic
+func (s *MQTTSink) Publish(ctx context.Context, topic string, v interface{}, retain bool) error {
+	if !s.client.IsConnectionOpen() {
+		return fmt.Errorf("publish %s: not connected to %s", topic, s.cfg.Broker)
+	}
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	t := s.client.Publish(topic, s.cfg.QoS, retain, payload)
+	select {
+	case <-t.Done():
+		if err := t.Error(); err != nil {
+			return fmt.Errorf("publish %s: %w", topic, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close disconnects from the broker, waiting briefly for queued messages
+func (s *MQTTSink) Close() {
+	s.client.Disconnect(250)
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Webhook body formats
+const (
+	WebhookJSON  = "json"  // The event as JSON (default)
+	WebhookSlack = "slack" // A Slack incoming-webhook message
+)
+
+// LogSink writes events to a logger at debug level, and errors at error level
+type LogSink struct {
+	Logger *slog.Logger
+}
+
+// Send implements Sink
+func (s LogSink) Send(ctx context.Context, e Event) error {
+	level := slog.LevelDebug
+	if e.Type == Error {
+		level = slog.LevelError
+	}
+	attrs := []any{"type", e.Type, "channel", e.Channel}
+	for k, v := range e.Data {
+		attrs = append(attrs, k, v)
+	}
+	s.Logger.Log(ctx, level, "Event", attrs...)
+	return nil
+}
+
+// WebhookSink POSTs each event as JSON to a URL
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+	Format  string       // WebhookJSON (default) or WebhookSlack
+	Client  *http.Client // default: 10s timeout
+}
+
+// NewWebhookSink creates a webhook sink
+func NewWebhookSink(url string, headers map[string]string) *WebhookSink {
+	return &WebhookSink{
+		URL:     url,
+		Headers: headers,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send implements Sink
+func (s *WebhookSink) Send(ctx context.Context, e Event) error {
+	var payload interface{} = e
+	if s.Format == WebhookSlack {
+		payload = map[string]string{"text": SlackText(e)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SlackText formats an event as a one-line Slack message, e.g.
+// "*clip.generated* on cam1: duration=12.5, play_id=p1"
+func SlackText(e Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*", e.Type)
+	if e.Channel != "" {
+		fmt.Fprintf(&b, " on %s", e.Channel)
+	}
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		sep := ", "
+		if i == 0 {
+			sep = ": "
+		}
+		fmt.Fprintf(&b, "%s%s=%v", sep, k, e.Data[k])
+	}
+	return b.String()
+}
+
+// SinkFunc adapts a function to a Sink
+type SinkFunc func(ctx context.Context, e Event) error
+
+// Send implements Sink
+func (f SinkFunc) Send(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
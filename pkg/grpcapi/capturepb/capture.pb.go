@@ -0,0 +1,1316 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: capture.proto
+
+package capturepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListChannelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChannelsRequest) Reset() {
+	*x = ListChannelsRequest{}
+	mi := &file_capture_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChannelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChannelsRequest) ProtoMessage() {}
+
+func (x *ListChannelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChannelsRequest.ProtoReflect.Descriptor instead.
+func (*ListChannelsRequest) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{0}
+}
+
+type ListChannelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      []*ChannelStatus       `protobuf:"bytes,1,rep,name=channels,proto3" json:"channels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChannelsResponse) Reset() {
+	*x = ListChannelsResponse{}
+	mi := &file_capture_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChannelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChannelsResponse) ProtoMessage() {}
+
+func (x *ListChannelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChannelsResponse.ProtoReflect.Descriptor instead.
+func (*ListChannelsResponse) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListChannelsResponse) GetChannels() []*ChannelStatus {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+type GetChannelStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChannelStatusRequest) Reset() {
+	*x = GetChannelStatusRequest{}
+	mi := &file_capture_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChannelStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChannelStatusRequest) ProtoMessage() {}
+
+func (x *GetChannelStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChannelStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetChannelStatusRequest) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetChannelStatusRequest) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+type ChannelStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	IsRunning     bool                   `protobuf:"varint,2,opt,name=is_running,json=isRunning,proto3" json:"is_running,omitempty"`
+	IsCapturing   bool                   `protobuf:"varint,3,opt,name=is_capturing,json=isCapturing,proto3" json:"is_capturing,omitempty"`
+	SessionId     string                 `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	BufferHealth  float64                `protobuf:"fixed64,5,opt,name=buffer_health,json=bufferHealth,proto3" json:"buffer_health,omitempty"` // 0-1 share of the buffer duration held
+	OldestTime    int64                  `protobuf:"varint,6,opt,name=oldest_time,json=oldestTime,proto3" json:"oldest_time,omitempty"`        // Unix ms
+	NewestTime    int64                  `protobuf:"varint,7,opt,name=newest_time,json=newestTime,proto3" json:"newest_time,omitempty"`        // Unix ms
+	SegmentCount  int32                  `protobuf:"varint,8,opt,name=segment_count,json=segmentCount,proto3" json:"segment_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelStatus) Reset() {
+	*x = ChannelStatus{}
+	mi := &file_capture_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelStatus) ProtoMessage() {}
+
+func (x *ChannelStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelStatus.ProtoReflect.Descriptor instead.
+func (*ChannelStatus) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ChannelStatus) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *ChannelStatus) GetIsRunning() bool {
+	if x != nil {
+		return x.IsRunning
+	}
+	return false
+}
+
+func (x *ChannelStatus) GetIsCapturing() bool {
+	if x != nil {
+		return x.IsCapturing
+	}
+	return false
+}
+
+func (x *ChannelStatus) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ChannelStatus) GetBufferHealth() float64 {
+	if x != nil {
+		return x.BufferHealth
+	}
+	return 0
+}
+
+func (x *ChannelStatus) GetOldestTime() int64 {
+	if x != nil {
+		return x.OldestTime
+	}
+	return 0
+}
+
+func (x *ChannelStatus) GetNewestTime() int64 {
+	if x != nil {
+		return x.NewestTime
+	}
+	return 0
+}
+
+func (x *ChannelStatus) GetSegmentCount() int32 {
+	if x != nil {
+		return x.SegmentCount
+	}
+	return 0
+}
+
+type SetSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetSessionRequest) Reset() {
+	*x = SetSessionRequest{}
+	mi := &file_capture_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSessionRequest) ProtoMessage() {}
+
+func (x *SetSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSessionRequest.ProtoReflect.Descriptor instead.
+func (*SetSessionRequest) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SetSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type SetSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetSessionResponse) Reset() {
+	*x = SetSessionResponse{}
+	mi := &file_capture_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSessionResponse) ProtoMessage() {}
+
+func (x *SetSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSessionResponse.ProtoReflect.Descriptor instead.
+func (*SetSessionResponse) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{5}
+}
+
+type MarkInRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	PlayId        string                 `protobuf:"bytes,2,opt,name=play_id,json=playId,proto3" json:"play_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkInRequest) Reset() {
+	*x = MarkInRequest{}
+	mi := &file_capture_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkInRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkInRequest) ProtoMessage() {}
+
+func (x *MarkInRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkInRequest.ProtoReflect.Descriptor instead.
+func (*MarkInRequest) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MarkInRequest) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *MarkInRequest) GetPlayId() string {
+	if x != nil {
+		return x.PlayId
+	}
+	return ""
+}
+
+type MarkInResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix ms
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkInResponse) Reset() {
+	*x = MarkInResponse{}
+	mi := &file_capture_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkInResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkInResponse) ProtoMessage() {}
+
+func (x *MarkInResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkInResponse.ProtoReflect.Descriptor instead.
+func (*MarkInResponse) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MarkInResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type MarkOutRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	PlayId        string                 `protobuf:"bytes,2,opt,name=play_id,json=playId,proto3" json:"play_id,omitempty"`
+	GenerateClip  bool                   `protobuf:"varint,3,opt,name=generate_clip,json=generateClip,proto3" json:"generate_clip,omitempty"`
+	Tags          map[string]string      `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Implies generate_clip
+	Options       *ClipOptions           `protobuf:"bytes,5,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkOutRequest) Reset() {
+	*x = MarkOutRequest{}
+	mi := &file_capture_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkOutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkOutRequest) ProtoMessage() {}
+
+func (x *MarkOutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkOutRequest.ProtoReflect.Descriptor instead.
+func (*MarkOutRequest) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MarkOutRequest) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *MarkOutRequest) GetPlayId() string {
+	if x != nil {
+		return x.PlayId
+	}
+	return ""
+}
+
+func (x *MarkOutRequest) GetGenerateClip() bool {
+	if x != nil {
+		return x.GenerateClip
+	}
+	return false
+}
+
+func (x *MarkOutRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *MarkOutRequest) GetOptions() *ClipOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type MarkOutResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix ms
+	Clip          *ClipResult            `protobuf:"bytes,2,opt,name=clip,proto3" json:"clip,omitempty"`            // Set when a clip was generated
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkOutResponse) Reset() {
+	*x = MarkOutResponse{}
+	mi := &file_capture_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkOutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkOutResponse) ProtoMessage() {}
+
+func (x *MarkOutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkOutResponse.ProtoReflect.Descriptor instead.
+func (*MarkOutResponse) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MarkOutResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *MarkOutResponse) GetClip() *ClipResult {
+	if x != nil {
+		return x.Clip
+	}
+	return nil
+}
+
+type ClipOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlaybackRate  float64                `protobuf:"fixed64,1,opt,name=playback_rate,json=playbackRate,proto3" json:"playback_rate,omitempty"` // Slow motion, e.g. 0.5 (0 = normal speed)
+	Interpolate   bool                   `protobuf:"varint,2,opt,name=interpolate,proto3" json:"interpolate,omitempty"`
+	Mute          bool                   `protobuf:"varint,3,opt,name=mute,proto3" json:"mute,omitempty"`
+	AudioTrack    string                 `protobuf:"bytes,4,opt,name=audio_track,json=audioTrack,proto3" json:"audio_track,omitempty"`
+	AudioMix      []*AudioMix            `protobuf:"bytes,5,rep,name=audio_mix,json=audioMix,proto3" json:"audio_mix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClipOptions) Reset() {
+	*x = ClipOptions{}
+	mi := &file_capture_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClipOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClipOptions) ProtoMessage() {}
+
+func (x *ClipOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClipOptions.ProtoReflect.Descriptor instead.
+func (*ClipOptions) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ClipOptions) GetPlaybackRate() float64 {
+	if x != nil {
+		return x.PlaybackRate
+	}
+	return 0
+}
+
+func (x *ClipOptions) GetInterpolate() bool {
+	if x != nil {
+		return x.Interpolate
+	}
+	return false
+}
+
+func (x *ClipOptions) GetMute() bool {
+	if x != nil {
+		return x.Mute
+	}
+	return false
+}
+
+func (x *ClipOptions) GetAudioTrack() string {
+	if x != nil {
+		return x.AudioTrack
+	}
+	return ""
+}
+
+func (x *ClipOptions) GetAudioMix() []*AudioMix {
+	if x != nil {
+		return x.AudioMix
+	}
+	return nil
+}
+
+type AudioMix struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Track         string                 `protobuf:"bytes,1,opt,name=track,proto3" json:"track,omitempty"`
+	GainDb        float64                `protobuf:"fixed64,2,opt,name=gain_db,json=gainDb,proto3" json:"gain_db,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioMix) Reset() {
+	*x = AudioMix{}
+	mi := &file_capture_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioMix) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioMix) ProtoMessage() {}
+
+func (x *AudioMix) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioMix.ProtoReflect.Descriptor instead.
+func (*AudioMix) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AudioMix) GetTrack() string {
+	if x != nil {
+		return x.Track
+	}
+	return ""
+}
+
+func (x *AudioMix) GetGainDb() float64 {
+	if x != nil {
+		return x.GainDb
+	}
+	return 0
+}
+
+type ClipResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	Duration      float64                `protobuf:"fixed64,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	FileSizeBytes int64                  `protobuf:"varint,3,opt,name=file_size_bytes,json=fileSizeBytes,proto3" json:"file_size_bytes,omitempty"`
+	SegmentCount  int32                  `protobuf:"varint,4,opt,name=segment_count,json=segmentCount,proto3" json:"segment_count,omitempty"`
+	PlaybackRate  float64                `protobuf:"fixed64,5,opt,name=playback_rate,json=playbackRate,proto3" json:"playback_rate,omitempty"`
+	Variants      []*ClipVariant         `protobuf:"bytes,6,rep,name=variants,proto3" json:"variants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClipResult) Reset() {
+	*x = ClipResult{}
+	mi := &file_capture_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClipResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClipResult) ProtoMessage() {}
+
+func (x *ClipResult) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClipResult.ProtoReflect.Descriptor instead.
+func (*ClipResult) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ClipResult) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ClipResult) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *ClipResult) GetFileSizeBytes() int64 {
+	if x != nil {
+		return x.FileSizeBytes
+	}
+	return 0
+}
+
+func (x *ClipResult) GetSegmentCount() int32 {
+	if x != nil {
+		return x.SegmentCount
+	}
+	return 0
+}
+
+func (x *ClipResult) GetPlaybackRate() float64 {
+	if x != nil {
+		return x.PlaybackRate
+	}
+	return 0
+}
+
+func (x *ClipResult) GetVariants() []*ClipVariant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+type ClipVariant struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	AspectRatio   string                 `protobuf:"bytes,2,opt,name=aspect_ratio,json=aspectRatio,proto3" json:"aspect_ratio,omitempty"`
+	FilePath      string                 `protobuf:"bytes,3,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	FileSizeBytes int64                  `protobuf:"varint,4,opt,name=file_size_bytes,json=fileSizeBytes,proto3" json:"file_size_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClipVariant) Reset() {
+	*x = ClipVariant{}
+	mi := &file_capture_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClipVariant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClipVariant) ProtoMessage() {}
+
+func (x *ClipVariant) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClipVariant.ProtoReflect.Descriptor instead.
+func (*ClipVariant) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ClipVariant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClipVariant) GetAspectRatio() string {
+	if x != nil {
+		return x.AspectRatio
+	}
+	return ""
+}
+
+func (x *ClipVariant) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ClipVariant) GetFileSizeBytes() int64 {
+	if x != nil {
+		return x.FileSizeBytes
+	}
+	return 0
+}
+
+type GenerateClipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	PlayId        string                 `protobuf:"bytes,2,opt,name=play_id,json=playId,proto3" json:"play_id,omitempty"`
+	StartTime     int64                  `protobuf:"varint,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix ms
+	EndTime       int64                  `protobuf:"varint,4,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`       // Unix ms
+	Options       *ClipOptions           `protobuf:"bytes,5,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateClipRequest) Reset() {
+	*x = GenerateClipRequest{}
+	mi := &file_capture_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateClipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateClipRequest) ProtoMessage() {}
+
+func (x *GenerateClipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateClipRequest.ProtoReflect.Descriptor instead.
+func (*GenerateClipRequest) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GenerateClipRequest) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *GenerateClipRequest) GetPlayId() string {
+	if x != nil {
+		return x.PlayId
+	}
+	return ""
+}
+
+func (x *GenerateClipRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *GenerateClipRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *GenerateClipRequest) GetOptions() *ClipOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type ClipProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`       // queued, running, succeeded, failed or cancelled
+	Stage         string                 `protobuf:"bytes,3,opt,name=stage,proto3" json:"stage,omitempty"`         // Current step, e.g. "concat"
+	Progress      float64                `protobuf:"fixed64,4,opt,name=progress,proto3" json:"progress,omitempty"` // 0-1
+	Result        *ClipResult            `protobuf:"bytes,5,opt,name=result,proto3" json:"result,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClipProgress) Reset() {
+	*x = ClipProgress{}
+	mi := &file_capture_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClipProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClipProgress) ProtoMessage() {}
+
+func (x *ClipProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClipProgress.ProtoReflect.Descriptor instead.
+func (*ClipProgress) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ClipProgress) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *ClipProgress) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ClipProgress) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *ClipProgress) GetProgress() float64 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *ClipProgress) GetResult() *ClipResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ClipProgress) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type WatchSegmentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"` // Empty for all channels
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchSegmentsRequest) Reset() {
+	*x = WatchSegmentsRequest{}
+	mi := &file_capture_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSegmentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSegmentsRequest) ProtoMessage() {}
+
+func (x *WatchSegmentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSegmentsRequest.ProtoReflect.Descriptor instead.
+func (*WatchSegmentsRequest) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchSegmentsRequest) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+type SegmentEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChannelId     string                 `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	Seq           int32                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	StartTime     int64                  `protobuf:"varint,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix ms
+	Duration      float64                `protobuf:"fixed64,5,opt,name=duration,proto3" json:"duration,omitempty"`                   // Seconds
+	SizeBytes     int64                  `protobuf:"varint,6,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentEvent) Reset() {
+	*x = SegmentEvent{}
+	mi := &file_capture_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentEvent) ProtoMessage() {}
+
+func (x *SegmentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentEvent.ProtoReflect.Descriptor instead.
+func (*SegmentEvent) Descriptor() ([]byte, []int) {
+	return file_capture_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SegmentEvent) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *SegmentEvent) GetSeq() int32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SegmentEvent) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SegmentEvent) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *SegmentEvent) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *SegmentEvent) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+var File_capture_proto protoreflect.FileDescriptor
+
+const file_capture_proto_rawDesc = "" +
+	"\n" +
+	"\rcapture.proto\x12\n" +
+	"capture.v1\"\x15\n" +
+	"\x13ListChannelsRequest\"M\n" +
+	"\x14ListChannelsResponse\x125\n" +
+	"\bchannels\x18\x01 \x03(\v2\x19.capture.v1.ChannelStatusR\bchannels\"8\n" +
+	"\x17GetChannelStatusRequest\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\"\x9b\x02\n" +
+	"\rChannelStatus\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\x12\x1d\n" +
+	"\n" +
+	"is_running\x18\x02 \x01(\bR\tisRunning\x12!\n" +
+	"\fis_capturing\x18\x03 \x01(\bR\visCapturing\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x04 \x01(\tR\tsessionId\x12#\n" +
+	"\rbuffer_health\x18\x05 \x01(\x01R\fbufferHealth\x12\x1f\n" +
+	"\voldest_time\x18\x06 \x01(\x03R\n" +
+	"oldestTime\x12\x1f\n" +
+	"\vnewest_time\x18\a \x01(\x03R\n" +
+	"newestTime\x12#\n" +
+	"\rsegment_count\x18\b \x01(\x05R\fsegmentCount\"2\n" +
+	"\x11SetSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x14\n" +
+	"\x12SetSessionResponse\"G\n" +
+	"\rMarkInRequest\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\x12\x17\n" +
+	"\aplay_id\x18\x02 \x01(\tR\x06playId\".\n" +
+	"\x0eMarkInResponse\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"\x93\x02\n" +
+	"\x0eMarkOutRequest\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\x12\x17\n" +
+	"\aplay_id\x18\x02 \x01(\tR\x06playId\x12#\n" +
+	"\rgenerate_clip\x18\x03 \x01(\bR\fgenerateClip\x128\n" +
+	"\x04tags\x18\x04 \x03(\v2$.capture.v1.MarkOutRequest.TagsEntryR\x04tags\x121\n" +
+	"\aoptions\x18\x05 \x01(\v2\x17.capture.v1.ClipOptionsR\aoptions\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"[\n" +
+	"\x0fMarkOutResponse\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12*\n" +
+	"\x04clip\x18\x02 \x01(\v2\x16.capture.v1.ClipResultR\x04clip\"\xbc\x01\n" +
+	"\vClipOptions\x12#\n" +
+	"\rplayback_rate\x18\x01 \x01(\x01R\fplaybackRate\x12 \n" +
+	"\vinterpolate\x18\x02 \x01(\bR\vinterpolate\x12\x12\n" +
+	"\x04mute\x18\x03 \x01(\bR\x04mute\x12\x1f\n" +
+	"\vaudio_track\x18\x04 \x01(\tR\n" +
+	"audioTrack\x121\n" +
+	"\taudio_mix\x18\x05 \x03(\v2\x14.capture.v1.AudioMixR\baudioMix\"9\n" +
+	"\bAudioMix\x12\x14\n" +
+	"\x05track\x18\x01 \x01(\tR\x05track\x12\x17\n" +
+	"\again_db\x18\x02 \x01(\x01R\x06gainDb\"\xec\x01\n" +
+	"\n" +
+	"ClipResult\x12\x1b\n" +
+	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x1a\n" +
+	"\bduration\x18\x02 \x01(\x01R\bduration\x12&\n" +
+	"\x0ffile_size_bytes\x18\x03 \x01(\x03R\rfileSizeBytes\x12#\n" +
+	"\rsegment_count\x18\x04 \x01(\x05R\fsegmentCount\x12#\n" +
+	"\rplayback_rate\x18\x05 \x01(\x01R\fplaybackRate\x123\n" +
+	"\bvariants\x18\x06 \x03(\v2\x17.capture.v1.ClipVariantR\bvariants\"\x89\x01\n" +
+	"\vClipVariant\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\faspect_ratio\x18\x02 \x01(\tR\vaspectRatio\x12\x1b\n" +
+	"\tfile_path\x18\x03 \x01(\tR\bfilePath\x12&\n" +
+	"\x0ffile_size_bytes\x18\x04 \x01(\x03R\rfileSizeBytes\"\xba\x01\n" +
+	"\x13GenerateClipRequest\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\x12\x17\n" +
+	"\aplay_id\x18\x02 \x01(\tR\x06playId\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x03 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x04 \x01(\x03R\aendTime\x121\n" +
+	"\aoptions\x18\x05 \x01(\v2\x17.capture.v1.ClipOptionsR\aoptions\"\xb5\x01\n" +
+	"\fClipProgress\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
+	"\x05stage\x18\x03 \x01(\tR\x05stage\x12\x1a\n" +
+	"\bprogress\x18\x04 \x01(\x01R\bprogress\x12.\n" +
+	"\x06result\x18\x05 \x01(\v2\x16.capture.v1.ClipResultR\x06result\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"5\n" +
+	"\x14WatchSegmentsRequest\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\"\xad\x01\n" +
+	"\fSegmentEvent\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x01 \x01(\tR\tchannelId\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x04 \x01(\x03R\tstartTime\x12\x1a\n" +
+	"\bduration\x18\x05 \x01(\x01R\bduration\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x06 \x01(\x03R\tsizeBytes2\xa5\x04\n" +
+	"\x0eCaptureControl\x12Q\n" +
+	"\fListChannels\x12\x1f.capture.v1.ListChannelsRequest\x1a .capture.v1.ListChannelsResponse\x12R\n" +
+	"\x10GetChannelStatus\x12#.capture.v1.GetChannelStatusRequest\x1a\x19.capture.v1.ChannelStatus\x12K\n" +
+	"\n" +
+	"SetSession\x12\x1d.capture.v1.SetSessionRequest\x1a\x1e.capture.v1.SetSessionResponse\x12?\n" +
+	"\x06MarkIn\x12\x19.capture.v1.MarkInRequest\x1a\x1a.capture.v1.MarkInResponse\x12B\n" +
+	"\aMarkOut\x12\x1a.capture.v1.MarkOutRequest\x1a\x1b.capture.v1.MarkOutResponse\x12K\n" +
+	"\fGenerateClip\x12\x1f.capture.v1.GenerateClipRequest\x1a\x18.capture.v1.ClipProgress0\x01\x12M\n" +
+	"\rWatchSegments\x12 .capture.v1.WatchSegmentsRequest\x1a\x18.capture.v1.SegmentEvent0\x01B@Z>github.com/video-system/go-video-capture/pkg/grpcapi/capturepbb\x06proto3"
+
+var (
+	file_capture_proto_rawDescOnce sync.Once
+	file_capture_proto_rawDescData []byte
+)
+
+func file_capture_proto_rawDescGZIP() []byte {
+	file_capture_proto_rawDescOnce.Do(func() {
+		file_capture_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_capture_proto_rawDesc), len(file_capture_proto_rawDesc)))
+	})
+	return file_capture_proto_rawDescData
+}
+
+var file_capture_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_capture_proto_goTypes = []any{
+	(*ListChannelsRequest)(nil),     // 0: capture.v1.ListChannelsRequest
+	(*ListChannelsResponse)(nil),    // 1: capture.v1.ListChannelsResponse
+	(*GetChannelStatusRequest)(nil), // 2: capture.v1.GetChannelStatusRequest
+	(*ChannelStatus)(nil),           // 3: capture.v1.ChannelStatus
+	(*SetSessionRequest)(nil),       // 4: capture.v1.SetSessionRequest
+	(*SetSessionResponse)(nil),      // 5: capture.v1.SetSessionResponse
+	(*MarkInRequest)(nil),           // 6: capture.v1.MarkInRequest
+	(*MarkInResponse)(nil),          // 7: capture.v1.MarkInResponse
+	(*MarkOutRequest)(nil),          // 8: capture.v1.MarkOutRequest
+	(*MarkOutResponse)(nil),         // 9: capture.v1.MarkOutResponse
+	(*ClipOptions)(nil),             // 10: capture.v1.ClipOptions
+	(*AudioMix)(nil),                // 11: capture.v1.AudioMix
+	(*ClipResult)(nil),              // 12: capture.v1.ClipResult
+	(*ClipVariant)(nil),             // 13: capture.v1.ClipVariant
+	(*GenerateClipRequest)(nil),     // 14: capture.v1.GenerateClipRequest
+	(*ClipProgress)(nil),            // 15: capture.v1.ClipProgress
+	(*WatchSegmentsRequest)(nil),    // 16: capture.v1.WatchSegmentsRequest
+	(*SegmentEvent)(nil),            // 17: capture.v1.SegmentEvent
+	nil,                             // 18: capture.v1.MarkOutRequest.TagsEntry
+}
+var file_capture_proto_depIdxs = []int32{
+	3,  // 0: capture.v1.ListChannelsResponse.channels:type_name -> capture.v1.ChannelStatus
+	18, // 1: capture.v1.MarkOutRequest.tags:type_name -> capture.v1.MarkOutRequest.TagsEntry
+	10, // 2: capture.v1.MarkOutRequest.options:type_name -> capture.v1.ClipOptions
+	12, // 3: capture.v1.MarkOutResponse.clip:type_name -> capture.v1.ClipResult
+	11, // 4: capture.v1.ClipOptions.audio_mix:type_name -> capture.v1.AudioMix
+	13, // 5: capture.v1.ClipResult.variants:type_name -> capture.v1.ClipVariant
+	10, // 6: capture.v1.GenerateClipRequest.options:type_name -> capture.v1.ClipOptions
+	12, // 7: capture.v1.ClipProgress.result:type_name -> capture.v1.ClipResult
+	0,  // 8: capture.v1.CaptureControl.ListChannels:input_type -> capture.v1.ListChannelsRequest
+	2,  // 9: capture.v1.CaptureControl.GetChannelStatus:input_type -> capture.v1.GetChannelStatusRequest
+	4,  // 10: capture.v1.CaptureControl.SetSession:input_type -> capture.v1.SetSessionRequest
+	6,  // 11: capture.v1.CaptureControl.MarkIn:input_type -> capture.v1.MarkInRequest
+	8,  // 12: capture.v1.CaptureControl.MarkOut:input_type -> capture.v1.MarkOutRequest
+	14, // 13: capture.v1.CaptureControl.GenerateClip:input_type -> capture.v1.GenerateClipRequest
+	16, // 14: capture.v1.CaptureControl.WatchSegments:input_type -> capture.v1.WatchSegmentsRequest
+	1,  // 15: capture.v1.CaptureControl.ListChannels:output_type -> capture.v1.ListChannelsResponse
+	3,  // 16: capture.v1.CaptureControl.GetChannelStatus:output_type -> capture.v1.ChannelStatus
+	5,  // 17: capture.v1.CaptureControl.SetSession:output_type -> capture.v1.SetSessionResponse
+	7,  // 18: capture.v1.CaptureControl.MarkIn:output_type -> capture.v1.MarkInResponse
+	9,  // 19: capture.v1.CaptureControl.MarkOut:output_type -> capture.v1.MarkOutResponse
+	15, // 20: capture.v1.CaptureControl.GenerateClip:output_type -> capture.v1.ClipProgress
+	17, // 21: capture.v1.CaptureControl.WatchSegments:output_type -> capture.v1.SegmentEvent
+	15, // [15:22] is the sub-list for method output_type
+	8,  // [8:15] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_capture_proto_init() }
+func file_capture_proto_init() {
+	if File_capture_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_capture_proto_rawDesc), len(file_capture_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_capture_proto_goTypes,
+		DependencyIndexes: file_capture_proto_depIdxs,
+		MessageInfos:      file_capture_proto_msgTypes,
+	}.Build()
+	File_capture_proto = out.File
+	file_capture_proto_goTypes = nil
+	file_capture_proto_depIdxs = nil
+}
@@ -0,0 +1,366 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: capture.proto
+
+package capturepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CaptureControl_ListChannels_FullMethodName     = "/capture.v1.CaptureControl/ListChannels"
+	CaptureControl_GetChannelStatus_FullMethodName = "/capture.v1.CaptureControl/GetChannelStatus"
+	CaptureControl_SetSession_FullMethodName       = "/capture.v1.CaptureControl/SetSession"
+	CaptureControl_MarkIn_FullMethodName           = "/capture.v1.CaptureControl/MarkIn"
+	CaptureControl_MarkOut_FullMethodName          = "/capture.v1.CaptureControl/MarkOut"
+	CaptureControl_GenerateClip_FullMethodName     = "/capture.v1.CaptureControl/GenerateClip"
+	CaptureControl_WatchSegments_FullMethodName    = "/capture.v1.CaptureControl/WatchSegments"
+)
+
+// CaptureControlClient is the client API for CaptureControl service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CaptureControlClient interface {
+	ListChannels(ctx context.Context, in *ListChannelsRequest, opts ...grpc.CallOption) (*ListChannelsResponse, error)
+	GetChannelStatus(ctx context.Context, in *GetChannelStatusRequest, opts ...grpc.CallOption) (*ChannelStatus, error)
+	SetSession(ctx context.Context, in *SetSessionRequest, opts ...grpc.CallOption) (*SetSessionResponse, error)
+	// Ghost clips: mark-in starts following the live buffer, mark-out ends
+	// the clip and optionally generates it
+	MarkIn(ctx context.Context, in *MarkInRequest, opts ...grpc.CallOption) (*MarkInResponse, error)
+	MarkOut(ctx context.Context, in *MarkOutRequest, opts ...grpc.CallOption) (*MarkOutResponse, error)
+	// Generates a clip for a time range as a background job, streaming its
+	// progress until it finishes. Cancelling the call cancels the job.
+	GenerateClip(ctx context.Context, in *GenerateClipRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClipProgress], error)
+	// Streams every new segment until the call is cancelled
+	WatchSegments(ctx context.Context, in *WatchSegmentsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SegmentEvent], error)
+}
+
+type captureControlClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCaptureControlClient(cc grpc.ClientConnInterface) CaptureControlClient {
+	return &captureControlClient{cc}
+}
+
+func (c *captureControlClient) ListChannels(ctx context.Context, in *ListChannelsRequest, opts ...grpc.CallOption) (*ListChannelsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListChannelsResponse)
+	err := c.cc.Invoke(ctx, CaptureControl_ListChannels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *captureControlClient) GetChannelStatus(ctx context.Context, in *GetChannelStatusRequest, opts ...grpc.CallOption) (*ChannelStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChannelStatus)
+	err := c.cc.Invoke(ctx, CaptureControl_GetChannelStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *captureControlClient) SetSession(ctx context.Context, in *SetSessionRequest, opts ...grpc.CallOption) (*SetSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetSessionResponse)
+	err := c.cc.Invoke(ctx, CaptureControl_SetSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *captureControlClient) MarkIn(ctx context.Context, in *MarkInRequest, opts ...grpc.CallOption) (*MarkInResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MarkInResponse)
+	err := c.cc.Invoke(ctx, CaptureControl_MarkIn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *captureControlClient) MarkOut(ctx context.Context, in *MarkOutRequest, opts ...grpc.CallOption) (*MarkOutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MarkOutResponse)
+	err := c.cc.Invoke(ctx, CaptureControl_MarkOut_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *captureControlClient) GenerateClip(ctx context.Context, in *GenerateClipRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClipProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CaptureControl_ServiceDesc.Streams[0], CaptureControl_GenerateClip_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenerateClipRequest, ClipProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureControl_GenerateClipClient = grpc.ServerStreamingClient[ClipProgress]
+
+func (c *captureControlClient) WatchSegments(ctx context.Context, in *WatchSegmentsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SegmentEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CaptureControl_ServiceDesc.Streams[1], CaptureControl_WatchSegments_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchSegmentsRequest, SegmentEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureControl_WatchSegmentsClient = grpc.ServerStreamingClient[SegmentEvent]
+
+// CaptureControlServer is the server API for CaptureControl service.
+// All implementations must embed UnimplementedCaptureControlServer
+// for forward compatibility.
+type CaptureControlServer interface {
+	ListChannels(context.Context, *ListChannelsRequest) (*ListChannelsResponse, error)
+	GetChannelStatus(context.Context, *GetChannelStatusRequest) (*ChannelStatus, error)
+	SetSession(context.Context, *SetSessionRequest) (*SetSessionResponse, error)
+	// Ghost clips: mark-in starts following the live buffer, mark-out ends
+	// the clip and optionally generates it
+	MarkIn(context.Context, *MarkInRequest) (*MarkInResponse, error)
+	MarkOut(context.Context, *MarkOutRequest) (*MarkOutResponse, error)
+	// Generates a clip for a time range as a background job, streaming its
+	// progress until it finishes. Cancelling the call cancels the job.
+	GenerateClip(*GenerateClipRequest, grpc.ServerStreamingServer[ClipProgress]) error
+	// Streams every new segment until the call is cancelled
+	WatchSegments(*WatchSegmentsRequest, grpc.ServerStreamingServer[SegmentEvent]) error
+	mustEmbedUnimplementedCaptureControlServer()
+}
+
+// UnimplementedCaptureControlServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCaptureControlServer struct{}
+
+func (UnimplementedCaptureControlServer) ListChannels(context.Context, *ListChannelsRequest) (*ListChannelsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListChannels not implemented")
+}
+func (UnimplementedCaptureControlServer) GetChannelStatus(context.Context, *GetChannelStatusRequest) (*ChannelStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChannelStatus not implemented")
+}
+func (UnimplementedCaptureControlServer) SetSession(context.Context, *SetSessionRequest) (*SetSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSession not implemented")
+}
+func (UnimplementedCaptureControlServer) MarkIn(context.Context, *MarkInRequest) (*MarkInResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkIn not implemented")
+}
+func (UnimplementedCaptureControlServer) MarkOut(context.Context, *MarkOutRequest) (*MarkOutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkOut not implemented")
+}
+func (UnimplementedCaptureControlServer) GenerateClip(*GenerateClipRequest, grpc.ServerStreamingServer[ClipProgress]) error {
+	return status.Errorf(codes.Unimplemented, "method GenerateClip not implemented")
+}
+func (UnimplementedCaptureControlServer) WatchSegments(*WatchSegmentsRequest, grpc.ServerStreamingServer[SegmentEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchSegments not implemented")
+}
+func (UnimplementedCaptureControlServer) mustEmbedUnimplementedCaptureControlServer() {}
+func (UnimplementedCaptureControlServer) testEmbeddedByValue()                        {}
+
+// UnsafeCaptureControlServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CaptureControlServer will
+// result in compilation errors.
+type UnsafeCaptureControlServer interface {
+	mustEmbedUnimplementedCaptureControlServer()
+}
+
+func RegisterCaptureControlServer(s grpc.ServiceRegistrar, srv CaptureControlServer) {
+	// If the following call pancis, it indicates UnimplementedCaptureControlServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CaptureControl_ServiceDesc, srv)
+}
+
+func _CaptureControl_ListChannels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChannelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaptureControlServer).ListChannels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CaptureControl_ListChannels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaptureControlServer).ListChannels(ctx, req.(*ListChannelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CaptureControl_GetChannelStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChannelStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaptureControlServer).GetChannelStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CaptureControl_GetChannelStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaptureControlServer).GetChannelStatus(ctx, req.(*GetChannelStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CaptureControl_SetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaptureControlServer).SetSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CaptureControl_SetSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaptureControlServer).SetSession(ctx, req.(*SetSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CaptureControl_MarkIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaptureControlServer).MarkIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CaptureControl_MarkIn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaptureControlServer).MarkIn(ctx, req.(*MarkInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CaptureControl_MarkOut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkOutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaptureControlServer).MarkOut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CaptureControl_MarkOut_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaptureControlServer).MarkOut(ctx, req.(*MarkOutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CaptureControl_GenerateClip_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateClipRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaptureControlServer).GenerateClip(m, &grpc.GenericServerStream[GenerateClipRequest, ClipProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureControl_GenerateClipServer = grpc.ServerStreamingServer[ClipProgress]
+
+func _CaptureControl_WatchSegments_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchSegmentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaptureControlServer).WatchSegments(m, &grpc.GenericServerStream[WatchSegmentsRequest, SegmentEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureControl_WatchSegmentsServer = grpc.ServerStreamingServer[SegmentEvent]
+
+// CaptureControl_ServiceDesc is the grpc.ServiceDesc for CaptureControl service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CaptureControl_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "capture.v1.CaptureControl",
+	HandlerType: (*CaptureControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListChannels",
+			Handler:    _CaptureControl_ListChannels_Handler,
+		},
+		{
+			MethodName: "GetChannelStatus",
+			Handler:    _CaptureControl_GetChannelStatus_Handler,
+		},
+		{
+			MethodName: "SetSession",
+			Handler:    _CaptureControl_SetSession_Handler,
+		},
+		{
+			MethodName: "MarkIn",
+			Handler:    _CaptureControl_MarkIn_Handler,
+		},
+		{
+			MethodName: "MarkOut",
+			Handler:    _CaptureControl_MarkOut_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GenerateClip",
+			Handler:       _CaptureControl_GenerateClip_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchSegments",
+			Handler:       _CaptureControl_WatchSegments_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "capture.proto",
+}
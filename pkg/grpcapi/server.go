@@ -0,0 +1,367 @@
+// Package grpcapi serves the capture control API over gRPC, alongside the
+// HTTP API, for orchestration layers that prefer it to polling JSON.
+//
+// The service is defined in capturepb/capture.proto. Messages mirror the
+// HTTP API's JSON, so results are converted by field name.
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/events"
+	"github.com/video-system/go-video-capture/pkg/grpcapi/capturepb"
+	"github.com/video-system/go-video-capture/pkg/jobs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var logger = logging.For("grpc")
+
+// progressInterval is how often GenerateClip checks its job
+const progressInterval = 250 * time.Millisecond
+
+// Config holds gRPC server configuration
+type Config struct {
+	Host    string
+	Port    int
+	Manager api.ChannelManager
+	Events  *events.Bus // Source for WatchSegments
+	Jobs    *jobs.Queue // Runs GenerateClip; shared with the HTTP API
+	APIKeys []string    // With any set, mutating calls need "authorization: Bearer <key>"
+}
+
+// Server is the gRPC control API server
+type Server struct {
+	capturepb.UnimplementedCaptureControlServer
+
+	cfg  Config
+	addr string
+	grpc *grpc.Server
+}
+
+// mutating lists the methods that need an API key when keys are configured
+var mutating = []string{
+	capturepb.CaptureControl_SetSession_FullMethodName,
+	capturepb.CaptureControl_MarkIn_FullMethodName,
+	capturepb.CaptureControl_MarkOut_FullMethodName,
+	capturepb.CaptureControl_GenerateClip_FullMethodName,
+}
+
+// New creates a gRPC server
+func New(cfg Config) *Server {
+	s := &Server{cfg: cfg, addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+	s.grpc = grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.authorize(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	capturepb.RegisterCaptureControlServer(s.grpc, s)
+	return s
+}
+
+// Start listens and serves until the server is stopped
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("gRPC server starting", "addr", s.addr)
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown stops the server, waiting for open calls until ctx is done and
+// then cancelling the rest (e.g. WatchSegments streams)
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return ctx.Err()
+	}
+}
+
+// authorize checks the API key of calls to mutating methods
+func (s *Server) authorize(ctx context.Context, method string) error {
+	if len(s.cfg.APIKeys) == 0 || !slices.Contains(mutating, method) {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok || token == "" {
+			continue
+		}
+		for _, key := range s.cfg.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid API key")
+}
+
+// channel finds a channel by ID, or the default channel for an empty ID
+func (s *Server) channel(id string) (api.ChannelInterface, error) {
+	if id == "" {
+		if ch, ok := s.cfg.Manager.GetDefaultChannel(); ok {
+			return ch, nil
+		}
+		return nil, status.Error(codes.NotFound, "no channel available")
+	}
+	ch, ok := s.cfg.Manager.GetChannel(id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "channel not found: %s", id)
+	}
+	return ch, nil
+}
+
+// ListChannels returns the status of every channel
+func (s *Server) ListChannels(ctx context.Context, req *capturepb.ListChannelsRequest) (*capturepb.ListChannelsResponse, error) {
+	statuses := s.cfg.Manager.GetAllStatuses()
+	ids := s.cfg.Manager.ListChannels()
+	slices.Sort(ids)
+
+	resp := &capturepb.ListChannelsResponse{}
+	for _, id := range ids {
+		st := &capturepb.ChannelStatus{}
+		if err := fromJSON(statuses[id], st); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		resp.Channels = append(resp.Channels, st)
+	}
+	return resp, nil
+}
+
+// GetChannelStatus returns one channel's status
+func (s *Server) GetChannelStatus(ctx context.Context, req *capturepb.GetChannelStatusRequest) (*capturepb.ChannelStatus, error) {
+	ch, err := s.channel(req.GetChannelId())
+	if err != nil {
+		return nil, err
+	}
+	st := &capturepb.ChannelStatus{}
+	if err := fromJSON(ch.GetStatus(), st); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return st, nil
+}
+
+// SetSession sets the session ID on all channels
+func (s *Server) SetSession(ctx context.Context, req *capturepb.SetSessionRequest) (*capturepb.SetSessionResponse, error) {
+	s.cfg.Manager.SetSession(req.GetSessionId())
+	return &capturepb.SetSessionResponse{}, nil
+}
+
+// MarkIn starts a ghost clip
+func (s *Server) MarkIn(ctx context.Context, req *capturepb.MarkInRequest) (*capturepb.MarkInResponse, error) {
+	ch, err := s.channel(req.GetChannelId())
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.StartGhostClip(req.GetPlayId()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &capturepb.MarkInResponse{Timestamp: time.Now().UnixMilli()}, nil
+}
+
+// MarkOut ends a ghost clip, generating it when asked to or when tags are set
+func (s *Server) MarkOut(ctx context.Context, req *capturepb.MarkOutRequest) (*capturepb.MarkOutResponse, error) {
+	ch, err := s.channel(req.GetChannelId())
+	if err != nil {
+		return nil, err
+	}
+	opts, err := clipOptions(req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.GetGenerateClip() && len(req.GetTags()) == 0 {
+		if err := ch.EndGhostClip(req.GetPlayId()); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return &capturepb.MarkOutResponse{Timestamp: time.Now().UnixMilli()}, nil
+	}
+
+	var tags map[string]interface{}
+	if len(req.GetTags()) > 0 {
+		tags = make(map[string]interface{}, len(req.GetTags()))
+		for k, v := range req.GetTags() {
+			tags[k] = v
+		}
+	}
+	result, err := ch.EndGhostClipAndGenerate(ctx, req.GetPlayId(), tags, opts)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	clip := &capturepb.ClipResult{}
+	if err := fromJSON(result, clip); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &capturepb.MarkOutResponse{Timestamp: time.Now().UnixMilli(), Clip: clip}, nil
+}
+
+// GenerateClip queues a time-range clip and streams the job's progress
+func (s *Server) GenerateClip(req *capturepb.GenerateClipRequest, stream grpc.ServerStreamingServer[capturepb.ClipProgress]) error {
+	ch, err := s.channel(req.GetChannelId())
+	if err != nil {
+		return err
+	}
+	opts, err := clipOptions(req.GetOptions())
+	if err != nil {
+		return err
+	}
+	if req.GetEndTime() <= req.GetStartTime() {
+		return status.Error(codes.InvalidArgument, "end_time must be after start_time")
+	}
+
+	playID := req.GetPlayId()
+	job := s.cfg.Jobs.Submit(jobs.Spec{Kind: "clip", ChannelID: ch.ID(), PlayID: playID}, func(ctx context.Context) (interface{}, error) {
+		return ch.GenerateClip(ctx, req.GetStartTime(), req.GetEndTime(), playID, opts)
+	})
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	var last *capturepb.ClipProgress
+	for {
+		j, ok := s.cfg.Jobs.Get(job.ID)
+		if !ok {
+			return status.Error(codes.Internal, "clip job disappeared")
+		}
+		progress := &capturepb.ClipProgress{
+			JobId:    j.ID,
+			Status:   string(j.Status),
+			Stage:    j.Stage,
+			Progress: j.Progress,
+			Error:    j.Error,
+		}
+		if j.Status == jobs.Succeeded {
+			progress.Result = &capturepb.ClipResult{}
+			if err := fromJSON(j.Result, progress.Result); err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+		}
+		if !proto.Equal(progress, last) {
+			if err := stream.Send(progress); err != nil {
+				s.cfg.Jobs.Cancel(job.ID)
+				return err
+			}
+			last = progress
+		}
+		if j.Status.Done() {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			s.cfg.Jobs.Cancel(job.ID)
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// WatchSegments streams new segments, of one channel or all of them
+func (s *Server) WatchSegments(req *capturepb.WatchSegmentsRequest, stream grpc.ServerStreamingServer[capturepb.SegmentEvent]) error {
+	if s.cfg.Events == nil {
+		return status.Error(codes.Unavailable, "events not available")
+	}
+	channelID := req.GetChannelId()
+	if channelID != "" {
+		if _, err := s.channel(channelID); err != nil {
+			return err
+		}
+	}
+
+	name := "grpc segments"
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		name += " " + p.Addr.String()
+	}
+	segments, unsubscribe := s.cfg.Events.Subscribe(name, events.SegmentReady)
+	defer unsubscribe()
+
+	for {
+		select {
+		case e := <-segments:
+			if channelID != "" && e.Channel != channelID {
+				continue
+			}
+			if err := stream.Send(segmentEvent(e)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// segmentEvent converts a segment.ready event
+func segmentEvent(e events.Event) *capturepb.SegmentEvent {
+	seq, _ := e.Data["seq"].(int)
+	path, _ := e.Data["path"].(string)
+	start, _ := e.Data["start_time"].(int64)
+	duration, _ := e.Data["duration"].(float64)
+	size, _ := e.Data["size_bytes"].(int64)
+	return &capturepb.SegmentEvent{
+		ChannelId: e.Channel,
+		Seq:       int32(seq),
+		Path:      path,
+		StartTime: start,
+		Duration:  duration,
+		SizeBytes: size,
+	}
+}
+
+// clipOptions converts and validates clip options
+func clipOptions(o *capturepb.ClipOptions) (api.ClipOptions, error) {
+	opts := api.ClipOptions{
+		PlaybackRate: o.GetPlaybackRate(),
+		Interpolate:  o.GetInterpolate(),
+		Mute:         o.GetMute(),
+		AudioTrack:   o.GetAudioTrack(),
+	}
+	for _, m := range o.GetAudioMix() {
+		opts.AudioMix = append(opts.AudioMix, api.ClipAudioMix{Track: m.GetTrack(), GainDB: m.GetGainDb()})
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return opts, nil
+}
+
+// fromJSON fills m from the JSON encoding of v, ignoring fields the message
+// doesn't have
+func fromJSON(v interface{}, m proto.Message) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+}
@@ -0,0 +1,74 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/video-system/go-video-capture/pkg/api"
+	"github.com/video-system/go-video-capture/pkg/grpcapi/capturepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeManager struct {
+	api.ChannelManager
+	session string
+}
+
+func (m *fakeManager) ListChannels() []string { return []string{"cam2", "cam1"} }
+
+func (m *fakeManager) GetAllStatuses() map[string]interface{} {
+	return map[string]interface{}{
+		"cam1": map[string]interface{}{"channel_id": "cam1", "is_running": true, "segment_count": 12, "encoder": "libx264"},
+		"cam2": map[string]interface{}{"channel_id": "cam2", "buffer_health": 0.5},
+	}
+}
+
+func (m *fakeManager) SetSession(id string) { m.session = id }
+
+func TestServer(t *testing.T) {
+	m := &fakeManager{}
+	s := New(Config{Manager: m, APIKeys: []string{"secret"}})
+	lis := bufconn.Listen(1 << 16)
+	go s.grpc.Serve(lis)
+	defer s.grpc.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := capturepb.NewCaptureControlClient(conn)
+	ctx := context.Background()
+
+	// Reads need no key
+	resp, err := client.ListChannels(ctx, &capturepb.ListChannelsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Channels) != 2 || resp.Channels[0].ChannelId != "cam1" || !resp.Channels[0].IsRunning || resp.Channels[0].SegmentCount != 12 {
+		t.Errorf("channels = %v", resp.Channels)
+	}
+	if resp.Channels[1].BufferHealth != 0.5 {
+		t.Errorf("cam2 buffer health = %v, want 0.5", resp.Channels[1].BufferHealth)
+	}
+
+	_, err = client.SetSession(ctx, &capturepb.SetSessionRequest{SessionId: "game-1"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("SetSession without key: %v, want Unauthenticated", err)
+	}
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	if _, err := client.SetSession(authed, &capturepb.SetSessionRequest{SessionId: "game-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if m.session != "game-1" {
+		t.Errorf("session = %q, want game-1", m.session)
+	}
+}
@@ -0,0 +1,118 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func init() {
+	// URLs and files, demuxer detected by FFmpeg
+	for _, typ := range []string{"file", "srt", "rtsp", "rtmp"} {
+		Register(typ, func() Input { return NewFFmpeg(typ, "") })
+	}
+	// Capture devices
+	for _, typ := range []string{"avfoundation", "v4l2", "dshow", "decklink"} {
+		Register(typ, func() Input { return NewFFmpeg(typ, typ) })
+	}
+	screen := "avfoundation"
+	switch runtime.GOOS {
+	case "windows":
+		screen = "gdigrab"
+	case "linux":
+		screen = "x11grab"
+	}
+	Register("screen", func() Input { return NewFFmpeg("screen", screen) })
+	Register("testsrc", func() Input { return NewFFmpeg("testsrc", "lavfi") })
+}
+
+// ErrReadByFFmpeg is returned by ReadFrame of inputs FFmpeg reads itself
+var ErrReadByFFmpeg = errors.New("input is read by ffmpeg, not frame by frame")
+
+// FFmpegInput is how FFmpeg reads an input itself
+type FFmpegInput struct {
+	URL     string   // File, device or URL passed to -i
+	Format  string   // Demuxer (-f); empty lets FFmpeg detect it
+	Options []string // Demuxer and protocol options placed before -i
+}
+
+// FFmpegReader is implemented by inputs FFmpeg reads directly with one of
+// its demuxers. Capture hands FFmpegInput to the encoder and never calls
+// ReadFrame; other inputs' frames are piped to the encoder as raw video.
+type FFmpegReader interface {
+	Input
+	FFmpegInput() FFmpegInput
+}
+
+// FFmpeg is the default input for every device and URL type FFmpeg can
+// read: it opens nothing itself and describes the input for FFmpeg's
+// command line
+type FFmpeg struct {
+	typ    string
+	format string
+	input  FFmpegInput
+}
+
+// NewFFmpeg creates an input of type typ read with FFmpeg's format demuxer
+func NewFFmpeg(typ, format string) *FFmpeg {
+	return &FFmpeg{typ: typ, format: format}
+}
+
+func (f *FFmpeg) Name() string { return "ffmpeg-" + f.typ }
+func (f *FFmpeg) Type() string { return f.typ }
+
+func (f *FFmpeg) Capabilities() Capabilities {
+	return Capabilities{SupportsAudio: true, SupportsVideo: true}
+}
+
+// Open builds the FFmpeg input for cfg
+func (f *FFmpeg) Open(cfg Config) error {
+	f.input = FFmpegInput{URL: cfg.Device, Format: f.format, Options: cfg.Options}
+	switch f.typ {
+	case "screen":
+		f.input.URL = "0:none"
+		switch f.format {
+		case "gdigrab":
+			f.input.URL = "desktop"
+		case "x11grab":
+			f.input.URL = os.Getenv("DISPLAY")
+			if f.input.URL == "" {
+				f.input.URL = ":0"
+			}
+		}
+	case "testsrc":
+		graph, err := ffmpeg.TestSource(cfg.Device, fmt.Sprintf("%dx%d", cfg.Width, cfg.Height), cfg.Framerate)
+		if err != nil {
+			return err
+		}
+		f.input.URL = graph
+		f.input.Options = append([]string{"-re"}, cfg.Options...)
+	default:
+		if cfg.Device == "" {
+			return fmt.Errorf("%s input: device is required", f.typ)
+		}
+	}
+	return nil
+}
+
+func (f *FFmpeg) Close() error { return nil }
+
+// ReadFrame always fails: FFmpeg reads the input
+func (f *FFmpeg) ReadFrame(ctx context.Context) (*Frame, error) {
+	return nil, ErrReadByFFmpeg
+}
+
+// ListDevices returns nothing; devices are listed through FFmpeg's device
+// probing instead
+func (f *FFmpeg) ListDevices() ([]Device, error) {
+	return nil, nil
+}
+
+// FFmpegInput describes the input opened last
+func (f *FFmpeg) FFmpegInput() FFmpegInput {
+	return f.input
+}
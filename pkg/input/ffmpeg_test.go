@@ -0,0 +1,62 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFFmpegInputOpen(t *testing.T) {
+	tests := []struct {
+		typ    string
+		cfg    Config
+		url    string
+		format string
+	}{
+		{"file", Config{Device: "/media/game.mp4"}, "/media/game.mp4", ""},
+		{"srt", Config{Device: "srt://0.0.0.0:9000", Options: []string{"-latency", "120000"}}, "srt://0.0.0.0:9000", ""},
+		{"rtsp", Config{Device: "rtsp://cam/stream"}, "rtsp://cam/stream", ""},
+		{"v4l2", Config{Device: "/dev/video0"}, "/dev/video0", "v4l2"},
+		{"decklink", Config{Device: "DeckLink Mini Recorder"}, "DeckLink Mini Recorder", "decklink"},
+	}
+	for _, tt := range tests {
+		in, ok := Get(tt.typ)
+		if !ok {
+			t.Errorf("%s: not registered", tt.typ)
+			continue
+		}
+		r, ok := in.(FFmpegReader)
+		if !ok {
+			t.Errorf("%s: not read by FFmpeg", tt.typ)
+			continue
+		}
+		if err := in.Open(tt.cfg); err != nil {
+			t.Errorf("%s: Open: %v", tt.typ, err)
+			continue
+		}
+		got := r.FFmpegInput()
+		if got.URL != tt.url || got.Format != tt.format || !slices.Equal(got.Options, tt.cfg.Options) {
+			t.Errorf("%s: FFmpegInput = %+v, want %s as %q", tt.typ, got, tt.url, tt.format)
+		}
+		if _, err := in.ReadFrame(context.Background()); !errors.Is(err, ErrReadByFFmpeg) {
+			t.Errorf("%s: ReadFrame = %v, want ErrReadByFFmpeg", tt.typ, err)
+		}
+	}
+
+	// Devices and URLs are required, except for the generated inputs
+	if err := NewFFmpeg("rtmp", "").Open(Config{}); err == nil {
+		t.Error("rtmp: expected an error without a device")
+	}
+	test := NewFFmpeg("testsrc", "lavfi")
+	if err := test.Open(Config{Device: "smptebars", Width: 1280, Height: 720, Framerate: 30}); err != nil {
+		t.Fatalf("testsrc: Open: %v", err)
+	}
+	if got := test.FFmpegInput(); !strings.HasPrefix(got.URL, "smptebars=size=1280x720:rate=30") || got.Options[0] != "-re" {
+		t.Errorf("testsrc: FFmpegInput = %+v, want a real-time smptebars graph", got)
+	}
+	if err := NewFFmpeg("testsrc", "lavfi").Open(Config{Device: "nope", Width: 1280, Height: 720, Framerate: 30}); err == nil {
+		t.Error("testsrc: expected an error for an unknown pattern")
+	}
+}
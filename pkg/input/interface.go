@@ -1,6 +1,10 @@
 package input
 
-import "context"
+import (
+	"context"
+	"errors"
+	"io"
+)
 
 // Input is the interface for video input sources
 type Input interface {
@@ -38,6 +42,11 @@ type Config struct {
 	Framerate  int
 	Format     PixelFormat
 	BufferSize int
+	Options    []string // FFmpeg demuxer and protocol options, for inputs FFmpeg reads
+
+	AudioChannels int // Embedded audio channels to capture, e.g. 2, 8 or 16 for SDI (0 = input default)
+
+	Params map[string]string // Plugin-specific settings, e.g. the window a screen input captures
 }
 
 // Device represents a discovered input device
@@ -65,8 +74,78 @@ type Frame struct {
 	Format    PixelFormat
 	Timestamp int64 // Unix nanoseconds
 	Sequence  int64
+	NoSignal  bool // The input has no signal; Data is filler, e.g. black
+}
+
+// AudioSource is implemented by native inputs that capture audio along with
+// video, e.g. audio embedded in SDI
+type AudioSource interface {
+	// AudioFormat describes the PCM returned by ReadAudio; valid once the
+	// input is open. No channels means the input captures no audio.
+	AudioFormat() AudioFormat
+
+	// ReadAudio returns the next block of interleaved samples
+	ReadAudio(ctx context.Context) ([]byte, error)
+}
+
+// AudioFormat describes interleaved PCM audio
+type AudioFormat struct {
+	SampleRate int
+	Channels   int
+	Sample     string // FFmpeg raw format: s16le, s32le, f32le
+}
+
+// ModeDetector is implemented by native inputs that detect the incoming
+// video mode rather than being told it
+type ModeDetector interface {
+	// DetectedMode returns the mode of the frames being read; valid once a
+	// frame has been read
+	DetectedMode() VideoMode
 }
 
+// PacketSource is implemented by native inputs that receive compressed
+// video. Capture muxes the access units into segments as they are, without
+// FFmpeg or an encode; ReadFrame is not used.
+type PacketSource interface {
+	// Stream describes the video; valid once the input is open
+	Stream() Stream
+
+	// ReadPacket returns the next access unit, in decode order. The first
+	// one read is a keyframe.
+	ReadPacket(ctx context.Context) (*Packet, error)
+}
+
+// ContainerSource is implemented by packet inputs whose stream can't
+// always be muxed as is, e.g. when it isn't in the channel's codec. When
+// Container returns a reader, the input delivers no packets: FFmpeg reads
+// the stream as received, in the FFmpeg format given, and encodes it.
+type ContainerSource interface {
+	Container() (r io.Reader, format string)
+}
+
+// Stream describes compressed video
+type Stream struct {
+	Codec  string // h264, hevc
+	Width  int
+	Height int
+	VPS    []byte // Parameter sets, without start codes; VPS for hevc only
+	SPS    []byte
+	PPS    []byte
+}
+
+// Packet is one compressed access unit
+type Packet struct {
+	NALUs     [][]byte // NAL units, without start codes
+	PTS       int64    // Presentation time in 90 kHz ticks
+	DTS       int64    // Decode time in 90 kHz ticks, PTS without B-frames
+	Keyframe  bool
+	Timestamp int64 // Unix nanoseconds the packet arrived
+}
+
+// ErrPacketsOnly is returned by ReadFrame of inputs that deliver compressed
+// packets
+var ErrPacketsOnly = errors.New("input delivers compressed packets, not frames")
+
 // PixelFormat represents a video pixel format
 type PixelFormat string
 
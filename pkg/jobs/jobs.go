@@ -0,0 +1,247 @@
+// Package jobs runs long operations such as clip exports in the background
+// so API requests can return immediately and be polled for the result.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+)
+
+var logger = logging.For("jobs")
+
+// Status is where a job is in its lifecycle
+type Status string
+
+const (
+	Queued    Status = "queued"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+	Cancelled Status = "cancelled"
+)
+
+// Done reports whether the job has finished
+func (s Status) Done() bool {
+	return s == Succeeded || s == Failed || s == Cancelled
+}
+
+// Func does a job's work, reporting progress with Report on ctx
+type Func func(ctx context.Context) (interface{}, error)
+
+// Spec describes a job for status listings
+type Spec struct {
+	Kind      string `json:"kind"` // e.g. "clip" or "reel"
+	ChannelID string `json:"channel_id,omitempty"`
+	PlayID    string `json:"play_id,omitempty"`
+}
+
+// Job is a snapshot of a job's state
+type Job struct {
+	ID string `json:"id"`
+	Spec
+	Status     Status      `json:"status"`
+	Stage      string      `json:"stage,omitempty"` // Current step, e.g. "concat"
+	Progress   float64     `json:"progress"`        // 0-1
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  time.Time   `json:"started_at,omitzero"`
+	FinishedAt time.Time   `json:"finished_at,omitzero"`
+}
+
+// Config sets the queue's concurrency and how long finished jobs are kept
+type Config struct {
+	Workers   int           // Jobs run at once (default 2)
+	Retention time.Duration // Finished jobs are forgotten after this (default 1h)
+}
+
+// Queue runs jobs on a fixed number of workers, in submission order
+type Queue struct {
+	cfg    Config
+	slots  chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	pending []*job
+}
+
+type job struct {
+	Job
+	fn     Func
+	cancel context.CancelFunc
+}
+
+// New creates a queue. Close cancels any jobs still running.
+func New(cfg Config) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = time.Hour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		cfg:    cfg,
+		slots:  make(chan struct{}, cfg.Workers),
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[string]*job),
+	}
+}
+
+// Submit queues fn and returns the new job
+func (q *Queue) Submit(spec Spec, fn Func) Job {
+	j := &job{Job: Job{ID: newID(), Spec: spec, Status: Queued, CreatedAt: time.Now()}, fn: fn}
+
+	q.mu.Lock()
+	q.pruneLocked()
+	q.jobs[j.ID] = j
+	q.pending = append(q.pending, j)
+	snapshot := j.Job
+	q.mu.Unlock()
+
+	recovery.Go("job "+j.ID, q.dispatch)
+	return snapshot
+}
+
+// Get returns a job by ID
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return j.Job, true
+}
+
+// List returns all known jobs, newest first
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pruneLocked()
+	list := make([]Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		list = append(list, j.Job)
+	}
+	sort.Slice(list, func(i, k int) bool { return list[i].CreatedAt.After(list[k].CreatedAt) })
+	return list
+}
+
+// Cancel stops a queued or running job. It returns false for unknown or
+// finished jobs.
+func (q *Queue) Cancel(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok || j.Status.Done() {
+		return false
+	}
+	if j.cancel != nil {
+		j.cancel() // run marks it cancelled when fn returns
+		return true
+	}
+	j.Status, j.FinishedAt = Cancelled, time.Now()
+	return true
+}
+
+// Close cancels all jobs
+func (q *Queue) Close() {
+	q.cancel()
+}
+
+// dispatch waits for a free worker and runs the oldest queued job
+func (q *Queue) dispatch() {
+	select {
+	case q.slots <- struct{}{}:
+	case <-q.ctx.Done():
+		return
+	}
+	defer func() { <-q.slots }()
+
+	q.mu.Lock()
+	var j *job
+	for len(q.pending) > 0 && j == nil {
+		j, q.pending = q.pending[0], q.pending[1:]
+		if j.Status != Queued {
+			j = nil // Cancelled while queued
+		}
+	}
+	if j == nil {
+		q.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+	j.cancel = cancel
+	j.Status, j.StartedAt = Running, time.Now()
+	q.mu.Unlock()
+
+	result, err := q.run(context.WithValue(ctx, reporterKey{}, &reporter{q: q, j: j}), j)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j.FinishedAt = time.Now()
+	switch {
+	case ctx.Err() != nil && err != nil:
+		j.Status, j.Error = Cancelled, err.Error()
+	case err != nil:
+		j.Status, j.Error = Failed, err.Error()
+		logger.Warn("Job failed", "id", j.ID, "kind", j.Kind, "play_id", j.PlayID, "error", err)
+	default:
+		j.Status, j.Result, j.Progress, j.Stage = Succeeded, result, 1, ""
+	}
+}
+
+// run calls the job's function, turning a panic into a failure
+func (q *Queue) run(ctx context.Context, j *job) (result interface{}, err error) {
+	defer recovery.Handle("job "+j.ID, func(perr error) { err = perr })
+	return j.fn(ctx)
+}
+
+// pruneLocked forgets finished jobs older than the retention
+func (q *Queue) pruneLocked() {
+	cutoff := time.Now().Add(-q.cfg.Retention)
+	for id, j := range q.jobs {
+		if j.Status.Done() && j.FinishedAt.Before(cutoff) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+type reporterKey struct{}
+
+type reporter struct {
+	q *Queue
+	j *job
+}
+
+// Report records the current stage and overall progress (0-1) of the job
+// running in ctx. It does nothing outside a job.
+func Report(ctx context.Context, stage string, progress float64) {
+	r, ok := ctx.Value(reporterKey{}).(*reporter)
+	if !ok {
+		return
+	}
+	r.q.mu.Lock()
+	defer r.q.mu.Unlock()
+	r.j.Stage = stage
+	if progress > r.j.Progress {
+		r.j.Progress = progress
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
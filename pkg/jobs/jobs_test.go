@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitDone(t *testing.T, q *Queue, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if j, _ := q.Get(id); j.Status.Done() {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestQueue(t *testing.T) {
+	q := New(Config{Workers: 1})
+	defer q.Close()
+
+	ok := q.Submit(Spec{Kind: "clip", PlayID: "p1"}, func(ctx context.Context) (interface{}, error) {
+		Report(ctx, "concat", 0.5)
+		return "clip.mp4", nil
+	})
+	if ok.Status != Queued {
+		t.Errorf("status = %s, want queued", ok.Status)
+	}
+	bad := q.Submit(Spec{Kind: "clip"}, func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("no segments")
+	})
+
+	if j := waitDone(t, q, ok.ID); j.Status != Succeeded || j.Result != "clip.mp4" || j.Progress != 1 {
+		t.Errorf("job = %+v", j)
+	}
+	if j := waitDone(t, q, bad.ID); j.Status != Failed || j.Error != "no segments" {
+		t.Errorf("job = %+v", j)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	q := New(Config{Workers: 1})
+	defer q.Close()
+
+	started := make(chan struct{})
+	running := q.Submit(Spec{Kind: "clip"}, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	queued := q.Submit(Spec{Kind: "clip"}, func(ctx context.Context) (interface{}, error) {
+		t.Error("cancelled job ran")
+		return nil, nil
+	})
+	<-started
+
+	if !q.Cancel(queued.ID) || !q.Cancel(running.ID) {
+		t.Fatal("cancel failed")
+	}
+	if j := waitDone(t, q, running.ID); j.Status != Cancelled {
+		t.Errorf("running job status = %s", j.Status)
+	}
+	if j, _ := q.Get(queued.ID); j.Status != Cancelled {
+		t.Errorf("queued job status = %s", j.Status)
+	}
+	if q.Cancel(running.ID) {
+		t.Error("cancelled a finished job")
+	}
+}
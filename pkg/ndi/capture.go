@@ -4,24 +4,50 @@ package ndi
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
+	"math"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
 )
 
+var logger = logging.For("ndi")
+
 // CaptureConfig configures NDI capture to FFmpeg pipeline
 type CaptureConfig struct {
-	SourceName      string  // NDI source name to capture
-	OutputDir       string  // Directory for output segments
-	SegmentDuration float64 // Segment duration in seconds
-	Codec           string  // Output codec (h264, hevc)
-	Preset          string  // Encoder preset
-	Bitrate         int     // Target bitrate in kbps
+	SourceName      string   // NDI source name to capture
+	OutputDir       string   // Directory for output segments
+	SegmentDuration float64  // Segment duration in seconds
+	EncoderType     string   // software (default), nvenc, qsv, vaapi, videotoolbox
+	Codec           string   // Output codec (h264, hevc, av1)
+	Preset          string   // Encoder preset
+	EncoderOptions  []string // Placed after the encoder, e.g. -gpu 1 for NVENC
+	Device          string   // DRM render node for vaapi and qsv (default: /dev/dri/renderD128)
+	Bitrate         int      // Target bitrate in kbps
+	AudioCodec      string   // aac (default), opus, none (video only)
+	AudioBitrate    int      // Audio bitrate in kbps (default: 128)
+
+	// How long without video before the source is rediscovered and
+	// reconnected, e.g. after it restarted (default: 5s)
+	ReconnectAfter time.Duration
 }
 
+// Frames queued for FFmpeg per input before new ones are dropped. Each
+// input has its own writer, so FFmpeg waiting on one pipe can't stall the
+// other.
+const (
+	videoQueue = 8
+	audioQueue = 64
+)
+
 // Capture handles NDI capture and encoding pipeline
 type Capture struct {
 	config   CaptureConfig
@@ -31,6 +57,18 @@ type Capture struct {
 	running    bool
 	ffmpegCmd  *exec.Cmd
 	ffmpegIn   io.WriteCloser
+	audioIn    io.WriteCloser // FFmpeg's second input (fd 3), nil without audio
+	video      *VideoFrame    // Format of the video input (Data released)
+	audio      *AudioFrame    // Format of the audio input
+	startSeq   int            // First segment number for the next FFmpeg start
+	videoCh    chan []byte
+	audioCh    chan []byte
+	audioPool  bufferPool // Interleaved audio buffers
+	pacer      *avPacer   // Source timestamps to FFmpeg input positions
+	duplicated uint64
+	skipped    uint64
+	writers    sync.WaitGroup
+	dropped    uint64
 	ctx        context.Context
 	cancel     context.CancelFunc
 	onSegment  func(SegmentInfo)
@@ -72,6 +110,12 @@ func NewCapture(config CaptureConfig) (*Capture, error) {
 	if config.Bitrate == 0 {
 		config.Bitrate = 6000
 	}
+	if config.AudioBitrate == 0 {
+		config.AudioBitrate = 128
+	}
+	if config.ReconnectAfter == 0 {
+		config.ReconnectAfter = 5 * time.Second
+	}
 
 	return &Capture{
 		config:   config,
@@ -95,56 +139,87 @@ func (c *Capture) Start(ctx context.Context) error {
 	c.ctx, c.cancel = context.WithCancel(ctx)
 	c.mu.Unlock()
 
-	// Get initial frame to determine resolution and framerate
-	log.Printf("[NDI] Waiting for first frame from %s...", c.config.SourceName)
+	// Get initial frames to determine resolution, framerate and audio format
+	logger.Info("Waiting for first frame", "source", c.config.SourceName)
+	firstFrame, firstAudio, err := c.probe(5 * time.Second)
+	if err != nil {
+		return err
+	}
+
+	// Start FFmpeg process
+	err = c.startFFmpeg(firstFrame, firstAudio)
+	c.receiver.ReleaseVideo(firstFrame)
+	if err != nil {
+		return fmt.Errorf("start ffmpeg: %w", err)
+	}
+	c.startWriters()
+
+	// Start capture loop
+	recovery.Go("ndi capture", c.captureLoop)
+
+	return nil
+}
+
+// probe waits for the first video frame, and audio unless disabled, to learn
+// the source format
+func (c *Capture) probe(timeout time.Duration) (*VideoFrame, *AudioFrame, error) {
+	withAudio := c.config.AudioCodec != "none"
 	var firstFrame *VideoFrame
-	for i := 0; i < 50; i++ { // Try for 5 seconds
-		frame, err := c.receiver.CaptureVideo(100 * time.Millisecond)
+	var firstAudio *AudioFrame
+	deadline := time.Now().Add(timeout)
+	var audioDeadline time.Time
+	for time.Now().Before(deadline) {
+		video, audio, err := c.receiver.Capture(100 * time.Millisecond)
 		if err != nil {
-			return fmt.Errorf("capture first frame: %w", err)
+			return nil, nil, fmt.Errorf("capture first frame: %w", err)
+		}
+		if video != nil {
+			if firstFrame != nil {
+				c.receiver.ReleaseVideo(firstFrame)
+			} else {
+				// Audio usually arrives within a few frames; after
+				// this the source is treated as silent
+				audioDeadline = time.Now().Add(500 * time.Millisecond)
+			}
+			firstFrame = video
+		}
+		if audio != nil && audio.NumSamples > 0 && firstAudio == nil {
+			firstAudio = audio
 		}
-		if frame != nil {
-			firstFrame = frame
+		if firstFrame != nil && (firstAudio != nil || !withAudio || time.Now().After(audioDeadline)) {
 			break
 		}
 	}
 	if firstFrame == nil {
-		return fmt.Errorf("timeout waiting for first frame from %s", c.config.SourceName)
+		return nil, nil, fmt.Errorf("timeout waiting for first frame from %s", c.config.SourceName)
 	}
-
-	log.Printf("[NDI] Source: %dx%d @ %d/%d fps, FourCC: 0x%08X",
-		firstFrame.Width, firstFrame.Height,
-		firstFrame.FrameRateN, firstFrame.FrameRateD,
-		firstFrame.FourCC)
-
-	// Start FFmpeg process
-	if err := c.startFFmpeg(firstFrame); err != nil {
-		return fmt.Errorf("start ffmpeg: %w", err)
+	if !withAudio {
+		firstAudio = nil
 	}
 
-	// Start capture loop
-	go c.captureLoop()
-
-	return nil
+	logger.Info("Source format", "source", c.config.SourceName,
+		"width", firstFrame.Width, "height", firstFrame.Height,
+		"fps", fmt.Sprintf("%d/%d", firstFrame.FrameRateN, firstFrame.FrameRateD),
+		"fourcc", fmt.Sprintf("0x%08X", firstFrame.FourCC))
+	if firstAudio != nil {
+		logger.Info("Audio format", "source", c.config.SourceName,
+			"sample_rate", firstAudio.SampleRate, "channels", firstAudio.NumChannels)
+	} else if withAudio {
+		logger.Warn("No audio from source, capturing video only", "source", c.config.SourceName)
+	}
+	return firstFrame, firstAudio, nil
 }
 
-// startFFmpeg starts the FFmpeg encoding process
-func (c *Capture) startFFmpeg(frame *VideoFrame) error {
-	// Determine pixel format based on FourCC
-	pixFmt := "uyvy422" // Default for UYVY
-	switch frame.FourCC {
-	case FourCCUYVY:
-		pixFmt = "uyvy422"
-	case FourCCBGRA:
-		pixFmt = "bgra"
-	case FourCCRGBA:
-		pixFmt = "rgba"
-	case FourCCNV12:
-		pixFmt = "nv12"
-	case FourCCI420:
-		pixFmt = "yuv420p"
+// startFFmpeg starts the FFmpeg encoding process. Video is read from stdin
+// and, when audio is set, interleaved f32le samples from fd 3.
+func (c *Capture) startFFmpeg(frame *VideoFrame, audio *AudioFrame) error {
+	sampleRate := 0
+	if audio != nil {
+		sampleRate = audio.SampleRate
 	}
+	c.pacer = newAVPacer(frame.FrameRateN, frame.FrameRateD, sampleRate)
 
+	pixFmt := pixelFormat(frame.FourCC)
 	frameRate := fmt.Sprintf("%d/%d", frame.FrameRateN, frame.FrameRateD)
 	resolution := fmt.Sprintf("%dx%d", frame.Width, frame.Height)
 
@@ -157,26 +232,43 @@ func (c *Capture) startFFmpeg(frame *VideoFrame) error {
 		"-framerate", frameRate,
 		"-i", "pipe:0", // Read from stdin
 	}
+	if audio != nil {
+		args = append(args,
+			"-f", "f32le",
+			"-ar", fmt.Sprintf("%d", audio.SampleRate),
+			"-ac", fmt.Sprintf("%d", audio.NumChannels),
+			"-i", "pipe:3", // First of cmd.ExtraFiles
+			"-map", "0:v", "-map", "1:a",
+		)
+	}
 
-	// Add encoder settings
-	switch c.config.Codec {
-	case "hevc", "h265":
-		args = append(args, "-c:v", "libx265")
-	default:
-		args = append(args, "-c:v", "libx264")
+	// Add encoder settings; hardware device args go before the inputs
+	hwArgs, encArgs, err := ffmpeg.RawEncodeArgs(c.config.EncoderType, c.config.Codec, c.config.Preset, c.config.Device)
+	if err != nil {
+		return err
 	}
+	args = append(append(hwArgs, args...), encArgs...)
+	args = append(args, c.config.EncoderOptions...)
 
 	args = append(args,
-		"-preset", c.config.Preset,
 		"-b:v", fmt.Sprintf("%dk", c.config.Bitrate),
 		"-g", fmt.Sprintf("%d", int(float64(frame.FrameRateN)/float64(frame.FrameRateD)*c.config.SegmentDuration)),
 		"-keyint_min", fmt.Sprintf("%d", int(float64(frame.FrameRateN)/float64(frame.FrameRateD)*c.config.SegmentDuration)),
 		"-sc_threshold", "0",
 	)
 
-	// fMP4 segment output
+	if audio != nil {
+		codec := "aac"
+		if c.config.AudioCodec == "opus" {
+			codec = "libopus"
+		}
+		args = append(args, "-c:a", codec, "-b:a", fmt.Sprintf("%dk", c.config.AudioBitrate))
+	}
+
+	// fMP4 segment output, numbered on from before a restart
 	args = append(args,
 		"-f", "segment",
+		"-segment_start_number", fmt.Sprintf("%d", c.startSeq),
 		"-segment_format", "mp4",
 		"-segment_time", fmt.Sprintf("%.1f", c.config.SegmentDuration),
 		"-segment_format_options", "movflags=+frag_keyframe+empty_moov+default_base_moof",
@@ -189,26 +281,114 @@ func (c *Capture) startFFmpeg(frame *VideoFrame) error {
 	// Note: FFmpeg segment muxer with fMP4 creates init.mp4 automatically
 	// when using frag_keyframe+empty_moov
 
-	log.Printf("[NDI] FFmpeg args: %v", args)
+	logger.Debug("FFmpeg args", "args", args)
 
 	c.ffmpegCmd = exec.CommandContext(c.ctx, "ffmpeg", args...)
 
 	// Get stdin pipe
-	var err error
 	c.ffmpegIn, err = c.ffmpegCmd.StdinPipe()
 	if err != nil {
 		return fmt.Errorf("get stdin pipe: %w", err)
 	}
 
+	// Audio pipe, inherited by FFmpeg as fd 3
+	var audioOut *os.File
+	if audio != nil {
+		audioOut, c.audioIn, err = os.Pipe()
+		if err != nil {
+			return fmt.Errorf("create audio pipe: %w", err)
+		}
+		c.ffmpegCmd.ExtraFiles = []*os.File{audioOut}
+	}
+	c.video, c.audio = frame, audio
+
 	// Start FFmpeg
-	if err := c.ffmpegCmd.Start(); err != nil {
+	err = c.ffmpegCmd.Start()
+	if audioOut != nil {
+		audioOut.Close() // FFmpeg has its own copy
+	}
+	if err != nil {
+		if c.audioIn != nil {
+			c.audioIn.Close()
+		}
 		return fmt.Errorf("start ffmpeg: %w", err)
 	}
 
-	log.Printf("[NDI] FFmpeg started (PID %d)", c.ffmpegCmd.Process.Pid)
+	logger.Info("FFmpeg started", "pid", c.ffmpegCmd.Process.Pid)
 	return nil
 }
 
+// pixelFormat returns the FFmpeg rawvideo pixel format for a FourCC
+func pixelFormat(fourCC uint32) string {
+	switch fourCC {
+	case FourCCBGRA:
+		return "bgra"
+	case FourCCRGBA:
+		return "rgba"
+	case FourCCNV12:
+		return "nv12"
+	case FourCCI420:
+		return "yuv420p"
+	default:
+		return "uyvy422"
+	}
+}
+
+// startWriters starts a goroutine per FFmpeg input that drains its queue
+func (c *Capture) startWriters() {
+	c.videoCh = make(chan []byte, videoQueue)
+	c.writers.Add(1)
+	recovery.Go("ndi video writer", func() {
+		defer c.writers.Done()
+		c.writeInput("video", c.ffmpegIn, c.videoCh, &c.receiver.pool)
+	})
+	if c.audioIn != nil {
+		c.audioCh = make(chan []byte, audioQueue)
+		c.writers.Add(1)
+		recovery.Go("ndi audio writer", func() {
+			defer c.writers.Done()
+			c.writeInput("audio", c.audioIn, c.audioCh, &c.audioPool)
+		})
+	}
+}
+
+// writeInput writes queued data to one FFmpeg input until the queue is
+// closed, then closes the input. Written buffers go back to pool. A failed
+// write stops the capture.
+func (c *Capture) writeInput(name string, w io.WriteCloser, queue <-chan []byte, pool *bufferPool) {
+	defer w.Close()
+	for data := range queue {
+		_, err := w.Write(data)
+		pool.put(data)
+		if err != nil {
+			c.mu.Lock()
+			c.lastErr = err
+			c.mu.Unlock()
+			logger.Error("Write error", "source", c.config.SourceName, "input", name, "error", err)
+			c.cancel()
+			for data := range queue {
+				pool.put(data) // Drain until the capture loop closes the queue
+			}
+			return
+		}
+	}
+}
+
+// enqueue queues data for an FFmpeg input, dropping it when FFmpeg falls
+// behind rather than blocking the receiver
+func (c *Capture) enqueue(queue chan<- []byte, data []byte, pool *bufferPool) bool {
+	select {
+	case queue <- data:
+		return true
+	default:
+		pool.put(data)
+		c.mu.Lock()
+		c.dropped++
+		c.mu.Unlock()
+		return false
+	}
+}
+
 // captureLoop runs the main capture loop
 func (c *Capture) captureLoop() {
 	defer func() {
@@ -216,52 +396,233 @@ func (c *Capture) captureLoop() {
 		c.running = false
 		c.mu.Unlock()
 
-		if c.ffmpegIn != nil {
-			c.ffmpegIn.Close()
-		}
-		if c.ffmpegCmd != nil {
-			c.ffmpegCmd.Wait()
-		}
+		c.stopFFmpeg()
 		c.receiver.Destroy()
 	}()
 
+	lastVideo := time.Now()
 	for {
 		select {
 		case <-c.ctx.Done():
-			log.Printf("[NDI] Capture stopped")
+			logger.Info("Capture stopped", "source", c.config.SourceName)
 			return
 		default:
 		}
 
-		// Capture video frame
-		frame, err := c.receiver.CaptureVideo(100 * time.Millisecond)
+		video, audio, err := c.receiver.Capture(100 * time.Millisecond)
 		if err != nil {
 			c.mu.Lock()
 			c.lastErr = err
 			c.mu.Unlock()
-			log.Printf("[NDI] Capture error: %v", err)
+			logger.Warn("Capture error", "source", c.config.SourceName, "error", err)
+		}
+
+		// A source that stopped sending has usually restarted, possibly on
+		// a new address
+		if video == nil && time.Since(lastVideo) > c.config.ReconnectAfter {
+			if !c.reconnect(time.Since(lastVideo)) {
+				return
+			}
+			lastVideo = time.Now()
 			continue
 		}
-		if frame == nil {
-			continue // Timeout, no frame
+
+		if video != nil {
+			lastVideo = time.Now()
+			c.writeVideo(video)
 		}
 
-		// Write frame data to FFmpeg
-		_, err = c.ffmpegIn.Write(frame.Data)
-		if err != nil {
-			c.mu.Lock()
-			c.lastErr = err
-			c.mu.Unlock()
-			log.Printf("[NDI] Write error: %v", err)
-			return
+		if audio != nil && c.audioCh != nil {
+			// FFmpeg was started with the first frame's format
+			if audio.SampleRate != c.audio.SampleRate || audio.NumChannels != c.audio.NumChannels {
+				logger.Warn("Audio format changed, dropping audio", "source", c.config.SourceName,
+					"sample_rate", audio.SampleRate, "channels", audio.NumChannels)
+				continue
+			}
+			c.writeAudio(audio)
 		}
+	}
+}
 
+// writeVideo queues a frame as many times as the pacer asks: none when it
+// is ahead of the timeline, more than once to fill a gap
+func (c *Capture) writeVideo(video *VideoFrame) {
+	n := c.pacer.video(video.Timestamp)
+	if n == 0 {
+		c.receiver.ReleaseVideo(video)
 		c.mu.Lock()
-		c.frameCount++
+		c.skipped++
 		c.mu.Unlock()
+		return
+	}
+
+	pool := &c.receiver.pool
+	for i := 1; i < n; i++ {
+		dup := pool.get(len(video.Data))
+		copy(dup, video.Data)
+		if !c.enqueue(c.videoCh, dup, pool) {
+			c.pacer.videoLost()
+		}
+	}
+	written := c.enqueue(c.videoCh, video.Data, pool)
+	if !written {
+		c.pacer.videoLost()
+	}
+
+	c.mu.Lock()
+	c.duplicated += uint64(n - 1)
+	if written {
+		c.frameCount++
+	}
+	c.mu.Unlock()
+}
+
+// writeAudio queues audio, padded with silence or trimmed to stay on the
+// source timeline
+func (c *Capture) writeAudio(audio *AudioFrame) {
+	pad, skip := c.pacer.audio(audio.Timestamp, audio.NumSamples)
+	frameBytes := 4 * audio.NumChannels
+
+	if pad > 0 {
+		silence := c.audioPool.get(pad * frameBytes)
+		clear(silence)
+		if !c.enqueue(c.audioCh, silence, &c.audioPool) {
+			c.pacer.audioLost(pad)
+		}
+	}
+	if skip >= audio.NumSamples {
+		return
+	}
+
+	data := c.interleaveAudio(audio)
+	if skip > 0 {
+		n := copy(data, data[skip*frameBytes:])
+		data = data[:n]
+	}
+	if !c.enqueue(c.audioCh, data, &c.audioPool) {
+		c.pacer.audioLost(audio.NumSamples - skip)
+	}
+}
+
+// reconnect rediscovers and reconnects to the source until it sends video
+// again or the capture stops. FFmpeg keeps running when the format is
+// unchanged and is restarted when it changed. It returns false when the
+// capture should stop.
+func (c *Capture) reconnect(silent time.Duration) bool {
+	logger.Warn("NDI source lost, reconnecting", "source", c.config.SourceName, "silent_for", silent.Round(time.Second))
+
+	backoff := time.Second
+	for {
+		if c.ctx.Err() != nil {
+			return false
+		}
+		err := c.receiver.Reconnect(5 * time.Second)
+		if err == nil {
+			var video *VideoFrame
+			var audio *AudioFrame
+			if video, audio, err = c.probe(5 * time.Second); err == nil {
+				if sameFormat(c.video, video) && sameAudioFormat(c.audio, audio) {
+					c.receiver.ReleaseVideo(video)
+					logger.Info("NDI source reconnected", "source", c.config.SourceName)
+					return true
+				}
+				logger.Info("NDI source reconnected with a new format, restarting FFmpeg", "source", c.config.SourceName)
+				if err := c.restartFFmpeg(video, audio); err != nil {
+					c.mu.Lock()
+					c.lastErr = err
+					c.mu.Unlock()
+					logger.Error("Restart FFmpeg failed", "source", c.config.SourceName, "error", err)
+					return false
+				}
+				return true
+			}
+		}
+
+		logger.Warn("NDI reconnect failed", "source", c.config.SourceName, "error", err, "retry_in", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-c.ctx.Done():
+			return false
+		}
+		backoff = min(2*backoff, 30*time.Second)
 	}
 }
 
+// restartFFmpeg stops FFmpeg after it has written what it was sent and
+// starts it for a new source format, continuing the segment numbering
+func (c *Capture) restartFFmpeg(video *VideoFrame, audio *AudioFrame) error {
+	c.stopFFmpeg()
+	c.startSeq = nextSegmentNumber(c.config.OutputDir)
+
+	err := c.startFFmpeg(video, audio)
+	c.receiver.ReleaseVideo(video)
+	if err != nil {
+		return fmt.Errorf("start ffmpeg: %w", err)
+	}
+	c.startWriters()
+	return nil
+}
+
+// stopFFmpeg closes FFmpeg's inputs once the queued frames are written and
+// waits for it to exit
+func (c *Capture) stopFFmpeg() {
+	if c.videoCh != nil {
+		close(c.videoCh)
+		c.videoCh = nil
+	}
+	if c.audioCh != nil {
+		close(c.audioCh)
+		c.audioCh = nil
+	}
+	c.writers.Wait()
+	c.audioIn = nil
+	if c.ffmpegCmd != nil {
+		c.ffmpegCmd.Wait()
+		c.ffmpegCmd = nil
+	}
+}
+
+// sameFormat reports whether two video frames have the same raw format
+func sameFormat(a, b *VideoFrame) bool {
+	return a.Width == b.Width && a.Height == b.Height && a.FourCC == b.FourCC &&
+		a.FrameRateN == b.FrameRateN && a.FrameRateD == b.FrameRateD
+}
+
+// sameAudioFormat reports whether two audio frames, either of which may be
+// nil for no audio, have the same format
+func sameAudioFormat(a, b *AudioFrame) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.SampleRate == b.SampleRate && a.NumChannels == b.NumChannels
+}
+
+// nextSegmentNumber returns the number after the highest segment in dir
+func nextSegmentNumber(dir string) int {
+	matches, _ := filepath.Glob(filepath.Join(dir, "segment_*.m4s"))
+	next := 0
+	for _, m := range matches {
+		var n int
+		if _, err := fmt.Sscanf(filepath.Base(m), "segment_%05d.m4s", &n); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return next
+}
+
+// interleaveAudio converts planar NDI audio to interleaved little-endian
+// float32 samples in a pooled buffer
+func (c *Capture) interleaveAudio(frame *AudioFrame) []byte {
+	out := c.audioPool.get(4 * frame.NumSamples * frame.NumChannels)
+	for ch := 0; ch < frame.NumChannels; ch++ {
+		plane := frame.Data[ch*frame.NumSamples : (ch+1)*frame.NumSamples]
+		for i, v := range plane {
+			binary.LittleEndian.PutUint32(out[4*(i*frame.NumChannels+ch):], math.Float32bits(v))
+		}
+	}
+	return out
+}
+
 // Stop stops the capture pipeline
 func (c *Capture) Stop() {
 	c.mu.Lock()
@@ -278,9 +639,19 @@ func (c *Capture) IsRunning() bool {
 	return c.running
 }
 
-// Stats returns capture statistics
+// Stats returns capture statistics, counting frames dropped because FFmpeg
+// fell behind and audio buffers with video ones
 func (c *Capture) Stats() ReceiverStats {
-	return c.receiver.Stats()
+	stats := c.receiver.Stats()
+	c.mu.RLock()
+	stats.FramesDropped += c.dropped
+	stats.FramesDuplicated = c.duplicated
+	stats.FramesSkipped = c.skipped
+	c.mu.RUnlock()
+	allocated, reused := c.audioPool.counts()
+	stats.BuffersAllocated += allocated
+	stats.BuffersReused += reused
+	return stats
 }
 
 // LastError returns the last error
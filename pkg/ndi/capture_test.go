@@ -0,0 +1,190 @@
+//go:build ndi
+
+package ndi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterleaveAudio(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame *AudioFrame
+		want  []float32
+	}{
+		{"mono", &AudioFrame{NumChannels: 1, NumSamples: 3, Data: []float32{1, 2, 3}}, []float32{1, 2, 3}},
+		{"stereo", &AudioFrame{NumChannels: 2, NumSamples: 2, Data: []float32{1, 2, -1, -2}}, []float32{1, -1, 2, -2}},
+		{"three channels", &AudioFrame{NumChannels: 3, NumSamples: 2, Data: []float32{1, 2, 3, 4, 5, 6}}, []float32{1, 3, 5, 2, 4, 6}},
+		{"empty", &AudioFrame{NumChannels: 2}, []float32{}},
+	}
+	c := &Capture{}
+	for _, tt := range tests {
+		out := c.interleaveAudio(tt.frame)
+		if len(out) != 4*len(tt.want) {
+			t.Errorf("%s: %d bytes, want %d", tt.name, len(out), 4*len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if got := math.Float32frombits(binary.LittleEndian.Uint32(out[4*i:])); got != want {
+				t.Errorf("%s: sample %d = %v, want %v", tt.name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	c := &Capture{}
+	var pool bufferPool
+	queue := make(chan []byte, 2)
+	tests := []struct {
+		want        bool
+		wantDropped uint64
+	}{
+		{true, 0},
+		{true, 0},
+		{false, 1}, // Queue full
+		{false, 2},
+	}
+	for i, tt := range tests {
+		if got := c.enqueue(queue, pool.get(16), &pool); got != tt.want {
+			t.Errorf("enqueue %d = %v, want %v", i, got, tt.want)
+		}
+		if c.dropped != tt.wantDropped {
+			t.Errorf("enqueue %d: dropped = %d, want %d", i, c.dropped, tt.wantDropped)
+		}
+	}
+}
+
+// recordingWriter collects writes, failing once failAt writes have been made
+type recordingWriter struct {
+	writes int
+	failAt int
+	closed bool
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if w.failAt > 0 && w.writes == w.failAt {
+		return 0, errors.New("broken pipe")
+	}
+	w.writes++
+	return len(p), nil
+}
+
+func (w *recordingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestWriteInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		queued     int
+		failAt     int
+		wantWrites int
+		wantErr    bool
+	}{
+		{"drains queue", 5, 0, 5, false},
+		{"write error stops capture", 5, 2, 2, true},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		c := &Capture{ctx: ctx, cancel: cancel}
+		var pool bufferPool
+		queue := make(chan []byte, tt.queued)
+		for i := 0; i < tt.queued; i++ {
+			queue <- pool.get(16)
+		}
+		close(queue)
+
+		w := &recordingWriter{failAt: tt.failAt}
+		c.writeInput("audio", w, queue, &pool)
+		if w.writes != tt.wantWrites {
+			t.Errorf("%s: %d writes, want %d", tt.name, w.writes, tt.wantWrites)
+		}
+		if !w.closed {
+			t.Errorf("%s: input not closed", tt.name)
+		}
+		if (c.LastError() != nil) != tt.wantErr {
+			t.Errorf("%s: last error = %v, wantErr %v", tt.name, c.LastError(), tt.wantErr)
+		}
+		if tt.wantErr && ctx.Err() == nil {
+			t.Errorf("%s: capture not cancelled", tt.name)
+		}
+		if len(queue) != 0 {
+			t.Errorf("%s: %d buffers left in the queue", tt.name, len(queue))
+		}
+		cancel()
+	}
+}
+
+func TestSameFormat(t *testing.T) {
+	base := VideoFrame{Width: 1920, Height: 1080, FourCC: 0x59565955, FrameRateN: 30000, FrameRateD: 1001}
+	tests := []struct {
+		name   string
+		change func(f *VideoFrame)
+		want   bool
+	}{
+		{"identical", func(f *VideoFrame) {}, true},
+		{"new data only", func(f *VideoFrame) { f.Data = []byte{1} }, true},
+		{"resolution", func(f *VideoFrame) { f.Width, f.Height = 1280, 720 }, false},
+		{"pixel format", func(f *VideoFrame) { f.FourCC = 0x41565955 }, false},
+		{"frame rate", func(f *VideoFrame) { f.FrameRateN, f.FrameRateD = 25, 1 }, false},
+	}
+	for _, tt := range tests {
+		other := base
+		tt.change(&other)
+		if got := sameFormat(&base, &other); got != tt.want {
+			t.Errorf("%s: sameFormat = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSameAudioFormat(t *testing.T) {
+	stereo := &AudioFrame{SampleRate: 48000, NumChannels: 2, NumSamples: 1600}
+	tests := []struct {
+		name string
+		a, b *AudioFrame
+		want bool
+	}{
+		{"both silent", nil, nil, true},
+		{"audio appeared", nil, stereo, false},
+		{"audio went away", stereo, nil, false},
+		{"same format", stereo, &AudioFrame{SampleRate: 48000, NumChannels: 2, NumSamples: 800}, true},
+		{"sample rate", stereo, &AudioFrame{SampleRate: 44100, NumChannels: 2}, false},
+		{"channels", stereo, &AudioFrame{SampleRate: 48000, NumChannels: 8}, false},
+	}
+	for _, tt := range tests {
+		if got := sameAudioFormat(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: sameAudioFormat = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextSegmentNumber(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  int
+	}{
+		{"empty", nil, 0},
+		{"continues after highest", []string{"segment_00000.m4s", "segment_00007.m4s", "segment_00003.m4s"}, 8},
+		{"ignores other files", []string{"init.mp4", "segment_00002.m4s", "segment_x.m4s", "notes.txt"}, 3},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for _, name := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := nextSegmentNumber(dir); got != tt.want {
+			t.Errorf("%s: nextSegmentNumber = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
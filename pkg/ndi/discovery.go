@@ -0,0 +1,84 @@
+package ndi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DiscoveryConfig configures how sources are found, e.g. on routed
+// networks where mDNS does not reach
+type DiscoveryConfig struct {
+	Groups          string // NDI groups to search (comma-separated, empty = public)
+	ExtraIPs        string // Additional IPs to query (comma-separated)
+	DiscoveryServer string // Discovery server address, host or host:port
+}
+
+var (
+	discoveryMu sync.Mutex
+	discovery   DiscoveryConfig
+)
+
+// Configure sets the discovery settings used by finders created without a
+// config, which includes DiscoverSources and receivers. The discovery
+// server is read by the SDK when it initializes, so Configure must run
+// before anything else in this package.
+func Configure(cfg DiscoveryConfig) error {
+	if cfg.DiscoveryServer != "" {
+		if err := useDiscoveryServer(cfg.DiscoveryServer); err != nil {
+			return err
+		}
+	}
+	discoveryMu.Lock()
+	discovery = cfg
+	discoveryMu.Unlock()
+	return nil
+}
+
+// DefaultFinderConfig returns a copy of the finder settings from Configure
+func DefaultFinderConfig() *FinderConfig {
+	discoveryMu.Lock()
+	defer discoveryMu.Unlock()
+	return &FinderConfig{
+		ShowLocalSources: true,
+		Groups:           discovery.Groups,
+		ExtraIPs:         discovery.ExtraIPs,
+	}
+}
+
+// useDiscoveryServer writes an SDK config file naming the server and
+// points the SDK at it through NDI_CONFIG_DIR
+func useDiscoveryServer(addr string) error {
+	if os.Getenv("NDI_CONFIG_DIR") != "" {
+		return errors.New("NDI_CONFIG_DIR is set; configure the discovery server in its ndi-config.v1.json")
+	}
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("go-video-capture-ndi-%d", os.Getpid()))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create ndi config dir: %w", err)
+	}
+	if err := writeSDKConfig(dir, addr); err != nil {
+		return err
+	}
+	return os.Setenv("NDI_CONFIG_DIR", dir)
+}
+
+// writeSDKConfig writes ndi-config.v1.json with the discovery server
+func writeSDKConfig(dir, addr string) error {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"ndi": map[string]interface{}{
+			"networks": map[string]interface{}{
+				"discovery": addr,
+			},
+		},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ndi-config.v1.json"), data, 0600); err != nil {
+		return fmt.Errorf("write ndi config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package ndi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSDKConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeSDKConfig(dir, "10.20.0.2:5959"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "ndi-config.v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg struct {
+		NDI struct {
+			Networks struct {
+				Discovery string `json:"discovery"`
+			} `json:"networks"`
+		} `json:"ndi"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NDI.Networks.Discovery != "10.20.0.2:5959" {
+		t.Errorf("discovery = %q", cfg.NDI.Networks.Discovery)
+	}
+}
+
+func TestDefaultFinderConfig(t *testing.T) {
+	t.Cleanup(func() { Configure(DiscoveryConfig{}) })
+	if err := Configure(DiscoveryConfig{Groups: "studio-a", ExtraIPs: "10.20.0.15"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultFinderConfig()
+	if !cfg.ShowLocalSources || cfg.Groups != "studio-a" || cfg.ExtraIPs != "10.20.0.15" {
+		t.Errorf("finder config = %+v", cfg)
+	}
+}
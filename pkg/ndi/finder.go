@@ -44,25 +44,25 @@ type Finder struct {
 	instance C.NDIlib_find_instance_t
 }
 
-// NewFinder creates a new NDI source finder
+// NewFinder creates a new NDI source finder. A nil config uses the
+// settings from Configure.
 func NewFinder(config *FinderConfig) (*Finder, error) {
 	if err := Initialize(); err != nil {
 		return nil, err
 	}
+	if config == nil {
+		config = DefaultFinderConfig()
+	}
 
 	var createSettings C.NDIlib_find_create_t
-	createSettings.show_local_sources = C.bool(true)
-
-	if config != nil {
-		createSettings.show_local_sources = C.bool(config.ShowLocalSources)
-		if config.Groups != "" {
-			createSettings.p_groups = C.CString(config.Groups)
-			defer C.free(unsafe.Pointer(createSettings.p_groups))
-		}
-		if config.ExtraIPs != "" {
-			createSettings.p_extra_ips = C.CString(config.ExtraIPs)
-			defer C.free(unsafe.Pointer(createSettings.p_extra_ips))
-		}
+	createSettings.show_local_sources = C.bool(config.ShowLocalSources)
+	if config.Groups != "" {
+		createSettings.p_groups = C.CString(config.Groups)
+		defer C.free(unsafe.Pointer(createSettings.p_groups))
+	}
+	if config.ExtraIPs != "" {
+		createSettings.p_extra_ips = C.CString(config.ExtraIPs)
+		defer C.free(unsafe.Pointer(createSettings.p_extra_ips))
 	}
 
 	instance := C.NDIlib_find_create_v2(&createSettings)
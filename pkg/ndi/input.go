@@ -0,0 +1,159 @@
+package ndi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func init() {
+	// Frames for encode plugins; Capture runs NDI through FFmpeg otherwise
+	if IsAvailable() {
+		input.Register("ndi", func() input.Input { return NewInput() })
+	}
+}
+
+// readInterval is how often a blocked read checks its context
+const readInterval = 100 * time.Millisecond
+
+var errInputClosed = errors.New("ndi input closed")
+
+// Input receives an NDI source's video as frames, for capture to encode
+// with an encode plugin. The sender sets the mode and may change it; frames
+// then arrive in the new size, format or rate. Audio is not received.
+type Input struct {
+	mu       sync.RWMutex // Held for reading by receives; Close waits for them
+	receiver *Receiver
+
+	// Owned by the goroutine reading frames
+	mode input.VideoMode
+	seq  int64
+}
+
+// NewInput creates an NDI input
+func NewInput() *Input {
+	return &Input{}
+}
+
+func (n *Input) Name() string { return "ndi-native" }
+func (n *Input) Type() string { return "ndi" }
+
+func (n *Input) Capabilities() input.Capabilities {
+	return input.Capabilities{
+		SupportsVideo:    true,
+		SupportedFormats: []input.PixelFormat{input.FormatUYVY, input.FormatBGRA},
+	}
+}
+
+// Open connects to the source named by the device
+func (n *Input) Open(cfg input.Config) error {
+	receiver, err := NewReceiver(ReceiverConfig{
+		SourceName:  cfg.Device,
+		ColorFormat: ColorFormatUYVYBGRA, // UYVY unless the source has alpha
+		Bandwidth:   BandwidthHighest,
+	})
+	if err != nil {
+		return fmt.Errorf("ndi %s: %w", cfg.Device, err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.receiver = receiver
+	return nil
+}
+
+// Close disconnects, once a receive in progress has returned
+func (n *Input) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.receiver != nil {
+		n.receiver.Destroy()
+		n.receiver = nil
+	}
+	return nil
+}
+
+// ReadFrame returns the next video frame
+func (n *Input) ReadFrame(ctx context.Context) (*input.Frame, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n.mu.RLock()
+		if n.receiver == nil {
+			n.mu.RUnlock()
+			return nil, errInputClosed
+		}
+		video, err := n.receiver.CaptureVideo(readInterval)
+		var frame *input.Frame
+		if err == nil && video != nil {
+			frame, err = n.frame(video)
+			n.receiver.ReleaseVideo(video)
+		}
+		n.mu.RUnlock()
+		if err != nil || frame != nil {
+			return frame, err
+		}
+	}
+}
+
+// frame copies a received frame, without the padding of its lines
+func (n *Input) frame(video *VideoFrame) (*input.Frame, error) {
+	format := input.FormatUYVY
+	bpp := 2
+	switch video.FourCC {
+	case FourCCUYVY:
+	case FourCCBGRA, FourCCBGRX:
+		format, bpp = input.FormatBGRA, 4
+	default:
+		return nil, fmt.Errorf("ndi: unsupported video format %08x", video.FourCC)
+	}
+
+	line := video.Width * bpp
+	stride := video.LineStride
+	if stride == 0 {
+		stride = line
+	}
+	data := make([]byte, line*video.Height)
+	for y := 0; y < video.Height; y++ {
+		copy(data[y*line:(y+1)*line], video.Data[y*stride:])
+	}
+
+	framerate := 0.0
+	if video.FrameRateD > 0 {
+		framerate = float64(video.FrameRateN) / float64(video.FrameRateD)
+	}
+	n.seq++
+	n.mode = input.VideoMode{Width: video.Width, Height: video.Height, Framerate: framerate, Format: format}
+	return &input.Frame{
+		Data:      data,
+		Width:     video.Width,
+		Height:    video.Height,
+		Format:    format,
+		Timestamp: time.Now().UnixNano(),
+		Sequence:  n.seq,
+	}, nil
+}
+
+// DetectedMode returns the mode of the last frame read
+func (n *Input) DetectedMode() input.VideoMode {
+	return n.mode
+}
+
+// ListDevices lists the sources on the network
+func (n *Input) ListDevices() ([]input.Device, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sources, err := DiscoverSources(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]input.Device, len(sources))
+	for i, s := range sources {
+		devices[i] = input.Device{ID: s.Name, Name: s.Name, Type: "ndi", Description: s.Address}
+	}
+	return devices, nil
+}
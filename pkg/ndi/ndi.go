@@ -15,9 +15,10 @@ import (
 	"time"
 )
 
-// DiscoverSources discovers NDI sources on the network using the native SDK
-func DiscoverSources(ctx context.Context) ([]Source, error) {
-	finder, err := NewFinder(nil)
+// DiscoverSources discovers NDI sources on the network using the native SDK.
+// A nil config uses the settings from Configure.
+func DiscoverSources(ctx context.Context, config *FinderConfig) ([]Source, error) {
+	finder, err := NewFinder(config)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,126 @@
+package ndi
+
+import "math"
+
+// NDI timestamps are in 100 ns units; senders that don't set one send
+// this value
+const (
+	timestampUnit      = 10_000_000
+	timestampUndefined = math.MaxInt64
+)
+
+// Corrections smaller than audioTolerance are left alone so jitter doesn't
+// cause constant padding and trimming. A jump larger than maxDrift, e.g.
+// after the source restarted, resets the timeline instead of filling it.
+const (
+	audioToleranceMs = 20
+	maxDriftSeconds  = 2
+)
+
+// avPacer maps NDI timestamps onto FFmpeg's inputs, which count time in
+// video frames at the nominal rate and in audio samples. Writing a frame
+// once per period of its timestamp keeps both timelines on the source
+// clock, so drops and jitter don't add up to drift between them.
+type avPacer struct {
+	rateN, rateD int64 // Video frame rate
+	sampleRate   int64
+
+	started   bool
+	origin    int64 // Timestamp at position zero of both inputs
+	videoNext int64 // Index of the next frame FFmpeg reads
+	audioNext int64 // Index of the next sample FFmpeg reads
+}
+
+// newAVPacer creates a pacer for a video rate and audio sample rate (0
+// without audio)
+func newAVPacer(rateN, rateD, sampleRate int) *avPacer {
+	if rateN <= 0 || rateD <= 0 {
+		rateN, rateD = 30, 1
+	}
+	return &avPacer{rateN: int64(rateN), rateD: int64(rateD), sampleRate: int64(sampleRate)}
+}
+
+// video returns how many times to write a frame with timestamp ts: 0 drops
+// it, more than 1 repeats it to fill a gap
+func (p *avPacer) video(ts int64) int {
+	if ts == timestampUndefined || ts <= 0 {
+		p.videoNext++
+		return 1
+	}
+	if !p.start(ts) {
+		p.videoNext++
+		return 1
+	}
+
+	idx := p.frameAt(ts)
+	if drift := idx - p.videoNext; drift > maxDriftSeconds*p.rateN/p.rateD || drift < -maxDriftSeconds*p.rateN/p.rateD {
+		p.resync(ts, p.videoNext*p.rateD*timestampUnit/p.rateN)
+		idx = p.videoNext
+	}
+
+	if idx < p.videoNext {
+		return 0
+	}
+	n := int(idx - p.videoNext + 1)
+	p.videoNext = idx + 1
+	return n
+}
+
+// audio returns how many samples of silence to write before a frame with
+// timestamp ts and the given length, or how many of its first samples to
+// skip
+func (p *avPacer) audio(ts int64, samples int) (pad, skip int) {
+	if p.sampleRate == 0 || ts == timestampUndefined || ts <= 0 || !p.start(ts) {
+		p.audioNext += int64(samples)
+		return 0, 0
+	}
+
+	pos := (ts - p.origin) * p.sampleRate / timestampUnit
+	drift := pos - p.audioNext
+	if drift > maxDriftSeconds*p.sampleRate || drift < -maxDriftSeconds*p.sampleRate {
+		p.resync(ts, p.audioNext*timestampUnit/p.sampleRate)
+		drift = 0
+	}
+
+	tolerance := audioToleranceMs * p.sampleRate / 1000
+	switch {
+	case drift > tolerance:
+		pad = int(drift)
+	case drift < -tolerance:
+		skip = min(int(-drift), samples)
+	}
+	p.audioNext += int64(pad + samples - skip)
+	return pad, skip
+}
+
+// videoLost and audioLost undo frames that were never written, e.g. when
+// a queue was full, so the next frame fills the gap
+func (p *avPacer) videoLost() {
+	p.videoNext--
+}
+
+func (p *avPacer) audioLost(samples int) {
+	p.audioNext -= int64(samples)
+}
+
+// start sets the origin from the first timestamp; it reports whether the
+// timeline was already running
+func (p *avPacer) start(ts int64) bool {
+	if p.started {
+		return true
+	}
+	p.started = true
+	p.origin = ts
+	return false
+}
+
+// resync moves the origin so ts lands at offset (in timestamp units)
+func (p *avPacer) resync(ts, offset int64) {
+	p.origin = ts - offset
+}
+
+// frameAt returns the frame index nearest to ts
+func (p *avPacer) frameAt(ts int64) int64 {
+	d := p.rateD * timestampUnit
+	return ((ts-p.origin)*p.rateN + d/2) / d
+}
@@ -0,0 +1,58 @@
+package ndi
+
+import "testing"
+
+func TestPacerVideo(t *testing.T) {
+	p := newAVPacer(25, 1, 0)
+	const frame = timestampUnit / 25
+	start := int64(1_000_000_000)
+
+	tests := []struct {
+		ts   int64
+		want int
+	}{
+		{start, 1},
+		{start + frame + frame/10, 1},  // Jitter
+		{start + 3*frame, 2},           // Frame 2 missing: repeat
+		{start + 3*frame + frame/5, 0}, // Frame 3 again
+		{start + 4*frame, 1},
+		{start + 500*frame, 1}, // Source restarted: resync, no fill
+		{start + 501*frame, 1},
+	}
+	for i, tt := range tests {
+		if got := p.video(tt.ts); got != tt.want {
+			t.Errorf("frame %d: video() = %d, want %d", i, got, tt.want)
+		}
+	}
+
+	// A frame lost before FFmpeg read it is filled by the next
+	p.videoLost()
+	if got := p.video(start + 502*frame); got != 2 {
+		t.Errorf("after lost frame: video() = %d, want 2", got)
+	}
+}
+
+func TestPacerAudio(t *testing.T) {
+	p := newAVPacer(25, 1, 48000)
+	start := int64(1_000_000_000)
+	p.video(start)
+
+	const samples = 1920 // 40 ms
+	ts := func(sample int64) int64 { return start + sample*timestampUnit/48000 }
+
+	if pad, skip := p.audio(ts(0), samples); pad != 0 || skip != 0 {
+		t.Errorf("first frame: pad %d skip %d", pad, skip)
+	}
+	// 10 ms late is within tolerance
+	if pad, skip := p.audio(ts(samples+480), samples); pad != 0 || skip != 0 {
+		t.Errorf("jitter: pad %d skip %d", pad, skip)
+	}
+	// A dropped frame is filled with silence
+	if pad, skip := p.audio(ts(3*samples), samples); pad != samples || skip != 0 {
+		t.Errorf("gap: pad %d skip %d, want pad %d", pad, skip, samples)
+	}
+	// Overlapping audio is trimmed
+	if pad, skip := p.audio(ts(4*samples-1200), samples); pad != 0 || skip != 1200 {
+		t.Errorf("overlap: pad %d skip %d, want skip 1200", pad, skip)
+	}
+}
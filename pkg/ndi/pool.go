@@ -0,0 +1,38 @@
+package ndi
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// bufferPool recycles frame buffers. A 1080p60 UYVY source delivers about
+// 250 MB/s of video; allocating a fresh slice per frame keeps the GC busy.
+type bufferPool struct {
+	pool      sync.Pool
+	allocated atomic.Uint64
+	reused    atomic.Uint64
+}
+
+// get returns a buffer of length n, reusing a pooled one when it is large
+// enough. Smaller ones, e.g. from before a resolution change, are dropped.
+func (p *bufferPool) get(n int) []byte {
+	if b, ok := p.pool.Get().(*[]byte); ok && cap(*b) >= n {
+		p.reused.Add(1)
+		return (*b)[:n]
+	}
+	p.allocated.Add(1)
+	return make([]byte, n)
+}
+
+// put returns a buffer for reuse; the caller must not touch it afterwards
+func (p *bufferPool) put(b []byte) {
+	if cap(b) == 0 {
+		return
+	}
+	p.pool.Put(&b)
+}
+
+// counts returns how many buffers were allocated and reused
+func (p *bufferPool) counts() (allocated, reused uint64) {
+	return p.allocated.Load(), p.reused.Load()
+}
@@ -0,0 +1,28 @@
+package ndi
+
+import "testing"
+
+func TestBufferPool(t *testing.T) {
+	var p bufferPool
+
+	b := p.get(1024)
+	if len(b) != 1024 {
+		t.Fatalf("len = %d, want 1024", len(b))
+	}
+	p.put(b)
+
+	// A smaller frame fits in a pooled buffer; a larger one never gets a
+	// short buffer
+	if b := p.get(512); len(b) != 512 {
+		t.Errorf("len = %d, want 512", len(b))
+	}
+	p.put(make([]byte, 256))
+	if b := p.get(4096); len(b) != 4096 {
+		t.Errorf("len = %d, want 4096", len(b))
+	}
+
+	// sync.Pool may drop items at any time, so only the total is exact
+	if allocated, reused := p.counts(); allocated+reused != 3 || allocated == 0 {
+		t.Errorf("allocated = %d, reused = %d", allocated, reused)
+	}
+}
@@ -0,0 +1,255 @@
+//go:build ndi
+
+package ndi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/recovery"
+)
+
+// PreviewConfig configures a low-bandwidth JPEG preview of a source
+type PreviewConfig struct {
+	SourceName string // NDI source name to preview
+	Width      int    // Scaled width, height keeps the aspect ratio (default: 640)
+	Framerate  int    // Preview frames per second (default: 10)
+	Quality    int    // JPEG qscale, 2 (best) to 31 (default: 8)
+}
+
+// Preview receives a source's low-bandwidth stream, which NDI senders
+// provide at reduced resolution, and encodes it to JPEG frames for
+// monitoring many channels cheaply
+type Preview struct {
+	config   PreviewConfig
+	receiver *Receiver
+
+	mu        sync.Mutex
+	running   bool
+	frame     []byte
+	frameTime time.Time
+	onFrame   func([]byte)
+	lastErr   error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// previewEncoder is an FFmpeg process turning raw frames of one format
+// into JPEGs
+type previewEncoder struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	width  int
+	height int
+	fourCC uint32
+	done   chan struct{}
+}
+
+// NewPreview creates a preview with its own receiver
+func NewPreview(config PreviewConfig) (*Preview, error) {
+	receiver, err := NewReceiver(ReceiverConfig{
+		SourceName:   config.SourceName,
+		ColorFormat:  ColorFormatUYVYBGRA,
+		Bandwidth:    BandwidthLowest,
+		ReceiverName: "preview",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create NDI preview receiver: %w", err)
+	}
+
+	if config.Width == 0 {
+		config.Width = 640
+	}
+	if config.Framerate == 0 {
+		config.Framerate = 10
+	}
+	if config.Quality == 0 {
+		config.Quality = 8
+	}
+	return &Preview{config: config, receiver: receiver}, nil
+}
+
+// OnFrame sets a callback for each new JPEG frame
+func (p *Preview) OnFrame(fn func(jpeg []byte)) {
+	p.mu.Lock()
+	p.onFrame = fn
+	p.mu.Unlock()
+}
+
+// Start starts receiving and encoding
+func (p *Preview) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running {
+		return fmt.Errorf("preview already running")
+	}
+	p.running = true
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+	recovery.Go("ndi preview", p.loop)
+	return nil
+}
+
+// Stop stops the preview and releases the receiver
+func (p *Preview) Stop() {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Frame returns the latest JPEG and when it was received, or nil before
+// the first frame
+func (p *Preview) Frame() ([]byte, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.frame, p.frameTime
+}
+
+// LastError returns the last receive or encode error
+func (p *Preview) LastError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastErr
+}
+
+// loop passes frames to the encoder at the preview rate
+func (p *Preview) loop() {
+	var enc *previewEncoder
+	defer func() {
+		enc.close()
+		p.receiver.Destroy()
+		p.mu.Lock()
+		p.running = false
+		p.mu.Unlock()
+		close(p.done)
+	}()
+
+	interval := time.Second / time.Duration(p.config.Framerate)
+	var lastSent time.Time
+	lastVideo := time.Now()
+	for p.ctx.Err() == nil {
+		frame, err := p.receiver.CaptureVideo(100 * time.Millisecond)
+		if err != nil {
+			p.setError(err)
+		}
+		if frame == nil {
+			// Same reconnect as capture, but the preview is best effort
+			if time.Since(lastVideo) > 5*time.Second {
+				if err := p.receiver.Reconnect(2 * time.Second); err != nil {
+					p.setError(err)
+				}
+				lastVideo = time.Now()
+			}
+			continue
+		}
+		lastVideo = time.Now()
+
+		if time.Since(lastSent) < interval {
+			p.receiver.ReleaseVideo(frame)
+			continue
+		}
+		lastSent = time.Now()
+
+		if enc == nil || enc.width != frame.Width || enc.height != frame.Height || enc.fourCC != frame.FourCC {
+			enc.close()
+			if enc, err = p.startEncoder(frame); err != nil {
+				p.setError(err)
+				p.receiver.ReleaseVideo(frame)
+				continue
+			}
+		}
+		if _, err := enc.stdin.Write(frame.Data); err != nil {
+			p.setError(fmt.Errorf("preview encoder: %w", err))
+			enc.close()
+			enc = nil
+		}
+		p.receiver.ReleaseVideo(frame)
+	}
+}
+
+// startEncoder starts FFmpeg for the frame's size and format
+func (p *Preview) startEncoder(frame *VideoFrame) (*previewEncoder, error) {
+	args := []string{
+		"-loglevel", "error",
+		"-f", "rawvideo",
+		"-pixel_format", pixelFormat(frame.FourCC),
+		"-video_size", fmt.Sprintf("%dx%d", frame.Width, frame.Height),
+		"-framerate", fmt.Sprintf("%d", p.config.Framerate),
+		"-i", "pipe:0",
+		"-vf", fmt.Sprintf("scale=%d:-2", p.config.Width),
+		"-pix_fmt", "yuvj420p",
+		"-c:v", "mjpeg",
+		"-q:v", fmt.Sprintf("%d", p.config.Quality),
+		"-f", "image2pipe",
+		"pipe:1",
+	}
+	cmd := exec.CommandContext(p.ctx, "ffmpeg", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("get stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start preview encoder: %w", err)
+	}
+
+	enc := &previewEncoder{
+		cmd:    cmd,
+		stdin:  stdin,
+		width:  frame.Width,
+		height: frame.Height,
+		fourCC: frame.FourCC,
+		done:   make(chan struct{}),
+	}
+	recovery.Go("ndi preview reader", func() {
+		defer close(enc.done)
+		if err := ffmpeg.ReadJPEGs(stdout, p.setFrame); err != nil {
+			p.setError(fmt.Errorf("read preview frames: %w", err))
+		}
+	})
+	logger.Info("NDI preview started", "source", p.config.SourceName,
+		"input", fmt.Sprintf("%dx%d", frame.Width, frame.Height), "width", p.config.Width)
+	return enc, nil
+}
+
+func (p *Preview) setFrame(jpeg []byte) {
+	p.mu.Lock()
+	p.frame = jpeg
+	p.frameTime = time.Now()
+	fn := p.onFrame
+	p.mu.Unlock()
+	if fn != nil {
+		fn(jpeg)
+	}
+}
+
+func (p *Preview) setError(err error) {
+	p.mu.Lock()
+	p.lastErr = err
+	p.mu.Unlock()
+}
+
+// close ends the encoder's input and waits for it to exit
+func (e *previewEncoder) close() {
+	if e == nil {
+		return
+	}
+	e.stdin.Close()
+	<-e.done
+	e.cmd.Wait()
+}
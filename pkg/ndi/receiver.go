@@ -119,6 +119,8 @@ type Receiver struct {
 	running  bool
 	lastErr  error
 	stats    ReceiverStats
+
+	pool bufferPool // Video frame buffers
 }
 
 // ReceiverStats holds receiver statistics
@@ -130,6 +132,16 @@ type ReceiverStats struct {
 	Height         int
 	FrameRateN     int
 	FrameRateD     int
+
+	// Frame buffer pool: allocations level off once the pool is warm
+	BuffersAllocated uint64
+	BuffersReused    uint64
+
+	Reconnects uint64 // Times the source was rediscovered after going away
+
+	// Capture pacing against the source timestamps
+	FramesDuplicated uint64 // Repeated to fill a gap
+	FramesSkipped    uint64 // Arrived ahead of the timeline
 }
 
 // NewReceiver creates a new NDI receiver for the specified source
@@ -210,14 +222,87 @@ func (r *Receiver) Destroy() {
 
 // Source returns the connected source
 func (r *Receiver) Source() Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.source
 }
 
+// Reconnect rediscovers the source by name, e.g. after it restarted on a new
+// address, and reconnects to it, keeping the receiver's buffers and stats
+func (r *Receiver) Reconnect(timeout time.Duration) error {
+	finder, err := NewFinder(nil)
+	if err != nil {
+		return fmt.Errorf("create finder: %w", err)
+	}
+	defer finder.Destroy()
+
+	source, err := finder.FindSourceByName(r.config.SourceName, timeout)
+	if err != nil {
+		return fmt.Errorf("find source: %w", err)
+	}
+
+	cSourceName := C.CString(source.Name)
+	defer C.free(unsafe.Pointer(cSourceName))
+
+	var cSourceAddr *C.char
+	if source.Address != "" {
+		cSourceAddr = C.CString(source.Address)
+		defer C.free(unsafe.Pointer(cSourceAddr))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.instance == nil {
+		return errors.New("receiver not initialized")
+	}
+	C.NDIlib_recv_connect(r.instance, &C.NDIlib_source_t{
+		p_ndi_name:    cSourceName,
+		p_url_address: cSourceAddr,
+	})
+	r.source = *source
+	r.stats.Reconnects++
+	return nil
+}
+
 // Stats returns current receiver statistics
 func (r *Receiver) Stats() ReceiverStats {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return r.stats
+	stats := r.stats
+	r.mu.RUnlock()
+	stats.BuffersAllocated, stats.BuffersReused = r.pool.counts()
+	return stats
+}
+
+// Capture captures the next video or audio frame with timeout. At most one
+// frame is returned; neither on timeout. Capturing only video would make
+// the SDK discard audio, and the other way round.
+func (r *Receiver) Capture(timeout time.Duration) (*VideoFrame, *AudioFrame, error) {
+	if r.instance == nil {
+		return nil, nil, errors.New("receiver not initialized")
+	}
+
+	var cVideoFrame C.NDIlib_video_frame_v2_t
+	var cAudioFrame C.NDIlib_audio_frame_v2_t
+	timeoutMs := uint32(timeout.Milliseconds())
+
+	frameType := C.NDIlib_recv_capture_v2(
+		r.instance,
+		&cVideoFrame,
+		&cAudioFrame,
+		nil, // no metadata
+		C.uint32_t(timeoutMs),
+	)
+
+	switch FrameType(frameType) {
+	case FrameTypeVideo:
+		return r.copyVideo(&cVideoFrame), nil, nil
+	case FrameTypeAudio:
+		return nil, r.copyAudio(&cAudioFrame), nil
+	case FrameTypeError:
+		return nil, nil, errors.New("NDI receive error")
+	default:
+		return nil, nil, nil // Timeout, status change or metadata
+	}
 }
 
 // CaptureVideo captures a single video frame with timeout
@@ -239,38 +324,7 @@ func (r *Receiver) CaptureVideo(timeout time.Duration) (*VideoFrame, error) {
 
 	switch FrameType(frameType) {
 	case FrameTypeVideo:
-		// Copy frame data to Go memory
-		dataSize := int(cVideoFrame.line_stride_in_bytes) * int(cVideoFrame.yres)
-		data := make([]byte, dataSize)
-		C.copy_video_data((*C.uint8_t)(unsafe.Pointer(&data[0])), &cVideoFrame)
-
-		frame := &VideoFrame{
-			Width:       int(cVideoFrame.xres),
-			Height:      int(cVideoFrame.yres),
-			FourCC:      uint32(cVideoFrame.FourCC),
-			FrameRateN:  int(cVideoFrame.frame_rate_N),
-			FrameRateD:  int(cVideoFrame.frame_rate_D),
-			Data:        data,
-			LineStride:  int(cVideoFrame.line_stride_in_bytes),
-			Timecode:    int64(cVideoFrame.timecode),
-			Timestamp:   int64(cVideoFrame.timestamp),
-			AspectRatio: float32(cVideoFrame.picture_aspect_ratio),
-		}
-
-		// Free the NDI frame
-		C.NDIlib_recv_free_video_v2(r.instance, &cVideoFrame)
-
-		// Update stats
-		r.mu.Lock()
-		r.stats.FramesReceived++
-		r.stats.LastFrameTime = time.Now()
-		r.stats.Width = frame.Width
-		r.stats.Height = frame.Height
-		r.stats.FrameRateN = frame.FrameRateN
-		r.stats.FrameRateD = frame.FrameRateD
-		r.mu.Unlock()
-
-		return frame, nil
+		return r.copyVideo(&cVideoFrame), nil
 
 	case FrameTypeNone:
 		return nil, nil // Timeout, no frame available
@@ -286,6 +340,49 @@ func (r *Receiver) CaptureVideo(timeout time.Duration) (*VideoFrame, error) {
 	}
 }
 
+// copyVideo copies a captured video frame into a pooled buffer and frees
+// it. Hand the buffer back with ReleaseVideo once it's written.
+func (r *Receiver) copyVideo(cVideoFrame *C.NDIlib_video_frame_v2_t) *VideoFrame {
+	dataSize := int(cVideoFrame.line_stride_in_bytes) * int(cVideoFrame.yres)
+	data := r.pool.get(dataSize)
+	C.copy_video_data((*C.uint8_t)(unsafe.Pointer(&data[0])), cVideoFrame)
+
+	frame := &VideoFrame{
+		Width:       int(cVideoFrame.xres),
+		Height:      int(cVideoFrame.yres),
+		FourCC:      uint32(cVideoFrame.FourCC),
+		FrameRateN:  int(cVideoFrame.frame_rate_N),
+		FrameRateD:  int(cVideoFrame.frame_rate_D),
+		Data:        data,
+		LineStride:  int(cVideoFrame.line_stride_in_bytes),
+		Timecode:    int64(cVideoFrame.timecode),
+		Timestamp:   int64(cVideoFrame.timestamp),
+		AspectRatio: float32(cVideoFrame.picture_aspect_ratio),
+	}
+
+	// Free the NDI frame
+	C.NDIlib_recv_free_video_v2(r.instance, cVideoFrame)
+
+	// Update stats
+	r.mu.Lock()
+	r.stats.FramesReceived++
+	r.stats.LastFrameTime = time.Now()
+	r.stats.Width = frame.Width
+	r.stats.Height = frame.Height
+	r.stats.FrameRateN = frame.FrameRateN
+	r.stats.FrameRateD = frame.FrameRateD
+	r.mu.Unlock()
+
+	return frame
+}
+
+// ReleaseVideo returns a frame's buffer to the pool for reuse. The frame's
+// Data must not be used afterwards.
+func (r *Receiver) ReleaseVideo(frame *VideoFrame) {
+	r.pool.put(frame.Data)
+	frame.Data = nil
+}
+
 // CaptureAudio captures a single audio frame with timeout
 func (r *Receiver) CaptureAudio(timeout time.Duration) (*AudioFrame, error) {
 	if r.instance == nil {
@@ -306,11 +403,17 @@ func (r *Receiver) CaptureAudio(timeout time.Duration) (*AudioFrame, error) {
 	if FrameType(frameType) != FrameTypeAudio {
 		return nil, nil
 	}
+	return r.copyAudio(&cAudioFrame), nil
+}
 
-	// Copy audio data to Go memory
+// copyAudio copies a captured audio frame to Go memory and frees it. Data
+// stays planar: all samples of channel 0, then channel 1, ...
+func (r *Receiver) copyAudio(cAudioFrame *C.NDIlib_audio_frame_v2_t) *AudioFrame {
 	numSamples := int(cAudioFrame.no_samples) * int(cAudioFrame.no_channels)
 	data := make([]float32, numSamples)
-	C.copy_audio_data((*C.float)(unsafe.Pointer(&data[0])), &cAudioFrame)
+	if numSamples > 0 {
+		C.copy_audio_data((*C.float)(unsafe.Pointer(&data[0])), cAudioFrame)
+	}
 
 	frame := &AudioFrame{
 		SampleRate:    int(cAudioFrame.sample_rate),
@@ -322,9 +425,9 @@ func (r *Receiver) CaptureAudio(timeout time.Duration) (*AudioFrame, error) {
 		Timestamp:     int64(cAudioFrame.timestamp),
 	}
 
-	C.NDIlib_recv_free_audio_v2(r.instance, &cAudioFrame)
+	C.NDIlib_recv_free_audio_v2(r.instance, cAudioFrame)
 
-	return frame, nil
+	return frame
 }
 
 // Run starts the receiver loop, sending frames to the provided callback
@@ -0,0 +1,191 @@
+//go:build ndi
+
+package ndi
+
+/*
+#include <stdlib.h>
+#include <stdbool.h>
+#include <stdint.h>
+#include <string.h>
+
+typedef void* NDIlib_send_instance_t;
+
+typedef struct NDIlib_send_create_t {
+    const char* p_ndi_name;
+    const char* p_groups;
+    bool clock_video;
+    bool clock_audio;
+} NDIlib_send_create_t;
+
+typedef struct NDIlib_video_frame_v2_t {
+    int xres;
+    int yres;
+    int FourCC;
+    int frame_rate_N;
+    int frame_rate_D;
+    float picture_aspect_ratio;
+    int frame_format_type;
+    int64_t timecode;
+    uint8_t* p_data;
+    int line_stride_in_bytes;
+    const char* p_metadata;
+    int64_t timestamp;
+} NDIlib_video_frame_v2_t;
+
+typedef struct NDIlib_audio_frame_v2_t {
+    int sample_rate;
+    int no_channels;
+    int no_samples;
+    int64_t timecode;
+    float* p_data;
+    int channel_stride_in_bytes;
+    const char* p_metadata;
+    int64_t timestamp;
+} NDIlib_audio_frame_v2_t;
+
+#define NDIlib_frame_format_type_progressive 1
+#define NDIlib_send_timecode_synthesize INT64_MAX
+
+extern NDIlib_send_instance_t NDIlib_send_create(const NDIlib_send_create_t* p_create_settings);
+extern void NDIlib_send_destroy(NDIlib_send_instance_t p_instance);
+extern void NDIlib_send_send_video_v2(NDIlib_send_instance_t p_instance, const NDIlib_video_frame_v2_t* p_video_data);
+extern void NDIlib_send_send_audio_v2(NDIlib_send_instance_t p_instance, const NDIlib_audio_frame_v2_t* p_audio_data);
+extern int NDIlib_send_get_no_connections(NDIlib_send_instance_t p_instance, uint32_t timeout_in_ms);
+
+// The frame structs are built here so Go memory never holds a pointer
+// to other Go memory passed to C. Sending is synchronous: the SDK is
+// done with data when these return.
+static inline void send_video(NDIlib_send_instance_t s, int xres, int yres, int fourcc, int rate_n, int rate_d, uint8_t* data, int stride) {
+    NDIlib_video_frame_v2_t frame;
+    memset(&frame, 0, sizeof(frame));
+    frame.xres = xres;
+    frame.yres = yres;
+    frame.FourCC = fourcc;
+    frame.frame_rate_N = rate_n;
+    frame.frame_rate_D = rate_d;
+    frame.picture_aspect_ratio = (float)xres / (float)yres;
+    frame.frame_format_type = NDIlib_frame_format_type_progressive;
+    frame.timecode = NDIlib_send_timecode_synthesize;
+    frame.p_data = data;
+    frame.line_stride_in_bytes = stride;
+    NDIlib_send_send_video_v2(s, &frame);
+}
+
+static inline void send_audio(NDIlib_send_instance_t s, int sample_rate, int channels, int samples, float* data) {
+    NDIlib_audio_frame_v2_t frame;
+    memset(&frame, 0, sizeof(frame));
+    frame.sample_rate = sample_rate;
+    frame.no_channels = channels;
+    frame.no_samples = samples;
+    frame.timecode = NDIlib_send_timecode_synthesize;
+    frame.p_data = data;
+    frame.channel_stride_in_bytes = samples * sizeof(float);
+    NDIlib_send_send_audio_v2(s, &frame);
+}
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// SenderConfig configures an NDI sender
+type SenderConfig struct {
+	Name   string // Source name shown to receivers
+	Groups string // NDI groups to publish in (comma-separated, empty = default)
+}
+
+// Sender publishes video and audio as an NDI source
+type Sender struct {
+	mu       sync.Mutex
+	instance C.NDIlib_send_instance_t
+	name     string
+}
+
+// NewSender creates an NDI source. Video sends are clocked by the SDK, so
+// SendVideo paces the caller at the frame rate.
+func NewSender(config SenderConfig) (*Sender, error) {
+	if err := Initialize(); err != nil {
+		return nil, err
+	}
+	if config.Name == "" {
+		return nil, errors.New("sender name is required")
+	}
+
+	cName := C.CString(config.Name)
+	defer C.free(unsafe.Pointer(cName))
+	settings := C.NDIlib_send_create_t{
+		p_ndi_name:  cName,
+		clock_video: C.bool(true),
+		clock_audio: C.bool(false),
+	}
+	if config.Groups != "" {
+		settings.p_groups = C.CString(config.Groups)
+		defer C.free(unsafe.Pointer(settings.p_groups))
+	}
+
+	instance := C.NDIlib_send_create(&settings)
+	if instance == nil {
+		return nil, fmt.Errorf("failed to create NDI sender %q", config.Name)
+	}
+	return &Sender{instance: instance, name: config.Name}, nil
+}
+
+// Name returns the source name
+func (s *Sender) Name() string {
+	return s.name
+}
+
+// SendVideo sends a frame. Data must be LineStride * Height bytes in the
+// frame's FourCC.
+func (s *Sender) SendVideo(frame *VideoFrame) error {
+	if len(frame.Data) < frame.LineStride*frame.Height || len(frame.Data) == 0 {
+		return fmt.Errorf("video frame too short: %d bytes", len(frame.Data))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.instance == nil {
+		return errors.New("sender destroyed")
+	}
+	C.send_video(s.instance, C.int(frame.Width), C.int(frame.Height), C.int(frame.FourCC),
+		C.int(frame.FrameRateN), C.int(frame.FrameRateD),
+		(*C.uint8_t)(unsafe.Pointer(&frame.Data[0])), C.int(frame.LineStride))
+	return nil
+}
+
+// SendAudio sends planar float32 audio
+func (s *Sender) SendAudio(frame *AudioFrame) error {
+	if len(frame.Data) < frame.NumSamples*frame.NumChannels || len(frame.Data) == 0 {
+		return fmt.Errorf("audio frame too short: %d samples", len(frame.Data))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.instance == nil {
+		return errors.New("sender destroyed")
+	}
+	C.send_audio(s.instance, C.int(frame.SampleRate), C.int(frame.NumChannels), C.int(frame.NumSamples),
+		(*C.float)(unsafe.Pointer(&frame.Data[0])))
+	return nil
+}
+
+// Connections returns how many receivers are connected
+func (s *Sender) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.instance == nil {
+		return 0
+	}
+	return int(C.NDIlib_send_get_no_connections(s.instance, 0))
+}
+
+// Destroy removes the source from the network
+func (s *Sender) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.instance != nil {
+		C.NDIlib_send_destroy(s.instance)
+		s.instance = nil
+	}
+}
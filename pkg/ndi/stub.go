@@ -105,7 +105,7 @@ func IsAvailable() bool {
 }
 
 // DiscoverSources returns an error when NDI is not available
-func DiscoverSources(ctx context.Context) ([]Source, error) {
+func DiscoverSources(ctx context.Context, config *FinderConfig) ([]Source, error) {
 	return nil, errNotAvailable
 }
 
@@ -164,6 +164,15 @@ type ReceiverStats struct {
 	Height         int
 	FrameRateN     int
 	FrameRateD     int
+
+	// Frame buffer pool: allocations level off once the pool is warm
+	BuffersAllocated uint64
+	BuffersReused    uint64
+
+	Reconnects uint64
+
+	FramesDuplicated uint64
+	FramesSkipped    uint64
 }
 
 // Receiver stub
@@ -182,16 +191,29 @@ func (r *Receiver) Source() Source {
 	return Source{}
 }
 
+// Reconnect returns an error
+func (r *Receiver) Reconnect(timeout time.Duration) error {
+	return errNotAvailable
+}
+
 // Stats returns empty stats
 func (r *Receiver) Stats() ReceiverStats {
 	return ReceiverStats{}
 }
 
+// Capture returns an error
+func (r *Receiver) Capture(timeout time.Duration) (*VideoFrame, *AudioFrame, error) {
+	return nil, nil, errNotAvailable
+}
+
 // CaptureVideo returns an error
 func (r *Receiver) CaptureVideo(timeout time.Duration) (*VideoFrame, error) {
 	return nil, errNotAvailable
 }
 
+// ReleaseVideo is a no-op
+func (r *Receiver) ReleaseVideo(frame *VideoFrame) {}
+
 // CaptureAudio returns an error
 func (r *Receiver) CaptureAudio(timeout time.Duration) (*AudioFrame, error) {
 	return nil, errNotAvailable
@@ -212,9 +234,14 @@ type CaptureConfig struct {
 	SourceName      string
 	OutputDir       string
 	SegmentDuration float64
+	EncoderType     string
 	Codec           string
 	Preset          string
+	EncoderOptions  []string
+	Device          string
 	Bitrate         int
+	AudioCodec      string
+	AudioBitrate    int
 }
 
 // SegmentInfo contains information about a completed segment
@@ -264,3 +291,77 @@ func (c *Capture) LastError() error {
 func (c *Capture) FrameCount() uint64 {
 	return 0
 }
+
+// SenderConfig configures an NDI sender
+type SenderConfig struct {
+	Name   string
+	Groups string
+}
+
+// Sender stub
+type Sender struct{}
+
+// NewSender returns an error when NDI is not available
+func NewSender(config SenderConfig) (*Sender, error) {
+	return nil, errNotAvailable
+}
+
+// Name returns an empty string
+func (s *Sender) Name() string {
+	return ""
+}
+
+// SendVideo returns an error
+func (s *Sender) SendVideo(frame *VideoFrame) error {
+	return errNotAvailable
+}
+
+// SendAudio returns an error
+func (s *Sender) SendAudio(frame *AudioFrame) error {
+	return errNotAvailable
+}
+
+// Connections returns 0
+func (s *Sender) Connections() int {
+	return 0
+}
+
+// Destroy is a no-op
+func (s *Sender) Destroy() {}
+
+// PreviewConfig configures a low-bandwidth JPEG preview of a source
+type PreviewConfig struct {
+	SourceName string
+	Width      int
+	Framerate  int
+	Quality    int
+}
+
+// Preview stub
+type Preview struct{}
+
+// NewPreview returns an error when NDI is not available
+func NewPreview(config PreviewConfig) (*Preview, error) {
+	return nil, errNotAvailable
+}
+
+// OnFrame is a no-op
+func (p *Preview) OnFrame(fn func(jpeg []byte)) {}
+
+// Start returns an error
+func (p *Preview) Start(ctx context.Context) error {
+	return errNotAvailable
+}
+
+// Stop is a no-op
+func (p *Preview) Stop() {}
+
+// Frame returns no frame
+func (p *Preview) Frame() ([]byte, time.Time) {
+	return nil, time.Time{}
+}
+
+// LastError returns the stub error
+func (p *Preview) LastError() error {
+	return errNotAvailable
+}
@@ -0,0 +1,193 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+)
+
+func init() {
+	Register("archive", func() Output { return NewArchive() })
+}
+
+// archivePruneInterval is how often the archive is checked against its
+// retention policy
+const archivePruneInterval = time.Minute
+
+// archiveDayFormat names the per-day directories (UTC)
+const archiveDayFormat = "2006-01-02"
+
+// Archive copies every segment and init segment into a long-term archive,
+// one directory per UTC day, and prunes it by its own retention policy
+// rather than the ring buffer's. File names start with the segment's UTC
+// start time, so a directory listing is in capture order and each segment
+// follows the init segment it plays with. The config path is the channel's
+// archive directory.
+type Archive struct {
+	dir       string
+	retention time.Duration
+	maxBytes  int64
+
+	mu        sync.Mutex
+	init      []byte
+	initDay   string // Day directory the current init segment was written to
+	lastPrune time.Time
+	pruning   bool
+	wg        sync.WaitGroup
+}
+
+// NewArchive creates an archive output
+func NewArchive() *Archive {
+	return &Archive{}
+}
+
+func (a *Archive) Name() string { return "archive" }
+func (a *Archive) Type() string { return "archive" }
+
+// Open creates the archive directory
+func (a *Archive) Open(cfg Config) error {
+	if cfg.Path == "" {
+		return errors.New("archive output: path is required")
+	}
+	if err := os.MkdirAll(cfg.Path, 0755); err != nil {
+		return fmt.Errorf("create archive directory: %w", err)
+	}
+	a.dir = cfg.Path
+	a.retention = cfg.Retention
+	a.maxBytes = cfg.MaxBytes
+	return nil
+}
+
+// Close waits for a running prune
+func (a *Archive) Close() error {
+	a.wg.Wait()
+	return nil
+}
+
+// WriteInit records the init segment; it is archived with the next media
+// segment. It is called again whenever the encoder restarts.
+func (a *Archive) WriteInit(ctx context.Context, init *InitSegment) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !slices.Equal(init.Data, a.init) {
+		a.init = init.Data
+		a.initDay = ""
+	}
+	return nil
+}
+
+// WriteSegment archives a media segment, preceded by its init segment when
+// that changed or a new day began
+func (a *Archive) WriteSegment(ctx context.Context, seg *Segment) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.init == nil {
+		return errors.New("archive output: no init segment")
+	}
+
+	start := time.Now().UTC()
+	if seg.StartTime > 0 {
+		start = time.UnixMilli(seg.StartTime).UTC()
+	}
+	day := start.Format(archiveDayFormat)
+	dir := filepath.Join(a.dir, day)
+	stamp := start.Format("150405.000")
+	if a.initDay != day {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create archive directory: %w", err)
+		}
+		if err := writeFileAtomic(dir, stamp+"_init.mp4", a.init); err != nil {
+			return err
+		}
+		a.initDay = day
+	}
+	if err := writeFileAtomic(dir, stamp+"_"+segmentName(seg.Sequence), seg.Data); err != nil {
+		return err
+	}
+
+	if (a.retention > 0 || a.maxBytes > 0) && !a.pruning && time.Since(a.lastPrune) >= archivePruneInterval {
+		a.pruning = true
+		a.lastPrune = time.Now()
+		a.wg.Add(1)
+		recovery.Go("archive prune", func() {
+			defer a.wg.Done()
+			a.prune()
+			a.mu.Lock()
+			a.pruning = false
+			a.mu.Unlock()
+		})
+	}
+	return nil
+}
+
+// archiveFile is a file in the archive, for pruning
+type archiveFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// prune deletes archived files older than the retention period, then the
+// oldest files until the archive fits its size cap. Empty day directories
+// are removed.
+func (a *Archive) prune() {
+	var files []archiveFile
+	var total int64
+	filepath.WalkDir(a.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, archiveFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	// Day directories and time-stamped names sort oldest first
+	slices.SortFunc(files, func(x, y archiveFile) int {
+		switch {
+		case x.path < y.path:
+			return -1
+		case x.path > y.path:
+			return 1
+		}
+		return 0
+	})
+
+	cutoff := time.Now().Add(-a.retention)
+	removed := 0
+	for _, f := range files {
+		expired := a.retention > 0 && f.modTime.Before(cutoff)
+		full := a.maxBytes > 0 && total > a.maxBytes
+		if !expired && !full {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			logger.Warn("Archive: prune failed", "path", f.path, "error", err)
+			continue
+		}
+		total -= f.size
+		removed++
+	}
+	if removed == 0 {
+		return
+	}
+
+	days, _ := os.ReadDir(a.dir)
+	for _, d := range days {
+		if d.IsDir() {
+			os.Remove(filepath.Join(a.dir, d.Name())) // Only succeeds once empty
+		}
+	}
+	logger.Info("Archive pruned", "path", a.dir, "files", removed)
+}
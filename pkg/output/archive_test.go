@@ -0,0 +1,148 @@
+package output
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// archiveFiles lists the archive's files as day/name, in path order
+func archiveFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var names []string
+	days, _ := os.ReadDir(dir)
+	for _, d := range days {
+		entries, err := os.ReadDir(filepath.Join(dir, d.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			names = append(names, d.Name()+"/"+e.Name())
+		}
+	}
+	return names
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	a := NewArchive()
+	if err := a.Open(Config{}); err == nil {
+		t.Error("expected an error without a path")
+	}
+	if err := a.Open(Config{Path: dir}); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer a.Close()
+	ctx := context.Background()
+
+	day1 := time.Date(2026, 3, 1, 23, 59, 58, 0, time.UTC)
+	day2 := day1.Add(2 * time.Second)
+	segment := func(seq int, start time.Time) *Segment {
+		return &Segment{Sequence: seq, Data: []byte("moof"), StartTime: start.UnixMilli()}
+	}
+
+	if err := a.WriteSegment(ctx, segment(1, day1)); err == nil {
+		t.Error("expected an error for a segment before the init segment")
+	}
+
+	tests := []struct {
+		name string
+		init string // Init segment written before the segment, if any
+		seg  *Segment
+		want []string // Files added
+	}{
+		{"first segment brings init", "h264", segment(1, day1),
+			[]string{"2026-03-01/235958.000_init.mp4", "2026-03-01/235958.000_segment_00001.m4s"}},
+		{"same init again", "h264", segment(2, day1.Add(time.Second)),
+			[]string{"2026-03-01/235959.000_segment_00002.m4s"}},
+		{"new day repeats init", "", segment(3, day2),
+			[]string{"2026-03-02/000000.000_init.mp4", "2026-03-02/000000.000_segment_00003.m4s"}},
+		{"changed init", "h265", segment(4, day2.Add(time.Second)),
+			[]string{"2026-03-02/000001.000_init.mp4", "2026-03-02/000001.000_segment_00004.m4s"}},
+	}
+	var before []string
+	for _, tt := range tests {
+		if tt.init != "" {
+			if err := a.WriteInit(ctx, &InitSegment{Data: []byte(tt.init)}); err != nil {
+				t.Fatalf("%s: WriteInit: %v", tt.name, err)
+			}
+		}
+		if err := a.WriteSegment(ctx, tt.seg); err != nil {
+			t.Fatalf("%s: WriteSegment: %v", tt.name, err)
+		}
+		after := archiveFiles(t, dir)
+		var added []string
+		for _, f := range after {
+			if !slices.Contains(before, f) {
+				added = append(added, f)
+			}
+		}
+		if !slices.Equal(added, tt.want) {
+			t.Errorf("%s: added %v, want %v", tt.name, added, tt.want)
+		}
+		before = after
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "2026-03-02", "000001.000_init.mp4"))
+	if err != nil || string(data) != "h265" {
+		t.Errorf("changed init segment = %q, %v", data, err)
+	}
+}
+
+func TestArchivePrune(t *testing.T) {
+	now := time.Now()
+	files := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"2026-03-01/000000.000_init.mp4", 72 * time.Hour},
+		{"2026-03-01/000000.000_segment_00001.m4s", 72 * time.Hour},
+		{"2026-03-02/000000.000_init.mp4", 48 * time.Hour},
+		{"2026-03-02/000000.000_segment_00002.m4s", 48 * time.Hour},
+		{"2026-03-03/000000.000_init.mp4", time.Hour},
+		{"2026-03-03/000000.000_segment_00003.m4s", time.Hour},
+	}
+
+	tests := []struct {
+		name      string
+		retention time.Duration
+		maxBytes  int64
+		want      []string
+	}{
+		{"no policy keeps everything", 0, 0, []string{"2026-03-01", "2026-03-02", "2026-03-03"}},
+		{"retention", 60 * time.Hour, 0, []string{"2026-03-02", "2026-03-03"}},
+		{"size cap", 0, 200, []string{"2026-03-02", "2026-03-03"}},
+		{"size cap of one day", 0, 100, []string{"2026-03-03"}},
+		{"both", 24 * time.Hour, 1000, []string{"2026-03-03"}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for _, f := range files {
+			path := filepath.Join(dir, filepath.FromSlash(f.name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, make([]byte, 50), 0644); err != nil {
+				t.Fatal(err)
+			}
+			mod := now.Add(-f.age)
+			if err := os.Chtimes(path, mod, mod); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		a := &Archive{dir: dir, retention: tt.retention, maxBytes: tt.maxBytes}
+		a.prune()
+		var days []string
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			days = append(days, e.Name())
+		}
+		if !slices.Equal(days, tt.want) {
+			t.Errorf("%s: days = %v, want %v", tt.name, days, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	Register("cmaf", func() Output { return NewCMAFPush() })
+}
+
+// CMAFPush pushes CMAF init and media segments to an HTTP origin.
+// Segments can be streamed while they are still being encoded: the body
+// is sent with chunked transfer encoding so each CMAF chunk reaches the
+// origin as soon as FFmpeg writes it.
+type CMAFPush struct {
+	cfg     Config
+	baseURL string
+	client  *http.Client
+}
+
+// NewCMAFPush creates a CMAF push output
+func NewCMAFPush() *CMAFPush {
+	return &CMAFPush{
+		// No overall timeout: a streamed segment stays open for its full duration
+		client: &http.Client{},
+	}
+}
+
+func (c *CMAFPush) Name() string { return "cmaf-push" }
+func (c *CMAFPush) Type() string { return "cmaf" }
+
+// Open validates the origin URL
+func (c *CMAFPush) Open(cfg Config) error {
+	u, err := url.Parse(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("parse origin url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("origin url must be http or https: %s", cfg.Path)
+	}
+	c.cfg = cfg
+	c.baseURL = strings.TrimRight(cfg.Path, "/")
+	return nil
+}
+
+// Close releases idle connections to the origin
+func (c *CMAFPush) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// WriteInit uploads the initialization segment
+func (c *CMAFPush) WriteInit(ctx context.Context, init *InitSegment) error {
+	return c.put(ctx, "init.mp4", bytes.NewReader(init.Data), int64(len(init.Data)))
+}
+
+// WriteSegment uploads a complete media segment
+func (c *CMAFPush) WriteSegment(ctx context.Context, seg *Segment) error {
+	return c.put(ctx, segmentName(seg.Sequence), bytes.NewReader(seg.Data), int64(len(seg.Data)))
+}
+
+// StreamSegment uploads a media segment as it is produced. The request body
+// is read until EOF, so r should block until more chunks are available.
+func (c *CMAFPush) StreamSegment(ctx context.Context, seq int, r io.Reader) error {
+	return c.put(ctx, segmentName(seq), r, -1)
+}
+
+// put sends a PUT request; a negative size uses chunked transfer encoding
+func (c *CMAFPush) put(ctx context.Context, name string, body io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+"/"+name, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "video/mp4")
+	for k, v := range c.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("push %s: origin returned %s", name, resp.Status)
+	}
+	return nil
+}
+
+// segmentName matches the segment naming used by the segment writer
+func segmentName(seq int) string {
+	return fmt.Sprintf("segment_%05d.m4s", seq)
+}
@@ -0,0 +1,73 @@
+package output
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCMAFPush(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[string]string)
+	var chunked bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got[r.URL.Path] = string(body)
+		if strings.HasSuffix(r.URL.Path, "segment_00002.m4s") {
+			chunked = r.ContentLength == -1
+		}
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "segment_00003.m4s") {
+			http.Error(w, "full", http.StatusInsufficientStorage)
+		}
+	}))
+	defer srv.Close()
+
+	push := NewCMAFPush()
+	if err := push.Open(Config{Path: srv.URL + "/live/cam1/", Headers: map[string]string{"Authorization": "Bearer key"}}); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer push.Close()
+	ctx := context.Background()
+
+	if err := push.WriteInit(ctx, &InitSegment{Data: []byte("init")}); err != nil {
+		t.Fatalf("WriteInit: %v", err)
+	}
+	if err := push.WriteSegment(ctx, &Segment{Sequence: 1, Data: []byte("one")}); err != nil {
+		t.Fatalf("WriteSegment: %v", err)
+	}
+	if err := push.StreamSegment(ctx, 2, strings.NewReader("two")); err != nil {
+		t.Fatalf("StreamSegment: %v", err)
+	}
+	if err := push.WriteSegment(ctx, &Segment{Sequence: 3, Data: []byte("three")}); err == nil {
+		t.Error("expected an error when the origin rejects a segment")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for path, want := range map[string]string{
+		"/live/cam1/init.mp4":          "init",
+		"/live/cam1/segment_00001.m4s": "one",
+		"/live/cam1/segment_00002.m4s": "two",
+	} {
+		if got[path] != want {
+			t.Errorf("%s = %q, want %q", path, got[path], want)
+		}
+	}
+	if !chunked {
+		t.Error("streamed segment not sent with chunked encoding")
+	}
+
+	if err := NewCMAFPush().Open(Config{Path: "rtmp://origin/live"}); err == nil {
+		t.Error("expected an error for a non-http origin")
+	}
+}
@@ -0,0 +1,166 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/manifest"
+)
+
+func init() {
+	Register("hls", func() Output { return NewHLSWriter() })
+}
+
+// hlsListSize is how many segments a live playlist lists by default
+const hlsListSize = 10
+
+// HLSWriter writes a live HLS playlist and its fMP4 segments to a
+// directory, e.g. one served by a web server or synced to a CDN. Segments
+// are deleted as they leave the playlist. The config path is the
+// directory.
+type HLSWriter struct {
+	dir      string
+	listSize int
+	target   time.Duration
+
+	mu       sync.Mutex
+	init     []byte
+	initName string
+	inits    int
+	segments []manifest.Segment
+}
+
+// NewHLSWriter creates an HLS file output
+func NewHLSWriter() *HLSWriter {
+	return &HLSWriter{}
+}
+
+func (h *HLSWriter) Name() string { return "hls-writer" }
+func (h *HLSWriter) Type() string { return "hls" }
+
+// Open creates the output directory
+func (h *HLSWriter) Open(cfg Config) error {
+	if cfg.Path == "" {
+		return errors.New("hls output: path is required")
+	}
+	if err := os.MkdirAll(cfg.Path, 0755); err != nil {
+		return fmt.Errorf("create hls directory: %w", err)
+	}
+	h.dir = cfg.Path
+	h.listSize = cfg.ListSize
+	if h.listSize <= 0 {
+		h.listSize = hlsListSize
+	}
+	h.target = time.Duration(cfg.SegmentDur * float64(time.Second))
+	return nil
+}
+
+// Close ends the playlist so players stop polling it
+func (h *HLSWriter) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.initName == "" {
+		return nil
+	}
+	return h.writePlaylist(true)
+}
+
+// WriteInit writes the initialization segment. It is called again whenever
+// the encoder restarts; if the init segment changed (e.g. a new codec), the
+// segments listed so far are dropped since they no longer match it.
+func (h *HLSWriter) WriteInit(ctx context.Context, init *InitSegment) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if bytes.Equal(init.Data, h.init) {
+		return nil
+	}
+
+	h.inits++
+	name := fmt.Sprintf("init_%d.mp4", h.inits)
+	if err := h.writeFile(name, init.Data); err != nil {
+		return err
+	}
+	h.removeSegments(h.segments)
+	h.segments = nil
+	if h.initName != "" {
+		os.Remove(filepath.Join(h.dir, h.initName))
+	}
+	h.init = init.Data
+	h.initName = name
+	return nil
+}
+
+// WriteSegment writes a media segment and the updated playlist
+func (h *HLSWriter) WriteSegment(ctx context.Context, seg *Segment) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.initName == "" {
+		return errors.New("hls output: no init segment")
+	}
+
+	name := segmentName(seg.Sequence)
+	if err := h.writeFile(name, seg.Data); err != nil {
+		return err
+	}
+	var start time.Time
+	if seg.StartTime > 0 {
+		start = time.UnixMilli(seg.StartTime)
+	}
+	dur := time.Duration(seg.Duration * float64(time.Second))
+	h.target = max(h.target, dur) // Target duration must never shrink
+	h.segments = append(h.segments, manifest.Segment{
+		Sequence: seg.Sequence,
+		URI:      name,
+		Start:    start,
+		Duration: dur,
+	})
+	if n := len(h.segments) - h.listSize; n > 0 {
+		// Removed after the playlist stops listing them
+		defer h.removeSegments(h.segments[:n:n])
+		h.segments = h.segments[n:]
+	}
+	return h.writePlaylist(false)
+}
+
+func (h *HLSWriter) writePlaylist(ended bool) error {
+	playlist := manifest.MediaPlaylist{
+		Segments:        h.segments,
+		MapURI:          h.initName,
+		ProgramDateTime: true,
+		Ended:           ended,
+		TargetDuration:  h.target,
+	}
+	return h.writeFile("index.m3u8", playlist.Encode())
+}
+
+func (h *HLSWriter) removeSegments(segments []manifest.Segment) {
+	for _, s := range segments {
+		os.Remove(filepath.Join(h.dir, s.URI))
+	}
+}
+
+// writeFile replaces a file atomically, so a web server never serves a
+// partial playlist or segment
+func (h *HLSWriter) writeFile(name string, data []byte) error {
+	return writeFileAtomic(h.dir, name, data)
+}
+
+// writeFileAtomic writes a file in dir through a temporary file and rename
+func writeFileAtomic(dir, name string, data []byte) error {
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package output
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHLSWriter(t *testing.T) {
+	dir := t.TempDir()
+	h := NewHLSWriter()
+	if err := h.Open(Config{Path: dir, ListSize: 2, SegmentDur: 2}); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ctx := context.Background()
+	playlist := func() string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, "index.m3u8"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	if err := h.WriteSegment(ctx, &Segment{Sequence: 1, Data: []byte("one"), Duration: 2}); err == nil {
+		t.Error("expected an error for a segment before the init segment")
+	}
+	if err := h.WriteInit(ctx, &InitSegment{Data: []byte("h264")}); err != nil {
+		t.Fatalf("WriteInit: %v", err)
+	}
+	for seq := 1; seq <= 3; seq++ {
+		if err := h.WriteSegment(ctx, &Segment{Sequence: seq, Data: []byte("moof"), Duration: 2}); err != nil {
+			t.Fatalf("WriteSegment %d: %v", seq, err)
+		}
+	}
+
+	// The window keeps the newest two, and the dropped segment's file goes
+	p := playlist()
+	if !strings.Contains(p, "#EXT-X-MEDIA-SEQUENCE:2\n") || strings.Contains(p, "segment_00001.m4s") ||
+		!strings.Contains(p, "segment_00003.m4s") || !strings.Contains(p, `#EXT-X-MAP:URI="init_1.mp4"`) {
+		t.Errorf("playlist after 3 segments:\n%s", p)
+	}
+	if exists("segment_00001.m4s") || !exists("segment_00002.m4s") {
+		t.Error("segment files don't match the window")
+	}
+
+	// The same init segment again, e.g. after an encoder restart, keeps them
+	if err := h.WriteInit(ctx, &InitSegment{Data: []byte("h264")}); err != nil {
+		t.Fatalf("WriteInit: %v", err)
+	}
+	if err := h.WriteSegment(ctx, &Segment{Sequence: 4, Data: []byte("moof"), Duration: 2}); err != nil {
+		t.Fatalf("WriteSegment: %v", err)
+	}
+	if p := playlist(); !strings.Contains(p, "segment_00003.m4s") || !strings.Contains(p, "init_1.mp4") {
+		t.Errorf("playlist after an unchanged init segment:\n%s", p)
+	}
+
+	// A new init segment drops the segments that don't match it
+	if err := h.WriteInit(ctx, &InitSegment{Data: []byte("hevc")}); err != nil {
+		t.Fatalf("WriteInit: %v", err)
+	}
+	if exists("init_1.mp4") || exists("segment_00003.m4s") || exists("segment_00004.m4s") || !exists("init_2.mp4") {
+		t.Error("files from before the init change not replaced")
+	}
+	if err := h.WriteSegment(ctx, &Segment{Sequence: 5, Data: []byte("moof"), Duration: 2}); err != nil {
+		t.Fatalf("WriteSegment: %v", err)
+	}
+	p = playlist()
+	if !strings.Contains(p, "#EXT-X-MEDIA-SEQUENCE:5\n") || strings.Contains(p, "segment_00004.m4s") ||
+		!strings.Contains(p, `#EXT-X-MAP:URI="init_2.mp4"`) || strings.Contains(p, "#EXT-X-ENDLIST") {
+		t.Errorf("playlist after an init change:\n%s", p)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if p := playlist(); !strings.HasSuffix(p, "#EXT-X-ENDLIST\n") || !strings.Contains(p, "segment_00005.m4s") {
+		t.Errorf("playlist after Close:\n%s", p)
+	}
+}
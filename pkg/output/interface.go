@@ -1,6 +1,10 @@
 package output
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // Output is the interface for video output destinations
 type Output interface {
@@ -17,11 +21,25 @@ type Output interface {
 	WriteInit(ctx context.Context, init *InitSegment) error
 }
 
+// Streamer is implemented by outputs that send a segment while it is still
+// being written, e.g. low-latency CMAF push. They get StreamSegment instead
+// of WriteSegment.
+type Streamer interface {
+	StreamSegment(ctx context.Context, seq int, r io.Reader) error
+}
+
 // Config holds output configuration
 type Config struct {
 	Path       string // Output path or URL
 	Format     string // hls, dash, srt, rtmp, file
 	SegmentDur float64
+	Headers    map[string]string // Extra request headers for HTTP outputs
+	ListSize   int               // Segments in a live playlist (hls)
+	Retention  time.Duration     // How long files are kept (archive, 0 = forever)
+	MaxBytes   int64             // Size cap, oldest files deleted first (archive, 0 = unlimited)
+	Region     string            // Signing region (s3, default: us-east-1)
+	AccessKey  string            // Credentials (s3)
+	SecretKey  string
 }
 
 // Segment represents an encoded video segment
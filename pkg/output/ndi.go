@@ -0,0 +1,247 @@
+package output
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/ndi"
+)
+
+// ndiQueueSize is how many segments may wait for the decoder before new
+// ones are dropped
+const ndiQueueSize = 4
+
+// audioChunk is how many samples per channel each NDI audio frame carries
+const audioChunk = 1024
+
+var logger = logging.For("output")
+
+// NDIOptions configures the frames an NDISend output publishes
+type NDIOptions struct {
+	Groups    string // NDI groups to publish in (empty = default)
+	Width     int    // Output size (default: 1280x720)
+	Height    int
+	Framerate int  // Output frame rate (default: 30)
+	Audio     bool // Decode and send the first audio track
+}
+
+// NDISend decodes the channel's fMP4 segments and re-sends them as an NDI
+// source, so production tools can monitor what is being captured. The
+// config path is the NDI source name.
+type NDISend struct {
+	ffmpeg *ffmpeg.FFmpeg
+	opts   NDIOptions
+	sender *ndi.Sender
+
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	decoder *ffmpeg.Decoder
+	stop    context.CancelFunc // Kills the current decoder
+	queue   chan []byte
+	wg      sync.WaitGroup
+}
+
+// NewNDISend creates an NDI output
+func NewNDISend(ff *ffmpeg.FFmpeg, opts NDIOptions) *NDISend {
+	if opts.Width == 0 || opts.Height == 0 {
+		opts.Width, opts.Height = 1280, 720
+	}
+	if opts.Framerate == 0 {
+		opts.Framerate = 30
+	}
+	return &NDISend{ffmpeg: ff, opts: opts}
+}
+
+func (n *NDISend) Name() string { return "ndi-send" }
+func (n *NDISend) Type() string { return "ndi" }
+
+// Open creates the NDI source
+func (n *NDISend) Open(cfg Config) error {
+	sender, err := ndi.NewSender(ndi.SenderConfig{Name: cfg.Path, Groups: n.opts.Groups})
+	if err != nil {
+		return fmt.Errorf("create ndi sender: %w", err)
+	}
+	n.sender = sender
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	return nil
+}
+
+// Close stops decoding and removes the NDI source
+func (n *NDISend) Close() error {
+	n.mu.Lock()
+	n.stopDecoder()
+	n.mu.Unlock()
+	if n.cancel != nil {
+		n.cancel()
+	}
+	if n.sender != nil {
+		n.sender.Destroy()
+	}
+	return nil
+}
+
+// Connections returns how many NDI receivers are watching
+func (n *NDISend) Connections() int {
+	if n.sender == nil {
+		return 0
+	}
+	return n.sender.Connections()
+}
+
+// WriteInit starts a new decoder for the stream the init segment begins.
+// It is called again whenever the encoder restarts.
+func (n *NDISend) WriteInit(ctx context.Context, init *InitSegment) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.sender == nil {
+		return errors.New("ndi output not open")
+	}
+	n.stopDecoder()
+
+	cfg := ffmpeg.DecoderConfig{
+		Width:     n.opts.Width,
+		Height:    n.opts.Height,
+		Framerate: n.opts.Framerate,
+		Audio:     n.opts.Audio,
+	}
+	decCtx, stop := context.WithCancel(n.ctx)
+	dec, err := n.ffmpeg.StartDecoder(decCtx, cfg)
+	if err != nil {
+		stop()
+		return err
+	}
+	queue := make(chan []byte, ndiQueueSize)
+	queue <- init.Data
+	n.decoder = dec
+	n.stop = stop
+	n.queue = queue
+
+	n.goWorker("ndi output feed", func() { n.feed(dec, queue) })
+	n.goWorker("ndi output video", func() { n.sendVideo(dec, cfg) })
+	if dec.Audio != nil {
+		n.goWorker("ndi output audio", func() { n.sendAudio(dec) })
+	}
+	return nil
+}
+
+// WriteSegment queues a media segment for decoding. The NDI clock paces
+// the decoder, so a segment is dropped rather than let latency build up
+// when the decoder falls behind.
+func (n *NDISend) WriteSegment(ctx context.Context, seg *Segment) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.queue == nil {
+		return errors.New("ndi output: no init segment")
+	}
+	select {
+	case n.queue <- seg.Data:
+		return nil
+	default:
+		return fmt.Errorf("ndi output: decoder behind, dropped segment %d", seg.Sequence)
+	}
+}
+
+// stopDecoder kills the current decoder, dropping frames still queued,
+// and waits for its workers; the caller holds n.mu
+func (n *NDISend) stopDecoder() {
+	if n.decoder == nil {
+		return
+	}
+	n.stop()
+	close(n.queue)
+	n.wg.Wait()
+	n.decoder.Close() // Killed, so the exit error says nothing
+	n.decoder = nil
+	n.stop = nil
+	n.queue = nil
+}
+
+func (n *NDISend) goWorker(name string, fn func()) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		defer recovery.Handle(name, nil)
+		fn()
+	}()
+}
+
+// feed writes queued segments to the decoder until the queue is closed
+func (n *NDISend) feed(dec *ffmpeg.Decoder, queue <-chan []byte) {
+	failed := false
+	for data := range queue {
+		if failed {
+			continue // Drain so WriteSegment never blocks
+		}
+		if _, err := dec.Write(data); err != nil {
+			logger.Warn("NDI output: decoder write failed", "error", err)
+			failed = true
+		}
+	}
+}
+
+// sendVideo reads decoded frames and sends them until the decoder exits
+func (n *NDISend) sendVideo(dec *ffmpeg.Decoder, cfg ffmpeg.DecoderConfig) {
+	frame := &ndi.VideoFrame{
+		Width:      cfg.Width,
+		Height:     cfg.Height,
+		FourCC:     ndi.FourCCUYVY,
+		FrameRateN: cfg.Framerate,
+		FrameRateD: 1,
+		LineStride: cfg.Width * 2,
+		Data:       make([]byte, cfg.FrameSize()),
+	}
+	for {
+		if _, err := io.ReadFull(dec.Video, frame.Data); err != nil {
+			return
+		}
+		if err := n.sender.SendVideo(frame); err != nil {
+			logger.Warn("NDI output: send video failed", "error", err)
+			return
+		}
+	}
+}
+
+// sendAudio reads interleaved float32 samples and sends them as planar
+// NDI audio frames
+func (n *NDISend) sendAudio(dec *ffmpeg.Decoder) {
+	const channels = ffmpeg.DecoderChannels
+	buf := make([]byte, audioChunk*channels*4)
+	frame := &ndi.AudioFrame{
+		SampleRate:    ffmpeg.DecoderSampleRate,
+		NumChannels:   channels,
+		NumSamples:    audioChunk,
+		ChannelStride: audioChunk * 4,
+		Data:          make([]float32, audioChunk*channels),
+	}
+	for {
+		if _, err := io.ReadFull(dec.Audio, buf); err != nil {
+			return
+		}
+		deinterleave(frame.Data, buf, channels)
+		if err := n.sender.SendAudio(frame); err != nil {
+			logger.Warn("NDI output: send audio failed", "error", err)
+			return
+		}
+	}
+}
+
+// deinterleave converts little-endian interleaved float32 samples into
+// planar ones
+func deinterleave(dst []float32, src []byte, channels int) {
+	samples := len(dst) / channels
+	for i := 0; i < samples; i++ {
+		for c := 0; c < channels; c++ {
+			off := (i*channels + c) * 4
+			dst[c*samples+i] = math.Float32frombits(binary.LittleEndian.Uint32(src[off:]))
+		}
+	}
+}
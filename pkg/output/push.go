@@ -0,0 +1,205 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/recovery"
+)
+
+// pushQueueSize is how many segments may wait for FFmpeg before new ones
+// are dropped
+const pushQueueSize = 4
+
+// pushRetryDelay is how long a failed push waits before reconnecting
+const pushRetryDelay = 5 * time.Second
+
+// PushStats describes a live push to a remote destination
+type PushStats struct {
+	Connected bool      `json:"connected"`
+	Since     time.Time `json:"since,omitzero"` // When the current connection started
+	Restarts  int       `json:"restarts"`       // Reconnects after a failure
+	BytesSent int64     `json:"bytes_sent"`
+	Dropped   int       `json:"dropped"` // Segments dropped because FFmpeg fell behind
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// push remuxes the channel's fMP4 stream to a live destination with an
+// FFmpeg process, without re-encoding video. When the connection drops,
+// it reconnects on a later segment. RTMPPush and SRTPush wrap it.
+type push struct {
+	ffmpeg *ffmpeg.FFmpeg
+	cfg    ffmpeg.RestreamConfig
+
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	init     []byte
+	proc     *ffmpeg.Restreamer
+	stop     context.CancelFunc // Kills the current process
+	queue    chan []byte
+	failed   atomic.Bool // Set by the feed when FFmpeg stops accepting data
+	failedAt time.Time
+	wg       sync.WaitGroup
+	stats    PushStats
+	sent     atomic.Int64
+}
+
+// open prepares the push; nothing connects until the init segment
+func (p *push) open(cfg ffmpeg.RestreamConfig) error {
+	if p.ffmpeg == nil {
+		ff, err := ffmpeg.New()
+		if err != nil {
+			return err
+		}
+		p.ffmpeg = ff
+	}
+	p.cfg = cfg
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return nil
+}
+
+// Close disconnects from the destination
+func (p *push) Close() error {
+	p.mu.Lock()
+	p.stopProcess()
+	p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	return nil
+}
+
+// Stats returns the push's connection state and counters
+func (p *push) Stats() PushStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Connected = p.proc != nil && !p.failed.Load()
+	stats.BytesSent = p.sent.Load()
+	return stats
+}
+
+// WriteInit starts a new FFmpeg process for the stream the init segment
+// begins. It is called again whenever the encoder restarts.
+func (p *push) WriteInit(ctx context.Context, init *InitSegment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ctx == nil {
+		return errors.New("push output not open")
+	}
+	p.init = init.Data
+	p.stopProcess()
+	if err := p.startProcess(); err != nil {
+		p.failedAt = time.Now()
+		p.stats.LastError = err.Error()
+		return err
+	}
+	return nil
+}
+
+// WriteSegment queues a media segment for FFmpeg, reconnecting first if the
+// last connection failed. Segments start with a keyframe, so the stream
+// picks up cleanly after a reconnect. A segment that doesn't fit in the
+// queue, e.g. while a listener waits for its caller, is dropped and counted.
+func (p *push) WriteSegment(ctx context.Context, seg *Segment) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.init == nil {
+		return errors.New("push output: no init segment")
+	}
+	if p.ctx.Err() != nil {
+		return errors.New("push output closed")
+	}
+	if p.failed.Load() && p.proc != nil {
+		p.stats.LastError = p.stopProcess().Error()
+		p.failedAt = time.Now()
+	}
+	if p.proc == nil {
+		if time.Since(p.failedAt) < pushRetryDelay {
+			return nil
+		}
+		p.stats.Restarts++
+		if err := p.startProcess(); err != nil {
+			p.failedAt = time.Now()
+			p.stats.LastError = err.Error()
+			return err
+		}
+	}
+	select {
+	case p.queue <- seg.Data:
+	default:
+		p.stats.Dropped++
+	}
+	return nil
+}
+
+// startProcess connects with the current init segment; the caller holds p.mu
+func (p *push) startProcess() error {
+	procCtx, stop := context.WithCancel(p.ctx)
+	proc, err := p.ffmpeg.StartRestream(procCtx, p.cfg)
+	if err != nil {
+		stop()
+		return err
+	}
+	queue := make(chan []byte, pushQueueSize)
+	queue <- p.init
+	p.proc = proc
+	p.stop = stop
+	p.queue = queue
+	p.failed.Store(false)
+	p.stats.Since = time.Now()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer recovery.Handle("push output feed", nil)
+		p.feed(proc, queue)
+	}()
+	return nil
+}
+
+// stopProcess kills the current process and returns why it stopped, if it
+// had failed; the caller holds p.mu
+func (p *push) stopProcess() error {
+	if p.proc == nil {
+		return nil
+	}
+	failed := p.failed.Load()
+	if !failed {
+		p.stop() // A failed process exits on its own once stdin closes
+	}
+	close(p.queue)
+	p.wg.Wait()
+	err := p.proc.Close()
+	p.stop()
+	p.proc = nil
+	p.stop = nil
+	p.queue = nil
+	if !failed {
+		return nil // Killed, so the exit error says nothing
+	}
+	if err == nil {
+		err = errors.New("push ended")
+	}
+	return err
+}
+
+// feed writes queued segments to FFmpeg until the queue is closed
+func (p *push) feed(proc *ffmpeg.Restreamer, queue <-chan []byte) {
+	for data := range queue {
+		if p.failed.Load() {
+			continue // Drain so WriteSegment never blocks
+		}
+		n, err := proc.Write(data)
+		p.sent.Add(int64(n))
+		if err != nil {
+			logger.Warn("Push output failed", "format", p.cfg.Format, "error", err)
+			p.failed.Store(true)
+		}
+	}
+}
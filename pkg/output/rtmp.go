@@ -0,0 +1,46 @@
+package output
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func init() {
+	Register("rtmp", func() Output { return NewRTMPPush(nil) })
+}
+
+// RTMPPush re-pushes the channel's encoded stream to an RTMP(S) server,
+// e.g. YouTube or Twitch. Audio is re-encoded to AAC, which every RTMP
+// ingest accepts. The config path is the full ingest URL including the
+// stream key.
+type RTMPPush struct {
+	push
+}
+
+// NewRTMPPush creates an RTMP output; a nil ff finds FFmpeg on Open
+func NewRTMPPush(ff *ffmpeg.FFmpeg) *RTMPPush {
+	return &RTMPPush{push{ffmpeg: ff}}
+}
+
+func (p *RTMPPush) Name() string { return "rtmp-push" }
+func (p *RTMPPush) Type() string { return "rtmp" }
+
+// Open validates the ingest URL
+func (p *RTMPPush) Open(cfg Config) error {
+	u, err := url.Parse(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("parse rtmp url: %w", err)
+	}
+	if u.Scheme != "rtmp" && u.Scheme != "rtmps" {
+		return fmt.Errorf("rtmp url must be rtmp or rtmps, got %q", u.Scheme)
+	}
+	return p.open(ffmpeg.RestreamConfig{
+		URL:        cfg.Path,
+		Format:     "flv",
+		Options:    []string{"-flvflags", "no_duration_filesize"},
+		AudioCodec: "aac",
+		Realtime:   true,
+	})
+}
@@ -0,0 +1,204 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/manifest"
+	"github.com/video-system/go-video-capture/internal/objstore"
+)
+
+func init() {
+	Register("s3", func() Output { return NewS3Upload() })
+}
+
+// s3QueueSize is how many uploads may wait while the bucket is slow before
+// segments are dropped
+const s3QueueSize = 8
+
+// s3Upload is an object queued for upload
+type s3Upload struct {
+	key     string
+	data    []byte
+	segment *manifest.Segment // Listed in the playlist once uploaded
+}
+
+// S3Upload uploads segments to S3-compatible object storage as they are
+// produced, with a live HLS playlist next to them, so the bucket can serve
+// as a cloud HLS origin and holds an off-site copy of the buffer. Segments
+// stay in the bucket after leaving the playlist; expire them with a bucket
+// lifecycle rule. The config path is a path-style URL,
+// endpoint/bucket/prefix, e.g. https://s3.us-east-1.amazonaws.com/live/studio.
+type S3Upload struct {
+	store    *objstore.Client
+	listSize int
+	target   time.Duration
+	queue    chan s3Upload
+	done     chan struct{}
+
+	mu       sync.Mutex
+	init     []byte
+	inits    int
+	initName string
+	dropped  int
+
+	// Owned by the upload goroutine
+	listed   []manifest.Segment
+	listInit string
+}
+
+// NewS3Upload creates an object storage output
+func NewS3Upload() *S3Upload {
+	return &S3Upload{}
+}
+
+func (s *S3Upload) Name() string { return "s3-upload" }
+func (s *S3Upload) Type() string { return "s3" }
+
+// Open parses the bucket URL and starts uploading
+func (s *S3Upload) Open(cfg Config) error {
+	u, err := url.Parse(cfg.Path)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("s3 output: invalid url %q", cfg.Path)
+	}
+	bucket, prefix, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if bucket == "" {
+		return fmt.Errorf("s3 output: no bucket in url %q", cfg.Path)
+	}
+	s.store, err = objstore.New(objstore.Config{
+		Endpoint:  u.Scheme + "://" + u.Host,
+		Region:    cfg.Region,
+		Bucket:    bucket,
+		Prefix:    prefix,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+	})
+	if err != nil {
+		return fmt.Errorf("s3 output: %w", err)
+	}
+	s.listSize = cfg.ListSize
+	if s.listSize <= 0 {
+		s.listSize = hlsListSize
+	}
+	s.target = time.Duration(cfg.SegmentDur * float64(time.Second))
+	s.queue = make(chan s3Upload, s3QueueSize)
+	s.done = make(chan struct{})
+	go s.upload()
+	return nil
+}
+
+// Close finishes queued uploads and ends the playlist so players stop
+// polling it
+func (s *S3Upload) Close() error {
+	if s.queue == nil {
+		return nil
+	}
+	close(s.queue)
+	<-s.done
+	if s.listInit == "" {
+		return nil
+	}
+	return s.putPlaylist(true)
+}
+
+// WriteInit queues the initialization segment. It is called again whenever
+// the encoder restarts; if the init segment changed, the playlist starts
+// over since earlier segments no longer match it.
+func (s *S3Upload) WriteInit(ctx context.Context, init *InitSegment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bytes.Equal(init.Data, s.init) {
+		return nil
+	}
+	s.inits++
+	s.init = init.Data
+	s.initName = fmt.Sprintf("init_%d.mp4", s.inits)
+	// Blocks rather than drops: segments are useless without it
+	s.queue <- s3Upload{key: s.initName, data: init.Data}
+	return nil
+}
+
+// WriteSegment queues a media segment. A segment that doesn't fit in the
+// queue is dropped and left out of the playlist.
+func (s *S3Upload) WriteSegment(ctx context.Context, seg *Segment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.initName == "" {
+		return errors.New("s3 output: no init segment")
+	}
+	name := segmentName(seg.Sequence)
+	var start time.Time
+	if seg.StartTime > 0 {
+		start = time.UnixMilli(seg.StartTime)
+	}
+	up := s3Upload{key: name, data: seg.Data, segment: &manifest.Segment{
+		Sequence: seg.Sequence,
+		URI:      name,
+		Start:    start,
+		Duration: time.Duration(seg.Duration * float64(time.Second)),
+	}}
+	select {
+	case s.queue <- up:
+		return nil
+	default:
+		s.dropped++
+		return fmt.Errorf("s3 output: upload queue full, dropped segment %d (%d dropped)", seg.Sequence, s.dropped)
+	}
+}
+
+// upload sends queued objects in order, updating the playlist after each
+// segment, until the queue is closed
+func (s *S3Upload) upload() {
+	defer close(s.done)
+	for up := range s.queue {
+		header := http.Header{"Content-Type": {"video/mp4"}}
+		if up.segment != nil {
+			header.Set("Content-Type", "video/iso.segment")
+		}
+		if err := s.store.PutObject(context.Background(), up.key, up.data, header); err != nil {
+			logger.Warn("S3 upload failed", "key", up.key, "error", err)
+			continue
+		}
+		if up.segment == nil {
+			// New init segment: earlier segments no longer play with it
+			s.listInit = up.key
+			s.listed = nil
+			continue
+		}
+		if s.listInit == "" {
+			continue
+		}
+		s.target = max(s.target, up.segment.Duration) // Target duration must never shrink
+		s.listed = append(s.listed, *up.segment)
+		if n := len(s.listed) - s.listSize; n > 0 {
+			s.listed = s.listed[n:]
+		}
+		if err := s.putPlaylist(false); err != nil {
+			logger.Warn("S3 playlist upload failed", "error", err)
+		}
+	}
+}
+
+// putPlaylist uploads the live playlist; it is never cached so players see
+// each new segment
+func (s *S3Upload) putPlaylist(ended bool) error {
+	playlist := manifest.MediaPlaylist{
+		Segments:        s.listed,
+		MapURI:          s.listInit,
+		ProgramDateTime: true,
+		Ended:           ended,
+		TargetDuration:  s.target,
+	}
+	header := http.Header{
+		"Content-Type":  {"application/vnd.apple.mpegurl"},
+		"Cache-Control": {"no-cache"},
+	}
+	return s.store.PutObject(context.Background(), "index.m3u8", playlist.Encode(), header)
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func init() {
+	Register("srt", func() Output { return NewSRTPush(nil, nil) })
+}
+
+// SRTPush sends the channel's encoded stream over SRT as MPEG-TS, e.g. as a
+// clean contribution feed into a production switcher. Audio and video are
+// copied. The config path is the SRT URL: the remote address in caller
+// mode, or the local address to bind in listener mode.
+type SRTPush struct {
+	push
+	options []string
+}
+
+// NewSRTPush creates an SRT output. options are FFmpeg SRT protocol
+// options such as -mode listener or -passphrase, kept out of the URL so
+// secrets stay out of logs. A nil ff finds FFmpeg on Open.
+func NewSRTPush(ff *ffmpeg.FFmpeg, options []string) *SRTPush {
+	return &SRTPush{push: push{ffmpeg: ff}, options: options}
+}
+
+func (p *SRTPush) Name() string { return "srt-push" }
+func (p *SRTPush) Type() string { return "srt" }
+
+// Open validates the SRT URL
+func (p *SRTPush) Open(cfg Config) error {
+	u, err := url.Parse(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("parse srt url: %w", err)
+	}
+	if u.Scheme != "srt" {
+		return fmt.Errorf("srt url must be srt://, got %q", cfg.Path)
+	}
+	return p.open(ffmpeg.RestreamConfig{
+		URL:     cfg.Path,
+		Format:  "mpegts",
+		Options: p.options,
+	})
+}
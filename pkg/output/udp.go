@@ -0,0 +1,56 @@
+package output
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func init() {
+	Register("udp", func() Output { return NewUDPPush(nil) })
+}
+
+// udpPacketSize fits seven 188-byte TS packets in an Ethernet frame, what
+// hardware decoders expect
+const udpPacketSize = "1316"
+
+// UDPPush sends the channel's encoded stream as MPEG-TS over UDP, unicast
+// or multicast, for in-venue distribution to hardware decoders and
+// confidence monitors. Audio and video are copied; segments are paced at
+// their native rate since UDP receivers have little buffer. The config path
+// is the UDP URL, e.g. udp://239.10.0.1:5000?ttl=4; FFmpeg UDP options such
+// as ttl and localaddr go in its query.
+type UDPPush struct {
+	push
+}
+
+// NewUDPPush creates a UDP output; a nil ff finds FFmpeg on Open
+func NewUDPPush(ff *ffmpeg.FFmpeg) *UDPPush {
+	return &UDPPush{push{ffmpeg: ff}}
+}
+
+func (p *UDPPush) Name() string { return "udp-push" }
+func (p *UDPPush) Type() string { return "udp" }
+
+// Open validates the UDP URL
+func (p *UDPPush) Open(cfg Config) error {
+	u, err := url.Parse(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("parse udp url: %w", err)
+	}
+	if u.Scheme != "udp" || u.Port() == "" {
+		return fmt.Errorf("udp url must be udp://host:port, got %q", cfg.Path)
+	}
+	q := u.Query()
+	if !q.Has("pkt_size") {
+		q.Set("pkt_size", udpPacketSize)
+		u.RawQuery = q.Encode()
+	}
+	return p.open(ffmpeg.RestreamConfig{
+		URL:      u.String(),
+		Format:   "mpegts",
+		Options:  []string{"-mpegts_flags", "+resend_headers"},
+		Realtime: true,
+	})
+}
@@ -0,0 +1,41 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+)
+
+func TestUDPPushOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantURL string
+		wantErr bool
+	}{
+		{"multicast", "udp://239.10.0.1:5000", "udp://239.10.0.1:5000?pkt_size=1316", false},
+		{"keeps options", "udp://239.10.0.1:5000?ttl=4", "udp://239.10.0.1:5000?pkt_size=1316&ttl=4", false},
+		{"own packet size", "udp://10.0.0.5:5000?pkt_size=188", "udp://10.0.0.5:5000?pkt_size=188", false},
+		{"no port", "udp://239.10.0.1", "", true},
+		{"wrong scheme", "srt://239.10.0.1:5000", "", true},
+		{"unparseable", "udp://[::1", "", true},
+	}
+	for _, tt := range tests {
+		p := NewUDPPush(&ffmpeg.FFmpeg{})
+		err := p.Open(Config{Path: tt.path})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Open = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if p.cfg.URL != tt.wantURL {
+			t.Errorf("%s: url = %q, want %q", tt.name, p.cfg.URL, tt.wantURL)
+		}
+		if p.cfg.Format != "mpegts" || !p.cfg.Realtime {
+			t.Errorf("%s: format %q realtime %v, want paced mpegts", tt.name, p.cfg.Format, p.cfg.Realtime)
+		}
+		p.Close()
+	}
+}
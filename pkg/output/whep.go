@@ -0,0 +1,149 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/whep"
+)
+
+// WHEPSend feeds the channel's stream to WebRTC players through a WHEP
+// source. Segments are streamed while they are written, so with chunked
+// segments players are a chunk or two behind capture. FFmpeg only runs
+// while players are connected; a new player starts at the next segment.
+type WHEPSend struct {
+	ffmpeg *ffmpeg.FFmpeg
+	source *whep.Source
+
+	feedMu sync.Mutex // Held while a segment streams, keeping segments in order
+
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	init     []byte
+	proc     *ffmpeg.Restreamer
+	stop     context.CancelFunc // Kills the current process
+	failedAt time.Time
+}
+
+// NewWHEPSend creates a WebRTC output feeding source
+func NewWHEPSend(ff *ffmpeg.FFmpeg, source *whep.Source) *WHEPSend {
+	return &WHEPSend{ffmpeg: ff, source: source}
+}
+
+func (w *WHEPSend) Name() string { return "whep-send" }
+func (w *WHEPSend) Type() string { return "whep" }
+
+// Open prepares the output; FFmpeg starts with the first player
+func (w *WHEPSend) Open(cfg Config) error {
+	if w.source == nil {
+		return errors.New("whep output: no source")
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	return nil
+}
+
+// Close stops feeding players; their sessions stay open for the next writer
+func (w *WHEPSend) Close() error {
+	w.mu.Lock()
+	w.stopProcess()
+	w.mu.Unlock()
+	if w.cancel != nil {
+		w.cancel()
+	}
+	return nil
+}
+
+// WriteInit records the init segment of a new stream. It is called again
+// whenever the encoder restarts.
+func (w *WHEPSend) WriteInit(ctx context.Context, init *InitSegment) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx == nil {
+		return errors.New("whep output not open")
+	}
+	w.init = init.Data
+	w.stopProcess()
+	return nil
+}
+
+// WriteSegment sends a complete media segment
+func (w *WHEPSend) WriteSegment(ctx context.Context, seg *Segment) error {
+	return w.StreamSegment(ctx, seg.Sequence, bytes.NewReader(seg.Data))
+}
+
+// StreamSegment sends a media segment as it is written, reading r until EOF
+func (w *WHEPSend) StreamSegment(ctx context.Context, seq int, r io.Reader) error {
+	w.feedMu.Lock()
+	defer w.feedMu.Unlock()
+	proc, err := w.process()
+	if proc == nil {
+		return err
+	}
+	if _, err := io.Copy(proc, r); err != nil {
+		w.mu.Lock()
+		if w.proc == proc && ctx.Err() == nil {
+			w.stopProcess()
+			w.failedAt = time.Now()
+		}
+		w.mu.Unlock()
+		return fmt.Errorf("whep output: %w", err)
+	}
+	return nil
+}
+
+// process returns the FFmpeg process to stream the next segment to,
+// starting it when players are connected and stopping it when none are.
+// It returns nil when no players are watching.
+func (w *WHEPSend) process() (*ffmpeg.Restreamer, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx == nil || w.ctx.Err() != nil {
+		return nil, errors.New("whep output closed")
+	}
+	if w.source.Sessions() == 0 {
+		w.stopProcess()
+		return nil, nil
+	}
+	if w.proc != nil {
+		return w.proc, nil
+	}
+	if w.init == nil || time.Since(w.failedAt) < pushRetryDelay {
+		return nil, nil
+	}
+
+	procCtx, stop := context.WithCancel(w.ctx)
+	proc, err := w.ffmpeg.StartRTP(procCtx, ffmpeg.RTPConfig{
+		VideoURL: w.source.VideoURL(),
+		AudioURL: w.source.AudioURL(),
+	})
+	if err != nil {
+		stop()
+		w.failedAt = time.Now()
+		return nil, err
+	}
+	if _, err := proc.Write(w.init); err != nil {
+		stop()
+		proc.Close()
+		w.failedAt = time.Now()
+		return nil, fmt.Errorf("whep output: %w", err)
+	}
+	w.proc, w.stop = proc, stop
+	return proc, nil
+}
+
+// stopProcess kills the current process; the caller holds w.mu
+func (w *WHEPSend) stopProcess() {
+	if w.proc == nil {
+		return
+	}
+	w.stop()
+	w.proc.Close() // Killed, so the exit error says nothing
+	w.proc, w.stop = nil, nil
+}
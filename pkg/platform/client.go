@@ -8,22 +8,30 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Client is the video-platform API client
 type Client struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	baseURL       string
+	apiKey        string
+	uploadMode    string
+	httpClient    *http.Client
+	storageClient *http.Client // Presigned uploads, no timeout
 }
 
 // Config holds platform client configuration
 type Config struct {
-	URL    string
-	APIKey string
+	URL        string
+	APIKey     string
+	UploadMode string // UploadMultipart (default) or UploadPresigned
+	TLS        TLSConfig
 }
 
 // ClipMetadata represents clip metadata for upload
@@ -37,6 +45,10 @@ type ClipMetadata struct {
 	DurationSeconds float64                `json:"duration_seconds"`
 	FileSizeBytes   int64                  `json:"file_size_bytes,omitempty"`
 	Tags            map[string]interface{} `json:"tags,omitempty"`
+	Variant         string                 `json:"variant,omitempty"`      // Aspect-ratio variant name (empty = master)
+	AspectRatio     string                 `json:"aspect_ratio,omitempty"` // e.g. 9:16
+	BundleID        string                 `json:"bundle_id,omitempty"`    // Multi-angle clip this is one channel of
+	Angles          []string               `json:"angles,omitempty"`       // Channels in the bundle
 }
 
 // UploadResult represents the result of a clip upload
@@ -58,6 +70,17 @@ type SegmentNotification struct {
 	IsFinal    bool   `json:"is_final"`
 }
 
+// AlertNotification reports an alert rule firing or resolving
+type AlertNotification struct {
+	Rule      string  `json:"rule"`
+	ChannelID string  `json:"channel_id,omitempty"`
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Firing    bool    `json:"firing"`
+	Timestamp int64   `json:"timestamp"`
+}
+
 // AgentStatus represents the status of a capture agent
 type AgentStatus string
 
@@ -78,6 +101,8 @@ type AgentCapabilities struct {
 	SupportedCodecs []string `json:"supported_codecs"`
 	MaxResolution   string   `json:"max_resolution"`
 	MaxBitrate      int      `json:"max_bitrate"`
+
+	HardwareEncoders []string `json:"hardware_encoders,omitempty"` // e.g. h264_nvenc, av1_qsv
 }
 
 // RegisterAgentRequest represents a request to register an agent
@@ -97,6 +122,26 @@ type AgentHeartbeatRequest struct {
 	SessionID    string      `json:"session_id,omitempty"`
 	ChannelID    string      `json:"channel_id,omitempty"`
 	ErrorMessage string      `json:"error_message,omitempty"`
+
+	Metrics *AgentMetrics `json:"metrics,omitempty"`
+}
+
+// AgentMetrics is host and channel health sent with each heartbeat, so the
+// platform can show it without calling the agent's API
+type AgentMetrics struct {
+	CPUPercent       float64                   `json:"cpu_percent"`
+	MemoryUsedBytes  uint64                    `json:"memory_used_bytes"`
+	MemoryTotalBytes uint64                    `json:"memory_total_bytes"`
+	DiskFreeBytes    uint64                    `json:"disk_free_bytes"` // Buffer volume
+	Channels         map[string]ChannelMetrics `json:"channels"`
+}
+
+// ChannelMetrics is one channel's capture health
+type ChannelMetrics struct {
+	Capturing         bool    `json:"capturing"`
+	BufferHealth      float64 `json:"buffer_health"`
+	CaptureFPS        float64 `json:"capture_fps"`
+	LastSegmentAgeSec float64 `json:"last_segment_age_sec"` // Since start when no segment arrived yet
 }
 
 // Agent represents a registered capture agent
@@ -116,15 +161,29 @@ type Agent struct {
 	UpdatedAt    time.Time         `json:"updated_at"`
 }
 
-// New creates a new platform client
-func New(cfg Config) *Client {
-	return &Client{
-		baseURL: cfg.URL,
-		apiKey:  cfg.APIKey,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Minute, // Long timeout for large uploads
-		},
+// New creates a new platform client. TLS settings apply to the platform
+// API only; presigned uploads go to object storage with Go's defaults.
+func New(cfg Config) (*Client, error) {
+	if cfg.UploadMode == "" {
+		cfg.UploadMode = UploadMultipart
 	}
+	httpClient := &http.Client{
+		Timeout: 5 * time.Minute, // Long timeout for large uploads
+	}
+	if cfg.TLS.enabled() {
+		transport, err := cfg.TLS.transport()
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Transport = transport
+	}
+	return &Client{
+		baseURL:       cfg.URL,
+		apiKey:        cfg.APIKey,
+		uploadMode:    cfg.UploadMode,
+		httpClient:    httpClient,
+		storageClient: &http.Client{},
+	}, nil
 }
 
 // IsConfigured returns true if the client is properly configured
@@ -132,12 +191,24 @@ func (c *Client) IsConfigured() bool {
 	return c.baseURL != ""
 }
 
-// UploadClip uploads a clip file to the platform
-func (c *Client) UploadClip(ctx context.Context, filePath string, metadata ClipMetadata) (*UploadResult, error) {
+// UploadClip uploads a clip file to the platform, or straight to object
+// storage in presigned mode
+func (c *Client) UploadClip(ctx context.Context, filePath string, metadata ClipMetadata) (_ *UploadResult, err error) {
 	if !c.IsConfigured() {
 		return nil, fmt.Errorf("platform client not configured")
 	}
 
+	ctx, span := tracing.Start(ctx, "platform.upload",
+		attribute.String("play_id", metadata.PlayID),
+		attribute.String("variant", metadata.Variant),
+		attribute.String("mode", c.uploadMode))
+	defer func() { tracing.End(span, err) }()
+	if c.uploadMode == UploadPresigned {
+		return c.uploadPresigned(ctx, filePath, metadata)
+	}
+	_, readSpan := tracing.Start(ctx, "disk.read_clip")
+	defer readSpan.End() // no-op once ended below
+
 	// Open the file
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -177,6 +248,8 @@ func (c *Client) UploadClip(ctx context.Context, filePath string, metadata ClipM
 	if err := writer.Close(); err != nil {
 		return nil, fmt.Errorf("close multipart writer: %w", err)
 	}
+	readSpan.SetAttributes(attribute.Int64("file_bytes", metadata.FileSizeBytes))
+	readSpan.End()
 
 	// Create request
 	url := fmt.Sprintf("%s/api/v1/clips/upload", c.baseURL)
@@ -189,6 +262,7 @@ func (c *Client) UploadClip(ctx context.Context, filePath string, metadata ClipM
 	if c.apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
+	tracing.Inject(ctx, req.Header)
 
 	// Execute request
 	resp, err := c.httpClient.Do(req)
@@ -303,6 +377,78 @@ func (c *Client) NotifySegmentReady(ctx context.Context, notification SegmentNot
 	return nil
 }
 
+// NotifyAlert sends an alert state change to the platform
+func (c *Client) NotifyAlert(ctx context.Context, alert AlertNotification) error {
+	if !c.IsConfigured() {
+		return nil // Silent skip if platform not configured
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshal alert: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/alerts", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("alert notification failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// UploadSessionReport sends an end-of-session capture report for QC
+func (c *Client) UploadSessionReport(ctx context.Context, sessionID string, report interface{}) error {
+	if !c.IsConfigured() {
+		return fmt.Errorf("platform client not configured")
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/sessions/%s/report", c.baseURL, neturl.PathEscape(sessionID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("report upload failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
 // RegisterAgent registers this capture agent with the platform
 func (c *Client) RegisterAgent(ctx context.Context, req RegisterAgentRequest) (*Agent, error) {
 	if !c.IsConfigured() {
@@ -0,0 +1,87 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"time"
+)
+
+// Command types the platform can send to an agent
+const (
+	CommandMarkIn         = "mark_in"         // Params: play_id
+	CommandMarkOut        = "mark_out"        // Params: play_id, generate_clip, tags, clip options
+	CommandGenerateClip   = "generate_clip"   // Params: start_time, end_time, play_id, clip options
+	CommandSetSession     = "set_session"     // Params: session_id
+	CommandRestartChannel = "restart_channel" // No params
+)
+
+// Command is an operation the platform asks an agent to run. Commands
+// reach agents over a long poll the agent opens, so agents behind NAT
+// need no inbound-reachable API URL.
+type Command struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	ChannelID string          `json:"channel_id,omitempty"` // Empty = default channel
+	Params    json.RawMessage `json:"params,omitempty"`
+	IssuedAt  time.Time       `json:"issued_at"`
+}
+
+// CommandResult reports how a command went
+type CommandResult struct {
+	Status string      `json:"status"` // ok or error
+	Error  string      `json:"error,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+// PollCommands waits up to wait for commands queued for the agent. It
+// returns no commands when the wait ends without any.
+func (c *Client) PollCommands(ctx context.Context, agentID string, wait time.Duration) ([]Command, error) {
+	if !c.IsConfigured() {
+		return nil, fmt.Errorf("platform client not configured")
+	}
+
+	url := fmt.Sprintf("%s/api/v1/agents/%s/commands?wait=%d", c.baseURL, neturl.PathEscape(agentID), int(wait.Seconds()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("poll commands failed (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var commands []Command
+	if err := json.Unmarshal(body, &commands); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	return commands, nil
+}
+
+// ReportCommandResult tells the platform how a command went
+func (c *Client) ReportCommandResult(ctx context.Context, agentID, commandID string, result CommandResult) error {
+	if !c.IsConfigured() {
+		return fmt.Errorf("platform client not configured")
+	}
+	path := fmt.Sprintf("/api/v1/agents/%s/commands/%s/result", neturl.PathEscape(agentID), neturl.PathEscape(commandID))
+	return c.postJSON(ctx, path, result, nil)
+}
@@ -0,0 +1,47 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCommands(t *testing.T) {
+	var reported CommandResult
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/agents/a1/commands":
+			if r.URL.Query().Get("wait") != "30" {
+				t.Errorf("wait = %q", r.URL.Query().Get("wait"))
+			}
+			json.NewEncoder(w).Encode([]Command{{ID: "c1", Type: CommandMarkIn, Params: json.RawMessage(`{"play_id":"p1"}`)}})
+		case "/api/v1/agents/a1/commands/c1/result":
+			json.NewDecoder(r.Body).Decode(&reported)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands, err := c.PollCommands(context.Background(), "a1", 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 1 || commands[0].Type != CommandMarkIn || string(commands[0].Params) != `{"play_id":"p1"}` {
+		t.Fatalf("commands = %+v", commands)
+	}
+	if err := c.ReportCommandResult(context.Background(), "a1", "c1", CommandResult{Status: "error", Error: "no buffer"}); err != nil {
+		t.Fatal(err)
+	}
+	if reported.Status != "error" || reported.Error != "no buffer" {
+		t.Errorf("reported = %+v", reported)
+	}
+}
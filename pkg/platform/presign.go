@@ -0,0 +1,158 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Upload modes
+const (
+	UploadMultipart = "multipart" // POST the file to the platform API (default)
+	UploadPresigned = "presigned" // PUT the file to object storage at a presigned URL
+)
+
+// presignRequest asks the platform for a URL to upload a clip to
+type presignRequest struct {
+	FileName    string       `json:"file_name"`
+	ContentType string       `json:"content_type"`
+	Metadata    ClipMetadata `json:"metadata"`
+}
+
+// presignResponse is where and how to upload the clip
+type presignResponse struct {
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method,omitempty"`  // Default PUT
+	Headers   map[string]string `json:"headers,omitempty"` // Headers the signature covers
+	ObjectKey string            `json:"object_key"`
+}
+
+// completeRequest registers an uploaded object as a clip
+type completeRequest struct {
+	ObjectKey string       `json:"object_key"`
+	Metadata  ClipMetadata `json:"metadata"`
+}
+
+// uploadPresigned streams the clip straight to object storage, so large
+// clips don't pass through the platform API, then posts its metadata
+func (c *Client) uploadPresigned(ctx context.Context, filePath string, metadata ClipMetadata) (*UploadResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	metadata.FileSizeBytes = fileInfo.Size()
+
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	var presign presignResponse
+	if err := c.postJSON(ctx, "/api/v1/clips/upload/presign", presignRequest{
+		FileName:    filepath.Base(filePath),
+		ContentType: contentType,
+		Metadata:    metadata,
+	}, &presign); err != nil {
+		return nil, fmt.Errorf("presign: %w", err)
+	}
+	if presign.UploadURL == "" || presign.ObjectKey == "" {
+		return nil, fmt.Errorf("presign: response missing upload_url or object_key")
+	}
+
+	if err := c.putObject(ctx, presign, file, fileInfo.Size(), contentType); err != nil {
+		return nil, err
+	}
+
+	var result UploadResult
+	if err := c.postJSON(ctx, "/api/v1/clips/upload/complete", completeRequest{
+		ObjectKey: presign.ObjectKey,
+		Metadata:  metadata,
+	}, &result); err != nil {
+		return nil, fmt.Errorf("complete upload: %w", err)
+	}
+	return &result, nil
+}
+
+// putObject streams the file to the presigned URL. The URL carries its own
+// credentials, so the API key is not sent.
+func (c *Client) putObject(ctx context.Context, presign presignResponse, body io.Reader, size int64, contentType string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.put", attribute.Int64("file_bytes", size))
+	defer func() { tracing.End(span, err) }()
+
+	method := presign.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+	req, err := http.NewRequestWithContext(ctx, method, presign.UploadURL, body)
+	if err != nil {
+		return fmt.Errorf("create storage request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range presign.Headers {
+		req.Header.Set(k, v)
+	}
+
+	// No client timeout: multi-gigabyte clips take as long as the context allows
+	resp, err := c.storageClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload to storage: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("upload to storage failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// postJSON sends a JSON request to the platform API and decodes the reply
+// into out unless it is nil
+func (c *Client) postJSON(ctx context.Context, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("request failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadPresigned(t *testing.T) {
+	var stored []byte
+	var completed completeRequest
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "" || r.Header.Get("X-Amz-Acl") != "private" {
+			t.Errorf("storage request: %s %v", r.Method, r.Header)
+		}
+		stored, _ = io.ReadAll(r.Body)
+	}))
+	defer storage.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/clips/upload/presign":
+			var req presignRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.FileName != "clip.mp4" || req.ContentType != "video/mp4" || req.Metadata.FileSizeBytes != 4 {
+				t.Errorf("presign request = %+v", req)
+			}
+			json.NewEncoder(w).Encode(presignResponse{
+				UploadURL: storage.URL + "/bucket/clip.mp4",
+				Headers:   map[string]string{"X-Amz-Acl": "private"},
+				ObjectKey: "bucket/clip.mp4",
+			})
+		case "/api/v1/clips/upload/complete":
+			json.NewDecoder(r.Body).Decode(&completed)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(UploadResult{Status: "ok", FilePath: completed.ObjectKey})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer api.Close()
+
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	os.WriteFile(path, []byte("mp4!"), 0644)
+	c, err := New(Config{URL: api.URL, APIKey: "key", UploadMode: UploadPresigned})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.UploadClip(context.Background(), path, ClipMetadata{PlayID: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != "mp4!" || result.FilePath != "bucket/clip.mp4" || completed.Metadata.PlayID != "p1" {
+		t.Errorf("stored %q, result %+v, completed %+v", stored, result, completed)
+	}
+}
+
+func TestPostJSONStatus(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		if status == http.StatusAccepted || status == http.StatusBadGateway {
+			io.WriteString(w, `{"status":"queued"}`)
+		}
+	}))
+	defer srv.Close()
+	c, err := New(Config{URL: srv.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Any 2xx is success; a nil out skips decoding an empty body
+	status = http.StatusNoContent
+	if err := c.postJSON(ctx, "/api/v1/x", struct{}{}, nil); err != nil {
+		t.Errorf("204 with nil out: %v", err)
+	}
+	status = http.StatusAccepted
+	var out UploadResult
+	if err := c.postJSON(ctx, "/api/v1/x", struct{}{}, &out); err != nil || out.Status != "queued" {
+		t.Errorf("202: %v, %+v", err, out)
+	}
+	status = http.StatusBadGateway
+	if err := c.postJSON(ctx, "/api/v1/x", struct{}{}, &out); err == nil {
+		t.Error("expected an error for 502")
+	}
+}
@@ -0,0 +1,64 @@
+package platform
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSConfig configures TLS for platform API requests
+type TLSConfig struct {
+	CertFile           string // Client certificate (PEM) for mTLS
+	KeyFile            string // Client key (PEM)
+	CAFile             string // CA bundle (PEM) to verify the platform with, instead of the system roots
+	InsecureSkipVerify bool   // Don't verify the platform's certificate (lab use only)
+}
+
+// enabled reports whether any setting differs from Go's defaults
+func (c TLSConfig) enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.CAFile != "" || c.InsecureSkipVerify
+}
+
+// load builds the tls.Config, reading the certificate files
+func (c TLSConfig) load() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, errors.New("tls: cert_file and key_file must be set together")
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: read ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls: no certificates in %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// transport returns an HTTP transport using the TLS settings
+func (c TLSConfig) transport() (*http.Transport, error) {
+	tlsCfg, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsCfg
+	return t, nil
+}
@@ -0,0 +1,44 @@
+package platform
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0644)
+
+	for _, tc := range []struct {
+		name string
+		tls  TLSConfig
+		ok   bool
+	}{
+		{"system roots", TLSConfig{}, false},
+		{"custom ca", TLSConfig{CAFile: caFile}, true},
+		{"skip verify", TLSConfig{InsecureSkipVerify: true}, true},
+	} {
+		c, err := New(Config{URL: srv.URL, TLS: tc.tls})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if err := c.CheckHealth(context.Background()); (err == nil) != tc.ok {
+			t.Errorf("%s: health err = %v", tc.name, err)
+		}
+	}
+
+	if _, err := New(Config{URL: srv.URL, TLS: TLSConfig{CertFile: "client.pem"}}); err == nil {
+		t.Error("expected error for cert without key")
+	}
+	if _, err := New(Config{URL: srv.URL, TLS: TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}); err == nil {
+		t.Error("expected error for missing ca file")
+	}
+}
@@ -0,0 +1,93 @@
+package ringbuffer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ClipAudioTrack is one audio track in a clip's mix
+type ClipAudioTrack struct {
+	Track  string  // Audio track name (empty = the track muxed with video)
+	GainDB float64 // Level in dB (0 = unchanged)
+}
+
+// primary reports whether t is the track muxed with video
+func (b *Buffer) primary(t ClipAudioTrack) bool {
+	return t.Track == "" || t.Track == b.cfg.PrimaryAudio
+}
+
+// remixAudio replaces the audio of a freshly concatenated clip as requested.
+// Alternate tracks are cut from their own segments, which share sequence
+// numbers with the video.
+func (b *Buffer) remixAudio(ctx context.Context, outputPath string, segments []*Segment, opts ClipOptions) error {
+	if !opts.Mute && (len(opts.Audio) == 0 || len(opts.Audio) == 1 && b.primary(opts.Audio[0]) && opts.Audio[0].GainDB == 0) {
+		return nil
+	}
+
+	var inputs []ffmpeg.ClipAudioInput
+	if !opts.Mute {
+		for _, t := range opts.Audio {
+			if b.primary(t) {
+				inputs = append(inputs, ffmpeg.ClipAudioInput{GainDB: t.GainDB})
+				continue
+			}
+			path, err := b.concatAudioTrack(ctx, t.Track, segments, outputPath)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(path)
+			inputs = append(inputs, ffmpeg.ClipAudioInput{Path: path, GainDB: t.GainDB})
+		}
+	}
+
+	tempPath := outputPath + ".src.mp4"
+	if err := os.Rename(outputPath, tempPath); err != nil {
+		return fmt.Errorf("prepare audio remix: %w", err)
+	}
+	defer os.Remove(tempPath)
+
+	err := traceStep(ctx, "ffmpeg.audio_remix", func(ctx context.Context) error {
+		return b.ffmpeg.RemixClipAudio(ctx, tempPath, outputPath, inputs, opts.Mute)
+	}, attribute.Bool("mute", opts.Mute), attribute.Int("tracks", len(inputs)))
+	if err != nil {
+		return fmt.Errorf("clip audio: %w", err)
+	}
+	return nil
+}
+
+// concatAudioTrack joins an alternate audio track's segments matching the
+// clip's video segments
+func (b *Buffer) concatAudioTrack(ctx context.Context, name string, segments []*Segment, outputPath string) (string, error) {
+	dir := filepath.Join(b.workPath(), ffmpeg.AudioRenditionDir(name))
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("audio track %q not found", name)
+	}
+
+	paths := make([]string, len(segments))
+	for i, seg := range segments {
+		// Imported footage has no renditions; init segments kept across an
+		// encode change sit with the captured ones
+		if seg.InitPath != "" && filepath.Dir(seg.InitPath) != b.workPath() {
+			return "", fmt.Errorf("audio track %s: not available for imported footage", name)
+		}
+		paths[i] = filepath.Join(dir, fmt.Sprintf("segment_%05d.m4s", seg.Sequence))
+		if _, err := os.Stat(paths[i]); err != nil {
+			return "", fmt.Errorf("audio track %s: segment %d not available: %w", name, seg.Sequence, err)
+		}
+	}
+
+	trackPath := fmt.Sprintf("%s.%s.mp4", strings.TrimSuffix(outputPath, ".mp4"), name)
+	err := traceStep(ctx, "ffmpeg.concat_audio", func(ctx context.Context) error {
+		return b.ffmpeg.ConcatSegments(ctx, filepath.Join(dir, "init.mp4"), paths, trackPath)
+	}, attribute.String("track", name), attribute.Int("segments", len(paths)))
+	if err != nil {
+		return "", fmt.Errorf("concat audio track %s: %w", name, err)
+	}
+	return trackPath, nil
+}
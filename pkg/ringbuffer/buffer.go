@@ -4,29 +4,72 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"github.com/video-system/go-video-capture/pkg/jobs"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+var logger = logging.For("ringbuffer")
+
 // Config holds ring buffer configuration
 type Config struct {
 	Duration      time.Duration // How long to keep segments (e.g., 30m)
+	MaxBytes      int64         // Quota for captured segments; oldest are evicted beyond it (0 = none)
+	InMemory      bool          // Hold finished segments in RAM and remove their files
+	Offload       ObjectStore   // Where aged segments move (nil = keep them local)
+	OffloadAfter  time.Duration // Segment age at which it is offloaded
 	SegmentSize   time.Duration // Duration of each segment (e.g., 2s)
 	Path          string        // Storage path for segments
+	WorkPath      string        // Where FFmpeg writes segments, e.g. a tmpfs (default: Path)
 	RecordingPath string        // Path for full session recording (optional)
 	ChannelID     string        // Channel identifier
+	ClipEncoder   string        // FFmpeg encoder for clip export (empty = stream copy)
+	ClipBitrate   int           // Clip export bitrate in kbps (0 = encoder default)
+	TrimEncoder   string        // Re-encodes boundary GOPs for frame-accurate cuts (empty = cut on keyframes)
+	TrimBitrate   int           // Bitrate in kbps for re-encoded boundaries (0 = encoder default)
+	ClipVariants  []ClipVariant // Alternate aspect-ratio exports made alongside each clip
+	ClipOverlay   *ClipOverlay  // Text burned into every clip (nil = none)
+	PrimaryAudio  string        // Name of the audio track muxed with video
+}
+
+// ClipVariant is an alternate aspect-ratio export of every clip
+type ClipVariant struct {
+	Name   string // Suffix for the output file (e.g. "vertical")
+	Aspect string // Aspect ratio, e.g. "9:16"
+	Anchor string // Crop anchor: center, left, right, top, bottom or 0-1
+}
+
+// ClipOptions adjusts a single clip export
+type ClipOptions struct {
+	Rate        float64 // Playback rate for slow motion, e.g. 0.5 (0 = normal speed)
+	Interpolate bool    // Synthesize in-between frames when slowing down
+	NoVariants  bool    // Skip aspect-ratio variants, e.g. for intermediate files
+
+	// Audio: muted, or the listed tracks mixed at their levels (none = the
+	// track muxed with video, unchanged)
+	Mute  bool
+	Audio []ClipAudioTrack
+
+	// Values for the overlay template, e.g. "session" or "tags.score"
+	OverlayFields map[string]string
 }
 
 // Buffer manages a ring buffer of CMAF segments
 type Buffer struct {
 	cfg    Config
 	ffmpeg *ffmpeg.FFmpeg
+	logger *slog.Logger
 
 	mu          sync.RWMutex
 	segments    map[int]*Segment // sequence -> segment
@@ -35,12 +78,32 @@ type Buffer struct {
 	initSegment string // Path to init.mp4
 	startTime   time.Time
 
+	// Segments imported from external recordings, by start time
+	imported []*Segment
+
+	// Newest segment, left on disk until the next one shows it is complete
+	// (in-memory mode)
+	pending *Segment
+
+	// Generated clips
+	clips *clipCatalog
+
+	// Serializes index writes so the newest snapshot lands last
+	indexMu sync.Mutex
+
 	// Ghost-clipping state
 	ghostMu      sync.RWMutex
 	activeGhosts map[string]*GhostClip
 
+	// Holes between segments still in the buffer, oldest first
+	gaps []Gap
+
+	// Discontinuities aged out before the oldest segment
+	prunedDiscontinuities int
+
 	// Event callbacks
 	onSegment      func(*Segment)
+	onGap          func(Gap)
 	onGhostSegment func(playID string, seg *Segment)
 
 	// Lifecycle
@@ -55,14 +118,27 @@ type Segment struct {
 	StartTime time.Time     `json:"start_time"`
 	Duration  time.Duration `json:"duration"`
 	SizeBytes int64         `json:"size_bytes"`
+	InitPath  string        `json:"init_path,omitempty"` // Own init segment of imported footage or an earlier encode (empty = the buffer's)
+	Remote    string        `json:"remote,omitempty"`    // Object key once offloaded; the local file is gone
+
+	// Follows a sequence gap or a change of init segment
+	Discontinuity bool `json:"discontinuity,omitempty"`
+
+	data []byte // Contents, once moved to memory
 }
 
 // GhostClip tracks an active ghost clip
 type GhostClip struct {
-	PlayID    string
-	StartTime time.Time
-	StartSeq  int   // First segment sequence
-	Segments  []int // Sequence numbers included
+	PlayID    string    `json:"play_id"`
+	StartTime time.Time `json:"start_time"`
+	StartSeq  int       `json:"start_seq"` // First segment sequence
+	Segments  []int     `json:"-"`         // Sequence numbers included
+
+	// Restored from the index after a restart. Capture numbers on from the
+	// segment files left in its work directory, which don't survive a
+	// reboot on tmpfs; numbering then starts below StartSeq again, so the
+	// clip's segments are picked by time instead.
+	restored bool
 }
 
 // New creates a new ring buffer
@@ -74,16 +150,44 @@ func New(cfg Config, ff *ffmpeg.FFmpeg) (*Buffer, error) {
 	if err := os.MkdirAll(filepath.Join(cfg.Path, "clips"), 0755); err != nil {
 		return nil, fmt.Errorf("create clips path: %w", err)
 	}
+	clips, err := loadCatalog(filepath.Join(cfg.Path, "clips"))
+	if err != nil {
+		return nil, err
+	}
 
 	return &Buffer{
 		cfg:          cfg,
 		ffmpeg:       ff,
+		logger:       logger.With("channel", cfg.ChannelID),
 		segments:     make(map[int]*Segment),
 		activeGhosts: make(map[string]*GhostClip),
+		clips:        clips,
 		startTime:    time.Now(),
 	}, nil
 }
 
+// Open loads an existing buffer directory for offline clip extraction. No
+// capture or cleanup runs, so segments are never removed.
+func Open(path string, ff *ffmpeg.FFmpeg) (*Buffer, error) {
+	if _, err := os.Stat(filepath.Join(path, "index.json")); err != nil {
+		return nil, fmt.Errorf("no buffer index in %s: %w", path, err)
+	}
+	b, err := New(Config{Path: path}, ff)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.loadExistingSegments(); err != nil {
+		return nil, err
+	}
+	if len(b.segments) == 0 {
+		return nil, fmt.Errorf("no segments in %s", path)
+	}
+	if b.initSegment == "" {
+		return nil, fmt.Errorf("no init segment in %s", path)
+	}
+	return b, nil
+}
+
 // OnSegment sets callback for new segments
 func (b *Buffer) OnSegment(fn func(*Segment)) {
 	b.onSegment = fn
@@ -99,15 +203,14 @@ func (b *Buffer) Start(ctx context.Context) error {
 	b.ctx, b.cancel = context.WithCancel(ctx)
 	b.startTime = time.Now()
 
-	log.Printf("Ring buffer started (path: %s, duration: %v, segment: %v)",
-		b.cfg.Path, b.cfg.Duration, b.cfg.SegmentSize)
+	b.logger.Info("Ring buffer started", "path", b.cfg.Path, "duration", b.cfg.Duration, "segment", b.cfg.SegmentSize)
 
 	// Start cleanup goroutine
-	go b.cleanupLoop()
+	recovery.Go("buffer cleanup", b.cleanupLoop)
 
 	// Load any existing segments from disk
 	if err := b.loadExistingSegments(); err != nil {
-		log.Printf("Warning: failed to load existing segments: %v", err)
+		b.logger.Warn("Failed to load existing segments", "error", err)
 	}
 
 	return nil
@@ -119,13 +222,17 @@ func (b *Buffer) Stop() {
 		b.cancel()
 	}
 	b.saveIndex()
-	log.Println("Ring buffer stopped")
+	b.logger.Info("Ring buffer stopped")
 }
 
 // AddSegment adds a new segment to the buffer
 func (b *Buffer) AddSegment(seg *Segment) {
 	b.mu.Lock()
 
+	gap, hasGap := b.detectGapLocked(seg)
+	if prev, ok := b.segments[b.lastSeq]; ok && seg.Sequence > b.lastSeq {
+		seg.Discontinuity = seg.Sequence != prev.Sequence+1 || seg.InitPath != prev.InitPath
+	}
 	b.segments[seg.Sequence] = seg
 	if b.firstSeq == 0 || seg.Sequence < b.firstSeq {
 		b.firstSeq = seg.Sequence
@@ -133,10 +240,25 @@ func (b *Buffer) AddSegment(seg *Segment) {
 	if seg.Sequence > b.lastSeq {
 		b.lastSeq = seg.Sequence
 	}
+	var complete *Segment
+	if b.cfg.InMemory {
+		complete, b.pending = b.pending, seg
+	}
 
 	b.mu.Unlock()
 
+	if complete != nil {
+		b.moveToMemory(complete)
+	}
+
 	// Notify callbacks
+	if hasGap {
+		b.logger.Warn("Gap in buffer", "after_seq", gap.AfterSeq, "before_seq", gap.BeforeSeq,
+			"missing", gap.Missing, "duration", gap.Duration)
+		if b.onGap != nil {
+			b.onGap(gap)
+		}
+	}
 	if b.onSegment != nil {
 		b.onSegment(seg)
 	}
@@ -146,10 +268,53 @@ func (b *Buffer) AddSegment(seg *Segment) {
 
 	// Periodically save index
 	if seg.Sequence%10 == 0 {
-		go b.saveIndex()
+		recovery.Go("buffer index", b.saveIndex)
+	}
+}
+
+// moveToMemory reads a finished segment into RAM and removes its file. On
+// failure the segment stays on disk.
+func (b *Buffer) moveToMemory(seg *Segment) {
+	data, err := os.ReadFile(seg.FilePath)
+	if err != nil {
+		b.logger.Warn("Failed to load segment into memory", "seq", seg.Sequence, "error", err)
+		return
+	}
+	b.mu.Lock()
+	seg.data = data
+	b.mu.Unlock()
+	if err := os.Remove(seg.FilePath); err != nil {
+		b.logger.Warn("Failed to remove segment file", "seq", seg.Sequence, "error", err)
 	}
 }
 
+// SegmentData returns a segment held in memory or fetched back from object
+// storage. It returns false for segments on local disk or no longer buffered.
+func (b *Buffer) SegmentData(seq int) ([]byte, bool) {
+	b.mu.RLock()
+	seg, ok := b.segments[seq]
+	var data []byte
+	if ok {
+		data = seg.data
+	}
+	b.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if data != nil {
+		return data, true
+	}
+	if seg.Remote == "" {
+		return nil, false
+	}
+	data, err := b.fetchSegment(b.ctx, seg)
+	if err != nil {
+		b.logger.Warn("Failed to serve offloaded segment", "seq", seq, "error", err)
+		return nil, false
+	}
+	return data, true
+}
+
 // SetInitSegment sets the path to the init.mp4 segment
 func (b *Buffer) SetInitSegment(path string) {
 	b.mu.Lock()
@@ -164,6 +329,50 @@ func (b *Buffer) GetInitSegment() string {
 	return b.initSegment
 }
 
+// KeepInitSegment copies the init segment aside for the segments captured
+// so far, before a writer with other encode settings replaces it. The copy,
+// init_NNNNN.mp4 after the last segment it covers, goes once they age out.
+func (b *Buffer) KeepInitSegment() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var segments []*Segment
+	for _, seg := range b.segments {
+		if seg.InitPath == "" {
+			segments = append(segments, seg)
+		}
+	}
+	if b.initSegment == "" || len(segments) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(b.initSegment)
+	if err != nil {
+		return fmt.Errorf("read init segment: %w", err)
+	}
+	path := filepath.Join(b.workPath(), fmt.Sprintf("init_%05d.mp4", b.lastSeq))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("keep init segment: %w", err)
+	}
+	for _, seg := range segments {
+		seg.InitPath = path
+	}
+	b.logger.Info("Kept init segment for earlier footage", "path", path, "segments", len(segments))
+	return nil
+}
+
+// removeKeptInitsLocked deletes init segments kept for footage that has
+// aged out
+func (b *Buffer) removeKeptInitsLocked() {
+	paths, _ := filepath.Glob(filepath.Join(b.workPath(), "init_*.mp4"))
+	for _, path := range paths {
+		var last int
+		if n, _ := fmt.Sscanf(filepath.Base(path), "init_%05d.mp4", &last); n == 1 && last < b.firstSeq {
+			os.Remove(path)
+		}
+	}
+}
+
 // GetStatus returns the current buffer status
 func (b *Buffer) GetStatus() BufferStatus {
 	b.mu.RLock()
@@ -181,6 +390,13 @@ func (b *Buffer) GetStatus() BufferStatus {
 		}
 	}
 
+	offloaded := 0
+	for _, seg := range b.segments {
+		if seg.Remote != "" {
+			offloaded++
+		}
+	}
+
 	maxSegments := int(b.cfg.Duration / b.cfg.SegmentSize)
 	health := float64(len(b.segments)) / float64(maxSegments)
 	if health > 1 {
@@ -196,6 +412,9 @@ func (b *Buffer) GetStatus() BufferStatus {
 		LastSeq:      b.lastSeq,
 		InitSegment:  b.initSegment,
 		ChannelID:    b.cfg.ChannelID,
+		Imported:     len(b.imported),
+		Offloaded:    offloaded,
+		Gaps:         append([]Gap(nil), b.gaps...),
 	}
 }
 
@@ -207,7 +426,31 @@ func (b *Buffer) GetSegment(seq int) (*Segment, bool) {
 	return seg, ok
 }
 
-// GetSegmentsInRange returns segments within a time range
+// ListSegments returns all buffered segments in sequence order
+func (b *Buffer) ListSegments() []*Segment {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	segments := make([]*Segment, 0, len(b.segments))
+	for _, seg := range b.segments {
+		segments = append(segments, seg)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Sequence < segments[j].Sequence
+	})
+	return segments
+}
+
+// StartTime returns the buffer's time origin: when it started, or the
+// oldest segment loaded from disk if that is earlier
+func (b *Buffer) StartTime() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.startTime
+}
+
+// GetSegmentsInRange returns segments within a time range, including
+// imported footage, in time order
 func (b *Buffer) GetSegmentsInRange(startTime, endTime time.Time) []*Segment {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -223,11 +466,29 @@ func (b *Buffer) GetSegmentsInRange(startTime, endTime time.Time) []*Segment {
 			result = append(result, seg)
 		}
 	}
+
+	imported := false
+	for _, seg := range b.imported {
+		if seg.StartTime.Before(endTime) && seg.StartTime.Add(seg.Duration).After(startTime) {
+			result = append(result, seg)
+			imported = true
+		}
+	}
+	if imported {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].StartTime.Before(result[j].StartTime)
+		})
+	}
 	return result
 }
 
 // GenerateClip extracts a clip from the buffer
-func (b *Buffer) GenerateClip(ctx context.Context, startMs, endMs int64, playID string) (*ClipResult, error) {
+func (b *Buffer) GenerateClip(ctx context.Context, startMs, endMs int64, playID string, opts ClipOptions) (_ *ClipResult, err error) {
+	ctx, span := tracing.Start(ctx, "clip.generate",
+		attribute.String("channel", b.cfg.ChannelID),
+		attribute.String("play_id", playID))
+	defer func() { tracing.End(span, err) }()
+
 	startTime := time.UnixMilli(startMs)
 	endTime := time.UnixMilli(endMs)
 
@@ -237,14 +498,10 @@ func (b *Buffer) GenerateClip(ctx context.Context, startMs, endMs int64, playID
 		return nil, fmt.Errorf("no segments found for time range %v - %v", startTime, endTime)
 	}
 
-	// Collect segment paths
-	var segPaths []string
-	for _, seg := range segments {
-		segPaths = append(segPaths, seg.FilePath)
-	}
+	span.SetAttributes(segmentAttrs(segments)...)
 
 	// Output path
-	outputPath := filepath.Join(b.cfg.Path, "clips", fmt.Sprintf("%s.mp4", playID))
+	outputPath := b.ClipPath(playID)
 
 	// Calculate trim amounts
 	firstSeg := segments[0]
@@ -253,22 +510,62 @@ func (b *Buffer) GenerateClip(ctx context.Context, startMs, endMs int64, playID
 	trimEnd := (lastSeg.StartTime.Add(lastSeg.Duration)).Sub(endTime).Seconds()
 
 	// Concatenate segments
-	if err := b.ffmpeg.ConcatSegments(ctx, b.initSegment, segPaths, outputPath); err != nil {
-		return nil, fmt.Errorf("concat segments: %w", err)
+	reportStage(ctx, "concat")
+	if err := b.concat(ctx, segments, outputPath); err != nil {
+		return nil, err
+	}
+	reportStage(ctx, "audio")
+	if err := b.remixAudio(ctx, outputPath, segments, opts); err != nil {
+		return nil, err
 	}
 
-	// Trim if needed (more than 0.1 second off)
-	if trimStart > 0.1 || trimEnd > 0.1 {
+	// Trim if needed (more than 0.1 second off, or any amount when cutting
+	// frame-accurately)
+	tolerance := 0.1
+	if b.cfg.TrimEncoder != "" {
+		tolerance = 0.001
+	}
+	clipStart := firstSeg.StartTime
+	if trimStart > tolerance || trimEnd > tolerance {
+		if trimStart > 0 {
+			clipStart = startTime
+		}
+		reportStage(ctx, "trim")
 		tempPath := outputPath + ".temp.mp4"
 		os.Rename(outputPath, tempPath)
 		defer os.Remove(tempPath)
 
 		duration := endTime.Sub(startTime).Seconds()
-		if err := b.ffmpeg.TrimClip(ctx, tempPath, outputPath, trimStart, duration); err != nil {
+		err := traceStep(ctx, "ffmpeg.trim", func(ctx context.Context) error {
+			if b.cfg.TrimEncoder != "" {
+				return b.ffmpeg.SmartTrimClip(ctx, tempPath, outputPath, trimStart, duration, b.cfg.TrimEncoder, b.cfg.TrimBitrate)
+			}
+			return b.ffmpeg.TrimClip(ctx, tempPath, outputPath, trimStart, duration)
+		}, attribute.Float64("trim_start_s", trimStart), attribute.Float64("duration_s", duration),
+			attribute.Bool("frame_accurate", b.cfg.TrimEncoder != ""))
+		if err != nil {
 			return nil, fmt.Errorf("trim clip: %w", err)
 		}
 	}
 
+	reportStage(ctx, "transcode")
+	if err := b.transcodeClip(ctx, outputPath); err != nil {
+		return nil, err
+	}
+	reportStage(ctx, "overlay")
+	if err := b.overlay(ctx, outputPath, playID, clipStart, opts); err != nil {
+		return nil, err
+	}
+	reportStage(ctx, "slow_motion")
+	if err := b.slowMotion(ctx, outputPath, opts); err != nil {
+		return nil, err
+	}
+	var variants []ClipVariantResult
+	if !opts.NoVariants {
+		reportStage(ctx, "variants")
+		variants = b.exportVariants(ctx, outputPath, playID)
+	}
+
 	// Get file info
 	info, err := os.Stat(outputPath)
 	if err != nil {
@@ -277,21 +574,34 @@ func (b *Buffer) GenerateClip(ctx context.Context, startMs, endMs int64, playID
 
 	// Get actual duration from ffprobe
 	actualDuration := endTime.Sub(startTime).Seconds()
-	if videoInfo, err := b.ffmpeg.GetVideoInfo(ctx, outputPath); err == nil && videoInfo.Duration > 0 {
+	if opts.Rate > 0 {
+		actualDuration /= opts.Rate
+	}
+	if videoInfo, err := b.probe(ctx, outputPath); err == nil && videoInfo.Duration > 0 {
 		actualDuration = videoInfo.Duration
 	}
+	span.SetAttributes(attribute.Int64("output_bytes", info.Size()))
 
-	return &ClipResult{
+	result := &ClipResult{
 		FilePath:      outputPath,
 		Duration:      actualDuration,
 		FileSizeBytes: info.Size(),
 		SegmentCount:  len(segments),
-	}, nil
+		PlaybackRate:  opts.Rate,
+		Variants:      variants,
+	}
+	b.catalogClip(playID, startTime, endTime, result)
+	return result, nil
 }
 
 // GenerateClipFromSegments creates a clip from specific segment sequence numbers
 // This is used for ghost clips where we track segments by sequence rather than time
-func (b *Buffer) GenerateClipFromSegments(ctx context.Context, seqNumbers []int, playID string) (*ClipResult, error) {
+func (b *Buffer) GenerateClipFromSegments(ctx context.Context, seqNumbers []int, playID string, opts ClipOptions) (_ *ClipResult, err error) {
+	ctx, span := tracing.Start(ctx, "clip.generate",
+		attribute.String("channel", b.cfg.ChannelID),
+		attribute.String("play_id", playID))
+	defer func() { tracing.End(span, err) }()
+
 	if len(seqNumbers) == 0 {
 		return nil, fmt.Errorf("no segments provided")
 	}
@@ -309,18 +619,37 @@ func (b *Buffer) GenerateClipFromSegments(ctx context.Context, seqNumbers []int,
 		return nil, fmt.Errorf("no valid segments found for sequences %v", seqNumbers)
 	}
 
-	// Collect segment paths
-	var segPaths []string
-	for _, seg := range segments {
-		segPaths = append(segPaths, seg.FilePath)
-	}
+	span.SetAttributes(segmentAttrs(segments)...)
 
 	// Output path
-	outputPath := filepath.Join(b.cfg.Path, "clips", fmt.Sprintf("%s.mp4", playID))
+	outputPath := b.ClipPath(playID)
 
 	// Concatenate segments (no trimming needed for ghost clips)
-	if err := b.ffmpeg.ConcatSegments(ctx, b.initSegment, segPaths, outputPath); err != nil {
-		return nil, fmt.Errorf("concat segments: %w", err)
+	reportStage(ctx, "concat")
+	if err := b.concat(ctx, segments, outputPath); err != nil {
+		return nil, err
+	}
+	reportStage(ctx, "audio")
+	if err := b.remixAudio(ctx, outputPath, segments, opts); err != nil {
+		return nil, err
+	}
+
+	reportStage(ctx, "transcode")
+	if err := b.transcodeClip(ctx, outputPath); err != nil {
+		return nil, err
+	}
+	reportStage(ctx, "overlay")
+	if err := b.overlay(ctx, outputPath, playID, segments[0].StartTime, opts); err != nil {
+		return nil, err
+	}
+	reportStage(ctx, "slow_motion")
+	if err := b.slowMotion(ctx, outputPath, opts); err != nil {
+		return nil, err
+	}
+	var variants []ClipVariantResult
+	if !opts.NoVariants {
+		reportStage(ctx, "variants")
+		variants = b.exportVariants(ctx, outputPath, playID)
 	}
 
 	// Get file info
@@ -330,8 +659,9 @@ func (b *Buffer) GenerateClipFromSegments(ctx context.Context, seqNumbers []int,
 	}
 
 	// Get actual duration from ffprobe (more accurate than segment count * 2s)
+	span.SetAttributes(attribute.Int64("output_bytes", info.Size()))
 	var actualDuration float64
-	if videoInfo, err := b.ffmpeg.GetVideoInfo(ctx, outputPath); err == nil && videoInfo.Duration > 0 {
+	if videoInfo, err := b.probe(ctx, outputPath); err == nil && videoInfo.Duration > 0 {
 		actualDuration = videoInfo.Duration
 	} else {
 		// Fallback to estimated duration from segments
@@ -340,14 +670,251 @@ func (b *Buffer) GenerateClipFromSegments(ctx context.Context, seqNumbers []int,
 			totalDuration += seg.Duration
 		}
 		actualDuration = totalDuration.Seconds()
+		if opts.Rate > 0 {
+			actualDuration /= opts.Rate
+		}
 	}
 
-	return &ClipResult{
+	result := &ClipResult{
 		FilePath:      outputPath,
 		Duration:      actualDuration,
 		FileSizeBytes: info.Size(),
 		SegmentCount:  len(segments),
-	}, nil
+		PlaybackRate:  opts.Rate,
+		Variants:      variants,
+	}
+	last := segments[len(segments)-1]
+	b.catalogClip(playID, segments[0].StartTime, last.StartTime.Add(last.Duration), result)
+	return result, nil
+}
+
+// workPath returns the directory FFmpeg writes segments to
+func (b *Buffer) workPath() string {
+	if b.cfg.WorkPath != "" {
+		return b.cfg.WorkPath
+	}
+	return b.cfg.Path
+}
+
+// ClipPath returns where the clip for playID is written
+func (b *Buffer) ClipPath(playID string) string {
+	return filepath.Join(b.cfg.Path, "clips", fmt.Sprintf("%s.mp4", playID))
+}
+
+// exportVariants crops the finished master clip into each configured aspect
+// ratio. A failed variant is logged and skipped so the master still ships.
+func (b *Buffer) exportVariants(ctx context.Context, masterPath, playID string) []ClipVariantResult {
+	var results []ClipVariantResult
+	for _, v := range b.cfg.ClipVariants {
+		outputPath := filepath.Join(b.cfg.Path, "clips", fmt.Sprintf("%s_%s.mp4", playID, v.Name))
+		err := traceStep(ctx, "ffmpeg.crop", func(ctx context.Context) error {
+			return b.ffmpeg.CropClip(ctx, masterPath, outputPath, v.Aspect, v.Anchor, b.cfg.ClipEncoder, b.cfg.ClipBitrate)
+		}, attribute.String("variant", v.Name), attribute.String("aspect", v.Aspect))
+		if err != nil {
+			b.logger.Warn("Clip variant failed", "play_id", playID, "variant", v.Name, "error", err)
+			continue
+		}
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			continue
+		}
+		results = append(results, ClipVariantResult{
+			Name:          v.Name,
+			AspectRatio:   v.Aspect,
+			FilePath:      outputPath,
+			FileSizeBytes: info.Size(),
+		})
+	}
+	return results
+}
+
+// transcodeClip re-encodes a finished clip in place when a clip encoder is configured
+func (b *Buffer) transcodeClip(ctx context.Context, outputPath string) error {
+	if b.cfg.ClipEncoder == "" {
+		return nil
+	}
+
+	tempPath := outputPath + ".src.mp4"
+	if err := os.Rename(outputPath, tempPath); err != nil {
+		return fmt.Errorf("prepare transcode: %w", err)
+	}
+	defer os.Remove(tempPath)
+
+	err := traceStep(ctx, "ffmpeg.transcode", func(ctx context.Context) error {
+		return b.ffmpeg.TranscodeClip(ctx, tempPath, outputPath, b.cfg.ClipEncoder, b.cfg.ClipBitrate)
+	}, attribute.String("encoder", b.cfg.ClipEncoder))
+	if err != nil {
+		return fmt.Errorf("transcode clip: %w", err)
+	}
+	return nil
+}
+
+// slowMotion re-encodes a finished clip in place at the requested playback
+// rate. Normal-speed clips are left alone.
+func (b *Buffer) slowMotion(ctx context.Context, outputPath string, opts ClipOptions) error {
+	if opts.Rate <= 0 || opts.Rate == 1 {
+		return nil
+	}
+
+	var fps float64
+	if opts.Interpolate {
+		info, err := b.probe(ctx, outputPath)
+		if err != nil {
+			return fmt.Errorf("probe clip frame rate: %w", err)
+		}
+		fps = info.Framerate
+	}
+
+	tempPath := outputPath + ".src.mp4"
+	if err := os.Rename(outputPath, tempPath); err != nil {
+		return fmt.Errorf("prepare slow motion: %w", err)
+	}
+	defer os.Remove(tempPath)
+
+	err := traceStep(ctx, "ffmpeg.slow_motion", func(ctx context.Context) error {
+		return b.ffmpeg.SlowMotionClip(ctx, tempPath, outputPath, opts.Rate, fps, opts.Interpolate, !opts.Mute, b.cfg.ClipEncoder, b.cfg.ClipBitrate)
+	}, attribute.Float64("rate", opts.Rate), attribute.Bool("interpolate", opts.Interpolate))
+	if err != nil {
+		return fmt.Errorf("slow motion clip: %w", err)
+	}
+	return nil
+}
+
+// concat joins segments into outputPath. The segment files are checked up
+// front in their own span so slow or missing storage shows up separately
+// from FFmpeg.
+func (b *Buffer) concat(ctx context.Context, segments []*Segment, outputPath string) error {
+	err := traceStep(ctx, "disk.check_segments", func(context.Context) error {
+		for _, seg := range segments {
+			if !b.segmentLocal(seg) {
+				continue
+			}
+			if _, err := os.Stat(seg.FilePath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, segmentAttrs(segments)...)
+	if err != nil {
+		return fmt.Errorf("segment missing: %w", err)
+	}
+
+	// Imported footage has its own init segment
+	runs := b.splitRuns(segments)
+	if len(runs) > 1 {
+		return b.joinRuns(ctx, runs, outputPath)
+	}
+
+	err = traceStep(ctx, "ffmpeg.concat", func(ctx context.Context) error {
+		return b.concatRun(ctx, runs[0], outputPath)
+	}, attribute.Int("segments", len(segments)))
+	if err != nil {
+		return fmt.Errorf("concat segments: %w", err)
+	}
+	return nil
+}
+
+// concatRun joins one run of segments, reading those held in memory from RAM
+// and fetching offloaded ones back
+func (b *Buffer) concatRun(ctx context.Context, run segmentRun, outputPath string) error {
+	data := make([][]byte, len(run.segments))
+	local := true
+	b.mu.RLock()
+	for i, seg := range run.segments {
+		data[i] = seg.data
+		local = local && seg.data == nil && seg.Remote == ""
+	}
+	b.mu.RUnlock()
+
+	if local {
+		paths := make([]string, len(run.segments))
+		for i, seg := range run.segments {
+			paths[i] = seg.FilePath
+		}
+		return b.ffmpeg.ConcatSegments(ctx, run.init, paths, outputPath)
+	}
+	for i, seg := range run.segments {
+		if data[i] != nil {
+			continue
+		}
+		var err error
+		if data[i], err = b.readSegment(ctx, seg); err != nil {
+			return err
+		}
+	}
+	return b.ffmpeg.ConcatSegmentData(ctx, run.init, data, outputPath)
+}
+
+// readSegment returns a segment's contents from memory, the object store
+// or local disk
+func (b *Buffer) readSegment(ctx context.Context, seg *Segment) ([]byte, error) {
+	b.mu.RLock()
+	data, remote := seg.data, seg.Remote
+	b.mu.RUnlock()
+	if data != nil {
+		return data, nil
+	}
+	if remote != "" {
+		return b.fetchSegment(ctx, seg)
+	}
+	data, err := os.ReadFile(seg.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("read segment %s: %w", seg.FilePath, err)
+	}
+	return data, nil
+}
+
+// segmentLocal reports whether a segment is a file on local storage, not
+// in memory or offloaded
+func (b *Buffer) segmentLocal(seg *Segment) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return seg.data == nil && seg.Remote == ""
+}
+
+// probe reads clip info with ffprobe inside a span
+func (b *Buffer) probe(ctx context.Context, path string) (info *ffmpeg.VideoInfo, err error) {
+	err = traceStep(ctx, "ffmpeg.probe", func(ctx context.Context) error {
+		info, err = b.ffmpeg.GetVideoInfo(ctx, path)
+		return err
+	})
+	return info, err
+}
+
+// clipStages is the share of a clip export done when each step starts
+var clipStages = map[string]float64{
+	"concat":      0.05,
+	"audio":       0.35,
+	"trim":        0.45,
+	"transcode":   0.55,
+	"overlay":     0.65,
+	"slow_motion": 0.75,
+	"variants":    0.85,
+}
+
+// reportStage updates the progress of the clip job running in ctx, if any
+func reportStage(ctx context.Context, stage string) {
+	jobs.Report(ctx, stage, clipStages[stage])
+}
+
+// traceStep runs fn inside a child span
+func traceStep(ctx context.Context, name string, fn func(context.Context) error, attrs ...attribute.KeyValue) error {
+	ctx, span := tracing.Start(ctx, name, attrs...)
+	err := fn(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// segmentAttrs describes the input segments of a clip
+func segmentAttrs(segments []*Segment) []attribute.KeyValue {
+	var size int64
+	for _, seg := range segments {
+		size += seg.SizeBytes
+	}
+	return []attribute.KeyValue{
+		attribute.Int("segments", len(segments)),
+		attribute.Int64("input_bytes", size),
+	}
 }
 
 // StartGhostClip begins ghost-clipping for a play
@@ -370,12 +937,51 @@ func (b *Buffer) StartGhostClip(playID string) error {
 		Segments:  make([]int, 0),
 	}
 
-	log.Printf("Ghost clip started: %s (from seq %d)", playID, startSeq)
+	b.logger.Info("Ghost clip started", "play_id", playID, "seq", startSeq)
+	recovery.Go("buffer index", b.saveIndex)
+	return nil
+}
+
+// ResumeGhostClip starts ghost-clipping for a play marked in at start, e.g.
+// on another agent, including the buffered segments since then
+func (b *Buffer) ResumeGhostClip(playID string, start time.Time) error {
+	b.ghostMu.Lock()
+	defer b.ghostMu.Unlock()
+
+	if _, exists := b.activeGhosts[playID]; exists {
+		return fmt.Errorf("ghost clip already active: %s", playID)
+	}
+
+	b.mu.RLock()
+	startSeq := b.lastSeq
+	for seq := b.firstSeq; seq <= b.lastSeq; seq++ {
+		if seg, ok := b.segments[seq]; ok && seg.StartTime.Add(seg.Duration).After(start) {
+			startSeq = seq
+			break
+		}
+	}
+	b.mu.RUnlock()
+
+	b.activeGhosts[playID] = &GhostClip{
+		PlayID:    playID,
+		StartTime: start,
+		StartSeq:  startSeq,
+		Segments:  make([]int, 0),
+	}
+
+	b.logger.Info("Ghost clip resumed", "play_id", playID, "seq", startSeq, "start", start)
+	recovery.Go("buffer index", b.saveIndex)
 	return nil
 }
 
 // EndGhostClip ends ghost-clipping for a play and returns segment info
 func (b *Buffer) EndGhostClip(playID string) (*GhostClipResult, error) {
+	return b.EndGhostClipAt(playID, time.Time{})
+}
+
+// EndGhostClipAt ends ghost-clipping for a play marked out at end, leaving
+// out segments that start after it. A zero end means now.
+func (b *Buffer) EndGhostClipAt(playID string, end time.Time) (*GhostClipResult, error) {
 	b.ghostMu.Lock()
 	defer b.ghostMu.Unlock()
 
@@ -390,26 +996,59 @@ func (b *Buffer) EndGhostClip(playID string) (*GhostClipResult, error) {
 	b.mu.RLock()
 	endSeq := b.lastSeq
 	var segments []int
-	for seq := ghost.StartSeq; seq <= endSeq; seq++ {
-		if _, ok := b.segments[seq]; ok {
-			segments = append(segments, seq)
+	if ghost.restored {
+		segments = b.segmentsSinceLocked(ghost.StartTime)
+	} else {
+		for seq := ghost.StartSeq; seq <= endSeq; seq++ {
+			if _, ok := b.segments[seq]; ok {
+				segments = append(segments, seq)
+			}
+		}
+	}
+	if !end.IsZero() {
+		n := 0
+		for _, seq := range segments {
+			if seg := b.segments[seq]; seg != nil && seg.StartTime.Before(end) {
+				segments[n] = seq
+				n++
+			}
 		}
+		segments = segments[:n]
+	} else {
+		end = time.Now()
 	}
 	b.mu.RUnlock()
 
 	result := &GhostClipResult{
 		PlayID:       playID,
 		StartTime:    ghost.StartTime,
-		EndTime:      time.Now(),
+		EndTime:      end,
 		SegmentCount: len(segments),
 		Segments:     segments,
 	}
 
-	log.Printf("Ghost clip ended: %s (segments: %d, seq %d-%d)", playID, len(segments), ghost.StartSeq, endSeq)
+	b.logger.Info("Ghost clip ended", "play_id", playID, "segments", len(segments), "start_seq", ghost.StartSeq, "end_seq", endSeq)
 	delete(b.activeGhosts, playID)
+	recovery.Go("buffer index", b.saveIndex)
 	return result, nil
 }
 
+// CancelGhostClip discards an active ghost clip without producing a result
+func (b *Buffer) CancelGhostClip(playID string) error {
+	b.ghostMu.Lock()
+	defer b.ghostMu.Unlock()
+
+	ghost, exists := b.activeGhosts[playID]
+	if !exists {
+		return fmt.Errorf("ghost clip not found: %s", playID)
+	}
+	delete(b.activeGhosts, playID)
+
+	b.logger.Info("Ghost clip cancelled", "play_id", playID, "start_seq", ghost.StartSeq)
+	recovery.Go("buffer index", b.saveIndex)
+	return nil
+}
+
 // GetActiveGhostClips returns list of active ghost clip IDs
 func (b *Buffer) GetActiveGhostClips() []string {
 	b.ghostMu.RLock()
@@ -448,11 +1087,13 @@ func (b *Buffer) cleanupLoop() {
 			return
 		case <-ticker.C:
 			b.cleanup()
+			b.offload()
 		}
 	}
 }
 
-// cleanup removes segments older than duration
+// cleanup removes segments older than duration, then the oldest segments
+// until the buffer fits its disk quota
 func (b *Buffer) cleanup() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -469,14 +1110,39 @@ func (b *Buffer) cleanup() {
 	}
 
 	// Remove segments
+	var remote []string
 	for _, seq := range toRemove {
 		seg := b.segments[seq]
-		if err := os.Remove(seg.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("Warning: failed to remove segment file: %v", err)
+		if seg.Remote != "" {
+			remote = append(remote, seg.Remote)
+		} else if err := os.Remove(seg.FilePath); err != nil && !os.IsNotExist(err) {
+			b.logger.Warn("Failed to remove segment file", "seq", seq, "error", err)
 		}
+		b.pruneDiscontinuityLocked(seg)
 		delete(b.segments, seq)
 		removed++
 	}
+	if len(remote) > 0 {
+		b.deleteRemote(remote)
+	}
+	removed += b.evictOverQuotaLocked()
+
+	// Imported footage expires the same way; drop an import's directory
+	// with its last segment
+	kept := b.imported[:0]
+	for _, seg := range b.imported {
+		if !seg.StartTime.Before(cutoff) {
+			kept = append(kept, seg)
+			continue
+		}
+		os.Remove(seg.FilePath)
+		removed++
+		if !slices.ContainsFunc(b.imported, func(s *Segment) bool { return s != seg && s.InitPath == seg.InitPath && !s.StartTime.Before(cutoff) }) {
+			os.RemoveAll(filepath.Dir(seg.InitPath))
+		}
+	}
+	clear(b.imported[len(kept):])
+	b.imported = kept
 
 	// Update firstSeq
 	if removed > 0 {
@@ -486,14 +1152,57 @@ func (b *Buffer) cleanup() {
 				break
 			}
 		}
-		log.Printf("Buffer cleanup: removed %d old segments (keeping %d)", removed, len(b.segments))
+		if first, ok := b.segments[b.firstSeq]; ok && first.Discontinuity {
+			// Not listed before the oldest segment any more
+			b.prunedDiscontinuities++
+			first.Discontinuity = false
+		}
+		b.pruneGapsLocked()
+		b.removeKeptInitsLocked()
+		b.logger.Debug("Buffer cleanup", "removed", removed, "kept", len(b.segments))
+	}
+}
+
+// evictOverQuotaLocked removes the oldest captured segments while the total
+// size of those held locally exceeds MaxBytes, always keeping the newest.
+// Offloaded segments don't count. It returns the number removed.
+func (b *Buffer) evictOverQuotaLocked() int {
+	if b.cfg.MaxBytes <= 0 {
+		return 0
+	}
+	var total int64
+	for _, seg := range b.segments {
+		if seg.Remote == "" {
+			total += seg.SizeBytes
+		}
+	}
+	if total <= b.cfg.MaxBytes {
+		return 0
+	}
+
+	removed := 0
+	for seq := b.firstSeq; seq < b.lastSeq && total > b.cfg.MaxBytes; seq++ {
+		seg, ok := b.segments[seq]
+		if !ok || seg.Remote != "" {
+			continue
+		}
+		if err := os.Remove(seg.FilePath); err != nil && !os.IsNotExist(err) {
+			b.logger.Warn("Failed to remove segment file", "seq", seq, "error", err)
+		}
+		b.pruneDiscontinuityLocked(seg)
+		delete(b.segments, seq)
+		total -= seg.SizeBytes
+		removed++
 	}
+	b.logger.Warn("Buffer over disk quota, evicted oldest segments",
+		"removed", removed, "size_bytes", total, "max_bytes", b.cfg.MaxBytes)
+	return removed
 }
 
 // loadExistingSegments loads segments from disk on startup
 func (b *Buffer) loadExistingSegments() error {
 	// Look for init.mp4
-	initPath := filepath.Join(b.cfg.Path, "init.mp4")
+	initPath := filepath.Join(b.workPath(), "init.mp4")
 	if _, err := os.Stat(initPath); err == nil {
 		b.initSegment = initPath
 	}
@@ -510,10 +1219,21 @@ func (b *Buffer) loadExistingSegments() error {
 		return fmt.Errorf("parse index: %w", err)
 	}
 
+	if b.cfg.ChannelID == "" {
+		b.cfg.ChannelID = index.ChannelID
+	}
+
 	// Validate and load segments
 	for _, seg := range index.Segments {
-		if _, err := os.Stat(seg.FilePath); err != nil {
-			continue // Segment file doesn't exist
+		if seg.Remote != "" && b.cfg.Offload == nil {
+			continue // Offloading since disabled
+		}
+		if _, err := os.Stat(seg.FilePath); err != nil && seg.Remote == "" {
+			// The directory may have been moved; look next to the index
+			seg.FilePath = filepath.Join(b.workPath(), filepath.Base(seg.FilePath))
+			if _, err := os.Stat(seg.FilePath); err != nil {
+				continue // Segment file doesn't exist
+			}
 		}
 		b.segments[seg.Sequence] = seg
 		if b.firstSeq == 0 || seg.Sequence < b.firstSeq {
@@ -522,14 +1242,80 @@ func (b *Buffer) loadExistingSegments() error {
 		if seg.Sequence > b.lastSeq {
 			b.lastSeq = seg.Sequence
 		}
+		if seg.StartTime.Before(b.startTime) {
+			b.startTime = seg.StartTime
+		}
 	}
 
-	log.Printf("Loaded %d existing segments from disk", len(b.segments))
+	for _, seg := range index.Imported {
+		if _, err := os.Stat(seg.FilePath); err == nil {
+			b.imported = append(b.imported, seg)
+		}
+	}
+
+	b.restoreGhostClips(index.Ghosts)
+
+	b.logger.Info("Loaded existing segments from disk", "count", len(b.segments), "imported", len(b.imported))
 	return nil
 }
 
+// restoreGhostClips resumes ghost clips that were active when the index was
+// last saved. Clips whose mark-in has aged out of the buffer are dropped.
+func (b *Buffer) restoreGhostClips(ghosts []*GhostClip) {
+	b.ghostMu.Lock()
+	defer b.ghostMu.Unlock()
+
+	for _, ghost := range ghosts {
+		if _, exists := b.activeGhosts[ghost.PlayID]; exists {
+			continue
+		}
+		if b.cfg.Duration > 0 && time.Since(ghost.StartTime) > b.cfg.Duration {
+			b.logger.Warn("Dropping expired ghost clip", "play_id", ghost.PlayID, "start", ghost.StartTime)
+			continue
+		}
+		ghost.Segments = make([]int, 0)
+		ghost.restored = true
+		b.activeGhosts[ghost.PlayID] = ghost
+		b.logger.Info("Ghost clip restored", "play_id", ghost.PlayID, "start", ghost.StartTime)
+	}
+}
+
+// segmentsSinceLocked returns the buffered segments ending after start,
+// in recording order
+func (b *Buffer) segmentsSinceLocked(start time.Time) []int {
+	var found []*Segment
+	for _, seg := range b.segments {
+		if seg.StartTime.Add(seg.Duration).After(start) {
+			found = append(found, seg)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].StartTime.Before(found[j].StartTime)
+	})
+	seqs := make([]int, len(found))
+	for i, seg := range found {
+		seqs[i] = seg.Sequence
+	}
+	return seqs
+}
+
 // saveIndex saves segment index to disk
 func (b *Buffer) saveIndex() {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+
+	// Snapshot ghost clips first: ghostMu is always taken before mu
+	b.ghostMu.RLock()
+	ghosts := make([]*GhostClip, 0, len(b.activeGhosts))
+	for _, ghost := range b.activeGhosts {
+		g := *ghost
+		ghosts = append(ghosts, &g)
+	}
+	b.ghostMu.RUnlock()
+	sort.Slice(ghosts, func(i, j int) bool {
+		return ghosts[i].StartTime.Before(ghosts[j].StartTime)
+	})
+
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
@@ -551,28 +1337,32 @@ func (b *Buffer) saveIndex() {
 		LastSeq:     b.lastSeq,
 		UpdatedAt:   time.Now(),
 		Segments:    segments,
+		Imported:    b.imported,
+		Ghosts:      ghosts,
 	}
 
 	data, err := json.MarshalIndent(index, "", "  ")
 	if err != nil {
-		log.Printf("Failed to marshal index: %v", err)
+		b.logger.Error("Failed to marshal index", "error", err)
 		return
 	}
 
 	indexPath := filepath.Join(b.cfg.Path, "index.json")
 	if err := os.WriteFile(indexPath, data, 0644); err != nil {
-		log.Printf("Failed to save index: %v", err)
+		b.logger.Error("Failed to save index", "error", err)
 	}
 }
 
 // segmentIndex is the on-disk index format
 type segmentIndex struct {
-	ChannelID   string     `json:"channel_id"`
-	InitSegment string     `json:"init_segment"`
-	FirstSeq    int        `json:"first_seq"`
-	LastSeq     int        `json:"last_seq"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	Segments    []*Segment `json:"segments"`
+	ChannelID   string       `json:"channel_id"`
+	InitSegment string       `json:"init_segment"`
+	FirstSeq    int          `json:"first_seq"`
+	LastSeq     int          `json:"last_seq"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Segments    []*Segment   `json:"segments"`
+	Imported    []*Segment   `json:"imported,omitempty"`
+	Ghosts      []*GhostClip `json:"ghosts,omitempty"` // Active ghost clips
 }
 
 // BufferStatus represents the buffer status
@@ -585,6 +1375,9 @@ type BufferStatus struct {
 	LastSeq      int     `json:"last_seq"`
 	InitSegment  string  `json:"init_segment"`
 	ChannelID    string  `json:"channel_id"`
+	Imported     int     `json:"imported_segments,omitempty"`  // Segments backfilled from external recordings
+	Offloaded    int     `json:"offloaded_segments,omitempty"` // Segments moved to object storage
+	Gaps         []Gap   `json:"gaps,omitempty"`               // Holes in the buffered footage
 }
 
 // ClipResult represents clip generation result
@@ -593,6 +1386,17 @@ type ClipResult struct {
 	Duration      float64 `json:"duration"`
 	FileSizeBytes int64   `json:"file_size_bytes"`
 	SegmentCount  int     `json:"segment_count"`
+	PlaybackRate  float64 `json:"playback_rate,omitempty"` // Slow-motion rate (0 = normal speed)
+
+	Variants []ClipVariantResult `json:"variants,omitempty"`
+}
+
+// ClipVariantResult is an exported aspect-ratio variant of a clip
+type ClipVariantResult struct {
+	Name          string `json:"name"`
+	AspectRatio   string `json:"aspect_ratio"`
+	FilePath      string `json:"file_path"`
+	FileSizeBytes int64  `json:"file_size_bytes"`
 }
 
 // GhostClipResult represents the result of ending a ghost clip
@@ -0,0 +1,209 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestBuffer(t *testing.T, cfg Config) *Buffer {
+	t.Helper()
+	cfg.Path = t.TempDir()
+	if cfg.SegmentSize == 0 {
+		cfg.SegmentSize = 2 * time.Second
+	}
+	b, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return b
+}
+
+// addTestSegment writes a segment file of size bytes and adds it
+func addTestSegment(t *testing.T, b *Buffer, seq int, start time.Time, size int) *Segment {
+	t.Helper()
+	path := filepath.Join(b.workPath(), fmt.Sprintf("segment_%05d.m4s", seq))
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	seg := &Segment{Sequence: seq, FilePath: path, StartTime: start, Duration: 2 * time.Second, SizeBytes: int64(size)}
+	b.AddSegment(seg)
+	return seg
+}
+
+func TestKeepInitSegment(t *testing.T) {
+	b := newTestBuffer(t, Config{Duration: time.Minute})
+	initPath := filepath.Join(b.workPath(), "init.mp4")
+	if err := os.WriteFile(initPath, []byte("h264"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b.SetInitSegment(initPath)
+
+	old := time.Now().Add(-time.Hour)
+	for seq := 1; seq <= 3; seq++ {
+		addTestSegment(t, b, seq, old.Add(time.Duration(seq)*2*time.Second), 10)
+	}
+	if err := b.KeepInitSegment(); err != nil {
+		t.Fatalf("KeepInitSegment: %v", err)
+	}
+	// The next writer replaces init.mp4
+	if err := os.WriteFile(initPath, []byte("hevc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	addTestSegment(t, b, 4, now, 10)
+	addTestSegment(t, b, 5, now.Add(2*time.Second), 10)
+
+	kept := filepath.Join(b.workPath(), "init_00003.mp4")
+	if data, err := os.ReadFile(kept); err != nil || string(data) != "h264" {
+		t.Fatalf("kept init = %q, %v; want the old one", data, err)
+	}
+	runs := b.splitRuns(b.ListSegments())
+	if len(runs) != 2 || runs[0].init != kept || len(runs[0].segments) != 3 || runs[1].init != initPath {
+		t.Errorf("runs = %+v, want 3 segments on the kept init, then the new one", runs)
+	}
+	if seg, _ := b.GetSegment(4); seg == nil || !seg.Discontinuity {
+		t.Error("first segment after the encode change not marked a discontinuity")
+	}
+
+	// Once the old footage ages out its init goes, and the discontinuity
+	// before the oldest segment is counted
+	b.cleanup()
+	if _, err := os.Stat(kept); !os.IsNotExist(err) {
+		t.Errorf("kept init not removed: %v", err)
+	}
+	if got := b.DiscontinuitySequence(); got != 1 {
+		t.Errorf("DiscontinuitySequence = %d, want 1", got)
+	}
+}
+
+func TestEvictOverQuota(t *testing.T) {
+	b := newTestBuffer(t, Config{Duration: time.Hour, MaxBytes: 250})
+	now := time.Now()
+	for seq := 1; seq <= 5; seq++ {
+		addTestSegment(t, b, seq, now.Add(time.Duration(seq)*2*time.Second), 100)
+	}
+	// Offloaded segments don't count against the quota
+	b.segments[2].Remote = "segments/2.m4s"
+
+	b.cleanup()
+	var kept []int
+	for _, seg := range b.ListSegments() {
+		kept = append(kept, seg.Sequence)
+	}
+	if want := []int{2, 4, 5}; fmt.Sprint(kept) != fmt.Sprint(want) {
+		t.Errorf("kept %v, want %v: oldest local segments evicted first", kept, want)
+	}
+	for _, seq := range []int{1, 3} {
+		if _, err := os.Stat(filepath.Join(b.workPath(), fmt.Sprintf("segment_%05d.m4s", seq))); !os.IsNotExist(err) {
+			t.Errorf("segment %d file not removed: %v", seq, err)
+		}
+	}
+
+	// The newest segment stays even when it alone is over the quota
+	b = newTestBuffer(t, Config{Duration: time.Hour, MaxBytes: 50})
+	addTestSegment(t, b, 1, now, 100)
+	addTestSegment(t, b, 2, now.Add(2*time.Second), 100)
+	b.cleanup()
+	if segs := b.ListSegments(); len(segs) != 1 || segs[0].Sequence != 2 {
+		t.Errorf("kept %d segments, want only the newest", len(segs))
+	}
+}
+
+func TestRestoredGhostClip(t *testing.T) {
+	b := newTestBuffer(t, Config{Duration: time.Hour})
+	before := time.Now().Add(-time.Minute)
+	for seq := 1; seq <= 3; seq++ {
+		addTestSegment(t, b, seq, before.Add(time.Duration(seq)*2*time.Second), 10)
+	}
+	if err := b.StartGhostClip("p1"); err != nil {
+		t.Fatalf("StartGhostClip: %v", err)
+	}
+	b.saveIndex()
+
+	// A reboot wiped segments 2 and 3, so capture numbers from 2 again
+	for _, seq := range []int{2, 3} {
+		os.Remove(filepath.Join(b.workPath(), fmt.Sprintf("segment_%05d.m4s", seq)))
+	}
+	restored, err := New(b.cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := restored.loadExistingSegments(); err != nil {
+		t.Fatalf("loadExistingSegments: %v", err)
+	}
+	after := time.Now()
+	addTestSegment(t, restored, 2, after, 10)
+	addTestSegment(t, restored, 3, after.Add(2*time.Second), 10)
+
+	res, err := restored.EndGhostClip("p1")
+	if err != nil {
+		t.Fatalf("EndGhostClip: %v", err)
+	}
+	if fmt.Sprint(res.Segments) != "[2 3]" {
+		t.Errorf("segments = %v, want [2 3] recorded after the mark-in", res.Segments)
+	}
+	if _, err := restored.EndGhostClip("p1"); err == nil {
+		t.Error("ended ghost clip still active")
+	}
+}
+
+func TestInMemorySegments(t *testing.T) {
+	b := newTestBuffer(t, Config{Duration: time.Hour, InMemory: true, WorkPath: t.TempDir()})
+	if b.workPath() == b.cfg.Path {
+		t.Fatal("segments written under path, want the work path")
+	}
+	now := time.Now()
+	for seq := 1; seq <= 3; seq++ {
+		addTestSegment(t, b, seq, now.Add(time.Duration(seq)*2*time.Second), 10*seq)
+	}
+
+	// Each segment moves to RAM once the next one shows it is complete
+	for _, tt := range []struct {
+		seq      int
+		inMemory bool
+	}{{1, true}, {2, true}, {3, false}} {
+		data, ok := b.SegmentData(tt.seq)
+		if ok != tt.inMemory || (ok && len(data) != 10*tt.seq) {
+			t.Errorf("segment %d: SegmentData = %d bytes, %v; want in memory %v", tt.seq, len(data), ok, tt.inMemory)
+		}
+		_, err := os.Stat(filepath.Join(b.workPath(), fmt.Sprintf("segment_%05d.m4s", tt.seq)))
+		if onDisk := err == nil; onDisk == tt.inMemory {
+			t.Errorf("segment %d: file on disk %v, want %v", tt.seq, onDisk, !tt.inMemory)
+		}
+	}
+	if _, ok := b.SegmentData(9); ok {
+		t.Error("SegmentData found a segment that isn't buffered")
+	}
+
+	// Disk mode leaves every segment on disk
+	b = newTestBuffer(t, Config{Duration: time.Hour})
+	addTestSegment(t, b, 1, now, 10)
+	addTestSegment(t, b, 2, now.Add(2*time.Second), 10)
+	if _, ok := b.SegmentData(1); ok || b.workPath() != b.cfg.Path {
+		t.Error("disk buffer holds segments in memory or writes outside path")
+	}
+}
+
+func TestEndGhostClipAt(t *testing.T) {
+	b := newTestBuffer(t, Config{Duration: time.Hour})
+	if err := b.StartGhostClip("p1"); err != nil {
+		t.Fatalf("StartGhostClip: %v", err)
+	}
+	now := time.Now()
+	for seq := 1; seq <= 4; seq++ {
+		addTestSegment(t, b, seq, now.Add(time.Duration(seq-1)*2*time.Second), 10)
+	}
+
+	// Segments starting after the gang mark-out belong to no angle
+	end := now.Add(3 * time.Second)
+	res, err := b.EndGhostClipAt("p1", end)
+	if err != nil {
+		t.Fatalf("EndGhostClipAt: %v", err)
+	}
+	if fmt.Sprint(res.Segments) != "[1 2]" || !res.EndTime.Equal(end) {
+		t.Errorf("segments %v ending %v, want [1 2] ending at the mark-out", res.Segments, res.EndTime)
+	}
+}
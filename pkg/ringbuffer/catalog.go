@@ -0,0 +1,176 @@
+package ringbuffer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrClipNotFound is returned for play IDs missing from the clip catalog
+var ErrClipNotFound = errors.New("clip not found")
+
+// ClipEntry is a generated clip in the buffer's clip library
+type ClipEntry struct {
+	PlayID        string              `json:"play_id"`
+	FilePath      string              `json:"file_path"`
+	StartTime     time.Time           `json:"start_time"`
+	EndTime       time.Time           `json:"end_time"`
+	Duration      float64             `json:"duration"`
+	FileSizeBytes int64               `json:"file_size_bytes"`
+	SegmentCount  int                 `json:"segment_count"`
+	PlaybackRate  float64             `json:"playback_rate,omitempty"`
+	Variants      []ClipVariantResult `json:"variants,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// clipCatalog indexes generated clips in clips/catalog.json so they can be
+// listed after a restart
+type clipCatalog struct {
+	path string
+
+	mu    sync.Mutex
+	clips map[string]*ClipEntry
+}
+
+// loadCatalog reads the catalog in dir, dropping clips whose files are gone
+func loadCatalog(dir string) (*clipCatalog, error) {
+	c := &clipCatalog{path: filepath.Join(dir, "catalog.json"), clips: make(map[string]*ClipEntry)}
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read clip catalog: %w", err)
+	}
+	var entries []*ClipEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse clip catalog: %w", err)
+	}
+	for _, e := range entries {
+		if _, err := os.Stat(e.FilePath); err == nil {
+			c.clips[e.PlayID] = e
+		}
+	}
+	return c, nil
+}
+
+// add records a clip, replacing any earlier clip with the same play ID
+func (c *clipCatalog) add(e *ClipEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clips[e.PlayID] = e
+	return c.saveLocked()
+}
+
+// list returns all clips, newest first
+func (c *clipCatalog) list() []ClipEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]ClipEntry, 0, len(c.clips))
+	for _, e := range c.clips {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+func (c *clipCatalog) get(playID string) (ClipEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.clips[playID]
+	if !ok {
+		return ClipEntry{}, false
+	}
+	return *e, true
+}
+
+// remove drops a clip from the catalog and returns it
+func (c *clipCatalog) remove(playID string) (*ClipEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.clips[playID]
+	if !ok {
+		return nil, ErrClipNotFound
+	}
+	delete(c.clips, playID)
+	return e, c.saveLocked()
+}
+
+func (c *clipCatalog) saveLocked() error {
+	entries := make([]*ClipEntry, 0, len(c.clips))
+	for _, e := range c.clips {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write clip catalog: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Clips lists the clips generated into the buffer, newest first
+func (b *Buffer) Clips() []ClipEntry {
+	return b.clips.list()
+}
+
+// Clip returns a generated clip by play ID
+func (b *Buffer) Clip(playID string) (ClipEntry, bool) {
+	return b.clips.get(playID)
+}
+
+// DeleteClip removes a clip and its variants from disk and the catalog
+func (b *Buffer) DeleteClip(playID string) error {
+	e, err := b.clips.remove(playID)
+	if err != nil {
+		return err
+	}
+	for _, path := range append([]string{e.FilePath}, variantPaths(e.Variants)...) {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			b.logger.Warn("Failed to remove clip file", "play_id", playID, "path", path, "error", err)
+		}
+	}
+	b.logger.Info("Clip deleted", "play_id", playID)
+	return nil
+}
+
+// catalogClip records a finished clip in the library. A catalog write
+// failure is logged; the clip itself is still usable.
+func (b *Buffer) catalogClip(playID string, start, end time.Time, result *ClipResult) {
+	err := b.clips.add(&ClipEntry{
+		PlayID:        playID,
+		FilePath:      result.FilePath,
+		StartTime:     start,
+		EndTime:       end,
+		Duration:      result.Duration,
+		FileSizeBytes: result.FileSizeBytes,
+		SegmentCount:  result.SegmentCount,
+		PlaybackRate:  result.PlaybackRate,
+		Variants:      result.Variants,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		b.logger.Warn("Failed to update clip catalog", "play_id", playID, "error", err)
+	}
+}
+
+func variantPaths(variants []ClipVariantResult) []string {
+	paths := make([]string, len(variants))
+	for i, v := range variants {
+		paths[i] = v.FilePath
+	}
+	return paths
+}
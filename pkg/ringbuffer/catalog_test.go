@@ -0,0 +1,125 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestClip writes an empty clip file and returns its path
+func writeTestClip(t *testing.T, b *Buffer, name string) string {
+	t.Helper()
+	path := filepath.Join(b.cfg.Path, "clips", name)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClipCatalog(t *testing.T) {
+	b := newTestBuffer(t, Config{Duration: time.Minute})
+	t0 := time.Now()
+	for i, id := range []string{"p1", "p2", "p3"} {
+		path := writeTestClip(t, b, id+".mp4")
+		result := &ClipResult{FilePath: path, Duration: 10, SegmentCount: 5}
+		if id == "p2" {
+			result.Variants = []ClipVariantResult{{FilePath: writeTestClip(t, b, "p2_720p.mp4")}}
+		}
+		b.catalogClip(id, t0, t0.Add(10*time.Second), result)
+		// Spread creation times so ordering is deterministic
+		b.clips.clips[id].CreatedAt = t0.Add(time.Duration(i) * time.Second)
+	}
+
+	if got := clipIDs(b.Clips()); got != "p3,p2,p1" {
+		t.Errorf("Clips() = %s, want newest first p3,p2,p1", got)
+	}
+	if e, ok := b.Clip("p2"); !ok || e.SegmentCount != 5 || len(e.Variants) != 1 {
+		t.Errorf("Clip(p2) = %+v, %v", e, ok)
+	}
+
+	tests := []struct {
+		name     string
+		playID   string
+		wantErr  error
+		wantGone []string
+		wantIDs  string
+	}{
+		{"delete with variants", "p2", nil, []string{"p2.mp4", "p2_720p.mp4"}, "p3,p1"},
+		{"delete again", "p2", ErrClipNotFound, nil, "p3,p1"},
+		{"delete unknown", "nope", ErrClipNotFound, nil, "p3,p1"},
+		{"delete with file already gone", "p1", nil, []string{"p1.mp4"}, "p3"},
+	}
+	// p1's file was removed out of band; deleting it still drops the entry
+	os.Remove(filepath.Join(b.cfg.Path, "clips", "p1.mp4"))
+	for _, tt := range tests {
+		if err := b.DeleteClip(tt.playID); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		for _, name := range tt.wantGone {
+			if _, err := os.Stat(filepath.Join(b.cfg.Path, "clips", name)); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%s: %s still on disk", tt.name, name)
+			}
+		}
+		if got := clipIDs(b.Clips()); got != tt.wantIDs {
+			t.Errorf("%s: clips = %s, want %s", tt.name, got, tt.wantIDs)
+		}
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string // Contents of catalog.json, empty for none
+		wantIDs string
+		wantErr bool
+	}{
+		{"no catalog", "", "", false},
+		{"drops missing files", `[{"play_id":"kept","file_path":"%KEPT%"},{"play_id":"gone","file_path":"/nonexistent/gone.mp4"}]`, "kept", false},
+		{"corrupt", "{", "", true},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		kept := filepath.Join(dir, "kept.mp4")
+		if err := os.WriteFile(kept, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if tt.catalog != "" {
+			data := []byte(strings.ReplaceAll(tt.catalog, "%KEPT%", kept))
+			if err := os.WriteFile(filepath.Join(dir, "catalog.json"), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c, err := loadCatalog(dir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if got := clipIDs(c.list()); got != tt.wantIDs {
+				t.Errorf("%s: clips = %s, want %s", tt.name, got, tt.wantIDs)
+			}
+		}
+	}
+
+	// The catalog survives a buffer restart
+	b := newTestBuffer(t, Config{Duration: time.Minute})
+	b.catalogClip("p1", time.Now(), time.Now(), &ClipResult{FilePath: writeTestClip(t, b, "p1.mp4")})
+	reopened, err := New(b.cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reopened.Clip("p1"); !ok {
+		t.Error("clip missing after reopening the buffer")
+	}
+}
+
+func clipIDs(entries []ClipEntry) string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.PlayID
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,160 @@
+package ringbuffer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"github.com/video-system/go-video-capture/pkg/jobs"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ExportResult describes a buffer range exported to one file
+type ExportResult struct {
+	Name          string  `json:"name"` // File name in the exports directory
+	FilePath      string  `json:"file_path"`
+	StartTime     int64   `json:"start_time"` // Unix ms
+	EndTime       int64   `json:"end_time"`   // Unix ms
+	Duration      float64 `json:"duration"`
+	FileSizeBytes int64   `json:"file_size_bytes"`
+	SegmentCount  int     `json:"segment_count"`
+}
+
+// exportFormats are the containers ExportRange writes
+var exportFormats = map[string]bool{"mp4": true, "mkv": true}
+
+// ExportsPath returns the directory exports are written to
+func (b *Buffer) ExportsPath() string {
+	return filepath.Join(b.cfg.Path, "exports")
+}
+
+// ExportRange writes every segment between start and end to one mp4 or mkv
+// file for archival, e.g. at the end of a session. Zero times mean the
+// oldest and newest footage. Segments are streamed through FFmpeg without
+// re-encoding; progress is reported to the job running in ctx, if any.
+func (b *Buffer) ExportRange(ctx context.Context, start, end time.Time, format string) (_ *ExportResult, err error) {
+	if format == "" {
+		format = "mp4"
+	}
+	if !exportFormats[format] {
+		return nil, fmt.Errorf("unsupported export format %q (want mp4 or mkv)", format)
+	}
+	if end.IsZero() {
+		end = time.Now().Add(time.Hour) // Past the newest segment
+	}
+
+	ctx, span := tracing.Start(ctx, "buffer.export",
+		attribute.String("channel", b.cfg.ChannelID),
+		attribute.String("format", format))
+	defer func() { tracing.End(span, err) }()
+
+	segments := b.GetSegmentsInRange(start, end)
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments found for time range %v - %v", start, end)
+	}
+	span.SetAttributes(segmentAttrs(segments)...)
+
+	first, last := segments[0], segments[len(segments)-1]
+	rangeStart, rangeEnd := first.StartTime, last.StartTime.Add(last.Duration)
+	if err := os.MkdirAll(b.ExportsPath(), 0755); err != nil {
+		return nil, fmt.Errorf("create exports path: %w", err)
+	}
+	channel := b.cfg.ChannelID
+	if channel == "" {
+		channel = "buffer"
+	}
+	name := fmt.Sprintf("%s_%s_%s.%s", channel,
+		rangeStart.UTC().Format("20060102T150405Z"), rangeEnd.UTC().Format("20060102T150405Z"), format)
+	outputPath := filepath.Join(b.ExportsPath(), name)
+
+	b.logger.Info("Exporting buffer range", "start", rangeStart, "end", rangeEnd, "segments", len(segments), "path", outputPath)
+	if err := b.exportSegments(ctx, segments, outputPath); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("stat output: %w", err)
+	}
+	duration := rangeEnd.Sub(rangeStart).Seconds()
+	if videoInfo, err := b.probe(ctx, outputPath); err == nil && videoInfo.Duration > 0 {
+		duration = videoInfo.Duration
+	}
+	span.SetAttributes(attribute.Int64("output_bytes", info.Size()))
+	b.logger.Info("Buffer range exported", "path", outputPath, "size_bytes", info.Size(), "duration", duration)
+
+	return &ExportResult{
+		Name:          name,
+		FilePath:      outputPath,
+		StartTime:     rangeStart.UnixMilli(),
+		EndTime:       rangeEnd.UnixMilli(),
+		Duration:      duration,
+		FileSizeBytes: info.Size(),
+		SegmentCount:  len(segments),
+	}, nil
+}
+
+// exportSegments streams each run of segments to its own file and joins
+// them when imported footage splits the range. Streaming is 90% of the
+// reported progress.
+func (b *Buffer) exportSegments(ctx context.Context, segments []*Segment, outputPath string) error {
+	runs := b.splitRuns(segments)
+	done := 0
+	progress := func(n int) {
+		jobs.Report(ctx, "export", 0.9*float64(done+n)/float64(len(segments)))
+	}
+
+	if len(runs) == 1 {
+		return b.exportRun(ctx, runs[0], outputPath, progress)
+	}
+
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	parts := make([]string, len(runs))
+	for i, run := range runs {
+		parts[i] = fmt.Sprintf("%s.part%d.mp4", base, i)
+		defer os.Remove(parts[i])
+		if err := b.exportRun(ctx, run, parts[i], progress); err != nil {
+			return err
+		}
+		done += len(run.segments)
+	}
+
+	jobs.Report(ctx, "join", 0.9)
+	joined := outputPath
+	if filepath.Ext(outputPath) != ".mp4" {
+		joined = base + ".joined.mp4"
+		defer os.Remove(joined)
+	}
+	if err := b.joinParts(ctx, parts, joined); err != nil {
+		return err
+	}
+	if joined == outputPath {
+		return nil
+	}
+	jobs.Report(ctx, "remux", 0.95)
+	err := traceStep(ctx, "ffmpeg.remux", func(ctx context.Context) error {
+		return b.ffmpeg.Remux(ctx, joined, outputPath)
+	})
+	if err != nil {
+		return fmt.Errorf("remux export: %w", err)
+	}
+	return nil
+}
+
+// exportRun streams one run of segments through FFmpeg into outputPath
+func (b *Buffer) exportRun(ctx context.Context, run segmentRun, outputPath string, progress func(done int)) error {
+	err := traceStep(ctx, "ffmpeg.export", func(ctx context.Context) error {
+		return b.ffmpeg.ExportSegments(ctx, run.init, len(run.segments), func(i int) ([]byte, error) {
+			return b.readSegment(ctx, run.segments[i])
+		}, outputPath, progress)
+	}, attribute.Int("segments", len(run.segments)))
+	if err != nil {
+		return fmt.Errorf("export segments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package ringbuffer
+
+import "time"
+
+// Gap is a hole in the buffer: sequence numbers that never arrived, or time
+// between two consecutive segments that neither covers (e.g. FFmpeg stalled
+// or restarted)
+type Gap struct {
+	AfterSeq  int     `json:"after_seq"`        // Last segment before the hole
+	BeforeSeq int     `json:"before_seq"`       // First segment after it
+	Missing   int     `json:"missing_segments"` // Sequence numbers skipped
+	Start     int64   `json:"start_time"`       // End of AfterSeq (Unix ms)
+	End       int64   `json:"end_time"`         // Start of BeforeSeq (Unix ms)
+	Duration  float64 `json:"duration"`         // Seconds of footage missing
+}
+
+// OnGap sets a callback for gaps as they open
+func (b *Buffer) OnGap(fn func(Gap)) {
+	b.onGap = fn
+}
+
+// detectGapLocked compares a new segment with the newest one before it. A
+// time hole counts once it is over half a segment long.
+func (b *Buffer) detectGapLocked(seg *Segment) (Gap, bool) {
+	if b.lastSeq == 0 || seg.Sequence <= b.lastSeq {
+		return Gap{}, false
+	}
+	prev, ok := b.segments[b.lastSeq]
+	if !ok {
+		return Gap{}, false
+	}
+
+	prevEnd := prev.StartTime.Add(prev.Duration)
+	hole := seg.StartTime.Sub(prevEnd)
+	missing := seg.Sequence - prev.Sequence - 1
+	tolerance := b.cfg.SegmentSize / 2
+	if tolerance <= 0 {
+		tolerance = time.Second
+	}
+	if missing == 0 && hole <= tolerance {
+		return Gap{}, false
+	}
+
+	gap := Gap{
+		AfterSeq:  prev.Sequence,
+		BeforeSeq: seg.Sequence,
+		Missing:   missing,
+		Start:     prevEnd.UnixMilli(),
+		End:       seg.StartTime.UnixMilli(),
+		Duration:  max(hole, 0).Seconds(),
+	}
+	b.gaps = append(b.gaps, gap)
+	return gap, true
+}
+
+// pruneGapsLocked forgets gaps that have aged out of the buffer
+func (b *Buffer) pruneGapsLocked() {
+	first, ok := b.segments[b.firstSeq]
+	kept := b.gaps[:0]
+	for _, g := range b.gaps {
+		if ok && g.AfterSeq >= first.Sequence {
+			kept = append(kept, g)
+		}
+	}
+	b.gaps = kept
+}
+
+// pruneDiscontinuityLocked counts the discontinuity before a segment being
+// removed
+func (b *Buffer) pruneDiscontinuityLocked(seg *Segment) {
+	if seg.Discontinuity {
+		b.prunedDiscontinuities++
+	}
+}
+
+// DiscontinuitySequence returns how many discontinuities, sequence gaps and
+// changes of init segment, have aged out of the buffer: the
+// EXT-X-DISCONTINUITY-SEQUENCE of a playlist starting at its oldest segment
+func (b *Buffer) DiscontinuitySequence() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.prunedDiscontinuities
+}
+
+// Gaps returns the holes in the buffered footage, oldest first
+func (b *Buffer) Gaps() []Gap {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]Gap(nil), b.gaps...)
+}
@@ -0,0 +1,63 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectGap(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name    string
+		seq     int
+		after   time.Duration // Start of the next segment after the first one's start
+		gap     bool
+		missing int
+		hole    float64
+	}{
+		{"contiguous", 2, 2 * time.Second, false, 0, 0},
+		{"jitter within half a segment", 2, 2*time.Second + 900*time.Millisecond, false, 0, 0},
+		{"time hole", 2, 5 * time.Second, true, 0, 3},
+		{"skipped sequences", 4, 2 * time.Second, true, 2, 0},
+		{"skipped sequences and time", 3, 10 * time.Second, true, 1, 8},
+	}
+	for _, tt := range tests {
+		b := newTestBuffer(t, Config{Duration: time.Hour})
+		var opened []Gap
+		b.OnGap(func(g Gap) { opened = append(opened, g) })
+		addTestSegment(t, b, 1, start, 10)
+		addTestSegment(t, b, tt.seq, start.Add(tt.after), 10)
+
+		gaps := b.Gaps()
+		if (len(gaps) == 1) != tt.gap || len(opened) != len(gaps) {
+			t.Errorf("%s: gaps %+v, %d reported; want gap %v", tt.name, gaps, len(opened), tt.gap)
+			continue
+		}
+		if !tt.gap {
+			continue
+		}
+		g := gaps[0]
+		if g.AfterSeq != 1 || g.BeforeSeq != tt.seq || g.Missing != tt.missing || g.Duration != tt.hole {
+			t.Errorf("%s: gap = %+v, want %d missing and %gs of footage", tt.name, g, tt.missing, tt.hole)
+		}
+	}
+}
+
+func TestGapsPruned(t *testing.T) {
+	b := newTestBuffer(t, Config{Duration: time.Minute})
+	old := time.Now().Add(-time.Hour)
+	addTestSegment(t, b, 1, old, 10)
+	addTestSegment(t, b, 3, old.Add(4*time.Second), 10)
+	now := time.Now()
+	addTestSegment(t, b, 4, now, 10)
+	addTestSegment(t, b, 5, now.Add(2*time.Second), 10)
+	if len(b.Gaps()) != 2 {
+		t.Fatalf("gaps = %+v, want the skipped sequence and the hour-long hole", b.Gaps())
+	}
+
+	// Once the footage around a gap ages out, so does the gap
+	b.cleanup()
+	if gaps := b.Gaps(); len(gaps) != 0 {
+		t.Errorf("gaps = %+v after the old footage aged out, want none", gaps)
+	}
+}
@@ -0,0 +1,213 @@
+package ringbuffer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"github.com/video-system/go-video-capture/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ImportResult describes footage merged into the buffer
+type ImportResult struct {
+	StartTime time.Time `json:"start_time"` // First imported segment
+	EndTime   time.Time `json:"end_time"`   // End of the last imported segment
+	Segments  int       `json:"segments"`   // Segments added
+	Skipped   int       `json:"skipped"`    // Segments overlapping buffered footage or outside the buffer window
+}
+
+// Import segments a finished recording that started at start, e.g. from a
+// camera's own recorder, and merges the parts that fall in gaps of the
+// buffer so downtime can be backfilled before clipping. enc holds the live
+// encode settings so imported segments match captured ones.
+//
+// Imported segments have their own init segment and are only used for
+// time-range clips; they never appear in live playlists. Segments that
+// overlap buffered footage, even partly, are dropped.
+func (b *Buffer) Import(ctx context.Context, path string, start time.Time, enc ffmpeg.SegmentConfig) (_ *ImportResult, err error) {
+	ctx, span := tracing.Start(ctx, "buffer.import",
+		attribute.String("channel", b.cfg.ChannelID),
+		attribute.String("source", filepath.Base(path)))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("recording: %w", err)
+	}
+
+	dir := filepath.Join(b.cfg.Path, "imports", fmt.Sprintf("%d-%d", start.UnixMilli(), time.Now().UnixMilli()))
+	enc.Input = path
+	enc.InputFormat = ""
+	enc.OutputDir = dir
+	enc.AudioRenditions = nil
+	enc.ThumbnailDir = ""
+	enc.ChunkDuration = 0
+
+	var parts []ffmpeg.IngestedSegment
+	err = traceStep(ctx, "ffmpeg.ingest", func(ctx context.Context) error {
+		parts, err = b.ffmpeg.SegmentRecording(ctx, enc)
+		return err
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("segment recording: %w", err)
+	}
+	os.Remove(filepath.Join(dir, "index.m3u8"))
+
+	initPath := filepath.Join(dir, "init.mp4")
+	var cutoff time.Time
+	if b.cfg.Duration > 0 {
+		cutoff = time.Now().Add(-b.cfg.Duration)
+	}
+
+	result := &ImportResult{}
+	b.mu.Lock()
+	for i, p := range parts {
+		seg := &Segment{
+			Sequence:  i,
+			FilePath:  p.Path,
+			StartTime: start.Add(time.Duration(p.Offset * float64(time.Second))),
+			Duration:  time.Duration(p.Duration * float64(time.Second)),
+			SizeBytes: p.Size,
+			InitPath:  initPath,
+		}
+		end := seg.StartTime.Add(seg.Duration)
+		if !end.After(cutoff) || b.overlapsLocked(seg.StartTime, end) {
+			os.Remove(seg.FilePath)
+			result.Skipped++
+			continue
+		}
+		b.imported = append(b.imported, seg)
+		if result.Segments == 0 {
+			result.StartTime = seg.StartTime
+		}
+		result.EndTime = end
+		result.Segments++
+	}
+	sort.Slice(b.imported, func(i, j int) bool {
+		return b.imported[i].StartTime.Before(b.imported[j].StartTime)
+	})
+	b.mu.Unlock()
+
+	span.SetAttributes(attribute.Int("segments", result.Segments), attribute.Int("skipped", result.Skipped))
+	if result.Segments == 0 {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("recording does not cover any gap in the buffer (%d segments overlap or expired)", result.Skipped)
+	}
+
+	b.logger.Info("Imported recording", "source", path, "segments", result.Segments, "skipped", result.Skipped,
+		"start", result.StartTime, "end", result.EndTime)
+	b.saveIndex()
+	return result, nil
+}
+
+// overlapsLocked reports whether buffered or imported footage covers any of
+// [start, end). Must be called with b.mu held.
+func (b *Buffer) overlapsLocked(start, end time.Time) bool {
+	overlaps := func(seg *Segment) bool {
+		return seg.StartTime.Before(end) && seg.StartTime.Add(seg.Duration).After(start)
+	}
+	for _, seg := range b.segments {
+		if overlaps(seg) {
+			return true
+		}
+	}
+	for _, seg := range b.imported {
+		if overlaps(seg) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentRun is a series of segments sharing an init segment
+type segmentRun struct {
+	init     string
+	segments []*Segment
+}
+
+// splitRuns groups consecutive segments by init segment
+func (b *Buffer) splitRuns(segments []*Segment) []segmentRun {
+	var runs []segmentRun
+	for _, seg := range segments {
+		init := seg.InitPath
+		if init == "" {
+			init = b.initSegment
+		}
+		if len(runs) == 0 || runs[len(runs)-1].init != init {
+			runs = append(runs, segmentRun{init: init})
+		}
+		runs[len(runs)-1].segments = append(runs[len(runs)-1].segments, seg)
+	}
+	return runs
+}
+
+// joinRuns concatenates each run separately and joins the results. Runs
+// with matching formats are joined without re-encoding; otherwise they are
+// normalized to the first run's format.
+func (b *Buffer) joinRuns(ctx context.Context, runs []segmentRun, outputPath string) error {
+	parts := make([]string, len(runs))
+	for i, run := range runs {
+		parts[i] = fmt.Sprintf("%s.part%d.mp4", strings.TrimSuffix(outputPath, ".mp4"), i)
+		defer os.Remove(parts[i])
+		err := traceStep(ctx, "ffmpeg.concat", func(ctx context.Context) error {
+			return b.concatRun(ctx, run, parts[i])
+		}, attribute.Int("segments", len(run.segments)))
+		if err != nil {
+			return fmt.Errorf("concat segments: %w", err)
+		}
+	}
+	return b.joinParts(ctx, parts, outputPath)
+}
+
+// joinParts joins MP4 parts made from separate runs into outputPath
+func (b *Buffer) joinParts(ctx context.Context, parts []string, outputPath string) error {
+	infos := make([]*ffmpeg.VideoInfo, len(parts))
+	for i := range parts {
+		info, err := b.probe(ctx, parts[i])
+		if err != nil {
+			return fmt.Errorf("probe clip part: %w", err)
+		}
+		infos[i] = info
+	}
+
+	same := true
+	for _, info := range infos[1:] {
+		first := infos[0]
+		if info.Width != first.Width || info.Height != first.Height || info.Codec != first.Codec || info.HasAudio != first.HasAudio {
+			same = false
+		}
+	}
+	if same {
+		err := traceStep(ctx, "ffmpeg.join", func(ctx context.Context) error {
+			return b.ffmpeg.JoinClips(ctx, parts, outputPath)
+		}, attribute.Int("parts", len(parts)))
+		if err != nil {
+			return fmt.Errorf("join clip parts: %w", err)
+		}
+		return nil
+	}
+
+	clips := make([]ffmpeg.ReelClip, len(parts))
+	for i, p := range parts {
+		clips[i] = ffmpeg.ReelClip{Path: p, Duration: infos[i].Duration, HasAudio: infos[i].HasAudio}
+	}
+	err := traceStep(ctx, "ffmpeg.join", func(ctx context.Context) error {
+		return b.ffmpeg.RenderReel(ctx, clips, ffmpeg.ReelConfig{
+			Width:   infos[0].Width,
+			Height:  infos[0].Height,
+			FPS:     infos[0].Framerate,
+			Encoder: b.cfg.ClipEncoder,
+			Bitrate: b.cfg.ClipBitrate,
+		}, outputPath)
+	}, attribute.Int("parts", len(parts)), attribute.Bool("reencode", true))
+	if err != nil {
+		return fmt.Errorf("join clip parts: %w", err)
+	}
+	return nil
+}
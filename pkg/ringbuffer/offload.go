@@ -0,0 +1,103 @@
+package ringbuffer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/recovery"
+)
+
+// ObjectStore holds segments offloaded from local storage, e.g. an S3 bucket
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// segmentKey names a segment's object. The start time keeps keys unique
+// when sequence numbers restart with a new capture.
+func segmentKey(seg *Segment) string {
+	return fmt.Sprintf("%d_%05d.m4s", seg.StartTime.UnixMilli(), seg.Sequence)
+}
+
+// offload moves captured segments older than OffloadAfter to the object
+// store and removes their local copies. A failed upload is retried on the
+// next pass.
+func (b *Buffer) offload() {
+	if b.cfg.Offload == nil {
+		return
+	}
+	cutoff := time.Now().Add(-b.cfg.OffloadAfter)
+
+	b.mu.RLock()
+	var aged []*Segment
+	for seq := b.firstSeq; seq <= b.lastSeq; seq++ {
+		seg, ok := b.segments[seq]
+		if ok && seg.Remote == "" && seg != b.pending && seg.StartTime.Before(cutoff) {
+			aged = append(aged, seg)
+		}
+	}
+	b.mu.RUnlock()
+
+	moved := 0
+	for _, seg := range aged {
+		if b.ctx.Err() != nil {
+			return
+		}
+		b.mu.RLock()
+		data := seg.data
+		b.mu.RUnlock()
+		if data == nil {
+			var err error
+			if data, err = os.ReadFile(seg.FilePath); err != nil {
+				continue // Evicted meanwhile
+			}
+		}
+
+		key := segmentKey(seg)
+		if err := b.cfg.Offload.Put(b.ctx, key, data); err != nil {
+			b.logger.Warn("Failed to offload segment", "seq", seg.Sequence, "error", err)
+			return
+		}
+
+		b.mu.Lock()
+		_, buffered := b.segments[seg.Sequence]
+		if buffered {
+			seg.Remote, seg.data = key, nil
+		}
+		b.mu.Unlock()
+		if !buffered {
+			b.deleteRemote([]string{key})
+			continue
+		}
+		os.Remove(seg.FilePath)
+		moved++
+	}
+	if moved > 0 {
+		b.logger.Debug("Segments offloaded", "count", moved)
+	}
+}
+
+// fetchSegment downloads an offloaded segment
+func (b *Buffer) fetchSegment(ctx context.Context, seg *Segment) ([]byte, error) {
+	data, err := b.cfg.Offload.Get(ctx, seg.Remote)
+	if err != nil {
+		return nil, fmt.Errorf("fetch offloaded segment %d: %w", seg.Sequence, err)
+	}
+	return data, nil
+}
+
+// deleteRemote removes expired objects in the background
+func (b *Buffer) deleteRemote(keys []string) {
+	recovery.Go("buffer offload delete", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		for _, key := range keys {
+			if err := b.cfg.Offload.Delete(ctx, key); err != nil {
+				b.logger.Warn("Failed to delete offloaded segment", "key", key, "error", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package ringbuffer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/ffmpeg"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ClipOverlay burns text into exported clips
+type ClipOverlay struct {
+	// Template text with {field} placeholders: {play_id}, {channel},
+	// {clock} (running wall-clock time), {date}, and any ClipOptions
+	// OverlayFields such as {session} or {tags.score}. Unknown fields are
+	// left empty.
+	Template string
+	Style    ffmpeg.OverlayStyle
+}
+
+// overlayField matches a {field} placeholder
+var overlayField = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+// renderOverlay expands the template into drawtext text for a clip whose
+// first frame was captured at start
+func renderOverlay(template string, fields map[string]string, start time.Time) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range overlayField.FindAllStringSubmatchIndex(template, -1) {
+		out.WriteString(ffmpeg.OverlayText(template[last:m[0]]))
+		switch name := template[m[2]:m[3]]; name {
+		case "clock":
+			out.WriteString(ffmpeg.OverlayClock(start))
+		case "date":
+			out.WriteString(start.Format("2006-01-02"))
+		default:
+			out.WriteString(ffmpeg.OverlayText(fields[name]))
+		}
+		last = m[1]
+	}
+	out.WriteString(ffmpeg.OverlayText(template[last:]))
+	return out.String()
+}
+
+// overlay burns the configured text into a finished clip in place
+func (b *Buffer) overlay(ctx context.Context, outputPath, playID string, start time.Time, opts ClipOptions) error {
+	if b.cfg.ClipOverlay == nil || b.cfg.ClipOverlay.Template == "" {
+		return nil
+	}
+
+	fields := map[string]string{"play_id": playID, "channel": b.cfg.ChannelID}
+	for k, v := range opts.OverlayFields {
+		fields[k] = v
+	}
+	text := renderOverlay(b.cfg.ClipOverlay.Template, fields, start)
+
+	tempPath := outputPath + ".src.mp4"
+	if err := os.Rename(outputPath, tempPath); err != nil {
+		return fmt.Errorf("prepare overlay: %w", err)
+	}
+	defer os.Remove(tempPath)
+
+	err := traceStep(ctx, "ffmpeg.overlay", func(ctx context.Context) error {
+		return b.ffmpeg.OverlayClip(ctx, tempPath, outputPath, text, b.cfg.ClipOverlay.Style, b.cfg.ClipEncoder, b.cfg.ClipBitrate)
+	}, attribute.String("position", b.cfg.ClipOverlay.Style.Position))
+	if err != nil {
+		return fmt.Errorf("overlay clip: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+// Package rtsp receives IP camera video in-process: a pure-Go RTSP client
+// that plays the camera's H.264 or H.265 stream over TCP and depacketizes
+// the RTP into access units. Capture muxes them into segments as they
+// are, so a camera needs no FFmpeg process and no re-encode.
+package rtsp
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+const userAgent = "go-video-capture"
+
+// Interleaved channels the video is set up on
+const (
+	rtpChannel  = 0
+	rtcpChannel = 1
+)
+
+// Client is an RTSP session with one camera, set up to receive its video
+// interleaved on the TCP connection
+type Client struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	url     *url.URL // Without credentials
+	user    *url.Userinfo
+	timeout time.Duration
+
+	mu      sync.Mutex // Serialises writes: requests and keepalives
+	cseq    int
+	auth    *digest
+	basic   bool
+	session string
+	base    string // Base for relative control URLs
+
+	// How often the session must be refreshed, from the server's session
+	// timeout
+	keepalive time.Duration
+}
+
+// response is an RTSP reply
+type response struct {
+	status int
+	reason string
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// Dial connects to the camera at rawURL (rtsp://[user:pass@]host[:port]/path).
+// timeout bounds connecting and every read after.
+func Dial(rawURL string, timeout time.Duration) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	if u.Scheme != "rtsp" {
+		return nil, fmt.Errorf("unsupported scheme %q (want rtsp)", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "554")
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:      conn,
+		br:        bufio.NewReaderSize(conn, 64*1024),
+		user:      u.User,
+		timeout:   timeout,
+		keepalive: 30 * time.Second,
+	}
+	u.User = nil
+	c.url = u
+	c.base = u.String()
+	return c, nil
+}
+
+// Describe fetches the session description and returns its video media
+func (c *Client) Describe() (*Media, error) {
+	resp, err := c.do("DESCRIBE", c.url.String(), map[string]string{"Accept": "application/sdp"})
+	if err != nil {
+		return nil, err
+	}
+	if base := resp.header.Get("Content-Base"); base != "" {
+		c.base = base
+	} else if loc := resp.header.Get("Content-Location"); loc != "" {
+		c.base = loc
+	}
+	return parseSDP(resp.body)
+}
+
+// Setup sets the media up to arrive interleaved on the connection
+func (c *Client) Setup(m *Media) error {
+	transport := fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", rtpChannel, rtcpChannel)
+	resp, err := c.do("SETUP", c.control(m.Control), map[string]string{"Transport": transport})
+	if err != nil {
+		return err
+	}
+	session, params, _ := strings.Cut(resp.header.Get("Session"), ";")
+	if session == "" {
+		return fmt.Errorf("SETUP: no session")
+	}
+	c.session = strings.TrimSpace(session)
+	if v, ok := strings.CutPrefix(strings.TrimSpace(params), "timeout="); ok {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 1 {
+			c.keepalive = time.Duration(secs) * time.Second / 2
+		}
+	}
+	return nil
+}
+
+// Play starts the stream. From here on, responses arrive among the media
+// and ReadRTP skips them.
+func (c *Client) Play() error {
+	_, err := c.do("PLAY", c.base, map[string]string{"Range": "npt=0.000-"})
+	return err
+}
+
+// KeepaliveInterval is how often Keepalive must be called while playing
+func (c *Client) KeepaliveInterval() time.Duration {
+	return c.keepalive
+}
+
+// Keepalive refreshes the session so the camera doesn't time it out. The
+// reply is skipped by ReadRTP.
+func (c *Client) Keepalive() error {
+	return c.send("GET_PARAMETER", c.base, nil)
+}
+
+// ReadRTP returns the next video RTP packet, skipping RTCP and replies to
+// keepalives
+func (c *Client) ReadRTP() (*rtp.Packet, error) {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+		b, err := c.br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != '$' {
+			if _, err := c.readResponse(); err != nil {
+				return nil, fmt.Errorf("read interleaved: %w", err)
+			}
+			continue
+		}
+
+		var header [4]byte
+		if _, err := io.ReadFull(c.br, header[:]); err != nil {
+			return nil, err
+		}
+		data := make([]byte, binary.BigEndian.Uint16(header[2:]))
+		if _, err := io.ReadFull(c.br, data); err != nil {
+			return nil, err
+		}
+		if header[1] != rtpChannel {
+			continue
+		}
+		pkt := &rtp.Packet{}
+		if err := pkt.Unmarshal(data); err != nil {
+			continue
+		}
+		return pkt, nil
+	}
+}
+
+// Close tears the session down and closes the connection
+func (c *Client) Close() error {
+	if c.session != "" {
+		c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.send("TEARDOWN", c.base, nil)
+	}
+	return c.conn.Close()
+}
+
+// control resolves a media control attribute against the base URL
+func (c *Client) control(ctrl string) string {
+	switch {
+	case ctrl == "" || ctrl == "*":
+		return c.base
+	case strings.HasPrefix(ctrl, "rtsp://"):
+		return ctrl
+	case strings.HasSuffix(c.base, "/"):
+		return c.base + ctrl
+	}
+	return c.base + "/" + ctrl
+}
+
+// do sends a request and reads its reply, authenticating and retrying once
+// when the camera asks for credentials
+func (c *Client) do(method, uri string, header map[string]string) (*response, error) {
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	for attempt := 0; ; attempt++ {
+		if err := c.send(method, uri, header); err != nil {
+			return nil, err
+		}
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+		if resp.status == 401 && attempt == 0 && c.user != nil {
+			if err := c.authenticate(resp.header.Values("WWW-Authenticate")); err != nil {
+				return nil, fmt.Errorf("%s: %w", method, err)
+			}
+			continue
+		}
+		if resp.status != 200 {
+			return nil, fmt.Errorf("%s: %d %s", method, resp.status, resp.reason)
+		}
+		return resp, nil
+	}
+}
+
+// send writes a request
+func (c *Client) send(method, uri string, header map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cseq++
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s RTSP/1.0\r\nCSeq: %d\r\nUser-Agent: %s\r\n", method, uri, c.cseq, userAgent)
+	if c.session != "" {
+		fmt.Fprintf(&b, "Session: %s\r\n", c.session)
+	}
+	switch {
+	case c.auth != nil:
+		fmt.Fprintf(&b, "Authorization: %s\r\n", c.auth.header(c.user, method, uri))
+	case c.basic:
+		pass, _ := c.user.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(c.user.Username() + ":" + pass))
+		fmt.Fprintf(&b, "Authorization: Basic %s\r\n", creds)
+	}
+	for k, v := range header {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("\r\n")
+	_, err := io.WriteString(c.conn, b.String())
+	return err
+}
+
+// readResponse reads a reply: status line, headers and body
+func (c *Client) readResponse() (*response, error) {
+	tp := textproto.NewReader(c.br)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	proto, status, ok := strings.Cut(line, " ")
+	if !ok || !strings.HasPrefix(proto, "RTSP/") {
+		return nil, fmt.Errorf("malformed status line %q", line)
+	}
+	code, reason, _ := strings.Cut(status, " ")
+	resp := &response{reason: reason}
+	if resp.status, err = strconv.Atoi(code); err != nil {
+		return nil, fmt.Errorf("malformed status line %q", line)
+	}
+	if resp.header, err = tp.ReadMIMEHeader(); err != nil && resp.header == nil {
+		return nil, err
+	}
+	if n, _ := strconv.Atoi(resp.header.Get("Content-Length")); n > 0 {
+		resp.body = make([]byte, n)
+		if _, err := io.ReadFull(c.br, resp.body); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// authenticate picks the scheme for the credentials from the camera's
+// challenges, preferring Digest
+func (c *Client) authenticate(challenges []string) error {
+	for _, ch := range challenges {
+		if scheme, params, _ := strings.Cut(ch, " "); strings.EqualFold(scheme, "Digest") {
+			c.auth = parseDigest(params)
+			return nil
+		}
+	}
+	for _, ch := range challenges {
+		if strings.HasPrefix(strings.ToLower(ch), "basic") {
+			c.basic = true
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported authentication %q", challenges)
+}
+
+// digest answers an RFC 2617 Digest challenge
+type digest struct {
+	realm, nonce, opaque string
+	qop                  bool // qop=auth
+	nc                   int
+}
+
+func parseDigest(params string) *digest {
+	d := &digest{}
+	for _, p := range strings.Split(params, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		v = strings.Trim(v, `"`)
+		switch strings.ToLower(k) {
+		case "realm":
+			d.realm = v
+		case "nonce":
+			d.nonce = v
+		case "opaque":
+			d.opaque = v
+		case "qop":
+			for _, q := range strings.Split(v, ",") {
+				d.qop = d.qop || strings.TrimSpace(q) == "auth"
+			}
+		}
+	}
+	return d
+}
+
+func (d *digest) header(user *url.Userinfo, method, uri string) string {
+	pass, _ := user.Password()
+	ha1 := md5hex(user.Username() + ":" + d.realm + ":" + pass)
+	ha2 := md5hex(method + ":" + uri)
+	h := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, user.Username(), d.realm, d.nonce, uri)
+	if d.qop {
+		d.nc++
+		nc := fmt.Sprintf("%08x", d.nc)
+		cnonce := make([]byte, 8)
+		rand.Read(cnonce)
+		cn := hex.EncodeToString(cnonce)
+		h += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s", response="%s"`, nc, cn,
+			md5hex(ha1+":"+d.nonce+":"+nc+":"+cn+":auth:"+ha2))
+	} else {
+		h += fmt.Sprintf(`, response="%s"`, md5hex(ha1+":"+d.nonce+":"+ha2))
+	}
+	if d.opaque != "" {
+		h += fmt.Sprintf(`, opaque="%s"`, d.opaque)
+	}
+	return h
+}
+
+func md5hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
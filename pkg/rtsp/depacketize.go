@@ -0,0 +1,162 @@
+package rtsp
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/rtp"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+)
+
+// RTP payload types that carry more or less than one NAL unit
+const (
+	h264STAPA = 24 // RFC 6184 aggregation packet
+	h264FUA   = 28 // RFC 6184 fragmentation unit
+	h265AP    = 48 // RFC 7798 aggregation packet
+	h265FU    = 49 // RFC 7798 fragmentation unit
+)
+
+// accessUnit is the NAL units of one picture
+type accessUnit struct {
+	nalus    [][]byte
+	pts      int64 // 90 kHz, unwrapped from the 32-bit RTP timestamp
+	keyframe bool
+}
+
+// depacketizer reassembles access units from H.264 (RFC 6184) or H.265
+// (RFC 7798) RTP packets. An access unit ends with the marker bit or when
+// the timestamp changes. After packet loss, access units are dropped until
+// the next keyframe, as decoders would show corruption up to it.
+type depacketizer struct {
+	codec string
+
+	nalus   [][]byte
+	fu      []byte // NAL unit being reassembled from fragments
+	ts      uint32
+	pts     int64
+	seq     uint16
+	started bool
+	lost    bool // A packet of the current access unit is missing
+	waitKey bool // Dropping access units until a keyframe
+}
+
+func newDepacketizer(codec string) *depacketizer {
+	return &depacketizer{codec: codec, waitKey: true}
+}
+
+// push adds a packet and returns the access units it completes
+func (d *depacketizer) push(pkt *rtp.Packet) []accessUnit {
+	var out []accessUnit
+	if d.started {
+		// A missing packet may belong to either side of a timestamp
+		// change, so both access units are dropped
+		gap := pkt.SequenceNumber != d.seq+1
+		if gap {
+			d.lost = true
+		}
+		if pkt.Timestamp != d.ts {
+			out = d.flush(out)
+		}
+		if gap {
+			d.lost = true
+			d.fu = nil
+		}
+		d.pts += int64(int32(pkt.Timestamp - d.ts))
+	} else {
+		d.started = true
+	}
+	d.seq, d.ts = pkt.SequenceNumber, pkt.Timestamp
+
+	if d.codec == h26x.H265 {
+		d.pushH265(pkt.Payload)
+	} else {
+		d.pushH264(pkt.Payload)
+	}
+	if pkt.Marker {
+		out = d.flush(out)
+	}
+	return out
+}
+
+// flush ends the current access unit
+func (d *depacketizer) flush(out []accessUnit) []accessUnit {
+	nalus, lost := d.nalus, d.lost
+	d.nalus, d.fu, d.lost = nil, nil, false
+	if len(nalus) == 0 {
+		return out
+	}
+	if lost {
+		d.waitKey = true
+		return out
+	}
+	key := h26x.IsKeyframe(d.codec, nalus)
+	if d.waitKey && !key {
+		return out
+	}
+	d.waitKey = false
+	return append(out, accessUnit{nalus: nalus, pts: d.pts, keyframe: key})
+}
+
+func (d *depacketizer) pushH264(p []byte) {
+	if len(p) < 1 {
+		return
+	}
+	switch int(p[0] & 0x1f) {
+	case h264STAPA:
+		d.aggregate(p[1:])
+	case h264FUA:
+		if len(p) < 2 {
+			return
+		}
+		start, end := p[1]&0x80 != 0, p[1]&0x40 != 0
+		if start {
+			d.fu = append([]byte{p[0]&0xe0 | p[1]&0x1f}, p[2:]...)
+		} else if d.fu != nil {
+			d.fu = append(d.fu, p[2:]...)
+		}
+		if end && d.fu != nil {
+			d.nalus = append(d.nalus, d.fu)
+			d.fu = nil
+		}
+	default:
+		d.nalus = append(d.nalus, p)
+	}
+}
+
+func (d *depacketizer) pushH265(p []byte) {
+	if len(p) < 2 {
+		return
+	}
+	switch int(p[0]>>1) & 0x3f {
+	case h265AP:
+		d.aggregate(p[2:])
+	case h265FU:
+		if len(p) < 3 {
+			return
+		}
+		start, end := p[2]&0x80 != 0, p[2]&0x40 != 0
+		if start {
+			d.fu = append([]byte{p[0]&0x81 | (p[2]&0x3f)<<1, p[1]}, p[3:]...)
+		} else if d.fu != nil {
+			d.fu = append(d.fu, p[3:]...)
+		}
+		if end && d.fu != nil {
+			d.nalus = append(d.nalus, d.fu)
+			d.fu = nil
+		}
+	default:
+		d.nalus = append(d.nalus, p)
+	}
+}
+
+// aggregate splits an aggregation packet's 16-bit length-prefixed NAL units
+func (d *depacketizer) aggregate(p []byte) {
+	for len(p) >= 2 {
+		n := int(binary.BigEndian.Uint16(p))
+		if n == 0 || 2+n > len(p) {
+			return
+		}
+		d.nalus = append(d.nalus, p[2:2+n])
+		p = p[2+n:]
+	}
+}
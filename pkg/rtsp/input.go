@@ -0,0 +1,230 @@
+package rtsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func init() {
+	input.Register(Type, func() input.Input { return New() })
+}
+
+// Type is the input plugin name; rtsp inputs with native set use it
+const Type = "rtsp-native"
+
+// ParamTimeout is the input.Config.Params setting for how long the camera
+// may send nothing before reads fail, as a duration (default: 10s)
+const ParamTimeout = "timeout"
+
+const defaultTimeout = 10 * time.Second
+
+// packetQueue is how many access units may wait for ReadPacket before the
+// connection reader blocks and TCP pushes back on the camera
+const packetQueue = 64
+
+var errClosed = errors.New("rtsp input closed")
+
+// Input plays an RTSP camera and delivers its video as packets
+type Input struct {
+	client  *Client
+	stream  input.Stream
+	timeout time.Duration
+	first   *input.Packet // Keyframe read while opening
+
+	packets chan *input.Packet
+	done    chan struct{}
+
+	mu     sync.Mutex
+	err    error // Why the reader stopped
+	closed bool
+}
+
+// New creates a native RTSP input
+func New() *Input {
+	return &Input{}
+}
+
+func (in *Input) Name() string { return "rtsp" }
+func (in *Input) Type() string { return Type }
+
+func (in *Input) Capabilities() input.Capabilities {
+	return input.Capabilities{SupportsVideo: true}
+}
+
+// Open plays the camera at cfg.Device and waits for a keyframe, so the
+// stream's parameter sets and size are known
+func (in *Input) Open(cfg input.Config) error {
+	if cfg.Device == "" {
+		return fmt.Errorf("rtsp input: device is required")
+	}
+	in.timeout = defaultTimeout
+	if v := cfg.Params[ParamTimeout]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("rtsp input: invalid timeout %q", v)
+		}
+		in.timeout = d
+	}
+
+	client, err := Dial(cfg.Device, in.timeout)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	media, err := client.Describe()
+	if err == nil {
+		err = client.Setup(media)
+	}
+	if err == nil {
+		err = client.Play()
+	}
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	in.client = client
+	in.stream = input.Stream{Codec: media.Codec, VPS: media.VPS, SPS: media.SPS, PPS: media.PPS}
+	in.packets = make(chan *input.Packet, packetQueue)
+	in.done = make(chan struct{})
+	recovery.Go("rtsp reader", func() { in.read(media) })
+	recovery.Go("rtsp keepalive", in.keepalive)
+
+	ctx, cancel := context.WithTimeout(context.Background(), in.timeout)
+	defer cancel()
+	if err := in.awaitKeyframe(ctx); err != nil {
+		in.Close()
+		return err
+	}
+	return nil
+}
+
+// awaitKeyframe reads until a keyframe, taking parameter sets from the
+// stream where the camera sends them in-band
+func (in *Input) awaitKeyframe(ctx context.Context) error {
+	for {
+		pkt, err := in.next(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for keyframe: %w", err)
+		}
+		s := &in.stream
+		vps, sps, pps := h26x.ParamSets(s.Codec, pkt.NALUs)
+		if sps != nil && pps != nil {
+			s.VPS, s.SPS, s.PPS = vps, sps, pps // In-band sets win over stale ones in the SDP
+		}
+		if !pkt.Keyframe {
+			continue
+		}
+		if s.SPS == nil || s.PPS == nil || s.Codec == h26x.H265 && s.VPS == nil {
+			return fmt.Errorf("no parameter sets in session description or stream")
+		}
+		if s.Width, s.Height, err = h26x.Size(s.Codec, s.SPS); err != nil {
+			return err
+		}
+		in.first = pkt
+		return nil
+	}
+}
+
+// Stream describes the camera's video
+func (in *Input) Stream() input.Stream {
+	return in.stream
+}
+
+// ReadPacket returns the next access unit
+func (in *Input) ReadPacket(ctx context.Context) (*input.Packet, error) {
+	if pkt := in.first; pkt != nil {
+		in.first = nil
+		return pkt, nil
+	}
+	return in.next(ctx)
+}
+
+func (in *Input) next(ctx context.Context) (*input.Packet, error) {
+	select {
+	case pkt, ok := <-in.packets:
+		if !ok {
+			in.mu.Lock()
+			defer in.mu.Unlock()
+			return nil, in.err
+		}
+		return pkt, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// read depacketizes the camera's RTP until the connection fails or closes
+func (in *Input) read(media *Media) {
+	defer close(in.packets)
+	dep := newDepacketizer(media.Codec)
+	for {
+		rtp, err := in.client.ReadRTP()
+		if err != nil {
+			in.mu.Lock()
+			if in.closed {
+				err = errClosed
+			}
+			in.err = fmt.Errorf("read rtp: %w", err)
+			in.mu.Unlock()
+			return
+		}
+		if rtp.PayloadType != media.PayloadType {
+			continue
+		}
+		now := time.Now().UnixNano()
+		for _, au := range dep.push(rtp) {
+			pkt := &input.Packet{NALUs: au.nalus, PTS: au.pts, DTS: au.pts, Keyframe: au.keyframe, Timestamp: now}
+			select {
+			case in.packets <- pkt:
+			case <-in.done:
+				return
+			}
+		}
+	}
+}
+
+// keepalive refreshes the session until the input closes
+func (in *Input) keepalive() {
+	ticker := time.NewTicker(in.client.KeepaliveInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-in.done:
+			return
+		case <-ticker.C:
+			if err := in.client.Keepalive(); err != nil {
+				return // The reader fails too
+			}
+		}
+	}
+}
+
+// Close tears the session down
+func (in *Input) Close() error {
+	in.mu.Lock()
+	if in.closed || in.client == nil {
+		in.mu.Unlock()
+		return nil
+	}
+	in.closed = true
+	in.mu.Unlock()
+	close(in.done)
+	return in.client.Close()
+}
+
+// ReadFrame always fails: the input delivers packets
+func (in *Input) ReadFrame(ctx context.Context) (*input.Frame, error) {
+	return nil, input.ErrPacketsOnly
+}
+
+// ListDevices returns nothing; cameras are addressed by URL
+func (in *Input) ListDevices() ([]input.Device, error) {
+	return nil, nil
+}
@@ -0,0 +1,207 @@
+package rtsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+)
+
+func packet(seq uint16, ts uint32, marker bool, payload ...byte) *rtp.Packet {
+	return &rtp.Packet{
+		Header:  rtp.Header{Version: 2, SequenceNumber: seq, Timestamp: ts, Marker: marker, PayloadType: 96},
+		Payload: payload,
+	}
+}
+
+func TestDepacketizeH264(t *testing.T) {
+	d := newDepacketizer(h26x.H264)
+
+	// STAP-A with SPS and PPS, then an IDR in two FU-A fragments
+	var out []accessUnit
+	out = append(out, d.push(packet(1, 1000, false, 24, 0, 2, 0x67, 1, 0, 2, 0x68, 2))...)
+	out = append(out, d.push(packet(2, 1000, false, 0x7c, 0x85, 0xaa))...)
+	out = append(out, d.push(packet(3, 1000, true, 0x7c, 0x45, 0xbb))...)
+	// A P frame
+	out = append(out, d.push(packet(4, 4000, true, 0x41, 0xcc))...)
+
+	if len(out) != 2 {
+		t.Fatalf("got %d access units, want 2", len(out))
+	}
+	idr := out[0]
+	if !idr.keyframe || len(idr.nalus) != 3 || !bytes.Equal(idr.nalus[2], []byte{0x65, 0xaa, 0xbb}) {
+		t.Errorf("keyframe = %+v", idr)
+	}
+	if out[1].keyframe || out[1].pts != 3000 {
+		t.Errorf("P frame = %+v, want pts 3000", out[1])
+	}
+}
+
+func TestDepacketizeDropsUntilKeyframeAfterLoss(t *testing.T) {
+	d := newDepacketizer(h26x.H264)
+	if out := d.push(packet(1, 0, true, 0x41, 1)); len(out) != 0 {
+		t.Fatal("P frame before the first keyframe kept")
+	}
+	if out := d.push(packet(2, 3000, true, 0x65, 1)); len(out) != 1 {
+		t.Fatal("keyframe dropped")
+	}
+	// Sequence 3 lost in the middle of a frame
+	d.push(packet(4, 6000, false, 28, 0x81, 1))
+	if out := d.push(packet(5, 6000, true, 28, 0x41, 2)); len(out) != 0 {
+		t.Fatal("frame with a lost packet kept")
+	}
+	if out := d.push(packet(6, 9000, true, 0x41, 3)); len(out) != 0 {
+		t.Fatal("P frame after loss kept")
+	}
+	if out := d.push(packet(7, 12000, true, 0x65, 4)); len(out) != 1 || !out[0].keyframe {
+		t.Fatal("keyframe after loss dropped")
+	}
+}
+
+func TestDepacketizeH265(t *testing.T) {
+	d := newDepacketizer(h26x.H265)
+	// FU carrying an IDR_W_RADL (type 19)
+	out := d.push(packet(1, 0, false, 49<<1, 1, 0x80|19, 0xaa))
+	out = append(out, d.push(packet(2, 0, true, 49<<1, 1, 0x40|19, 0xbb))...)
+	if len(out) != 1 || !out[0].keyframe {
+		t.Fatalf("access units = %+v", out)
+	}
+	if want := []byte{19 << 1, 1, 0xaa, 0xbb}; !bytes.Equal(out[0].nalus[0], want) {
+		t.Errorf("nalu = %x, want %x", out[0].nalus[0], want)
+	}
+}
+
+func TestDepacketizeTimestampWrap(t *testing.T) {
+	d := newDepacketizer(h26x.H264)
+	d.push(packet(1, 0xffffff00, true, 0x65, 1))
+	out := d.push(packet(2, 0x100, true, 0x41, 2))
+	if len(out) != 1 || out[0].pts != 0x200 {
+		t.Errorf("pts across wrap = %+v, want 512", out)
+	}
+}
+
+const testSDP = "v=0\r\n" +
+	"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+	"s=Camera\r\n" +
+	"m=audio 0 RTP/AVP 0\r\n" +
+	"a=control:trackID=2\r\n" +
+	"m=video 0 RTP/AVP 96\r\n" +
+	"a=rtpmap:96 H264/90000\r\n" +
+	"a=fmtp:96 packetization-mode=1; sprop-parameter-sets=Z0IAKA==,aM48gA==\r\n" +
+	"a=control:trackID=1\r\n"
+
+func TestParseSDP(t *testing.T) {
+	m, err := parseSDP([]byte(testSDP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Codec != h26x.H264 || m.PayloadType != 96 || m.Control != "trackID=1" {
+		t.Errorf("media = %+v", m)
+	}
+	if !bytes.Equal(m.SPS, []byte{0x67, 0x42, 0, 0x28}) || !bytes.Equal(m.PPS, []byte{0x68, 0xce, 0x3c, 0x80}) {
+		t.Errorf("parameter sets = %x %x", m.SPS, m.PPS)
+	}
+
+	hevc := "m=video 0 RTP/AVP 97\r\na=rtpmap:97 H265/90000\r\na=fmtp:97 sprop-vps=QAE=;sprop-sps=QgE=;sprop-pps=RAE=\r\n"
+	if m, err := parseSDP([]byte(hevc)); err != nil || m.Codec != h26x.H265 || len(m.VPS) != 2 {
+		t.Errorf("hevc media = %+v, %v", m, err)
+	}
+	if _, err := parseSDP([]byte("m=video 0 RTP/AVP 26\r\na=rtpmap:26 JPEG/90000\r\n")); err == nil {
+		t.Error("MJPEG-only description accepted")
+	}
+}
+
+// fakeCamera answers one client: a Digest challenge, then DESCRIBE, SETUP
+// and PLAY, then sends one interleaved RTP packet
+func fakeCamera(t *testing.T, ln net.Listener) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewReader(bufio.NewReader(conn))
+	authed := false
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		hdr, _ := tp.ReadMIMEHeader()
+		method, _, _ := strings.Cut(line, " ")
+		cseq := hdr.Get("CSeq")
+		if !authed {
+			if !strings.Contains(hdr.Get("Authorization"), `username="admin"`) {
+				fmt.Fprintf(conn, "RTSP/1.0 401 Unauthorized\r\nCSeq: %s\r\nWWW-Authenticate: Digest realm=\"cam\", nonce=\"abc\"\r\n\r\n", cseq)
+				continue
+			}
+			authed = true
+		}
+		switch method {
+		case "DESCRIBE":
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nContent-Base: rtsp://cam/stream/\r\nContent-Length: %d\r\n\r\n%s", cseq, len(testSDP), testSDP)
+		case "SETUP":
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nSession: 1234;timeout=60\r\n\r\n", cseq)
+		case "PLAY":
+			if hdr.Get("Session") != "1234" {
+				t.Errorf("PLAY session = %q", hdr.Get("Session"))
+			}
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\n\r\n", cseq)
+			// RTCP on channel 1 and a stray reply before the video
+			conn.Write([]byte{'$', 1, 0, 2, 0x80, 0xc8})
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: 99\r\n\r\n")
+			pkt, _ := packet(1, 0, true, 0x65, 1).Marshal()
+			frame := append([]byte{'$', 0, 0, 0}, pkt...)
+			binary.BigEndian.PutUint16(frame[2:], uint16(len(pkt)))
+			conn.Write(frame)
+		case "TEARDOWN":
+			return
+		}
+	}
+}
+
+func TestClientSession(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go fakeCamera(t, ln)
+
+	c, err := Dial("rtsp://admin:secret@"+ln.Addr().String()+"/stream", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	m, err := c.Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.control(m.Control); got != "rtsp://cam/stream/trackID=1" {
+		t.Errorf("control url = %q", got)
+	}
+	if err := c.Setup(m); err != nil {
+		t.Fatal(err)
+	}
+	if c.KeepaliveInterval() != 30*time.Second {
+		t.Errorf("keepalive = %s, want half the session timeout", c.KeepaliveInterval())
+	}
+	if err := c.Play(); err != nil {
+		t.Fatal(err)
+	}
+	pkt, err := c.ReadRTP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pkt.Payload, []byte{0x65, 1}) {
+		t.Errorf("payload = %x", pkt.Payload)
+	}
+}
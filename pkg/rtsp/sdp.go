@@ -0,0 +1,101 @@
+package rtsp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+)
+
+// Media is the video stream a session description offers
+type Media struct {
+	Codec       string // h264, hevc
+	PayloadType uint8
+	Control     string // Control URL, absolute or relative to the base
+
+	// Parameter sets from the fmtp line, if the camera sends them there
+	VPS, SPS, PPS []byte
+}
+
+// parseSDP returns the first H.264 or H.265 video media in a session
+// description
+func parseSDP(sdp []byte) (*Media, error) {
+	var media []*Media
+	var cur *Media
+	inVideo := false
+	for _, line := range strings.Split(string(sdp), "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "m="):
+			fields := strings.Fields(line[2:])
+			inVideo = len(fields) >= 4 && fields[0] == "video"
+			cur = nil
+			if inVideo {
+				pt, err := strconv.Atoi(fields[3])
+				if err != nil {
+					inVideo = false
+					continue
+				}
+				cur = &Media{PayloadType: uint8(pt)}
+				media = append(media, cur)
+			}
+		case !inVideo || cur == nil:
+		case strings.HasPrefix(line, "a=rtpmap:"):
+			pt, enc, _ := strings.Cut(line[len("a=rtpmap:"):], " ")
+			if pt != strconv.Itoa(int(cur.PayloadType)) {
+				continue
+			}
+			name, _, _ := strings.Cut(enc, "/")
+			switch strings.ToUpper(name) {
+			case "H264":
+				cur.Codec = h26x.H264
+			case "H265", "HEVC":
+				cur.Codec = h26x.H265
+			}
+		case strings.HasPrefix(line, "a=fmtp:"):
+			pt, params, _ := strings.Cut(line[len("a=fmtp:"):], " ")
+			if pt != strconv.Itoa(int(cur.PayloadType)) {
+				continue
+			}
+			cur.parseFmtp(params)
+		case strings.HasPrefix(line, "a=control:"):
+			cur.Control = strings.TrimSpace(line[len("a=control:"):])
+		}
+	}
+	for _, m := range media {
+		if m.Codec != "" {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("no H.264 or H.265 video in session description")
+}
+
+// parseFmtp reads the parameter sets from the format parameters
+func (m *Media) parseFmtp(params string) {
+	for _, p := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		switch strings.ToLower(k) {
+		case "sprop-parameter-sets":
+			for _, ps := range strings.Split(v, ",") {
+				nalu, err := base64.StdEncoding.DecodeString(ps)
+				if err != nil || len(nalu) == 0 {
+					continue
+				}
+				switch h26x.NALType(h26x.H264, nalu) {
+				case h26x.H264NALSPS:
+					m.SPS = nalu
+				case h26x.H264NALPPS:
+					m.PPS = nalu
+				}
+			}
+		case "sprop-vps":
+			m.VPS, _ = base64.StdEncoding.DecodeString(v)
+		case "sprop-sps":
+			m.SPS, _ = base64.StdEncoding.DecodeString(v)
+		case "sprop-pps":
+			m.PPS, _ = base64.StdEncoding.DecodeString(v)
+		}
+	}
+}
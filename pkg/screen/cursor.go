@@ -0,0 +1,77 @@
+package screen
+
+// Pointer shape types, as DXGI desktop duplication reports them
+const (
+	shapeMonochrome  = 1 // 1bpp AND mask over 1bpp XOR mask
+	shapeColor       = 2 // BGRA, alpha blended
+	shapeMaskedColor = 4 // BGR with alpha 0 (replace) or 0xFF (XOR)
+)
+
+// cursorShape is the pointer image
+type cursorShape struct {
+	typ           int
+	width, height int // Height of the image; a monochrome shape holds two masks
+	pitch         int
+	data          []byte
+}
+
+// cursor is the pointer as last reported, for drawing into captured frames
+// since duplicated desktops don't include it
+type cursor struct {
+	visible bool
+	x, y    int // Top left of the shape on the captured output
+	shape   cursorShape
+}
+
+// draw composites the pointer onto a BGRA image of width x height whose
+// top left is at (ox, oy) on the output
+func (c *cursor) draw(img []byte, width, height, ox, oy int) {
+	if !c.visible || len(c.shape.data) == 0 {
+		return
+	}
+	s := c.shape
+	rows := s.height
+	if s.typ == shapeMonochrome {
+		rows /= 2
+	}
+	for sy := 0; sy < rows; sy++ {
+		y := c.y + sy - oy
+		if y < 0 || y >= height {
+			continue
+		}
+		for sx := 0; sx < s.width; sx++ {
+			x := c.x + sx - ox
+			if x < 0 || x >= width {
+				continue
+			}
+			px := img[(y*width+x)*4 : (y*width+x)*4+4]
+			switch s.typ {
+			case shapeMonochrome:
+				bit := byte(0x80) >> (sx % 8)
+				and := s.data[sy*s.pitch+sx/8]&bit != 0
+				xor := s.data[(sy+rows)*s.pitch+sx/8]&bit != 0
+				switch {
+				case !and && !xor:
+					px[0], px[1], px[2] = 0, 0, 0
+				case !and && xor:
+					px[0], px[1], px[2] = 0xff, 0xff, 0xff
+				case and && xor:
+					px[0], px[1], px[2] = ^px[0], ^px[1], ^px[2]
+				}
+			case shapeColor:
+				src := s.data[sy*s.pitch+sx*4:]
+				a := int(src[3])
+				for i := 0; i < 3; i++ {
+					px[i] = byte((int(src[i])*a + int(px[i])*(255-a)) / 255)
+				}
+			case shapeMaskedColor:
+				src := s.data[sy*s.pitch+sx*4:]
+				if src[3] == 0 {
+					px[0], px[1], px[2] = src[0], src[1], src[2]
+				} else {
+					px[0], px[1], px[2] = px[0]^src[0], px[1]^src[1], px[2]^src[2]
+				}
+			}
+		}
+	}
+}
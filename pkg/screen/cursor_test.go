@@ -0,0 +1,62 @@
+package screen
+
+import (
+	"bytes"
+	"testing"
+)
+
+// gray returns a BGRA image filled with one gray level
+func gray(width, height int, level byte) []byte {
+	return bytes.Repeat([]byte{level, level, level, 0xff}, width*height)
+}
+
+func TestCursorMonochrome(t *testing.T) {
+	// 8x1 shape: AND 0b00111111 then XOR 0b01000001: black, white, untouched x5, inverted
+	c := cursor{visible: true, x: 1, y: 1, shape: cursorShape{
+		typ: shapeMonochrome, width: 8, height: 2, pitch: 1,
+		data: []byte{0b00111111, 0b01000001},
+	}}
+	img := gray(10, 3, 0x40)
+	c.draw(img, 10, 3, 0, 0)
+	row := img[1*10*4:]
+	want := []byte{0x40, 0x00, 0xff, 0x40, 0x40, 0x40, 0x40, 0x40, 0xbf, 0x40}
+	for x, w := range want {
+		if row[x*4] != w {
+			t.Errorf("x=%d: got %#x, want %#x", x, row[x*4], w)
+		}
+	}
+	if !bytes.Equal(img[:40], gray(10, 1, 0x40)) {
+		t.Error("row above the cursor changed")
+	}
+}
+
+func TestCursorColor(t *testing.T) {
+	c := cursor{visible: true, x: -1, y: 0, shape: cursorShape{
+		typ: shapeColor, width: 2, height: 1, pitch: 8,
+		data: []byte{0, 0, 0xff, 0xff, 0xff, 0, 0, 0x80},
+	}}
+	img := gray(2, 1, 0)
+	c.draw(img, 2, 1, 0, 0)
+	// First pixel is off the image; the second is half-blended blue
+	if img[0] != 0x80 || img[1] != 0 || img[2] != 0 || img[4] != 0 {
+		t.Errorf("got %v", img)
+	}
+}
+
+func TestCursorCrop(t *testing.T) {
+	c := cursor{visible: true, x: 100, y: 50, shape: cursorShape{
+		typ: shapeMaskedColor, width: 1, height: 1, pitch: 4,
+		data: []byte{0x10, 0x20, 0x30, 0},
+	}}
+	img := gray(4, 4, 0)
+	c.draw(img, 4, 4, 98, 49) // Cursor lands at (2, 1) of the crop
+	if p := img[(1*4+2)*4:]; p[0] != 0x10 || p[1] != 0x20 || p[2] != 0x30 {
+		t.Errorf("got %v", p[:4])
+	}
+	c.visible = false
+	img = gray(4, 4, 0)
+	c.draw(img, 4, 4, 98, 49)
+	if !bytes.Equal(img, gray(4, 4, 0)) {
+		t.Error("hidden cursor drawn")
+	}
+}
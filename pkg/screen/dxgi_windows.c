@@ -0,0 +1,191 @@
+//go:build cgo
+
+#define COBJMACROS
+#include <initguid.h>
+#include <d3d11.h>
+#include <dxgi1_2.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "dxgi_windows.h"
+
+struct dxgi_dup {
+	ID3D11Device *device;
+	ID3D11DeviceContext *context;
+	IDXGIOutputDuplication *dup;
+	ID3D11Texture2D *staging;
+	int width, height;
+	void *shape;
+	UINT shape_size;
+};
+
+int dxgi_outputs(dxgi_output *outputs, int max) {
+	IDXGIFactory1 *factory;
+	if (FAILED(CreateDXGIFactory1(&IID_IDXGIFactory1, (void **)&factory))) {
+		return 0;
+	}
+	int n = 0;
+	IDXGIAdapter1 *adapter;
+	for (UINT a = 0; n < max && IDXGIFactory1_EnumAdapters1(factory, a, &adapter) != DXGI_ERROR_NOT_FOUND; a++) {
+		IDXGIOutput *output;
+		for (UINT o = 0; n < max && IDXGIAdapter1_EnumOutputs(adapter, o, &output) != DXGI_ERROR_NOT_FOUND; o++) {
+			DXGI_OUTPUT_DESC desc;
+			if (SUCCEEDED(IDXGIOutput_GetDesc(output, &desc)) && desc.AttachedToDesktop) {
+				dxgi_output *out = &outputs[n++];
+				out->adapter = a;
+				out->output = o;
+				out->left = desc.DesktopCoordinates.left;
+				out->top = desc.DesktopCoordinates.top;
+				out->width = desc.DesktopCoordinates.right - desc.DesktopCoordinates.left;
+				out->height = desc.DesktopCoordinates.bottom - desc.DesktopCoordinates.top;
+				WideCharToMultiByte(CP_UTF8, 0, desc.DeviceName, -1, out->name, sizeof(out->name), NULL, NULL);
+			}
+			IDXGIOutput_Release(output);
+		}
+		IDXGIAdapter1_Release(adapter);
+	}
+	IDXGIFactory1_Release(factory);
+	return n;
+}
+
+dxgi_dup *dxgi_open(unsigned adapter_index, unsigned output_index, int *width, int *height, const char **err) {
+	IDXGIFactory1 *factory = NULL;
+	IDXGIAdapter1 *adapter = NULL;
+	IDXGIOutput *output = NULL;
+	IDXGIOutput1 *output1 = NULL;
+	dxgi_dup *d = calloc(1, sizeof(dxgi_dup));
+
+	if (FAILED(CreateDXGIFactory1(&IID_IDXGIFactory1, (void **)&factory))) {
+		*err = "create DXGI factory failed";
+		goto fail;
+	}
+	if (FAILED(IDXGIFactory1_EnumAdapters1(factory, adapter_index, &adapter)) ||
+			FAILED(IDXGIAdapter1_EnumOutputs(adapter, output_index, &output))) {
+		*err = "display not found";
+		goto fail;
+	}
+	if (FAILED(IDXGIOutput_QueryInterface(output, &IID_IDXGIOutput1, (void **)&output1))) {
+		*err = "desktop duplication needs Windows 8 or later";
+		goto fail;
+	}
+	// The device must be on the adapter driving the output
+	if (FAILED(D3D11CreateDevice((IDXGIAdapter *)adapter, D3D_DRIVER_TYPE_UNKNOWN, NULL, 0, NULL, 0,
+			D3D11_SDK_VERSION, &d->device, NULL, &d->context))) {
+		*err = "create D3D11 device failed";
+		goto fail;
+	}
+	HRESULT hr = IDXGIOutput1_DuplicateOutput(output1, (IUnknown *)d->device, &d->dup);
+	if (FAILED(hr)) {
+		*err = hr == DXGI_ERROR_NOT_CURRENTLY_AVAILABLE
+			? "too many applications are duplicating the desktop"
+			: hr == E_ACCESSDENIED ? "access denied (secure desktop or not in the console session)"
+			: "duplicate output failed";
+		goto fail;
+	}
+
+	DXGI_OUTDUPL_DESC desc;
+	IDXGIOutputDuplication_GetDesc(d->dup, &desc);
+	d->width = desc.ModeDesc.Width;
+	d->height = desc.ModeDesc.Height;
+	D3D11_TEXTURE2D_DESC tex = {0};
+	tex.Width = d->width;
+	tex.Height = d->height;
+	tex.MipLevels = 1;
+	tex.ArraySize = 1;
+	tex.Format = DXGI_FORMAT_B8G8R8A8_UNORM;
+	tex.SampleDesc.Count = 1;
+	tex.Usage = D3D11_USAGE_STAGING;
+	tex.CPUAccessFlags = D3D11_CPU_ACCESS_READ;
+	if (FAILED(ID3D11Device_CreateTexture2D(d->device, &tex, NULL, &d->staging))) {
+		*err = "create staging texture failed";
+		goto fail;
+	}
+	*width = d->width;
+	*height = d->height;
+	IDXGIOutput1_Release(output1);
+	IDXGIOutput_Release(output);
+	IDXGIAdapter1_Release(adapter);
+	IDXGIFactory1_Release(factory);
+	return d;
+
+fail:
+	if (output1 != NULL) IDXGIOutput1_Release(output1);
+	if (output != NULL) IDXGIOutput_Release(output);
+	if (adapter != NULL) IDXGIAdapter1_Release(adapter);
+	if (factory != NULL) IDXGIFactory1_Release(factory);
+	dxgi_close(d);
+	return NULL;
+}
+
+int dxgi_next(dxgi_dup *d, int timeout_ms, void *buf, dxgi_pointer *pointer) {
+	DXGI_OUTDUPL_FRAME_INFO info;
+	IDXGIResource *resource;
+	HRESULT hr = IDXGIOutputDuplication_AcquireNextFrame(d->dup, timeout_ms, &info, &resource);
+	if (hr == DXGI_ERROR_WAIT_TIMEOUT) {
+		return DXGI_NONE;
+	}
+	if (hr == DXGI_ERROR_ACCESS_LOST) {
+		return DXGI_LOST;
+	}
+	if (FAILED(hr)) {
+		return DXGI_FAILED;
+	}
+
+	int rc = DXGI_NONE;
+	if (info.LastPresentTime.QuadPart != 0) {
+		ID3D11Texture2D *tex;
+		if (SUCCEEDED(IDXGIResource_QueryInterface(resource, &IID_ID3D11Texture2D, (void **)&tex))) {
+			ID3D11DeviceContext_CopyResource(d->context, (ID3D11Resource *)d->staging, (ID3D11Resource *)tex);
+			ID3D11Texture2D_Release(tex);
+			D3D11_MAPPED_SUBRESOURCE mapped;
+			if (SUCCEEDED(ID3D11DeviceContext_Map(d->context, (ID3D11Resource *)d->staging, 0, D3D11_MAP_READ, 0, &mapped))) {
+				size_t row = (size_t)d->width * 4;
+				for (int y = 0; y < d->height; y++) {
+					memcpy((char *)buf + y * row, (char *)mapped.pData + (size_t)y * mapped.RowPitch, row);
+				}
+				ID3D11DeviceContext_Unmap(d->context, (ID3D11Resource *)d->staging, 0);
+				rc = DXGI_FRAME;
+			}
+		}
+	}
+
+	pointer->moved = 0;
+	pointer->shape_changed = 0;
+	if (info.LastMouseUpdateTime.QuadPart != 0) {
+		pointer->moved = 1;
+		pointer->visible = info.PointerPosition.Visible;
+		pointer->x = info.PointerPosition.Position.x;
+		pointer->y = info.PointerPosition.Position.y;
+	}
+	if (info.PointerShapeBufferSize > 0) {
+		if (info.PointerShapeBufferSize > d->shape_size) {
+			free(d->shape);
+			d->shape = malloc(info.PointerShapeBufferSize);
+			d->shape_size = d->shape != NULL ? info.PointerShapeBufferSize : 0;
+		}
+		DXGI_OUTDUPL_POINTER_SHAPE_INFO shape;
+		UINT size;
+		if (d->shape != NULL && SUCCEEDED(IDXGIOutputDuplication_GetFramePointerShape(d->dup, d->shape_size, d->shape, &size, &shape))) {
+			pointer->shape_changed = 1;
+			pointer->type = shape.Type;
+			pointer->width = shape.Width;
+			pointer->height = shape.Height;
+			pointer->pitch = shape.Pitch;
+			pointer->shape = d->shape;
+			pointer->shape_size = size;
+		}
+	}
+
+	IDXGIResource_Release(resource);
+	IDXGIOutputDuplication_ReleaseFrame(d->dup);
+	return rc;
+}
+
+void dxgi_close(dxgi_dup *d) {
+	if (d->staging != NULL) ID3D11Texture2D_Release(d->staging);
+	if (d->dup != NULL) IDXGIOutputDuplication_Release(d->dup);
+	if (d->context != NULL) ID3D11DeviceContext_Release(d->context);
+	if (d->device != NULL) ID3D11Device_Release(d->device);
+	free(d->shape);
+	free(d);
+}
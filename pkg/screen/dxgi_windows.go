@@ -0,0 +1,321 @@
+//go:build cgo
+
+package screen
+
+/*
+#cgo LDFLAGS: -ld3d11 -ldxgi
+
+#include <stdlib.h>
+#include "dxgi_windows.h"
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func init() {
+	input.Register("screen", func() input.Input { return New() })
+}
+
+const (
+	defaultFramerate = 30
+	maxOutputs       = 16
+)
+
+var errClosed = errors.New("screen input closed")
+
+// IsAvailable reports whether desktop duplication capture is built in
+func IsAvailable() bool { return true }
+
+// Content lists the displays, primary first; windows can't be captured
+// alone on Windows
+func Content() ([]Display, []Window, error) {
+	outputs, err := listOutputs()
+	if err != nil {
+		return nil, nil, err
+	}
+	displays := make([]Display, len(outputs))
+	for i, o := range outputs {
+		displays[i] = Display{
+			ID:          uint32(i),
+			Name:        C.GoString(&o.name[0]),
+			Width:       int(o.width),
+			Height:      int(o.height),
+			PixelWidth:  int(o.width),
+			PixelHeight: int(o.height),
+		}
+	}
+	return displays, nil, nil
+}
+
+// listOutputs returns the outputs attached to the desktop, the primary one,
+// at the desktop origin, first
+func listOutputs() ([]C.dxgi_output, error) {
+	outputs := make([]C.dxgi_output, maxOutputs)
+	outputs = outputs[:C.dxgi_outputs(&outputs[0], maxOutputs)]
+	if len(outputs) == 0 {
+		return nil, errors.New("screen capture: no displays")
+	}
+	for i, o := range outputs {
+		if o.left == 0 && o.top == 0 {
+			outputs[0], outputs[i] = outputs[i], outputs[0]
+			break
+		}
+	}
+	return outputs, nil
+}
+
+// Input captures a display, or a region of it, with DXGI desktop
+// duplication, which keeps up with 60fps where gdigrab doesn't. The pointer
+// isn't part of duplicated images, so it is drawn in unless hidden.
+type Input struct {
+	mu     sync.Mutex  // Guards the duplication against Close
+	handle *C.dxgi_dup // nil while access is lost
+	closed bool
+
+	output    C.dxgi_output
+	crop      Rect // Zero = whole display
+	cursor    bool
+	framerate int
+
+	// Latest desktop image and pointer
+	desktop       []byte
+	width, height int
+	pointer       cursor
+	fresh         bool // desktop holds an image
+	retryAt       time.Time
+
+	// Owned by the goroutine reading frames
+	next time.Time
+	seq  int64
+}
+
+// reopenInterval is how often duplication is restarted while access is
+// lost, e.g. while a UAC prompt is on the secure desktop
+const reopenInterval = time.Second
+
+// New creates a screen input
+func New() *Input {
+	return &Input{}
+}
+
+func (s *Input) Name() string { return "dxgi-duplication" }
+func (s *Input) Type() string { return "screen" }
+
+func (s *Input) Capabilities() input.Capabilities {
+	return input.Capabilities{
+		SupportsVideo:    true,
+		SupportedFormats: []input.PixelFormat{input.FormatBGRA},
+	}
+}
+
+// Open starts duplicating the display selected by cfg.Params at its own
+// resolution; a crop is in pixels
+func (s *Input) Open(cfg input.Config) error {
+	if cfg.Params[ParamWindow] != "" {
+		return errors.New("screen capture: window capture is only supported on macOS")
+	}
+	if cfg.Params[ParamAudio] == "true" {
+		return errors.New("screen capture: system audio is only supported on macOS")
+	}
+	displays, _, err := Content()
+	if err != nil {
+		return err
+	}
+	display, err := findDisplay(cfg.Params[ParamDisplay], displays)
+	if err != nil {
+		return fmt.Errorf("screen capture: %w", err)
+	}
+	outputs, err := listOutputs()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output = outputs[display.ID]
+	s.crop = Rect{}
+	if spec := cfg.Params[ParamCrop]; spec != "" {
+		if s.crop, err = ParseCrop(spec); err != nil {
+			return fmt.Errorf("screen capture: %w", err)
+		}
+	}
+	s.cursor = cfg.Params[ParamCursor] != "false"
+	s.framerate = cfg.Framerate
+	if s.framerate <= 0 {
+		s.framerate = defaultFramerate
+	}
+	s.closed = false
+	return s.reopen()
+}
+
+// reopen (re)starts duplication, at the display's current mode
+func (s *Input) reopen() error {
+	if s.handle != nil {
+		C.dxgi_close(s.handle)
+		s.handle = nil
+	}
+	var width, height C.int
+	var cerr *C.char
+	h := C.dxgi_open(C.uint(s.output.adapter), C.uint(s.output.output), &width, &height, &cerr)
+	if h == nil {
+		s.retryAt = time.Now().Add(reopenInterval)
+		return fmt.Errorf("screen capture %s: %s", C.GoString(&s.output.name[0]), C.GoString(cerr))
+	}
+	c := s.crop
+	if c.Width > 0 && (c.X+c.Width > int(width) || c.Y+c.Height > int(height)) {
+		C.dxgi_close(h)
+		return fmt.Errorf("screen capture: crop %dx%d+%d+%d exceeds the %dx%d display", c.Width, c.Height, c.X, c.Y, width, height)
+	}
+	s.handle = h
+	if int(width) != s.width || int(height) != s.height {
+		s.width, s.height = int(width), int(height)
+		s.desktop = make([]byte, s.width*s.height*4)
+		s.fresh = false
+	}
+	return nil
+}
+
+// Close stops duplicating
+func (s *Input) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.handle != nil {
+		C.dxgi_close(s.handle)
+		s.handle = nil
+	}
+	return nil
+}
+
+// update takes the latest desktop image and pointer, reopening duplication
+// when access was lost; the last image is kept meanwhile
+func (s *Input) update() error {
+	if s.handle == nil {
+		if time.Now().Before(s.retryAt) {
+			return nil
+		}
+		if err := s.reopen(); err != nil {
+			if s.retryAt.After(time.Now()) {
+				return nil // Still lost
+			}
+			return err
+		}
+	}
+	var p C.dxgi_pointer
+	switch C.dxgi_next(s.handle, 0, unsafe.Pointer(&s.desktop[0]), &p) {
+	case C.DXGI_FRAME:
+		s.fresh = true
+	case C.DXGI_LOST, C.DXGI_FAILED:
+		C.dxgi_close(s.handle)
+		s.handle = nil
+		return nil
+	}
+	if p.moved != 0 {
+		s.pointer.visible = p.visible != 0
+		s.pointer.x, s.pointer.y = int(p.x), int(p.y)
+	}
+	if p.shape_changed != 0 {
+		s.pointer.shape = cursorShape{
+			typ:    int(p._type),
+			width:  int(p.width),
+			height: int(p.height),
+			pitch:  int(p.pitch),
+			data:   C.GoBytes(p.shape, p.shape_size),
+		}
+	}
+	return nil
+}
+
+// ReadFrame returns the latest picture at the configured rate; duplication
+// only reports changes, so an idle desktop repeats its last frame. Until
+// the first image frames are black and flagged NoSignal. A mode change
+// resizes the frames.
+func (s *Input) ReadFrame(ctx context.Context) (*input.Frame, error) {
+	interval := time.Second / time.Duration(s.framerate)
+	now := time.Now()
+	if s.next.Before(now.Add(-interval)) {
+		s.next = now // Start, or reader fell behind
+	}
+	if wait := time.Until(s.next); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.next = s.next.Add(interval)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errClosed
+	}
+	if err := s.update(); err != nil {
+		return nil, err
+	}
+
+	// Even sizes for 4:2:0 encoding
+	x, y, width, height := s.crop.X, s.crop.Y, s.crop.Width&^1, s.crop.Height&^1
+	if s.crop.Width == 0 {
+		x, y, width, height = 0, 0, s.width&^1, s.height&^1
+	}
+	data := make([]byte, width*height*4)
+	if s.fresh {
+		for row := 0; row < height; row++ {
+			src := ((y+row)*s.width + x) * 4
+			copy(data[row*width*4:(row+1)*width*4], s.desktop[src:src+width*4])
+		}
+		if s.cursor {
+			s.pointer.draw(data, width, height, x, y)
+		}
+	}
+	s.seq++
+	return &input.Frame{
+		Data:      data,
+		Width:     width,
+		Height:    height,
+		Format:    input.FormatBGRA,
+		Timestamp: now.UnixNano(),
+		Sequence:  s.seq,
+		NoSignal:  !s.fresh,
+	}, nil
+}
+
+// DetectedMode returns the size of the frames being read
+func (s *Input) DetectedMode() input.VideoMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	width, height := s.crop.Width, s.crop.Height
+	if width == 0 {
+		width, height = s.width, s.height
+	}
+	return input.VideoMode{Width: width &^ 1, Height: height &^ 1, Framerate: float64(s.framerate), Format: input.FormatBGRA}
+}
+
+// ListDevices lists the displays by index
+func (s *Input) ListDevices() ([]input.Device, error) {
+	displays, _, err := Content()
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]input.Device, len(displays))
+	for i, d := range displays {
+		devices[i] = input.Device{
+			ID:          strconv.Itoa(i),
+			Name:        d.Name,
+			Type:        "display",
+			Description: fmt.Sprintf("%dx%d", d.PixelWidth, d.PixelHeight),
+		}
+	}
+	return devices, nil
+}
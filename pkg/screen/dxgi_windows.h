@@ -0,0 +1,39 @@
+#ifndef SCREEN_DXGI_H
+#define SCREEN_DXGI_H
+
+// dxgi_next results
+#define DXGI_NONE 0    // No new image
+#define DXGI_FRAME 1   // buf holds a new image
+#define DXGI_LOST -1   // Access lost, e.g. mode change or secure desktop; reopen
+#define DXGI_FAILED -2
+
+typedef struct {
+	unsigned adapter, output;
+	int left, top, width, height; // Desktop coordinates in pixels
+	char name[64];                // e.g. \\.\DISPLAY1
+} dxgi_output;
+
+typedef struct {
+	int moved;   // visible, x and y were updated
+	int visible;
+	int x, y;
+	int shape_changed; // The fields below were updated
+	int type, width, height, pitch;
+	void *shape; // Owned by the duplication; valid until the next call
+	int shape_size;
+} dxgi_pointer;
+
+typedef struct dxgi_dup dxgi_dup;
+
+// dxgi_outputs lists up to max outputs attached to the desktop
+int dxgi_outputs(dxgi_output *outputs, int max);
+
+dxgi_dup *dxgi_open(unsigned adapter, unsigned output, int *width, int *height, const char **err);
+
+// dxgi_next waits up to timeout_ms for the next desktop update, copying a
+// new image to buf as packed BGRA
+int dxgi_next(dxgi_dup *d, int timeout_ms, void *buf, dxgi_pointer *pointer);
+
+void dxgi_close(dxgi_dup *d);
+
+#endif
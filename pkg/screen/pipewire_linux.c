@@ -0,0 +1,464 @@
+//go:build pipewire
+
+#include <dbus/dbus.h>
+#include <pipewire/pipewire.h>
+#include <spa/param/video/format-utils.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <time.h>
+#include <unistd.h>
+
+#include "pipewire_linux.h"
+
+#define PORTAL_BUS "org.freedesktop.portal.Desktop"
+#define PORTAL_PATH "/org/freedesktop/portal/desktop"
+#define SCREENCAST "org.freedesktop.portal.ScreenCast"
+
+// How long the user has to answer the portal's dialog
+#define RESPONSE_TIMEOUT 120
+
+struct pw_capture {
+	DBusConnection *bus; // Holds the portal session open
+	char *session;
+	int token;
+
+	struct pw_thread_loop *loop;
+	struct pw_context *context;
+	struct pw_core *core;
+	struct pw_stream *stream;
+	struct spa_hook listener;
+
+	// Guarded by the thread loop lock
+	int width, height;
+	uint8_t *frame;
+	int has_frame;
+	int stopped;
+	char *err;
+};
+
+// Portal requests
+
+static char *dbus_error(DBusError *e, const char *fallback) {
+	char *msg = strdup(dbus_error_is_set(e) ? e->message : fallback);
+	dbus_error_free(e);
+	return msg;
+}
+
+static DBusMessage *new_call(const char *method) {
+	return dbus_message_new_method_call(PORTAL_BUS, PORTAL_PATH, SCREENCAST, method);
+}
+
+static void add_option(DBusMessageIter *dict, const char *key, int type, const void *value) {
+	DBusMessageIter entry, variant;
+	char sig[2] = {(char)type, 0};
+	dbus_message_iter_open_container(dict, DBUS_TYPE_DICT_ENTRY, NULL, &entry);
+	dbus_message_iter_append_basic(&entry, DBUS_TYPE_STRING, &key);
+	dbus_message_iter_open_container(&entry, DBUS_TYPE_VARIANT, sig, &variant);
+	dbus_message_iter_append_basic(&variant, type, value);
+	dbus_message_iter_close_container(&entry, &variant);
+	dbus_message_iter_close_container(dict, &entry);
+}
+
+// next_token names a request so its response path is known up front
+static void next_token(pw_capture *c, char *token, size_t size) {
+	snprintf(token, size, "gvc%d_%d", (int)getpid(), ++c->token);
+}
+
+// request_path is where the portal answers a request: the sender's unique
+// name without the colon and dots, then the token
+static void request_path(pw_capture *c, const char *token, char *path, size_t size) {
+	char sender[128];
+	snprintf(sender, sizeof(sender), "%s", dbus_bus_get_unique_name(c->bus) + 1);
+	for (char *p = sender; *p; p++) {
+		if (*p == '.') {
+			*p = '_';
+		}
+	}
+	snprintf(path, size, PORTAL_PATH "/request/%s/%s", sender, token);
+}
+
+// request sends a call answered by a Response signal and returns the
+// signal, whose second argument holds the results
+static DBusMessage *request(pw_capture *c, DBusMessage *call, const char *token, char **err) {
+	char path[256], rule[512];
+	request_path(c, token, path, sizeof(path));
+	snprintf(rule, sizeof(rule),
+		"type='signal',interface='org.freedesktop.portal.Request',member='Response',path='%s'", path);
+	DBusError e;
+	dbus_error_init(&e);
+	dbus_bus_add_match(c->bus, rule, &e);
+	if (dbus_error_is_set(&e)) {
+		dbus_message_unref(call);
+		*err = dbus_error(&e, "");
+		return NULL;
+	}
+
+	DBusMessage *reply = dbus_connection_send_with_reply_and_block(c->bus, call, DBUS_TIMEOUT_USE_DEFAULT, &e);
+	dbus_message_unref(call);
+	if (reply == NULL) {
+		*err = dbus_error(&e, "portal call failed");
+		dbus_bus_remove_match(c->bus, rule, NULL);
+		return NULL;
+	}
+	dbus_message_unref(reply);
+
+	DBusMessage *response = NULL;
+	time_t deadline = time(NULL) + RESPONSE_TIMEOUT;
+	while (response == NULL && time(NULL) < deadline && dbus_connection_read_write(c->bus, 1000)) {
+		DBusMessage *msg;
+		while (response == NULL && (msg = dbus_connection_pop_message(c->bus)) != NULL) {
+			if (dbus_message_is_signal(msg, "org.freedesktop.portal.Request", "Response") &&
+					strcmp(dbus_message_get_path(msg), path) == 0) {
+				response = msg;
+			} else {
+				dbus_message_unref(msg);
+			}
+		}
+	}
+	dbus_bus_remove_match(c->bus, rule, NULL);
+	if (response == NULL) {
+		*err = strdup("no answer from the screen sharing dialog");
+		return NULL;
+	}
+
+	DBusMessageIter it;
+	dbus_uint32_t code = 2;
+	if (dbus_message_iter_init(response, &it) && dbus_message_iter_get_arg_type(&it) == DBUS_TYPE_UINT32) {
+		dbus_message_iter_get_basic(&it, &code);
+	}
+	if (code != 0) {
+		dbus_message_unref(response);
+		*err = strdup(code == 1 ? "screen sharing was cancelled" : "screen sharing was refused");
+		return NULL;
+	}
+	return response;
+}
+
+// find_result points value into the variant of a response result
+static int find_result(DBusMessage *response, const char *key, DBusMessageIter *value) {
+	DBusMessageIter it, dict, entry;
+	dbus_message_iter_init(response, &it);
+	if (!dbus_message_iter_next(&it) || dbus_message_iter_get_arg_type(&it) != DBUS_TYPE_ARRAY) {
+		return 0;
+	}
+	dbus_message_iter_recurse(&it, &dict);
+	for (; dbus_message_iter_get_arg_type(&dict) == DBUS_TYPE_DICT_ENTRY; dbus_message_iter_next(&dict)) {
+		const char *name;
+		dbus_message_iter_recurse(&dict, &entry);
+		dbus_message_iter_get_basic(&entry, &name);
+		if (strcmp(name, key) == 0) {
+			dbus_message_iter_next(&entry);
+			dbus_message_iter_recurse(&entry, value);
+			return 1;
+		}
+	}
+	return 0;
+}
+
+// open_session runs the portal's CreateSession, SelectSources and Start
+// and returns the PipeWire node to receive, or 0
+static uint32_t open_session(pw_capture *c, int source_type, int cursor_mode, const char *restore_token,
+		char **new_token, char **err) {
+	char token[64], session_token[64];
+	DBusMessageIter args, dict, value;
+
+	// CreateSession
+	next_token(c, token, sizeof(token));
+	next_token(c, session_token, sizeof(session_token));
+	DBusMessage *call = new_call("CreateSession");
+	dbus_message_iter_init_append(call, &args);
+	dbus_message_iter_open_container(&args, DBUS_TYPE_ARRAY, "{sv}", &dict);
+	const char *t = token, *st = session_token;
+	add_option(&dict, "handle_token", DBUS_TYPE_STRING, &t);
+	add_option(&dict, "session_handle_token", DBUS_TYPE_STRING, &st);
+	dbus_message_iter_close_container(&args, &dict);
+	DBusMessage *response = request(c, call, token, err);
+	if (response == NULL) {
+		return 0;
+	}
+	const char *session = NULL;
+	if (find_result(response, "session_handle", &value) && dbus_message_iter_get_arg_type(&value) == DBUS_TYPE_STRING) {
+		dbus_message_iter_get_basic(&value, &session);
+		c->session = strdup(session);
+	}
+	dbus_message_unref(response);
+	if (c->session == NULL) {
+		*err = strdup("portal returned no session");
+		return 0;
+	}
+
+	// SelectSources: remembered until revoked, so restarts don't ask again
+	next_token(c, token, sizeof(token));
+	call = new_call("SelectSources");
+	dbus_message_iter_init_append(call, &args);
+	dbus_message_iter_append_basic(&args, DBUS_TYPE_OBJECT_PATH, &c->session);
+	dbus_message_iter_open_container(&args, DBUS_TYPE_ARRAY, "{sv}", &dict);
+	dbus_uint32_t types = source_type, cursor = cursor_mode, persist = 2;
+	dbus_bool_t multiple = FALSE;
+	add_option(&dict, "handle_token", DBUS_TYPE_STRING, &t);
+	add_option(&dict, "types", DBUS_TYPE_UINT32, &types);
+	add_option(&dict, "multiple", DBUS_TYPE_BOOLEAN, &multiple);
+	add_option(&dict, "cursor_mode", DBUS_TYPE_UINT32, &cursor);
+	add_option(&dict, "persist_mode", DBUS_TYPE_UINT32, &persist);
+	if (restore_token != NULL && restore_token[0] != 0) {
+		add_option(&dict, "restore_token", DBUS_TYPE_STRING, &restore_token);
+	}
+	dbus_message_iter_close_container(&args, &dict);
+	response = request(c, call, token, err);
+	if (response == NULL) {
+		return 0;
+	}
+	dbus_message_unref(response);
+
+	// Start: shows the dialog unless the restore token was accepted
+	next_token(c, token, sizeof(token));
+	call = new_call("Start");
+	dbus_message_iter_init_append(call, &args);
+	const char *parent = "";
+	dbus_message_iter_append_basic(&args, DBUS_TYPE_OBJECT_PATH, &c->session);
+	dbus_message_iter_append_basic(&args, DBUS_TYPE_STRING, &parent);
+	dbus_message_iter_open_container(&args, DBUS_TYPE_ARRAY, "{sv}", &dict);
+	add_option(&dict, "handle_token", DBUS_TYPE_STRING, &t);
+	dbus_message_iter_close_container(&args, &dict);
+	response = request(c, call, token, err);
+	if (response == NULL) {
+		return 0;
+	}
+	uint32_t node = 0;
+	DBusMessageIter streams, stream;
+	if (find_result(response, "streams", &value) && dbus_message_iter_get_arg_type(&value) == DBUS_TYPE_ARRAY) {
+		dbus_message_iter_recurse(&value, &streams);
+		if (dbus_message_iter_get_arg_type(&streams) == DBUS_TYPE_STRUCT) {
+			dbus_message_iter_recurse(&streams, &stream);
+			dbus_message_iter_get_basic(&stream, &node);
+		}
+	}
+	if (find_result(response, "restore_token", &value) && dbus_message_iter_get_arg_type(&value) == DBUS_TYPE_STRING) {
+		const char *restore;
+		dbus_message_iter_get_basic(&value, &restore);
+		*new_token = strdup(restore);
+	}
+	dbus_message_unref(response);
+	if (node == 0) {
+		*err = strdup("portal returned no stream");
+	}
+	return node;
+}
+
+// open_remote returns the PipeWire connection the portal grants access to
+// the session's streams through
+static int open_remote(pw_capture *c, char **err) {
+	DBusMessageIter args, dict;
+	DBusMessage *call = new_call("OpenPipeWireRemote");
+	dbus_message_iter_init_append(call, &args);
+	dbus_message_iter_append_basic(&args, DBUS_TYPE_OBJECT_PATH, &c->session);
+	dbus_message_iter_open_container(&args, DBUS_TYPE_ARRAY, "{sv}", &dict);
+	dbus_message_iter_close_container(&args, &dict);
+
+	DBusError e;
+	dbus_error_init(&e);
+	DBusMessage *reply = dbus_connection_send_with_reply_and_block(c->bus, call, DBUS_TIMEOUT_USE_DEFAULT, &e);
+	dbus_message_unref(call);
+	int fd = -1;
+	if (reply == NULL || !dbus_message_get_args(reply, &e, DBUS_TYPE_UNIX_FD, &fd, DBUS_TYPE_INVALID)) {
+		*err = dbus_error(&e, "open PipeWire remote failed");
+	}
+	if (reply != NULL) {
+		dbus_message_unref(reply);
+	}
+	return fd;
+}
+
+static void close_session(pw_capture *c) {
+	if (c->session != NULL) {
+		DBusMessage *call = dbus_message_new_method_call(PORTAL_BUS, c->session, "org.freedesktop.portal.Session", "Close");
+		dbus_connection_send(c->bus, call, NULL);
+		dbus_connection_flush(c->bus);
+		dbus_message_unref(call);
+		free(c->session);
+		c->session = NULL;
+	}
+}
+
+// PipeWire stream
+
+static void stream_failed(pw_capture *c, const char *err) {
+	if (!c->stopped) {
+		c->stopped = 1;
+		c->err = strdup(err);
+	}
+}
+
+static void on_state_changed(void *data, enum pw_stream_state old, enum pw_stream_state state, const char *error) {
+	pw_capture *c = data;
+	if (state == PW_STREAM_STATE_ERROR) {
+		stream_failed(c, error != NULL ? error : "stream error");
+	} else if (state == PW_STREAM_STATE_UNCONNECTED) {
+		stream_failed(c, "screen sharing ended");
+	}
+}
+
+// on_param_changed takes the negotiated size and asks for buffers in
+// memory rather than DMA-BUF, which would need a GPU to read
+static void on_param_changed(void *data, uint32_t id, const struct spa_pod *param) {
+	pw_capture *c = data;
+	if (param == NULL || id != SPA_PARAM_Format) {
+		return;
+	}
+	struct spa_video_info_raw info;
+	if (spa_format_video_raw_parse(param, &info) < 0) {
+		return;
+	}
+	if ((int)info.size.width != c->width || (int)info.size.height != c->height) {
+		c->width = info.size.width;
+		c->height = info.size.height;
+		free(c->frame);
+		c->frame = calloc((size_t)c->width * c->height, 4);
+		c->has_frame = 0;
+	}
+
+	uint8_t buffer[256];
+	struct spa_pod_builder b = SPA_POD_BUILDER_INIT(buffer, sizeof(buffer));
+	const struct spa_pod *params[1];
+	params[0] = spa_pod_builder_add_object(&b,
+		SPA_TYPE_OBJECT_ParamBuffers, SPA_PARAM_Buffers,
+		SPA_PARAM_BUFFERS_dataType, SPA_POD_CHOICE_FLAGS_Int((1 << SPA_DATA_MemPtr) | (1 << SPA_DATA_MemFd)));
+	pw_stream_update_params(c->stream, params, 1);
+}
+
+static void on_process(void *data) {
+	pw_capture *c = data;
+	struct pw_buffer *b = pw_stream_dequeue_buffer(c->stream);
+	if (b == NULL) {
+		return;
+	}
+	struct spa_data *d = &b->buffer->datas[0];
+	if (d->data != NULL && d->chunk->size > 0 && c->frame != NULL && c->height > 0) {
+		size_t row = (size_t)c->width * 4;
+		size_t stride = d->chunk->stride > 0 ? (size_t)d->chunk->stride : row;
+		uint8_t *src = (uint8_t *)d->data + d->chunk->offset;
+		if (d->chunk->offset + stride * (c->height - 1) + row <= d->maxsize) {
+			for (int y = 0; y < c->height; y++) {
+				memcpy(c->frame + y * row, src + y * stride, row);
+			}
+			c->has_frame = 1;
+		}
+	}
+	pw_stream_queue_buffer(c->stream, b);
+}
+
+static const struct pw_stream_events stream_events = {
+	PW_VERSION_STREAM_EVENTS,
+	.state_changed = on_state_changed,
+	.param_changed = on_param_changed,
+	.process = on_process,
+};
+
+static int connect_stream(pw_capture *c, int fd, uint32_t node, int fps, char **err) {
+	pw_init(NULL, NULL);
+	c->loop = pw_thread_loop_new("screen-capture", NULL);
+	c->context = pw_context_new(pw_thread_loop_get_loop(c->loop), NULL, 0);
+	if (c->context == NULL || pw_thread_loop_start(c->loop) < 0) {
+		close(fd);
+		*err = strdup("start PipeWire loop failed");
+		return -1;
+	}
+
+	pw_thread_loop_lock(c->loop);
+	c->core = pw_context_connect_fd(c->context, fd, NULL, 0); // Takes the fd
+	if (c->core == NULL) {
+		pw_thread_loop_unlock(c->loop);
+		*err = strdup("connect to PipeWire failed");
+		return -1;
+	}
+	c->stream = pw_stream_new(c->core, "go-video-capture", pw_properties_new(
+		PW_KEY_MEDIA_TYPE, "Video",
+		PW_KEY_MEDIA_CATEGORY, "Capture",
+		PW_KEY_MEDIA_ROLE, "Screen",
+		NULL));
+	pw_stream_add_listener(c->stream, &c->listener, &stream_events, c);
+
+	uint8_t buffer[1024];
+	struct spa_pod_builder b = SPA_POD_BUILDER_INIT(buffer, sizeof(buffer));
+	const struct spa_pod *params[1];
+	params[0] = spa_pod_builder_add_object(&b,
+		SPA_TYPE_OBJECT_Format, SPA_PARAM_EnumFormat,
+		SPA_FORMAT_mediaType, SPA_POD_Id(SPA_MEDIA_TYPE_video),
+		SPA_FORMAT_mediaSubtype, SPA_POD_Id(SPA_MEDIA_SUBTYPE_raw),
+		SPA_FORMAT_VIDEO_format, SPA_POD_CHOICE_ENUM_Id(3, SPA_VIDEO_FORMAT_BGRx, SPA_VIDEO_FORMAT_BGRx, SPA_VIDEO_FORMAT_BGRA),
+		SPA_FORMAT_VIDEO_size, SPA_POD_CHOICE_RANGE_Rectangle(
+			&SPA_RECTANGLE(1920, 1080), &SPA_RECTANGLE(1, 1), &SPA_RECTANGLE(8192, 8192)),
+		SPA_FORMAT_VIDEO_framerate, SPA_POD_CHOICE_RANGE_Fraction(
+			&SPA_FRACTION(fps, 1), &SPA_FRACTION(0, 1), &SPA_FRACTION(240, 1)));
+	int rc = pw_stream_connect(c->stream, PW_DIRECTION_INPUT, node,
+		PW_STREAM_FLAG_AUTOCONNECT | PW_STREAM_FLAG_MAP_BUFFERS, params, 1);
+	pw_thread_loop_unlock(c->loop);
+	if (rc < 0) {
+		*err = strdup("connect PipeWire stream failed");
+		return -1;
+	}
+	return 0;
+}
+
+pw_capture *pw_start(int source_type, int cursor_mode, int fps, const char *restore_token,
+		char **new_token, char **err) {
+	pw_capture *c = calloc(1, sizeof(pw_capture));
+	DBusError e;
+	dbus_error_init(&e);
+	c->bus = dbus_bus_get_private(DBUS_BUS_SESSION, &e);
+	if (c->bus == NULL) {
+		*err = dbus_error(&e, "no session bus");
+		free(c);
+		return NULL;
+	}
+	dbus_connection_set_exit_on_disconnect(c->bus, FALSE);
+
+	uint32_t node = open_session(c, source_type, cursor_mode, restore_token, new_token, err);
+	int fd = node != 0 ? open_remote(c, err) : -1;
+	if (fd < 0 || connect_stream(c, fd, node, fps, err) < 0) {
+		pw_stop(c);
+		return NULL;
+	}
+	return c;
+}
+
+int pw_latest(pw_capture *c, void *buf, int size, int *width, int *height, char **err) {
+	pw_thread_loop_lock(c->loop);
+	int rc = 0;
+	*width = c->width;
+	*height = c->height;
+	if (c->stopped) {
+		*err = strdup(c->err);
+		rc = -1;
+	} else if (c->has_frame && size == c->width * c->height * 4) {
+		memcpy(buf, c->frame, size);
+		rc = 1;
+	}
+	pw_thread_loop_unlock(c->loop);
+	return rc;
+}
+
+void pw_stop(pw_capture *c) {
+	if (c->loop != NULL) {
+		pw_thread_loop_lock(c->loop);
+		if (c->stream != NULL) {
+			pw_stream_destroy(c->stream);
+		}
+		if (c->core != NULL) {
+			pw_core_disconnect(c->core);
+		}
+		pw_thread_loop_unlock(c->loop);
+		pw_thread_loop_stop(c->loop);
+		if (c->context != NULL) {
+			pw_context_destroy(c->context);
+		}
+		pw_thread_loop_destroy(c->loop);
+	}
+	close_session(c);
+	dbus_connection_close(c->bus);
+	dbus_connection_unref(c->bus);
+	free(c->frame);
+	free(c->err);
+	free(c);
+}
@@ -0,0 +1,274 @@
+//go:build cgo && pipewire
+
+package screen
+
+/*
+#cgo pkg-config: libpipewire-0.3 dbus-1
+
+#include <stdlib.h>
+#include "pipewire_linux.h"
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func init() {
+	// X11 sessions keep FFmpeg's x11grab
+	if IsAvailable() {
+		input.Register("screen", func() input.Input { return New() })
+	}
+}
+
+const (
+	defaultFramerate = 30
+
+	// negotiateTimeout bounds the wait for PipeWire to settle the stream's
+	// size once the portal granted it
+	negotiateTimeout = 5 * time.Second
+)
+
+var errClosed = errors.New("screen input closed")
+
+var logger = logging.For("screen")
+
+// IsAvailable reports whether this is a Wayland session, captured through
+// the desktop portal and PipeWire
+func IsAvailable() bool { return os.Getenv("WAYLAND_DISPLAY") != "" }
+
+// Content lists nothing: on Wayland the portal's dialog lets the user pick
+// the monitor or window
+func Content() ([]Display, []Window, error) { return nil, nil, nil }
+
+// Input captures a monitor or window shared through the ScreenCast portal.
+// The user picks it in the desktop's dialog the first time; the portal's
+// restore token is saved so later starts don't ask again.
+type Input struct {
+	mu     sync.Mutex    // Guards the stream against Close
+	handle *C.pw_capture // nil when closed
+
+	crop      Rect // Zero = whole stream, in pixels
+	framerate int
+
+	// Latest image from the stream
+	image         []byte
+	width, height int
+	fresh         bool
+
+	// Owned by the goroutine reading frames
+	next time.Time
+	seq  int64
+}
+
+// New creates a screen input
+func New() *Input {
+	return &Input{}
+}
+
+func (s *Input) Name() string { return "pipewire-screencast" }
+func (s *Input) Type() string { return "screen" }
+
+func (s *Input) Capabilities() input.Capabilities {
+	return input.Capabilities{
+		SupportsVideo:    true,
+		SupportedFormats: []input.PixelFormat{input.FormatBGRA},
+	}
+}
+
+// Open asks the portal for a monitor, or a window when cfg.Params names
+// one, and waits for its stream. display and window only key the saved
+// permission; which one is shared is chosen in the dialog.
+func (s *Input) Open(cfg input.Config) error {
+	if cfg.Params[ParamAudio] == "true" {
+		return errors.New("screen capture: system audio is only supported on macOS")
+	}
+	var err error
+	crop := Rect{}
+	if spec := cfg.Params[ParamCrop]; spec != "" {
+		if crop, err = ParseCrop(spec); err != nil {
+			return fmt.Errorf("screen capture: %w", err)
+		}
+	}
+	source := C.int(portalMonitor)
+	if cfg.Params[ParamWindow] != "" {
+		source = portalWindow
+	}
+	cursor := C.int(portalCursorEmbedded)
+	if cfg.Params[ParamCursor] == "false" {
+		cursor = portalCursorHidden
+	}
+	framerate := cfg.Framerate
+	if framerate <= 0 {
+		framerate = defaultFramerate
+	}
+
+	path, err := tokenFile(cfg.Params[ParamDisplay], cfg.Params[ParamWindow])
+	if err != nil {
+		return fmt.Errorf("screen capture: %w", err)
+	}
+	restore := C.CString(readToken(path))
+	defer C.free(unsafe.Pointer(restore))
+	var newToken, cerr *C.char
+	h := C.pw_start(source, cursor, C.int(framerate), restore, &newToken, &cerr)
+	if h == nil {
+		defer C.free(unsafe.Pointer(cerr))
+		return fmt.Errorf("screen capture: %s", C.GoString(cerr))
+	}
+	if newToken != nil {
+		if err := writeToken(path, C.GoString(newToken)); err != nil {
+			logger.Warn("Saving screen sharing permission failed", "error", err)
+		}
+		C.free(unsafe.Pointer(newToken))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handle = h
+	s.crop = crop
+	s.framerate = framerate
+	s.width, s.height, s.fresh = 0, 0, false
+	deadline := time.Now().Add(negotiateTimeout)
+	for s.width == 0 {
+		if err := s.update(); err != nil {
+			s.close()
+			return err
+		}
+		if s.width == 0 {
+			if time.Now().After(deadline) {
+				s.close()
+				return errors.New("screen capture: stream never started")
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	c := s.crop
+	if c.Width > 0 && (c.X+c.Width > s.width || c.Y+c.Height > s.height) {
+		s.close()
+		return fmt.Errorf("screen capture: crop %dx%d+%d+%d exceeds the %dx%d screen", c.Width, c.Height, c.X, c.Y, s.width, s.height)
+	}
+	return nil
+}
+
+// Close stops the stream and ends the portal session
+func (s *Input) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+	return nil
+}
+
+func (s *Input) close() {
+	if s.handle != nil {
+		C.pw_stop(s.handle)
+		s.handle = nil
+	}
+}
+
+// update takes the stream's latest image, resizing it when the shared
+// monitor or window changed size
+func (s *Input) update() error {
+	var width, height C.int
+	var cerr *C.char
+	for {
+		var buf unsafe.Pointer
+		if len(s.image) > 0 {
+			buf = unsafe.Pointer(&s.image[0])
+		}
+		switch C.pw_latest(s.handle, buf, C.int(len(s.image)), &width, &height, &cerr) {
+		case 1:
+			s.fresh = true
+			return nil
+		case -1:
+			defer C.free(unsafe.Pointer(cerr))
+			return fmt.Errorf("screen capture: %s", C.GoString(cerr))
+		}
+		if int(width) == s.width && int(height) == s.height {
+			return nil // No image yet
+		}
+		s.width, s.height = int(width), int(height)
+		s.image = make([]byte, s.width*s.height*4)
+		s.fresh = false
+	}
+}
+
+// ReadFrame returns the latest picture at the configured rate; PipeWire
+// only sends damaged frames, so an idle screen repeats its last frame.
+// Until the first image frames are black and flagged NoSignal. A size
+// change resizes the frames.
+func (s *Input) ReadFrame(ctx context.Context) (*input.Frame, error) {
+	interval := time.Second / time.Duration(s.framerate)
+	now := time.Now()
+	if s.next.Before(now.Add(-interval)) {
+		s.next = now // Start, or reader fell behind
+	}
+	if wait := time.Until(s.next); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.next = s.next.Add(interval)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.handle == nil {
+		return nil, errClosed
+	}
+	if err := s.update(); err != nil {
+		return nil, err
+	}
+
+	// Even sizes for 4:2:0 encoding; a crop is clipped to a shrunken stream
+	x, y, width, height := s.crop.X, s.crop.Y, s.crop.Width, s.crop.Height
+	if width == 0 {
+		x, y, width, height = 0, 0, s.width, s.height
+	}
+	width, height = min(width, s.width-x)&^1, min(height, s.height-y)&^1
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("screen capture: crop is outside the %dx%d screen", s.width, s.height)
+	}
+	data := make([]byte, width*height*4)
+	if s.fresh {
+		for row := 0; row < height; row++ {
+			src := ((y+row)*s.width + x) * 4
+			copy(data[row*width*4:(row+1)*width*4], s.image[src:src+width*4])
+		}
+	}
+	s.seq++
+	return &input.Frame{
+		Data:      data,
+		Width:     width,
+		Height:    height,
+		Format:    input.FormatBGRA,
+		Timestamp: now.UnixNano(),
+		Sequence:  s.seq,
+		NoSignal:  !s.fresh,
+	}, nil
+}
+
+// DetectedMode returns the size of the frames being read
+func (s *Input) DetectedMode() input.VideoMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	width, height := s.crop.Width, s.crop.Height
+	if width == 0 {
+		width, height = s.width, s.height
+	}
+	return input.VideoMode{Width: width &^ 1, Height: height &^ 1, Framerate: float64(s.framerate), Format: input.FormatBGRA}
+}
+
+// ListDevices lists nothing; the portal's dialog picks what is shared
+func (s *Input) ListDevices() ([]input.Device, error) {
+	return nil, nil
+}
@@ -0,0 +1,21 @@
+#ifndef SCREEN_PIPEWIRE_H
+#define SCREEN_PIPEWIRE_H
+
+typedef struct pw_capture pw_capture;
+
+// pw_start asks the ScreenCast portal for a monitor or window (the user
+// picks one unless restore_token remembers it) and starts receiving it
+// from PipeWire. new_token, when set, is a malloc'd token for next time;
+// errors are malloc'd.
+pw_capture *pw_start(int source_type, int cursor_mode, int fps, const char *restore_token,
+	char **new_token, char **err);
+
+// pw_latest copies the newest frame, packed BGRA, to buf when it is
+// size bytes: 1 when copied, 0 before the first frame or when buf is the
+// wrong size, -1 once the stream stopped with err set. width and height
+// are always set to the stream's size.
+int pw_latest(pw_capture *c, void *buf, int size, int *width, int *height, char **err);
+
+void pw_stop(pw_capture *c);
+
+#endif
@@ -0,0 +1,53 @@
+package screen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Portal source types and cursor modes of the ScreenCast interface
+const (
+	portalMonitor = 1
+	portalWindow  = 2
+
+	portalCursorHidden   = 1
+	portalCursorEmbedded = 2
+)
+
+// tokenFile returns where the portal's restore token for a display or
+// window selection is kept, so restarts don't ask again
+func tokenFile(display, window string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	name := "monitor-" + display
+	if window != "" {
+		name = "window-" + window
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	return filepath.Join(dir, "go-video-capture", "screencast-"+name+".token"), nil
+}
+
+// readToken returns the saved restore token, empty if there is none
+func readToken(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// writeToken saves a restore token; only the user may read it
+func writeToken(path, token string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(token+"\n"), 0600)
+}
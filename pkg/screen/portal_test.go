@@ -0,0 +1,28 @@
+package screen
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	monitor, err := tokenFile("0", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	window, _ := tokenFile("0", "Score/board 1")
+	if filepath.Base(monitor) != "screencast-monitor-0.token" || filepath.Base(window) != "screencast-window-Score_board_1.token" {
+		t.Errorf("token files = %s, %s", monitor, window)
+	}
+
+	if got := readToken(monitor); got != "" {
+		t.Errorf("missing token = %q", got)
+	}
+	if err := writeToken(monitor, "4f1c-token"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readToken(monitor); got != "4f1c-token" {
+		t.Errorf("token = %q", got)
+	}
+}
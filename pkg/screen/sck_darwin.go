@@ -0,0 +1,289 @@
+//go:build cgo
+
+package screen
+
+/*
+#cgo CFLAGS: -fobjc-arc
+#cgo LDFLAGS: -framework ScreenCaptureKit -framework CoreMedia -framework CoreVideo -framework CoreGraphics -framework Foundation
+
+#include <stdlib.h>
+#include "sck_darwin.h"
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func init() {
+	input.Register("screen", func() input.Input { return New() })
+}
+
+const (
+	defaultFramerate = 30
+	audioSampleRate  = 48000
+	audioChannels    = 2
+
+	// readInterval is how often a blocked audio read checks its context
+	readInterval = 100 * time.Millisecond
+)
+
+var errClosed = errors.New("screen input closed")
+
+// IsAvailable reports whether ScreenCaptureKit capture is built in
+func IsAvailable() bool { return true }
+
+// Content lists the displays, main display first, and the on-screen
+// windows that can be captured. It fails until the process is allowed to
+// record the screen.
+func Content() ([]Display, []Window, error) {
+	var (
+		cd      *C.sck_display
+		windows *C.sck_window
+		nd, nw  C.int
+		cerr    *C.char
+	)
+	if C.sck_content(&cd, &nd, &windows, &nw, &cerr) != 0 {
+		defer C.free(unsafe.Pointer(cerr))
+		return nil, nil, fmt.Errorf("screen capture: %s", C.GoString(cerr))
+	}
+	defer C.sck_content_free(cd, windows, nw)
+
+	displays := make([]Display, nd)
+	for i, d := range unsafe.Slice(cd, int(nd)) {
+		displays[i] = Display{
+			ID:          uint32(d.id),
+			Width:       int(d.width),
+			Height:      int(d.height),
+			PixelWidth:  int(d.pixel_width),
+			PixelHeight: int(d.pixel_height),
+		}
+	}
+	list := make([]Window, nw)
+	for i, w := range unsafe.Slice(windows, int(nw)) {
+		list[i] = Window{
+			ID:     uint32(w.id),
+			Title:  C.GoString(w.title),
+			App:    C.GoString(w.app),
+			Width:  int(w.width),
+			Height: int(w.height),
+		}
+	}
+	return displays, list, nil
+}
+
+// Input captures a display, a window or a region of either with
+// ScreenCaptureKit, optionally with system audio
+type Input struct {
+	mu     sync.RWMutex // Held for reading by calls into the stream; Close waits for them
+	handle *C.sck_stream
+	mode   input.VideoMode
+	audio  bool
+
+	// Owned by the goroutine reading frames
+	next time.Time
+	seq  int64
+}
+
+// New creates a screen input
+func New() *Input {
+	return &Input{}
+}
+
+func (s *Input) Name() string { return "screencapturekit" }
+func (s *Input) Type() string { return "screen" }
+
+func (s *Input) Capabilities() input.Capabilities {
+	return input.Capabilities{
+		SupportsAudio:    true,
+		SupportsVideo:    true,
+		SupportedFormats: []input.PixelFormat{input.FormatBGRA},
+	}
+}
+
+// Open starts capturing the display or window selected by cfg.Params. The
+// configured resolution scales the capture; without one it is captured at
+// the display's pixel density.
+func (s *Input) Open(cfg input.Config) error {
+	displays, windows, err := Content()
+	if err != nil {
+		return err
+	}
+	display, err := findDisplay(cfg.Params[ParamDisplay], displays)
+	if err != nil {
+		return fmt.Errorf("screen capture: %w", err)
+	}
+	c := C.sck_config{display: C.uint32_t(display.ID), cursor: 1}
+	width, height := display.Width, display.Height
+	if title := cfg.Params[ParamWindow]; title != "" {
+		w, err := findWindow(title, windows)
+		if err != nil {
+			return fmt.Errorf("screen capture: %w", err)
+		}
+		c.window = C.uint32_t(w.ID)
+		width, height = w.Width, w.Height
+	}
+	if crop := cfg.Params[ParamCrop]; crop != "" {
+		r, err := ParseCrop(crop)
+		if err != nil {
+			return fmt.Errorf("screen capture: %w", err)
+		}
+		c.crop_x, c.crop_y, c.crop_width, c.crop_height = C.int(r.X), C.int(r.Y), C.int(r.Width), C.int(r.Height)
+		width, height = r.Width, r.Height
+	}
+	width, height = captureSize(width, height, display, cfg.Width, cfg.Height)
+	fps := cfg.Framerate
+	if fps <= 0 {
+		fps = defaultFramerate
+	}
+	c.width, c.height, c.fps = C.int(width), C.int(height), C.int(fps)
+	if cfg.Params[ParamCursor] == "false" {
+		c.cursor = 0
+	}
+	audio := cfg.Params[ParamAudio] == "true"
+	if audio {
+		c.audio = 1
+	}
+
+	var cerr *C.char
+	h := C.sck_start(&c, &cerr)
+	if h == nil {
+		defer C.free(unsafe.Pointer(cerr))
+		return fmt.Errorf("screen capture: %s", C.GoString(cerr))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handle = h
+	s.audio = audio
+	s.mode = input.VideoMode{Width: width, Height: height, Framerate: float64(fps), Format: input.FormatBGRA}
+	s.next = time.Time{}
+	return nil
+}
+
+// Close stops capturing, ending blocked reads
+func (s *Input) Close() error {
+	s.mu.RLock()
+	h := s.handle
+	s.mu.RUnlock()
+	if h == nil {
+		return nil
+	}
+	C.sck_stop(h)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.handle != nil {
+		C.sck_free(s.handle)
+		s.handle = nil
+	}
+	return nil
+}
+
+// ReadFrame returns the latest picture at the configured rate.
+// ScreenCaptureKit only sends frames when the picture changes, so an idle
+// screen repeats its last frame; until the first arrives frames are black
+// and flagged NoSignal.
+func (s *Input) ReadFrame(ctx context.Context) (*input.Frame, error) {
+	interval := time.Second / time.Duration(s.mode.Framerate)
+	now := time.Now()
+	if s.next.Before(now.Add(-interval)) {
+		s.next = now // Start, or reader fell behind
+	}
+	if wait := time.Until(s.next); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.next = s.next.Add(interval)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.handle == nil {
+		return nil, errClosed
+	}
+	data := make([]byte, s.mode.Width*s.mode.Height*4)
+	var cerr *C.char
+	rc := C.sck_latest(s.handle, unsafe.Pointer(&data[0]), &cerr)
+	if rc < 0 {
+		defer C.free(unsafe.Pointer(cerr))
+		return nil, fmt.Errorf("screen capture stopped: %s", C.GoString(cerr))
+	}
+	s.seq++
+	return &input.Frame{
+		Data:      data,
+		Width:     s.mode.Width,
+		Height:    s.mode.Height,
+		Format:    input.FormatBGRA,
+		Timestamp: now.UnixNano(),
+		Sequence:  s.seq,
+		NoSignal:  rc == 0,
+	}, nil
+}
+
+// DetectedMode returns the capture size, which without a configured
+// resolution follows the display or window
+func (s *Input) DetectedMode() input.VideoMode {
+	return s.mode
+}
+
+// AudioFormat describes system audio, 48kHz stereo float; no channels
+// unless audio is enabled
+func (s *Input) AudioFormat() input.AudioFormat {
+	if !s.audio {
+		return input.AudioFormat{}
+	}
+	return input.AudioFormat{SampleRate: audioSampleRate, Channels: audioChannels, Sample: "f32le"}
+}
+
+// ReadAudio returns the system audio captured since the last read
+func (s *Input) ReadAudio(ctx context.Context) ([]byte, error) {
+	buf := make([]byte, audioSampleRate*audioChannels*4/10)
+	for {
+		s.mu.RLock()
+		if s.handle == nil {
+			s.mu.RUnlock()
+			return nil, errClosed
+		}
+		n := C.sck_read_audio(s.handle, C.int(readInterval/time.Millisecond), unsafe.Pointer(&buf[0]), C.int(len(buf)))
+		s.mu.RUnlock()
+		switch {
+		case n < 0:
+			return nil, errClosed
+		case n > 0:
+			return buf[:n], nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ListDevices lists the displays, by index, and the windows, by title
+func (s *Input) ListDevices() ([]input.Device, error) {
+	displays, windows, err := Content()
+	if err != nil {
+		return nil, err
+	}
+	var devices []input.Device
+	for i, d := range displays {
+		devices = append(devices, input.Device{
+			ID:          strconv.Itoa(i),
+			Name:        fmt.Sprintf("Display %d", d.ID),
+			Type:        "display",
+			Description: fmt.Sprintf("%dx%d", d.PixelWidth, d.PixelHeight),
+		})
+	}
+	for _, w := range windows {
+		devices = append(devices, input.Device{ID: w.Title, Name: w.App, Type: "window"})
+	}
+	return devices, nil
+}
@@ -0,0 +1,47 @@
+#ifndef SCREEN_SCK_H
+#define SCREEN_SCK_H
+
+#include <stdint.h>
+
+typedef struct {
+	uint32_t id;
+	int width, height;             // Points
+	int pixel_width, pixel_height;
+} sck_display;
+
+typedef struct {
+	uint32_t id;
+	char *title;
+	char *app;
+	int width, height;
+} sck_window;
+
+typedef struct {
+	uint32_t display;
+	uint32_t window; // 0 captures the display
+	int crop_x, crop_y, crop_width, crop_height; // Points; crop_width 0 = all
+	int width, height, fps;
+	int cursor;
+	int audio;
+} sck_config;
+
+typedef struct sck_stream sck_stream;
+
+// sck_content lists displays and on-screen windows; errors are malloc'd
+int sck_content(sck_display **displays, int *ndisplays, sck_window **windows, int *nwindows, char **err);
+void sck_content_free(sck_display *displays, sck_window *windows, int nwindows);
+
+sck_stream *sck_start(const sck_config *cfg, char **err);
+
+// sck_latest copies the newest BGRA frame to buf: 1 when copied, 0 before
+// the first frame, -1 once the stream stopped with err set
+int sck_latest(sck_stream *s, void *buf, char **err);
+
+// sck_read_audio waits up to timeout_ms for interleaved f32le stereo
+// audio: bytes copied, 0 on timeout, -1 once the stream stopped
+int sck_read_audio(sck_stream *s, int timeout_ms, void *buf, int size);
+
+void sck_stop(sck_stream *s);
+void sck_free(sck_stream *s);
+
+#endif
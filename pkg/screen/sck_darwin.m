@@ -0,0 +1,402 @@
+//go:build cgo
+
+#import <CoreGraphics/CoreGraphics.h>
+#import <CoreMedia/CoreMedia.h>
+#import <CoreVideo/CoreVideo.h>
+#import <ScreenCaptureKit/ScreenCaptureKit.h>
+
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/time.h>
+
+#include "sck_darwin.h"
+
+// System audio: 48kHz stereo float, up to a second buffered
+#define AUDIO_RATE 48000
+#define AUDIO_CHANNELS 2
+#define AUDIO_BUFFER (AUDIO_RATE * AUDIO_CHANNELS * 4)
+
+struct sck_stream {
+	void *stream; // SCStream, retained
+	void *output; // SCKOutput, retained
+
+	pthread_mutex_t mu;
+	pthread_cond_t cond;
+	int width, height;
+	uint8_t *frame;
+	int has_frame;
+	uint8_t *audio;
+	int audio_len;
+	int stopped;
+	char *err;
+};
+
+static char *copy_error(NSError *error, const char *fallback) {
+	if (error != nil) {
+		return strdup(error.localizedDescription.UTF8String);
+	}
+	return strdup(fallback);
+}
+
+static void stream_stopped(sck_stream *s, NSError *error) {
+	pthread_mutex_lock(&s->mu);
+	if (!s->stopped) {
+		s->stopped = 1;
+		s->err = copy_error(error, "stopped");
+	}
+	pthread_cond_broadcast(&s->cond);
+	pthread_mutex_unlock(&s->mu);
+}
+
+API_AVAILABLE(macos(12.3))
+@interface SCKOutput : NSObject <SCStreamOutput, SCStreamDelegate>
+@property(nonatomic, assign) sck_stream *s;
+@end
+
+@implementation SCKOutput
+
+- (void)stream:(SCStream *)stream didOutputSampleBuffer:(CMSampleBufferRef)sample ofType:(SCStreamOutputType)type {
+	if (!CMSampleBufferIsValid(sample)) {
+		return;
+	}
+	if (type == SCStreamOutputTypeScreen) {
+		[self video:sample];
+		return;
+	}
+	if (@available(macOS 13.0, *)) {
+		if (type == SCStreamOutputTypeAudio) {
+			[self audio:sample];
+		}
+	}
+}
+
+- (void)stream:(SCStream *)stream didStopWithError:(NSError *)error {
+	stream_stopped(self.s, error);
+}
+
+// video keeps complete frames; idle ones repeat the last picture
+- (void)video:(CMSampleBufferRef)sample {
+	CFArrayRef attachments = CMSampleBufferGetSampleAttachmentsArray(sample, false);
+	if (attachments != NULL && CFArrayGetCount(attachments) > 0) {
+		NSDictionary *info = (__bridge NSDictionary *)CFArrayGetValueAtIndex(attachments, 0);
+		NSNumber *status = info[SCStreamFrameInfoStatus];
+		if (status != nil && status.integerValue != SCFrameStatusComplete) {
+			return;
+		}
+	}
+	CVImageBufferRef pixels = CMSampleBufferGetImageBuffer(sample);
+	if (pixels == NULL) {
+		return;
+	}
+	CVPixelBufferLockBaseAddress(pixels, kCVPixelBufferLock_ReadOnly);
+	int width = (int)CVPixelBufferGetWidth(pixels);
+	int height = (int)CVPixelBufferGetHeight(pixels);
+	size_t stride = CVPixelBufferGetBytesPerRow(pixels);
+	uint8_t *base = CVPixelBufferGetBaseAddress(pixels);
+
+	sck_stream *s = self.s;
+	pthread_mutex_lock(&s->mu);
+	if (base != NULL && width == s->width && height == s->height) {
+		size_t row = (size_t)width * 4;
+		for (int y = 0; y < height; y++) {
+			memcpy(s->frame + y * row, base + y * stride, row);
+		}
+		s->has_frame = 1;
+	}
+	pthread_mutex_unlock(&s->mu);
+	CVPixelBufferUnlockBaseAddress(pixels, kCVPixelBufferLock_ReadOnly);
+}
+
+// audio interleaves the planar float samples ScreenCaptureKit delivers
+- (void)audio:(CMSampleBufferRef)sample {
+	struct {
+		AudioBufferList list;
+		AudioBuffer extra[AUDIO_CHANNELS - 1];
+	} buffers;
+	CMBlockBufferRef block = NULL;
+	if (CMSampleBufferGetAudioBufferListWithRetainedBlockBuffer(sample, NULL, &buffers.list, sizeof(buffers),
+			NULL, NULL, kCMSampleBufferFlag_AudioBufferList_Assure16ByteAlignment, &block) != noErr) {
+		return;
+	}
+	int frames = (int)CMSampleBufferGetNumSamples(sample);
+	AudioBufferList *list = &buffers.list;
+	int planar = list->mNumberBuffers == AUDIO_CHANNELS;
+
+	sck_stream *s = self.s;
+	pthread_mutex_lock(&s->mu);
+	int size = frames * AUDIO_CHANNELS * 4;
+	if (size > AUDIO_BUFFER) {
+		size = AUDIO_BUFFER;
+		frames = size / (AUDIO_CHANNELS * 4);
+	}
+	if (s->audio_len + size > AUDIO_BUFFER) {
+		// Reader behind: drop the oldest
+		int drop = s->audio_len + size - AUDIO_BUFFER;
+		memmove(s->audio, s->audio + drop, s->audio_len - drop);
+		s->audio_len -= drop;
+	}
+	float *out = (float *)(s->audio + s->audio_len);
+	for (int i = 0; i < frames; i++) {
+		for (int c = 0; c < AUDIO_CHANNELS; c++) {
+			out[i * AUDIO_CHANNELS + c] = planar
+				? ((float *)list->mBuffers[c].mData)[i]
+				: ((float *)list->mBuffers[0].mData)[i * AUDIO_CHANNELS + c];
+		}
+	}
+	s->audio_len += size;
+	pthread_cond_broadcast(&s->cond);
+	pthread_mutex_unlock(&s->mu);
+	CFRelease(block);
+}
+
+@end
+
+API_AVAILABLE(macos(12.3))
+static SCShareableContent *shareable_content(char **err) {
+	__block SCShareableContent *content = nil;
+	__block NSError *error = nil;
+	dispatch_semaphore_t done = dispatch_semaphore_create(0);
+	[SCShareableContent getShareableContentExcludingDesktopWindows:YES
+		onScreenWindowsOnly:YES
+		completionHandler:^(SCShareableContent *c, NSError *e) {
+			content = c;
+			error = e;
+			dispatch_semaphore_signal(done);
+		}];
+	dispatch_semaphore_wait(done, DISPATCH_TIME_FOREVER);
+	if (content == nil) {
+		*err = copy_error(error, "no shareable content (is Screen Recording allowed in System Settings?)");
+	}
+	return content;
+}
+
+int sck_content(sck_display **displays, int *ndisplays, sck_window **windows, int *nwindows, char **err) {
+	if (@available(macOS 12.3, *)) {
+		SCShareableContent *content = shareable_content(err);
+		if (content == nil) {
+			return -1;
+		}
+		*ndisplays = (int)content.displays.count;
+		*displays = calloc(*ndisplays + 1, sizeof(sck_display));
+		int i = 0;
+		for (SCDisplay *d in content.displays) {
+			sck_display *out = &(*displays)[i++];
+			out->id = d.displayID;
+			out->width = (int)d.width;
+			out->height = (int)d.height;
+			out->pixel_width = out->width;
+			out->pixel_height = out->height;
+			CGDisplayModeRef mode = CGDisplayCopyDisplayMode(d.displayID);
+			if (mode != NULL) {
+				out->pixel_width = (int)CGDisplayModeGetPixelWidth(mode);
+				out->pixel_height = (int)CGDisplayModeGetPixelHeight(mode);
+				CGDisplayModeRelease(mode);
+			}
+		}
+		// The main display first, so index 0 is the default
+		for (i = 1; i < *ndisplays; i++) {
+			if ((*displays)[i].id == CGMainDisplayID()) {
+				sck_display main = (*displays)[i];
+				(*displays)[i] = (*displays)[0];
+				(*displays)[0] = main;
+			}
+		}
+
+		*nwindows = 0;
+		*windows = calloc(content.windows.count + 1, sizeof(sck_window));
+		for (SCWindow *w in content.windows) {
+			if (w.windowLayer != 0 || w.frame.size.width < 2 || w.frame.size.height < 2) {
+				continue; // Menu bar, dock and other system layers
+			}
+			sck_window *out = &(*windows)[(*nwindows)++];
+			out->id = w.windowID;
+			out->title = strdup(w.title != nil ? w.title.UTF8String : "");
+			out->app = strdup(w.owningApplication.applicationName != nil ? w.owningApplication.applicationName.UTF8String : "");
+			out->width = (int)w.frame.size.width;
+			out->height = (int)w.frame.size.height;
+		}
+		return 0;
+	}
+	*err = strdup("ScreenCaptureKit needs macOS 12.3 or later");
+	return -1;
+}
+
+void sck_content_free(sck_display *displays, sck_window *windows, int nwindows) {
+	for (int i = 0; i < nwindows; i++) {
+		free(windows[i].title);
+		free(windows[i].app);
+	}
+	free(windows);
+	free(displays);
+}
+
+sck_stream *sck_start(const sck_config *cfg, char **err) {
+	if (@available(macOS 12.3, *)) {
+		SCShareableContent *content = shareable_content(err);
+		if (content == nil) {
+			return NULL;
+		}
+		SCContentFilter *filter = nil;
+		if (cfg->window != 0) {
+			for (SCWindow *w in content.windows) {
+				if (w.windowID == cfg->window) {
+					filter = [[SCContentFilter alloc] initWithDesktopIndependentWindow:w];
+				}
+			}
+		} else {
+			for (SCDisplay *d in content.displays) {
+				if (d.displayID == cfg->display) {
+					filter = [[SCContentFilter alloc] initWithDisplay:d excludingWindows:@[]];
+				}
+			}
+		}
+		if (filter == nil) {
+			*err = strdup(cfg->window != 0 ? "window closed" : "display disconnected");
+			return NULL;
+		}
+
+		SCStreamConfiguration *conf = [[SCStreamConfiguration alloc] init];
+		conf.width = cfg->width;
+		conf.height = cfg->height;
+		conf.minimumFrameInterval = CMTimeMake(1, cfg->fps);
+		conf.pixelFormat = kCVPixelFormatType_32BGRA;
+		conf.showsCursor = cfg->cursor != 0;
+		conf.queueDepth = 5;
+		if (cfg->crop_width > 0) {
+			conf.sourceRect = CGRectMake(cfg->crop_x, cfg->crop_y, cfg->crop_width, cfg->crop_height);
+		}
+		if (cfg->audio) {
+			if (@available(macOS 13.0, *)) {
+				conf.capturesAudio = YES;
+				conf.sampleRate = AUDIO_RATE;
+				conf.channelCount = AUDIO_CHANNELS;
+				conf.excludesCurrentProcessAudio = YES;
+			} else {
+				*err = strdup("system audio capture needs macOS 13 or later");
+				return NULL;
+			}
+		}
+
+		sck_stream *s = calloc(1, sizeof(sck_stream));
+		pthread_mutex_init(&s->mu, NULL);
+		pthread_cond_init(&s->cond, NULL);
+		s->width = cfg->width;
+		s->height = cfg->height;
+		s->frame = calloc((size_t)cfg->width * cfg->height, 4);
+		s->audio = malloc(AUDIO_BUFFER);
+
+		SCKOutput *output = [[SCKOutput alloc] init];
+		output.s = s;
+		SCStream *stream = [[SCStream alloc] initWithFilter:filter configuration:conf delegate:output];
+		s->stream = (void *)CFBridgingRetain(stream);
+		s->output = (void *)CFBridgingRetain(output);
+
+		dispatch_queue_t queue = dispatch_queue_create("screen.capture", DISPATCH_QUEUE_SERIAL);
+		NSError *error = nil;
+		BOOL added = [stream addStreamOutput:output type:SCStreamOutputTypeScreen sampleHandlerQueue:queue error:&error];
+		if (added && cfg->audio) {
+			if (@available(macOS 13.0, *)) {
+				added = [stream addStreamOutput:output type:SCStreamOutputTypeAudio sampleHandlerQueue:queue error:&error];
+			}
+		}
+		if (!added) {
+			*err = copy_error(error, "add stream output failed");
+			sck_free(s);
+			return NULL;
+		}
+
+		__block NSError *startError = nil;
+		dispatch_semaphore_t started = dispatch_semaphore_create(0);
+		[stream startCaptureWithCompletionHandler:^(NSError *e) {
+			startError = e;
+			dispatch_semaphore_signal(started);
+		}];
+		dispatch_semaphore_wait(started, DISPATCH_TIME_FOREVER);
+		if (startError != nil) {
+			*err = copy_error(startError, "start capture failed");
+			sck_free(s);
+			return NULL;
+		}
+		return s;
+	}
+	*err = strdup("ScreenCaptureKit needs macOS 12.3 or later");
+	return NULL;
+}
+
+int sck_latest(sck_stream *s, void *buf, char **err) {
+	pthread_mutex_lock(&s->mu);
+	int rc = 0;
+	if (s->stopped) {
+		*err = strdup(s->err);
+		rc = -1;
+	} else if (s->has_frame) {
+		memcpy(buf, s->frame, (size_t)s->width * s->height * 4);
+		rc = 1;
+	}
+	pthread_mutex_unlock(&s->mu);
+	return rc;
+}
+
+int sck_read_audio(sck_stream *s, int timeout_ms, void *buf, int size) {
+	struct timeval now;
+	gettimeofday(&now, NULL);
+	struct timespec deadline;
+	deadline.tv_sec = now.tv_sec + timeout_ms / 1000;
+	deadline.tv_nsec = now.tv_usec * 1000 + (long)(timeout_ms % 1000) * 1000000;
+	if (deadline.tv_nsec >= 1000000000) {
+		deadline.tv_sec++;
+		deadline.tv_nsec -= 1000000000;
+	}
+
+	pthread_mutex_lock(&s->mu);
+	while (s->audio_len == 0 && !s->stopped) {
+		if (pthread_cond_timedwait(&s->cond, &s->mu, &deadline) != 0) {
+			break;
+		}
+	}
+	int n = 0;
+	if (s->stopped) {
+		n = -1;
+	} else if (s->audio_len > 0) {
+		n = s->audio_len < size ? s->audio_len : size;
+		n -= n % (AUDIO_CHANNELS * 4); // Whole sample frames
+		memcpy(buf, s->audio, n);
+		memmove(s->audio, s->audio + n, s->audio_len - n);
+		s->audio_len -= n;
+	}
+	pthread_mutex_unlock(&s->mu);
+	return n;
+}
+
+void sck_stop(sck_stream *s) {
+	pthread_mutex_lock(&s->mu);
+	int stopped = s->stopped;
+	pthread_mutex_unlock(&s->mu);
+	if (!stopped) {
+		if (@available(macOS 12.3, *)) {
+			SCStream *stream = (__bridge SCStream *)s->stream;
+			dispatch_semaphore_t done = dispatch_semaphore_create(0);
+			[stream stopCaptureWithCompletionHandler:^(NSError *e) {
+				dispatch_semaphore_signal(done);
+			}];
+			dispatch_semaphore_wait(done, dispatch_time(DISPATCH_TIME_NOW, 2 * NSEC_PER_SEC));
+		}
+	}
+	stream_stopped(s, nil);
+}
+
+void sck_free(sck_stream *s) {
+	if (s->stream != NULL) {
+		CFBridgingRelease(s->stream);
+	}
+	if (s->output != NULL) {
+		CFBridgingRelease(s->output);
+	}
+	pthread_cond_destroy(&s->cond);
+	pthread_mutex_destroy(&s->mu);
+	free(s->frame);
+	free(s->audio);
+	free(s->err);
+	free(s);
+}
@@ -0,0 +1,111 @@
+// Package screen captures screens natively, replacing FFmpeg's screen
+// inputs in builds with cgo: on macOS a display, a single window or a
+// region of one with ScreenCaptureKit, with system audio; on Windows a
+// display or a region of it with DXGI desktop duplication; on Wayland,
+// built with the pipewire tag, a monitor or window shared through the
+// desktop portal and PipeWire.
+package screen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Settings read from input.Config.Params
+const (
+	ParamDisplay = "display" // Display index or ID (default: main display)
+	ParamWindow  = "window"  // Title or application name of a window to capture alone
+	ParamCrop    = "crop"    // Region to capture, WxH+X+Y in points (pixels on Windows and Wayland)
+	ParamAudio   = "audio"   // "true" to capture system audio
+	ParamCursor  = "cursor"  // "false" to hide the cursor
+)
+
+// Rect is a capture region in points from the top left of the display or
+// window
+type Rect struct {
+	X, Y, Width, Height int
+}
+
+// ParseCrop parses a region given as WxH+X+Y, e.g. 1280x720+0+0
+func ParseCrop(s string) (Rect, error) {
+	var r Rect
+	if n, err := fmt.Sscanf(s, "%dx%d+%d+%d", &r.Width, &r.Height, &r.X, &r.Y); err != nil || n != 4 {
+		return r, fmt.Errorf("invalid crop %q (want WxH+X+Y, e.g. 1280x720+0+0)", s)
+	}
+	if r.Width <= 0 || r.Height <= 0 || r.X < 0 || r.Y < 0 {
+		return r, fmt.Errorf("invalid crop %q: size must be positive and offset not negative", s)
+	}
+	return r, nil
+}
+
+// Display is a display that can be captured, sized in points and pixels
+type Display struct {
+	ID                      uint32
+	Name                    string // Empty where displays have no names
+	Width, Height           int
+	PixelWidth, PixelHeight int
+}
+
+// Window is an on-screen window that can be captured
+type Window struct {
+	ID            uint32
+	Title, App    string
+	Width, Height int
+}
+
+// findDisplay resolves the display setting, an index or a display ID; empty
+// picks the main display, listed first
+func findDisplay(spec string, displays []Display) (Display, error) {
+	if len(displays) == 0 {
+		return Display{}, fmt.Errorf("no displays")
+	}
+	if spec == "" {
+		return displays[0], nil
+	}
+	n, err := strconv.ParseUint(spec, 10, 32)
+	if err != nil {
+		return Display{}, fmt.Errorf("invalid display %q (want an index or display ID)", spec)
+	}
+	if n < uint64(len(displays)) {
+		return displays[n], nil
+	}
+	for _, d := range displays {
+		if d.ID == uint32(n) {
+			return d, nil
+		}
+	}
+	return Display{}, fmt.Errorf("display %s not found (%d present)", spec, len(displays))
+}
+
+// findWindow returns the window whose title matches spec exactly, or else
+// the first whose title or application name contains it, ignoring case
+func findWindow(spec string, windows []Window) (Window, error) {
+	for _, w := range windows {
+		if w.Title == spec {
+			return w, nil
+		}
+	}
+	want := strings.ToLower(spec)
+	for _, w := range windows {
+		if strings.Contains(strings.ToLower(w.Title), want) || strings.Contains(strings.ToLower(w.App), want) {
+			return w, nil
+		}
+	}
+	return Window{}, fmt.Errorf("no window matching %q", spec)
+}
+
+// captureSize returns the pixel size to capture a region of width x height
+// points at: the configured size if set, else the region at the display's
+// pixel density, rounded down to even for 4:2:0 encoding
+func captureSize(width, height int, d Display, cfgWidth, cfgHeight int) (int, int) {
+	if cfgWidth > 0 && cfgHeight > 0 {
+		return cfgWidth, cfgHeight
+	}
+	scale := 1.0
+	if d.Width > 0 && d.PixelWidth > 0 {
+		scale = float64(d.PixelWidth) / float64(d.Width)
+	}
+	w, h := int(float64(width)*scale), int(float64(height)*scale)
+	return w &^ 1, h &^ 1
+}
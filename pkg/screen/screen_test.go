@@ -0,0 +1,65 @@
+package screen
+
+import "testing"
+
+func TestParseCrop(t *testing.T) {
+	r, err := ParseCrop("1280x720+100+50")
+	if err != nil || r != (Rect{X: 100, Y: 50, Width: 1280, Height: 720}) {
+		t.Errorf("ParseCrop = %+v, %v", r, err)
+	}
+	for _, s := range []string{"", "1280x720", "0x720+0+0", "1280x720+-1+0", "wide"} {
+		if _, err := ParseCrop(s); err == nil {
+			t.Errorf("ParseCrop(%q): expected error", s)
+		}
+	}
+}
+
+func TestFindDisplay(t *testing.T) {
+	displays := []Display{{ID: 1}, {ID: 69733382}}
+	tests := []struct {
+		spec string
+		want uint32
+		ok   bool
+	}{
+		{"", 1, true},
+		{"1", 69733382, true},
+		{"69733382", 69733382, true},
+		{"5", 0, false},
+		{"left", 0, false},
+	}
+	for _, tt := range tests {
+		d, err := findDisplay(tt.spec, displays)
+		if (err == nil) != tt.ok || d.ID != tt.want {
+			t.Errorf("findDisplay(%q) = %d, %v", tt.spec, d.ID, err)
+		}
+	}
+}
+
+func TestFindWindow(t *testing.T) {
+	windows := []Window{
+		{ID: 1, Title: "Scoreboard - Live", App: "Safari"},
+		{ID: 2, Title: "Scoreboard", App: "Scorer"},
+		{ID: 3, Title: "Untitled", App: "Keynote"},
+	}
+	for spec, want := range map[string]uint32{"Scoreboard": 2, "live": 1, "keynote": 3} {
+		if w, err := findWindow(spec, windows); err != nil || w.ID != want {
+			t.Errorf("findWindow(%q) = %d, %v", spec, w.ID, err)
+		}
+	}
+	if _, err := findWindow("Terminal", windows); err == nil {
+		t.Error("expected error for missing window")
+	}
+}
+
+func TestCaptureSize(t *testing.T) {
+	retina := Display{Width: 1512, Height: 982, PixelWidth: 3024, PixelHeight: 1964}
+	if w, h := captureSize(1512, 982, retina, 0, 0); w != 3024 || h != 1964 {
+		t.Errorf("display = %dx%d", w, h)
+	}
+	if w, h := captureSize(641, 361, Display{}, 0, 0); w != 640 || h != 360 {
+		t.Errorf("odd region = %dx%d", w, h)
+	}
+	if w, h := captureSize(1512, 982, retina, 1920, 1080); w != 1920 || h != 1080 {
+		t.Errorf("configured = %dx%d", w, h)
+	}
+}
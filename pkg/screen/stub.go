@@ -0,0 +1,13 @@
+//go:build !cgo || (!darwin && !windows && !(linux && pipewire))
+
+package screen
+
+import "errors"
+
+var errNotAvailable = errors.New("native screen capture not available - needs macOS or Windows and cgo")
+
+// IsAvailable reports whether native screen capture is built in
+func IsAvailable() bool { return false }
+
+// Content lists the displays and on-screen windows that can be captured
+func Content() ([]Display, []Window, error) { return nil, nil, errNotAvailable }
@@ -0,0 +1,274 @@
+// Package srt receives SRT in-process through libsrt. Contribution
+// encoders send MPEG-TS over SRT; video already in the channel's codec is
+// demuxed into packets that capture remuxes without an encode, and any
+// other stream is handed to FFmpeg as received.
+package srt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/h26x"
+	"github.com/video-system/go-video-capture/internal/mpegts"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func init() {
+	if IsAvailable() {
+		input.Register(Type, func() input.Input { return New() })
+	}
+}
+
+// Type is the input plugin name; srt inputs with native set use it
+const Type = "srt-native"
+
+// packetQueue is how many access units may wait for ReadPacket before the
+// demuxer blocks and SRT's receive buffer fills
+const packetQueue = 64
+
+var errClosed = errors.New("srt input closed")
+
+// Input receives an SRT stream
+type Input struct {
+	sock   *socket
+	stream input.Stream
+	first  *input.Packet // Keyframe read while opening
+
+	// Set instead of packets when the video isn't remuxed
+	container io.Reader
+
+	packets chan *input.Packet
+	done    chan struct{}
+
+	mu     sync.Mutex
+	err    error // Why the demuxer stopped
+	closed bool
+}
+
+// New creates a native SRT input
+func New() *Input {
+	return &Input{}
+}
+
+func (in *Input) Name() string { return "srt" }
+func (in *Input) Type() string { return Type }
+
+func (in *Input) Capabilities() input.Capabilities {
+	return input.Capabilities{SupportsVideo: true, SupportsAudio: true}
+}
+
+// Open connects, or waits for a caller in listener mode, and reads until
+// the video's codec is known. Video in the codec to remux is then read up
+// to a keyframe, so its parameter sets and size are known. A listener may
+// wait indefinitely; Close ends the wait.
+func (in *Input) Open(cfg input.Config) error {
+	opts, err := parseOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("srt input: %w", err)
+	}
+	sock, err := newSocket(opts)
+	if err != nil {
+		return err
+	}
+	in.mu.Lock()
+	if in.closed {
+		in.mu.Unlock()
+		sock.Close()
+		return errClosed
+	}
+	in.sock = sock
+	in.mu.Unlock()
+
+	if err := sock.connect(); err != nil {
+		in.Close()
+		return err
+	}
+
+	// What's read while probing is kept for FFmpeg in case it gets the stream
+	probe := &probeReader{r: sock, keep: true}
+	dmx := mpegts.NewDemuxer(probe)
+	if err := dmx.ReadPMT(); err != nil {
+		in.Close()
+		return fmt.Errorf("read program: %w", err)
+	}
+	codec := mpegts.Codec(dmx.StreamType())
+	if codec == "" || codec != opts.remux {
+		in.container = io.MultiReader(probe.replay(), sock)
+		return nil
+	}
+	probe.keep, probe.kept = false, nil
+
+	in.stream = input.Stream{Codec: codec}
+	in.mu.Lock()
+	if in.closed {
+		in.mu.Unlock()
+		return errClosed
+	}
+	in.packets = make(chan *input.Packet, packetQueue)
+	in.done = make(chan struct{})
+	in.mu.Unlock()
+	recovery.Go("srt demuxer", func() { in.read(dmx) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+	if err := in.awaitKeyframe(ctx); err != nil {
+		in.Close()
+		return err
+	}
+	return nil
+}
+
+// awaitKeyframe reads until a keyframe and takes the parameter sets from it
+func (in *Input) awaitKeyframe(ctx context.Context) error {
+	for {
+		pkt, err := in.next(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for keyframe: %w", err)
+		}
+		if !pkt.Keyframe {
+			continue
+		}
+		s := &in.stream
+		s.VPS, s.SPS, s.PPS = h26x.ParamSets(s.Codec, pkt.NALUs)
+		if s.SPS == nil || s.PPS == nil || s.Codec == h26x.H265 && s.VPS == nil {
+			return fmt.Errorf("no parameter sets at keyframe")
+		}
+		if s.Width, s.Height, err = h26x.Size(s.Codec, s.SPS); err != nil {
+			return err
+		}
+		in.first = pkt
+		return nil
+	}
+}
+
+// Container returns the stream as received when its video isn't remuxed
+func (in *Input) Container() (io.Reader, string) {
+	if in.container == nil {
+		return nil, ""
+	}
+	return in.container, "mpegts"
+}
+
+// Stream describes the video
+func (in *Input) Stream() input.Stream {
+	return in.stream
+}
+
+// ReadPacket returns the next access unit
+func (in *Input) ReadPacket(ctx context.Context) (*input.Packet, error) {
+	if pkt := in.first; pkt != nil {
+		in.first = nil
+		return pkt, nil
+	}
+	return in.next(ctx)
+}
+
+func (in *Input) next(ctx context.Context) (*input.Packet, error) {
+	if in.packets == nil {
+		return nil, input.ErrPacketsOnly
+	}
+	select {
+	case pkt, ok := <-in.packets:
+		if !ok {
+			in.mu.Lock()
+			defer in.mu.Unlock()
+			return nil, in.err
+		}
+		return pkt, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// read demuxes access units until the connection fails or closes. After
+// loss, access units are dropped until the next keyframe.
+func (in *Input) read(dmx *mpegts.Demuxer) {
+	defer close(in.packets)
+	codec := in.stream.Codec
+	waitKey := true
+	for {
+		pes, err := dmx.ReadPES()
+		if err != nil {
+			in.mu.Lock()
+			if in.closed {
+				err = errClosed
+			}
+			in.err = fmt.Errorf("read stream: %w", err)
+			in.mu.Unlock()
+			return
+		}
+		nalus := h26x.SplitAnnexB(pes.Data)
+		if len(nalus) == 0 {
+			continue
+		}
+		key := h26x.IsKeyframe(codec, nalus)
+		if pes.Discontinuity {
+			waitKey = true
+		}
+		if waitKey && !key {
+			continue
+		}
+		waitKey = false
+		pkt := &input.Packet{NALUs: nalus, PTS: pes.PTS, DTS: pes.DTS, Keyframe: key, Timestamp: time.Now().UnixNano()}
+		select {
+		case in.packets <- pkt:
+		case <-in.done:
+			return
+		}
+	}
+}
+
+// Close closes the connection, ending a wait for a caller
+func (in *Input) Close() error {
+	in.mu.Lock()
+	if in.closed {
+		in.mu.Unlock()
+		return nil
+	}
+	in.closed = true
+	sock, done := in.sock, in.done
+	in.mu.Unlock()
+	if done != nil {
+		close(done)
+	}
+	if sock == nil {
+		return nil
+	}
+	return sock.Close()
+}
+
+// ReadFrame always fails: the input delivers packets or its stream
+func (in *Input) ReadFrame(ctx context.Context) (*input.Frame, error) {
+	return nil, input.ErrPacketsOnly
+}
+
+// ListDevices returns nothing; senders are addressed by URL
+func (in *Input) ListDevices() ([]input.Device, error) {
+	return nil, nil
+}
+
+// probeReader keeps what is read through it while keep is set
+type probeReader struct {
+	r    io.Reader
+	keep bool
+	kept []byte
+}
+
+func (p *probeReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if p.keep {
+		p.kept = append(p.kept, b[:n]...)
+	}
+	return n, err
+}
+
+// replay returns what was kept, for a reader taking the stream over
+func (p *probeReader) replay() io.Reader {
+	return bytes.NewReader(p.kept)
+}
@@ -0,0 +1,215 @@
+//go:build srt && cgo
+
+package srt
+
+/*
+#cgo pkg-config: srt
+
+#include <stdlib.h>
+#include <string.h>
+#include <netdb.h>
+#include <srt/srt.h>
+
+static int gvc_setint(SRTSOCKET s, SRT_SOCKOPT opt, int v) {
+	return srt_setsockflag(s, opt, &v, sizeof v);
+}
+
+static int gvc_setstr(SRTSOCKET s, SRT_SOCKOPT opt, const char *v) {
+	return srt_setsockflag(s, opt, v, (int)strlen(v));
+}
+
+// gvc_addr resolves host (NULL for any) and port and binds or connects s
+static int gvc_addr(SRTSOCKET s, const char *host, const char *port, int bind) {
+	struct addrinfo hints, *res;
+	memset(&hints, 0, sizeof hints);
+	hints.ai_family = AF_UNSPEC;
+	hints.ai_socktype = SOCK_DGRAM;
+	hints.ai_flags = host ? 0 : AI_PASSIVE;
+	if (getaddrinfo(host, port, &hints, &res) != 0) {
+		return SRT_ERROR;
+	}
+	int rc = bind ? srt_bind(s, res->ai_addr, (int)res->ai_addrlen)
+	              : srt_connect(s, res->ai_addr, (int)res->ai_addrlen);
+	freeaddrinfo(res);
+	return rc;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+// maxMessage is the largest live mode message, 7 TS packets in practice
+const maxMessage = 1500
+
+var startup = sync.OnceValue(func() bool { return C.srt_startup() >= 0 })
+
+// IsAvailable reports whether libsrt can be used
+func IsAvailable() bool { return startup() }
+
+// socket is an SRT live mode connection receiving a stream. Reads return
+// the messages' bytes in order, however the caller sizes its buffer.
+type socket struct {
+	opts options
+
+	mu       sync.Mutex
+	listener C.SRTSOCKET // Listener mode, until a caller is accepted
+	conn     C.SRTSOCKET
+	closed   bool
+
+	buf [maxMessage]byte
+	msg []byte // Unread part of the last message
+}
+
+func lastError(op string) error {
+	return fmt.Errorf("srt %s: %s", op, C.GoString(C.srt_getlasterror_str()))
+}
+
+// newSocket creates the socket; listeners are bound and listening
+func newSocket(o options) (*socket, error) {
+	s := C.srt_create_socket()
+	if s == C.SRT_INVALID_SOCK {
+		return nil, lastError("socket")
+	}
+	sock := &socket{opts: o, listener: C.SRT_INVALID_SOCK, conn: C.SRT_INVALID_SOCK}
+	if err := sock.configure(s); err != nil {
+		C.srt_close(s)
+		return nil, err
+	}
+
+	port := C.CString(o.port)
+	defer C.free(unsafe.Pointer(port))
+	if o.mode != "listener" {
+		sock.conn = s
+		if o.mode == "rendezvous" && C.gvc_addr(s, nil, port, 1) == C.SRT_ERROR {
+			C.srt_close(s)
+			return nil, lastError("bind")
+		}
+		return sock, nil
+	}
+	var host *C.char
+	if o.host != "" {
+		host = C.CString(o.host)
+		defer C.free(unsafe.Pointer(host))
+	}
+	if C.gvc_addr(s, host, port, 1) == C.SRT_ERROR {
+		C.srt_close(s)
+		return nil, lastError("bind")
+	}
+	if C.srt_listen(s, 1) == C.SRT_ERROR {
+		C.srt_close(s)
+		return nil, lastError("listen")
+	}
+	sock.listener = s
+	return sock, nil
+}
+
+// configure sets live mode and the connection options on s
+func (sock *socket) configure(s C.SRTSOCKET) error {
+	o := sock.opts
+	set := func(name string, rc C.int) error {
+		if rc == C.SRT_ERROR {
+			return lastError("set " + name)
+		}
+		return nil
+	}
+	err := set("transtype", C.gvc_setint(s, C.SRTO_TRANSTYPE, C.SRTT_LIVE))
+	if err == nil && o.mode == "rendezvous" {
+		err = set("rendezvous", C.gvc_setint(s, C.SRTO_RENDEZVOUS, 1))
+	}
+	if err == nil && o.latency > 0 {
+		err = set("latency", C.gvc_setint(s, C.SRTO_RCVLATENCY, C.int(o.latency.Milliseconds())))
+	}
+	if err == nil {
+		err = set("rcvtimeo", C.gvc_setint(s, C.SRTO_RCVTIMEO, C.int(o.timeout.Milliseconds())))
+	}
+	for _, opt := range []struct {
+		name  string
+		flag  C.SRT_SOCKOPT
+		value string
+	}{
+		{"passphrase", C.SRTO_PASSPHRASE, o.passphrase},
+		{"streamid", C.SRTO_STREAMID, o.streamID},
+	} {
+		if err != nil || opt.value == "" {
+			continue
+		}
+		v := C.CString(opt.value)
+		err = set(opt.name, C.gvc_setstr(s, opt.flag, v))
+		C.free(unsafe.Pointer(v))
+	}
+	return err
+}
+
+// connect connects to the sender, or waits for a caller in listener mode
+func (sock *socket) connect() error {
+	if sock.listener == C.SRT_INVALID_SOCK {
+		host := C.CString(sock.opts.host)
+		defer C.free(unsafe.Pointer(host))
+		port := C.CString(sock.opts.port)
+		defer C.free(unsafe.Pointer(port))
+		if C.gvc_addr(sock.conn, host, port, 0) == C.SRT_ERROR {
+			return lastError("connect")
+		}
+		return nil
+	}
+
+	// Closing the listener ends the wait
+	s := C.srt_accept(sock.listener, nil, nil)
+	sock.mu.Lock()
+	defer sock.mu.Unlock()
+	if s == C.SRT_INVALID_SOCK {
+		if sock.closed {
+			return errors.New("srt input closed")
+		}
+		return lastError("accept")
+	}
+	// Listener options are inherited by accepted sockets
+	sock.conn = s
+	C.srt_close(sock.listener)
+	sock.listener = C.SRT_INVALID_SOCK
+	if sock.closed {
+		C.srt_close(s)
+		return errors.New("srt input closed")
+	}
+	return nil
+}
+
+// Read returns the stream's next bytes
+func (sock *socket) Read(p []byte) (int, error) {
+	if len(sock.msg) == 0 {
+		n := C.srt_recvmsg(sock.conn, (*C.char)(unsafe.Pointer(&sock.buf[0])), maxMessage)
+		if n == C.SRT_ERROR {
+			if C.srt_getsockstate(sock.conn) >= C.SRTS_BROKEN {
+				return 0, io.EOF
+			}
+			return 0, lastError("recv")
+		}
+		sock.msg = sock.buf[:n]
+	}
+	n := copy(p, sock.msg)
+	sock.msg = sock.msg[n:]
+	return n, nil
+}
+
+// Close closes the connection and the listener
+func (sock *socket) Close() error {
+	sock.mu.Lock()
+	defer sock.mu.Unlock()
+	if sock.closed {
+		return nil
+	}
+	sock.closed = true
+	if sock.listener != C.SRT_INVALID_SOCK {
+		C.srt_close(sock.listener)
+	}
+	if sock.conn != C.SRT_INVALID_SOCK {
+		C.srt_close(sock.conn)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package srt
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+// input.Config.Params settings; they override the same settings in the
+// device URL's query
+const (
+	ParamMode       = "mode"       // caller (default), listener, rendezvous
+	ParamLatency    = "latency"    // Receiver latency as a duration (default: SRT's 120ms)
+	ParamPassphrase = "passphrase" // Encryption passphrase
+	ParamStreamID   = "streamid"   // Stream ID sent by callers
+	ParamTimeout    = "timeout"    // How long the sender may send nothing before reads fail (default: 10s)
+
+	// ParamRemux is the codec (h264, hevc) whose video is delivered as
+	// packets to remux; in any other codec, or when empty, the stream goes
+	// to FFmpeg to encode
+	ParamRemux = "remux"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// options are the settings of an SRT connection
+type options struct {
+	mode       string
+	host, port string
+	latency    time.Duration
+	passphrase string
+	streamID   string
+	timeout    time.Duration
+	remux      string
+}
+
+// parseOptions reads the connection settings from the device URL, as
+// FFmpeg takes it (srt://host:port?mode=listener&latency=200000, latency
+// in microseconds), and from the params
+func parseOptions(cfg input.Config) (options, error) {
+	u, err := url.Parse(cfg.Device)
+	if err != nil || u.Scheme != "srt" {
+		return options{}, fmt.Errorf("invalid srt url %q", cfg.Device)
+	}
+	q := u.Query()
+	o := options{
+		mode:       q.Get("mode"),
+		host:       u.Hostname(),
+		port:       u.Port(),
+		passphrase: q.Get("passphrase"),
+		streamID:   q.Get("streamid"),
+		timeout:    defaultTimeout,
+		remux:      cfg.Params[ParamRemux],
+	}
+	if v := q.Get("latency"); v != "" {
+		us, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || us < 0 {
+			return o, fmt.Errorf("invalid latency %q", v)
+		}
+		o.latency = time.Duration(us) * time.Microsecond
+	}
+
+	p := cfg.Params
+	if v := p[ParamMode]; v != "" {
+		o.mode = v
+	}
+	if v := p[ParamPassphrase]; v != "" {
+		o.passphrase = v
+	}
+	if v := p[ParamStreamID]; v != "" {
+		o.streamID = v
+	}
+	for name, d := range map[string]*time.Duration{ParamLatency: &o.latency, ParamTimeout: &o.timeout} {
+		if v := p[name]; v != "" {
+			if *d, err = time.ParseDuration(v); err != nil || *d < 0 {
+				return o, fmt.Errorf("invalid %s %q", name, v)
+			}
+		}
+	}
+
+	switch o.mode {
+	case "":
+		o.mode = "caller"
+	case "caller", "listener", "rendezvous":
+	default:
+		return o, fmt.Errorf("unknown mode %q", o.mode)
+	}
+	if o.port == "" {
+		return o, fmt.Errorf("srt url %q has no port", cfg.Device)
+	}
+	if o.host == "" && o.mode != "listener" {
+		return o, fmt.Errorf("srt url %q has no host to connect to", cfg.Device)
+	}
+	if o.timeout == 0 {
+		o.timeout = defaultTimeout
+	}
+	return o, nil
+}
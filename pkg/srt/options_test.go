@@ -0,0 +1,44 @@
+package srt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/input"
+)
+
+func TestParseOptions(t *testing.T) {
+	o, err := parseOptions(input.Config{
+		Device: "srt://:9000?mode=listener&latency=200000&passphrase=secretsecret",
+		Params: map[string]string{ParamStreamID: "cam1", ParamRemux: "h264"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := options{mode: "listener", port: "9000", latency: 200 * time.Millisecond,
+		passphrase: "secretsecret", streamID: "cam1", timeout: defaultTimeout, remux: "h264"}
+	if o != want {
+		t.Errorf("options = %+v, want %+v", o, want)
+	}
+
+	// Params win over the URL
+	o, err = parseOptions(input.Config{
+		Device: "srt://encoder:9000?latency=200000",
+		Params: map[string]string{ParamLatency: "80ms", ParamTimeout: "3s"},
+	})
+	if err != nil || o.mode != "caller" || o.host != "encoder" || o.latency != 80*time.Millisecond || o.timeout != 3*time.Second {
+		t.Errorf("options = %+v, %v", o, err)
+	}
+
+	for _, device := range []string{
+		"udp://:9000",
+		"srt://encoder",
+		"srt://:9000",
+		"srt://:9000?mode=push",
+		"srt://encoder:9000?latency=fast",
+	} {
+		if _, err := parseOptions(input.Config{Device: device}); err == nil {
+			t.Errorf("%s accepted", device)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !srt || !cgo
+
+package srt
+
+import "errors"
+
+var errNotAvailable = errors.New("libsrt not available - build with -tags srt")
+
+// IsAvailable reports whether libsrt can be used
+func IsAvailable() bool { return false }
+
+type socket struct{}
+
+func newSocket(options) (*socket, error) { return nil, errNotAvailable }
+
+func (s *socket) connect() error             { return errNotAvailable }
+func (s *socket) Read(p []byte) (int, error) { return 0, errNotAvailable }
+func (s *socket) Close() error               { return nil }
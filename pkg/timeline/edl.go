@@ -0,0 +1,63 @@
+package timeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// recordStartSeconds is the record timecode of the first edit (01:00:00:00)
+const recordStartSeconds = 3600
+
+// EDL renders a CMX 3600 edit decision list. Each clip or ghost clip
+// becomes a cut on the record side; mark-ins become LOC comments.
+func EDL(title string, src Source, events []Event) []byte {
+	rate := src.Rate
+	reel := reelName(src.Name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "TITLE: %s\n", title)
+	b.WriteString("FCM: NON-DROP FRAME\n\n")
+
+	rec := int64(recordStartSeconds * rate)
+	marks := markers(events)
+	for i, e := range ranges(events) {
+		srcIn := todFrames(e.Start, rate)
+		dur := durationFrames(e.End.Sub(e.Start), rate)
+
+		fmt.Fprintf(&b, "%03d  %-8s V     C        %s %s %s %s\n",
+			i+1, reel,
+			timecode(srcIn, rate), timecode(srcIn+dur, rate),
+			timecode(rec, rate), timecode(rec+dur, rate))
+		fmt.Fprintf(&b, "* FROM CLIP NAME: %s\n", e.Name)
+		if e.FilePath != "" {
+			fmt.Fprintf(&b, "* SOURCE FILE: %s\n", e.FilePath)
+		}
+		for _, m := range marks {
+			if m.Start.Before(e.Start) || !m.Start.Before(e.End) {
+				continue
+			}
+			at := rec + todFrames(m.Start, rate) - srcIn
+			fmt.Fprintf(&b, "* LOC: %s RED     %s\n", timecode(at, rate), m.Name)
+		}
+		b.WriteString("\n")
+		rec += dur
+	}
+	return []byte(b.String())
+}
+
+// reelName makes a CMX-safe reel name: up to 8 uppercase alphanumerics
+func reelName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+		if b.Len() == 8 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "AX"
+	}
+	return b.String()
+}
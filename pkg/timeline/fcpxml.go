@@ -0,0 +1,137 @@
+package timeline
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type fcpxmlDoc struct {
+	XMLName   xml.Name     `xml:"fcpxml"`
+	Version   string       `xml:"version,attr"`
+	Resources fcpResources `xml:"resources"`
+	Event     fcpEvent     `xml:"library>event"`
+}
+
+type fcpResources struct {
+	Format fcpFormat `xml:"format"`
+	Asset  fcpAsset  `xml:"asset"`
+}
+
+type fcpFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+}
+
+type fcpAsset struct {
+	ID       string      `xml:"id,attr"`
+	Name     string      `xml:"name,attr"`
+	Start    string      `xml:"start,attr"`
+	HasVideo int         `xml:"hasVideo,attr"`
+	HasAudio int         `xml:"hasAudio,attr"`
+	Format   string      `xml:"format,attr"`
+	MediaRep fcpMediaRep `xml:"media-rep"`
+}
+
+type fcpMediaRep struct {
+	Kind string `xml:"kind,attr"`
+	Src  string `xml:"src,attr"`
+}
+
+type fcpEvent struct {
+	Name    string     `xml:"name,attr"`
+	Project fcpProject `xml:"project"`
+}
+
+type fcpProject struct {
+	Name     string      `xml:"name,attr"`
+	Sequence fcpSequence `xml:"sequence"`
+}
+
+type fcpSequence struct {
+	Format   string         `xml:"format,attr"`
+	TCStart  string         `xml:"tcStart,attr"`
+	TCFormat string         `xml:"tcFormat,attr"`
+	Clips    []fcpAssetClip `xml:"spine>asset-clip"`
+}
+
+type fcpAssetClip struct {
+	Ref      string      `xml:"ref,attr"`
+	Name     string      `xml:"name,attr"`
+	Offset   string      `xml:"offset,attr"`
+	Start    string      `xml:"start,attr"`
+	Duration string      `xml:"duration,attr"`
+	Markers  []fcpMarker `xml:"marker"`
+}
+
+type fcpMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+// FCPXML renders a Final Cut Pro XML (1.9) project with one asset-clip per
+// clip or ghost clip, cut from the continuous recording asset
+func FCPXML(title string, src Source, events []Event) ([]byte, error) {
+	rate := src.Rate
+	t := func(frames int64) string { return fmt.Sprintf("%d/%ds", frames, rate) }
+
+	doc := fcpxmlDoc{
+		Version: "1.9",
+		Resources: fcpResources{
+			Format: fcpFormat{ID: "r1", FrameDuration: fmt.Sprintf("1/%ds", rate)},
+			Asset: fcpAsset{
+				ID:       "r2",
+				Name:     src.Name,
+				Start:    t(todFrames(src.Start, rate)),
+				HasVideo: 1,
+				HasAudio: 1,
+				Format:   "r1",
+				MediaRep: fcpMediaRep{Kind: "original-media", Src: src.URL},
+			},
+		},
+		Event: fcpEvent{
+			Name: title,
+			Project: fcpProject{
+				Name: title,
+				Sequence: fcpSequence{
+					Format:   "r1",
+					TCStart:  t(int64(recordStartSeconds * rate)),
+					TCFormat: "NDF",
+				},
+			},
+		},
+	}
+
+	marks := markers(events)
+	var offset int64
+	for _, e := range ranges(events) {
+		start := todFrames(e.Start, rate)
+		dur := durationFrames(e.End.Sub(e.Start), rate)
+		clip := fcpAssetClip{
+			Ref:      "r2",
+			Name:     e.Name,
+			Offset:   t(int64(recordStartSeconds*rate) + offset),
+			Start:    t(start),
+			Duration: t(dur),
+		}
+		// Marker times are in the clip's source (asset) time
+		for _, m := range marks {
+			if m.Start.Before(e.Start) || !m.Start.Before(e.End) {
+				continue
+			}
+			clip.Markers = append(clip.Markers, fcpMarker{
+				Start:    t(todFrames(m.Start, rate)),
+				Duration: t(1),
+				Value:    m.Name,
+			})
+		}
+		doc.Event.Project.Sequence.Clips = append(doc.Event.Project.Sequence.Clips, clip)
+		offset += dur
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode fcpxml: %w", err)
+	}
+	return append([]byte(xml.Header+"<!DOCTYPE fcpxml>\n"), out...), nil
+}
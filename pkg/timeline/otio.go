@@ -0,0 +1,126 @@
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// otioTime is an OTIO RationalTime
+type otioTime struct {
+	Schema string  `json:"OTIO_SCHEMA"`
+	Rate   float64 `json:"rate"`
+	Value  float64 `json:"value"`
+}
+
+// otioRange is an OTIO TimeRange
+type otioRange struct {
+	Schema    string   `json:"OTIO_SCHEMA"`
+	StartTime otioTime `json:"start_time"`
+	Duration  otioTime `json:"duration"`
+}
+
+func rationalTime(frames int64, rate int) otioTime {
+	return otioTime{Schema: "RationalTime.1", Rate: float64(rate), Value: float64(frames)}
+}
+
+func timeRange(start, dur int64, rate int) *otioRange {
+	return &otioRange{
+		Schema:    "TimeRange.1",
+		StartTime: rationalTime(start, rate),
+		Duration:  rationalTime(dur, rate),
+	}
+}
+
+// otioItem covers the OTIO schemas used here (Timeline, Stack, Track,
+// Clip, Marker, ExternalReference); unused fields are omitted
+type otioItem struct {
+	Schema          string                 `json:"OTIO_SCHEMA"`
+	Name            string                 `json:"name,omitempty"`
+	Kind            string                 `json:"kind,omitempty"`
+	TargetURL       string                 `json:"target_url,omitempty"`
+	Color           string                 `json:"color,omitempty"`
+	GlobalStartTime *otioTime              `json:"global_start_time,omitempty"`
+	SourceRange     *otioRange             `json:"source_range,omitempty"`
+	AvailableRange  *otioRange             `json:"available_range,omitempty"`
+	MarkedRange     *otioRange             `json:"marked_range,omitempty"`
+	MediaReference  *otioItem              `json:"media_reference,omitempty"`
+	Tracks          *otioItem              `json:"tracks,omitempty"`
+	Children        []*otioItem            `json:"children,omitempty"`
+	Markers         []*otioItem            `json:"markers,omitempty"`
+	Metadata        map[string]interface{} `json:"metadata"`
+}
+
+// OTIO renders an OpenTimelineIO timeline with one clip per clip or ghost
+// clip on a single video track, and mark-ins as timeline markers
+func OTIO(title string, src Source, events []Event) ([]byte, error) {
+	rate := src.Rate
+	recStart := int64(recordStartSeconds * rate)
+
+	track := &otioItem{Schema: "Track.1", Name: "V1", Kind: "Video", Metadata: map[string]interface{}{}}
+	stack := &otioItem{Schema: "Stack.1", Name: "tracks", Children: []*otioItem{track}, Metadata: map[string]interface{}{}}
+
+	var offset int64
+	var clips []struct{ start, offset int64 }
+	rangeEvents := ranges(events)
+	for _, e := range rangeEvents {
+		start := todFrames(e.Start, rate)
+		dur := durationFrames(e.End.Sub(e.Start), rate)
+		track.Children = append(track.Children, &otioItem{
+			Schema:      "Clip.1",
+			Name:        e.Name,
+			SourceRange: timeRange(start, dur, rate),
+			MediaReference: &otioItem{
+				Schema:    "ExternalReference.1",
+				TargetURL: src.URL,
+				Metadata:  map[string]interface{}{},
+			},
+			Metadata: map[string]interface{}{
+				"capture": map[string]interface{}{
+					"kind":      e.Kind,
+					"file_path": e.FilePath,
+					"tags":      e.Tags,
+				},
+			},
+		})
+		clips = append(clips, struct{ start, offset int64 }{start, offset})
+		offset += dur
+	}
+
+	// Markers go on the stack, positioned in timeline time
+	for _, m := range markers(events) {
+		for i, e := range rangeEvents {
+			if m.Start.Before(e.Start) || !m.Start.Before(e.End) {
+				continue
+			}
+			at := clips[i].offset + todFrames(m.Start, rate) - clips[i].start
+			stack.Markers = append(stack.Markers, &otioItem{
+				Schema:      "Marker.2",
+				Name:        m.Name,
+				Color:       "RED",
+				MarkedRange: timeRange(at, 0, rate),
+				Metadata:    map[string]interface{}{},
+			})
+			break
+		}
+	}
+
+	start := rationalTime(recStart, rate)
+	doc := &otioItem{
+		Schema:          "Timeline.1",
+		Name:            title,
+		GlobalStartTime: &start,
+		Tracks:          stack,
+		Metadata: map[string]interface{}{
+			"capture": map[string]interface{}{
+				"source":          src.Name,
+				"recording_start": src.Start,
+			},
+		},
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode otio: %w", err)
+	}
+	return out, nil
+}
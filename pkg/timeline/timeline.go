@@ -0,0 +1,193 @@
+// Package timeline records a session's marks and clips and exports them as
+// EDL, FCPXML or OpenTimelineIO so editors can conform highlights in an NLE.
+//
+// Events are placed against the continuous recording using time-of-day
+// timecode, which is how venue recorders stamp their files.
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kind identifies what produced an event
+type Kind string
+
+const (
+	KindMarker Kind = "marker" // Mark-in point
+	KindGhost  Kind = "ghost"  // Ghost clip (mark-in to mark-out)
+	KindClip   Kind = "clip"   // Generated clip file
+)
+
+// Event is a marker or range on the session timeline
+type Event struct {
+	Kind     Kind                   `json:"kind"`
+	Name     string                 `json:"name"` // Play ID
+	Start    time.Time              `json:"start"`
+	End      time.Time              `json:"end,omitempty"` // Zero for markers
+	FilePath string                 `json:"file_path,omitempty"`
+	Tags     map[string]interface{} `json:"tags,omitempty"`
+}
+
+// IsRange reports whether the event covers a time range
+func (e Event) IsRange() bool {
+	return !e.End.IsZero() && e.End.After(e.Start)
+}
+
+// Source describes the continuous recording events refer to
+type Source struct {
+	Name  string    // Reel / asset name
+	URL   string    // Media location for FCPXML and OTIO
+	Start time.Time // Recording start
+	Rate  int       // Frames per second
+}
+
+// Log is a session event log, optionally persisted as JSON
+type Log struct {
+	mu     sync.Mutex
+	path   string
+	events []Event
+}
+
+// NewLog creates an event log, loading existing events from path if present.
+// An empty path keeps the log in memory only.
+func NewLog(path string) (*Log, error) {
+	l := &Log{path: path}
+	if path == "" {
+		return l, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read timeline: %w", err)
+	}
+	if err := json.Unmarshal(data, &l.events); err != nil {
+		return nil, fmt.Errorf("parse timeline: %w", err)
+	}
+	return l, nil
+}
+
+// Add appends an event and persists the log
+func (l *Log) Add(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, e)
+	l.saveLocked()
+}
+
+// RemoveMarker drops the newest marker with the given name, e.g. a mark-in
+// that was cancelled
+func (l *Log) RemoveMarker(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if e := l.events[i]; e.Kind == KindMarker && e.Name == name {
+			l.events = append(l.events[:i], l.events[i+1:]...)
+			l.saveLocked()
+			return
+		}
+	}
+}
+
+// saveLocked persists the log if it has a path
+func (l *Log) saveLocked() {
+	if l.path == "" {
+		return
+	}
+	if data, err := json.MarshalIndent(l.events, "", "  "); err == nil {
+		os.WriteFile(l.path, data, 0644)
+	}
+}
+
+// Events returns a copy of the logged events
+func (l *Log) Events() []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Event(nil), l.events...)
+}
+
+// Clear removes all events, e.g. when a new session starts
+func (l *Log) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = nil
+	if l.path != "" {
+		os.Remove(l.path)
+	}
+}
+
+// Export renders events in the named format (edl, fcpxml, otio) and
+// returns the data with its content type
+func Export(format, title string, src Source, events []Event) ([]byte, string, error) {
+	if src.Rate <= 0 {
+		src.Rate = 30
+	}
+	switch format {
+	case "edl":
+		return EDL(title, src, events), "text/plain", nil
+	case "fcpxml":
+		data, err := FCPXML(title, src, events)
+		return data, "application/xml", err
+	case "otio":
+		data, err := OTIO(title, src, events)
+		return data, "application/json", err
+	default:
+		return nil, "", fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// ranges returns the events that cover a time range, in start order
+func ranges(events []Event) []Event {
+	var out []Event
+	for _, e := range events {
+		if e.IsRange() {
+			out = append(out, e)
+		}
+	}
+	sortByStart(out)
+	return out
+}
+
+// markers returns the point events, in start order
+func markers(events []Event) []Event {
+	var out []Event
+	for _, e := range events {
+		if e.Kind == KindMarker {
+			out = append(out, e)
+		}
+	}
+	sortByStart(out)
+	return out
+}
+
+func sortByStart(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+}
+
+// todFrames converts a wall-clock time to time-of-day frames
+func todFrames(t time.Time, rate int) int64 {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return int64(t.Sub(midnight).Seconds()*float64(rate) + 0.5)
+}
+
+// durationFrames converts a duration to frames, at least one
+func durationFrames(d time.Duration, rate int) int64 {
+	return max(int64(d.Seconds()*float64(rate)+0.5), 1)
+}
+
+// timecode formats frames as non-drop-frame HH:MM:SS:FF
+func timecode(frames int64, rate int) string {
+	r := int64(rate)
+	return fmt.Sprintf("%02d:%02d:%02d:%02d",
+		frames/(r*3600)%24, frames/(r*60)%60, frames/r%60, frames%r)
+}
@@ -0,0 +1,75 @@
+package timeline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEvents() (Source, []Event) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	src := Source{Name: "cam-1", URL: "file:///rec/cam1.mov", Start: day.Add(19 * time.Hour), Rate: 30}
+	return src, []Event{
+		{Kind: KindClip, Name: "play2", Start: day.Add(19*time.Hour + 10*time.Minute), End: day.Add(19*time.Hour + 10*time.Minute + 4*time.Second)},
+		{Kind: KindMarker, Name: "play1", Start: day.Add(19*time.Hour + 5*time.Minute + time.Second)},
+		{Kind: KindGhost, Name: "play1", Start: day.Add(19*time.Hour + 5*time.Minute), End: day.Add(19*time.Hour + 5*time.Minute + 6*time.Second)},
+	}
+}
+
+func TestEDL(t *testing.T) {
+	src, events := testEvents()
+	edl := string(EDL("Game", src, events))
+
+	for _, want := range []string{
+		"001  CAM1     V     C        19:05:00:00 19:05:06:00 01:00:00:00 01:00:06:00\n",
+		"* LOC: 01:00:01:00 RED     play1\n",
+		"002  CAM1     V     C        19:10:00:00 19:10:04:00 01:00:06:00 01:00:10:00\n",
+	} {
+		if !strings.Contains(edl, want) {
+			t.Errorf("EDL missing %q:\n%s", want, edl)
+		}
+	}
+}
+
+func TestOTIO(t *testing.T) {
+	src, events := testEvents()
+	data, err := OTIO("Game", src, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc otioItem
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	track := doc.Tracks.Children[0]
+	if len(track.Children) != 2 || track.Children[0].Name != "play1" {
+		t.Fatalf("unexpected clips: %+v", track.Children)
+	}
+	if got := track.Children[0].SourceRange.Duration.Value; got != 180 {
+		t.Errorf("clip duration = %v frames, want 180", got)
+	}
+	if len(doc.Tracks.Markers) != 1 || doc.Tracks.Markers[0].MarkedRange.StartTime.Value != 30 {
+		t.Errorf("unexpected markers: %+v", doc.Tracks.Markers)
+	}
+}
+
+func TestRemoveMarker(t *testing.T) {
+	_, events := testEvents()
+	l, _ := NewLog("")
+	for _, e := range events {
+		l.Add(e)
+	}
+	l.RemoveMarker("play1")
+
+	got := l.Events()
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	for _, e := range got {
+		if e.Kind == KindMarker {
+			t.Errorf("marker %q not removed", e.Name)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Package uploads retries failed clip uploads from a queue kept on disk, so
+// clips reach the platform after outages and agent restarts.
+package uploads
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/video-system/go-video-capture/internal/logging"
+	"github.com/video-system/go-video-capture/internal/recovery"
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+var logger = logging.For("uploads")
+
+// Status is where a queued upload stands
+type Status string
+
+const (
+	Pending Status = "pending" // Waiting for its next attempt
+	Failed  Status = "failed"  // Out of attempts or the file is gone
+)
+
+// Upload is a clip file waiting to be uploaded
+type Upload struct {
+	ID          string                `json:"id"`
+	FilePath    string                `json:"file_path"`
+	Metadata    platform.ClipMetadata `json:"metadata"`
+	Status      Status                `json:"status"`
+	Attempts    int                   `json:"attempts"`
+	LastError   string                `json:"last_error,omitempty"`
+	NextAttempt time.Time             `json:"next_attempt,omitzero"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// Func uploads a file; it is called from a single goroutine
+type Func func(ctx context.Context, filePath string, metadata platform.ClipMetadata) error
+
+// Config sets where the queue is stored and how it backs off
+type Config struct {
+	Path           string        // JSON file the queue is kept in
+	MaxAttempts    int           // Attempts before an upload is marked failed (default 10)
+	InitialBackoff time.Duration // Delay before the first retry, doubled each time (default 30s)
+	MaxBackoff     time.Duration // Longest delay between attempts (default 30m)
+	Timeout        time.Duration // Per attempt (default 5m)
+}
+
+// Queue retries uploads in the background, oldest due first
+type Queue struct {
+	cfg    Config
+	upload Func
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	uploads map[string]*Upload
+}
+
+// New loads the queue from cfg.Path and starts retrying
+func New(cfg Config, upload Func) (*Queue, error) {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+
+	q := &Queue{
+		cfg:     cfg,
+		upload:  upload,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		uploads: make(map[string]*Upload),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	recovery.Go("upload retries", q.run)
+	return q, nil
+}
+
+// Close stops retrying; queued uploads stay on disk for the next start
+func (q *Queue) Close() {
+	q.cancel()
+	<-q.done
+}
+
+// Add queues a file whose upload just failed with err
+func (q *Queue) Add(filePath string, metadata platform.ClipMetadata, err error) Upload {
+	u := &Upload{
+		ID:        newID(),
+		FilePath:  filePath,
+		Metadata:  metadata,
+		Status:    Pending,
+		Attempts:  1,
+		CreatedAt: time.Now(),
+	}
+	q.mu.Lock()
+	q.uploads[u.ID] = u
+	q.failedLocked(u, err)
+	q.saveLocked()
+	snapshot := *u
+	q.mu.Unlock()
+
+	q.poke()
+	return snapshot
+}
+
+// List returns queued uploads, oldest first
+func (q *Queue) List() []Upload {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]Upload, 0, len(q.uploads))
+	for _, u := range q.uploads {
+		list = append(list, *u)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+func (q *Queue) poke() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run attempts due uploads one at a time and sleeps until the next is due
+func (q *Queue) run() {
+	defer close(q.done)
+	for {
+		u, wait := q.next()
+		if u != nil {
+			q.attempt(*u)
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-q.wake:
+			timer.Stop()
+		case <-q.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// next returns the most overdue pending upload, or how long until one is due
+func (q *Queue) next() (*Upload, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var due *Upload
+	wait := time.Hour
+	now := time.Now()
+	for _, u := range q.uploads {
+		if u.Status != Pending {
+			continue
+		}
+		if d := u.NextAttempt.Sub(now); d > 0 {
+			wait = min(wait, d)
+			continue
+		}
+		if due == nil || u.NextAttempt.Before(due.NextAttempt) {
+			due = u
+		}
+	}
+	if due != nil {
+		snapshot := *due
+		return &snapshot, 0
+	}
+	return nil, wait
+}
+
+// attempt uploads u and records the outcome
+func (q *Queue) attempt(u Upload) {
+	var err error
+	if _, statErr := os.Stat(u.FilePath); statErr != nil {
+		err = fmt.Errorf("clip file gone: %w", statErr)
+	} else {
+		ctx, cancel := context.WithTimeout(q.ctx, q.cfg.Timeout)
+		err = q.upload(ctx, u.FilePath, u.Metadata)
+		cancel()
+		if q.ctx.Err() != nil {
+			return // Shutting down; try again after restart
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cur := q.uploads[u.ID]
+	if err == nil {
+		delete(q.uploads, u.ID)
+		logger.Info("Queued upload succeeded", "play_id", u.Metadata.PlayID, "variant", u.Metadata.Variant, "attempts", cur.Attempts+1)
+	} else {
+		cur.Attempts++
+		if errors.Is(err, os.ErrNotExist) {
+			cur.Attempts = q.cfg.MaxAttempts
+		}
+		q.failedLocked(cur, err)
+	}
+	q.saveLocked()
+}
+
+// failedLocked records a failed attempt and schedules the next one
+func (q *Queue) failedLocked(u *Upload, err error) {
+	u.LastError = err.Error()
+	if u.Attempts >= q.cfg.MaxAttempts {
+		u.Status = Failed
+		u.NextAttempt = time.Time{}
+		logger.Warn("Upload failed, giving up", "play_id", u.Metadata.PlayID, "variant", u.Metadata.Variant, "attempts", u.Attempts, "error", err)
+		return
+	}
+	u.NextAttempt = time.Now().Add(q.backoff(u.Attempts))
+	logger.Warn("Upload failed, will retry", "play_id", u.Metadata.PlayID, "variant", u.Metadata.Variant, "attempts", u.Attempts, "retry_at", u.NextAttempt, "error", err)
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.cfg.InitialBackoff
+	for i := 1; i < attempts && d < q.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.cfg.MaxBackoff)
+}
+
+func (q *Queue) load() error {
+	data, err := os.ReadFile(q.cfg.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read upload queue: %w", err)
+	}
+	var list []*Upload
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse upload queue: %w", err)
+	}
+	for _, u := range list {
+		q.uploads[u.ID] = u
+	}
+	return nil
+}
+
+// saveLocked writes the queue; a failed write is logged, the queue keeps
+// working from memory
+func (q *Queue) saveLocked() {
+	list := make([]*Upload, 0, len(q.uploads))
+	for _, u := range q.uploads {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err == nil {
+		tmp := q.cfg.Path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, q.cfg.Path)
+		}
+	}
+	if err != nil {
+		logger.Warn("Failed to save upload queue", "path", q.cfg.Path, "error", err)
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,96 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/video-system/go-video-capture/pkg/platform"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met")
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	dir := t.TempDir()
+	clip := filepath.Join(dir, "clip.mp4")
+	os.WriteFile(clip, []byte("mp4"), 0644)
+
+	var calls atomic.Int32
+	q, err := New(Config{Path: filepath.Join(dir, "uploads.json"), InitialBackoff: time.Millisecond},
+		func(ctx context.Context, path string, meta platform.ClipMetadata) error {
+			if calls.Add(1) < 2 {
+				return errors.New("platform down")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	q.Add(clip, platform.ClipMetadata{PlayID: "p1"}, errors.New("network blip"))
+	waitFor(t, func() bool { return len(q.List()) == 0 })
+	if n := calls.Load(); n != 2 {
+		t.Errorf("upload calls = %d, want 2", n)
+	}
+}
+
+func TestGiveUpAndPersist(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "uploads.json")
+	fail := func(ctx context.Context, path string, meta platform.ClipMetadata) error {
+		return errors.New("platform down")
+	}
+
+	q, err := New(Config{Path: path, MaxAttempts: 3, InitialBackoff: time.Millisecond}, fail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clip := filepath.Join(dir, "clip.mp4")
+	os.WriteFile(clip, []byte("mp4"), 0644)
+	q.Add(clip, platform.ClipMetadata{PlayID: "p1"}, errors.New("network blip"))
+	q.Add(filepath.Join(dir, "missing.mp4"), platform.ClipMetadata{PlayID: "p2"}, errors.New("network blip"))
+	waitFor(t, func() bool {
+		for _, u := range q.List() {
+			if u.Status != Failed {
+				return false
+			}
+		}
+		return true
+	})
+	q.Close()
+
+	// Failed uploads survive a restart
+	q, err = New(Config{Path: path}, fail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	list := q.List()
+	if len(list) != 2 || list[0].Metadata.PlayID != "p1" || list[0].Attempts != 3 || list[0].LastError != "platform down" {
+		t.Errorf("list = %+v", list)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	q := &Queue{cfg: Config{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}}
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := q.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}